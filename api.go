@@ -7,4 +7,120 @@ const (
 	CrFieldPathAnnotation  = Domain + "/CrReadyWhenFieldPath"
 	CrFieldValueAnnotation = Domain + "/CrReadyWhenFieldValue"
 	CrdSupportEnabled      = Domain + "/SupportEnabled"
+	// CrReadyWhenTestsAnnotation is applied to a CRD to declare test cases for its readiness rule: a JSON list of
+	// sample objects with expected verdicts.
+	// See docs/design/managing-resources.md
+	CrReadyWhenTestsAnnotation = Domain + "/CrReadyWhenTests"
+
+	// SyncOnlyResourceAnnotation is applied to a Bundle to restrict processing to a single named resource.
+	// See docs/design/managing-resources.md
+	SyncOnlyResourceAnnotation = Domain + "/SyncOnlyResource"
+
+	// ReadyWhenAnnotation is applied to an object to declare when it is ready with an expression
+	// like `$.status.phase == "Bound"`.
+	// See docs/design/managing-resources.md
+	ReadyWhenAnnotation = Domain + "/readyWhen"
+
+	// SyncMutexAnnotation names a mutex. Bundles with the same mutex are never processed concurrently.
+	// See docs/design/managing-resources.md
+	SyncMutexAnnotation = Domain + "/SyncMutex"
+
+	// BundleClassLabel is set on Bundles created from a BundleClass to the name of the BundleClass.
+	// See docs/design/bundle-class.md
+	BundleClassLabel = Domain + "/BundleClass"
+	// BundleClassValueAnnotationPrefix is a prefix of Namespace annotations that provide values for BundleClass templates.
+	// See docs/design/bundle-class.md
+	BundleClassValueAnnotationPrefix = Domain + "/value."
+
+	// ProductionLabel with value "true" marks a Bundle as a production one.
+	// See docs/design/managing-resources.md
+	ProductionLabel = Domain + "/Production"
+	// DeletionConfirmedAnnotation with value "true" confirms deletion of a production Bundle.
+	// See docs/design/managing-resources.md
+	DeletionConfirmedAnnotation = Domain + "/DeletionConfirmed"
+	// AuthoredVersionAnnotation is set on a Bundle to the API version its spec was last written via, e.g.
+	// "smith.atlassian.com/v2alpha1". It is informational and does not change how the Bundle is processed.
+	// See docs/design/api-versions.md
+	AuthoredVersionAnnotation = Domain + "/AuthoredVersion"
+
+	// BundleNameLabel is set on objects managed by a Bundle to the name of the Bundle, see LabelValue for names that
+	// are too long for a label value.
+	// See docs/design/managing-resources.md
+	BundleNameLabel = Domain + "/BundleName"
+	// BundleNameAnnotation is set on objects in other namespaces than the namespace of their Bundle to the full name
+	// of the Bundle because the BundleNameLabel may not have it.
+	// See docs/design/managing-resources.md
+	BundleNameAnnotation = Domain + "/BundleName"
+	// BundleNamespaceLabel is set on objects in other namespaces than the namespace of their Bundle to the namespace
+	// of the Bundle.
+	// See docs/design/managing-resources.md
+	BundleNamespaceLabel = Domain + "/BundleNamespace"
+	// BundleUIDLabel is set on objects in other namespaces than the namespace of their Bundle to the UID of the Bundle.
+	// Such objects cannot have an owner reference to the Bundle, the label is used to track them instead.
+	// See docs/design/managing-resources.md
+	BundleUIDLabel = Domain + "/BundleUID"
+
+	// DryRunAnnotation with value "true" makes the controller compute changes to objects of a Bundle and record them
+	// in the Bundle status instead of making them.
+	// See docs/design/managing-resources.md
+	DryRunAnnotation = Domain + "/DryRun"
+
+	// ZoneAffinityAnnotation is applied to a Bundle or a Namespace to assign Bundles to controllers running in
+	// the named zone.
+	// See docs/design/managing-resources.md
+	ZoneAffinityAnnotation = Domain + "/ZoneAffinity"
+
+	// RequeueAnnotation is applied to a Bundle that is waiting to be retried after a retriable error. Setting it to
+	// a new value, e.g. the current time, makes the controller process the Bundle straight away and resets its backoff.
+	// See docs/design/managing-resources.md
+	RequeueAnnotation = Domain + "/Requeue"
+
+	// LastAppliedAnnotation is set on objects of resources with the "merge" update strategy to the spec that was
+	// applied last time.
+	// See docs/design/managing-resources.md
+	LastAppliedAnnotation = Domain + "/last-applied-configuration"
+
+	// ManagedFieldsAnnotation is set on objects in field ownership mode to hashes of the values of the fields that
+	// were set from the spec last time.
+	// See docs/design/managing-resources.md
+	ManagedFieldsAnnotation = Domain + "/managed-fields"
+
+	// ResourceAnnotation is set on objects of resources named from metadata.generateName to the name of the resource,
+	// so that the object can be found before its generated name is recorded in the Bundle status.
+	// See docs/design/managing-resources.md
+	ResourceAnnotation = Domain + "/Resource"
+
+	// DeletionPolicyAnnotation is set on objects of resources with a deletion policy to the policy, so that it is
+	// known when the resource has been removed from the Bundle.
+	// See docs/design/managing-resources.md
+	DeletionPolicyAnnotation = Domain + "/DeletionPolicy"
+
+	// PrunePausedAnnotation with value "true" stops deletion of objects removed from a Bundle until it is removed.
+	// See docs/design/managing-resources.md
+	PrunePausedAnnotation = Domain + "/PrunePaused"
+
+	// PausedAnnotation with value "true" stops processing of a Bundle until it is removed. Deletion of the Bundle
+	// is not paused.
+	// See docs/design/managing-resources.md
+	PausedAnnotation = Domain + "/paused"
+
+	// StrictFieldValidationAnnotation with value "true" makes the controller create and update objects of a Bundle
+	// with strict server-side field validation, so that objects with unknown or duplicate fields are rejected
+	// instead of having the fields dropped. Only has effect if the controller runs with -bundle-strict-field-validation.
+	// See docs/design/managing-resources.md
+	StrictFieldValidationAnnotation = Domain + "/StrictFieldValidation"
+
+	// ResyncPeriodAnnotation is applied to a Bundle to override how often it is processed again without events,
+	// e.g. "5m". "0" disables periodic re-sync of the Bundle.
+	// See docs/design/managing-resources.md
+	ResyncPeriodAnnotation = Domain + "/ResyncPeriod"
+
+	// RollbackAnnotation with value "true" makes the controller replace the spec of a Bundle that is in a terminal
+	// error with the last spec that made it ready. A revision number from the revision history may be specified
+	// instead of "true". The annotation is removed once the spec is replaced.
+	// See docs/design/managing-resources.md
+	RollbackAnnotation = Domain + "/Rollback"
+	// RevisionOfLabel is set on BundleRevisions to the name of their Bundle.
+	// See docs/design/managing-resources.md
+	RevisionOfLabel = Domain + "/RevisionOf"
 )
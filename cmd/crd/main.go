@@ -9,6 +9,7 @@ import (
 	"github.com/atlassian/smith/pkg/resources"
 	"github.com/ghodss/yaml"
 	"github.com/pkg/errors"
+	apiext_v1b1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 )
 
 func main() {
@@ -19,29 +20,74 @@ func main() {
 }
 
 func innerMain() error {
-	printBundle := flag.String("print-bundle", "yaml", "Print Bundle CRD and exit (specify format: json or yaml)")
+	printBundle := flag.String("print-bundle", "yaml", "Print CRD and exit (specify format: json or yaml)")
+	crdName := flag.String("crd", "bundle", "CRD to print (specify bundle, bundleclass or bundlerevision)")
+	printSchema := flag.Bool("print-bundle-schema", false, "Print JSON schema of Bundle manifests for editors instead of the CRD and exit")
 	flag.Parse()
 
+	if *printSchema {
+		schema, err := resources.BundleJSONSchema()
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(schema)
+		return errors.Wrap(err, "failed to write Bundle schema to stdout")
+	}
+
+	var crd *apiext_v1b1.CustomResourceDefinition
+	var printerColumns []resources.PrinterColumn
+	switch *crdName {
+	case "bundle":
+		crd = resources.BundleCrd()
+		printerColumns = resources.BundlePrinterColumns()
+	case "bundleclass":
+		crd = resources.BundleClassCrd()
+	case "bundlerevision":
+		crd = resources.BundleRevisionCrd()
+	default:
+		return errors.Errorf("unsupported CRD %q", *crdName)
+	}
+	obj, err := withPrinterColumns(crd, printerColumns)
+	if err != nil {
+		return err
+	}
+
 	switch *printBundle {
 	case "json":
 		enc := json.NewEncoder(os.Stdout)
 		enc.SetIndent("", "  ")
 		enc.SetEscapeHTML(false)
-		err := enc.Encode(resources.BundleCrd())
+		err := enc.Encode(obj)
 		if err != nil {
-			return errors.Wrap(err, "failed to marshal Bundle CRD into JSON")
+			return errors.Wrap(err, "failed to marshal CRD into JSON")
 		}
 	case "yaml":
-		data, err := yaml.Marshal(resources.BundleCrd())
+		data, err := yaml.Marshal(obj)
 		if err != nil {
-			return errors.Wrap(err, "failed to marshal Bundle CRD into YAML")
+			return errors.Wrap(err, "failed to marshal CRD into YAML")
 		}
 		_, err = os.Stdout.Write(data)
 		if err != nil {
-			return errors.Wrap(err, "failed to write Bundle CRD YAML to stdout")
+			return errors.Wrap(err, "failed to write CRD YAML to stdout")
 		}
 	default:
-		return errors.Errorf("unsupported Bundle CRD output format %q", *printBundle)
+		return errors.Errorf("unsupported CRD output format %q", *printBundle)
 	}
 	return nil
 }
+
+// withPrinterColumns converts the CRD into a map and adds the printer columns to its spec.
+func withPrinterColumns(crd *apiext_v1b1.CustomResourceDefinition, printerColumns []resources.PrinterColumn) (map[string]interface{}, error) {
+	data, err := json.Marshal(crd)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal CRD into JSON")
+	}
+	var obj map[string]interface{}
+	if err = json.Unmarshal(data, &obj); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal CRD from JSON")
+	}
+	if len(printerColumns) > 0 {
+		obj["spec"].(map[string]interface{})["additionalPrinterColumns"] = printerColumns
+	}
+	return obj, nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/atlassian/smith/pkg/plugin"
+	"github.com/pkg/errors"
+)
+
+func main() {
+	if err := innerMain(); err != nil {
+		fmt.Fprintf(os.Stderr, "%#v", err)
+		os.Exit(1)
+	}
+}
+
+func innerMain() error {
+	output := flag.String("print", "typescript", "What to print (specify invocation-schema, result-schema or typescript)")
+	flag.Parse()
+
+	switch *output {
+	case "invocation-schema":
+		_, err := fmt.Fprintln(os.Stdout, plugin.InvocationSchema)
+		return errors.WithStack(err)
+	case "result-schema":
+		_, err := fmt.Fprintln(os.Stdout, plugin.ResultSchema)
+		return errors.WithStack(err)
+	case "typescript":
+		return typeScript(os.Stdout)
+	default:
+		return errors.Errorf("unsupported output %q", *output)
+	}
+}
+
+// typeScript prints TypeScript declarations of the plugin contract types.
+func typeScript(w io.Writer) error {
+	var sb bytes.Buffer
+	sb.WriteString("// Code generated by plugin-contract. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&sb, "export const contractVersion = %q;\n", plugin.ContractVersion)
+	for _, t := range []reflect.Type{
+		reflect.TypeOf(plugin.Invocation{}),
+		reflect.TypeOf(plugin.DependencySnapshot{}),
+		reflect.TypeOf(plugin.Result{}),
+	} {
+		fmt.Fprintf(&sb, "\nexport interface %s {\n", t.Name())
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			tag := strings.Split(field.Tag.Get("json"), ",")
+			optional := ""
+			if len(tag) > 1 && tag[1] == "omitempty" {
+				optional = "?"
+			}
+			fmt.Fprintf(&sb, "  %s%s: %s;\n", tag[0], optional, tsType(field.Type))
+		}
+		sb.WriteString("}\n")
+	}
+	_, err := io.WriteString(w, sb.String())
+	return errors.WithStack(err)
+}
+
+func tsType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Interface:
+		return "any"
+	case reflect.Slice:
+		return tsType(t.Elem()) + "[]"
+	case reflect.Map:
+		return "{ [key: string]: " + tsType(t.Elem()) + " }"
+	case reflect.Struct:
+		return t.Name()
+	default:
+		panic(errors.Errorf("unsupported type %s", t))
+	}
+}
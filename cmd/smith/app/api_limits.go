@@ -0,0 +1,166 @@
+package app
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atlassian/smith/pkg/client/smart"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// apiLimiterAll is the limiter label of requests that are not limited per kind.
+const apiLimiterAll = "all"
+
+// apiLimit is a client-side rate limit of requests to the API server.
+type apiLimit struct {
+	qps   float32
+	burst int
+}
+
+// apiLimits holds client configurations with client-side rate limiters. All clients created from a configuration
+// share its limiter. Requests for objects of kinds with their own limit are only subject to that limit so that
+// a busy kind does not starve the others.
+type apiLimits struct {
+	restConfig  *rest.Config
+	kindConfigs map[schema.GroupKind]*rest.Config
+	waits       *prometheus.HistogramVec
+}
+
+// newAPILimits returns limits derived from the configuration. Non-positive qps and burst keep the limit
+// of the configuration, or the client defaults if it has none.
+func newAPILimits(restConfig *rest.Config, qps float64, burst int, kindLimits map[schema.GroupKind]apiLimit) *apiLimits {
+	limits := &apiLimits{
+		kindConfigs: make(map[schema.GroupKind]*rest.Config, len(kindLimits)),
+		waits: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "smith",
+			Subsystem: "api_client",
+			Name:      "rate_limiter_wait_seconds",
+			Help:      "Time requests to the API server waited for the client-side rate limiter, by limiter: all or Kind.group",
+			Buckets:   []float64{0.001, 0.01, 0.05, 0.1, 0.5, 1, 5, 30},
+		}, []string{"limiter"}),
+	}
+	global := apiLimit{
+		qps:   restConfig.QPS,
+		burst: restConfig.Burst,
+	}
+	if qps > 0 {
+		global.qps = float32(qps)
+	}
+	if burst > 0 {
+		global.burst = burst
+	}
+	if global.qps <= 0 {
+		global.qps = rest.DefaultQPS
+	}
+	if global.burst <= 0 {
+		global.burst = rest.DefaultBurst
+	}
+	limits.restConfig = limits.limited(restConfig, apiLimiterAll, global)
+	for gk, limit := range kindLimits {
+		limits.kindConfigs[gk] = limits.limited(restConfig, gk.String(), limit)
+	}
+	return limits
+}
+
+// RegisterMetrics registers metrics of the limiters with the registerer.
+func (l *apiLimits) RegisterMetrics(registerer prometheus.Registerer) error {
+	return errors.WithStack(registerer.Register(l.waits))
+}
+
+// clientPool returns a pool of dynamic clients that uses clients with the limits of the kind for kinds with their own
+// limits.
+func (l *apiLimits) clientPool(mapper meta.RESTMapper) smart.ClientPool {
+	pool := dynamic.NewClientPool(l.restConfig, mapper, dynamic.LegacyAPIPathResolverFunc)
+	if len(l.kindConfigs) == 0 {
+		return pool
+	}
+	kinds := make(map[schema.GroupKind]smart.ClientPool, len(l.kindConfigs))
+	for gk, cfg := range l.kindConfigs {
+		kinds[gk] = dynamic.NewClientPool(cfg, mapper, dynamic.LegacyAPIPathResolverFunc)
+	}
+	return kindClientPool{
+		ClientPool: pool,
+		kinds:      kinds,
+	}
+}
+
+func (l *apiLimits) limited(restConfig *rest.Config, limiter string, limit apiLimit) *rest.Config {
+	cfg := rest.CopyConfig(restConfig)
+	cfg.QPS = limit.qps
+	cfg.Burst = limit.burst
+	cfg.RateLimiter = observedRateLimiter{
+		RateLimiter: flowcontrol.NewTokenBucketRateLimiter(limit.qps, limit.burst),
+		wait:        l.waits.WithLabelValues(limiter),
+	}
+	return cfg
+}
+
+// kindClientPool returns clients of the pool of the kind, clients of the embedded pool for other kinds.
+type kindClientPool struct {
+	smart.ClientPool
+	kinds map[schema.GroupKind]smart.ClientPool
+}
+
+func (p kindClientPool) ClientForGroupVersionKind(gvk schema.GroupVersionKind) (dynamic.Interface, error) {
+	if pool, ok := p.kinds[gvk.GroupKind()]; ok {
+		return pool.ClientForGroupVersionKind(gvk)
+	}
+	return p.ClientPool.ClientForGroupVersionKind(gvk)
+}
+
+// observedRateLimiter records how long callers waited for the rate limiter.
+type observedRateLimiter struct {
+	flowcontrol.RateLimiter
+	wait prometheus.Observer
+}
+
+func (l observedRateLimiter) Accept() {
+	start := time.Now()
+	l.RateLimiter.Accept()
+	l.wait.Observe(time.Since(start).Seconds())
+}
+
+// parseAPIKindLimits parses a comma separated list of Kind.group=qps:burst pairs.
+// The group is omitted for kinds of the core group, e.g. ConfigMap=20:40. Burst defaults to the QPS rounded up.
+func parseAPIKindLimits(list string) (map[schema.GroupKind]apiLimit, error) {
+	result := make(map[schema.GroupKind]apiLimit)
+	for _, pair := range splitNonEmpty(list) {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, errors.Errorf("invalid API limit %q, expected Kind.group=qps:burst", pair)
+		}
+		rate := strings.SplitN(parts[1], ":", 2)
+		qps, err := strconv.ParseFloat(rate[0], 32)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid API limit %q", pair)
+		}
+		if qps <= 0 {
+			return nil, errors.Errorf("invalid API limit %q, QPS must be positive", pair)
+		}
+		burst := int(qps)
+		if float64(burst) < qps {
+			burst++
+		}
+		if len(rate) == 2 {
+			burst, err = strconv.Atoi(rate[1])
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid API limit %q", pair)
+			}
+			if burst <= 0 {
+				return nil, errors.Errorf("invalid API limit %q, burst must be positive", pair)
+			}
+		}
+		result[schema.ParseGroupKind(parts[0])] = apiLimit{
+			qps:   float32(qps),
+			burst: burst,
+		}
+	}
+	return result, nil
+}
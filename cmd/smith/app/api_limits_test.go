@@ -0,0 +1,172 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/atlassian/smith/pkg/client/smart"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+)
+
+var (
+	configMapGK  = schema.GroupKind{Kind: "ConfigMap"}
+	deploymentGK = schema.GroupKind{Group: "apps", Kind: "Deployment"}
+	secretGK     = schema.GroupKind{Kind: "Secret"}
+)
+
+// fakeClientPool records kinds clients are requested for.
+type fakeClientPool struct {
+	requested []schema.GroupVersionKind
+}
+
+func (p *fakeClientPool) ClientForGroupVersionKind(gvk schema.GroupVersionKind) (dynamic.Interface, error) {
+	p.requested = append(p.requested, gvk)
+	return nil, nil
+}
+
+func TestParseAPIKindLimits(t *testing.T) {
+	t.Parallel()
+	limits, err := parseAPIKindLimits(" ConfigMap=20:40, Deployment.apps=2.5,,Secret=5 ")
+	require.NoError(t, err)
+	assert.Equal(t, map[schema.GroupKind]apiLimit{
+		configMapGK:  {qps: 20, burst: 40},
+		deploymentGK: {qps: 2.5, burst: 3},
+		secretGK:     {qps: 5, burst: 5},
+	}, limits)
+
+	limits, err = parseAPIKindLimits("")
+	require.NoError(t, err)
+	assert.Empty(t, limits)
+}
+
+func TestParseAPIKindLimitsInvalid(t *testing.T) {
+	t.Parallel()
+	testcases := map[string]struct {
+		list        string
+		expectedErr string
+	}{
+		"no rate": {
+			list:        "ConfigMap",
+			expectedErr: `invalid API limit "ConfigMap", expected Kind.group=qps:burst`,
+		},
+		"no kind": {
+			list:        "=20",
+			expectedErr: `invalid API limit "=20", expected Kind.group=qps:burst`,
+		},
+		"invalid qps": {
+			list:        "ConfigMap=x",
+			expectedErr: `invalid API limit "ConfigMap=x": strconv.ParseFloat: parsing "x": invalid syntax`,
+		},
+		"zero qps": {
+			list:        "ConfigMap=0",
+			expectedErr: `invalid API limit "ConfigMap=0", QPS must be positive`,
+		},
+		"invalid burst": {
+			list:        "ConfigMap=20:y",
+			expectedErr: `invalid API limit "ConfigMap=20:y": strconv.Atoi: parsing "y": invalid syntax`,
+		},
+		"negative burst": {
+			list:        "Secret=5,ConfigMap=20:-1",
+			expectedErr: `invalid API limit "ConfigMap=20:-1", burst must be positive`,
+		},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			_, err := parseAPIKindLimits(tc.list)
+			assert.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestNewAPILimits(t *testing.T) {
+	t.Parallel()
+	testcases := map[string]struct {
+		restConfig    *rest.Config
+		qps           float64
+		burst         int
+		expectedQPS   float32
+		expectedBurst int
+	}{
+		"client defaults": {
+			restConfig:    &rest.Config{},
+			expectedQPS:   rest.DefaultQPS,
+			expectedBurst: rest.DefaultBurst,
+		},
+		"limit of the configuration": {
+			restConfig:    &rest.Config{QPS: 50, Burst: 100},
+			expectedQPS:   50,
+			expectedBurst: 100,
+		},
+		"flags override the configuration": {
+			restConfig:    &rest.Config{QPS: 50, Burst: 100},
+			qps:           25,
+			burst:         30,
+			expectedQPS:   25,
+			expectedBurst: 30,
+		},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			limits := newAPILimits(tc.restConfig, tc.qps, tc.burst, map[schema.GroupKind]apiLimit{
+				configMapGK: {qps: 1, burst: 2},
+			})
+			assert.Equal(t, tc.expectedQPS, limits.restConfig.QPS)
+			assert.Equal(t, tc.expectedBurst, limits.restConfig.Burst)
+			assert.IsType(t, observedRateLimiter{}, limits.restConfig.RateLimiter)
+
+			require.Contains(t, limits.kindConfigs, configMapGK)
+			kindConfig := limits.kindConfigs[configMapGK]
+			assert.Equal(t, float32(1), kindConfig.QPS)
+			assert.Equal(t, 2, kindConfig.Burst)
+			assert.IsType(t, observedRateLimiter{}, kindConfig.RateLimiter)
+			// Configuration the limits are derived from is not modified
+			assert.Nil(t, tc.restConfig.RateLimiter)
+		})
+	}
+}
+
+func TestKindClientPool(t *testing.T) {
+	t.Parallel()
+	all := &fakeClientPool{}
+	configMaps := &fakeClientPool{}
+	pool := kindClientPool{
+		ClientPool: all,
+		kinds: map[schema.GroupKind]smart.ClientPool{
+			configMapGK: configMaps,
+		},
+	}
+	cmGVK := configMapGK.WithVersion("v1")
+	deploymentGVK := deploymentGK.WithVersion("v1")
+	appsConfigMapGVK := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "ConfigMap"}
+
+	for _, gvk := range []schema.GroupVersionKind{cmGVK, deploymentGVK, appsConfigMapGVK} {
+		_, err := pool.ClientForGroupVersionKind(gvk)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []schema.GroupVersionKind{cmGVK}, configMaps.requested)
+	assert.Equal(t, []schema.GroupVersionKind{deploymentGVK, appsConfigMapGVK}, all.requested)
+}
+
+func TestAPILimitsClientPool(t *testing.T) {
+	t.Parallel()
+	var mapper meta.RESTMapper
+
+	limits := newAPILimits(&rest.Config{}, 0, 0, nil)
+	assert.IsType(t, dynamic.NewClientPool(limits.restConfig, mapper, dynamic.LegacyAPIPathResolverFunc), limits.clientPool(mapper))
+
+	limits = newAPILimits(&rest.Config{}, 0, 0, map[schema.GroupKind]apiLimit{
+		configMapGK: {qps: 1, burst: 1},
+	})
+	pool, ok := limits.clientPool(mapper).(kindClientPool)
+	require.True(t, ok)
+	assert.Len(t, pool.kinds, 1)
+	assert.Contains(t, pool.kinds, configMapGK)
+}
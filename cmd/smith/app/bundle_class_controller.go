@@ -0,0 +1,74 @@
+package app
+
+import (
+	"flag"
+
+	"github.com/atlassian/ctrl"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	smithClientset "github.com/atlassian/smith/pkg/client/clientset_generated/clientset"
+	smith_v1inf "github.com/atlassian/smith/pkg/client/informers_generated/externalversions/smith/v1"
+	smith_v1lst "github.com/atlassian/smith/pkg/client/listers_generated/smith/v1"
+	"github.com/atlassian/smith/pkg/controller/bundleclassc"
+	"k8s.io/client-go/tools/cache"
+)
+
+type BundleClassControllerConstructor struct {
+	// To override things constructed by default. And for tests.
+	SmithClient smithClientset.Interface
+}
+
+func (c *BundleClassControllerConstructor) AddFlags(flagset *flag.FlagSet) {
+}
+
+func (c *BundleClassControllerConstructor) New(config *ctrl.Config, cctx *ctrl.Context) (*ctrl.Constructed, error) {
+	// Clients
+	smithClient := c.SmithClient
+	if smithClient == nil {
+		var err error
+		smithClient, err = smithClientset.NewForConfig(config.RestConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Informers
+	bundleClassInf := cctx.Informers[smith_v1.BundleClassGVK]
+	if bundleClassInf == nil {
+		bundleClassInf = smith_v1inf.NewBundleClassInformer(smithClient, config.ResyncPeriod, cache.Indexers{})
+		if err := cctx.RegisterInformer(smith_v1.BundleClassGVK, bundleClassInf); err != nil {
+			return nil, err
+		}
+	}
+	bundleInf, err := smithInformer(config, cctx, smithClient, []string{config.Namespace}, smith_v1.BundleGVK, smith_v1inf.NewBundleInformer)
+	if err != nil {
+		return nil, err
+	}
+	namespaceInf, err := namespaceInformer(config, cctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Controller
+	cntrlr := &bundleclassc.Controller{
+		Logger:        config.Logger,
+		ReadyForWork:  cctx.ReadyForWork,
+		BundleClient:  smithClient.SmithV1(),
+		WorkQueue:     cctx.WorkQueue,
+		BundleInf:     bundleInf,
+		NamespaceInf:  namespaceInf,
+		BundleClasses: smith_v1lst.NewBundleClassLister(bundleClassInf.GetIndexer()),
+		Bundles:       smith_v1lst.NewBundleLister(bundleInf.GetIndexer()),
+		Namespace:     config.Namespace,
+	}
+	cntrlr.Prepare()
+
+	return &ctrl.Constructed{
+		Interface: cntrlr,
+	}, nil
+}
+
+func (c *BundleClassControllerConstructor) Describe() ctrl.Descriptor {
+	return ctrl.Descriptor{
+		Gvk: smith_v1.BundleClassGVK,
+	}
+}
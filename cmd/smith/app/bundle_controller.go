@@ -2,46 +2,167 @@ package app
 
 import (
 	"flag"
+	"io/ioutil"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/atlassian/ctrl"
+	"github.com/atlassian/smith"
 	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
 	"github.com/atlassian/smith/pkg/cleanup"
 	clean_types "github.com/atlassian/smith/pkg/cleanup/types"
 	"github.com/atlassian/smith/pkg/client"
 	smithClientset "github.com/atlassian/smith/pkg/client/clientset_generated/clientset"
+	smith_v1inf "github.com/atlassian/smith/pkg/client/informers_generated/externalversions/smith/v1"
+	smith_v1lst "github.com/atlassian/smith/pkg/client/listers_generated/smith/v1"
 	"github.com/atlassian/smith/pkg/client/smart"
 	"github.com/atlassian/smith/pkg/controller/bundlec"
+	"github.com/atlassian/smith/pkg/migration"
 	"github.com/atlassian/smith/pkg/plugin"
+	"github.com/atlassian/smith/pkg/plugin/smoke"
 	"github.com/atlassian/smith/pkg/readychecker"
 	ready_types "github.com/atlassian/smith/pkg/readychecker/types"
+	"github.com/atlassian/smith/pkg/resources"
+	"github.com/atlassian/smith/pkg/secretstore"
 	"github.com/atlassian/smith/pkg/speccheck"
 	"github.com/atlassian/smith/pkg/store"
+	"github.com/atlassian/smith/pkg/tracing"
+	"github.com/atlassian/smith/pkg/webhook"
 	sc_v1b1 "github.com/kubernetes-incubator/service-catalog/pkg/apis/servicecatalog/v1beta1"
 	scClientset "github.com/kubernetes-incubator/service-catalog/pkg/client/clientset_generated/clientset"
 	sc_v1b1inf "github.com/kubernetes-incubator/service-catalog/pkg/client/informers_generated/externalversions/servicecatalog/v1beta1"
 	"github.com/pkg/errors"
 	apps_v1 "k8s.io/api/apps/v1"
+	batch_v1 "k8s.io/api/batch/v1"
 	core_v1 "k8s.io/api/core/v1"
 	ext_v1b1 "k8s.io/api/extensions/v1beta1"
 	apiext_v1b1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	apiExtClientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	apiext_v1b1inf "k8s.io/apiextensions-apiserver/pkg/client/informers/externalversions/apiextensions/v1beta1"
+	api_errors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
 	apps_v1inf "k8s.io/client-go/informers/apps/v1"
+	batch_v1inf "k8s.io/client-go/informers/batch/v1"
 	core_v1inf "k8s.io/client-go/informers/core/v1"
 	ext_v1b1inf "k8s.io/client-go/informers/extensions/v1beta1"
 	"k8s.io/client-go/kubernetes"
+	core_v1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	core_v1lst "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 )
 
 type BundleControllerConstructor struct {
-	Plugins               []plugin.NewFunc
-	ServiceCatalogSupport bool
+	Plugins                  []plugin.NewFunc
+	ServiceCatalogSupport    bool
+	ForceRemovableFinalizers string
+	FinalizerRemovalTimeout  time.Duration
+	PruneBatchSize           int
+	PruneBatchInterval       time.Duration
+	InitialReassertQPS       float64
+	BundleResyncPeriod       time.Duration
+	// Require confirmation before deleting production Bundles.
+	RequireDeletionConfirmation bool
+	RepairStaleOwnerReferences  bool
+	StrictOwnership             bool
+	// Delete objects of deleted Bundles in reverse dependency order.
+	OrderedDeletion bool
+	// Resolve references of all resources of a Bundle before applying any of them.
+	StrictReferenceResolution bool
+	// Manage objects of resources in other namespaces than the namespace of their Bundle.
+	CrossNamespaceResources bool
+	TolerateDrift           bool
+	FieldOwnership          bool
+	// Plan changes to objects of all Bundles instead of making them.
+	DryRun bool
+	// Update objects using server-side apply.
+	ServerSideApply bool
+	// Validate changes of objects with server-side dry-run during pre-flight validation.
+	PreflightDryRun bool
+	// Create and update objects of Bundles that opt in with strict server-side field validation.
+	StrictFieldValidation bool
+	// How long failures to find a REST mapping for a kind are cached for.
+	RestMappingNegativeTTL time.Duration
+	// Comma separated list of Kind.group=timeout pairs for create, update and delete requests.
+	WriteTimeouts string
+	// Client-side rate limit of requests to the API server shared by all clients. Zero keeps the limit of the client
+	// configuration.
+	KubeAPIQPS   float64
+	KubeAPIBurst int
+	// Comma separated list of Kind.group=qps:burst pairs. Requests for objects of listed kinds have their own limits.
+	KubeAPIKindLimits string
+	// Address to serve debug endpoints on. Empty disables them.
+	DebugListenOn string
+	// Base URL of an OTLP/HTTP endpoint to export traces of syncs to. Empty disables tracing.
+	TracingOTLPEndpoint string
+	// Address to serve liveness and readiness probes on. Empty disables them.
+	HealthListenOn string
+	// How long a sync may run or pending Bundles may wait for progress before the controller is unhealthy.
+	StuckWorkerTimeout time.Duration
+	// Address to serve admission webhooks on. Empty disables them.
+	WebhookListenOn    string
+	WebhookTLSCertFile string
+	WebhookTLSKeyFile  string
+	// SmokePlugins enables built-in plugins that produce canary objects.
+	SmokePlugins bool
+	// InventoryMetrics enables export of Bundle inventory metrics.
+	InventoryMetrics bool
+	// How often ownership metadata of objects is checked. Zero disables periodic checks.
+	ConsistencyCheckInterval time.Duration
+	// How often objects of deleted Bundles and objects Bundles do not define anymore are audited. Zero disables
+	// periodic audits.
+	OrphanAuditInterval time.Duration
+	// Delete objects of deleted Bundles found by periodic audits.
+	DeleteOrphans bool
+	// Backoff for Bundles that failed with a retriable error.
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+	MaxRetries     int
+	// Rate of retries per namespace. Zero disables the budget.
+	NamespaceRetryQPS   float64
+	NamespaceRetryBurst int
+	// Feature gate for rewriting objects at the versions declared migration rules convert them to.
+	MigrateChildren bool
+	// Path to a file with migration rules.
+	MigrationRules string
+	// External secret stores for references. Empty address or region disables the store.
+	VaultAddress            string
+	VaultTokenFile          string
+	AWSSecretsManagerRegion string
+	// How long secrets without a lease are cached for.
+	SecretStoreCacheTTL time.Duration
+	// Timeout of requests to secret stores.
+	SecretStoreTimeout time.Duration
+	// Zone the controller runs in and comma separated list of all zones controllers run in. Empty zone disables
+	// zone assignment.
+	Zone  string
+	Zones string
+	// Shard owned by the controller and the number of shards Bundles are split into. Zero shards disables sharding.
+	Shard  int
+	Shards int
+	// Number of BundleRevisions kept per Bundle. Zero disables revision history.
+	RevisionHistoryLimit int
+	// How long BundleRevisions are kept for after their specs last made Bundles ready. Zero means they do not expire.
+	RevisionTTL time.Duration
+	// Comma separated list of namespaces to watch. Empty means the namespace of the -namespace flag is watched.
+	WatchNamespaces string
+	// Create or update the Bundle CRD on startup and wait for it to become established before starting informers.
+	EnsureCrd           bool
+	CrdEstablishTimeout time.Duration
+	// Optional per-kind comparison strategies for objects of custom kinds.
+	SpecCheckStrategies *speccheck.Registry
+	// Optional readiness checks. Take precedence over built-in checks for the same kinds.
+	ReadyCheckers map[schema.GroupKind]readychecker.IsObjectReady
+
+	// Optional. Reloads client credentials when the API server responds with 401 Unauthorized.
+	CredentialRefresher *client.CredentialRefresher
 
 	// To override things constructed by default. And for tests.
 	SmithClient  smithClientset.Interface
@@ -52,6 +173,62 @@ type BundleControllerConstructor struct {
 
 func (c *BundleControllerConstructor) AddFlags(flagset *flag.FlagSet) {
 	flagset.BoolVar(&c.ServiceCatalogSupport, "bundle-service-catalog", true, "Service Catalog support in Bundle controller. Enabled by default.")
+	flagset.StringVar(&c.ForceRemovableFinalizers, "bundle-force-removable-finalizers", "", "Comma separated list of finalizers that may be removed from objects being pruned if they block deletion for longer than -bundle-finalizer-removal-timeout")
+	flagset.DurationVar(&c.FinalizerRemovalTimeout, "bundle-finalizer-removal-timeout", 0, "Time after which finalizers listed in -bundle-force-removable-finalizers are removed from objects being pruned. Zero disables removal")
+	flagset.IntVar(&c.PruneBatchSize, "bundle-prune-batch-size", 0, "Maximum number of objects removed from a Bundle that are deleted at once. Zero means all such objects are deleted straight away")
+	flagset.DurationVar(&c.PruneBatchInterval, "bundle-prune-batch-interval", 10*time.Second, "Minimum interval between batches of deletions of objects removed from a Bundle. Only used with -bundle-prune-batch-size")
+	flagset.Float64Var(&c.InitialReassertQPS, "bundle-initial-reassert-qps", 0, "Maximum number of healthy Bundles processed per second after the controller starts. Bundles that are not ready are processed first. Zero disables throttling")
+	flagset.DurationVar(&c.BundleResyncPeriod, "bundle-resync-period", 0, "How often each Bundle is processed again without any events to revert out-of-band changes of its objects. Overridden per Bundle by the smith.atlassian.com/ResyncPeriod annotation. Zero disables periodic re-sync")
+	flagset.BoolVar(&c.TolerateDrift, "bundle-tolerate-drift", false, "Ignore differences between desired and actual objects that do not change their meaning: fields defaulted to zero values and equivalent resource quantities like 1000m and 1")
+	flagset.BoolVar(&c.FieldOwnership, "bundle-field-ownership", false, "Only update fields of objects that are set in the spec, keeping fields set by other controllers like replicas set by an autoscaler. Fields are recorded in the "+smith.ManagedFieldsAnnotation+" annotation and external changes of them are reverted and reported as "+bundlec.EventReasonObjectModifiedExternally+" Events")
+	flagset.BoolVar(&c.RepairStaleOwnerReferences, "bundle-repair-stale-owner-references", false, "Re-parent objects controlled by a previous incarnation of a Bundle (same name, different UID) instead of refusing to manage them")
+	flagset.BoolVar(&c.StrictOwnership, "bundle-strict-ownership", false, "Only manage and prune objects if their "+smith.BundleNameLabel+" label agrees with their controller owner reference. Mismatches are reported as "+bundlec.EventReasonOwnershipMismatch+" Events")
+	flagset.BoolVar(&c.OrderedDeletion, "bundle-ordered-deletion", false, "Delete objects of a deleted Bundle in reverse dependency order, waiting for objects of dependent resources to be gone before deleting their dependencies")
+	flagset.BoolVar(&c.StrictReferenceResolution, "bundle-strict-reference-resolution", false, "Resolve references of all resources of a Bundle before creating or updating any of them, reporting all unresolvable references at once")
+	flagset.BoolVar(&c.CrossNamespaceResources, "bundle-cross-namespace-resources", false, "Allow resources of a Bundle to declare a namespace other than the namespace of the Bundle. Objects in other namespaces are tracked with "+smith.BundleUIDLabel+" labels instead of owner references, the namespaces must be watched")
+	flagset.BoolVar(&c.DryRun, "bundle-dry-run", false, "Compute changes to objects of all Bundles and record them in Bundle status and Events instead of making them. Individual Bundles can be processed in dry-run mode with the "+smith.DryRunAnnotation+"=true annotation")
+	flagset.BoolVar(&c.ServerSideApply, "bundle-server-side-apply", false, "Update objects using server-side apply with the "+bundlec.FieldManager+" field manager instead of full updates. Fields set by other controllers are preserved. Requires Kubernetes 1.16 or later")
+	flagset.BoolVar(&c.PreflightDryRun, "bundle-preflight-dry-run", false, "Submit changes of objects to the API server with server-side dry-run during pre-flight validation. Requires Kubernetes 1.13 or later")
+	flagset.BoolVar(&c.StrictFieldValidation, "bundle-strict-field-validation", false, "Create and update objects of Bundles annotated with "+smith.StrictFieldValidationAnnotation+"=true with strict server-side field validation, rejecting objects with unknown or duplicate fields instead of dropping the fields. Requires Kubernetes 1.25 or later")
+	flagset.DurationVar(&c.RestMappingNegativeTTL, "rest-mapping-negative-ttl", 30*time.Second, "How long failures to find an API for a kind are cached for. Zero disables caching of failures")
+	flagset.StringVar(&c.WriteTimeouts, "bundle-write-timeouts", "", "Comma separated list of Kind.group=timeout pairs, e.g. Deployment.apps=5s,ConfigMap=3s. Create, update and delete requests for objects of listed kinds time out after the given duration instead of the default client timeout")
+	flagset.Float64Var(&c.KubeAPIQPS, "kube-api-qps", 0, "Maximum number of requests per second to the API server, shared by all clients of the controller except for requests for kinds listed in -kube-api-kind-limits. Zero keeps the limit of the client configuration")
+	flagset.IntVar(&c.KubeAPIBurst, "kube-api-burst", 0, "Number of requests to the API server allowed in a burst over -kube-api-qps. Zero keeps the burst of the client configuration")
+	flagset.StringVar(&c.KubeAPIKindLimits, "kube-api-kind-limits", "", "Comma separated list of Kind.group=qps:burst pairs, e.g. ConfigMap=20:40,Deployment.apps=5. Requests for objects of listed kinds, including list and watch requests of their informers, are limited separately instead of by -kube-api-qps")
+	flagset.StringVar(&c.DebugListenOn, "debug-listen-on", "", "Address to serve debug endpoints on, e.g. :9090. Empty disables debug endpoints")
+	flagset.StringVar(&c.TracingOTLPEndpoint, "tracing-otlp-endpoint", "", "Base URL of an OpenTelemetry collector to export traces of Bundle syncs to using OTLP over HTTP, e.g. http://otel-collector:4318. Empty disables tracing")
+	flagset.StringVar(&c.HealthListenOn, "health-listen-on", "", "Address to serve /healthz and /readyz probes on, e.g. :8080. Empty disables the probes")
+	flagset.DurationVar(&c.StuckWorkerTimeout, "health-stuck-worker-timeout", 10*time.Minute, "How long a Bundle sync may run, or Bundles with unobserved changes may wait for any sync to finish, before /healthz reports the controller as stuck. Zero disables the check")
+	flagset.StringVar(&c.WebhookListenOn, "webhook-listen-on", "", "Address to serve the Bundle validation and defaulting admission webhooks on, e.g. :8443. Empty disables the webhooks")
+	flagset.StringVar(&c.WebhookTLSCertFile, "webhook-tls-cert-file", "", "Path to the TLS certificate of the admission webhook server")
+	flagset.StringVar(&c.WebhookTLSKeyFile, "webhook-tls-key-file", "", "Path to the TLS private key of the admission webhook server")
+	flagset.BoolVar(&c.SmokePlugins, "bundle-smoke-plugins", false, "Enable built-in "+smoke.ConfigMapPluginName+" and "+smoke.JobPluginName+" plugins that produce canary objects to validate namespace permissions and admission control")
+	flagset.DurationVar(&c.ConsistencyCheckInterval, "bundle-consistency-check-interval", time.Hour, "How often objects are checked for missing "+smith.BundleNameLabel+" labels, controller references and owner references to deleted dependencies. Bundles of objects with issues are queued for processing to repair them. Zero disables periodic checks")
+	flagset.DurationVar(&c.OrphanAuditInterval, "bundle-orphan-audit-interval", 0, "How often objects with "+smith.BundleNameLabel+" labels are audited for Bundles that do not exist or do not define them anymore. Bundles that do not define their objects anymore are queued for processing to prune them. Zero disables periodic audits")
+	flagset.BoolVar(&c.DeleteOrphans, "bundle-delete-orphans", false, "Delete objects of Bundles that do not exist anymore found by periodic orphan audits. By default they are only reported")
+	flagset.DurationVar(&c.RetryBaseDelay, "bundle-retry-base-delay", time.Second, "Delay before the first retry of a Bundle that failed with a retriable error. The delay doubles with every consecutive failure")
+	flagset.DurationVar(&c.RetryMaxDelay, "bundle-retry-max-delay", 5*time.Minute, "Maximum delay between retries of a Bundle that failed with a retriable error")
+	flagset.IntVar(&c.MaxRetries, "bundle-max-retries", 0, "Number of consecutive retries after which a retriable error of a Bundle is treated as terminal. Zero means there is no limit")
+	flagset.Float64Var(&c.NamespaceRetryQPS, "bundle-namespace-retry-qps", 0, "Maximum number of retries of failed Bundles per second per namespace. Retries over the budget are deferred. Zero disables the budget")
+	flagset.IntVar(&c.NamespaceRetryBurst, "bundle-namespace-retry-burst", 10, "Number of retries of failed Bundles per namespace allowed in a burst over -bundle-namespace-retry-qps")
+	flagset.BoolVar(&c.MigrateChildren, "bundle-migrate-children", false, "Feature gate. Rewrite objects of Bundles at the API versions that rules from -bundle-migration-rules convert them to")
+	flagset.StringVar(&c.MigrationRules, "bundle-migration-rules", "", "Path to a YAML or JSON file with rules to convert objects between API versions. Used with -bundle-migrate-children")
+	flagset.StringVar(&c.VaultAddress, "vault-address", "", "Address of a Vault server, e.g. https://vault:8200. Enables references to secrets in Vault with the "+secretstore.VaultProviderName+": source prefix. Empty disables Vault")
+	flagset.StringVar(&c.VaultTokenFile, "vault-token-file", "", "Path to a file with the Vault token. The "+vaultTokenEnvVar+" environment variable is used if empty")
+	flagset.StringVar(&c.AWSSecretsManagerRegion, "aws-secrets-manager-region", "", "AWS region to read secrets from. Enables references to secrets in AWS Secrets Manager with the "+secretstore.AWSSecretsManagerProviderName+": source prefix. Credentials are read from the standard AWS environment variables. Empty disables AWS Secrets Manager")
+	flagset.DurationVar(&c.SecretStoreCacheTTL, "secret-store-cache-ttl", 5*time.Minute, "How long secrets read from external secret stores are cached for unless the store specifies a lease duration. Zero disables caching of such secrets")
+	flagset.DurationVar(&c.SecretStoreTimeout, "secret-store-timeout", 10*time.Second, "Timeout of requests to external secret stores")
+	flagset.StringVar(&c.Zone, "bundle-zone", "", "Zone the controller runs in. Only Bundles assigned to the zone are processed. Bundles and namespaces are assigned to zones with the "+smith.ZoneAffinityAnnotation+" annotation, other Bundles are spread across -bundle-zones. Empty disables zone assignment")
+	flagset.StringVar(&c.Zones, "bundle-zones", "", "Comma separated list of all zones controllers run in. Used with -bundle-zone")
+	flagset.IntVar(&c.Shard, "bundle-shard", 0, "Shard of Bundles the controller owns, from 0 to -bundle-shards minus one. Used with -bundle-shards")
+	flagset.IntVar(&c.Shards, "bundle-shards", 0, "Number of shards Bundles are split into by a hash of their namespace and name. Each deployment of the controller only processes Bundles of its -bundle-shard. Zero disables sharding")
+	flagset.IntVar(&c.RevisionHistoryLimit, "bundle-revision-history-limit", 0, "Number of BundleRevisions kept per Bundle. A BundleRevision with the spec of a Bundle is created every time a new generation of the spec makes the Bundle ready. Requires the BundleRevision CustomResourceDefinition. Zero disables revision history")
+	flagset.DurationVar(&c.RevisionTTL, "bundle-revision-ttl", 0, "How long BundleRevisions are kept for after their specs last made Bundles ready. The most recent BundleRevision of a Bundle is always kept. Zero means BundleRevisions only get deleted when they are over the history limit")
+	flagset.StringVar(&c.WatchNamespaces, "bundle-watch-namespaces", "", "Comma separated list of namespaces to watch Bundles and their objects in. Objects in other namespaces are neither read nor written, so the controller only needs permissions in these namespaces. Cannot be used with -namespace. Empty means the namespace specified by -namespace or all namespaces are watched")
+	flagset.BoolVar(&c.InventoryMetrics, "bundle-inventory-metrics", false, "Export the number of Bundles by kinds of objects and plugins they use as metrics. Adds a time series per kind and plugin in use")
+	flagset.BoolVar(&c.EnsureCrd, "bundle-ensure-crd", false, "Create or update the Bundle CustomResourceDefinition on startup and wait for it to become established. Requires permissions to create and update CustomResourceDefinitions")
+	flagset.DurationVar(&c.CrdEstablishTimeout, "bundle-crd-establish-timeout", time.Minute, "How long to wait for the Bundle CustomResourceDefinition to be created or updated and become established. Used with -bundle-ensure-crd")
+	flagset.BoolVar(&c.RequireDeletionConfirmation, "bundle-require-deletion-confirmation", false, "Require Bundles labeled "+smith.ProductionLabel+"=true to be annotated with "+smith.DeletionConfirmedAnnotation+"=true before their resources are deleted")
 }
 
 func (c *BundleControllerConstructor) New(config *ctrl.Config, cctx *ctrl.Context) (*ctrl.Constructed, error) {
@@ -63,34 +240,115 @@ func (c *BundleControllerConstructor) New(config *ctrl.Config, cctx *ctrl.Contex
 	for pluginName := range pluginContainers {
 		config.Logger.Sugar().Infof("Loaded plugin: %q", pluginName)
 	}
+	migrations, err := c.loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	secretResolver, err := c.secretResolver()
+	if err != nil {
+		return nil, err
+	}
+	var zones *bundlec.ZoneAssignment
+	if c.Zone != "" {
+		zones, err = bundlec.NewZoneAssignment(c.Zone, splitNonEmpty(c.Zones))
+		if err != nil {
+			return nil, err
+		}
+	} else if c.Zones != "" {
+		return nil, errors.New("-bundle-zone must be specified if -bundle-zones is specified")
+	}
+	var shards *bundlec.ShardAssignment
+	if c.Shards != 0 {
+		shards, err = bundlec.NewShardAssignment(c.Shard, c.Shards)
+		if err != nil {
+			return nil, err
+		}
+	} else if c.Shard != 0 {
+		return nil, errors.New("-bundle-shards must be specified if -bundle-shard is specified")
+	}
+	if c.RevisionHistoryLimit < 0 {
+		return nil, errors.Errorf("-bundle-revision-history-limit must not be negative, got %d", c.RevisionHistoryLimit)
+	}
+	if c.RevisionTTL < 0 {
+		return nil, errors.Errorf("-bundle-revision-ttl must not be negative, got %s", c.RevisionTTL)
+	}
+	watchNamespaces := splitNonEmpty(c.WatchNamespaces)
+	namespaces := watchNamespaces
+	if len(watchNamespaces) == 0 {
+		namespaces = []string{config.Namespace}
+	} else if config.Namespace != "" {
+		return nil, errors.New("-bundle-watch-namespaces cannot be used with -namespace")
+	}
+	if c.FieldOwnership && c.ServerSideApply {
+		return nil, errors.New("-bundle-field-ownership cannot be used with -bundle-server-side-apply, which tracks field ownership on its own")
+	}
 	scheme, err := FullScheme(c.ServiceCatalogSupport)
 	if err != nil {
 		return nil, err
 	}
 
 	// Clients
+	if c.CredentialRefresher != nil {
+		if err = c.CredentialRefresher.RegisterMetrics(config.Registry); err != nil {
+			return nil, err
+		}
+		config.RestConfig = c.CredentialRefresher.WrapConfig(config.RestConfig)
+		config.MainClient, err = kubernetes.NewForConfig(config.RestConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+	kindLimits, err := parseAPIKindLimits(c.KubeAPIKindLimits)
+	if err != nil {
+		return nil, err
+	}
+	limits := newAPILimits(config.RestConfig, c.KubeAPIQPS, c.KubeAPIBurst, kindLimits)
+	if err = limits.RegisterMetrics(config.Registry); err != nil {
+		return nil, err
+	}
+	restConfig := limits.restConfig
+	mainClient := config.MainClient
+	if c.KubeAPIQPS > 0 || c.KubeAPIBurst > 0 {
+		mainClient, err = kubernetes.NewForConfig(restConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
 	smithClient := c.SmithClient
 	if smithClient == nil {
-		smithClient, err = smithClientset.NewForConfig(config.RestConfig)
+		smithClient, err = smithClientset.NewForConfig(restConfig)
 		if err != nil {
 			return nil, err
 		}
 	}
 	scClient := c.ScClient
 	if scClient == nil {
-		scClient, err = scClientset.NewForConfig(config.RestConfig)
+		scClient, err = scClientset.NewForConfig(restConfig)
 		if err != nil {
 			return nil, err
 		}
 	}
 	apiExtClient := c.ApiExtClient
 	if apiExtClient == nil {
-		apiExtClient, err = apiExtClientset.NewForConfig(config.RestConfig)
+		apiExtClient, err = apiExtClientset.NewForConfig(restConfig)
 		if err != nil {
 			return nil, err
 		}
 	}
+	if c.EnsureCrd {
+		crds := []*apiext_v1b1.CustomResourceDefinition{resources.BundleCrd()}
+		if c.RevisionHistoryLimit > 0 {
+			crds = append(crds, resources.BundleRevisionCrd())
+		}
+		if err = ensureCrds(config.Logger, apiExtClient, c.CrdEstablishTimeout, crds...); err != nil {
+			return nil, err
+		}
+	}
 	smartClient := c.SmartClient
+	var applyClient bundlec.ApplyClient
+	var dryRunClient bundlec.DryRunClient
+	var strictClient bundlec.StrictClient
+	debugHandlers := make(map[string]http.Handler)
 	if smartClient == nil {
 		rm := discovery.NewDeferredDiscoveryRESTMapper(
 			&smart.CachedDiscoveryClient{
@@ -98,14 +356,43 @@ func (c *BundleControllerConstructor) New(config *ctrl.Config, cctx *ctrl.Contex
 			},
 			meta.InterfacesForUnstructured,
 		)
+		cachingMapper := smart.NewCachingMapper(rm, c.RestMappingNegativeTTL)
+		if err = cachingMapper.RegisterMetrics(config.Registry); err != nil {
+			return nil, err
+		}
+		debugHandlers["/debug/rest-mappings"] = cachingMapper
+		writeTimeouts, err := parseWriteTimeouts(c.WriteTimeouts)
+		if err != nil {
+			return nil, err
+		}
 		smartClient = &smart.DynamicClient{
-			ClientPool: dynamic.NewClientPool(config.RestConfig, rm, dynamic.LegacyAPIPathResolverFunc),
-			Mapper:     rm,
+			ClientPool:       limits.clientPool(rm),
+			Mapper:           cachingMapper,
+			WriteClientPools: writeClientPools(limits, rm, writeTimeouts),
+			Namespaces:       watchNamespaces,
+		}
+		if c.ServerSideApply {
+			applyClient = &smart.ApplyClient{
+				RestConfig: restConfig,
+				Mapper:     cachingMapper,
+			}
+		}
+		if c.PreflightDryRun {
+			dryRunClient = &smart.DryRunClient{
+				RestConfig: restConfig,
+				Mapper:     cachingMapper,
+			}
+		}
+		if c.StrictFieldValidation {
+			strictClient = &smart.StrictClient{
+				RestConfig: restConfig,
+				Mapper:     cachingMapper,
+			}
 		}
 	}
 
 	// Informers
-	bundleInf, err := smithInformer(config, cctx, smithClient, smith_v1.BundleGVK, client.BundleInformer)
+	bundleInf, err := smithInformer(config, cctx, smithClient, namespaces, smith_v1.BundleGVK, smith_v1inf.NewBundleInformer)
 	if err != nil {
 		return nil, err
 	}
@@ -119,6 +406,21 @@ func (c *BundleControllerConstructor) New(config *ctrl.Config, cctx *ctrl.Contex
 	if err != nil {
 		return nil, err
 	}
+	namespaceInf, err := namespaceInformer(config, cctx)
+	if err != nil {
+		return nil, err
+	}
+	// Informers the controller needs to have synced to be ready
+	syncedInfs := []cache.SharedIndexInformer{bundleInf, crdInf, namespaceInf}
+	var revisionLister smith_v1lst.BundleRevisionLister
+	revisionInf, err := c.revisionInformer(config, cctx, smithClient, apiExtClient, namespaces)
+	if err != nil {
+		return nil, err
+	}
+	if revisionInf != nil {
+		revisionLister = smith_v1lst.NewBundleRevisionLister(revisionInf.GetIndexer())
+		syncedInfs = append(syncedInfs, revisionInf)
+	}
 
 	var catalog *store.Catalog
 	if c.ServiceCatalogSupport {
@@ -138,6 +440,7 @@ func (c *BundleControllerConstructor) New(config *ctrl.Config, cctx *ctrl.Contex
 		if err != nil {
 			return nil, err
 		}
+		syncedInfs = append(syncedInfs, serviceClassInf, servicePlanInf)
 	}
 
 	// Ready Checker
@@ -145,7 +448,12 @@ func (c *BundleControllerConstructor) New(config *ctrl.Config, cctx *ctrl.Contex
 	if c.ServiceCatalogSupport {
 		readyTypes = append(readyTypes, ready_types.ServiceCatalogKnownTypes)
 	}
-	rc := readychecker.New(crdStore, readyTypes...)
+	rc := readychecker.New(crdStore, withoutKinds(readyTypes, c.ReadyCheckers)...)
+	for gk, isObjectReady := range c.ReadyCheckers {
+		if err = rc.Register(gk, isObjectReady); err != nil {
+			return nil, err
+		}
+	}
 
 	// Object cleanup
 	cleanupTypes := []map[schema.GroupKind]cleanup.SpecCleanup{clean_types.MainKnownTypes}
@@ -155,9 +463,22 @@ func (c *BundleControllerConstructor) New(config *ctrl.Config, cctx *ctrl.Contex
 	oc := cleanup.New(cleanupTypes...)
 
 	// Spec check
+	strategies := c.SpecCheckStrategies
+	if c.TolerateDrift {
+		if strategies == nil {
+			strategies = speccheck.NewRegistry()
+		}
+		strategies.SetDefaultComparator(speccheck.SemanticComparator{
+			MissingAsZero: true,
+			Quantities:    true,
+		})
+	}
 	specCheck := &speccheck.SpecCheck{
-		Logger:  config.Logger,
-		Cleaner: oc,
+		Logger:     config.Logger,
+		Cleaner:    oc,
+		Strategies: strategies,
+
+		FieldOwnership: c.FieldOwnership,
 	}
 
 	// Multi store
@@ -169,7 +490,7 @@ func (c *BundleControllerConstructor) New(config *ctrl.Config, cctx *ctrl.Contex
 	}
 
 	// Add resource informers to Multi store (not ServiceClass/Plan informers, ...)
-	resourceInfs, err := c.resourceInformers(config, cctx, scClient)
+	resourceInfs, err := c.resourceInformers(config, cctx, mainClient, scClient, limits, namespaces)
 	if err != nil {
 		return nil, err
 	}
@@ -179,8 +500,98 @@ func (c *BundleControllerConstructor) New(config *ctrl.Config, cctx *ctrl.Contex
 		if err = multiStore.AddInformer(gvk, inf); err != nil {
 			return nil, errors.Errorf("failed to add informer for %s", gvk)
 		}
+		syncedInfs = append(syncedInfs, inf)
+	}
+
+	// Statistics and health checks only account for Bundles of the shard
+	listBundles := bundleInf.GetStore().List
+	if shards != nil {
+		if err = shards.RegisterMetrics(config.Registry); err != nil {
+			return nil, err
+		}
+		listBundles = shards.FilterBundles(listBundles)
 	}
 
+	// Autoscaling signals
+	syncStats := bundlec.NewSyncStats(listBundles)
+	if err = syncStats.RegisterMetrics(config.Registry); err != nil {
+		return nil, err
+	}
+	debugHandlers["/autoscaling"] = syncStats
+
+	// Tracing
+	var tracer *tracing.Tracer
+	var spanExporter *tracing.OTLPExporter
+	if c.TracingOTLPEndpoint != "" {
+		spanExporter = tracing.NewOTLPExporter(config.Logger, c.TracingOTLPEndpoint, "smith", &http.Client{
+			Timeout: tracingExportTimeout,
+		})
+		if err = spanExporter.RegisterMetrics(config.Registry); err != nil {
+			return nil, err
+		}
+		tracer = tracing.NewTracer(spanExporter)
+	}
+
+	// Health
+	health := bundlec.NewHealthChecker(informersSynced(syncedInfs), crdInf.GetIndexer().GetByKey, listBundles, c.StuckWorkerTimeout)
+
+	// Retry budget
+	var retryBudget *bundlec.NamespaceRetryBudget
+	if c.NamespaceRetryQPS > 0 {
+		retryBudget, err = bundlec.NewNamespaceRetryBudget(c.NamespaceRetryQPS, c.NamespaceRetryBurst)
+		if err != nil {
+			return nil, err
+		}
+		if err = retryBudget.RegisterMetrics(config.Registry); err != nil {
+			return nil, err
+		}
+	}
+
+	// Inventory
+	inventory := bundlec.NewInventory(listBundles, pluginContainers)
+	if c.InventoryMetrics {
+		if err = inventory.RegisterMetrics(config.Registry); err != nil {
+			return nil, err
+		}
+	}
+	debugHandlers["/debug/inventory"] = inventory
+	if err = bundlec.NewWarningMetrics(listBundles).RegisterMetrics(config.Registry); err != nil {
+		return nil, err
+	}
+	debugHandlers["/debug/graph"] = &bundlec.GraphHandler{
+		BundleStore: bs,
+	}
+	bundleSchema, err := resources.BundleJSONSchema()
+	if err != nil {
+		return nil, err
+	}
+	debugHandlers["/schemas/bundle/v1/bundle.json"] = schemaHandler(bundleSchema)
+	workQueue := bundlec.NewCoalescingWorkQueue(cctx.WorkQueue)
+	if err = workQueue.RegisterMetrics(config.Registry); err != nil {
+		return nil, err
+	}
+
+	// Ownership metadata consistency
+	consistencyChecker := &bundlec.ConsistencyChecker{
+		Logger:                     config.Logger,
+		Store:                      multiStore,
+		BundleStore:                bs,
+		WorkQueue:                  workQueue,
+		RepairStaleOwnerReferences: c.RepairStaleOwnerReferences,
+	}
+	debugHandlers["/debug/consistency"] = consistencyChecker
+	orphanAuditor := &bundlec.OrphanAuditor{
+		Logger:        config.Logger,
+		Store:         multiStore,
+		BundleStore:   bs,
+		SmartClient:   smartClient,
+		WorkQueue:     workQueue,
+		Namespaces:    namespaces,
+		HasSynced:     informersSynced(syncedInfs),
+		DeleteOrphans: c.DeleteOrphans,
+	}
+	debugHandlers["/debug/orphans"] = orphanAuditor
+
 	// Controller
 	cntrlr := &bundlec.Controller{
 		Logger:           config.Logger,
@@ -188,20 +599,107 @@ func (c *BundleControllerConstructor) New(config *ctrl.Config, cctx *ctrl.Contex
 		BundleClient:     smithClient.SmithV1(),
 		BundleStore:      bs,
 		SmartClient:      smartClient,
+		ApplyClient:      applyClient,
+		DryRunClient:     dryRunClient,
+		StrictClient:     strictClient,
+		SecretResolver:   secretResolver,
 		Rc:               rc,
 		Store:            multiStore,
 		SpecCheck:        specCheck,
-		WorkQueue:        cctx.WorkQueue,
+		WorkQueue:        workQueue,
+		Recorder:         eventRecorder(config, scheme),
+		Namespaces:       core_v1lst.NewNamespaceLister(namespaceInf.GetIndexer()),
 		CrdResyncPeriod:  config.ResyncPeriod,
 		Namespace:        config.Namespace,
+		WatchNamespaces:  watchNamespaces,
 		PluginContainers: pluginContainers,
 		Scheme:           scheme,
 		Catalog:          catalog,
+
+		ForceRemovableFinalizers: splitNonEmpty(c.ForceRemovableFinalizers),
+		FinalizerRemovalTimeout:  c.FinalizerRemovalTimeout,
+		PruneBatchSize:           c.PruneBatchSize,
+		PruneBatchInterval:       c.PruneBatchInterval,
+		InitialReassertInterval:  qpsToInterval(c.InitialReassertQPS),
+		ResyncPeriod:             c.BundleResyncPeriod,
+
+		RequireDeletionConfirmation: c.RequireDeletionConfirmation,
+		RepairStaleOwnerReferences:  c.RepairStaleOwnerReferences,
+		StrictOwnership:             c.StrictOwnership,
+		OrderedDeletion:             c.OrderedDeletion,
+		StrictReferenceResolution:   c.StrictReferenceResolution,
+		CrossNamespaceResources:     c.CrossNamespaceResources,
+		FieldOwnership:              c.FieldOwnership,
+		DryRun:                      c.DryRun,
+
+		SyncStats: syncStats,
+		Health:    health,
+		Tracer:    tracer,
+
+		ConsistencyChecker:       consistencyChecker,
+		ConsistencyCheckInterval: c.ConsistencyCheckInterval,
+		OrphanAuditor:            orphanAuditor,
+		OrphanAuditInterval:      c.OrphanAuditInterval,
+
+		RetryBaseDelay: c.RetryBaseDelay,
+		RetryMaxDelay:  c.RetryMaxDelay,
+		MaxRetries:     c.MaxRetries,
+		RetryBudget:    retryBudget,
+
+		Migrations: migrations,
+		Zones:      zones,
+		Shards:     shards,
+
+		RevisionClient:       smithClient.SmithV1(),
+		RevisionLister:       revisionLister,
+		RevisionHistoryLimit: c.RevisionHistoryLimit,
+		RevisionTTL:          c.RevisionTTL,
 	}
 	cntrlr.Prepare(crdInf, resourceInfs)
 
+	var iface ctrl.Interface = cntrlr
+	if spanExporter != nil {
+		iface = &tracingExporter{
+			Interface: iface,
+			exporter:  spanExporter,
+		}
+	}
+	if c.DebugListenOn != "" {
+		iface = &debugServer{
+			Interface: iface,
+			logger:    config.Logger,
+			addr:      c.DebugListenOn,
+			handlers:  debugHandlers,
+		}
+	}
+	if c.HealthListenOn != "" {
+		iface = &healthServer{
+			Interface: iface,
+			logger:    config.Logger,
+			addr:      c.HealthListenOn,
+			health:    health,
+		}
+	}
+	if c.WebhookListenOn != "" {
+		if c.WebhookTLSCertFile == "" || c.WebhookTLSKeyFile == "" {
+			return nil, errors.New("-webhook-tls-cert-file and -webhook-tls-key-file must be set to serve the admission webhooks")
+		}
+		authoredVersions := webhook.NewAuthoredVersionMetrics()
+		if err = authoredVersions.RegisterMetrics(config.Registry); err != nil {
+			return nil, err
+		}
+		iface = &webhookServer{
+			Interface:        iface,
+			logger:           config.Logger,
+			addr:             c.WebhookListenOn,
+			certFile:         c.WebhookTLSCertFile,
+			keyFile:          c.WebhookTLSKeyFile,
+			readinessRules:   rc,
+			authoredVersions: authoredVersions,
+		}
+	}
 	return &ctrl.Constructed{
-		Interface: cntrlr,
+		Interface: iface,
 	}, nil
 }
 
@@ -212,8 +710,13 @@ func (c *BundleControllerConstructor) Describe() ctrl.Descriptor {
 }
 
 func (c *BundleControllerConstructor) loadPlugins() (map[smith_v1.PluginName]plugin.PluginContainer, error) {
-	pluginContainers := make(map[smith_v1.PluginName]plugin.PluginContainer, len(c.Plugins))
-	for _, p := range c.Plugins {
+	plugins := make([]plugin.NewFunc, 0, len(c.Plugins)+2)
+	plugins = append(plugins, c.Plugins...)
+	if c.SmokePlugins {
+		plugins = append(plugins, smoke.NewConfigMap, smoke.NewJob)
+	}
+	pluginContainers := make(map[smith_v1.PluginName]plugin.PluginContainer, len(plugins))
+	for _, p := range plugins {
 		pluginContainer, err := plugin.NewPluginContainer(p)
 		if err != nil {
 			return nil, err
@@ -227,19 +730,47 @@ func (c *BundleControllerConstructor) loadPlugins() (map[smith_v1.PluginName]plu
 	return pluginContainers, nil
 }
 
-func (c *BundleControllerConstructor) resourceInformers(config *ctrl.Config, cctx *ctrl.Context, scClient scClientset.Interface) (map[schema.GroupVersionKind]cache.SharedIndexInformer, error) {
+func (c *BundleControllerConstructor) loadMigrations() (*migration.Rules, error) {
+	if !c.MigrateChildren {
+		return nil, nil
+	}
+	if c.MigrationRules == "" {
+		return nil, errors.New("-bundle-migration-rules must be specified if -bundle-migrate-children is enabled")
+	}
+	data, err := ioutil.ReadFile(c.MigrationRules)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read migration rules")
+	}
+	return migration.Load(data)
+}
+
+// resourceInformers returns informers for objects of the kinds the controller watches. Informers of kinds with
+// their own API limits use clients with these limits.
+func (c *BundleControllerConstructor) resourceInformers(config *ctrl.Config, cctx *ctrl.Context, mainClient kubernetes.Interface, scClient scClientset.Interface, limits *apiLimits, namespaces []string) (map[schema.GroupVersionKind]cache.SharedIndexInformer, error) {
 	coreInfs := map[schema.GroupVersionKind]func(kubernetes.Interface, string, time.Duration, cache.Indexers) cache.SharedIndexInformer{
 		// Core API types
-		ext_v1b1.SchemeGroupVersion.WithKind("Ingress"):       ext_v1b1inf.NewIngressInformer,
-		core_v1.SchemeGroupVersion.WithKind("Service"):        core_v1inf.NewServiceInformer,
-		core_v1.SchemeGroupVersion.WithKind("ConfigMap"):      core_v1inf.NewConfigMapInformer,
-		core_v1.SchemeGroupVersion.WithKind("Secret"):         core_v1inf.NewSecretInformer,
-		core_v1.SchemeGroupVersion.WithKind("ServiceAccount"): core_v1inf.NewServiceAccountInformer,
-		apps_v1.SchemeGroupVersion.WithKind("Deployment"):     apps_v1inf.NewDeploymentInformer,
+		ext_v1b1.SchemeGroupVersion.WithKind("Ingress"):              ext_v1b1inf.NewIngressInformer,
+		core_v1.SchemeGroupVersion.WithKind("Service"):               core_v1inf.NewServiceInformer,
+		core_v1.SchemeGroupVersion.WithKind("ConfigMap"):             core_v1inf.NewConfigMapInformer,
+		core_v1.SchemeGroupVersion.WithKind("Secret"):                core_v1inf.NewSecretInformer,
+		core_v1.SchemeGroupVersion.WithKind("ServiceAccount"):        core_v1inf.NewServiceAccountInformer,
+		core_v1.SchemeGroupVersion.WithKind("Endpoints"):             core_v1inf.NewEndpointsInformer,
+		core_v1.SchemeGroupVersion.WithKind("PersistentVolumeClaim"): core_v1inf.NewPersistentVolumeClaimInformer,
+		apps_v1.SchemeGroupVersion.WithKind("Deployment"):            apps_v1inf.NewDeploymentInformer,
+		apps_v1.SchemeGroupVersion.WithKind("StatefulSet"):           apps_v1inf.NewStatefulSetInformer,
+		batch_v1.SchemeGroupVersion.WithKind("Job"):                  batch_v1inf.NewJobInformer,
 	}
 	infs := make(map[schema.GroupVersionKind]cache.SharedIndexInformer, len(coreInfs)+2)
 	for gvk, coreInf := range coreInfs {
-		inf, err := cctx.MainInformer(config, gvk, coreInf)
+		client := mainClient
+		if cfg, ok := limits.kindConfigs[gvk.GroupKind()]; ok {
+			var err error
+			client, err = kubernetes.NewForConfig(cfg)
+			if err != nil {
+				return nil, err
+			}
+		}
+		inf, err := mainInformer(config, cctx, client, namespaces, gvk, coreInf)
 		if err != nil {
 			return nil, err
 		}
@@ -254,7 +785,15 @@ func (c *BundleControllerConstructor) resourceInformers(config *ctrl.Config, cct
 			sc_v1b1.SchemeGroupVersion.WithKind("ServiceInstance"): sc_v1b1inf.NewServiceInstanceInformer,
 		}
 		for gvk, scInf := range scInfs {
-			inf, err := svcCatInformer(config, cctx, scClient, gvk, scInf)
+			client := scClient
+			if cfg, ok := limits.kindConfigs[gvk.GroupKind()]; ok {
+				var err error
+				client, err = scClientset.NewForConfig(cfg)
+				if err != nil {
+					return nil, err
+				}
+			}
+			inf, err := svcCatInformer(config, cctx, client, namespaces, gvk, scInf)
 			if err != nil {
 				return nil, err
 			}
@@ -272,6 +811,7 @@ func FullScheme(serviceCatalog bool) (*runtime.Scheme, error) {
 	sb.Register(ext_v1b1.SchemeBuilder...)
 	sb.Register(core_v1.SchemeBuilder...)
 	sb.Register(apps_v1.SchemeBuilder...)
+	sb.Register(batch_v1.SchemeBuilder...)
 	sb.Register(apiext_v1b1.SchemeBuilder...)
 	if serviceCatalog {
 		sb.Register(sc_v1b1.SchemeBuilder...)
@@ -282,10 +822,41 @@ func FullScheme(serviceCatalog bool) (*runtime.Scheme, error) {
 	return scheme, nil
 }
 
-func smithInformer(config *ctrl.Config, cctx *ctrl.Context, smithClient smithClientset.Interface, gvk schema.GroupVersionKind, f func(smithClientset.Interface, string, time.Duration) cache.SharedIndexInformer) (cache.SharedIndexInformer, error) {
+// namespaceInformer returns the informer for Namespaces. Namespaces are cluster-scoped so it is never limited
+// to a single namespace.
+func namespaceInformer(config *ctrl.Config, cctx *ctrl.Context) (cache.SharedIndexInformer, error) {
+	namespaceGVK := core_v1.SchemeGroupVersion.WithKind("Namespace")
+	namespaceInf := cctx.Informers[namespaceGVK]
+	if namespaceInf == nil {
+		namespaceInf = core_v1inf.NewNamespaceInformer(config.MainClient, config.ResyncPeriod, cache.Indexers{})
+		if err := cctx.RegisterInformer(namespaceGVK, namespaceInf); err != nil {
+			return nil, err
+		}
+	}
+	return namespaceInf, nil
+}
+
+// mainInformer returns the informer for objects of the kind in the namespaces.
+func mainInformer(config *ctrl.Config, cctx *ctrl.Context, client kubernetes.Interface, namespaces []string, gvk schema.GroupVersionKind, f func(kubernetes.Interface, string, time.Duration, cache.Indexers) cache.SharedIndexInformer) (cache.SharedIndexInformer, error) {
+	inf := cctx.Informers[gvk]
+	if inf == nil {
+		inf = store.NewMultiNamespaceInformer(namespaces, func(namespace string) cache.SharedIndexInformer {
+			return f(client, namespace, config.ResyncPeriod, cache.Indexers{})
+		})
+		err := cctx.RegisterInformer(gvk, inf)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return inf, nil
+}
+
+func smithInformer(config *ctrl.Config, cctx *ctrl.Context, smithClient smithClientset.Interface, namespaces []string, gvk schema.GroupVersionKind, f func(smithClientset.Interface, string, time.Duration, cache.Indexers) cache.SharedIndexInformer) (cache.SharedIndexInformer, error) {
 	inf := cctx.Informers[gvk]
 	if inf == nil {
-		inf = f(smithClient, config.Namespace, config.ResyncPeriod)
+		inf = store.NewMultiNamespaceInformer(namespaces, func(namespace string) cache.SharedIndexInformer {
+			return f(smithClient, namespace, config.ResyncPeriod, cache.Indexers{})
+		})
 		err := cctx.RegisterInformer(gvk, inf)
 		if err != nil {
 			return nil, err
@@ -294,6 +865,21 @@ func smithInformer(config *ctrl.Config, cctx *ctrl.Context, smithClient smithCli
 	return inf, nil
 }
 
+// revisionInformer returns the informer for BundleRevisions. It returns nil if revision history is disabled by default
+// and the BundleRevision CRD is not installed, Bundles cannot enable the history then and the informer would never sync.
+func (c *BundleControllerConstructor) revisionInformer(config *ctrl.Config, cctx *ctrl.Context, smithClient smithClientset.Interface, apiExtClient apiExtClientset.Interface, namespaces []string) (cache.SharedIndexInformer, error) {
+	if c.RevisionHistoryLimit == 0 {
+		_, err := apiExtClient.ApiextensionsV1beta1().CustomResourceDefinitions().Get(smith_v1.BundleRevisionResourceName, meta_v1.GetOptions{})
+		if err != nil {
+			if api_errors.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, errors.Wrapf(err, "failed to get CustomResourceDefinition %s", smith_v1.BundleRevisionResourceName)
+		}
+	}
+	return smithInformer(config, cctx, smithClient, namespaces, smith_v1.BundleRevisionGVK, smith_v1inf.NewBundleRevisionInformer)
+}
+
 func apiExtensionsInformer(config *ctrl.Config, cctx *ctrl.Context, apiExtClient apiExtClientset.Interface, gvk schema.GroupVersionKind, f func(apiExtClientset.Interface, time.Duration, cache.Indexers) cache.SharedIndexInformer) (cache.SharedIndexInformer, error) {
 	inf := cctx.Informers[gvk]
 	if inf == nil {
@@ -318,10 +904,12 @@ func svcCatClusterInformer(config *ctrl.Config, cctx *ctrl.Context, scClient scC
 	return inf, nil
 }
 
-func svcCatInformer(config *ctrl.Config, cctx *ctrl.Context, scClient scClientset.Interface, gvk schema.GroupVersionKind, f func(scClientset.Interface, string, time.Duration, cache.Indexers) cache.SharedIndexInformer) (cache.SharedIndexInformer, error) {
+func svcCatInformer(config *ctrl.Config, cctx *ctrl.Context, scClient scClientset.Interface, namespaces []string, gvk schema.GroupVersionKind, f func(scClientset.Interface, string, time.Duration, cache.Indexers) cache.SharedIndexInformer) (cache.SharedIndexInformer, error) {
 	inf := cctx.Informers[gvk]
 	if inf == nil {
-		inf = f(scClient, config.Namespace, config.ResyncPeriod, cache.Indexers{})
+		inf = store.NewMultiNamespaceInformer(namespaces, func(namespace string) cache.SharedIndexInformer {
+			return f(scClient, namespace, config.ResyncPeriod, cache.Indexers{})
+		})
 		err := cctx.RegisterInformer(gvk, inf)
 		if err != nil {
 			return nil, err
@@ -329,3 +917,91 @@ func svcCatInformer(config *ctrl.Config, cctx *ctrl.Context, scClient scClientse
 	}
 	return inf, nil
 }
+
+// splitNonEmpty splits a comma separated list, dropping empty elements.
+func splitNonEmpty(list string) []string {
+	var result []string
+	for _, s := range strings.Split(list, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// parseWriteTimeouts parses a comma separated list of Kind.group=timeout pairs.
+// The group is omitted for kinds of the core group, e.g. ConfigMap=3s.
+func parseWriteTimeouts(list string) (map[schema.GroupKind]time.Duration, error) {
+	result := make(map[schema.GroupKind]time.Duration)
+	for _, pair := range splitNonEmpty(list) {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, errors.Errorf("invalid write timeout %q, expected Kind.group=timeout", pair)
+		}
+		timeout, err := time.ParseDuration(parts[1])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid write timeout %q", pair)
+		}
+		if timeout <= 0 {
+			return nil, errors.Errorf("invalid write timeout %q, must be positive", pair)
+		}
+		result[schema.ParseGroupKind(parts[0])] = timeout
+	}
+	return result, nil
+}
+
+// writeClientPools returns a client pool for each kind with a write timeout. Kinds with the same timeout share a pool
+// unless they have their own API limits.
+func writeClientPools(limits *apiLimits, mapper meta.RESTMapper, writeTimeouts map[schema.GroupKind]time.Duration) map[schema.GroupKind]smart.ClientPool {
+	pools := make(map[schema.GroupKind]smart.ClientPool, len(writeTimeouts))
+	byTimeout := make(map[time.Duration]smart.ClientPool)
+	for gk, timeout := range writeTimeouts {
+		if kindConfig, ok := limits.kindConfigs[gk]; ok {
+			cfg := rest.CopyConfig(kindConfig)
+			cfg.Timeout = timeout
+			pools[gk] = dynamic.NewClientPool(cfg, mapper, dynamic.LegacyAPIPathResolverFunc)
+			continue
+		}
+		pool, ok := byTimeout[timeout]
+		if !ok {
+			cfg := rest.CopyConfig(limits.restConfig)
+			cfg.Timeout = timeout
+			pool = dynamic.NewClientPool(cfg, mapper, dynamic.LegacyAPIPathResolverFunc)
+			byTimeout[timeout] = pool
+		}
+		pools[gk] = pool
+	}
+	return pools
+}
+
+// eventRecorder returns a recorder that writes Events to the API server on behalf of the app.
+func eventRecorder(config *ctrl.Config, scheme *runtime.Scheme) record.EventRecorder {
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartRecordingToSink(&core_v1client.EventSinkImpl{
+		Interface: config.MainClient.CoreV1().Events(config.Namespace),
+	})
+	return eventBroadcaster.NewRecorder(scheme, core_v1.EventSource{Component: config.AppName})
+}
+
+// withoutKinds returns readiness checks without the kinds that have a check in overrides.
+func withoutKinds(readyTypes []map[schema.GroupKind]readychecker.IsObjectReady, overrides map[schema.GroupKind]readychecker.IsObjectReady) []map[schema.GroupKind]readychecker.IsObjectReady {
+	result := make([]map[schema.GroupKind]readychecker.IsObjectReady, 0, len(readyTypes))
+	for _, types := range readyTypes {
+		filtered := make(map[schema.GroupKind]readychecker.IsObjectReady, len(types))
+		for gk, isObjectReady := range types {
+			if _, ok := overrides[gk]; !ok {
+				filtered[gk] = isObjectReady
+			}
+		}
+		result = append(result, filtered)
+	}
+	return result
+}
+
+// qpsToInterval converts a rate into an interval between events. Non-positive rate produces zero interval.
+func qpsToInterval(qps float64) time.Duration {
+	if qps <= 0 {
+		return 0
+	}
+	return time.Duration(float64(time.Second) / qps)
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+)
+
+func TestParseWriteTimeouts(t *testing.T) {
+	t.Parallel()
+	timeouts, err := parseWriteTimeouts(" Deployment.apps=5s,,ConfigMap=3s ")
+	require.NoError(t, err)
+	assert.Equal(t, map[schema.GroupKind]time.Duration{
+		configMapGK:  3 * time.Second,
+		deploymentGK: 5 * time.Second,
+	}, timeouts)
+
+	timeouts, err = parseWriteTimeouts("")
+	require.NoError(t, err)
+	assert.Empty(t, timeouts)
+}
+
+func TestParseWriteTimeoutsInvalid(t *testing.T) {
+	t.Parallel()
+	testcases := map[string]struct {
+		list        string
+		expectedErr string
+	}{
+		"no timeout": {
+			list:        "ConfigMap",
+			expectedErr: `invalid write timeout "ConfigMap", expected Kind.group=timeout`,
+		},
+		"no kind": {
+			list:        "=3s",
+			expectedErr: `invalid write timeout "=3s", expected Kind.group=timeout`,
+		},
+		"invalid duration": {
+			list:        "ConfigMap=3",
+			expectedErr: `invalid write timeout "ConfigMap=3": time: missing unit in duration`,
+		},
+		"zero duration": {
+			list:        "Secret=1s,ConfigMap=0s",
+			expectedErr: `invalid write timeout "ConfigMap=0s", must be positive`,
+		},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			_, err := parseWriteTimeouts(tc.list)
+			require.Error(t, err)
+			// Messages of the time package differ between Go versions
+			assert.Contains(t, err.Error(), tc.expectedErr)
+		})
+	}
+}
+
+func TestWriteClientPools(t *testing.T) {
+	t.Parallel()
+	var mapper meta.RESTMapper
+	limits := newAPILimits(&rest.Config{}, 0, 0, map[schema.GroupKind]apiLimit{
+		deploymentGK: {qps: 1, burst: 1},
+	})
+	pools := writeClientPools(limits, mapper, map[schema.GroupKind]time.Duration{
+		configMapGK:  3 * time.Second,
+		secretGK:     3 * time.Second,
+		deploymentGK: 3 * time.Second,
+	})
+	require.Len(t, pools, 3)
+	// Kinds with the same timeout share a pool unless they have their own limits
+	assert.True(t, pools[configMapGK] == pools[secretGK])
+	assert.False(t, pools[deploymentGK] == pools[configMapGK])
+
+	assert.Empty(t, writeClientPools(limits, mapper, nil))
+}
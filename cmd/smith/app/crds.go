@@ -0,0 +1,95 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/atlassian/smith/pkg/resources"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	apiext_v1b1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	apiExtClientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	apiext_v1b1inf "k8s.io/apiextensions-apiserver/pkg/client/informers/externalversions/apiextensions/v1beta1"
+	apiext_v1b1lst "k8s.io/apiextensions-apiserver/pkg/client/listers/apiextensions/v1beta1"
+	api_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ensureCrds creates or updates the CRDs and waits for them to become established. Uses a short-lived informer
+// because informers of the controller are only started after it has been constructed, and they cannot list objects
+// of kinds that are not established yet.
+func ensureCrds(logger *zap.Logger, apiExtClient apiExtClientset.Interface, timeout time.Duration, crds ...*apiext_v1b1.CustomResourceDefinition) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	var wg wait.Group
+	defer wg.Wait()
+	defer cancel()
+
+	crdInf := apiext_v1b1inf.NewCustomResourceDefinitionInformer(apiExtClient, 0, cache.Indexers{})
+	wg.StartWithChannel(ctx.Done(), crdInf.Run)
+	// The cache must be populated, otherwise existing CRDs would be re-created
+	if !cache.WaitForCacheSync(ctx.Done(), crdInf.HasSynced) {
+		return errors.Errorf("timed out after %s waiting for CustomResourceDefinitions to be listed", timeout)
+	}
+	crdLister := apiext_v1b1lst.NewCustomResourceDefinitionLister(crdInf.GetIndexer())
+	for _, crd := range crds {
+		if err := checkCrdVersioning(apiExtClient, crd.Name); err != nil {
+			return err
+		}
+		if err := resources.EnsureCrdExistsAndIsEstablished(ctx, logger, apiExtClient, crdLister, crd); err != nil {
+			return errors.Wrapf(err, "failed to ensure CustomResourceDefinition %s is established", crd.Name)
+		}
+	}
+	return nil
+}
+
+// checkCrdVersioning returns an error if the existing CRD serves several versions or converts objects between them.
+// The API client Smith is built with predates CRD versioning, so updating such a CRD would drop its versions and
+// conversion blocks, e.g. stop serving Bundles at v2alpha1. The CRD is read as raw JSON because the typed client
+// does not decode these blocks.
+func checkCrdVersioning(apiExtClient apiExtClientset.Interface, name string) error {
+	data, err := apiExtClient.ApiextensionsV1beta1().RESTClient().Get().
+		Resource("customresourcedefinitions").
+		Name(name).
+		DoRaw()
+	if err != nil {
+		if api_errors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to get CustomResourceDefinition %s", name)
+	}
+	return crdVersioning(name, data)
+}
+
+// crdVersioning returns an error if the JSON of the CRD defines several versions or a conversion strategy other
+// than None. The API server defaults both blocks from the version field, defaults are fine to drop.
+func crdVersioning(name string, data []byte) error {
+	var crd struct {
+		Spec struct {
+			Versions []struct {
+				Name string `json:"name"`
+			} `json:"versions"`
+			Conversion *struct {
+				Strategy string `json:"strategy"`
+			} `json:"conversion"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal(data, &crd); err != nil {
+		return errors.Wrapf(err, "failed to unmarshal CustomResourceDefinition %s", name)
+	}
+	if len(crd.Spec.Versions) > 1 {
+		versions := make([]string, 0, len(crd.Spec.Versions))
+		for _, version := range crd.Spec.Versions {
+			versions = append(versions, version.Name)
+		}
+		return errors.Errorf("CustomResourceDefinition %s serves versions %s that would be dropped by updating it, "+
+			"it must be managed without -bundle-ensure-crd", name, strings.Join(versions, ", "))
+	}
+	if conversion := crd.Spec.Conversion; conversion != nil && conversion.Strategy != "" && conversion.Strategy != "None" {
+		return errors.Errorf("CustomResourceDefinition %s has %s conversion that would be dropped by updating it, "+
+			"it must be managed without -bundle-ensure-crd", name, conversion.Strategy)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCrdVersioning(t *testing.T) {
+	t.Parallel()
+	testcases := map[string]struct {
+		crd         string
+		expectedErr string
+	}{
+		"no versioning": {
+			crd: `{"spec": {"version": "v1"}}`,
+		},
+		"defaulted versioning": {
+			crd: `{"spec": {"version": "v1", "versions": [{"name": "v1", "served": true, "storage": true}], "conversion": {"strategy": "None"}}}`,
+		},
+		"several versions": {
+			crd:         `{"spec": {"version": "v1", "versions": [{"name": "v1"}, {"name": "v2alpha1"}]}}`,
+			expectedErr: "CustomResourceDefinition bundles.smith.atlassian.com serves versions v1, v2alpha1 that would be dropped by updating it, it must be managed without -bundle-ensure-crd",
+		},
+		"conversion webhook": {
+			crd:         `{"spec": {"version": "v1", "conversion": {"strategy": "Webhook"}}}`,
+			expectedErr: "CustomResourceDefinition bundles.smith.atlassian.com has Webhook conversion that would be dropped by updating it, it must be managed without -bundle-ensure-crd",
+		},
+		"invalid JSON": {
+			crd:         `{`,
+			expectedErr: "failed to unmarshal CustomResourceDefinition bundles.smith.atlassian.com: unexpected end of JSON input",
+		},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			err := crdVersioning("bundles.smith.atlassian.com", []byte(tc.crd))
+			if tc.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
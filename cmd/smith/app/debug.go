@@ -0,0 +1,59 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/atlassian/ctrl"
+	"go.uber.org/zap"
+)
+
+const (
+	debugServerShutdownTimeout = 5 * time.Second
+)
+
+// debugServer serves debug endpoints while the controller is running.
+type debugServer struct {
+	ctrl.Interface
+	logger   *zap.Logger
+	addr     string
+	handlers map[string]http.Handler
+}
+
+func (s *debugServer) Run(ctx context.Context) {
+	mux := http.NewServeMux()
+	for pattern, handler := range s.handlers {
+		mux.Handle(pattern, handler)
+	}
+	srv := &http.Server{
+		Addr:    s.addr,
+		Handler: mux,
+	}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			s.logger.Error("Debug server failed", zap.Error(err))
+		}
+	}()
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), debugServerShutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			s.logger.Error("Failed to shut down debug server", zap.Error(err))
+		}
+	}()
+	s.Interface.Run(ctx)
+}
+
+// schemaHandler serves a JSON schema.
+type schemaHandler []byte
+
+func (h schemaHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.Write(h) // Nothing can be done if write fails
+}
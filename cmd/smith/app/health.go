@@ -0,0 +1,59 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/atlassian/ctrl"
+	"github.com/atlassian/smith/pkg/controller/bundlec"
+	"go.uber.org/zap"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	healthServerShutdownTimeout = 5 * time.Second
+)
+
+// healthServer serves liveness and readiness probes while the controller is running.
+type healthServer struct {
+	ctrl.Interface
+	logger *zap.Logger
+	addr   string
+	health *bundlec.HealthChecker
+}
+
+func (s *healthServer) Run(ctx context.Context) {
+	mux := http.NewServeMux()
+	mux.Handle("/healthz", s.health.HealthyHandler())
+	mux.Handle("/readyz", s.health.ReadyHandler())
+	srv := &http.Server{
+		Addr:    s.addr,
+		Handler: mux,
+	}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			s.logger.Error("Health server failed", zap.Error(err))
+		}
+	}()
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), healthServerShutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			s.logger.Error("Failed to shut down health server", zap.Error(err))
+		}
+	}()
+	s.Interface.Run(ctx)
+}
+
+// informersSynced returns a function that returns true if all informers have synced.
+func informersSynced(infs []cache.SharedIndexInformer) func() bool {
+	return func() bool {
+		for _, inf := range infs {
+			if !inf.HasSynced() {
+				return false
+			}
+		}
+		return true
+	}
+}
@@ -0,0 +1,62 @@
+package app
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/atlassian/smith/pkg/controller/bundlec"
+	"github.com/atlassian/smith/pkg/secretstore"
+	"github.com/pkg/errors"
+)
+
+const (
+	vaultTokenEnvVar         = "VAULT_TOKEN"
+	awsAccessKeyIDEnvVar     = "AWS_ACCESS_KEY_ID"
+	awsSecretAccessKeyEnvVar = "AWS_SECRET_ACCESS_KEY"
+	awsSessionTokenEnvVar    = "AWS_SESSION_TOKEN"
+)
+
+// secretResolver returns a resolver for references to configured external secret stores.
+// Nil is returned if no secret stores are configured.
+func (c *BundleControllerConstructor) secretResolver() (bundlec.SecretResolver, error) {
+	providers := make(map[string]secretstore.Provider)
+	if c.VaultAddress != "" {
+		token := os.Getenv(vaultTokenEnvVar)
+		if c.VaultTokenFile != "" {
+			data, err := ioutil.ReadFile(c.VaultTokenFile)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to read Vault token")
+			}
+			token = strings.TrimSpace(string(data))
+		}
+		if token == "" {
+			return nil, errors.Errorf("-vault-token-file or %s must be set to use Vault", vaultTokenEnvVar)
+		}
+		providers[secretstore.VaultProviderName] = &secretstore.VaultProvider{
+			Address: c.VaultAddress,
+			Token:   token,
+			Client:  &http.Client{},
+		}
+	}
+	if c.AWSSecretsManagerRegion != "" {
+		creds := secretstore.AWSCredentials{
+			AccessKeyID:     os.Getenv(awsAccessKeyIDEnvVar),
+			SecretAccessKey: os.Getenv(awsSecretAccessKeyEnvVar),
+			SessionToken:    os.Getenv(awsSessionTokenEnvVar),
+		}
+		if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+			return nil, errors.Errorf("%s and %s must be set to use AWS Secrets Manager", awsAccessKeyIDEnvVar, awsSecretAccessKeyEnvVar)
+		}
+		providers[secretstore.AWSSecretsManagerProviderName] = &secretstore.AWSSecretsManagerProvider{
+			Region:      c.AWSSecretsManagerRegion,
+			Credentials: creds,
+			Client:      &http.Client{},
+		}
+	}
+	if len(providers) == 0 {
+		return nil, nil
+	}
+	return secretstore.NewResolver(providers, c.SecretStoreCacheTTL, c.SecretStoreTimeout), nil
+}
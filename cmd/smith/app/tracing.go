@@ -0,0 +1,30 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/ash2k/stager/wait"
+	"github.com/atlassian/ctrl"
+	"github.com/atlassian/smith/pkg/tracing"
+)
+
+const (
+	tracingExportTimeout = 10 * time.Second
+)
+
+// tracingExporter exports spans while the controller is running. Spans of syncs that are finished when
+// the controller stops are exported before Run returns.
+type tracingExporter struct {
+	ctrl.Interface
+	exporter *tracing.OTLPExporter
+}
+
+func (s *tracingExporter) Run(ctx context.Context) {
+	var wg wait.Group
+	defer wg.Wait()
+	exporterCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wg.StartWithContext(exporterCtx, s.exporter.Run)
+	s.Interface.Run(ctx)
+}
@@ -0,0 +1,60 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/atlassian/ctrl"
+	"github.com/atlassian/smith/pkg/webhook"
+	"go.uber.org/zap"
+)
+
+const (
+	webhookServerShutdownTimeout = 5 * time.Second
+)
+
+// webhookServer serves admission webhooks over TLS while the controller is running.
+type webhookServer struct {
+	ctrl.Interface
+	logger   *zap.Logger
+	addr     string
+	certFile string
+	keyFile  string
+	// readinessRules are used to warn about objects of kinds without a readiness rule.
+	readinessRules webhook.ReadinessRules
+	// authoredVersions counts Bundle writes by API version.
+	authoredVersions *webhook.AuthoredVersionMetrics
+}
+
+func (s *webhookServer) Run(ctx context.Context) {
+	mux := http.NewServeMux()
+	mux.Handle(webhook.BundleValidationPath, &webhook.BundleValidator{
+		Logger:         s.logger,
+		ReadinessRules: s.readinessRules,
+	})
+	mux.Handle(webhook.BundleDefaultingPath, &webhook.BundleDefaulter{
+		Logger:           s.logger,
+		AuthoredVersions: s.authoredVersions,
+	})
+	mux.Handle(webhook.BundleConversionPath, &webhook.BundleConverter{
+		Logger: s.logger,
+	})
+	srv := &http.Server{
+		Addr:    s.addr,
+		Handler: mux,
+	}
+	go func() {
+		if err := srv.ListenAndServeTLS(s.certFile, s.keyFile); err != nil && err != http.ErrServerClosed {
+			s.logger.Error("Webhook server failed", zap.Error(err))
+		}
+	}()
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), webhookServerShutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			s.logger.Error("Failed to shut down webhook server", zap.Error(err))
+		}
+	}()
+	s.Interface.Run(ctx)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"time"
+
+	"github.com/atlassian/smith/pkg/client"
+	"github.com/pkg/errors"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// Flags of the controller library that determine where client configuration is loaded from.
+	clientConfigFromFlag     = "client-config-from"
+	clientConfigFileNameFlag = "client-config-file-name"
+	clientConfigContextFlag  = "client-config-context"
+
+	// Rejected requests are not reloading credentials more often than this.
+	credentialReloadInterval = 10 * time.Second
+)
+
+// credentialRefresher returns a refresher that reloads client configuration from the same source it was loaded
+// from on startup. Must be called after the flags have been parsed.
+func credentialRefresher(fs *flag.FlagSet) (*client.CredentialRefresher, error) {
+	var values [3]string
+	for i, name := range []string{clientConfigFromFlag, clientConfigFileNameFlag, clientConfigContextFlag} {
+		f := fs.Lookup(name)
+		if f == nil {
+			return nil, errors.Errorf("flag -%s is not defined", name)
+		}
+		values[i] = f.Value.String()
+	}
+	configFileFrom, configFileName, configContext := values[0], values[1], values[2]
+	return client.NewCredentialRefresher(func() (*rest.Config, error) {
+		return client.LoadConfig(configFileFrom, configFileName, configContext)
+	}, credentialReloadInterval), nil
+}
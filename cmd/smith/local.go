@@ -0,0 +1,105 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	localFlag         = "local"
+	localResyncPeriod = "30s"
+)
+
+// localArgs turns "run -local" arguments into arguments for running Smith out-of-cluster for local development.
+// The controller uses the current kubeconfig, is scoped to a single namespace (the one of the current context unless
+// -namespace is specified), resyncs fast and logs verbosely in a human readable format. Leader election is disabled.
+// Explicitly specified flags take precedence over the defaults of the local mode.
+// Arguments are returned untouched if the local mode is not requested.
+func localArgs(args []string) ([]string, error) {
+	local := false
+	rest := make([]string, 0, len(args))
+	for _, arg := range args {
+		if isFlag(arg, localFlag) {
+			local = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	if !local {
+		return args, nil
+	}
+	if len(rest) > 0 && rest[0] == "run" {
+		rest = rest[1:]
+	}
+	kubeConfig, ok := flagValue(rest, "client-config-file-name")
+	if !ok {
+		kubeConfig = defaultKubeConfig()
+	}
+	defaults := []string{
+		"-client-config-from=file",
+		"-client-config-file-name=" + kubeConfig,
+		"-leader-elect=false",
+		"-resync-period=" + localResyncPeriod,
+		"-log-encoding=console",
+		"-log-level=debug",
+	}
+	if _, ok := flagValue(rest, "namespace"); !ok {
+		kubeContext, _ := flagValue(rest, "client-config-context")
+		namespace, err := currentNamespace(kubeConfig, kubeContext)
+		if err != nil {
+			return nil, err
+		}
+		defaults = append(defaults, "-namespace="+namespace)
+	}
+	// Last occurrence of a flag wins so explicitly specified flags go after the defaults
+	return append(defaults, rest...), nil
+}
+
+func defaultKubeConfig() string {
+	if kubeConfig := os.Getenv(clientcmd.RecommendedConfigPathEnvVar); kubeConfig != "" {
+		return filepath.SplitList(kubeConfig)[0]
+	}
+	return clientcmd.RecommendedHomeFile
+}
+
+// currentNamespace returns the namespace of the context, "default" if the context has no namespace.
+func currentNamespace(kubeConfig, kubeContext string) (string, error) {
+	loadingRules := &clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeConfig}
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: kubeContext}
+	namespace, _, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides).Namespace()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to determine namespace from %s", kubeConfig)
+	}
+	return namespace, nil
+}
+
+// isFlag returns true if arg is a boolean flag with the name, spelled with one or two dashes.
+func isFlag(arg, name string) bool {
+	trimmed := strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+	return trimmed != arg && (trimmed == name || trimmed == name+"=true")
+}
+
+// flagValue returns the value of the last occurrence of a flag in args.
+func flagValue(args []string, name string) (string, bool) {
+	var value string
+	found := false
+	for i := 0; i < len(args); i++ {
+		trimmed := strings.TrimPrefix(strings.TrimPrefix(args[i], "-"), "-")
+		if trimmed == args[i] {
+			continue
+		}
+		if trimmed == name && i+1 < len(args) {
+			value = args[i+1]
+			found = true
+			i++
+		} else if strings.HasPrefix(trimmed, name+"=") {
+			value = trimmed[len(name)+1:]
+			found = true
+		}
+	}
+	return value, found
+}
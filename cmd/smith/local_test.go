@@ -0,0 +1,77 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testKubeConfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: c1
+  cluster:
+    server: https://127.0.0.1:6443
+users:
+- name: u1
+  user:
+    token: abc
+contexts:
+- name: ctx1
+  context:
+    cluster: c1
+    user: u1
+    namespace: ns1
+- name: ctx2
+  context:
+    cluster: c1
+    user: u1
+current-context: ctx1
+`
+
+func TestLocalArgsNotLocal(t *testing.T) {
+	t.Parallel()
+	args, err := localArgs([]string{"-namespace=x", "-bundle-service-catalog=false"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"-namespace=x", "-bundle-service-catalog=false"}, args)
+
+	args, err = localArgs([]string{"run", "-namespace=x"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"run", "-namespace=x"}, args)
+}
+
+func TestLocalArgs(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "smith-local")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	kubeConfig := filepath.Join(dir, "config")
+	require.NoError(t, ioutil.WriteFile(kubeConfig, []byte(testKubeConfig), 0600))
+
+	args, err := localArgs([]string{"run", "--local", "-client-config-file-name", kubeConfig, "-log-level=info"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"-client-config-from=file",
+		"-client-config-file-name=" + kubeConfig,
+		"-leader-elect=false",
+		"-resync-period=" + localResyncPeriod,
+		"-log-encoding=console",
+		"-log-level=debug",
+		"-namespace=ns1",
+		"-client-config-file-name", kubeConfig,
+		"-log-level=info",
+	}, args)
+
+	args, err = localArgs([]string{"-local", "-client-config-file-name=" + kubeConfig, "-client-config-context=ctx2"})
+	require.NoError(t, err)
+	assert.Contains(t, args, "-namespace=default")
+
+	args, err = localArgs([]string{"-local", "-client-config-file-name=" + kubeConfig, "-namespace=other"})
+	require.NoError(t, err)
+	assert.NotContains(t, args, "-namespace=ns1")
+	assert.Contains(t, args, "-namespace=other")
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	logFormatFlag   = "log-format"
+	logEncodingFlag = "log-encoding"
+)
+
+// logFormat is the value of the -log-format flag. It is forwarded to the -log-encoding flag of the controller
+// library, which also provides -log-level, as the flags are parsed so that the last occurrence of either flag wins.
+// Supported formats are json and console.
+type logFormat struct {
+	fs     *flag.FlagSet
+	format string
+}
+
+// addLogFormatFlag registers the -log-format flag. The -log-encoding flag must be registered on the same flag set
+// before it is parsed.
+func addLogFormatFlag(fs *flag.FlagSet) {
+	fs.Var(&logFormat{fs: fs}, logFormatFlag, "Log format, json or console. Same as -"+logEncodingFlag)
+}
+
+func (f *logFormat) String() string {
+	return f.format
+}
+
+func (f *logFormat) Set(value string) error {
+	if value != "json" && value != "console" {
+		return errors.Errorf("unsupported log format %q, must be json or console", value)
+	}
+	if err := f.fs.Set(logEncodingFlag, value); err != nil {
+		return errors.WithStack(err)
+	}
+	f.format = value
+	return nil
+}
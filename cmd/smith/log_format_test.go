@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func logFormatFlagSet() (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet("smith", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	addLogFormatFlag(fs)
+	encoding := fs.String(logEncodingFlag, "json", "")
+	return fs, encoding
+}
+
+func TestLogFormatFlag(t *testing.T) {
+	t.Parallel()
+	testcases := map[string]struct {
+		args             []string
+		expectedEncoding string
+	}{
+		"default": {
+			expectedEncoding: "json",
+		},
+		"format": {
+			args:             []string{"--log-format", "console"},
+			expectedEncoding: "console",
+		},
+		"last format wins": {
+			args:             []string{"-log-encoding=console", "--log-format", "json", "-log-level=debug", "-log-format=console"},
+			expectedEncoding: "console",
+		},
+		"last encoding wins": {
+			args:             []string{"-log-format=console", "-log-encoding=json"},
+			expectedEncoding: "json",
+		},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			fs, encoding := logFormatFlagSet()
+			fs.String("log-level", "info", "")
+			require.NoError(t, fs.Parse(tc.args))
+			assert.Equal(t, tc.expectedEncoding, *encoding)
+		})
+	}
+}
+
+func TestLogFormatFlagUnsupported(t *testing.T) {
+	t.Parallel()
+	fs, encoding := logFormatFlagSet()
+	err := fs.Parse([]string{"-log-format=text"})
+	assert.EqualError(t, err, `invalid value "text" for flag -log-format: unsupported log format "text", must be json or console`)
+	assert.Equal(t, "json", *encoding)
+}
@@ -9,6 +9,10 @@ import (
 	"github.com/atlassian/ctrl"
 	ctrlApp "github.com/atlassian/ctrl/app"
 	"github.com/atlassian/smith/cmd/smith/app"
+	// Register OIDC auth provider so that tokens are refreshed transparently for long running processes.
+	// Exec credential plugins are supported by client-go out of the box. Other rotated credentials are reloaded
+	// when the API server responds with 401 Unauthorized, see credentials.go.
+	_ "k8s.io/client-go/plugin/pkg/client/auth/oidc"
 )
 
 func main() {
@@ -27,10 +31,21 @@ func run() error {
 }
 
 func runWithContext(ctx context.Context) error {
+	bundleController := &app.BundleControllerConstructor{}
 	controllers := []ctrl.Constructor{
-		&app.BundleControllerConstructor{},
+		bundleController,
+		&app.BundleClassControllerConstructor{},
 	}
-	a, err := ctrlApp.NewFromFlags("smith", controllers, flag.CommandLine, os.Args[1:])
+	args, err := localArgs(os.Args[1:])
+	if err != nil {
+		return err
+	}
+	addLogFormatFlag(flag.CommandLine)
+	a, err := ctrlApp.NewFromFlags("smith", controllers, flag.CommandLine, args)
+	if err != nil {
+		return err
+	}
+	bundleController.CredentialRefresher, err = credentialRefresher(flag.CommandLine)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/atlassian/smith/pkg/client/clientset_generated/clientset"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// bundleFlags are flags of commands that inspect a Bundle in the cluster.
+type bundleFlags struct {
+	namespace  *string
+	kubeconfig *string
+}
+
+func addBundleFlags(fs *flag.FlagSet) bundleFlags {
+	return bundleFlags{
+		namespace:  fs.String("namespace", "", "Namespace of the Bundle, the namespace of the current context if empty"),
+		kubeconfig: fs.String("kubeconfig", "", "Path to the kubeconfig file, the default loading rules apply if empty"),
+	}
+}
+
+// bundleArg returns the name of the Bundle passed as the only positional argument.
+func bundleArg(fs *flag.FlagSet) (string, error) {
+	if fs.NArg() != 1 {
+		return "", errors.Errorf("usage: smithctl %s [flags] <bundle>", fs.Name())
+	}
+	return fs.Arg(0), nil
+}
+
+// loadConfig loads the kubeconfig and returns the REST config and the namespace to use.
+func (f bundleFlags) loadConfig() (*rest.Config, string, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = *f.kubeconfig
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{})
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to load kubeconfig")
+	}
+	namespace := *f.namespace
+	if namespace == "" {
+		namespace, _, err = clientConfig.Namespace()
+		if err != nil {
+			return nil, "", errors.Wrap(err, "failed to get namespace of the current context")
+		}
+	}
+	return restConfig, namespace, nil
+}
+
+func getBundle(restConfig *rest.Config, namespace, name string) (*smith_v1.Bundle, error) {
+	smithClient, err := clientset.NewForConfig(restConfig)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	bundle, err := smithClient.SmithV1().Bundles(namespace).Get(name, meta_v1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get Bundle %s/%s", namespace, name)
+	}
+	return bundle, nil
+}
+
+// readBundleFile reads a Bundle manifest from the file, - for stdin.
+func readBundleFile(file string) ([]byte, error) {
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(file)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read Bundle")
+	}
+	data, err = yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert Bundle YAML to JSON")
+	}
+	return data, nil
+}
+
+// resourceDependencies returns names of resources the resource depends on in the order they are declared.
+// References to external secret stores are not dependencies.
+func resourceDependencies(res *smith_v1.Resource) []smith_v1.ResourceName {
+	seen := make(map[smith_v1.ResourceName]struct{})
+	var deps []smith_v1.ResourceName
+	add := func(name smith_v1.ResourceName) {
+		if _, ok := seen[name]; ok {
+			return
+		}
+		seen[name] = struct{}{}
+		deps = append(deps, name)
+	}
+	for _, ref := range res.References {
+		if ref.IsExternal() {
+			continue
+		}
+		add(ref.Resource)
+	}
+	for _, quorum := range res.Quorums {
+		for _, member := range quorum.Resources {
+			add(member)
+		}
+	}
+	return deps
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/atlassian/smith/pkg/cleanup"
+	clean_types "github.com/atlassian/smith/pkg/cleanup/types"
+	"github.com/atlassian/smith/pkg/client/smart"
+	"github.com/atlassian/smith/pkg/controller/bundlec"
+	"github.com/atlassian/smith/pkg/readychecker"
+	ready_types "github.com/atlassian/smith/pkg/readychecker/types"
+	"github.com/atlassian/smith/pkg/speccheck"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	core_v1 "k8s.io/api/core/v1"
+	apiext_v1b1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	apiExtClientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	api_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// diff prints how live objects of a Bundle differ from the objects its resources define, i.e. what the controller
+// would change. Specs are evaluated the way the controller evaluates them, against live objects of the Bundle.
+// Resources of plugins, resources with references to external secret stores and resources with dependencies that
+// are not ready are skipped. Values of Secrets are not printed, only paths of fields that differ.
+// Usage: smithctl diff [-namespace <namespace>] <bundle>
+func diff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	bf := addBundleFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	name, err := bundleArg(fs)
+	if err != nil {
+		return err
+	}
+	restConfig, namespace, err := bf.loadConfig()
+	if err != nil {
+		return err
+	}
+	bundle, err := getBundle(restConfig, namespace, name)
+	if err != nil {
+		return err
+	}
+	mainClient, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	apiExtClient, err := apiExtClientset.NewForConfig(restConfig)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	rm := discovery.NewDeferredDiscoveryRESTMapper(
+		&smart.CachedDiscoveryClient{
+			DiscoveryInterface: mainClient.Discovery(),
+		},
+		meta.InterfacesForUnstructured,
+	)
+	evaluator := &bundlec.SpecEvaluator{
+		Logger: zap.NewNop(),
+		Store: &liveStore{
+			client: &smart.DynamicClient{
+				ClientPool: dynamic.NewClientPool(restConfig, rm, dynamic.LegacyAPIPathResolverFunc),
+				Mapper:     rm,
+			},
+		},
+		Rc:                      readychecker.New(&liveCrdStore{client: apiExtClient}, ready_types.MainKnownTypes, ready_types.ServiceCatalogKnownTypes),
+		CrossNamespaceResources: true,
+	}
+	evaluated, err := evaluator.Evaluate(bundle)
+	if err != nil {
+		return err
+	}
+	specCheck := &speccheck.SpecCheck{
+		Logger:  zap.NewNop(),
+		Cleaner: cleanup.New(clean_types.MainKnownTypes, clean_types.ServiceCatalogKnownTypes),
+	}
+	if drifted := printDiff(os.Stdout, specCheck, evaluated); drifted > 0 {
+		return errors.Errorf("%d resource(s) differ from the Bundle", drifted)
+	}
+	return nil
+}
+
+// printDiff prints the difference between live objects of evaluated resources and the objects they define.
+// Returns the number of resources that differ.
+func printDiff(w io.Writer, specCheck bundlec.SpecCheck, evaluated []bundlec.EvaluatedResource) int {
+	drifted := 0
+	for i := range evaluated {
+		res := &evaluated[i]
+		differs, err := diffResource(w, specCheck, res)
+		if err != nil {
+			fmt.Fprintf(w, "%s: skipped: %v\n", res.Name, err)
+			continue
+		}
+		if differs {
+			drifted++
+		}
+	}
+	return drifted
+}
+
+// diffResource prints the difference between the live object of the resource and the object it defines.
+// Returns true if they differ.
+func diffResource(w io.Writer, specCheck bundlec.SpecCheck, res *bundlec.EvaluatedResource) (bool, error) {
+	if res.Err != nil {
+		return false, res.Err
+	}
+	if res.Actual == nil {
+		fmt.Fprintf(w, "%s: object does not exist\n", res.Name)
+		return true, nil
+	}
+	updated, match, err := specCheck.CompareActualVsSpec(res.Spec, res.Actual.DeepCopy())
+	if err != nil {
+		return false, err
+	}
+	if match {
+		fmt.Fprintf(w, "%s: in sync\n", res.Name)
+		return false, nil
+	}
+	drift := speccheck.ComputeDrift(res.Actual, updated)
+	fmt.Fprintf(w, "%s: differs\n", res.Name)
+	for _, field := range drift.Fields {
+		fmt.Fprintf(w, "  %s\n", field)
+	}
+	gvk := res.Actual.GroupVersionKind()
+	if gvk.Group == core_v1.GroupName && gvk.Kind == "Secret" {
+		// Values of a Secret must not be printed
+		return true, nil
+	}
+	patch, err := yaml.Marshal(drift.Patch)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to marshal patch into YAML")
+	}
+	fmt.Fprintf(w, "  patch:\n")
+	for _, line := range strings.Split(strings.TrimSuffix(string(patch), "\n"), "\n") {
+		fmt.Fprintf(w, "    %s\n", line)
+	}
+	return true, nil
+}
+
+// liveStore gets objects from the API server for the spec evaluator. Only getting objects is supported, so names
+// generated from metadata.generateName are only found if they are recorded in the status of the Bundle.
+type liveStore struct {
+	client *smart.DynamicClient
+}
+
+func (s *liveStore) Get(gvk schema.GroupVersionKind, namespace, name string) (runtime.Object, bool, error) {
+	resClient, err := s.client.ForGVK(gvk, namespace)
+	if err != nil {
+		return nil, false, err
+	}
+	obj, err := resClient.Get(name, meta_v1.GetOptions{})
+	if err != nil {
+		if api_errors.IsNotFound(err) {
+			return nil, false, nil
+		}
+		return nil, false, errors.Wrapf(err, "failed to get %s %q", gvk.Kind, name)
+	}
+	return obj, true, nil
+}
+
+func (s *liveStore) ObjectsControlledBy(namespace string, uid types.UID) ([]runtime.Object, error) {
+	return nil, nil
+}
+
+func (s *liveStore) ObjectsTrackedBy(uid types.UID) ([]runtime.Object, error) {
+	return nil, nil
+}
+
+func (s *liveStore) AddInformer(schema.GroupVersionKind, cache.SharedIndexInformer) error {
+	return errors.New("informers are not supported")
+}
+
+func (s *liveStore) RemoveInformer(schema.GroupVersionKind) bool {
+	return false
+}
+
+func (s *liveStore) HasInformer(schema.GroupVersionKind) bool {
+	return true
+}
+
+func (s *liveStore) HasSynced(...schema.GroupVersionKind) bool {
+	return true
+}
+
+// liveCrdStore gets CRDs from the API server for the ready checker.
+type liveCrdStore struct {
+	client apiExtClientset.Interface
+	crds   map[schema.GroupKind]*apiext_v1b1.CustomResourceDefinition
+}
+
+func (s *liveCrdStore) Get(gk schema.GroupKind) (*apiext_v1b1.CustomResourceDefinition, error) {
+	if s.crds == nil {
+		list, err := s.client.ApiextensionsV1beta1().CustomResourceDefinitions().List(meta_v1.ListOptions{})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list CRDs")
+		}
+		s.crds = make(map[schema.GroupKind]*apiext_v1b1.CustomResourceDefinition, len(list.Items))
+		for i := range list.Items {
+			crd := &list.Items[i]
+			s.crds[schema.GroupKind{Group: crd.Spec.Group, Kind: crd.Spec.Names.Kind}] = crd
+		}
+	}
+	return s.crds[gk], nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/atlassian/smith/pkg/controller/bundlec"
+	"github.com/atlassian/smith/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// dataSpecCheck considers objects matching if their "data" fields are equal.
+type dataSpecCheck struct{}
+
+func (dataSpecCheck) CompareActualVsSpec(spec, actual runtime.Object) (*unstructured.Unstructured, bool, error) {
+	specUnstr, err := util.RuntimeToUnstructured(spec)
+	if err != nil {
+		return nil, false, err
+	}
+	actualUnstr, err := util.RuntimeToUnstructured(actual)
+	if err != nil {
+		return nil, false, err
+	}
+	return specUnstr, equality.Semantic.DeepEqual(specUnstr.Object["data"], actualUnstr.Object["data"]), nil
+}
+
+func diffObject(kind string, data map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       kind,
+			"metadata": map[string]interface{}{
+				"name": "obj1",
+			},
+			"data": data,
+		},
+	}
+}
+
+func TestPrintDiff(t *testing.T) {
+	t.Parallel()
+	testcases := map[string]struct {
+		res             bundlec.EvaluatedResource
+		expectedOutput  string
+		expectedDrifted int
+	}{
+		"does not exist": {
+			res: bundlec.EvaluatedResource{
+				Name: "a",
+				Spec: diffObject("ConfigMap", map[string]interface{}{"x": "1"}),
+			},
+			expectedOutput:  "a: object does not exist\n",
+			expectedDrifted: 1,
+		},
+		"in sync": {
+			res: bundlec.EvaluatedResource{
+				Name:   "a",
+				Spec:   diffObject("ConfigMap", map[string]interface{}{"x": "1"}),
+				Actual: diffObject("ConfigMap", map[string]interface{}{"x": "1"}),
+			},
+			expectedOutput: "a: in sync\n",
+		},
+		"differs": {
+			res: bundlec.EvaluatedResource{
+				Name:   "a",
+				Spec:   diffObject("ConfigMap", map[string]interface{}{"x": "2", "y": "1"}),
+				Actual: diffObject("ConfigMap", map[string]interface{}{"x": "1", "y": "1"}),
+			},
+			expectedOutput: "a: differs\n" +
+				"  data.x\n" +
+				"  patch:\n" +
+				"    data:\n" +
+				"      x: \"2\"\n",
+			expectedDrifted: 1,
+		},
+		"secret differs": {
+			res: bundlec.EvaluatedResource{
+				Name:   "a",
+				Spec:   diffObject("Secret", map[string]interface{}{"password": "c2VjcmV0Mg=="}),
+				Actual: diffObject("Secret", map[string]interface{}{"password": "c2VjcmV0MQ=="}),
+			},
+			expectedOutput:  "a: differs\n  data.password\n",
+			expectedDrifted: 1,
+		},
+		"not evaluated": {
+			res: bundlec.EvaluatedResource{
+				Name:   "a",
+				Actual: diffObject("ConfigMap", nil),
+				Err:    errors.New(`dependencies are not ready: ["b"]`),
+			},
+			expectedOutput: "a: skipped: dependencies are not ready: [\"b\"]\n",
+		},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			drifted := printDiff(&buf, dataSpecCheck{}, []bundlec.EvaluatedResource{tc.res})
+			assert.Equal(t, tc.expectedOutput, buf.String())
+			assert.Equal(t, tc.expectedDrifted, drifted)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"strings"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+const jsonPathOutputPrefix = "jsonpath="
+
+// get prints a Bundle. JSONPath templates are evaluated the way kubectl get -o jsonpath=... evaluates them so that
+// the same expressions work with both, see the status fields that automation can rely on in the docs.
+// Usage: smithctl get [-namespace <namespace>] [-o yaml|json|jsonpath=<template>] <bundle>
+func get(args []string) error {
+	fs := flag.NewFlagSet("get", flag.ContinueOnError)
+	bf := addBundleFlags(fs)
+	output := fs.String("o", "yaml", "Format to print the Bundle in (yaml, json or jsonpath=<template>)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	name, err := bundleArg(fs)
+	if err != nil {
+		return err
+	}
+	restConfig, namespace, err := bf.loadConfig()
+	if err != nil {
+		return err
+	}
+	bundle, err := getBundle(restConfig, namespace, name)
+	if err != nil {
+		return err
+	}
+	return printBundle(os.Stdout, bundle, *output)
+}
+
+func printBundle(w io.Writer, bundle *smith_v1.Bundle, output string) error {
+	// Typed clients do not set apiVersion and kind
+	bundle = bundle.DeepCopy()
+	bundle.APIVersion = smith_v1.BundleResourceGroupVersion
+	bundle.Kind = smith_v1.BundleResourceKind
+	switch {
+	case output == "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		return errors.Wrap(enc.Encode(bundle), "failed to marshal Bundle into JSON")
+	case output == "yaml":
+		data, err := yaml.Marshal(bundle)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal Bundle into YAML")
+		}
+		_, err = w.Write(data)
+		return errors.Wrap(err, "failed to write Bundle YAML")
+	case strings.HasPrefix(output, jsonPathOutputPrefix):
+		return printJSONPath(w, bundle, strings.TrimPrefix(output, jsonPathOutputPrefix))
+	default:
+		return errors.Errorf("unsupported output format %q", output)
+	}
+}
+
+// printJSONPath prints the result of the JSONPath template evaluated against the JSON representation of the Bundle.
+// Like with kubectl, missing keys evaluate to nothing.
+func printJSONPath(w io.Writer, bundle *smith_v1.Bundle, template string) error {
+	j := jsonpath.New("bundle")
+	j.AllowMissingKeys(true)
+	if err := j.Parse(template); err != nil {
+		return errors.Wrapf(err, "failed to parse JSONPath template %q", template)
+	}
+	data, err := json.Marshal(bundle)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal Bundle into JSON")
+	}
+	var obj interface{}
+	if err = json.Unmarshal(data, &obj); err != nil {
+		return errors.Wrap(err, "failed to unmarshal Bundle JSON")
+	}
+	var buf bytes.Buffer
+	if err = j.Execute(&buf, obj); err != nil {
+		return errors.Wrapf(err, "failed to evaluate JSONPath template %q", template)
+	}
+	_, err = buf.WriteTo(w)
+	return errors.Wrap(err, "failed to write result of JSONPath template")
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestPrintBundleJSONPath checks that smithctl get -o jsonpath=... evaluates the expressions documented for
+// kubectl get -o jsonpath=... the same way.
+func TestPrintBundleJSONPath(t *testing.T) {
+	t.Parallel()
+	bundle := &smith_v1.Bundle{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Namespace: "ns",
+			Name:      "b1",
+		},
+		Status: smith_v1.BundleStatus{
+			Conditions: []smith_v1.BundleCondition{
+				{Type: smith_v1.BundleReady, Status: smith_v1.ConditionFalse},
+				{Type: smith_v1.BundleError, Status: smith_v1.ConditionTrue, Reason: smith_v1.BundleReasonTerminalError},
+			},
+			ResourceStatuses: []smith_v1.ResourceStatus{
+				{Name: "a", State: smith_v1.ResourceStateReady},
+				{Name: "b", State: smith_v1.ResourceStateError, Message: "boom"},
+			},
+			Ready:           smith_v1.ConditionFalse,
+			FailedResources: []smith_v1.ResourceName{"b"},
+			Outputs: map[string]string{
+				"host": "db.example.com",
+			},
+		},
+	}
+	testcases := map[string]struct {
+		template       string
+		expectedOutput string
+	}{
+		"kind": {
+			template:       "{.kind}",
+			expectedOutput: "Bundle",
+		},
+		"ready": {
+			template:       "{.status.ready}",
+			expectedOutput: "False",
+		},
+		"failed resources": {
+			template:       "{.status.failedResources[*]}",
+			expectedOutput: "b",
+		},
+		"condition": {
+			template:       `{.status.conditions[?(@.type=="Error")].reason}`,
+			expectedOutput: "TerminalError",
+		},
+		"output": {
+			template:       "{.status.outputs.host}",
+			expectedOutput: "db.example.com",
+		},
+		"missing output": {
+			template: "{.status.outputs.port}",
+		},
+		"range": {
+			template:       `{range .status.resourceStatuses[*]}{.name}{"\t"}{.state}{"\t"}{.message}{"\n"}{end}`,
+			expectedOutput: "a\tReady\t\nb\tError\tboom\n",
+		},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			require.NoError(t, printBundle(&buf, bundle, "jsonpath="+tc.template))
+			assert.Equal(t, tc.expectedOutput, buf.String())
+		})
+	}
+}
+
+func TestPrintBundle(t *testing.T) {
+	t.Parallel()
+	bundle := &smith_v1.Bundle{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Namespace: "ns",
+			Name:      "b1",
+		},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, printBundle(&buf, bundle, "yaml"))
+	assert.Contains(t, buf.String(), "kind: Bundle\n")
+	// Bundle is not modified
+	assert.Empty(t, bundle.Kind)
+
+	buf.Reset()
+	require.NoError(t, printBundle(&buf, bundle, "json"))
+	assert.Contains(t, buf.String(), `"kind": "Bundle"`)
+
+	assert.EqualError(t, printBundle(&buf, bundle, "wide"), `unsupported output format "wide"`)
+	assert.Error(t, printBundle(&buf, bundle, "jsonpath={.status"))
+}
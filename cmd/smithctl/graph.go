@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/atlassian/smith/pkg/controller/bundlec"
+	"github.com/atlassian/smith/pkg/util/graph"
+	"github.com/pkg/errors"
+	k8s_json "k8s.io/apimachinery/pkg/util/json"
+)
+
+// graphCmd prints the dependency graph of a Bundle, one resource per line with the resources it depends on, or in the
+// Graphviz DOT format with resources colored by state.
+// Usage: smithctl graph [-namespace <namespace>] [-output dot] <bundle>
+// or: smithctl graph [-output dot] -f <bundle file>
+func graphCmd(args []string) error {
+	fs := flag.NewFlagSet("graph", flag.ContinueOnError)
+	file := fs.String("f", "", "File with the Bundle, - for stdin. The Bundle is read from the cluster if empty")
+	output := fs.String("output", "text", "Format to print the graph in (text or dot)")
+	bf := addBundleFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	var bundle *smith_v1.Bundle
+	if *file != "" {
+		if fs.NArg() != 0 {
+			return errors.New("Bundle name must not be specified together with -f")
+		}
+		data, err := readBundleFile(*file)
+		if err != nil {
+			return err
+		}
+		bundle = &smith_v1.Bundle{}
+		if err = k8s_json.Unmarshal(data, bundle); err != nil {
+			return errors.Wrap(err, "failed to unmarshal Bundle")
+		}
+	} else {
+		name, err := bundleArg(fs)
+		if err != nil {
+			return err
+		}
+		restConfig, namespace, err := bf.loadConfig()
+		if err != nil {
+			return err
+		}
+		bundle, err = getBundle(restConfig, namespace, name)
+		if err != nil {
+			return err
+		}
+	}
+	switch *output {
+	case "text":
+		return printGraph(os.Stdout, bundle)
+	case "dot":
+		return bundlec.WriteBundleDOT(os.Stdout, bundle)
+	default:
+		return errors.Errorf("unsupported output format %q", *output)
+	}
+}
+
+// printGraph prints resources in the order they are declared. An error is returned if dependencies form a cycle
+// or point at resources that are not in the Bundle.
+func printGraph(w io.Writer, bundle *smith_v1.Bundle) error {
+	g := graph.NewGraph(len(bundle.Spec.Resources))
+	for _, res := range bundle.Spec.Resources {
+		g.AddVertex(graph.V(res.Name), nil)
+	}
+	for i := range bundle.Spec.Resources {
+		res := &bundle.Spec.Resources[i]
+		deps := resourceDependencies(res)
+		names := make([]string, 0, len(deps))
+		for _, dep := range deps {
+			if err := g.AddEdge(res.Name, dep); err != nil {
+				return errors.Wrapf(err, "resource %q has an invalid dependency", res.Name)
+			}
+			names = append(names, string(dep))
+		}
+		if len(names) == 0 {
+			fmt.Fprintf(w, "%s\n", res.Name)
+		} else {
+			fmt.Fprintf(w, "%s -> %s\n", res.Name, strings.Join(names, ", "))
+		}
+	}
+	if _, err := g.TopologicalSort(); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/atlassian/smith/pkg/client/clientset_generated/clientset"
+	"github.com/atlassian/smith/pkg/client/smart"
+	"github.com/atlassian/smith/pkg/helmimport"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// importHelm converts a rendered Helm release manifest into a Bundle.
+// Usage: helm get manifest <release> | smithctl import-helm -name <bundle> -namespace <namespace>
+func importHelm(args []string) error {
+	fs := flag.NewFlagSet("import-helm", flag.ContinueOnError)
+	name := fs.String("name", "", "Name of the Bundle")
+	namespace := fs.String("namespace", "", "Namespace of the release and the Bundle")
+	file := fs.String("f", "-", "File with the manifest of the release, - for stdin")
+	output := fs.String("output", "yaml", "Format to print the Bundle in (json or yaml)")
+	create := fs.Bool("create", false, "Create the Bundle")
+	adopt := fs.Bool("adopt", false, "Make the Bundle the controller of existing objects of the release. Implies -create")
+	kubeconfig := fs.String("kubeconfig", "", "Path to the kubeconfig file, the default loading rules apply if empty")
+	readyChecked := fs.String("ready-checked-kinds", "", "Comma separated list of kinds with a readiness check "+
+		"in addition to the built-in ones, in Kind.group format. Objects of other kinds are ready as soon as they exist")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var manifest []byte
+	var err error
+	if *file == "-" {
+		manifest, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		manifest, err = ioutil.ReadFile(*file)
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to read manifest")
+	}
+	var kinds []schema.GroupKind
+	for _, kind := range strings.Split(*readyChecked, ",") {
+		if kind = strings.TrimSpace(kind); kind != "" {
+			kinds = append(kinds, schema.ParseGroupKind(kind))
+		}
+	}
+	result, err := helmimport.Convert(manifest, helmimport.Options{
+		BundleName:        *name,
+		Namespace:         *namespace,
+		ReadyCheckedKinds: kinds,
+	})
+	if err != nil {
+		return err
+	}
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+
+	if *create || *adopt {
+		return createBundle(*kubeconfig, result, *adopt)
+	}
+
+	switch *output {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		if err = enc.Encode(result.Bundle); err != nil {
+			return errors.Wrap(err, "failed to marshal Bundle into JSON")
+		}
+	case "yaml":
+		data, err := yaml.Marshal(result.Bundle)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal Bundle into YAML")
+		}
+		if _, err = os.Stdout.Write(data); err != nil {
+			return errors.Wrap(err, "failed to write Bundle YAML to stdout")
+		}
+	default:
+		return errors.Errorf("unsupported output format %q", *output)
+	}
+	return nil
+}
+
+// createBundle creates the Bundle and optionally adopts existing objects.
+// Objects must be adopted after the Bundle is created because owner references need its UID. Until then Smith
+// reports the Bundle as not ready because it refuses to update objects it does not control.
+func createBundle(kubeconfig string, result *helmimport.Result, adopt bool) error {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = kubeconfig
+	restConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return errors.Wrap(err, "failed to load kubeconfig")
+	}
+	smithClient, err := clientset.NewForConfig(restConfig)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	bundle, err := smithClient.SmithV1().Bundles(result.Bundle.Namespace).Create(result.Bundle)
+	if err != nil {
+		return errors.Wrap(err, "failed to create Bundle")
+	}
+	fmt.Fprintf(os.Stderr, "created Bundle %s/%s\n", bundle.Namespace, bundle.Name)
+	if !adopt {
+		return nil
+	}
+
+	mainClient, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	rm := discovery.NewDeferredDiscoveryRESTMapper(
+		&smart.CachedDiscoveryClient{
+			DiscoveryInterface: mainClient.Discovery(),
+		},
+		meta.InterfacesForUnstructured,
+	)
+	smartClient := &smart.DynamicClient{
+		ClientPool: dynamic.NewClientPool(restConfig, rm, dynamic.LegacyAPIPathResolverFunc),
+		Mapper:     rm,
+	}
+	adopted, err := helmimport.Adopt(smartClient, bundle, result.Objects)
+	for _, resName := range adopted {
+		fmt.Fprintf(os.Stderr, "adopted %s\n", resName)
+	}
+	return err
+}
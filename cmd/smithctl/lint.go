@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/atlassian/smith/pkg/validate"
+	"github.com/pkg/errors"
+	"k8s.io/client-go/discovery"
+)
+
+// lint checks a Bundle manifest like validate does and also checks that kinds of objects and external objects are
+// served by the cluster, using API discovery. Nothing is created in the cluster.
+// Usage: smithctl lint [-warnings-as-errors] [-check-kinds=false] [-kubeconfig <file>] -f <bundle file>
+func lint(args []string) error {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	file := fs.String("f", "", "File with the Bundle, - for stdin")
+	warningsAsErrors := fs.Bool("warnings-as-errors", false, "Treat warnings as errors")
+	checkKinds := fs.Bool("check-kinds", true, "Check that kinds of objects are served by the cluster")
+	kubeconfig := fs.String("kubeconfig", "", "Path to the kubeconfig file, the default loading rules apply if empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("-f must be specified")
+	}
+	linter := &validate.Linter{}
+	if *checkKinds {
+		restConfig, _, err := bundleFlags{namespace: new(string), kubeconfig: kubeconfig}.loadConfig()
+		if err != nil {
+			return err
+		}
+		discoveryClient, err := discovery.NewDiscoveryClientForConfig(restConfig)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		linter.Kinds = validate.NewDiscoveryKinds(discoveryClient)
+	}
+	return lintFile(linter, *file, *warningsAsErrors)
+}
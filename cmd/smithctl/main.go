@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// command runs a subcommand with its arguments.
+type command func(args []string) error
+
+var commands = map[string]command{
+	"diff":           diff,
+	"get":            get,
+	"graph":          graphCmd,
+	"import-helm":    importHelm,
+	"lint":           lint,
+	"migrate-bundle": migrateBundle,
+	"orphans":        orphans,
+	"revisions":      revisions,
+	"status":         status,
+	"test-readiness": testReadiness,
+	"validate":       validateCmd,
+}
+
+func main() {
+	if err := innerMain(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%#v\n", err)
+		os.Exit(1)
+	}
+}
+
+func innerMain(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: smithctl <command> [flags], commands: diff, get, graph, import-helm, lint, migrate-bundle, orphans, revisions, status, test-readiness, validate")
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return errors.Errorf("unknown command %q", args[0])
+	}
+	return cmd(args[1:])
+}
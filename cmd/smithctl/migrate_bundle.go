@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/atlassian/smith/pkg/client/clientset_generated/clientset"
+	"github.com/atlassian/smith/pkg/migration"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// migrateBundle rewrites Bundle specs so that objects are declared at the versions migration rules convert them to.
+// Usage: smithctl migrate-bundle -rules <file> -f <bundle file>
+// or: smithctl migrate-bundle -rules <file> -namespace <namespace> -update
+func migrateBundle(args []string) error {
+	fs := flag.NewFlagSet("migrate-bundle", flag.ContinueOnError)
+	rulesFile := fs.String("rules", "", "File with migration rules, the same file the controller is configured with")
+	file := fs.String("f", "", "File with the Bundle, - for stdin. Bundles in the cluster are migrated if empty")
+	output := fs.String("output", "yaml", "Format to print the migrated Bundle in (json or yaml)")
+	namespace := fs.String("namespace", "", "Namespace of Bundles to migrate in the cluster, all namespaces if empty")
+	update := fs.Bool("update", false, "Update Bundles in the cluster. Only migrations are printed otherwise")
+	kubeconfig := fs.String("kubeconfig", "", "Path to the kubeconfig file, the default loading rules apply if empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *rulesFile == "" {
+		return errors.New("-rules must be specified")
+	}
+	data, err := ioutil.ReadFile(*rulesFile)
+	if err != nil {
+		return errors.Wrap(err, "failed to read migration rules")
+	}
+	rules, err := migration.Load(data)
+	if err != nil {
+		return err
+	}
+	if *file == "" {
+		return migrateClusterBundles(*kubeconfig, *namespace, rules, *update)
+	}
+
+	if *file == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(*file)
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to read Bundle")
+	}
+	var bundle smith_v1.Bundle
+	if err = yaml.Unmarshal(data, &bundle); err != nil {
+		return errors.Wrap(err, "failed to unmarshal Bundle")
+	}
+	migrated, migrations, err := rules.MigrateBundle(&bundle)
+	if err != nil {
+		return err
+	}
+	printMigrations(&bundle, migrations)
+
+	switch *output {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		if err = enc.Encode(migrated); err != nil {
+			return errors.Wrap(err, "failed to marshal Bundle into JSON")
+		}
+	case "yaml":
+		data, err := yaml.Marshal(migrated)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal Bundle into YAML")
+		}
+		if _, err = os.Stdout.Write(data); err != nil {
+			return errors.Wrap(err, "failed to write Bundle YAML to stdout")
+		}
+	default:
+		return errors.Errorf("unsupported output format %q", *output)
+	}
+	return nil
+}
+
+// migrateClusterBundles migrates Bundles in the cluster. Failure to update one Bundle does not prevent updates
+// of others, the first error is returned.
+func migrateClusterBundles(kubeconfig, namespace string, rules *migration.Rules, update bool) error {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = kubeconfig
+	restConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return errors.Wrap(err, "failed to load kubeconfig")
+	}
+	smithClient, err := clientset.NewForConfig(restConfig)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	bundles, err := smithClient.SmithV1().Bundles(namespace).List(meta_v1.ListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "failed to list Bundles")
+	}
+	var firstErr error
+	for i := range bundles.Items {
+		bundle := &bundles.Items[i]
+		migrated, migrations, err := rules.MigrateBundle(bundle)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to migrate Bundle %s/%s: %v\n", bundle.Namespace, bundle.Name, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		printMigrations(bundle, migrations)
+		if !update || len(migrations) == 0 {
+			continue
+		}
+		// Update fails on conflict if the Bundle has been changed since it was listed
+		if _, err = smithClient.SmithV1().Bundles(migrated.Namespace).Update(migrated); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to update Bundle %s/%s: %v\n", bundle.Namespace, bundle.Name, err)
+			if firstErr == nil {
+				firstErr = errors.Wrapf(err, "failed to update Bundle %s/%s", bundle.Namespace, bundle.Name)
+			}
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "updated Bundle %s/%s\n", bundle.Namespace, bundle.Name)
+	}
+	return firstErr
+}
+
+func printMigrations(bundle *smith_v1.Bundle, migrations []migration.Migration) {
+	for _, m := range migrations {
+		fmt.Fprintf(os.Stderr, "Bundle %s/%s: resource %q migrated from %s to %s\n",
+			bundle.Namespace, bundle.Name, m.Resource, m.From.GroupVersion(), m.To.GroupVersion())
+	}
+}
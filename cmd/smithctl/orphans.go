@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/atlassian/smith/pkg/controller/bundlec"
+	"github.com/pkg/errors"
+)
+
+// orphans runs an orphan audit on a running controller and prints objects of Bundles that do not exist or do not
+// define them anymore. Bundles that do not define their objects anymore are queued for processing, which prunes them.
+// Returns an error if orphans were left behind, so that it can be used in scripts.
+// Usage: smithctl orphans [-url http://localhost:9090] [-delete]
+func orphans(args []string) error {
+	fs := flag.NewFlagSet("orphans", flag.ContinueOnError)
+	url := fs.String("url", "http://localhost:9090", "Address of the debug endpoints of the controller, see -debug-listen-on")
+	deleteOrphans := fs.Bool("delete", false, "Delete objects of Bundles that do not exist anymore")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	endpoint := strings.TrimSuffix(*url, "/") + "/debug/orphans"
+	if *deleteOrphans {
+		endpoint += "?delete=true"
+	}
+	resp, err := http.Post(endpoint, "", nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to run orphan audit")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return errors.Errorf("orphan audit failed with %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	var report bundlec.OrphanReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return errors.Wrap(err, "failed to decode orphan report")
+	}
+	left := printOrphans(os.Stdout, &report)
+	if left > 0 {
+		return errors.Errorf("%d orphaned object(s) left", left)
+	}
+	return nil
+}
+
+// printOrphans prints the report and returns the number of orphans that were not deleted or queued for pruning.
+func printOrphans(w io.Writer, report *bundlec.OrphanReport) int {
+	left := 0
+	for _, orphan := range report.Orphans {
+		var outcome string
+		switch {
+		case orphan.Deleted:
+			outcome = "deleted"
+		case orphan.Queued:
+			outcome = "Bundle queued for pruning"
+		case orphan.Error != "":
+			outcome = "failed to delete: " + orphan.Error
+			left++
+		default:
+			outcome = "left"
+			left++
+		}
+		kind := orphan.Kind
+		if orphan.Group != "" {
+			kind += "." + orphan.Group
+		}
+		fmt.Fprintf(w, "%s %s/%s: %s (Bundle %s/%s), %s\n", kind, orphan.Namespace, orphan.Name, orphan.Problem,
+			orphan.BundleNamespace, orphan.Bundle, outcome)
+	}
+	fmt.Fprintf(w, "Audited %d object(s), found %d orphan(s)\n", report.Objects, len(report.Orphans))
+	return left
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/atlassian/smith/pkg/client/clientset_generated/clientset"
+	"github.com/atlassian/smith/pkg/speccheck"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	k8s_json "k8s.io/apimachinery/pkg/util/json"
+)
+
+// revisions lists BundleRevisions of a Bundle, the most recent first. With -diff it prints paths of fields that
+// differ between the spec of a revision and the current spec of the Bundle, or the spec of the -to revision, together
+// with a patch that turns the former into the latter. Resources are matched by name.
+// Usage: smithctl revisions [-namespace <namespace>] [-diff <revision> [-to <revision>]] <bundle>
+func revisions(args []string) error {
+	fs := flag.NewFlagSet("revisions", flag.ContinueOnError)
+	flags := addBundleFlags(fs)
+	from := fs.Int64("diff", 0, "Revision to compare with the current spec of the Bundle")
+	to := fs.Int64("to", 0, "Revision to compare the -diff revision with instead of the current spec. Used with -diff")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	name, err := bundleArg(fs)
+	if err != nil {
+		return err
+	}
+	if *to != 0 && *from == 0 {
+		return errors.New("-diff must be specified if -to is specified")
+	}
+	restConfig, namespace, err := flags.loadConfig()
+	if err != nil {
+		return err
+	}
+	bundle, err := getBundle(restConfig, namespace, name)
+	if err != nil {
+		return err
+	}
+	smithClient, err := clientset.NewForConfig(restConfig)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	list, err := smithClient.SmithV1().BundleRevisions(namespace).List(meta_v1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{smith.RevisionOfLabel: smith.LabelValue(name)}).String(),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to list BundleRevisions")
+	}
+	revs := controlledRevisions(bundle, list.Items)
+	if *from == 0 {
+		printRevisions(os.Stdout, bundle, revs)
+		return nil
+	}
+	fromSpec, err := revisionSpec(revs, *from)
+	if err != nil {
+		return err
+	}
+	toSpec := &bundle.Spec
+	if *to != 0 {
+		toSpec, err = revisionSpec(revs, *to)
+		if err != nil {
+			return err
+		}
+	}
+	return printSpecDiff(os.Stdout, fromSpec, toSpec)
+}
+
+// controlledRevisions returns revisions controlled by the Bundle, the most recent first.
+func controlledRevisions(bundle *smith_v1.Bundle, items []smith_v1.BundleRevision) []smith_v1.BundleRevision {
+	revs := make([]smith_v1.BundleRevision, 0, len(items))
+	for _, rev := range items {
+		if controller := meta_v1.GetControllerOf(&rev); controller != nil && controller.UID == bundle.UID {
+			revs = append(revs, rev)
+		}
+	}
+	sort.Slice(revs, func(i, j int) bool {
+		return revs[i].Revision > revs[j].Revision
+	})
+	return revs
+}
+
+func revisionSpec(revs []smith_v1.BundleRevision, number int64) (*smith_v1.BundleSpec, error) {
+	for i := range revs {
+		if revs[i].Revision == number {
+			return &revs[i].Spec, nil
+		}
+	}
+	return nil, errors.Errorf("revision %d not found", number)
+}
+
+func printRevisions(w io.Writer, bundle *smith_v1.Bundle, revs []smith_v1.BundleRevision) {
+	if len(revs) == 0 {
+		fmt.Fprintf(w, "Bundle %q has no revisions\n", bundle.Name)
+		return
+	}
+	for _, rev := range revs {
+		var notes []string
+		if rev.Revision == bundle.Generation {
+			notes = append(notes, "current")
+		}
+		if last := bundle.Status.LastReadyRevision; last != nil && last.Generation == rev.Revision {
+			notes = append(notes, "last ready")
+		}
+		line := fmt.Sprintf("%d: %s, %d resource(s), created %s", rev.Revision, rev.Hash, len(rev.Spec.Resources),
+			rev.CreationTimestamp.UTC().Format("2006-01-02T15:04:05Z"))
+		if len(notes) > 0 {
+			line += " (" + strings.Join(notes, ", ") + ")"
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// printSpecDiff prints paths of fields that differ between the specs and a merge patch that turns from into to.
+func printSpecDiff(w io.Writer, from, to *smith_v1.BundleSpec) error {
+	fromObj, err := specByResourceName(from)
+	if err != nil {
+		return err
+	}
+	toObj, err := specByResourceName(to)
+	if err != nil {
+		return err
+	}
+	drift := speccheck.ComputeDrift(fromObj, toObj)
+	if len(drift.Fields) == 0 {
+		fmt.Fprintln(w, "Specs are identical")
+		return nil
+	}
+	for _, field := range drift.Fields {
+		fmt.Fprintf(w, "%s\n", field)
+	}
+	patch, err := yaml.Marshal(drift.Patch)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal patch into YAML")
+	}
+	fmt.Fprintf(w, "patch:\n")
+	for _, line := range strings.Split(strings.TrimSuffix(string(patch), "\n"), "\n") {
+		fmt.Fprintf(w, "  %s\n", line)
+	}
+	return nil
+}
+
+// specByResourceName converts the spec into an object where resources are keyed by their names rather than listed,
+// so that resources are compared by name regardless of their order.
+func specByResourceName(spec *smith_v1.BundleSpec) (*unstructured.Unstructured, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal Bundle spec")
+	}
+	var obj map[string]interface{}
+	if err = k8s_json.Unmarshal(data, &obj); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal Bundle spec")
+	}
+	list, _ := obj["resources"].([]interface{})
+	resources := make(map[string]interface{}, len(list))
+	for _, item := range list {
+		res, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := res["name"].(string)
+		delete(res, "name")
+		resources[name] = res
+	}
+	obj["resources"] = resources
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": obj,
+		},
+	}, nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+)
+
+// status prints readiness of a Bundle and its resources as a tree. Resources nothing depends on are at the top
+// with their dependencies below them, so that it is easy to see what blocks a resource.
+// Usage: smithctl status [-namespace <namespace>] <bundle>
+func status(args []string) error {
+	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	bf := addBundleFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	name, err := bundleArg(fs)
+	if err != nil {
+		return err
+	}
+	restConfig, namespace, err := bf.loadConfig()
+	if err != nil {
+		return err
+	}
+	bundle, err := getBundle(restConfig, namespace, name)
+	if err != nil {
+		return err
+	}
+	printStatus(os.Stdout, bundle)
+	return nil
+}
+
+func printStatus(w io.Writer, bundle *smith_v1.Bundle) {
+	ready := bundle.Status.Ready
+	if ready == "" {
+		ready = smith_v1.ConditionUnknown
+	}
+	fmt.Fprintf(w, "Bundle %s/%s: Ready=%s", bundle.Namespace, bundle.Name, ready)
+	if bundle.Status.Resources != "" {
+		fmt.Fprintf(w, ", %s resources ready", bundle.Status.Resources)
+	}
+	if bundle.Status.ObservedGeneration != bundle.Generation {
+		fmt.Fprintf(w, ", generation %d not observed yet", bundle.Generation)
+	}
+	fmt.Fprintln(w)
+	if bundle.Status.Summary != "" {
+		fmt.Fprintf(w, "%s\n", bundle.Status.Summary)
+	}
+	if retry := bundle.Status.Retry; retry != nil {
+		fmt.Fprintf(w, "Failed %d time(s) in a row, next retry at %s\n", retry.Attempts, retry.NextRetryTime.Format(time.RFC3339))
+	}
+	for _, warning := range bundle.Status.Warnings {
+		fmt.Fprintf(w, "Warning: %s\n", warning.String())
+	}
+
+	resources := make(map[smith_v1.ResourceName]*smith_v1.Resource, len(bundle.Spec.Resources))
+	dependedOn := make(map[smith_v1.ResourceName]struct{})
+	for i := range bundle.Spec.Resources {
+		res := &bundle.Spec.Resources[i]
+		resources[res.Name] = res
+		for _, dep := range resourceDependencies(res) {
+			dependedOn[dep] = struct{}{}
+		}
+	}
+	var roots []smith_v1.ResourceName
+	for _, res := range bundle.Spec.Resources {
+		if _, ok := dependedOn[res.Name]; !ok {
+			roots = append(roots, res.Name)
+		}
+	}
+	if len(roots) == 0 && len(bundle.Spec.Resources) > 0 {
+		// Every resource is depended on, i.e. there is a cycle. Print all resources so that nothing is hidden.
+		for _, res := range bundle.Spec.Resources {
+			roots = append(roots, res.Name)
+		}
+	}
+	p := statusPrinter{
+		w:         w,
+		bundle:    bundle,
+		resources: resources,
+		path:      make(map[smith_v1.ResourceName]struct{}),
+	}
+	for i, root := range roots {
+		p.print(root, "", i == len(roots)-1)
+	}
+}
+
+type statusPrinter struct {
+	w         io.Writer
+	bundle    *smith_v1.Bundle
+	resources map[smith_v1.ResourceName]*smith_v1.Resource
+	// path is the set of resources between the root and the resource being printed.
+	path map[smith_v1.ResourceName]struct{}
+}
+
+func (p *statusPrinter) print(resName smith_v1.ResourceName, prefix string, last bool) {
+	branch, indent := "├── ", "│   "
+	if last {
+		branch, indent = "└── ", "    "
+	}
+	fmt.Fprintf(p.w, "%s%s%s\n", prefix, branch, p.describe(resName))
+	if _, ok := p.path[resName]; ok {
+		fmt.Fprintf(p.w, "%s%s└── (cycle)\n", prefix, indent)
+		return
+	}
+	res, ok := p.resources[resName]
+	if !ok {
+		return
+	}
+	p.path[resName] = struct{}{}
+	defer delete(p.path, resName)
+	deps := resourceDependencies(res)
+	for i, dep := range deps {
+		p.print(dep, prefix+indent, i == len(deps)-1)
+	}
+}
+
+func (p *statusPrinter) describe(resName smith_v1.ResourceName) string {
+	if _, ok := p.resources[resName]; !ok {
+		return fmt.Sprintf("%s [not in Bundle]", resName)
+	}
+	_, resStatus := p.bundle.Status.GetResourceStatus(resName)
+	if resStatus == nil {
+		return fmt.Sprintf("%s [%s]", resName, smith_v1.ResourceStateUnknown)
+	}
+	state := resStatus.State
+	if state == "" {
+		state = smith_v1.ResourceStateUnknown
+	}
+	if resStatus.Message == "" {
+		return fmt.Sprintf("%s [%s]", resName, state)
+	}
+	return fmt.Sprintf("%s [%s] %s", resName, state, resStatus.Message)
+}
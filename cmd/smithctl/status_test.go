@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/stretchr/testify/assert"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func statusResource(name smith_v1.ResourceName, dependencies ...smith_v1.ResourceName) smith_v1.Resource {
+	res := smith_v1.Resource{
+		Name: name,
+	}
+	for _, dep := range dependencies {
+		res.References = append(res.References, smith_v1.Reference{Resource: dep})
+	}
+	return res
+}
+
+func TestPrintStatus(t *testing.T) {
+	t.Parallel()
+	testcases := map[string]struct {
+		bundle         smith_v1.Bundle
+		expectedOutput string
+	}{
+		"tree": {
+			bundle: smith_v1.Bundle{
+				Spec: smith_v1.BundleSpec{
+					Resources: []smith_v1.Resource{
+						statusResource("a", "b"),
+						statusResource("b", "c"),
+						statusResource("c"),
+						statusResource("d"),
+					},
+				},
+				Status: smith_v1.BundleStatus{
+					Ready:     smith_v1.ConditionFalse,
+					Resources: "1/4",
+					ResourceStatuses: []smith_v1.ResourceStatus{
+						{Name: "a", State: smith_v1.ResourceStateBlocked},
+						{Name: "b", State: smith_v1.ResourceStateInProgress, Message: "waiting"},
+						{Name: "c", State: smith_v1.ResourceStateReady},
+					},
+				},
+			},
+			expectedOutput: "Bundle ns/b1: Ready=False, 1/4 resources ready\n" +
+				"├── a [Blocked]\n" +
+				"│   └── b [InProgress] waiting\n" +
+				"│       └── c [Ready]\n" +
+				"└── d [Unknown]\n",
+		},
+		"cycle": {
+			bundle: smith_v1.Bundle{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Generation: 2,
+				},
+				Spec: smith_v1.BundleSpec{
+					Resources: []smith_v1.Resource{
+						statusResource("a", "b"),
+						statusResource("b", "a"),
+					},
+				},
+				Status: smith_v1.BundleStatus{
+					ObservedGeneration: 1,
+				},
+			},
+			expectedOutput: "Bundle ns/b1: Ready=Unknown, generation 2 not observed yet\n" +
+				"├── a [Unknown]\n" +
+				"│   └── b [Unknown]\n" +
+				"│       └── a [Unknown]\n" +
+				"│           └── (cycle)\n" +
+				"└── b [Unknown]\n" +
+				"    └── a [Unknown]\n" +
+				"        └── b [Unknown]\n" +
+				"            └── (cycle)\n",
+		},
+		"dependency not in Bundle": {
+			bundle: smith_v1.Bundle{
+				Spec: smith_v1.BundleSpec{
+					Resources: []smith_v1.Resource{
+						statusResource("a", "x"),
+					},
+				},
+				Status: smith_v1.BundleStatus{
+					Ready:   smith_v1.ConditionFalse,
+					Summary: "1 resource(s) failed",
+					ResourceStatuses: []smith_v1.ResourceStatus{
+						{Name: "a", State: smith_v1.ResourceStateError, Message: "boom"},
+					},
+				},
+			},
+			expectedOutput: "Bundle ns/b1: Ready=False\n" +
+				"1 resource(s) failed\n" +
+				"└── a [Error] boom\n" +
+				"    └── x [not in Bundle]\n",
+		},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			tc.bundle.Namespace = "ns"
+			tc.bundle.Name = "b1"
+			var buf bytes.Buffer
+			printStatus(&buf, &tc.bundle)
+			assert.Equal(t, tc.expectedOutput, buf.String())
+		})
+	}
+}
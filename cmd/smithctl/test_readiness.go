@@ -0,0 +1,127 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/atlassian/smith/pkg/readychecker"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	apiext_v1b1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	apiExtClientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// testReadiness runs test cases of readiness rules.
+// Usage: smithctl test-readiness -f <rules file or CRD manifest>
+// or: smithctl test-readiness -crd <name>
+func testReadiness(args []string) error {
+	fs := flag.NewFlagSet("test-readiness", flag.ContinueOnError)
+	file := fs.String("f", "", "File with a list of readiness rules or a CRD manifest, - for stdin")
+	crdName := fs.String("crd", "", "Name of a CRD in the cluster to test the readiness rule of")
+	kubeconfig := fs.String("kubeconfig", "", "Path to the kubeconfig file, the default loading rules apply if empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	var rules []readychecker.Rule
+	var err error
+	switch {
+	case *file != "" && *crdName != "":
+		return errors.New("only one of -f and -crd may be specified")
+	case *file != "":
+		rules, err = readRules(*file)
+	case *crdName != "":
+		rules, err = clusterCrdRules(*kubeconfig, *crdName)
+	default:
+		return errors.New("-f or -crd must be specified")
+	}
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for i := range rules {
+		rule := &rules[i]
+		results, err := rule.Run()
+		if err != nil {
+			return err
+		}
+		if len(results) == 0 {
+			fmt.Fprintf(os.Stderr, "warning: rule %q has no test cases\n", rule.Name)
+		}
+		for _, result := range results {
+			switch {
+			case result.Err != nil:
+				failed++
+				fmt.Printf("FAIL %s/%s: %v\n", rule.Name, result.Case, result.Err)
+			case !result.Passed:
+				failed++
+				fmt.Printf("FAIL %s/%s: ready is %t\n", rule.Name, result.Case, result.Ready)
+			default:
+				fmt.Printf("PASS %s/%s\n", rule.Name, result.Case)
+			}
+		}
+	}
+	if failed > 0 {
+		return errors.Errorf("%d test case(s) failed", failed)
+	}
+	return nil
+}
+
+func readRules(file string) ([]readychecker.Rule, error) {
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(file)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read rules")
+	}
+	var meta meta_v1.TypeMeta
+	if err = yaml.Unmarshal(data, &meta); err == nil && meta.Kind == "CustomResourceDefinition" {
+		var crd apiext_v1b1.CustomResourceDefinition
+		if err = yaml.Unmarshal(data, &crd); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal CRD")
+		}
+		return crdRules(&crd)
+	}
+	var rules []readychecker.Rule
+	if err = yaml.Unmarshal(data, &rules); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal rules")
+	}
+	return rules, nil
+}
+
+func clusterCrdRules(kubeconfig, name string) ([]readychecker.Rule, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = kubeconfig
+	restConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load kubeconfig")
+	}
+	apiExtClient, err := apiExtClientset.NewForConfig(restConfig)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	crd, err := apiExtClient.ApiextensionsV1beta1().CustomResourceDefinitions().Get(name, meta_v1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get CRD")
+	}
+	return crdRules(crd)
+}
+
+func crdRules(crd *apiext_v1b1.CustomResourceDefinition) ([]readychecker.Rule, error) {
+	rule, err := readychecker.RuleFromCrd(crd)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return nil, errors.Errorf("CRD %q does not declare a readiness rule", crd.Name)
+	}
+	return []readychecker.Rule{*rule}, nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/atlassian/smith/pkg/validate"
+	"github.com/pkg/errors"
+)
+
+// validateCmd checks a Bundle manifest the same way the admission webhooks do, i.e. defaults are applied first.
+// Warnings are printed but only fail validation with -warnings-as-errors. Readiness rules are not checked because
+// they depend on CRDs in the cluster.
+// Usage: smithctl validate [-warnings-as-errors] -f <bundle file>
+func validateCmd(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	file := fs.String("f", "", "File with the Bundle, - for stdin")
+	warningsAsErrors := fs.Bool("warnings-as-errors", false, "Treat warnings as errors")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("-f must be specified")
+	}
+	return lintFile(&validate.Linter{}, *file, *warningsAsErrors)
+}
+
+// lintFile lints the Bundle manifest in the file and prints errors and warnings.
+func lintFile(linter *validate.Linter, file string, warningsAsErrors bool) error {
+	data, err := readBundleFile(file)
+	if err != nil {
+		return err
+	}
+	result, err := linter.LintManifest(data)
+	if err != nil {
+		return err
+	}
+	bundle := result.Bundle
+	for _, e := range result.Errors {
+		fmt.Printf("%v\n", e)
+	}
+	if !result.Valid() {
+		return errors.Errorf("Bundle %q is invalid: %d error(s)", bundle.Name, len(result.Errors))
+	}
+	for _, warning := range result.Warnings {
+		fmt.Printf("Warning: %s\n", warning.String())
+	}
+	if len(result.Warnings) > 0 {
+		if warningsAsErrors {
+			return errors.Errorf("Bundle %q is valid but has %d warning(s)", bundle.Name, len(result.Warnings))
+		}
+		fmt.Printf("Bundle %q is valid with %d warning(s)\n", bundle.Name, len(result.Warnings))
+		return nil
+	}
+	fmt.Printf("Bundle %q is valid\n", bundle.Name)
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/ash2k/stager"
+	"github.com/atlassian/smith"
 	"github.com/atlassian/smith/examples/sleeper"
 	sleeper_v1 "github.com/atlassian/smith/examples/sleeper/pkg/apis/sleeper/v1"
 	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
@@ -84,6 +85,6 @@ func testCrdAttribute(ctxTest context.Context, t *testing.T, cfg *Config, args .
 		Do().
 		Into(&sleeperObj))
 
-	assert.Empty(t, sleeperObj.Labels)
+	assert.Equal(t, map[string]string{smith.BundleNameLabel: cfg.Bundle.Name}, sleeperObj.Labels)
 	assert.Equal(t, sleeper_v1.Awake, sleeperObj.Status.State)
 }
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/ash2k/stager"
+	"github.com/atlassian/smith"
 	"github.com/atlassian/smith/examples/sleeper"
 	sleeper_v1 "github.com/atlassian/smith/examples/sleeper/pkg/apis/sleeper/v1"
 	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
@@ -233,9 +234,10 @@ func testUpdate(ctxTest context.Context, t *testing.T, cfg *Config, args ...inte
 	cfMap, err := cmClient.Get(cm2.Name, meta_v1.GetOptions{})
 	require.NoError(t, err)
 	assert.Equal(t, map[string]string{
-		"configLabel":      "configValue",
-		"bundleLabel":      "bundleValue2",
-		"overlappingLabel": "overlappingConfigValue",
+		"configLabel":         "configValue",
+		"bundleLabel":         "bundleValue2",
+		"overlappingLabel":    "overlappingConfigValue",
+		smith.BundleNameLabel: bundle2.Name,
 	}, cfMap.Labels)
 	assert.Equal(t, cm2.Data, cfMap.Data)
 
@@ -254,9 +256,10 @@ func testUpdate(ctxTest context.Context, t *testing.T, cfg *Config, args ...inte
 		Into(&sleeperObj)
 	require.NoError(t, err)
 	assert.Equal(t, map[string]string{
-		"configLabel":      "configValue",
-		"bundleLabel":      "bundleValue2",
-		"overlappingLabel": "overlappingConfigValue",
+		"configLabel":         "configValue",
+		"bundleLabel":         "bundleValue2",
+		"overlappingLabel":    "overlappingConfigValue",
+		smith.BundleNameLabel: bundle2.Name,
 	}, sleeperObj.Labels)
 	assert.Equal(t, sleeper_v1.Awake, sleeperObj.Status.State)
 	assert.Equal(t, sleeper2.Spec, sleeperObj.Spec)
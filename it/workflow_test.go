@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/atlassian/smith"
 	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -97,9 +98,10 @@ func testWorkflow(ctx context.Context, t *testing.T, cfg *Config, args ...interf
 	cfMap, err := cfg.MainClient.CoreV1().ConfigMaps(cfg.Namespace).Get("config1", meta_v1.GetOptions{})
 	require.NoError(t, err)
 	assert.Equal(t, map[string]string{
-		"configLabel":      "configValue",
-		"bundleLabel":      "bundleValue",
-		"overlappingLabel": "overlappingConfigValue",
+		"configLabel":         "configValue",
+		"bundleLabel":         "bundleValue",
+		"overlappingLabel":    "overlappingConfigValue",
+		smith.BundleNameLabel: bundleRes.Name,
 	}, cfMap.GetLabels())
 
 	secret, err := cfg.MainClient.CoreV1().Secrets(cfg.Namespace).Get("secret1", meta_v1.GetOptions{})
@@ -0,0 +1,32 @@
+package smith
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	// maxLabelValueLength is the maximum length of a label value.
+	maxLabelValueLength = 63
+	// truncatedNameHashLength is the number of hex digits of the hash suffix of truncated names.
+	truncatedNameHashLength = 10
+)
+
+// LabelValue returns the value of a label that refers to an object by its name, e.g. the BundleNameLabel.
+// Names of most objects may be up to 253 characters long but label values are limited to 63 characters.
+// See TruncateName.
+func LabelValue(name string) string {
+	return TruncateName(name, maxLabelValueLength)
+}
+
+// TruncateName returns the name if it is not longer than maxLength. Longer names are truncated and suffixed with
+// a hash of the full name so that truncated names of different names are distinct.
+func TruncateName(name string, maxLength int) string {
+	if len(name) <= maxLength {
+		return name
+	}
+	sum := sha256.Sum256([]byte(name))
+	suffix := "-" + hex.EncodeToString(sum[:])[:truncatedNameHashLength]
+	return strings.TrimRight(name[:maxLength-len(suffix)], "-.") + suffix
+}
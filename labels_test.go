@@ -0,0 +1,40 @@
+package smith
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+func TestLabelValue(t *testing.T) {
+	t.Parallel()
+	long := strings.Repeat("a", 100)
+	testcases := map[string]struct {
+		name     string
+		expected string
+	}{
+		"short":        {name: "bundle1", expected: "bundle1"},
+		"max length":   {name: strings.Repeat("a", 63), expected: strings.Repeat("a", 63)},
+		"long":         {name: long},
+		"long dotted":  {name: strings.Repeat("a.", 100)},
+		"long dashed":  {name: strings.Repeat("a-", 100)},
+		"max dns name": {name: strings.Repeat("a", 253)},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			value := LabelValue(tc.name)
+			if tc.expected != "" {
+				assert.Equal(t, tc.expected, value)
+			}
+			assert.Empty(t, validation.IsValidLabelValue(value))
+		})
+	}
+
+	assert.Len(t, LabelValue(long), 63)
+	assert.True(t, strings.HasPrefix(LabelValue(long), strings.Repeat("a", 52)+"-"))
+	assert.NotEqual(t, LabelValue(long), LabelValue(long+"b"), "names with a common prefix must have distinct values")
+}
@@ -16,6 +16,11 @@ func Kind(kind string) schema.GroupKind {
 	return SchemeGroupVersion.WithKind(kind).GroupKind()
 }
 
+// Resource takes an unqualified resource and returns a Group qualified GroupResource.
+func Resource(resource string) schema.GroupResource {
+	return SchemeGroupVersion.WithResource(resource).GroupResource()
+}
+
 var (
 	// SchemeBuilder needs to be exported as `SchemeBuilder` so
 	// the code-generation can find it.
@@ -28,6 +33,10 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&Bundle{},
 		&BundleList{},
+		&BundleClass{},
+		&BundleClassList{},
+		&BundleRevision{},
+		&BundleRevisionList{},
 	)
 	meta_v1.AddToGroupVersion(scheme, SchemeGroupVersion)
 
@@ -0,0 +1,73 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// TestStatusJSONPaths guards JSONPath expressions that automation relies on, e.g. kubectl get -o jsonpath=...
+// Changing any of these paths is a breaking API change.
+func TestStatusJSONPaths(t *testing.T) {
+	t.Parallel()
+	bundle := Bundle{
+		Status: BundleStatus{
+			Conditions: []BundleCondition{
+				{Type: BundleInProgress, Status: ConditionFalse},
+				{Type: BundleReady, Status: ConditionFalse},
+				{Type: BundleError, Status: ConditionTrue, Reason: BundleReasonTerminalError},
+			},
+			ResourceStatuses: []ResourceStatus{
+				{
+					Name: "a",
+					Conditions: []ResourceCondition{
+						{Type: ResourceReady, Status: ConditionTrue},
+					},
+				},
+				{
+					Name: "b",
+					Conditions: []ResourceCondition{
+						{Type: ResourceReady, Status: ConditionFalse},
+						{Type: ResourceError, Status: ConditionTrue},
+					},
+					State:   ResourceStateError,
+					Message: "boom",
+				},
+			},
+			Ready:           ConditionFalse,
+			FailedResources: []ResourceName{"b"},
+			Outputs: map[string]string{
+				"host": "db.example.com",
+			},
+		},
+	}
+	data, err := json.Marshal(bundle)
+	require.NoError(t, err)
+	var obj interface{}
+	require.NoError(t, json.Unmarshal(data, &obj))
+
+	cases := []struct {
+		path     string
+		expected string
+	}{
+		{path: "{.status.ready}", expected: "False"},
+		{path: "{.status.failedResources[*]}", expected: "b"},
+		{path: `{.status.conditions[?(@.type=="Ready")].status}`, expected: "False"},
+		{path: `{.status.conditions[?(@.type=="Error")].reason}`, expected: "TerminalError"},
+		{path: `{.status.resourceStatuses[?(@.name=="a")].conditions[?(@.type=="Ready")].status}`, expected: "True"},
+		{path: `{.status.resourceStatuses[?(@.name=="b")].state}`, expected: "Error"},
+		{path: `{.status.resourceStatuses[?(@.name=="b")].message}`, expected: "boom"},
+		{path: "{.status.outputs.host}", expected: "db.example.com"},
+	}
+	for _, c := range cases {
+		j := jsonpath.New("test")
+		require.NoError(t, j.Parse(c.path), c.path)
+		var buf bytes.Buffer
+		require.NoError(t, j.Execute(&buf, obj), c.path)
+		assert.Equal(t, c.expected, buf.String(), c.path)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	k8s_json "k8s.io/apimachinery/pkg/util/json"
 )
 
@@ -18,11 +19,33 @@ const (
 	BundleInProgress BundleConditionType = "InProgress"
 	BundleReady      BundleConditionType = "Ready"
 	BundleError      BundleConditionType = "Error"
+	// BundleTimedOut is only set if the Bundle has a progress deadline.
+	BundleTimedOut BundleConditionType = "TimedOut"
+	// BundlePaused is only set if the Bundle is paused or has been paused before.
+	BundlePaused BundleConditionType = "Paused"
+	// BundlePruneDeferred is only set if deletion of objects removed from the Bundle has been deferred before.
+	BundlePruneDeferred BundleConditionType = "PruneDeferred"
 )
 
 const (
-	BundleReasonTerminalError  = "TerminalError"
-	BundleReasonRetriableError = "RetriableError"
+	BundleReasonTerminalError   = "TerminalError"
+	BundleReasonRetriableError  = "RetriableError"
+	BundleReasonPreflightFailed = "PreflightFailed"
+	// BundleReasonReferenceResolutionFailed means references of some resources could not be resolved and nothing was applied.
+	BundleReasonReferenceResolutionFailed = "ReferenceResolutionFailed"
+	// BundleReasonRetriesExhausted means processing failed with a retriable error too many times in a row.
+	BundleReasonRetriesExhausted = "RetriesExhausted"
+	// BundleReasonRetryNotAllowed means processing failed with a retriable error of a class that the retry policy of
+	// the Bundle does not allow to retry.
+	BundleReasonRetryNotAllowed = "RetryNotAllowed"
+
+	BundleReasonDeletionNotConfirmed = "DeletionNotConfirmed"
+	BundleReasonNamespaceTerminating = "NamespaceTerminating"
+
+	BundleReasonProgressDeadlineExceeded = "ProgressDeadlineExceeded"
+	// BundleReasonInformersNotSynced means objects removed from the Bundle were not deleted because informers of
+	// their kinds have not synced yet.
+	BundleReasonInformersNotSynced = "InformersNotSynced"
 )
 
 type ResourceConditionType string
@@ -39,11 +62,42 @@ const (
 	// Blocked condition reasons
 
 	ResourceReasonDependenciesNotReady = "DependenciesNotReady"
+	ResourceReasonMissingAPI           = "MissingAPI"
 
 	// Error condition reasons
 
 	ResourceReasonTerminalError  = "TerminalError"
 	ResourceReasonRetriableError = "RetriableError"
+	// ResourceReasonScopeMismatch means the kind of the object is cluster-scoped and cannot be managed by a Bundle.
+	ResourceReasonScopeMismatch = "ScopeMismatch"
+	// ResourceReasonReadinessTimeout means the object has not become ready within the readiness timeout
+	// of the resource.
+	ResourceReasonReadinessTimeout = "ReadinessTimeout"
+)
+
+// These are reasons of Bundle warnings.
+const (
+	// WarningReasonDeprecatedAPIVersion means the object is of an API version that is deprecated.
+	WarningReasonDeprecatedAPIVersion = "DeprecatedAPIVersion"
+	// WarningReasonUnusedDependency means a dependency is declared in dependsOn although it is implied
+	// by a reference, a quorum or another dependsOn entry.
+	WarningReasonUnusedDependency = "UnusedDependency"
+	// WarningReasonNoReadinessRule means there is no readiness rule for the kind of the object,
+	// so it is never considered ready.
+	WarningReasonNoReadinessRule = "NoReadinessRule"
+)
+
+// ResourceState summarizes conditions of a resource.
+type ResourceState string
+
+// These are valid states of a resource.
+const (
+	ResourceStateBlocked    ResourceState = "Blocked"
+	ResourceStateInProgress ResourceState = "InProgress"
+	ResourceStateReady      ResourceState = "Ready"
+	ResourceStateError      ResourceState = "Error"
+	// ResourceStateUnknown means the resource was not processed.
+	ResourceStateUnknown ResourceState = "Unknown"
 )
 
 type ConditionStatus string
@@ -71,8 +125,28 @@ const (
 	ReferenceModifierBindSecret = "bindsecret"
 )
 
+const (
+	BundleClassResourceSingular = "bundleclass"
+	BundleClassResourcePlural   = "bundleclasses"
+	BundleClassResourceKind     = "BundleClass"
+
+	BundleClassResourceName = BundleClassResourcePlural + "." + smith.GroupName
+)
+
 var BundleGVK = SchemeGroupVersion.WithKind(BundleResourceKind)
 
+var BundleClassGVK = SchemeGroupVersion.WithKind(BundleClassResourceKind)
+
+const (
+	BundleRevisionResourceSingular = "bundlerevision"
+	BundleRevisionResourcePlural   = "bundlerevisions"
+	BundleRevisionResourceKind     = "BundleRevision"
+
+	BundleRevisionResourceName = BundleRevisionResourcePlural + "." + smith.GroupName
+)
+
+var BundleRevisionGVK = SchemeGroupVersion.WithKind(BundleRevisionResourceKind)
+
 // +k8s:deepcopy-gen=true
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 type BundleList struct {
@@ -116,6 +190,106 @@ func (b *Bundle) GetCondition(conditionType BundleConditionType) (int, *BundleCo
 // +k8s:deepcopy-gen=true
 type BundleSpec struct {
 	Resources []Resource `json:"resources,omitempty"`
+	// IdentityPolicies are applied to all objects of matching API groups.
+	IdentityPolicies []IdentityPolicy `json:"identityPolicies,omitempty"`
+	// ProgressDeadlineSeconds is the number of seconds resources of the Bundle may stay not ready before the Bundle
+	// is considered timed out. Not set means no deadline.
+	ProgressDeadlineSeconds *int32 `json:"progressDeadlineSeconds,omitempty"`
+	// RetryPolicy customizes how processing of the Bundle is retried after it has failed with a retriable error.
+	// Not set means the configuration of the controller is used.
+	RetryPolicy *RetryPolicy `json:"retryPolicy,omitempty"`
+	// Parameters are substituted for references to them in strings of object and plugin specs of resources,
+	// e.g. ${params.environment}, so that Bundles of the same shape can differ in a few values.
+	Parameters map[string]string `json:"parameters,omitempty"`
+	// Outputs are values of fields of objects of the Bundle that are published in its status so that a Bundle
+	// that includes it as a resource can reference them.
+	Outputs []Output `json:"outputs,omitempty"`
+	// OutputsExport publishes the outputs of the Bundle in a ConfigMap or a Secret so that they can be read by
+	// consumers outside of the Bundle. Not set means outputs are only published in the status.
+	OutputsExport *OutputsExport `json:"outputsExport,omitempty"`
+	// MaxHistory is the number of BundleRevisions kept for the Bundle. Not set means the limit of the controller
+	// is used, zero disables revision history for the Bundle.
+	MaxHistory *int32 `json:"maxHistory,omitempty"`
+	// HistoryTTLSeconds is the number of seconds a BundleRevision is kept for after its spec last made the Bundle
+	// ready. The most recent BundleRevision is kept regardless. Not set means the TTL of the controller is used,
+	// zero means BundleRevisions do not expire.
+	HistoryTTLSeconds *int64 `json:"historyTTLSeconds,omitempty"`
+}
+
+// +k8s:deepcopy-gen=true
+// Output publishes the value of a field of the object of a resource in the status of the Bundle.
+type Output struct {
+	// Name is the key of the value in the outputs of the Bundle status.
+	Name string `json:"name"`
+	// Resource is the name of the resource whose object the value is taken from.
+	Resource ResourceName `json:"resource"`
+	// Path is a JSONPath to the field of the object, like the path of a reference, e.g. status.loadBalancer.
+	Path string `json:"path"`
+}
+
+// OutputsExportKind is the kind of the object outputs of a Bundle are exported to.
+type OutputsExportKind string
+
+// These are valid kinds of objects outputs are exported to.
+const (
+	OutputsExportConfigMap OutputsExportKind = "ConfigMap"
+	OutputsExportSecret    OutputsExportKind = "Secret"
+)
+
+// +k8s:deepcopy-gen=true
+// OutputsExport describes the object outputs of a Bundle are exported to. The object is created in the namespace
+// of the Bundle, it is controlled by the Bundle and its data is replaced with the outputs on every sync.
+type OutputsExport struct {
+	// Kind of the object, ConfigMap or Secret.
+	Kind OutputsExportKind `json:"kind"`
+	// Name of the object. Defaults to the name of the Bundle with the "-outputs" suffix.
+	Name string `json:"name,omitempty"`
+}
+
+// ObjectName returns the name of the object outputs of the Bundle with the name are exported to.
+func (in *OutputsExport) ObjectName(bundleName string) string {
+	if in.Name != "" {
+		return in.Name
+	}
+	return bundleName + "-outputs"
+}
+
+// +k8s:deepcopy-gen=true
+// RetryPolicy customizes retries of a Bundle. Fields that are not set default to the configuration of the controller.
+type RetryPolicy struct {
+	// MaxAttempts is the number of consecutive retries after which a retriable error is treated as terminal.
+	// Zero means there is no limit.
+	MaxAttempts *int32 `json:"maxAttempts,omitempty"`
+	// BaseDelaySeconds is the delay before the first retry. The delay doubles with every consecutive failure.
+	BaseDelaySeconds int32 `json:"baseDelaySeconds,omitempty"`
+	// MaxDelaySeconds caps the delay between retries.
+	MaxDelaySeconds int32 `json:"maxDelaySeconds,omitempty"`
+	// RetryOn is a list of classes of retriable errors that are retried. Errors of other classes are treated as
+	// terminal. Empty means errors of all classes are retried.
+	RetryOn []RetryClass `json:"retryOn,omitempty"`
+}
+
+// RetryClass is a class of retriable errors.
+type RetryClass string
+
+// These are valid retry classes.
+const (
+	// RetryClassResourceError is a failure to process an object of a resource, e.g. to create or update it.
+	RetryClassResourceError RetryClass = "ResourceError"
+	// RetryClassExternalReference is a failure to resolve a reference to an external secret store.
+	RetryClassExternalReference RetryClass = "ExternalReference"
+	// RetryClassPruning is a failure to delete objects that have been removed from the Bundle.
+	RetryClassPruning RetryClass = "Pruning"
+)
+
+// +k8s:deepcopy-gen=true
+// IdentityPolicy describes cloud identity annotations (IAM role, workload identity service account, etc)
+// that must be set consistently on all objects of particular API groups.
+type IdentityPolicy struct {
+	// Groups is a list of API groups of objects the policy applies to.
+	Groups []string `json:"groups"`
+	// Annotations to set on each object the policy applies to.
+	Annotations map[string]string `json:"annotations"`
 }
 
 // +k8s:deepcopy-gen=true
@@ -151,10 +325,88 @@ func (bc *BundleCondition) String() string {
 
 // +k8s:deepcopy-gen=true
 // BundleStatus represents the latest available observations of a Bundle's current state.
+// Paths of the Ready and FailedResources fields are part of the API contract so that automation can query them with
+// simple JSONPath expressions like {.status.ready} and {.status.failedResources[*]}.
 type BundleStatus struct {
 	Conditions       []BundleCondition `json:"conditions,omitempty"`
 	ResourceStatuses []ResourceStatus  `json:"resourceStatuses,omitempty"`
 	ObjectsToDelete  []ObjectToDelete  `json:"objectsToDelete,omitempty"`
+	// Ready mirrors the status of the Ready condition.
+	Ready ConditionStatus `json:"ready,omitempty"`
+	// FailedResources is a list of resources that are in the Error state.
+	FailedResources []ResourceName `json:"failedResources,omitempty"`
+	// Resources is the number of ready resources out of the total number of resources, e.g. "2/3".
+	Resources string `json:"resources,omitempty"`
+	// Summary is a short human readable description of the state of the Bundle.
+	Summary string `json:"summary,omitempty"`
+	// ObservedGeneration is the generation of the Bundle spec the conditions refer to. Conditions are stale
+	// if it is less than the generation of the Bundle.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// ValidatedGeneration is the most recent generation of the Bundle spec that passed pre-flight checks.
+	ValidatedGeneration int64 `json:"validatedGeneration,omitempty"`
+	// DeletionReport lists objects affected by deletion of the Bundle. Set once the Bundle is marked for deletion.
+	DeletionReport *DeletionReport `json:"deletionReport,omitempty"`
+	// ProgressStartTime is when the Bundle started making progress towards being ready, i.e. when a new generation
+	// of the spec was observed or when the Bundle stopped being ready. Not set while the Bundle is ready.
+	ProgressStartTime *meta_v1.Time `json:"progressStartTime,omitempty"`
+	// Plan lists changes that processing of the Bundle would make. Only set in dry-run mode.
+	Plan *Plan `json:"plan,omitempty"`
+	// Retry is set while the Bundle is waiting to be processed again after failing with a retriable error.
+	Retry *RetryStatus `json:"retry,omitempty"`
+	// Pruning is set while objects removed from the Bundle are waiting to be deleted because pruning is paused
+	// or rate limited.
+	Pruning *PruningStatus `json:"pruning,omitempty"`
+	// Warnings lists non-fatal issues with the spec of the Bundle. They do not affect processing.
+	Warnings []BundleWarning `json:"warnings,omitempty"`
+	// Outputs maps names of outputs of the Bundle to their values. Values that are not strings are JSON encoded.
+	// An output is only set once its resource is ready.
+	Outputs map[string]string `json:"outputs,omitempty"`
+	// LastReadyRevision is the most recent revision of the spec that made the Bundle ready. It is re-applied if
+	// the Bundle is annotated for rollback while it is in a terminal error.
+	LastReadyRevision *BundleSnapshot `json:"lastReadyRevision,omitempty"`
+}
+
+// +k8s:deepcopy-gen=true
+// BundleSnapshot is a snapshot of the spec of a Bundle.
+type BundleSnapshot struct {
+	// Generation of the Bundle the spec was taken from.
+	Generation int64 `json:"generation"`
+	// Hash of the spec, it is the same for identical specs of different generations.
+	Hash string     `json:"hash"`
+	Spec BundleSpec `json:"spec"`
+}
+
+// +k8s:deepcopy-gen=true
+// BundleWarning describes a non-fatal issue with a resource of a Bundle.
+type BundleWarning struct {
+	// Resource is the name of the resource the warning is about.
+	Resource ResourceName `json:"resource"`
+	Reason   string       `json:"reason"`
+	Message  string       `json:"message"`
+}
+
+func (w *BundleWarning) String() string {
+	return fmt.Sprintf("resource %q: %s", w.Resource, w.Message)
+}
+
+// +k8s:deepcopy-gen=true
+// PruningStatus describes progress of deletion of objects removed from a Bundle.
+type PruningStatus struct {
+	// Pending is the number of objects that are waiting to be deleted.
+	Pending int32 `json:"pending"`
+	// Paused is true if pruning is paused with the PrunePaused annotation.
+	Paused bool `json:"paused,omitempty"`
+	// NextBatchTime is when the next batch of objects is deleted. Not set if pruning is paused.
+	NextBatchTime *meta_v1.Time `json:"nextBatchTime,omitempty"`
+}
+
+// +k8s:deepcopy-gen=true
+// RetryStatus describes when a Bundle that failed with a retriable error is processed again.
+type RetryStatus struct {
+	// Attempts is the number of consecutive failed attempts to process the Bundle.
+	Attempts int32 `json:"attempts"`
+	// NextRetryTime is when the Bundle is processed again unless it or one of its objects changes earlier.
+	NextRetryTime meta_v1.Time `json:"nextRetryTime"`
 }
 
 func (bs *BundleStatus) String() string {
@@ -201,17 +453,120 @@ type Resource struct {
 	// Explicit dependencies.
 	References []Reference `json:"references,omitempty"`
 
+	// Quorums are groups of dependencies where only some of the resources need to be ready.
+	Quorums []Quorum `json:"quorums,omitempty"`
+
+	// MetadataPolicy customizes metadata that Smith sets on the object.
+	MetadataPolicy *MetadataPolicy `json:"metadataPolicy,omitempty"`
+
+	// UpdateStrategy is how the object is updated when it differs from the spec. Defaults to UpdateStrategyReplace.
+	UpdateStrategy UpdateStrategy `json:"updateStrategy,omitempty"`
+
+	// IgnoreFields are paths of fields of the object that are ignored when it is compared with the spec,
+	// e.g. "spec.replicas" of a Deployment that is scaled by an autoscaler. Keys with dots are quoted in brackets,
+	// e.g. `metadata.annotations["deployment.kubernetes.io/revision"]`.
+	IgnoreFields []string `json:"ignoreFields,omitempty"`
+
+	// DeletionPolicy is what happens to the object when the resource is removed from the Bundle or the Bundle is
+	// deleted. Defaults to DeletionPolicyDelete.
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// Adopt makes the Bundle take control of an existing object that does not have a controller, e.g. one that was
+	// created manually before the resource was added to the Bundle. By default such objects are not touched.
+	Adopt bool `json:"adopt,omitempty"`
+
+	// Namespace of the object. Defaults to the namespace of the Bundle. Other namespaces are only allowed if
+	// the controller is configured to manage objects in other namespaces.
+	Namespace string `json:"namespace,omitempty"`
+
+	// ReadinessTimeoutSeconds is the number of seconds the object may stay not ready before the resource is put into
+	// the Error state. Not set means the resource waits for the object indefinitely.
+	ReadinessTimeoutSeconds *int32 `json:"readinessTimeoutSeconds,omitempty"`
+
+	// SpecFrom is where the manifest of the object is read from instead of Spec. Keeps large Bundles small.
+	SpecFrom *SpecSource `json:"specFrom,omitempty"`
+
 	Spec ResourceSpec `json:"spec"`
 }
 
 // +k8s:deepcopy-gen=true
-// Refer to a part of another object
+// SpecSource is a ConfigMap or a Secret in the namespace of the Bundle that holds the manifest of the object of
+// a resource, as YAML or JSON. Exactly one of the fields must be set.
+type SpecSource struct {
+	ConfigMapKeyRef *KeyReference `json:"configMapKeyRef,omitempty"`
+	SecretKeyRef    *KeyReference `json:"secretKeyRef,omitempty"`
+}
+
+// +k8s:deepcopy-gen=true
+// KeyReference refers to a key of a ConfigMap or a Secret.
+type KeyReference struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+}
+
+// UpdateStrategy is how an object is updated when it differs from the spec of its resource.
+// Metadata is handled the same way by all strategies.
+type UpdateStrategy string
+
+// These are valid update strategies.
+const (
+	// UpdateStrategyReplace replaces top level fields of the object with the fields of the spec.
+	UpdateStrategyReplace UpdateStrategy = "replace"
+	// UpdateStrategyPatch merges the spec into the object. Fields that are not in the spec are kept.
+	UpdateStrategyPatch UpdateStrategy = "patch"
+	// UpdateStrategyMerge is a three-way merge of the spec, the object and the spec that was applied last time.
+	// Fields removed from the spec since it was applied last time are removed from the object, other fields
+	// that are not in the spec are kept.
+	UpdateStrategyMerge UpdateStrategy = "merge"
+)
+
+// DeletionPolicy is what happens to an object when its resource is removed from the Bundle or the Bundle is deleted.
+type DeletionPolicy string
+
+// These are valid deletion policies.
+const (
+	// DeletionPolicyDelete deletes the object.
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	// DeletionPolicyOrphan keeps the object and removes all metadata that ties it to the Bundle.
+	DeletionPolicyOrphan DeletionPolicy = "Orphan"
+	// DeletionPolicyRetain keeps the object labeled with the name of the Bundle so that a Bundle with the same name
+	// adopts it when the resource is defined again.
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+)
+
+// +k8s:deepcopy-gen=true
+// MetadataPolicy customizes metadata that Smith sets on an object.
+// By default Smith sets owner references to the Bundle and to objects of referenced resources, all with
+// BlockOwnerDeletion set to true.
+type MetadataPolicy struct {
+	// SkipReferenceOwnerReferences disables owner references to objects of referenced resources.
+	// The controller owner reference to the Bundle is always set.
+	SkipReferenceOwnerReferences bool `json:"skipReferenceOwnerReferences,omitempty"`
+	// BlockOwnerDeletion is the value of the BlockOwnerDeletion field of owner references. Defaults to true.
+	BlockOwnerDeletion *bool `json:"blockOwnerDeletion,omitempty"`
+	// Finalizers to add to the object.
+	Finalizers []string `json:"finalizers,omitempty"`
+}
+
+// +k8s:deepcopy-gen=true
+// Quorum is a group of resources a resource depends on.
+// Processing of the dependent resource starts once at least MinReady resources of the group are ready.
+type Quorum struct {
+	Resources []ResourceName `json:"resources"`
+	MinReady  int            `json:"minReady"`
+}
+
+// +k8s:deepcopy-gen=true
+// Refer to a part of another object or to a secret in an external secret store
 type Reference struct {
 	Name     ReferenceName `json:"name,omitempty"`
-	Resource ResourceName  `json:"resource"`
+	Resource ResourceName  `json:"resource,omitempty"`
 	Path     string        `json:"path,omitempty"`
 	Example  interface{}   `json:"example,omitempty"`
 	Modifier string        `json:"modifier,omitempty"`
+	// Source is a secret in an external secret store in the <provider>:<name>[#<key>] form, e.g. vault:secret/db#password.
+	// Mutually exclusive with Resource, Path and Modifier.
+	Source string `json:"source,omitempty"`
 }
 
 // DeepCopyInto is an deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -225,17 +580,25 @@ func (in *Reference) Ref() string {
 	return "!{" + string(in.Name) + "}"
 }
 
+// IsExternal returns true if the reference points at an external secret store rather than at a resource.
+func (in *Reference) IsExternal() bool {
+	return in.Source != ""
+}
+
 // +k8s:deepcopy-gen=true
-// ResourceSpec is a union type - either object of plugin can be specified.
+// ResourceSpec is a union type - either object, plugin or external can be specified.
 type ResourceSpec struct {
 	Object runtime.Object `json:"object,omitempty"`
 	Plugin *PluginSpec    `json:"plugin,omitempty"`
+	// External is an object that Smith does not create but waits for.
+	External *ExternalObject `json:"external,omitempty"`
 }
 
 func (rs *ResourceSpec) UnmarshalJSON(data []byte) error {
 	var res struct {
-		Object *unstructured.Unstructured `json:"object,omitempty"`
-		Plugin *PluginSpec                `json:"plugin,omitempty"`
+		Object   *unstructured.Unstructured `json:"object,omitempty"`
+		Plugin   *PluginSpec                `json:"plugin,omitempty"`
+		External *ExternalObject            `json:"external,omitempty"`
 	}
 	err := k8s_json.Unmarshal(data, &res)
 	if err != nil {
@@ -248,6 +611,7 @@ func (rs *ResourceSpec) UnmarshalJSON(data []byte) error {
 	}
 
 	rs.Plugin = res.Plugin
+	rs.External = res.External
 	return nil
 }
 
@@ -265,10 +629,74 @@ func (in *PluginSpec) DeepCopyInto(out *PluginSpec) {
 	out.Spec = runtime.DeepCopyJSON(in.Spec)
 }
 
+// +k8s:deepcopy-gen=true
+// ExternalObject is an object that is created by something other than Smith, e.g. provided by the platform.
+// The resource is ready once the object exists and is ready, it is never created, updated or deleted.
+// The object is in the namespace of the resource.
+type ExternalObject struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	// ReadyWhen is a readiness expression of the same form as the value of the ReadyWhenAnnotation, e.g.
+	// `$.status.phase == "Ready"`. Built-in readiness checks are used if it is empty.
+	ReadyWhen string `json:"readyWhen,omitempty"`
+}
+
+// GroupVersionKind returns the GVK of the object.
+func (in *ExternalObject) GroupVersionKind() schema.GroupVersionKind {
+	return schema.FromAPIVersionAndKind(in.APIVersion, in.Kind)
+}
+
 // +k8s:deepcopy-gen=true
 type ResourceStatus struct {
 	Name       ResourceName        `json:"name"`
 	Conditions []ResourceCondition `json:"conditions,omitempty"`
+	// ObjectName is the name of the object of the resource generated by the server from metadata.generateName.
+	// Only set for resources without metadata.name.
+	ObjectName string `json:"objectName,omitempty"`
+	// State summarizes the conditions. Error takes precedence over Ready, Ready over Blocked and Blocked
+	// over InProgress.
+	State ResourceState `json:"state,omitempty"`
+	// Message of the condition that determines the state.
+	Message string `json:"message,omitempty"`
+	// LastTransitionTime of the condition that determines the state.
+	LastTransitionTime meta_v1.Time `json:"lastTransitionTime,omitempty"`
+	// LastDiff summarizes the last update of the object made because it differed from the spec.
+	LastDiff *ResourceDiff `json:"lastDiff,omitempty"`
+	// ProgressStartTime is when the resource started waiting for its object to become ready. Only set for
+	// resources with a readiness timeout while the object is not ready.
+	ProgressStartTime *meta_v1.Time `json:"progressStartTime,omitempty"`
+	// ResolvedObject identifies the object of a resource with specFrom. The kind and name of such objects are
+	// only known once the spec source has been read. Only set for resources with specFrom.
+	ResolvedObject *ResolvedObject `json:"resolvedObject,omitempty"`
+}
+
+// +k8s:deepcopy-gen=true
+// ResolvedObject identifies the object of a resource as read from its spec source.
+type ResolvedObject struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	// Name of the object. Empty if the name is generated by the server, see ObjectName.
+	Name string `json:"name,omitempty"`
+}
+
+// GroupVersionKind returns the GVK of the object.
+func (in *ResolvedObject) GroupVersionKind() schema.GroupVersionKind {
+	return schema.FromAPIVersionAndKind(in.APIVersion, in.Kind)
+}
+
+// +k8s:deepcopy-gen=true
+// ResourceDiff summarizes an update of an object made because it differed from the spec.
+type ResourceDiff struct {
+	// Fields are paths of fields that differed, e.g. "spec.replicas". Truncated if there are many of them.
+	Fields []string `json:"fields,omitempty"`
+	// Updates is the number of updates made at the observed generation of the Bundle. A growing number means
+	// something keeps changing the object, e.g. another controller or a mutating webhook.
+	Updates int32 `json:"updates"`
+	// ObservedGeneration is the generation of the Bundle the last update was made at.
+	ObservedGeneration int64 `json:"observedGeneration"`
+	// LastUpdateTime is the time of the last update.
+	LastUpdateTime meta_v1.Time `json:"lastUpdateTime"`
 }
 
 func (rs *ResourceStatus) GetCondition(conditionType ResourceConditionType) (int, *ResourceCondition) {
@@ -281,6 +709,7 @@ func (rs *ResourceStatus) GetCondition(conditionType ResourceConditionType) (int
 	return -1, nil
 }
 
+// +k8s:deepcopy-gen=true
 type ObjectToDelete struct {
 	// GVK of the object.
 
@@ -289,6 +718,76 @@ type ObjectToDelete struct {
 	Kind    string `json:"kind"`
 	// Name of the object.
 	Name string `json:"name"`
+	// Namespace of the object if it is not the namespace of the Bundle.
+	Namespace string `json:"namespace,omitempty"`
+	// BlockingFinalizers is a list of third-party finalizers that prevent the object, which has already been marked
+	// for deletion, from being deleted. Non-empty if pruning of the object is pending.
+	BlockingFinalizers []string `json:"blockingFinalizers,omitempty"`
+	// FailedDeletionAttempts is the number of consecutive failed attempts to delete the object.
+	// Failed attempts are retried with backoff.
+	FailedDeletionAttempts int32 `json:"failedDeletionAttempts,omitempty"`
+	// LastDeletionError is the error of the last failed attempt to delete the object.
+	LastDeletionError string `json:"lastDeletionError,omitempty"`
+}
+
+// +k8s:deepcopy-gen=true
+// DeletionReport describes the impact of deleting a Bundle.
+type DeletionReport struct {
+	// Children are objects controlled by the Bundle. They are deleted with the Bundle.
+	Children []ObjectReference `json:"children,omitempty"`
+	// Cascaded are objects controlled by the children. They are deleted by cascading deletion.
+	Cascaded []ObjectReference `json:"cascaded,omitempty"`
+	// Retained are objects defined in the Bundle but not controlled by it. They are not deleted.
+	Retained []ObjectReference `json:"retained,omitempty"`
+	// Released are objects controlled by the Bundle that have the Retain or Orphan deletion policy. They are
+	// released from the Bundle instead of being deleted, together with the objects they control.
+	Released []ObjectReference `json:"released,omitempty"`
+}
+
+// +k8s:deepcopy-gen=true
+// Plan describes changes that processing of a Bundle would make to objects.
+type Plan struct {
+	// ObservedGeneration is the generation of the Bundle spec the plan was computed for.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Changes to objects of resources in the order the resources are processed, followed by deletions of objects
+	// that are no longer defined in the Bundle. Objects that would not change are not listed.
+	Changes []PlannedChange `json:"changes,omitempty"`
+}
+
+type PlannedAction string
+
+const (
+	PlannedActionCreate PlannedAction = "Create"
+	PlannedActionUpdate PlannedAction = "Update"
+	PlannedActionDelete PlannedAction = "Delete"
+	// PlannedActionUnknown means the change cannot be computed because dependencies of the resource are not ready,
+	// e.g. because they would be created first.
+	PlannedActionUnknown PlannedAction = "Unknown"
+)
+
+// +k8s:deepcopy-gen=true
+// PlannedChange describes a change to an object.
+type PlannedChange struct {
+	// Resource the object belongs to. Empty for objects that are no longer defined in the Bundle.
+	Resource ResourceName  `json:"resource,omitempty"`
+	Group    string        `json:"group"`
+	Version  string        `json:"version"`
+	Kind     string        `json:"kind"`
+	Name     string        `json:"name"`
+	Action   PlannedAction `json:"action"`
+	// Diff between the actual and the desired object. Only set for updates.
+	Diff string `json:"diff,omitempty"`
+	// Message is a human readable description of the change.
+	Message string `json:"message,omitempty"`
+}
+
+// +k8s:deepcopy-gen=true
+// ObjectReference identifies an object in the namespace of the Bundle.
+type ObjectReference struct {
+	Group   string `json:"group"`
+	Version string `json:"version"`
+	Kind    string `json:"kind"`
+	Name    string `json:"name"`
 }
 
 // +k8s:deepcopy-gen=true
@@ -321,3 +820,83 @@ func (rc *ResourceCondition) String() string {
 	}
 	return buf.String()
 }
+
+// +k8s:deepcopy-gen=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type BundleClassList struct {
+	meta_v1.TypeMeta `json:",inline"`
+	// Standard list metadata.
+	meta_v1.ListMeta `json:"metadata,omitempty"`
+
+	// Items is a list of bundle classes.
+	Items []BundleClass `json:"items"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +genclient:noStatus
+
+// +k8s:deepcopy-gen=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// BundleClass describes a Bundle that should be instantiated in every namespace matching a selector.
+type BundleClass struct {
+	meta_v1.TypeMeta `json:",inline"`
+
+	// Standard object metadata
+	meta_v1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec is the specification of the desired behavior of the BundleClass.
+	Spec BundleClassSpec `json:"spec,omitempty"`
+}
+
+// +k8s:deepcopy-gen=true
+type BundleClassSpec struct {
+	// NamespaceSelector selects namespaces to instantiate the Bundle in.
+	NamespaceSelector meta_v1.LabelSelector `json:"namespaceSelector"`
+	// Template describes the Bundle that will be created in each selected namespace.
+	Template BundleTemplate `json:"template"`
+}
+
+// +k8s:deepcopy-gen=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type BundleRevisionList struct {
+	meta_v1.TypeMeta `json:",inline"`
+	// Standard list metadata.
+	meta_v1.ListMeta `json:"metadata,omitempty"`
+
+	// Items is a list of bundle revisions.
+	Items []BundleRevision `json:"items"`
+}
+
+// +genclient
+// +genclient:noStatus
+
+// +k8s:deepcopy-gen=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// BundleRevision is a snapshot of the spec of a Bundle that made it ready. Revisions are created by the controller
+// in the namespace of the Bundle and are controlled by it, similar to ControllerRevisions of StatefulSets.
+type BundleRevision struct {
+	meta_v1.TypeMeta `json:",inline"`
+
+	// Standard object metadata
+	meta_v1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Revision is the generation of the Bundle the spec was taken from. If the same spec made the Bundle ready
+	// several times, it is the most recent generation.
+	Revision int64 `json:"revision"`
+	// Hash of the spec, the same as in the name of the BundleRevision.
+	Hash string `json:"hash"`
+	// ReadyTime is when the spec last made the Bundle ready. Expiry of revisions is counted from it.
+	ReadyTime meta_v1.Time `json:"readyTime,omitempty"`
+	// Spec is the spec of the Bundle.
+	Spec BundleSpec `json:"spec"`
+}
+
+// +k8s:deepcopy-gen=true
+// BundleTemplate describes a Bundle to be created from a BundleClass.
+// Bundle is named after the BundleClass.
+type BundleTemplate struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Spec        BundleSpec        `json:"spec"`
+}
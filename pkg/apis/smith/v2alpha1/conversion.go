@@ -0,0 +1,109 @@
+package v2alpha1
+
+import (
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConvertFromV1 returns the v2alpha1 representation of a v1 Bundle. Policies blocks are only set if they have
+// fields that are set. The argument is not mutated.
+func ConvertFromV1(in *smith_v1.Bundle) *Bundle {
+	in = in.DeepCopy()
+	out := &Bundle{
+		TypeMeta: meta_v1.TypeMeta{
+			APIVersion: BundleResourceGroupVersion,
+			Kind:       smith_v1.BundleResourceKind,
+		},
+		ObjectMeta: in.ObjectMeta,
+		Spec: BundleSpec{
+			Parameters:    in.Spec.Parameters,
+			Outputs:       in.Spec.Outputs,
+			OutputsExport: in.Spec.OutputsExport,
+		},
+		Status: in.Status,
+	}
+	if len(in.Spec.IdentityPolicies) > 0 || in.Spec.ProgressDeadlineSeconds != nil || in.Spec.RetryPolicy != nil ||
+		in.Spec.MaxHistory != nil || in.Spec.HistoryTTLSeconds != nil {
+		out.Spec.Policies = &BundlePolicies{
+			Identity:                in.Spec.IdentityPolicies,
+			ProgressDeadlineSeconds: in.Spec.ProgressDeadlineSeconds,
+			Retry:                   in.Spec.RetryPolicy,
+			MaxHistory:              in.Spec.MaxHistory,
+			HistoryTTLSeconds:       in.Spec.HistoryTTLSeconds,
+		}
+	}
+	if in.Spec.Resources != nil {
+		out.Spec.Resources = make([]Resource, 0, len(in.Spec.Resources))
+	}
+	for _, res := range in.Spec.Resources {
+		r := Resource{
+			Name:       res.Name,
+			Namespace:  res.Namespace,
+			References: res.References,
+			Quorums:    res.Quorums,
+			SpecFrom:   res.SpecFrom,
+			Spec:       res.Spec,
+		}
+		if res.MetadataPolicy != nil || res.UpdateStrategy != "" || res.IgnoreFields != nil || res.DeletionPolicy != "" || res.Adopt || res.ReadinessTimeoutSeconds != nil {
+			r.Policies = &ResourcePolicies{
+				Metadata:                res.MetadataPolicy,
+				Update:                  res.UpdateStrategy,
+				IgnoreFields:            res.IgnoreFields,
+				Deletion:                res.DeletionPolicy,
+				Adopt:                   res.Adopt,
+				ReadinessTimeoutSeconds: res.ReadinessTimeoutSeconds,
+			}
+		}
+		out.Spec.Resources = append(out.Spec.Resources, r)
+	}
+	return out
+}
+
+// ConvertToV1 returns the v1 representation of a v2alpha1 Bundle. The argument is not mutated.
+func ConvertToV1(in *Bundle) *smith_v1.Bundle {
+	in = in.DeepCopy()
+	out := &smith_v1.Bundle{
+		TypeMeta: meta_v1.TypeMeta{
+			APIVersion: smith_v1.BundleResourceGroupVersion,
+			Kind:       smith_v1.BundleResourceKind,
+		},
+		ObjectMeta: in.ObjectMeta,
+		Spec: smith_v1.BundleSpec{
+			Parameters:    in.Spec.Parameters,
+			Outputs:       in.Spec.Outputs,
+			OutputsExport: in.Spec.OutputsExport,
+		},
+		Status: in.Status,
+	}
+	if policies := in.Spec.Policies; policies != nil {
+		out.Spec.IdentityPolicies = policies.Identity
+		out.Spec.ProgressDeadlineSeconds = policies.ProgressDeadlineSeconds
+		out.Spec.RetryPolicy = policies.Retry
+		out.Spec.MaxHistory = policies.MaxHistory
+		out.Spec.HistoryTTLSeconds = policies.HistoryTTLSeconds
+	}
+	if in.Spec.Resources != nil {
+		out.Spec.Resources = make([]smith_v1.Resource, 0, len(in.Spec.Resources))
+	}
+	for _, res := range in.Spec.Resources {
+		r := smith_v1.Resource{
+			Name:       res.Name,
+			Namespace:  res.Namespace,
+			References: res.References,
+			Quorums:    res.Quorums,
+			SpecFrom:   res.SpecFrom,
+			Spec:       res.Spec,
+		}
+		if policies := res.Policies; policies != nil {
+			r.MetadataPolicy = policies.Metadata
+			r.UpdateStrategy = policies.Update
+			r.IgnoreFields = policies.IgnoreFields
+			r.DeletionPolicy = policies.Deletion
+			r.Adopt = policies.Adopt
+			r.ReadinessTimeoutSeconds = policies.ReadinessTimeoutSeconds
+		}
+		out.Spec.Resources = append(out.Spec.Resources, r)
+	}
+	return out
+}
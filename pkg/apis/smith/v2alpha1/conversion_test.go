@@ -0,0 +1,108 @@
+package v2alpha1
+
+import (
+	"testing"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestConversionRoundTrip(t *testing.T) {
+	t.Parallel()
+	deadline := int32(600)
+	timeout := int32(60)
+	maxHistory := int32(3)
+	v1Bundle := &smith_v1.Bundle{
+		TypeMeta: meta_v1.TypeMeta{
+			APIVersion: smith_v1.BundleResourceGroupVersion,
+			Kind:       smith_v1.BundleResourceKind,
+		},
+		ObjectMeta: meta_v1.ObjectMeta{
+			Namespace: "ns",
+			Name:      "b1",
+		},
+		Spec: smith_v1.BundleSpec{
+			Parameters:              map[string]string{"environment": "dev"},
+			ProgressDeadlineSeconds: &deadline,
+			RetryPolicy:             &smith_v1.RetryPolicy{BaseDelaySeconds: 5},
+			OutputsExport:           &smith_v1.OutputsExport{Kind: smith_v1.OutputsExportConfigMap},
+			MaxHistory:              &maxHistory,
+			Resources: []smith_v1.Resource{
+				{
+					Name:                    "a",
+					UpdateStrategy:          smith_v1.UpdateStrategyMerge,
+					IgnoreFields:            []string{"spec.replicas"},
+					DeletionPolicy:          smith_v1.DeletionPolicyRetain,
+					Adopt:                   true,
+					ReadinessTimeoutSeconds: &timeout,
+					Spec: smith_v1.ResourceSpec{
+						Object: &unstructured.Unstructured{
+							Object: map[string]interface{}{
+								"apiVersion": "v1",
+								"kind":       "ConfigMap",
+								"metadata": map[string]interface{}{
+									"name": "a",
+								},
+							},
+						},
+					},
+				},
+				{
+					Name: "b",
+					References: []smith_v1.Reference{
+						{Resource: "a"},
+					},
+					Spec: smith_v1.ResourceSpec{
+						Plugin: &smith_v1.PluginSpec{
+							Name:       "p",
+							ObjectName: "b",
+						},
+					},
+				},
+			},
+		},
+		Status: smith_v1.BundleStatus{
+			ObservedGeneration: 3,
+		},
+	}
+
+	v2Bundle := ConvertFromV1(v1Bundle)
+	assert.Equal(t, BundleResourceGroupVersion, v2Bundle.APIVersion)
+	require.NotNil(t, v2Bundle.Spec.Policies)
+	assert.Equal(t, &deadline, v2Bundle.Spec.Policies.ProgressDeadlineSeconds)
+	assert.Equal(t, &maxHistory, v2Bundle.Spec.Policies.MaxHistory)
+	require.Len(t, v2Bundle.Spec.Resources, 2)
+	assert.Equal(t, &ResourcePolicies{
+		Update:                  smith_v1.UpdateStrategyMerge,
+		IgnoreFields:            []string{"spec.replicas"},
+		Deletion:                smith_v1.DeletionPolicyRetain,
+		Adopt:                   true,
+		ReadinessTimeoutSeconds: &timeout,
+	}, v2Bundle.Spec.Resources[0].Policies)
+	assert.Nil(t, v2Bundle.Spec.Resources[1].Policies)
+	assert.EqualValues(t, 3, v2Bundle.Status.ObservedGeneration)
+
+	assert.Equal(t, v1Bundle, ConvertToV1(v2Bundle))
+}
+
+func TestConversionWithoutPolicies(t *testing.T) {
+	t.Parallel()
+	v1Bundle := &smith_v1.Bundle{
+		Spec: smith_v1.BundleSpec{
+			Resources: []smith_v1.Resource{
+				{Name: "a"},
+			},
+		},
+	}
+
+	v2Bundle := ConvertFromV1(v1Bundle)
+	assert.Nil(t, v2Bundle.Spec.Policies)
+	require.Len(t, v2Bundle.Spec.Resources, 1)
+	assert.Nil(t, v2Bundle.Spec.Resources[0].Policies)
+
+	v2Bundle.Spec.Policies = &BundlePolicies{}
+	assert.Equal(t, smith_v1.BundleSpec{Resources: v1Bundle.Spec.Resources}, ConvertToV1(v2Bundle).Spec)
+}
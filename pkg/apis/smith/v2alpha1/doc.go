@@ -0,0 +1,4 @@
+// Package v2alpha1 defines the v2alpha1 definitions of the Smith model.
+// Bundles are stored as v1, v2alpha1 Bundles are converted by the conversion webhook.
+// +groupName=smith.atlassian.com
+package v2alpha1
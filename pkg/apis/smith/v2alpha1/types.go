@@ -0,0 +1,128 @@
+package v2alpha1
+
+import (
+	"github.com/atlassian/smith/pkg/apis/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	BundleResourceVersion = "v2alpha1"
+
+	BundleResourceGroupVersion = smith.GroupName + "/" + BundleResourceVersion
+)
+
+var BundleGVK = SchemeGroupVersion.WithKind(smith_v1.BundleResourceKind)
+
+// +k8s:deepcopy-gen=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type BundleList struct {
+	meta_v1.TypeMeta `json:",inline"`
+	// Standard list metadata.
+	meta_v1.ListMeta `json:"metadata,omitempty"`
+
+	// Items is a list of bundles.
+	Items []Bundle `json:"items"`
+}
+
+// +k8s:deepcopy-gen=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// Bundle describes a resources bundle. It has the same meaning as a v1 Bundle, policies are grouped in policies
+// blocks of the Bundle and of its resources.
+type Bundle struct {
+	meta_v1.TypeMeta `json:",inline"`
+
+	// Standard object metadata
+	meta_v1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec is the specification of the desired behavior of the Bundle.
+	Spec BundleSpec `json:"spec,omitempty"`
+
+	// Status is most recently observed status of the Bundle.
+	Status smith_v1.BundleStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen=true
+type BundleSpec struct {
+	Resources []Resource `json:"resources,omitempty"`
+	// Parameters are substituted for references to them in strings of object and plugin specs of resources,
+	// e.g. ${params.environment}, so that Bundles of the same shape can differ in a few values.
+	Parameters map[string]string `json:"parameters,omitempty"`
+	// Outputs are values of fields of objects of the Bundle that are published in its status so that a Bundle
+	// that includes it as a resource can reference them.
+	Outputs []smith_v1.Output `json:"outputs,omitempty"`
+	// OutputsExport publishes the outputs of the Bundle in a ConfigMap or a Secret so that they can be read by
+	// consumers outside of the Bundle. Not set means outputs are only published in the status.
+	OutputsExport *smith_v1.OutputsExport `json:"outputsExport,omitempty"`
+	// Policies customize how the Bundle is processed.
+	Policies *BundlePolicies `json:"policies,omitempty"`
+}
+
+// +k8s:deepcopy-gen=true
+// BundlePolicies customize how a Bundle is processed.
+type BundlePolicies struct {
+	// Identity policies are applied to all objects of matching API groups.
+	Identity []smith_v1.IdentityPolicy `json:"identity,omitempty"`
+	// ProgressDeadlineSeconds is the number of seconds resources of the Bundle may stay not ready before the Bundle
+	// is considered timed out. Not set means no deadline.
+	ProgressDeadlineSeconds *int32 `json:"progressDeadlineSeconds,omitempty"`
+	// Retry customizes how processing of the Bundle is retried after it has failed with a retriable error.
+	// Not set means the configuration of the controller is used.
+	Retry *smith_v1.RetryPolicy `json:"retry,omitempty"`
+	// MaxHistory is the number of BundleRevisions kept for the Bundle. Not set means the limit of the controller
+	// is used, zero disables revision history for the Bundle.
+	MaxHistory *int32 `json:"maxHistory,omitempty"`
+	// HistoryTTLSeconds is the number of seconds a BundleRevision is kept for after its spec last made the Bundle
+	// ready. Not set means the TTL of the controller is used, zero means BundleRevisions do not expire.
+	HistoryTTLSeconds *int64 `json:"historyTTLSeconds,omitempty"`
+}
+
+// +k8s:deepcopy-gen=true
+// Resource describes an object that should be provisioned.
+type Resource struct {
+	// Name of the resource for references.
+	Name smith_v1.ResourceName `json:"name"`
+
+	// Namespace of the object. Defaults to the namespace of the Bundle. Other namespaces are only allowed if
+	// the controller is configured to manage objects in other namespaces.
+	Namespace string `json:"namespace,omitempty"`
+
+	// Explicit dependencies.
+	References []smith_v1.Reference `json:"references,omitempty"`
+
+	// Quorums are groups of dependencies where only some of the resources need to be ready.
+	Quorums []smith_v1.Quorum `json:"quorums,omitempty"`
+
+	// Policies customize how the object is managed.
+	Policies *ResourcePolicies `json:"policies,omitempty"`
+
+	// SpecFrom is where the manifest of the object is read from instead of Spec.
+	SpecFrom *smith_v1.SpecSource `json:"specFrom,omitempty"`
+
+	Spec smith_v1.ResourceSpec `json:"spec"`
+}
+
+// +k8s:deepcopy-gen=true
+// ResourcePolicies customize how the object of a resource is managed.
+type ResourcePolicies struct {
+	// Metadata customizes metadata that Smith sets on the object.
+	Metadata *smith_v1.MetadataPolicy `json:"metadata,omitempty"`
+
+	// Update is how the object is updated when it differs from the spec. Defaults to replace.
+	Update smith_v1.UpdateStrategy `json:"update,omitempty"`
+
+	// IgnoreFields are paths of fields of the object that are ignored when it is compared with the spec.
+	IgnoreFields []string `json:"ignoreFields,omitempty"`
+
+	// Deletion is what happens to the object when the resource is removed from the Bundle or the Bundle is
+	// deleted. Defaults to Delete.
+	Deletion smith_v1.DeletionPolicy `json:"deletion,omitempty"`
+
+	// Adopt makes the Bundle take control of an existing object that does not have a controller.
+	Adopt bool `json:"adopt,omitempty"`
+
+	// ReadinessTimeoutSeconds is the number of seconds the object may stay not ready before the resource is put into
+	// the Error state. Not set means the resource waits for the object indefinitely.
+	ReadinessTimeoutSeconds *int32 `json:"readinessTimeoutSeconds,omitempty"`
+}
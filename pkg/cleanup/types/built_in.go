@@ -14,9 +14,10 @@ import (
 
 var (
 	MainKnownTypes = map[schema.GroupKind]cleanup.SpecCleanup{
-		{Group: apps_v1.GroupName, Kind: "Deployment"}: deploymentCleanup,
-		{Group: core_v1.GroupName, Kind: "Service"}:    serviceCleanup,
-		{Group: core_v1.GroupName, Kind: "Secret"}:     secretCleanup,
+		{Group: apps_v1.GroupName, Kind: "Deployment"}:            deploymentCleanup,
+		{Group: core_v1.GroupName, Kind: "Service"}:               serviceCleanup,
+		{Group: core_v1.GroupName, Kind: "Secret"}:                secretCleanup,
+		{Group: core_v1.GroupName, Kind: "PersistentVolumeClaim"}: persistentVolumeClaimCleanup,
 	}
 
 	ServiceCatalogKnownTypes = map[schema.GroupKind]cleanup.SpecCleanup{
@@ -104,6 +105,28 @@ func secretCleanup(spec, actual *unstructured.Unstructured) (runtime.Object, err
 	return &secretSpec, nil
 }
 
+func persistentVolumeClaimCleanup(spec, actual *unstructured.Unstructured) (runtime.Object, error) {
+	var pvcSpec core_v1.PersistentVolumeClaim
+	if err := util.ConvertType(core_v1_scheme, spec, &pvcSpec); err != nil {
+		return nil, err
+	}
+	var pvcActual core_v1.PersistentVolumeClaim
+	if err := util.ConvertType(core_v1_scheme, actual, &pvcActual); err != nil {
+		return nil, err
+	}
+
+	// Set on binding and by the defaulting admission plugin. Spec is immutable so they must be preserved.
+	if pvcSpec.Spec.VolumeName == "" {
+		pvcSpec.Spec.VolumeName = pvcActual.Spec.VolumeName
+	}
+	if pvcSpec.Spec.StorageClassName == nil {
+		pvcSpec.Spec.StorageClassName = pvcActual.Spec.StorageClassName
+	}
+	pvcSpec.Status = pvcActual.Status
+
+	return &pvcSpec, nil
+}
+
 func scServiceBindingCleanup(spec, actual *unstructured.Unstructured) (runtime.Object, error) {
 	var sbSpec sc_v1b1.ServiceBinding
 	if err := util.ConvertType(sc_v1b1_scheme, spec, &sbSpec); err != nil {
@@ -14,6 +14,14 @@ type FakeSmithV1 struct {
 	*testing.Fake
 }
 
+func (c *FakeSmithV1) BundleClasses() v1.BundleClassInterface {
+	return &FakeBundleClasses{c}
+}
+
+func (c *FakeSmithV1) BundleRevisions(namespace string) v1.BundleRevisionInterface {
+	return &FakeBundleRevisions{c, namespace}
+}
+
 func (c *FakeSmithV1) Bundles(namespace string) v1.BundleInterface {
 	return &FakeBundles{c, namespace}
 }
@@ -4,4 +4,8 @@
 
 package v1
 
+type BundleClassExpansion interface{}
+
+type BundleRevisionExpansion interface{}
+
 type BundleExpansion interface{}
@@ -13,6 +13,8 @@ import (
 
 type SmithV1Interface interface {
 	RESTClient() rest.Interface
+	BundleClassesGetter
+	BundleRevisionsGetter
 	BundlesGetter
 }
 
@@ -21,6 +23,14 @@ type SmithV1Client struct {
 	restClient rest.Interface
 }
 
+func (c *SmithV1Client) BundleClasses() BundleClassInterface {
+	return newBundleClasses(c)
+}
+
+func (c *SmithV1Client) BundleRevisions(namespace string) BundleRevisionInterface {
+	return newBundleRevisions(c, namespace)
+}
+
 func (c *SmithV1Client) Bundles(namespace string) BundleInterface {
 	return newBundles(c, namespace)
 }
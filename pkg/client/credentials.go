@@ -0,0 +1,237 @@
+package client
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	refreshResultSuccess = "success"
+	refreshResultFailure = "failure"
+)
+
+// CredentialRefresher reloads client configuration when the API server rejects credentials with 401 Unauthorized.
+// This handles rotated service account tokens, client certificates and kubeconfig files without a restart.
+// Reloading happens at most once per minimum interval. Requests that were rejected are retried once with the reloaded
+// credentials if their body can be replayed. Watches established with the old credentials are closed after a reload
+// so that informers re-list and re-watch with the new ones.
+type CredentialRefresher struct {
+	load         func() (*rest.Config, error)
+	newTransport func(*rest.Config) (http.RoundTripper, error)
+	minInterval  time.Duration
+	now          func() time.Time
+	refreshes    *prometheus.CounterVec
+
+	mx          sync.Mutex
+	generation  uint64
+	transport   http.RoundTripper // nil until credentials have been reloaded
+	lastAttempt time.Time
+	watches     map[*trackedWatch]uint64
+}
+
+func NewCredentialRefresher(load func() (*rest.Config, error), minInterval time.Duration) *CredentialRefresher {
+	return &CredentialRefresher{
+		load:         load,
+		newTransport: rest.TransportFor,
+		minInterval:  minInterval,
+		now:          time.Now,
+		refreshes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "smith",
+			Subsystem: "api_client",
+			Name:      "credential_refreshes_total",
+			Help:      "Number of client credential reloads after 401 Unauthorized responses by result: success or failure",
+		}, []string{"result"}),
+		watches: make(map[*trackedWatch]uint64),
+	}
+}
+
+// RegisterMetrics registers metrics of the refresher with the registerer.
+func (r *CredentialRefresher) RegisterMetrics(registerer prometheus.Registerer) error {
+	return errors.WithStack(registerer.Register(r.refreshes))
+}
+
+// WrapTransport is meant to be set as rest.Config.WrapTransport.
+func (r *CredentialRefresher) WrapTransport(rt http.RoundTripper) http.RoundTripper {
+	return &refreshingTransport{
+		refresher: r,
+		initial:   rt,
+	}
+}
+
+// WrapConfig returns a copy of the config with transports wrapped by the refresher.
+func (r *CredentialRefresher) WrapConfig(config *rest.Config) *rest.Config {
+	config = rest.CopyConfig(config)
+	if wt := config.WrapTransport; wt != nil {
+		config.WrapTransport = func(rt http.RoundTripper) http.RoundTripper {
+			return r.WrapTransport(wt(rt))
+		}
+	} else {
+		config.WrapTransport = r.WrapTransport
+	}
+	return config
+}
+
+func (r *CredentialRefresher) current() (http.RoundTripper, uint64) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	return r.transport, r.generation
+}
+
+// refresh reloads credentials unless they have already been reloaded since the failed generation was current.
+// Returns the transport to retry with and its generation, or false if credentials were not reloaded.
+func (r *CredentialRefresher) refresh(failedGeneration uint64) (http.RoundTripper, uint64, bool) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	if r.generation != failedGeneration {
+		// Another request has reloaded credentials already
+		return r.transport, r.generation, true
+	}
+	now := r.now()
+	if !r.lastAttempt.IsZero() && now.Sub(r.lastAttempt) < r.minInterval {
+		return nil, 0, false
+	}
+	r.lastAttempt = now
+	config, err := r.load()
+	if err != nil {
+		r.refreshes.WithLabelValues(refreshResultFailure).Inc()
+		return nil, 0, false
+	}
+	transport, err := r.newTransport(config)
+	if err != nil {
+		r.refreshes.WithLabelValues(refreshResultFailure).Inc()
+		return nil, 0, false
+	}
+	r.refreshes.WithLabelValues(refreshResultSuccess).Inc()
+	r.transport = transport
+	r.generation++
+	for w, generation := range r.watches {
+		if generation < r.generation {
+			w.cancel()
+			delete(r.watches, w)
+		}
+	}
+	return r.transport, r.generation, true
+}
+
+func (r *CredentialRefresher) trackWatch(req *http.Request, generation uint64) (*http.Request, *trackedWatch) {
+	ctx, cancel := context.WithCancel(req.Context())
+	w := &trackedWatch{
+		cancel: cancel,
+	}
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	r.watches[w] = generation
+	return req.WithContext(ctx), w
+}
+
+func (r *CredentialRefresher) untrackWatch(w *trackedWatch) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	delete(r.watches, w)
+}
+
+type trackedWatch struct {
+	cancel context.CancelFunc
+}
+
+type refreshingTransport struct {
+	refresher *CredentialRefresher
+	initial   http.RoundTripper
+}
+
+func (t *refreshingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt, generation := t.refresher.current()
+	resp, err := t.roundTrip(rt, generation, req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	rt, generation, ok := t.refresher.refresh(generation)
+	if !ok || !replayable(req) {
+		return resp, nil
+	}
+	// Discard the rejected response and retry once with reloaded credentials
+	io.Copy(ioutil.Discard, resp.Body) // Drain the body so that the connection is reused
+	resp.Body.Close()
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		req = shallowCopy(req)
+		req.Body = body
+	}
+	return t.roundTrip(rt, generation, req)
+}
+
+func (t *refreshingTransport) roundTrip(rt http.RoundTripper, generation uint64, req *http.Request) (*http.Response, error) {
+	if rt == nil {
+		rt = t.initial
+	} else {
+		// Credentials applied by the wrapping transports are stale, the reloaded transport applies its own.
+		req = shallowCopy(req)
+		req.Header.Del("Authorization")
+	}
+	if !isWatch(req) {
+		return rt.RoundTrip(req)
+	}
+	req, w := t.refresher.trackWatch(req, generation)
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.refresher.untrackWatch(w)
+		w.cancel()
+		return nil, err
+	}
+	resp.Body = &watchBody{
+		ReadCloser: resp.Body,
+		close: func() {
+			t.refresher.untrackWatch(w)
+			w.cancel()
+		},
+	}
+	return resp, nil
+}
+
+type watchBody struct {
+	io.ReadCloser
+	once  sync.Once
+	close func()
+}
+
+func (b *watchBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.close)
+	return err
+}
+
+func isWatch(req *http.Request) bool {
+	switch req.URL.Query().Get("watch") {
+	case "true", "1":
+		return true
+	}
+	return strings.Contains(req.URL.Path, "/watch/")
+}
+
+func replayable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// shallowCopy copies the request and its headers so that they can be modified.
+// A RoundTripper must not modify the request it was given.
+func shallowCopy(req *http.Request) *http.Request {
+	r := new(http.Request)
+	*r = *req
+	r.Header = make(http.Header, len(req.Header))
+	for k, v := range req.Header {
+		r.Header[k] = append([]string(nil), v...)
+	}
+	return r
+}
@@ -0,0 +1,203 @@
+package client
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/rest"
+)
+
+// tokenServer accepts requests authenticated with the current token.
+// Watch requests are kept open until the client goes away.
+type tokenServer struct {
+	mx           sync.Mutex
+	token        string
+	unauthorized int
+}
+
+func (s *tokenServer) setToken(token string) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.token = token
+}
+
+func (s *tokenServer) unauthorizedCount() int {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.unauthorized
+}
+
+func (s *tokenServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mx.Lock()
+	authorized := r.Header.Get("Authorization") == "Bearer "+s.token
+	if !authorized {
+		s.unauthorized++
+	}
+	s.mx.Unlock()
+	if !authorized {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if r.URL.Query().Get("watch") == "true" {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+		return
+	}
+	w.Write([]byte("ok"))
+}
+
+type testLoader struct {
+	mx    sync.Mutex
+	host  string
+	token string
+	loads int
+}
+
+func (l *testLoader) load() (*rest.Config, error) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	l.loads++
+	return &rest.Config{
+		Host:        l.host,
+		BearerToken: l.token,
+	}, nil
+}
+
+func (l *testLoader) loadCount() int {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	return l.loads
+}
+
+func newTestClient(t *testing.T, srv *httptest.Server, r *CredentialRefresher, token string) *http.Client {
+	rt, err := rest.TransportFor(&rest.Config{
+		Host:          srv.URL,
+		BearerToken:   token,
+		WrapTransport: r.WrapTransport,
+	})
+	require.NoError(t, err)
+	return &http.Client{Transport: rt}
+}
+
+func get(t *testing.T, client *http.Client, url string) int {
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	_, err = ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode
+}
+
+func TestCredentialRefresherRetriesWithReloadedCredentials(t *testing.T) {
+	t.Parallel()
+	ts := &tokenServer{token: "new"}
+	srv := httptest.NewServer(ts)
+	defer srv.Close()
+	loader := &testLoader{host: srv.URL, token: "new"}
+	r := NewCredentialRefresher(loader.load, time.Minute)
+	client := newTestClient(t, srv, r, "old")
+
+	assert.Equal(t, http.StatusOK, get(t, client, srv.URL+"/api/v1/namespaces"))
+	assert.Equal(t, 1, ts.unauthorizedCount())
+	assert.Equal(t, 1, loader.loadCount())
+
+	// Clients built before the reload use reloaded credentials from now on
+	other := newTestClient(t, srv, r, "old")
+	assert.Equal(t, http.StatusOK, get(t, client, srv.URL+"/api/v1/namespaces"))
+	assert.Equal(t, http.StatusOK, get(t, other, srv.URL+"/api/v1/namespaces"))
+	assert.Equal(t, 1, ts.unauthorizedCount())
+	assert.Equal(t, 1, loader.loadCount())
+}
+
+func TestCredentialRefresherRateLimitsReloads(t *testing.T) {
+	t.Parallel()
+	ts := &tokenServer{token: "new"}
+	srv := httptest.NewServer(ts)
+	defer srv.Close()
+	// Credentials have not been rotated yet
+	loader := &testLoader{host: srv.URL, token: "old"}
+	r := NewCredentialRefresher(loader.load, time.Minute)
+	now := time.Now()
+	r.now = func() time.Time {
+		return now
+	}
+	client := newTestClient(t, srv, r, "old")
+
+	assert.Equal(t, http.StatusUnauthorized, get(t, client, srv.URL+"/api/v1/namespaces"))
+	assert.Equal(t, 1, loader.loadCount())
+	assert.Equal(t, http.StatusUnauthorized, get(t, client, srv.URL+"/api/v1/namespaces"))
+	assert.Equal(t, 1, loader.loadCount())
+
+	loader.mx.Lock()
+	loader.token = "new"
+	loader.mx.Unlock()
+	now = now.Add(time.Minute)
+	assert.Equal(t, http.StatusOK, get(t, client, srv.URL+"/api/v1/namespaces"))
+	assert.Equal(t, 2, loader.loadCount())
+}
+
+func TestCredentialRefresherClosesStaleWatches(t *testing.T) {
+	t.Parallel()
+	ts := &tokenServer{token: "old"}
+	srv := httptest.NewServer(ts)
+	defer srv.Close()
+	loader := &testLoader{host: srv.URL, token: "new"}
+	r := NewCredentialRefresher(loader.load, time.Minute)
+	client := newTestClient(t, srv, r, "old")
+
+	resp, err := client.Get(srv.URL + "/api/v1/configmaps?watch=true")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	watchClosed := make(chan struct{})
+	go func() {
+		defer close(watchClosed)
+		ioutil.ReadAll(resp.Body)
+	}()
+
+	// Credentials are rotated, the next request is rejected and triggers a reload
+	ts.setToken("new")
+	assert.Equal(t, http.StatusOK, get(t, client, srv.URL+"/api/v1/namespaces"))
+
+	select {
+	case <-watchClosed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch established with stale credentials was not closed")
+	}
+
+	// A new watch is established with reloaded credentials
+	resp2, err := client.Get(srv.URL + "/api/v1/configmaps?watch=true")
+	require.NoError(t, err)
+	resp2.Body.Close()
+	assert.Equal(t, http.StatusOK, resp2.StatusCode)
+}
+
+func TestIsWatch(t *testing.T) {
+	t.Parallel()
+	testcases := map[string]bool{
+		"/api/v1/configmaps?watch=true":               true,
+		"/api/v1/configmaps?watch=1":                  true,
+		"/api/v1/watch/namespaces/ns/configmaps":      true,
+		"/api/v1/configmaps":                          false,
+		"/api/v1/configmaps?watch=false":              false,
+		"/api/v1/namespaces/watch?labelSelector=a%3D": false,
+	}
+	for url, expected := range testcases {
+		url := url
+		expected := expected
+		t.Run(strings.TrimPrefix(url, "/"), func(t *testing.T) {
+			t.Parallel()
+			req, err := http.NewRequest(http.MethodGet, "https://example.com"+url, nil)
+			require.NoError(t, err)
+			assert.Equal(t, expected, isWatch(req))
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package smart
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+)
+
+// ApplyPatchType is the patch type of server-side apply requests.
+// The client libraries Smith is built with predate server-side apply and do not define it.
+const ApplyPatchType types.PatchType = "application/apply-patch+yaml"
+
+// ApplyClient applies objects using server-side apply. Requires Kubernetes 1.16 or later.
+// The dynamic client does not allow setting the fieldManager parameter, so requests are made via REST clients
+// created for each group version.
+type ApplyClient struct {
+	RestConfig *rest.Config
+	Mapper     Mapper
+
+	clients restClients
+}
+
+// Apply applies the object on behalf of the field manager. Conflicts with other field managers are resolved
+// in favor of the object, i.e. fields set in it are taken over.
+func (c *ApplyClient) Apply(obj *unstructured.Unstructured, namespace, fieldManager string) (*unstructured.Unstructured, error) {
+	gvk := obj.GroupVersionKind()
+	rm, err := c.Mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get rest mapping for %s", gvk)
+	}
+	if err = checkScope(gvk, rm, namespace); err != nil {
+		return nil, err
+	}
+	client, err := c.clients.client(c.RestConfig, gvk.GroupVersion())
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(obj) // JSON is valid YAML
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	result, err := client.Patch(ApplyPatchType).
+		NamespaceIfScoped(namespace, namespace != meta_v1.NamespaceNone).
+		Resource(rm.Resource).
+		Name(obj.GetName()).
+		Param("fieldManager", fieldManager).
+		Param("force", "true").
+		Body(data).
+		Do().
+		Get()
+	if err != nil {
+		return nil, err
+	}
+	applied, ok := result.(*unstructured.Unstructured)
+	if !ok {
+		return nil, errors.Errorf("unexpected object type %T", result)
+	}
+	return applied, nil
+}
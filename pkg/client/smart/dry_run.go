@@ -0,0 +1,67 @@
+package smart
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/rest"
+)
+
+// DryRunClient validates creates and updates of objects with server-side dry-run: the request is processed by the
+// API server including admission and validation, but nothing is persisted. Requires Kubernetes 1.13 or later.
+// The dynamic client does not allow setting the dryRun parameter, so requests are made via REST clients
+// created for each group version.
+type DryRunClient struct {
+	RestConfig *rest.Config
+	Mapper     Mapper
+
+	clients restClients
+}
+
+// DryRunCreate returns the error the API server would reject the creation of the object with, if any.
+func (c *DryRunClient) DryRunCreate(obj *unstructured.Unstructured, namespace string) error {
+	req, err := c.request(obj, namespace, func(client rest.Interface) *rest.Request {
+		return client.Post()
+	})
+	if err != nil {
+		return err
+	}
+	return req.Do().Error()
+}
+
+// DryRunUpdate returns the error the API server would reject the update of the object with, if any.
+func (c *DryRunClient) DryRunUpdate(obj *unstructured.Unstructured, namespace string) error {
+	req, err := c.request(obj, namespace, func(client rest.Interface) *rest.Request {
+		return client.Put()
+	})
+	if err != nil {
+		return err
+	}
+	return req.Name(obj.GetName()).Do().Error()
+}
+
+func (c *DryRunClient) request(obj *unstructured.Unstructured, namespace string, verb func(rest.Interface) *rest.Request) (*rest.Request, error) {
+	gvk := obj.GroupVersionKind()
+	rm, err := c.Mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get rest mapping for %s", gvk)
+	}
+	if err = checkScope(gvk, rm, namespace); err != nil {
+		return nil, err
+	}
+	client, err := c.clients.client(c.RestConfig, gvk.GroupVersion())
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return verb(client).
+		NamespaceIfScoped(namespace, namespace != meta_v1.NamespaceNone).
+		Resource(rm.Resource).
+		Param("dryRun", "All").
+		Body(data), nil
+}
@@ -0,0 +1,85 @@
+package smart
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	api_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+)
+
+type configMapMapper struct{}
+
+func (configMapMapper) RESTMapping(gk schema.GroupKind, versions ...string) (*meta.RESTMapping, error) {
+	return &meta.RESTMapping{
+		Resource:         "configmaps",
+		GroupVersionKind: schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"},
+		Scope:            meta.RESTScopeNamespace,
+	}, nil
+}
+
+func TestDryRunClient(t *testing.T) {
+	t.Parallel()
+	var mx sync.Mutex
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mx.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery)
+		mx.Unlock()
+		var obj map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&obj); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if obj["data"] != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			json.NewEncoder(w).Encode(&meta_v1.Status{
+				TypeMeta: meta_v1.TypeMeta{Kind: "Status", APIVersion: "v1"},
+				Status:   meta_v1.StatusFailure,
+				Reason:   meta_v1.StatusReasonInvalid,
+				Message:  "data is invalid",
+				Code:     http.StatusUnprocessableEntity,
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(obj)
+	}))
+	defer srv.Close()
+	client := &DryRunClient{
+		RestConfig: &rest.Config{Host: srv.URL},
+		Mapper:     configMapMapper{},
+	}
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name": "map1",
+			},
+		},
+	}
+
+	require.NoError(t, client.DryRunCreate(obj, "ns"))
+	require.NoError(t, client.DryRunUpdate(obj, "ns"))
+	mx.Lock()
+	assert.Equal(t, []string{
+		"POST /api/v1/namespaces/ns/configmaps?dryRun=All",
+		"PUT /api/v1/namespaces/ns/configmaps/map1?dryRun=All",
+	}, requests)
+	mx.Unlock()
+
+	obj.Object["data"] = "invalid"
+	err := client.DryRunCreate(obj, "ns")
+	require.Error(t, err)
+	assert.True(t, api_errors.IsInvalid(err))
+}
@@ -0,0 +1,203 @@
+package smart
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	lookupResultHit         = "hit"
+	lookupResultNegativeHit = "negative_hit"
+	lookupResultMiss        = "miss"
+)
+
+// resetter is implemented by mappers that cache discovery information, e.g. discovery.DeferredDiscoveryRESTMapper.
+type resetter interface {
+	Reset()
+}
+
+type mappingKey struct {
+	gk       schema.GroupKind
+	versions string
+}
+
+type mappingEntry struct {
+	mapping *meta.RESTMapping
+	err     error
+	// Negative entries expire, positive ones are kept until invalidated.
+	expires time.Time
+}
+
+// MappingDecision describes a cached result of a GroupKind to resource resolution.
+type MappingDecision struct {
+	Group    string    `json:"group"`
+	Kind     string    `json:"kind"`
+	Versions []string  `json:"versions,omitempty"`
+	Version  string    `json:"version,omitempty"`
+	Resource string    `json:"resource,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Expires  time.Time `json:"expires,omitempty"`
+}
+
+// CachingMapper caches mappings resolved by a Mapper.
+// Failures to find a mapping for a kind are cached for the negative TTL so that repeated lookups of unknown kinds do
+// not cause repeated discovery calls. Other errors are not cached.
+type CachingMapper struct {
+	mapper      Mapper
+	negativeTTL time.Duration
+	now         func() time.Time
+	lookups     *prometheus.CounterVec
+
+	mx      sync.RWMutex
+	entries map[mappingKey]mappingEntry
+}
+
+func NewCachingMapper(mapper Mapper, negativeTTL time.Duration) *CachingMapper {
+	return &CachingMapper{
+		mapper:      mapper,
+		negativeTTL: negativeTTL,
+		now:         time.Now,
+		lookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "smith",
+			Subsystem: "rest_mapper",
+			Name:      "lookups_total",
+			Help:      "Number of REST mapping lookups by result: hit, negative_hit (cached failure) or miss",
+		}, []string{"result"}),
+		entries: make(map[mappingKey]mappingEntry),
+	}
+}
+
+// RegisterMetrics registers metrics of the mapper with the registerer.
+func (m *CachingMapper) RegisterMetrics(registerer prometheus.Registerer) error {
+	return errors.WithStack(registerer.Register(m.lookups))
+}
+
+func (m *CachingMapper) RESTMapping(gk schema.GroupKind, versions ...string) (*meta.RESTMapping, error) {
+	key := mappingKey{
+		gk:       gk,
+		versions: strings.Join(versions, ","),
+	}
+	m.mx.RLock()
+	entry, ok := m.entries[key]
+	m.mx.RUnlock()
+	if ok {
+		if entry.err == nil {
+			m.lookups.WithLabelValues(lookupResultHit).Inc()
+			return entry.mapping, nil
+		}
+		if m.now().Before(entry.expires) {
+			m.lookups.WithLabelValues(lookupResultNegativeHit).Inc()
+			return nil, entry.err
+		}
+		// Expired negative entry. The kind may have become available since.
+		m.mx.Lock()
+		m.resetMapper()
+		m.mx.Unlock()
+	}
+	m.lookups.WithLabelValues(lookupResultMiss).Inc()
+	mapping, err := m.mapper.RESTMapping(gk, versions...)
+	switch {
+	case err == nil:
+		entry = mappingEntry{mapping: mapping}
+	case meta.IsNoMatchError(err) && m.negativeTTL > 0:
+		entry = mappingEntry{err: err, expires: m.now().Add(m.negativeTTL)}
+	default:
+		return nil, err
+	}
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.entries[key] = entry
+	return mapping, err
+}
+
+// Invalidate removes all cached mappings.
+func (m *CachingMapper) Invalidate() {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.entries = make(map[mappingKey]mappingEntry)
+	m.resetMapper()
+}
+
+// InvalidateGroupKind removes cached mappings for the kind.
+// Should be called when an API for the kind is added or removed, e.g. when a CRD is created or deleted.
+func (m *CachingMapper) InvalidateGroupKind(gk schema.GroupKind) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	for key := range m.entries {
+		if key.gk == gk {
+			delete(m.entries, key)
+		}
+	}
+	m.resetMapper()
+}
+
+func (m *CachingMapper) resetMapper() {
+	// Underlying mapper may have cached discovery information that does not include the kind
+	if r, ok := m.mapper.(resetter); ok {
+		r.Reset()
+	}
+}
+
+// Decisions returns cached mappings sorted by group and kind.
+func (m *CachingMapper) Decisions() []MappingDecision {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	decisions := make([]MappingDecision, 0, len(m.entries))
+	for key, entry := range m.entries {
+		d := MappingDecision{
+			Group: key.gk.Group,
+			Kind:  key.gk.Kind,
+		}
+		if key.versions != "" {
+			d.Versions = strings.Split(key.versions, ",")
+		}
+		if entry.err == nil {
+			d.Version = entry.mapping.GroupVersionKind.Version
+			d.Resource = entry.mapping.Resource
+		} else {
+			d.Error = entry.err.Error()
+			d.Expires = entry.expires
+		}
+		decisions = append(decisions, d)
+	}
+	sort.Slice(decisions, func(i, j int) bool {
+		a, b := decisions[i], decisions[j]
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return strings.Join(a.Versions, ",") < strings.Join(b.Versions, ",")
+	})
+	return decisions
+}
+
+// ServeHTTP serves cached mappings as JSON on GET and invalidates the cache on DELETE.
+func (m *CachingMapper) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		data, err := json.MarshalIndent(m.Decisions(), "", "  ")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data) // Nothing can be done if write fails
+	case http.MethodDelete:
+		m.Invalidate()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, DELETE")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,118 @@
+package smart
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var widgetGK = schema.GroupKind{Group: "example.com", Kind: "Widget"}
+
+type fakeMapper struct {
+	known  bool
+	calls  int
+	resets int
+}
+
+func (m *fakeMapper) RESTMapping(gk schema.GroupKind, versions ...string) (*meta.RESTMapping, error) {
+	m.calls++
+	if !m.known {
+		return nil, &meta.NoKindMatchError{PartialKind: gk.WithVersion("")}
+	}
+	return &meta.RESTMapping{
+		Resource:         "widgets",
+		GroupVersionKind: gk.WithVersion("v1"),
+	}, nil
+}
+
+func (m *fakeMapper) Reset() {
+	m.resets++
+}
+
+func TestCachingMapperCachesMappings(t *testing.T) {
+	t.Parallel()
+	fm := &fakeMapper{known: true}
+	m := NewCachingMapper(fm, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		rm, err := m.RESTMapping(widgetGK, "v1")
+		require.NoError(t, err)
+		assert.Equal(t, "widgets", rm.Resource)
+	}
+	assert.Equal(t, 1, fm.calls)
+
+	decisions := m.Decisions()
+	require.Len(t, decisions, 1)
+	assert.Equal(t, MappingDecision{
+		Group:    "example.com",
+		Kind:     "Widget",
+		Versions: []string{"v1"},
+		Version:  "v1",
+		Resource: "widgets",
+	}, decisions[0])
+}
+
+func TestCachingMapperNegativeCacheExpires(t *testing.T) {
+	t.Parallel()
+	fm := &fakeMapper{}
+	m := NewCachingMapper(fm, time.Minute)
+	now := time.Now()
+	m.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		_, err := m.RESTMapping(widgetGK)
+		require.Error(t, err)
+		assert.True(t, meta.IsNoMatchError(err))
+	}
+	assert.Equal(t, 1, fm.calls)
+
+	// CRD got created
+	fm.known = true
+	now = now.Add(2 * time.Minute)
+	_, err := m.RESTMapping(widgetGK)
+	require.NoError(t, err)
+	assert.Equal(t, 2, fm.calls)
+	assert.Equal(t, 1, fm.resets)
+}
+
+func TestCachingMapperInvalidateGroupKind(t *testing.T) {
+	t.Parallel()
+	fm := &fakeMapper{}
+	m := NewCachingMapper(fm, time.Hour)
+
+	_, err := m.RESTMapping(widgetGK)
+	require.Error(t, err)
+
+	fm.known = true
+	m.InvalidateGroupKind(widgetGK)
+	_, err = m.RESTMapping(widgetGK)
+	require.NoError(t, err)
+	assert.Equal(t, 2, fm.calls)
+	assert.Equal(t, 1, fm.resets)
+}
+
+func TestCachingMapperServeHTTP(t *testing.T) {
+	t.Parallel()
+	fm := &fakeMapper{known: true}
+	m := NewCachingMapper(fm, time.Minute)
+	require.NoError(t, m.RegisterMetrics(prometheus.NewPedanticRegistry()))
+	_, err := m.RESTMapping(widgetGK)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"resource": "widgets"`)
+
+	w = httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, m.Decisions())
+}
@@ -0,0 +1,40 @@
+package smart
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+)
+
+// restClients caches REST clients for group versions. Used for requests that the dynamic client cannot make.
+type restClients struct {
+	mx      sync.Mutex
+	clients map[schema.GroupVersion]rest.Interface
+}
+
+func (c *restClients) client(restConfig *rest.Config, gv schema.GroupVersion) (rest.Interface, error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if client, ok := c.clients[gv]; ok {
+		return client, nil
+	}
+	config := rest.CopyConfig(restConfig)
+	config.GroupVersion = &gv
+	config.APIPath = dynamic.LegacyAPIPathResolverFunc(schema.GroupVersionKind{Group: gv.Group, Version: gv.Version})
+	config.ContentConfig = dynamic.ContentConfig()
+	if config.UserAgent == "" {
+		config.UserAgent = rest.DefaultKubernetesUserAgent()
+	}
+	client, err := rest.RESTClientFor(config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create REST client for %s", gv)
+	}
+	if c.clients == nil {
+		c.clients = make(map[schema.GroupVersion]rest.Interface)
+	}
+	c.clients[gv] = client
+	return client, nil
+}
@@ -0,0 +1,39 @@
+package smart
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ScopeMismatchError means a client for a cluster-scoped kind was requested in a namespace.
+// Requests for such a client would be sent to a namespaced path that does not exist.
+type ScopeMismatchError struct {
+	GVK       schema.GroupVersionKind
+	Namespace string
+}
+
+func (e *ScopeMismatchError) Error() string {
+	return fmt.Sprintf("%s is cluster-scoped and cannot be used in namespace %q", e.GVK, e.Namespace)
+}
+
+// IsScopeMismatch returns true if the cause of the error is a ScopeMismatchError.
+func IsScopeMismatch(err error) bool {
+	_, ok := errors.Cause(err).(*ScopeMismatchError)
+	return ok
+}
+
+// checkScope returns a ScopeMismatchError if the mapping is for a cluster-scoped kind and the namespace is not empty.
+// Namespaced kinds may be used without a namespace to access objects in all namespaces.
+func checkScope(gvk schema.GroupVersionKind, rm *meta.RESTMapping, namespace string) error {
+	if rm.Scope == nil || rm.Scope.Name() != meta.RESTScopeNameRoot || namespace == meta_v1.NamespaceNone {
+		return nil
+	}
+	return errors.WithStack(&ScopeMismatchError{
+		GVK:       gvk,
+		Namespace: namespace,
+	})
+}
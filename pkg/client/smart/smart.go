@@ -4,6 +4,7 @@ import (
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 )
@@ -23,21 +24,91 @@ type ClientPool interface {
 type DynamicClient struct {
 	ClientPool ClientPool
 	Mapper     Mapper
+	// WriteClientPools are used instead of ClientPool to create, update and delete objects of particular kinds.
+	// E.g. pools with a shorter request timeout for kinds guarded by slow admission webhooks. Optional.
+	WriteClientPools map[schema.GroupKind]ClientPool
+	// Namespaces limits the client to objects in the namespaces. Empty means there is no limit. Optional.
+	Namespaces []string
 }
 
+// ForGVK returns a client for objects of the kind in the namespace.
+// A ScopeMismatchError is returned if the kind is cluster-scoped and the namespace is not empty.
 func (c *DynamicClient) ForGVK(gvk schema.GroupVersionKind, namespace string) (dynamic.ResourceInterface, error) {
-	client, err := c.ClientPool.ClientForGroupVersionKind(gvk)
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to instantiate client for %s", gvk)
+	if err := c.checkNamespace(namespace); err != nil {
+		return nil, err
 	}
 	rm, err := c.Mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to get rest mapping for %s", gvk)
 	}
+	if err = checkScope(gvk, rm, namespace); err != nil {
+		return nil, err
+	}
+	resClient, err := resourceClient(c.ClientPool, gvk, rm, namespace)
+	if err != nil {
+		return nil, err
+	}
+	writePool, ok := c.WriteClientPools[gvk.GroupKind()]
+	if !ok {
+		return resClient, nil
+	}
+	writeClient, err := resourceClient(writePool, gvk, rm, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return writeResource{
+		ResourceInterface: resClient,
+		write:             writeClient,
+	}, nil
+}
 
+func resourceClient(pool ClientPool, gvk schema.GroupVersionKind, rm *meta.RESTMapping, namespace string) (dynamic.ResourceInterface, error) {
+	client, err := pool.ClientForGroupVersionKind(gvk)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to instantiate client for %s", gvk)
+	}
 	return client.Resource(&meta_v1.APIResource{
 		Name:       rm.Resource,
 		Namespaced: namespace != meta_v1.NamespaceNone,
 		Kind:       gvk.Kind,
 	}, namespace), nil
 }
+
+// writeResource sends create, update and delete requests via a separate client.
+// Reads, watches and other requests go via the embedded client.
+type writeResource struct {
+	dynamic.ResourceInterface
+	write dynamic.ResourceInterface
+}
+
+func (r writeResource) Create(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	return r.write.Create(obj)
+}
+
+func (r writeResource) Update(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	return r.write.Update(obj)
+}
+
+func (r writeResource) Delete(name string, opts *meta_v1.DeleteOptions) error {
+	return r.write.Delete(name, opts)
+}
+
+// checkNamespace returns an error if the client is limited to a set of namespaces and the namespace is not one of them.
+func (c *DynamicClient) checkNamespace(namespace string) error {
+	if len(c.Namespaces) == 0 {
+		return nil
+	}
+	for _, ns := range c.Namespaces {
+		if ns == namespace {
+			return nil
+		}
+	}
+	return errors.Errorf("client is limited to namespaces %q, %q is not one of them", c.Namespaces, namespace)
+}
+
+// InvalidateGroupKind removes cached mappings for the kind if Mapper caches them.
+func (c *DynamicClient) InvalidateGroupKind(gk schema.GroupKind) {
+	if m, ok := c.Mapper.(*CachingMapper); ok {
+		m.InvalidateGroupKind(gk)
+	}
+}
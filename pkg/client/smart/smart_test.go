@@ -0,0 +1,113 @@
+package smart
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// recordingResource records names of methods called on it. Methods that are not overridden panic.
+type recordingResource struct {
+	dynamic.ResourceInterface
+	calls *[]string
+	name  string
+}
+
+func (r recordingResource) Get(name string, opts meta_v1.GetOptions) (*unstructured.Unstructured, error) {
+	*r.calls = append(*r.calls, r.name+".Get")
+	return &unstructured.Unstructured{}, nil
+}
+
+func (r recordingResource) Create(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	*r.calls = append(*r.calls, r.name+".Create")
+	return obj, nil
+}
+
+func (r recordingResource) Update(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	*r.calls = append(*r.calls, r.name+".Update")
+	return obj, nil
+}
+
+func (r recordingResource) Delete(name string, opts *meta_v1.DeleteOptions) error {
+	*r.calls = append(*r.calls, r.name+".Delete")
+	return nil
+}
+
+func TestWriteResource(t *testing.T) {
+	t.Parallel()
+	var calls []string
+	res := writeResource{
+		ResourceInterface: recordingResource{calls: &calls, name: "read"},
+		write:             recordingResource{calls: &calls, name: "write"},
+	}
+
+	obj := &unstructured.Unstructured{}
+	_, err := res.Get("a", meta_v1.GetOptions{})
+	require.NoError(t, err)
+	_, err = res.Create(obj)
+	require.NoError(t, err)
+	_, err = res.Update(obj)
+	require.NoError(t, err)
+	require.NoError(t, res.Delete("a", &meta_v1.DeleteOptions{}))
+
+	assert.Equal(t, []string{"read.Get", "write.Create", "write.Update", "write.Delete"}, calls)
+}
+
+type fixedMapper struct {
+	scope meta.RESTScope
+}
+
+func (m fixedMapper) RESTMapping(gk schema.GroupKind, versions ...string) (*meta.RESTMapping, error) {
+	return &meta.RESTMapping{
+		Resource: "namespaces",
+		Scope:    m.scope,
+	}, nil
+}
+
+func TestForGVKScopeMismatch(t *testing.T) {
+	t.Parallel()
+	c := &DynamicClient{
+		Mapper: fixedMapper{scope: meta.RESTScopeRoot},
+	}
+	gvk := schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}
+
+	_, err := c.ForGVK(gvk, "ns1")
+	require.Error(t, err)
+	assert.True(t, IsScopeMismatch(err))
+	assert.Contains(t, err.Error(), "cluster-scoped")
+}
+
+func TestCheckScope(t *testing.T) {
+	t.Parallel()
+	gvk := schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+	namespaced := &meta.RESTMapping{Scope: meta.RESTScopeNamespace}
+	root := &meta.RESTMapping{Scope: meta.RESTScopeRoot}
+
+	assert.NoError(t, checkScope(gvk, namespaced, "ns1"))
+	assert.NoError(t, checkScope(gvk, namespaced, meta_v1.NamespaceNone))
+	assert.NoError(t, checkScope(gvk, root, meta_v1.NamespaceNone))
+	assert.True(t, IsScopeMismatch(checkScope(gvk, root, "ns1")))
+	assert.False(t, IsScopeMismatch(errors.New("other")))
+}
+
+func TestForGVKNamespaceNotAllowed(t *testing.T) {
+	t.Parallel()
+	c := &DynamicClient{
+		Mapper:     fixedMapper{scope: meta.RESTScopeNamespace},
+		Namespaces: []string{"ns1", "ns2"},
+	}
+	gvk := schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+
+	_, err := c.ForGVK(gvk, "ns3")
+	require.EqualError(t, err, `client is limited to namespaces ["ns1" "ns2"], "ns3" is not one of them`)
+	_, err = c.ForGVK(gvk, meta_v1.NamespaceNone)
+	require.Error(t, err)
+	assert.NoError(t, c.checkNamespace("ns2"))
+}
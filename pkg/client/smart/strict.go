@@ -0,0 +1,79 @@
+package smart
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/rest"
+)
+
+// StrictClient creates and updates objects with strict server-side field validation: the API server rejects
+// objects with unknown or duplicate fields instead of dropping them. Requires Kubernetes 1.25 or later, older
+// API servers ignore the parameter. The dynamic client does not allow setting the fieldValidation parameter,
+// so requests are made via REST clients created for each group version.
+type StrictClient struct {
+	RestConfig *rest.Config
+	Mapper     Mapper
+
+	clients restClients
+}
+
+// StrictCreate creates the object.
+func (c *StrictClient) StrictCreate(obj *unstructured.Unstructured, namespace string) (*unstructured.Unstructured, error) {
+	req, err := c.request(obj, namespace, func(client rest.Interface) *rest.Request {
+		return client.Post()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return decodeResult(req.Do())
+}
+
+// StrictUpdate updates the object.
+func (c *StrictClient) StrictUpdate(obj *unstructured.Unstructured, namespace string) (*unstructured.Unstructured, error) {
+	req, err := c.request(obj, namespace, func(client rest.Interface) *rest.Request {
+		return client.Put()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return decodeResult(req.Name(obj.GetName()).Do())
+}
+
+func (c *StrictClient) request(obj *unstructured.Unstructured, namespace string, verb func(rest.Interface) *rest.Request) (*rest.Request, error) {
+	gvk := obj.GroupVersionKind()
+	rm, err := c.Mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get rest mapping for %s", gvk)
+	}
+	if err = checkScope(gvk, rm, namespace); err != nil {
+		return nil, err
+	}
+	client, err := c.clients.client(c.RestConfig, gvk.GroupVersion())
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return verb(client).
+		NamespaceIfScoped(namespace, namespace != meta_v1.NamespaceNone).
+		Resource(rm.Resource).
+		Param("fieldValidation", "Strict").
+		Body(data), nil
+}
+
+func decodeResult(result rest.Result) (*unstructured.Unstructured, error) {
+	obj, err := result.Get()
+	if err != nil {
+		return nil, err
+	}
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, errors.Errorf("unexpected object type %T", obj)
+	}
+	return u, nil
+}
@@ -0,0 +1,77 @@
+package smart
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	api_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/rest"
+)
+
+func TestStrictClient(t *testing.T) {
+	t.Parallel()
+	var mx sync.Mutex
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mx.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery)
+		mx.Unlock()
+		var obj map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&obj); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if obj["unknown"] != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(&meta_v1.Status{
+				TypeMeta: meta_v1.TypeMeta{Kind: "Status", APIVersion: "v1"},
+				Status:   meta_v1.StatusFailure,
+				Reason:   meta_v1.StatusReasonBadRequest,
+				Message:  `ConfigMap in version "v1" cannot be handled as a ConfigMap: strict decoding error: unknown field "unknown"`,
+				Code:     http.StatusBadRequest,
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(obj)
+	}))
+	defer srv.Close()
+	client := &StrictClient{
+		RestConfig: &rest.Config{Host: srv.URL},
+		Mapper:     configMapMapper{},
+	}
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name": "map1",
+			},
+		},
+	}
+
+	created, err := client.StrictCreate(obj, "ns")
+	require.NoError(t, err)
+	assert.Equal(t, obj, created)
+	updated, err := client.StrictUpdate(obj, "ns")
+	require.NoError(t, err)
+	assert.Equal(t, obj, updated)
+	mx.Lock()
+	assert.Equal(t, []string{
+		"POST /api/v1/namespaces/ns/configmaps?fieldValidation=Strict",
+		"PUT /api/v1/namespaces/ns/configmaps/map1?fieldValidation=Strict",
+	}, requests)
+	mx.Unlock()
+
+	obj.Object["unknown"] = "field"
+	_, err = client.StrictCreate(obj, "ns")
+	require.Error(t, err)
+	assert.True(t, api_errors.IsBadRequest(err))
+}
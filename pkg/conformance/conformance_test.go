@@ -0,0 +1,320 @@
+package conformance
+
+import (
+	"testing"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/atlassian/smith/pkg/plugin"
+	ready_types "github.com/atlassian/smith/pkg/readychecker/types"
+	"github.com/pkg/errors"
+	apps_v1 "k8s.io/api/apps/v1"
+	batch_v1 "k8s.io/api/batch/v1"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type configMapPlugin struct{}
+
+func newConfigMapPlugin() (plugin.Plugin, error) {
+	return configMapPlugin{}, nil
+}
+
+func (configMapPlugin) Describe() *plugin.Description {
+	return &plugin.Description{
+		Name: "configMap",
+		GVK:  core_v1.SchemeGroupVersion.WithKind("ConfigMap"),
+		SpecSchema: []byte(`{
+			"type": "object",
+			"properties": {
+				"value": {"type": "string"}
+			},
+			"required": ["value"]
+		}`),
+	}
+}
+
+func (configMapPlugin) Process(spec map[string]interface{}, context *plugin.Context) (*plugin.ProcessResult, error) {
+	value := spec["value"].(string)
+	if value == "" {
+		return nil, errors.New("value must not be empty")
+	}
+	return &plugin.ProcessResult{
+		Object: &core_v1.ConfigMap{
+			TypeMeta: meta_v1.TypeMeta{
+				Kind:       "ConfigMap",
+				APIVersion: core_v1.SchemeGroupVersion.String(),
+			},
+			Data: map[string]string{
+				"value":     value,
+				"namespace": context.Namespace,
+			},
+		},
+	}, nil
+}
+
+func TestPluginHarness(t *testing.T) {
+	t.Parallel()
+	TestPlugin(t, newConfigMapPlugin,
+		PluginCase{
+			Name: "valid",
+			Spec: map[string]interface{}{"value": "a"},
+			Context: plugin.Context{
+				Dependencies: map[smith_v1.ResourceName]plugin.Dependency{
+					"dep": {
+						Actual: &core_v1.Secret{},
+					},
+				},
+			},
+		},
+		PluginCase{
+			Name:        "schema violation",
+			Spec:        map[string]interface{}{},
+			ExpectError: true,
+		},
+		PluginCase{
+			Name:        "rejected by plugin",
+			Spec:        map[string]interface{}{"value": ""},
+			ExpectError: true,
+		},
+	)
+}
+
+func TestReadinessHarnessDeployment(t *testing.T) {
+	t.Parallel()
+	replicas := int32(2)
+	deployment := func(observedGeneration int64, updatedReplicas int32) *apps_v1.Deployment {
+		return &apps_v1.Deployment{
+			TypeMeta: meta_v1.TypeMeta{
+				Kind:       "Deployment",
+				APIVersion: apps_v1.SchemeGroupVersion.String(),
+			},
+			ObjectMeta: meta_v1.ObjectMeta{
+				Generation: 2,
+			},
+			Spec: apps_v1.DeploymentSpec{
+				Replicas: &replicas,
+			},
+			Status: apps_v1.DeploymentStatus{
+				ObservedGeneration: observedGeneration,
+				UpdatedReplicas:    updatedReplicas,
+			},
+		}
+	}
+	isReady := ready_types.MainKnownTypes[schema.GroupKind{Group: apps_v1.GroupName, Kind: "Deployment"}]
+	TestReadiness(t, isReady,
+		ReadinessCase{
+			Name:   "ready",
+			Object: deployment(2, 2),
+			Ready:  true,
+		},
+		ReadinessCase{
+			Name:   "not observed",
+			Object: deployment(1, 2),
+			Ready:  false,
+		},
+		ReadinessCase{
+			Name:   "rolling out",
+			Object: deployment(2, 1),
+			Ready:  false,
+		},
+	)
+}
+
+func TestReadinessHarnessJob(t *testing.T) {
+	t.Parallel()
+	job := func(conditions ...batch_v1.JobCondition) *batch_v1.Job {
+		return &batch_v1.Job{
+			TypeMeta: meta_v1.TypeMeta{
+				Kind:       "Job",
+				APIVersion: batch_v1.SchemeGroupVersion.String(),
+			},
+			Status: batch_v1.JobStatus{
+				Conditions: conditions,
+			},
+		}
+	}
+	isReady := ready_types.MainKnownTypes[schema.GroupKind{Group: batch_v1.GroupName, Kind: "Job"}]
+	TestReadiness(t, isReady,
+		ReadinessCase{
+			Name:   "running",
+			Object: job(),
+			Ready:  false,
+		},
+		ReadinessCase{
+			Name:   "complete",
+			Object: job(batch_v1.JobCondition{Type: batch_v1.JobComplete, Status: core_v1.ConditionTrue}),
+			Ready:  true,
+		},
+		ReadinessCase{
+			Name:        "failed",
+			Object:      job(batch_v1.JobCondition{Type: batch_v1.JobFailed, Status: core_v1.ConditionTrue, Reason: "BackoffLimitExceeded"}),
+			ExpectError: true,
+			Retriable:   false,
+		},
+	)
+}
+
+func TestReadinessHarnessStatefulSet(t *testing.T) {
+	t.Parallel()
+	replicas := int32(2)
+	statefulSet := func(readyReplicas int32, updateRevision string) *apps_v1.StatefulSet {
+		return &apps_v1.StatefulSet{
+			TypeMeta: meta_v1.TypeMeta{
+				Kind:       "StatefulSet",
+				APIVersion: apps_v1.SchemeGroupVersion.String(),
+			},
+			Spec: apps_v1.StatefulSetSpec{
+				Replicas: &replicas,
+				UpdateStrategy: apps_v1.StatefulSetUpdateStrategy{
+					Type: apps_v1.RollingUpdateStatefulSetStrategyType,
+				},
+			},
+			Status: apps_v1.StatefulSetStatus{
+				ReadyReplicas:   readyReplicas,
+				UpdatedReplicas: readyReplicas,
+				CurrentRevision: "rev1",
+				UpdateRevision:  updateRevision,
+			},
+		}
+	}
+	isReady := ready_types.MainKnownTypes[schema.GroupKind{Group: apps_v1.GroupName, Kind: "StatefulSet"}]
+	TestReadiness(t, isReady,
+		ReadinessCase{
+			Name:   "ready",
+			Object: statefulSet(2, "rev1"),
+			Ready:  true,
+		},
+		ReadinessCase{
+			Name:   "scaling up",
+			Object: statefulSet(1, "rev1"),
+			Ready:  false,
+		},
+		ReadinessCase{
+			Name:   "rolling out",
+			Object: statefulSet(2, "rev2"),
+			Ready:  false,
+		},
+	)
+}
+
+func TestReadinessHarnessPersistentVolumeClaim(t *testing.T) {
+	t.Parallel()
+	pvc := func(phase core_v1.PersistentVolumeClaimPhase) *core_v1.PersistentVolumeClaim {
+		return &core_v1.PersistentVolumeClaim{
+			TypeMeta: meta_v1.TypeMeta{
+				Kind:       "PersistentVolumeClaim",
+				APIVersion: core_v1.SchemeGroupVersion.String(),
+			},
+			Status: core_v1.PersistentVolumeClaimStatus{
+				Phase: phase,
+			},
+		}
+	}
+	isReady := ready_types.MainKnownTypes[schema.GroupKind{Group: core_v1.GroupName, Kind: "PersistentVolumeClaim"}]
+	TestReadiness(t, isReady,
+		ReadinessCase{
+			Name:   "pending",
+			Object: pvc(core_v1.ClaimPending),
+			Ready:  false,
+		},
+		ReadinessCase{
+			Name:   "bound",
+			Object: pvc(core_v1.ClaimBound),
+			Ready:  true,
+		},
+		ReadinessCase{
+			Name:        "lost",
+			Object:      pvc(core_v1.ClaimLost),
+			ExpectError: true,
+			Retriable:   false,
+		},
+	)
+}
+
+func TestReadinessHarnessService(t *testing.T) {
+	t.Parallel()
+	service := func(serviceType core_v1.ServiceType, ingress ...core_v1.LoadBalancerIngress) *core_v1.Service {
+		return &core_v1.Service{
+			TypeMeta: meta_v1.TypeMeta{
+				Kind:       "Service",
+				APIVersion: core_v1.SchemeGroupVersion.String(),
+			},
+			Spec: core_v1.ServiceSpec{
+				Type: serviceType,
+			},
+			Status: core_v1.ServiceStatus{
+				LoadBalancer: core_v1.LoadBalancerStatus{
+					Ingress: ingress,
+				},
+			},
+		}
+	}
+	isReady := ready_types.MainKnownTypes[schema.GroupKind{Group: core_v1.GroupName, Kind: "Service"}]
+	TestReadiness(t, isReady,
+		ReadinessCase{
+			Name:   "cluster IP",
+			Object: service(core_v1.ServiceTypeClusterIP),
+			Ready:  true,
+		},
+		ReadinessCase{
+			Name:   "load balancer pending",
+			Object: service(core_v1.ServiceTypeLoadBalancer),
+			Ready:  false,
+		},
+		ReadinessCase{
+			Name:   "load balancer provisioned",
+			Object: service(core_v1.ServiceTypeLoadBalancer, core_v1.LoadBalancerIngress{Hostname: "lb.example.com"}),
+			Ready:  true,
+		},
+	)
+}
+
+// provisioner is a fake external system.
+type provisioner struct {
+	keys []string
+}
+
+func (p *provisioner) IdempotencyKeys() []string {
+	return p.keys
+}
+
+type provisioningPlugin struct {
+	configMapPlugin
+	provisioner *provisioner
+}
+
+func (p provisioningPlugin) Process(spec map[string]interface{}, context *plugin.Context) (*plugin.ProcessResult, error) {
+	result, err := p.configMapPlugin.Process(spec, context)
+	if err != nil {
+		return nil, err
+	}
+	if !context.DryRun {
+		p.provisioner.keys = append(p.provisioner.keys, context.IdempotencyKey)
+	}
+	return result, nil
+}
+
+func TestPluginSideEffectsHarness(t *testing.T) {
+	t.Parallel()
+	prov := &provisioner{}
+	newPlugin := func() (plugin.Plugin, error) {
+		return provisioningPlugin{provisioner: prov}, nil
+	}
+	cases := []PluginCase{
+		{
+			Name: "valid",
+			Spec: map[string]interface{}{"value": "a"},
+			Context: plugin.Context{
+				IdempotencyKey: "uid/res1",
+			},
+		},
+		{
+			Name:        "rejected by plugin",
+			Spec:        map[string]interface{}{"value": ""},
+			ExpectError: true,
+		},
+	}
+	TestPlugin(t, newPlugin, cases...)
+	TestPluginSideEffects(t, newPlugin, prov, cases...)
+}
@@ -0,0 +1,13 @@
+// Package conformance contains test harnesses for authors of Smith plugins and readiness checks.
+//
+// Smith may invoke extensions many times for the same input: after a failure, on every resync and after a
+// restart. Extensions must be deterministic, must not mutate their input and must produce output that is stable when
+// fed back as the actual object. Harnesses in this package verify these properties. Call them from a regular Go test:
+//
+//	func TestConformance(t *testing.T) {
+//		conformance.TestPlugin(t, NewPlugin, conformance.PluginCase{
+//			Name: "minimal",
+//			Spec: map[string]interface{}{"name": "x"},
+//		})
+//	}
+package conformance
@@ -0,0 +1,218 @@
+package conformance
+
+import (
+	"testing"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/atlassian/smith/pkg/plugin"
+	"github.com/atlassian/smith/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// PluginCase is an input for a plugin.
+type PluginCase struct {
+	// Name of the test case.
+	Name string
+	// Spec is the plugin spec as found in a Bundle.
+	Spec map[string]interface{}
+	// Context passed to the plugin. Namespace defaults to "default", IdempotencyKey defaults to a key derived from
+	// the name of the case. Actual and DryRun are ignored.
+	Context plugin.Context
+	// ExpectError means the plugin must reject the input. Either from spec validation or from Process.
+	ExpectError bool
+}
+
+// TestPlugin verifies that the plugin behaves correctly when invoked by Smith.
+// The following properties are checked:
+// - the plugin has a name, a GVK and a valid spec schema;
+// - description of the plugin does not change between calls;
+// - specs are validated against the schema before Process is invoked, the same way Smith does it;
+// - Process does not mutate the spec or the context;
+// - Process is deterministic, i.e. retries with the same input produce the same object;
+// - Process produces the same object in dry-run mode;
+// - the input and the result conform to the plugin contract and the output schema of the plugin;
+// - the produced object has the GVK declared in the description;
+// - feeding the produced object back as the actual object produces the same object, i.e. updates are idempotent.
+func TestPlugin(t *testing.T, newFunc plugin.NewFunc, cases ...PluginCase) {
+	pluginContainer, err := plugin.NewPluginContainer(newFunc)
+	require.NoError(t, err)
+	p := pluginContainer.Plugin
+	description := p.Describe()
+	require.NotNil(t, description)
+	assert.NotEmpty(t, description.Name, "plugin must have a name")
+	assert.NotEmpty(t, description.GVK.Kind, "plugin must declare a Kind")
+	assert.NotEmpty(t, description.GVK.Version, "plugin must declare a Version")
+	assert.Equal(t, description, p.Describe(), "description must not change between calls")
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			testPluginCase(t, pluginContainer, c)
+		})
+	}
+}
+
+func testPluginCase(t *testing.T, pluginContainer plugin.PluginContainer, c PluginCase) {
+	p := pluginContainer.Plugin
+	if err := pluginContainer.ValidateSpec(c.Spec); err != nil {
+		assert.True(t, c.ExpectError, "unexpected spec validation error: %v", err)
+		return
+	}
+	context := caseContext(c)
+	inv, err := plugin.NewInvocation(p.Describe().Name, c.Spec, &context)
+	require.NoError(t, err)
+	require.NoError(t, plugin.ValidateInvocation(inv), "invocation must conform to the plugin contract")
+	specCopy := runtime.DeepCopyJSON(c.Spec)
+	contextCopy := copyContext(context)
+
+	first, err := p.Process(c.Spec, &context)
+	if c.ExpectError {
+		assert.Error(t, err, "plugin must reject the input")
+		return
+	}
+	require.NoError(t, err)
+	assert.True(t, equality.Semantic.DeepEqual(specCopy, runtime.DeepCopyJSON(c.Spec)), "Process must not mutate the spec")
+	assert.True(t, equality.Semantic.DeepEqual(*contextCopy, context), "Process must not mutate the context")
+	require.NotNil(t, first)
+	require.NotNil(t, first.Object)
+	firstUnstr, err := pluginContainer.ValidateResult(first)
+	require.NoError(t, err, "result must conform to the plugin contract")
+	assert.Equal(t, p.Describe().GVK, firstUnstr.GroupVersionKind(), "produced object must have the declared GVK")
+
+	// Retry with the same input
+	second, err := p.Process(runtime.DeepCopyJSON(specCopy), copyContext(contextCopy))
+	require.NoError(t, err)
+	secondUnstr, err := util.RuntimeToUnstructured(second.Object)
+	require.NoError(t, err)
+	assert.True(t, equality.Semantic.DeepEqual(firstUnstr.Object, secondUnstr.Object), "Process must be deterministic")
+
+	// Dry-run
+	dryRunContext := copyContext(contextCopy)
+	dryRunContext.DryRun = true
+	dryRun, err := p.Process(runtime.DeepCopyJSON(specCopy), dryRunContext)
+	require.NoError(t, err)
+	dryRunUnstr, err := util.RuntimeToUnstructured(dryRun.Object)
+	require.NoError(t, err)
+	assert.True(t, equality.Semantic.DeepEqual(firstUnstr.Object, dryRunUnstr.Object), "Process must produce the same object in dry-run mode")
+
+	// Update of an existing object
+	updateContext := copyContext(contextCopy)
+	updateContext.Actual = first.Object.DeepCopyObject()
+	third, err := p.Process(runtime.DeepCopyJSON(specCopy), updateContext)
+	require.NoError(t, err)
+	thirdUnstr, err := util.RuntimeToUnstructured(third.Object)
+	require.NoError(t, err)
+	assert.True(t, equality.Semantic.DeepEqual(firstUnstr.Object, thirdUnstr.Object), "Process must be idempotent when the object exists already")
+}
+
+// SideEffectRecorder records external side effects of a plugin under test.
+// Usually it is a fake client of the external system the plugin provisions resources in.
+type SideEffectRecorder interface {
+	// IdempotencyKeys returns idempotency keys that were passed along with all side effects recorded so far.
+	IdempotencyKeys() []string
+}
+
+// TestPluginSideEffects verifies that a plugin that causes external side effects honors the side-effect contract:
+// - Process does not cause side effects in dry-run mode;
+// - side effects caused by Process carry the idempotency key from the context, so they can be deduplicated.
+// The plugin returned by newFunc must report its side effects to the recorder.
+func TestPluginSideEffects(t *testing.T, newFunc plugin.NewFunc, recorder SideEffectRecorder, cases ...PluginCase) {
+	pluginContainer, err := plugin.NewPluginContainer(newFunc)
+	require.NoError(t, err)
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			testPluginSideEffects(t, pluginContainer, recorder, c)
+		})
+	}
+}
+
+func testPluginSideEffects(t *testing.T, pluginContainer plugin.PluginContainer, recorder SideEffectRecorder, c PluginCase) {
+	if err := pluginContainer.ValidateSpec(c.Spec); err != nil {
+		assert.True(t, c.ExpectError, "unexpected spec validation error: %v", err)
+		return
+	}
+	p := pluginContainer.Plugin
+	context := caseContext(c)
+
+	before := len(recorder.IdempotencyKeys())
+	dryRunContext := copyContext(context)
+	dryRunContext.DryRun = true
+	_, err := p.Process(runtime.DeepCopyJSON(c.Spec), dryRunContext)
+	if c.ExpectError {
+		assert.Error(t, err, "plugin must reject the input")
+	} else {
+		require.NoError(t, err)
+	}
+	assert.Len(t, recorder.IdempotencyKeys(), before, "Process must not cause side effects in dry-run mode")
+
+	for i := 0; i < 2; i++ { // a retry must carry the same key
+		before = len(recorder.IdempotencyKeys())
+		_, err = p.Process(runtime.DeepCopyJSON(c.Spec), copyContext(context))
+		if c.ExpectError {
+			assert.Error(t, err, "plugin must reject the input")
+		} else {
+			require.NoError(t, err)
+		}
+		for _, key := range recorder.IdempotencyKeys()[before:] {
+			assert.Equal(t, context.IdempotencyKey, key, "side effects must carry the idempotency key from the context")
+		}
+	}
+}
+
+func caseContext(c PluginCase) plugin.Context {
+	context := c.Context
+	if context.Namespace == "" {
+		context.Namespace = "default"
+	}
+	if context.IdempotencyKey == "" {
+		context.IdempotencyKey = "conformance/" + c.Name
+	}
+	context.Actual = nil
+	context.DryRun = false
+	return context
+}
+
+func copyContext(context plugin.Context) *plugin.Context {
+	result := plugin.Context{
+		Namespace:      context.Namespace,
+		DryRun:         context.DryRun,
+		IdempotencyKey: context.IdempotencyKey,
+	}
+	if context.Actual != nil {
+		result.Actual = context.Actual.DeepCopyObject()
+	}
+	if context.Dependencies != nil {
+		result.Dependencies = make(map[smith_v1.ResourceName]plugin.Dependency, len(context.Dependencies))
+		for name, dep := range context.Dependencies {
+			result.Dependencies[name] = plugin.Dependency{
+				Spec:      *dep.Spec.DeepCopy(),
+				Actual:    copyObject(dep.Actual),
+				Outputs:   copyObjects(dep.Outputs),
+				Auxiliary: copyObjects(dep.Auxiliary),
+			}
+		}
+	}
+	return &result
+}
+
+func copyObject(obj runtime.Object) runtime.Object {
+	if obj == nil {
+		return nil
+	}
+	return obj.DeepCopyObject()
+}
+
+func copyObjects(objs []runtime.Object) []runtime.Object {
+	if objs == nil {
+		return nil
+	}
+	result := make([]runtime.Object, 0, len(objs))
+	for _, obj := range objs {
+		result = append(result, copyObject(obj))
+	}
+	return result
+}
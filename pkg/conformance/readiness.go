@@ -0,0 +1,63 @@
+package conformance
+
+import (
+	"testing"
+
+	"github.com/atlassian/smith/pkg/readychecker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ReadinessCase is an object with the expected outcome of the readiness check.
+type ReadinessCase struct {
+	// Name of the test case.
+	Name string
+	// Object to check. Smith passes objects from informers, which may or may not have TypeMeta set.
+	Object runtime.Object
+	// Ready is the expected readiness.
+	Ready bool
+	// ExpectError means the check must fail. Smith reports the error on the resource.
+	ExpectError bool
+	// Retriable is the expected retriability of the error. Only checked if ExpectError is true.
+	Retriable bool
+}
+
+// TestReadiness verifies that the readiness check behaves correctly when invoked by Smith.
+// The following properties are checked:
+// - the result matches the expectation;
+// - an object is never reported as both ready and failed;
+// - an error is not reported as retriable unless there is an error;
+// - the check does not mutate the object, which is shared with the informer cache;
+// - the check is deterministic, i.e. retries with the same object produce the same result.
+func TestReadiness(t *testing.T, isObjectReady readychecker.IsObjectReady, cases ...ReadinessCase) {
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			require.NotNil(t, c.Object)
+			objCopy := c.Object.DeepCopyObject()
+
+			isReady, retriable, err := isObjectReady(c.Object)
+			assert.True(t, equality.Semantic.DeepEqual(objCopy, c.Object), "readiness check must not mutate the object")
+			if err != nil {
+				assert.False(t, isReady, "object must not be ready if there is an error")
+			} else {
+				assert.False(t, retriable, "retriable must be false if there is no error")
+			}
+			if c.ExpectError {
+				require.Error(t, err)
+				assert.Equal(t, c.Retriable, retriable)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, c.Ready, isReady)
+			}
+
+			// Retry with the same object
+			isReady2, retriable2, err2 := isObjectReady(objCopy.DeepCopyObject())
+			assert.Equal(t, isReady, isReady2, "readiness check must be deterministic")
+			assert.Equal(t, retriable, retriable2, "readiness check must be deterministic")
+			assert.Equal(t, err != nil, err2 != nil, "readiness check must be deterministic")
+		})
+	}
+}
@@ -5,30 +5,49 @@ import (
 	"fmt"
 	"reflect"
 	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/atlassian/ctrl"
 	ctrlLogz "github.com/atlassian/ctrl/logz"
+	"github.com/atlassian/smith"
 	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
 	smithClient_v1 "github.com/atlassian/smith/pkg/client/clientset_generated/clientset/typed/smith/v1"
+	smith_v1lst "github.com/atlassian/smith/pkg/client/listers_generated/smith/v1"
+	"github.com/atlassian/smith/pkg/migration"
 	"github.com/atlassian/smith/pkg/plugin"
 	"github.com/atlassian/smith/pkg/resources"
 	"github.com/atlassian/smith/pkg/store"
+	"github.com/atlassian/smith/pkg/tracing"
 	"github.com/atlassian/smith/pkg/util/graph"
 	"github.com/atlassian/smith/pkg/util/logz"
+	"github.com/atlassian/smith/pkg/webhook"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
+	core_v1 "k8s.io/api/core/v1"
 	api_errors "k8s.io/apimachinery/pkg/api/errors"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
 )
 
 type bundleSyncTask struct {
 
 	// Inputs
 
-	logger           *zap.Logger
-	bundleClient     smithClient_v1.BundlesGetter
-	smartClient      SmartClient
+	logger *zap.Logger
+	// span of the sync of the Bundle. May be nil.
+	span         *tracing.Span
+	bundleClient smithClient_v1.BundlesGetter
+	smartClient  SmartClient
+	applyClient  ApplyClient
+	dryRunClient DryRunClient
+	// strictClient is set if the Bundle opted into strict field validation. May be nil.
+	strictClient     StrictClient
+	secretResolver   SecretResolver
 	rc               ReadyChecker
 	store            Store
 	specCheck        SpecCheck
@@ -36,12 +55,87 @@ type bundleSyncTask struct {
 	pluginContainers map[smith_v1.PluginName]plugin.PluginContainer
 	scheme           *runtime.Scheme
 	catalog          *store.Catalog
+	// Kinds of CRDs that were watched and then deleted. May be nil.
+	removedAPIs *removedAPIs
+	// Finalizers that may be removed from pruned objects stuck in deletion for longer than finalizerRemovalTimeout.
+	forceRemovableFinalizers []string
+	finalizerRemovalTimeout  time.Duration
+	// Require confirmation before deleting production Bundles.
+	requireDeletionConfirmation bool
+	repairStaleOwnerReferences  bool
+	recorder                    record.EventRecorder
+	// strictOwnership means objects are only managed and pruned if their labels agree with controller references.
+	strictOwnership bool
+	// orderedDeletion means objects of a deleted Bundle are deleted in reverse dependency order.
+	orderedDeletion bool
+	// strictReferenceResolution means references of all resources are resolved before any of them are applied.
+	strictReferenceResolution bool
+	// crossNamespaceResources means resources may declare a namespace other than the namespace of the Bundle.
+	crossNamespaceResources bool
+	// fieldOwnership means external changes of fields set from the spec are reported.
+	fieldOwnership bool
+	// namespaceTerminating is set if the namespace of the Bundle is being deleted.
+	namespaceTerminating bool
+	// paused is set if processing of the Bundle is paused with the PausedAnnotation.
+	paused bool
+	// pruneBackoff tracks failed attempts to delete pruned objects. May be nil.
+	pruneBackoff *pruneBackoff
+	// pruneRateLimiter limits deletion of pruned objects to batches. May be nil.
+	pruneRateLimiter *pruneRateLimiter
+	// exhaustedRetries is set to the number of retries if a retriable error must be treated as terminal.
+	exhaustedRetries int
+	// retryBackoff schedules retries of Bundles that failed with a retriable error. May be nil.
+	retryBackoff *retryBackoff
+	// retryBudget limits retries per namespace. May be nil.
+	retryBudget *NamespaceRetryBudget
+	// syncStats tracks processing of Bundles. May be nil.
+	syncStats *SyncStats
+	// dryRun means changes to objects are planned and recorded in the Bundle status rather than made.
+	dryRun bool
+	// planDeletions means objects of a deleted Bundle are planned for deletion rather than deleted.
+	// Set when all Bundles are processed in dry-run mode, the annotation does not block deletion of a Bundle.
+	planDeletions bool
+	// migrations convert objects of resources to newer versions. May be nil.
+	migrations *migration.Rules
+	// migratedFrom maps names of migrated resources to the API versions declared in the Bundle.
+	migratedFrom map[smith_v1.ResourceName]string
+	// resolvedResources are the resources of the Bundle as returned by resources().
+	resolvedResources []smith_v1.Resource
+	// generatedNames maps names of resources with objects named from metadata.generateName to the generated names.
+	// Names of objects that have not been created yet are empty.
+	generatedNames map[smith_v1.ResourceName]string
+	// revisionClient is used to record specs that made the Bundle ready as BundleRevisions. May be nil.
+	revisionClient smithClient_v1.BundleRevisionsGetter
+	// revisionLister reads BundleRevisions from the informer cache. May be nil.
+	revisionLister smith_v1lst.BundleRevisionLister
+	// recordedRevision is the BundleRevision created or updated during the sync.
+	recordedRevision *smith_v1.BundleRevision
+	// revisionHistoryLimit is the number of BundleRevisions kept unless the Bundle overrides it.
+	// Zero disables revision history.
+	revisionHistoryLimit int
+	// revisionTTL is how long BundleRevisions are kept for unless the Bundle overrides it. Zero means they do not expire.
+	revisionTTL time.Duration
 
 	// Outputs
 
-	processedResources map[smith_v1.ResourceName]*resourceInfo
-	objectsToDelete    map[objectRef]runtime.Object
-	newFinalizers      []string
+	processedResources         map[smith_v1.ResourceName]*resourceInfo
+	objectsToDelete            map[objectRef]runtime.Object
+	newFinalizers              []string
+	validatedGenerationUpdated bool
+	deletionReportUpdated      bool
+	planUpdated                bool
+	pruningUpdated             bool
+	// pruneDeferred is set if objects removed from the Bundle were not deleted because informers have not synced.
+	pruneDeferred bool
+	// awaitingDeletionConfirmation is set if deletion of the Bundle is blocked until it is confirmed.
+	awaitingDeletionConfirmation bool
+	// awaitingObjectsDeletion is set if removal of the finalizer is waiting for objects of the Bundle to be gone.
+	awaitingObjectsDeletion bool
+	// requeueAfter is set if the Bundle must be processed again after a delay to check its progress deadline or
+	// to retry deletion of pruned objects.
+	requeueAfter time.Duration
+	// retry is set once a retry of the Bundle has been scheduled.
+	retry *retrySchedule
 }
 
 // Parse bundle, build resource graph, traverse graph, assert each resource exists.
@@ -53,21 +147,51 @@ type bundleSyncTask struct {
 // that a field "State" in the Status of the resource is set to "Ready". It is customizable via
 // annotations with some defaults.
 func (st *bundleSyncTask) processNormal() (retriableError bool, e error) {
-	// If the "deleteResources" finalizer is missing, add it and finish the processing iteration
-	if !hasDeleteResourcesFinalizer(st.bundle) {
+	if st.namespaceTerminating {
+		// Objects cannot be created in a terminating namespace and everything in it is going to be deleted anyway
+		st.logger.Info("Not processing Bundle because its namespace is terminating")
+		return false, nil
+	}
+
+	if st.paused {
+		st.logger.Info("Not processing Bundle because it is paused")
+		return false, nil
+	}
+
+	if !st.dryRun {
+		st.setPlan(nil)
+	}
+
+	// If the "deleteResources" finalizer is missing, add it and finish the processing iteration.
+	// Not needed in dry-run mode because no objects are created.
+	if !hasDeleteResourcesFinalizer(st.bundle) && !st.dryRun {
 		st.newFinalizers = addDeleteResourcesFinalizer(st.bundle.GetFinalizers())
 		return false, nil
 	}
 
+	resources, err := st.resources()
+	if err != nil {
+		return false, err
+	}
+
 	// Build resource map by name
-	resourceMap := make(map[smith_v1.ResourceName]smith_v1.Resource, len(st.bundle.Spec.Resources))
-	for _, res := range st.bundle.Spec.Resources {
+	resourceMap := make(map[smith_v1.ResourceName]smith_v1.Resource, len(resources))
+	for _, res := range resources {
 		if _, exist := resourceMap[res.Name]; exist {
 			return false, errors.Errorf("bundle contains two resources with the same name %q", res.Name)
 		}
 		resourceMap[res.Name] = res
 	}
 
+	// Partial sync - only the resource named in the annotation is created/updated, others are only observed
+	syncOnly := smith_v1.ResourceName(st.bundle.Annotations[smith.SyncOnlyResourceAnnotation])
+	if syncOnly != "" {
+		if _, ok := resourceMap[syncOnly]; !ok {
+			return false, errors.Errorf("resource %q specified in %s annotation does not exist in the bundle", syncOnly, smith.SyncOnlyResourceAnnotation)
+		}
+		st.logger.Sugar().Infof("Partial sync of resource %q", syncOnly)
+	}
+
 	// Build the graph and topologically sort it
 	_, sorted, sortErr := sortBundle(st.bundle)
 	if sortErr != nil {
@@ -76,24 +200,35 @@ func (st *bundleSyncTask) processNormal() (retriableError bool, e error) {
 
 	st.processedResources = make(map[smith_v1.ResourceName]*resourceInfo, len(st.bundle.Spec.Resources))
 
+	// Spec has changed - validate all resources before mutating anything so that the Bundle is not half-applied
+	if st.bundle.Generation != st.bundle.Status.ValidatedGeneration {
+		if err := st.preflight(sorted, resourceMap); err != nil {
+			return false, err
+		}
+		st.bundle.Status.ValidatedGeneration = st.bundle.Generation
+		st.validatedGenerationUpdated = true
+	}
+
+	// Resolve references of all resources before applying any of them so that all failures are reported at once
+	if st.strictReferenceResolution {
+		if retriable, err := st.resolveReferences(sorted, resourceMap); err != nil {
+			return retriable, err
+		}
+	}
+
 	// Visit vertices in sorted order
+	resourceNames := make([]smith_v1.ResourceName, 0, len(sorted))
 	for _, resName := range sorted {
 		// Process the resource
 		resourceName := resName.(smith_v1.ResourceName)
-		logger := st.logger.With(logz.Resource(resourceName))
 		res := resourceMap[resourceName]
-		rst := resourceSyncTask{
-			logger:             logger,
-			smartClient:        st.smartClient,
-			rc:                 st.rc,
-			store:              st.store,
-			specCheck:          st.specCheck,
-			bundle:             st.bundle,
-			processedResources: st.processedResources,
-			pluginContainers:   st.pluginContainers,
-			scheme:             st.scheme,
-			catalog:            st.catalog,
-		}
+		logger := st.resourceLogger(&res)
+		rst := st.newResourceSyncTask(logger)
+		rst.span = st.span.StartChild("Resource.process", resourceAttributes(&res)...)
+		rst.observeOnly = syncOnly != "" && syncOnly != resourceName
+		rst.dryRun = st.dryRun
+		rst.migratedFrom = st.migratedFrom[resourceName]
+		resourceNames = append(resourceNames, resourceName)
 		resInfo := rst.processResource(&res)
 		if retriable, err := resInfo.fetchError(); err != nil && api_errors.IsConflict(errors.Cause(err)) {
 			// Short circuit on conflict
@@ -105,15 +240,24 @@ func (st *bundleSyncTask) processNormal() (retriableError bool, e error) {
 		} else {
 			logger.Info("Done processing resource", zap.Bool("ready", resInfo.isReady()))
 		}
+		rst.span.SetAttributes(tracing.String("resource.ready", strconv.FormatBool(resInfo.isReady())))
+		rst.span.SetError(resErr)
+		rst.span.Finish()
 		st.processedResources[resourceName] = &resInfo
 	}
-	err := st.findObjectsToDelete()
+	err = st.findObjectsToDelete()
 	if err != nil {
 		return false, err
 	}
-	if st.isBundleReady() {
+	if st.dryRun {
+		st.setPlan(st.computePlan(resourceNames, syncOnly == ""))
+		return false, nil
+	}
+	if syncOnly != "" {
+		st.logger.Info("Not deleting objects removed from the bundle because of partial sync")
+	} else if st.isBundleReady() {
 		// Delete objects which were removed from the bundle
-		retriable, err := st.deleteRemovedResources()
+		retriable, err := st.pruneRemovedObjects()
 		if err != nil {
 			return retriable, err
 		}
@@ -122,15 +266,187 @@ func (st *bundleSyncTask) processNormal() (retriableError bool, e error) {
 	return false, nil
 }
 
+// pruneRemovedObjects deletes objects removed from the Bundle. Deletion is deferred and the Bundle is requeued if
+// informers of relevant kinds have not synced yet, so that deletion decisions are not based on an incomplete cache,
+// e.g. right after startup.
+func (st *bundleSyncTask) pruneRemovedObjects() (retriableError bool, e error) {
+	if gvks := st.relevantGVKs(); !st.store.HasSynced(gvks...) {
+		st.logger.Info("Not deleting objects removed from the bundle because informers have not synced yet")
+		st.pruneDeferred = true
+		st.syncStats.pruneDeferred()
+		st.requeueNoLaterThan(pruneDeferredRequeueDelay)
+		return false, nil
+	}
+	return st.deleteRemovedResources()
+}
+
+// resourceLogger returns a logger with the name of the resource and the kind of its object or the name of its plugin.
+func (st *bundleSyncTask) resourceLogger(res *smith_v1.Resource) *zap.Logger {
+	logger := st.logger.With(logz.Resource(res.Name))
+	switch {
+	case res.Spec.Object != nil:
+		return logger.With(logz.ResourceGvk(res.Spec.Object.GetObjectKind().GroupVersionKind()))
+	case res.Spec.Plugin != nil:
+		return logger.With(logz.Plugin(res.Spec.Plugin.Name))
+	case res.Spec.External != nil:
+		return logger.With(logz.ResourceGvk(res.Spec.External.GroupVersionKind()))
+	default:
+		return logger
+	}
+}
+
+// resourceAttributes returns span attributes with the name of the resource and the kind of its object or the name
+// of its plugin.
+func resourceAttributes(res *smith_v1.Resource) []tracing.Attribute {
+	attributes := []tracing.Attribute{tracing.String("resource.name", string(res.Name))}
+	switch {
+	case res.Spec.Object != nil:
+		return append(attributes, tracing.String("resource.gvk", res.Spec.Object.GetObjectKind().GroupVersionKind().String()))
+	case res.Spec.Plugin != nil:
+		return append(attributes, tracing.String("resource.plugin", string(res.Spec.Plugin.Name)))
+	case res.Spec.External != nil:
+		return append(attributes, tracing.String("resource.gvk", res.Spec.External.GroupVersionKind().String()))
+	default:
+		return attributes
+	}
+}
+
+func (st *bundleSyncTask) newResourceSyncTask(logger *zap.Logger) resourceSyncTask {
+	return resourceSyncTask{
+		logger:             logger,
+		smartClient:        st.smartClient,
+		applyClient:        st.applyClient,
+		dryRunClient:       st.dryRunClient,
+		strictClient:       st.strictClient,
+		secretResolver:     st.secretResolver,
+		rc:                 st.rc,
+		store:              st.store,
+		removedAPIs:        st.removedAPIs,
+		specCheck:          st.specCheck,
+		bundle:             st.bundle,
+		processedResources: st.processedResources,
+		pluginContainers:   st.pluginContainers,
+		scheme:             st.scheme,
+		catalog:            st.catalog,
+
+		repairStaleOwnerReferences: st.repairStaleOwnerReferences,
+		strictOwnership:            st.strictOwnership,
+		crossNamespaceResources:    st.crossNamespaceResources,
+		fieldOwnership:             st.fieldOwnership,
+		recorder:                   st.recorder,
+	}
+}
+
+// preflight validates all resources before any of them are created or updated.
+// Resources that fail validation are recorded in processedResources with an error status.
+// With a dry-run client changes of objects are also submitted to the API server with server-side dry-run, see
+// serverDryRun.
+func (st *bundleSyncTask) preflight(sorted []graph.V, resourceMap map[smith_v1.ResourceName]smith_v1.Resource) error {
+	observed := make(map[smith_v1.ResourceName]*resourceInfo, len(sorted))
+	var failedResources []smith_v1.ResourceName
+	for _, resName := range sorted {
+		resourceName := resName.(smith_v1.ResourceName)
+		res := resourceMap[resourceName]
+		rst := st.newResourceSyncTask(st.resourceLogger(&res))
+		err := rst.prevalidate(&res)
+		if err == nil && st.dryRunClient != nil {
+			rst.processedResources = observed
+			// Events are recorded when the resource is applied
+			rst.recorder = nil
+			var resInfo resourceInfo
+			resInfo, err = rst.serverDryRun(&res)
+			observed[resourceName] = &resInfo
+		}
+		if err != nil {
+			resInfo := resourceInfo{
+				status: resourceStatusError{
+					err: err,
+				},
+			}
+			// Dependent resources are not submitted with server-side dry-run because the resource is not ready
+			observed[resourceName] = &resInfo
+			st.processedResources[resourceName] = &resInfo
+			failedResources = append(failedResources, resourceName)
+		}
+	}
+	if len(failedResources) > 0 {
+		return &preflightError{failedResources: failedResources}
+	}
+	return nil
+}
+
+// preflightError means pre-flight validation of resources failed and nothing was created or updated.
+type preflightError struct {
+	failedResources []smith_v1.ResourceName
+}
+
+func (e *preflightError) Error() string {
+	return fmt.Sprintf("pre-flight check failed for resource(s), no changes were applied: %q", e.failedResources)
+}
+
 // Process the bundle marked with DeletionTimestamp
 // TODO: remove this method after https://github.com/kubernetes/kubernetes/issues/59850 is fixed
 func (st *bundleSyncTask) processDeleted() (retriableError bool, e error) {
+	// Objects are not pruned from a Bundle that is being deleted
+	st.pruneBackoff.forget(st.bundle.UID)
+	st.pruneRateLimiter.forget(st.bundle.UID)
+	if hasDeleteResourcesFinalizer(st.bundle) && st.planDeletions {
+		return false, st.planDeletion()
+	}
+	if hasDeleteResourcesFinalizer(st.bundle) && st.namespaceTerminating {
+		// Namespace controller deletes all objects in the namespace, there is nothing to confirm or wait for.
+		// Objects in other namespaces are deleted here because nothing else deletes them.
+		// Remove the finalizer straight away so that namespace deletion is not blocked.
+		if retriable, err := st.deleteCrossNamespaceObjects(); err != nil {
+			return retriable, err
+		}
+		st.logger.Info("Removing finalizer because namespace of the Bundle is terminating")
+		st.newFinalizers = removeDeleteResourcesFinalizer(st.bundle.GetFinalizers())
+		return false, nil
+	}
+	foreground := resources.HasFinalizer(st.bundle, meta_v1.FinalizerDeleteDependents)
+	if !hasDeleteResourcesFinalizer(st.bundle) && !foreground {
+		return false, nil
+	}
+	// Confirmation is checked whatever the propagation policy is. With the Foreground propagation policy the garbage
+	// collector deletes objects in the namespace of the Bundle, objects in other namespaces and the finalizer of the
+	// Bundle wait for the confirmation still.
+	if st.bundle.Status.DeletionReport == nil {
+		report, err := st.deletionReport()
+		if err != nil {
+			return false, err
+		}
+		st.bundle.Status.DeletionReport = report
+		st.deletionReportUpdated = true
+	}
+	if !st.deletionConfirmed() {
+		st.logger.Info("Deletion of production Bundle is waiting for confirmation")
+		st.awaitingDeletionConfirmation = true
+		return false, nil
+	}
 	if hasDeleteResourcesFinalizer(st.bundle) {
-		if !resources.HasFinalizer(st.bundle, meta_v1.FinalizerDeleteDependents) {
+		if !foreground {
 			// If "foregroundDeletion" finalizer was not set, perform manual cascade deletion
-			retrieable, err := st.deleteAllResources()
+			if st.orderedDeletion {
+				done, retriable, err := st.deleteResourcesInOrder()
+				if err != nil {
+					return retriable, err
+				}
+				if !done {
+					st.awaitingObjectsDeletion = true
+					return false, nil
+				}
+			} else {
+				retrieable, err := st.deleteAllResources()
+				if err != nil {
+					return retrieable, err
+				}
+			}
+		} else {
+			// Garbage collector only deletes objects that have owner references to the Bundle
+			retriable, err := st.deleteCrossNamespaceObjects()
 			if err != nil {
-				return retrieable, err
+				return retriable, err
 			}
 		}
 
@@ -142,12 +458,19 @@ func (st *bundleSyncTask) processDeleted() (retriableError bool, e error) {
 }
 
 func (st *bundleSyncTask) deleteAllResources() (retriableError bool, e error) {
-	objs, err := st.store.ObjectsControlledBy(st.bundle.Namespace, st.bundle.UID)
+	objs, err := st.childObjects()
 	if err != nil {
 		return false, err
 	}
 	st.objectsToDelete = make(map[objectRef]runtime.Object, len(objs))
+	for _, obj := range objs {
+		st.objectsToDelete[st.objectRefOf(obj)] = obj
+	}
+	return st.deleteObjects(objs)
+}
 
+// deleteObjects issues foreground deletion of objects that are not marked for deletion yet.
+func (st *bundleSyncTask) deleteObjects(objs []runtime.Object) (retriableError bool, e error) {
 	var firstErr error
 	retriable := true
 	policy := meta_v1.DeletePropagationForeground
@@ -155,21 +478,26 @@ func (st *bundleSyncTask) deleteAllResources() (retriableError bool, e error) {
 		m := obj.(meta_v1.Object)
 		gvk := obj.GetObjectKind().GroupVersionKind()
 		name := m.GetName()
-		ref := objectRef{
-			GroupVersionKind: gvk,
-			Name:             name,
-		}
-		st.objectsToDelete[ref] = obj
 
 		logger := st.logger.With(ctrlLogz.ObjectGk(gvk.GroupKind()), ctrlLogz.ObjectName(name))
 		if m.GetDeletionTimestamp() != nil {
 			logger.Debug("Object is marked for deletion already")
 			continue
 		}
+		if policy := objectDeletionPolicy(m); policy != smith_v1.DeletionPolicyDelete {
+			if err := st.releaseObject(logger, st.objectRefOf(obj), obj, policy); err != nil {
+				if firstErr == nil {
+					firstErr = errors.Wrapf(err, "failed to release %s %q", gvk.Kind, name)
+				} else {
+					logger.Warn("Failed to release object", zap.Error(err))
+				}
+			}
+			continue
+		}
 		uid := m.GetUID()
 
 		logger.Info("Deleting object")
-		resClient, err := st.smartClient.ForGVK(gvk, st.bundle.Namespace)
+		resClient, err := st.smartClient.ForGVK(gvk, m.GetNamespace())
 		if err != nil {
 			if firstErr == nil {
 				retriable = false
@@ -201,22 +529,43 @@ func (st *bundleSyncTask) deleteAllResources() (retriableError bool, e error) {
 }
 
 // findObjectsToDelete initializes objectsToDelete field with objects that have controller owner references to
-// the Bundle being processed but are not defined in it.
+// the Bundle being processed, or are tracked by it in other namespaces, but are not defined in it.
+// In strict ownership mode objects that are not labeled with the name of the Bundle are not deleted.
 func (st *bundleSyncTask) findObjectsToDelete() error {
-	objs, err := st.store.ObjectsControlledBy(st.bundle.Namespace, st.bundle.UID)
+	// Objects of resources with specFrom are only known once their specs have been read
+	resources, err := st.resources()
+	if err != nil {
+		return err
+	}
+	objs, err := st.childObjects()
 	if err != nil {
 		return err
 	}
 	st.objectsToDelete = make(map[objectRef]runtime.Object, len(objs))
 	for _, obj := range objs {
 		m := obj.(meta_v1.Object)
-		ref := objectRef{
-			GroupVersionKind: obj.GetObjectKind().GroupVersionKind(),
-			Name:             m.GetName(),
+		ref := st.objectRefOf(obj)
+		if st.strictOwnership {
+			if message, mismatch := ownershipMismatch(st.bundle, m, false); mismatch {
+				st.logger.Warn("Ownership mismatch, object is not pruned", ctrlLogz.ObjectGk(ref.GroupKind()), ctrlLogz.ObjectName(ref.Name), zap.String("reason", message))
+				recordEvent(st.recorder, st.bundle, core_v1.EventTypeWarning, EventReasonOwnershipMismatch,
+					"Not pruning %s %q: %s", ref.Kind, ref.Name, message)
+				continue
+			}
 		}
 		st.objectsToDelete[ref] = obj
 	}
-	for _, res := range st.bundle.Spec.Resources {
+	// Objects are matched by group, kind and name regardless of the version. An object is the same object
+	// whatever version it is read at, e.g. after the storage version of a CRD has been migrated.
+	type groupKindName struct {
+		schema.GroupKind
+		namespace string
+		name      string
+	}
+	defined := make(map[groupKindName]struct{}, len(resources))
+	// Objects of resources with generated names that are not known yet may have been created already
+	var unnamed map[generatedNameKey]struct{}
+	for _, res := range resources {
 		var gvk schema.GroupVersionKind
 		var name string
 		if res.Spec.Object != nil {
@@ -231,56 +580,246 @@ func (st *bundleSyncTask) findObjectsToDelete() error {
 			// must have been reported earlier while processing this resource.
 			continue
 		}
-		delete(st.objectsToDelete, objectRef{
-			GroupVersionKind: gvk,
-			Name:             name,
-		})
+		if name == "" && hasGeneratedName(&res) {
+			if resInfo, ok := st.processedResources[res.Name]; ok && resInfo.actual != nil {
+				// Created in this sync
+				name = resInfo.actual.GetName()
+			} else {
+				if unnamed == nil {
+					unnamed = make(map[generatedNameKey]struct{})
+				}
+				unnamed[generatedNameKey{GroupKind: gvk.GroupKind(), namespace: resourceNamespace(&res, st.bundle), resource: res.Name}] = struct{}{}
+				continue
+			}
+		}
+		defined[groupKindName{GroupKind: gvk.GroupKind(), namespace: resourceNamespace(&res, st.bundle), name: name}] = struct{}{}
+	}
+	// The object outputs are exported to is not a resource but must not be pruned
+	if ref, ok := outputsExportRef(st.bundle); ok {
+		defined[groupKindName{GroupKind: ref.GroupKind(), namespace: ref.Namespace, name: ref.Name}] = struct{}{}
+	}
+	for ref, obj := range st.objectsToDelete {
+		if _, ok := defined[groupKindName{GroupKind: ref.GroupKind(), namespace: ref.Namespace, name: ref.Name}]; ok {
+			delete(st.objectsToDelete, ref)
+			continue
+		}
+		if resName, ok := obj.(meta_v1.Object).GetAnnotations()[smith.ResourceAnnotation]; ok {
+			if _, ok = unnamed[generatedNameKey{GroupKind: ref.GroupKind(), namespace: ref.Namespace, resource: smith_v1.ResourceName(resName)}]; ok {
+				delete(st.objectsToDelete, ref)
+			}
+		}
 	}
 	return nil
 }
 
+// pruneDeferredRequeueDelay is how soon a Bundle is processed again after deletion of objects removed from it has been
+// deferred because informers have not synced yet.
+const pruneDeferredRequeueDelay = 5 * time.Second
+
+// relevantGVKs returns GVKs of objects defined in the Bundle and of objects that are going to be deleted.
+// Only GVKs with an informer are returned, objects of other kinds cannot be found in the Store anyway.
+func (st *bundleSyncTask) relevantGVKs() []schema.GroupVersionKind {
+	gvkSet := make(map[schema.GroupVersionKind]struct{}, len(st.processedResources)+len(st.objectsToDelete))
+	for _, resInfo := range st.processedResources {
+		if resInfo.actual != nil {
+			gvkSet[resInfo.actual.GroupVersionKind()] = struct{}{}
+		}
+	}
+	for ref := range st.objectsToDelete {
+		gvkSet[ref.GroupVersionKind] = struct{}{}
+	}
+	gvks := make([]schema.GroupVersionKind, 0, len(gvkSet))
+	for gvk := range gvkSet {
+		if st.store.HasInformer(gvk) {
+			gvks = append(gvks, gvk)
+		}
+	}
+	return gvks
+}
+
+// deleteRemovedResources deletes all objects in objectsToDelete.
+// Failure to delete one object does not prevent deletion of others, all errors are returned.
+// Objects that failed to be deleted are retried with backoff, errors of the last attempt are returned until then.
+// The returned error is retriable if at least one of the failures is retriable.
 func (st *bundleSyncTask) deleteRemovedResources() (retriableError bool, e error) {
-	var firstErr error
-	retriable := true
-	policy := meta_v1.DeletePropagationForeground
+	span := st.span.StartChild("Bundle.deleteRemovedResources", tracing.String("objects", strconv.Itoa(len(st.objectsToDelete))))
+	defer func() {
+		span.SetError(e)
+		span.Finish()
+	}()
+	st.pruneBackoff.retain(st.bundle.UID, st.objectsToDelete)
+	// Objects that are not marked for deletion yet and are not postponed after failed attempts are deleted in
+	// batches if pruning is rate limited. Deterministic order so that the same objects are deleted first in every sync.
+	refs := make([]objectRef, 0, len(st.objectsToDelete))
+	pending := 0
 	for ref, obj := range st.objectsToDelete {
+		refs = append(refs, ref)
+		if _, delay := st.pruneBackoff.get(st.bundle.UID, ref); obj.(meta_v1.Object).GetDeletionTimestamp() == nil && delay == 0 {
+			pending++
+		}
+	}
+	sortObjectRefs(refs)
+	budget := -1
+	var nextBatch time.Time
+	paused := st.bundle.Annotations[smith.PrunePausedAnnotation] == "true"
+	if paused {
+		st.logger.Sugar().Infof("Not deleting %d object(s) removed from the bundle because pruning is paused", pending)
+		budget = 0
+	} else if pending > 0 {
+		var delay time.Duration
+		budget, nextBatch, delay = st.pruneRateLimiter.take(st.bundle.UID)
+		if budget >= 0 && budget < pending {
+			st.logger.Sugar().Infof("Deleting %d out of %d object(s) removed from the bundle, next batch in %s", budget, pending, delay)
+			st.requeueNoLaterThan(delay)
+		}
+	}
+
+	var errs []error
+	retriable := false
+	for _, ref := range refs {
+		obj := st.objectsToDelete[ref]
 		logger := st.logger.With(ctrlLogz.ObjectGk(ref.GroupVersionKind.GroupKind()), ctrlLogz.ObjectName(ref.Name))
-		m := obj.(meta_v1.Object)
-		if m.GetDeletionTimestamp() != nil {
-			logger.Debug("Object is marked for deletion already")
+		if failure, delay := st.pruneBackoff.get(st.bundle.UID, ref); delay > 0 {
+			logger.Sugar().Debugf("Postponing deletion of object by %s after %d failed attempt(s)", delay, failure.attempts)
+			errs = append(errs, failure.err)
+			retriable = retriable || failure.retriable
+			st.requeueNoLaterThan(delay)
 			continue
 		}
-		logger.Info("Deleting object")
-		resClient, err := st.smartClient.ForGVK(ref.GroupVersionKind, st.bundle.Namespace)
-		if err != nil {
-			if firstErr == nil {
-				retriable = false
-				firstErr = err
-			} else {
-				logger.Error("Failed to get client for object", zap.Error(err))
+		if obj.(meta_v1.Object).GetDeletionTimestamp() == nil {
+			if budget == 0 {
+				continue
+			}
+			if budget > 0 {
+				budget--
 			}
+			pending--
+		}
+		objRetriable, err := st.deleteRemovedResource(logger, ref, obj)
+		if err != nil {
+			errs = append(errs, err)
+			retriable = retriable || objRetriable
+			st.requeueNoLaterThan(st.pruneBackoff.failed(st.bundle.UID, ref, err, objRetriable))
 			continue
 		}
+		st.pruneBackoff.succeeded(st.bundle.UID, ref)
+	}
+	st.setPruningStatus(pending, paused, nextBatch)
+	if len(errs) > 0 {
+		return retriable, utilerrors.NewAggregate(errs)
+	}
+	return false, nil
+}
 
-		uid := m.GetUID()
-		err = resClient.Delete(ref.Name, &meta_v1.DeleteOptions{
-			Preconditions: &meta_v1.Preconditions{
-				UID: &uid,
-			},
-			PropagationPolicy: &policy,
-		})
-		if err != nil && !api_errors.IsNotFound(err) && !api_errors.IsConflict(err) {
-			// not found means object has been deleted already
-			// conflict means it has been deleted and re-created (UID does not match)
-			if firstErr == nil {
-				firstErr = err
-			} else {
-				logger.Warn("Failed to delete object", zap.Error(err))
-			}
-			continue
+// setPruningStatus records progress of pruning that is paused or rate limited.
+// Objects that are marked for deletion already or are postponed after failed attempts are not pending.
+func (st *bundleSyncTask) setPruningStatus(pending int, paused bool, nextBatch time.Time) {
+	var pruning *smith_v1.PruningStatus
+	if pending > 0 {
+		pruning = &smith_v1.PruningStatus{
+			Pending: int32(pending),
+			Paused:  paused,
+		}
+		if !paused && !nextBatch.IsZero() {
+			// Status only has a precision of seconds
+			t := meta_v1.NewTime(nextBatch.Truncate(time.Second))
+			pruning.NextBatchTime = &t
 		}
 	}
-	return retriable, firstErr
+	if !reflect.DeepEqual(st.bundle.Status.Pruning, pruning) {
+		st.bundle.Status.Pruning = pruning
+		st.pruningUpdated = true
+	}
+}
+
+// updateWarnings sets warnings about the spec of the Bundle in its status. Returns true if they have changed.
+func (st *bundleSyncTask) updateWarnings() bool {
+	// Readiness rules are only checked if the ready checker supports it
+	rules, _ := st.rc.(webhook.ReadinessRules)
+	warnings, err := webhook.WarnBundle(st.bundle, rules)
+	if err != nil {
+		// Warnings must not affect processing, keep the old ones
+		st.logger.Error("Failed to check Bundle for warnings", zap.Error(err))
+		return false
+	}
+	if reflect.DeepEqual(st.bundle.Status.Warnings, warnings) {
+		return false
+	}
+	st.bundle.Status.Warnings = warnings
+	return true
+}
+
+// updateObservedGeneration records that conditions of the Bundle refer to the current generation of its spec.
+// Returns true if the observed generation has changed.
+func (st *bundleSyncTask) updateObservedGeneration() bool {
+	if st.bundle.Status.ObservedGeneration == st.bundle.Generation {
+		return false
+	}
+	st.bundle.Status.ObservedGeneration = st.bundle.Generation
+	return true
+}
+
+func sortObjectRefs(refs []objectRef) {
+	sort.Slice(refs, func(i, j int) bool {
+		a, b := refs[i], refs[j]
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+}
+
+// deleteRemovedResource deletes an object that is no longer defined in the Bundle.
+// If the object has been marked for deletion already, its finalizers are removed if they block deletion for too long.
+func (st *bundleSyncTask) deleteRemovedResource(logger *zap.Logger, ref objectRef, obj runtime.Object) (retriableError bool, e error) {
+	m := obj.(meta_v1.Object)
+	if m.GetDeletionTimestamp() != nil {
+		logger.Debug("Object is marked for deletion already")
+		if err := st.forceRemoveFinalizers(logger, ref, obj); err != nil {
+			logger.Warn("Failed to remove finalizers from object", zap.Error(err))
+			return isRetriableDeleteError(err), errors.Wrapf(err, "failed to remove finalizers from %s %q", ref.Kind, ref.Name)
+		}
+		return false, nil
+	}
+	if policy := objectDeletionPolicy(m); policy != smith_v1.DeletionPolicyDelete {
+		if err := st.releaseObject(logger, ref, obj, policy); err != nil {
+			logger.Warn("Failed to release object", zap.Error(err))
+			return isRetriableDeleteError(err), errors.Wrapf(err, "failed to release %s %q", ref.Kind, ref.Name)
+		}
+		recordEvent(st.recorder, st.bundle, core_v1.EventTypeNormal, EventReasonObjectKept, "Kept %s %q that is no longer defined in the Bundle according to its %s deletion policy", ref.Kind, ref.Name, policy)
+		return false, nil
+	}
+	logger.Info("Deleting object")
+	resClient, err := st.smartClient.ForGVK(ref.GroupVersionKind, st.namespaceOf(ref))
+	if err != nil {
+		logger.Error("Failed to get client for object", zap.Error(err))
+		return false, errors.Wrapf(err, "failed to get client for %s %q", ref.Kind, ref.Name)
+	}
+
+	uid := m.GetUID()
+	policy := meta_v1.DeletePropagationForeground
+	err = resClient.Delete(ref.Name, &meta_v1.DeleteOptions{
+		Preconditions: &meta_v1.Preconditions{
+			UID: &uid,
+		},
+		PropagationPolicy: &policy,
+	})
+	if err != nil && !api_errors.IsNotFound(err) && !api_errors.IsConflict(err) {
+		// not found means object has been deleted already
+		// conflict means it has been deleted and re-created (UID does not match)
+		logger.Warn("Failed to delete object", zap.Error(err))
+		return isRetriableDeleteError(err), errors.Wrapf(err, "failed to delete %s %q", ref.Kind, ref.Name)
+	}
+	if err == nil {
+		recordEvent(st.recorder, st.bundle, core_v1.EventTypeNormal, EventReasonObjectDeleted, "Deleted %s %q that is no longer defined in the Bundle", ref.Kind, ref.Name)
+	}
+	return false, nil
 }
 
 func (st *bundleSyncTask) updateBundle() error {
@@ -323,12 +862,41 @@ func (st *bundleSyncTask) handleProcessResult(retriable bool, processErr error)
 			st.logger.Error("Error updating ObjectsToDelete status field", zap.Error(err))
 		}
 		bundleUpdated = true
+	} else if st.bundle.DeletionTimestamp == nil && st.namespaceTerminating {
+		inProgressCond := smith_v1.BundleCondition{Type: smith_v1.BundleInProgress, Status: smith_v1.ConditionFalse}
+		readyCond := smith_v1.BundleCondition{
+			Type:    smith_v1.BundleReady,
+			Status:  smith_v1.ConditionFalse,
+			Reason:  smith_v1.BundleReasonNamespaceTerminating,
+			Message: "namespace is being deleted, resources are not processed",
+		}
+		errorCond := smith_v1.BundleCondition{Type: smith_v1.BundleError, Status: smith_v1.ConditionFalse}
+		bundleUpdated = updateBundleCondition(st.bundle, &inProgressCond) || bundleUpdated
+		bundleUpdated = updateBundleCondition(st.bundle, &readyCond) || bundleUpdated
+		bundleUpdated = updateBundleCondition(st.bundle, &errorCond) || bundleUpdated
+		if st.bundle.Status.Ready != readyCond.Status {
+			st.bundle.Status.Ready = readyCond.Status
+			bundleUpdated = true
+		}
+		if bundleUpdated {
+			st.bundle.Status.Conditions = []smith_v1.BundleCondition{inProgressCond, readyCond, errorCond}
+		}
+		bundleUpdated = st.updateObservedGeneration() || bundleUpdated
+	} else if st.bundle.DeletionTimestamp == nil && st.paused {
+		// Everything else in the status is left as it was when the Bundle was paused
+		bundleUpdated = st.updatePausedCondition()
 	} else if st.bundle.DeletionTimestamp == nil {
 		// Construct resource conditions and check if there were any resource errors
 		resourceStatuses := make([]smith_v1.ResourceStatus, 0, len(st.processedResources))
 		var failedResources []smith_v1.ResourceName
+		var waitingOn []string
+		readyResources := 0
 		retriableResourceErr := true
+		retryClasses := make(map[smith_v1.RetryClass]struct{})
+		now := time.Now()
 		for _, res := range st.bundle.Spec.Resources { // Deterministic iteration order
+			_, oldStatus := st.bundle.Status.GetResourceStatus(res.Name)
+			var progressStart *meta_v1.Time
 			blockedCond := smith_v1.ResourceCondition{Type: smith_v1.ResourceBlocked, Status: smith_v1.ConditionFalse}
 			inProgressCond := smith_v1.ResourceCondition{Type: smith_v1.ResourceInProgress, Status: smith_v1.ConditionFalse}
 			readyCond := smith_v1.ResourceCondition{Type: smith_v1.ResourceReady, Status: smith_v1.ConditionFalse}
@@ -336,26 +904,41 @@ func (st *bundleSyncTask) handleProcessResult(retriable bool, processErr error)
 
 			if resInfo, ok := st.processedResources[res.Name]; ok {
 				// Resource was processed
+				progressStart = st.checkReadinessTimeout(&res, resInfo, oldStatus, now)
 				switch resStatus := resInfo.status.(type) {
 				case resourceStatusDependenciesNotReady:
 					blockedCond.Status = smith_v1.ConditionTrue
 					blockedCond.Reason = smith_v1.ResourceReasonDependenciesNotReady
 					blockedCond.Message = fmt.Sprintf("Not ready: %q", resStatus.dependencies)
+				case resourceStatusMissingAPI:
+					blockedCond.Status = smith_v1.ConditionTrue
+					blockedCond.Reason = smith_v1.ResourceReasonMissingAPI
+					blockedCond.Message = fmt.Sprintf("API for %s is not available", resStatus.gvk)
+					waitingOn = append(waitingOn, fmt.Sprintf("%s (API for %s not available)", res.Name, resStatus.gvk.GroupKind()))
 				case resourceStatusInProgress:
 					inProgressCond.Status = smith_v1.ConditionTrue
+					waitingOn = append(waitingOn, fmt.Sprintf("%s (%s not ready)", res.Name, resInfo.kind()))
 				case resourceStatusReady:
 					readyCond.Status = smith_v1.ConditionTrue
+					readyResources++
 				case resourceStatusError:
 					errorCond.Status = smith_v1.ConditionTrue
 					errorCond.Message = resStatus.err.Error()
 					if resStatus.isRetriableError {
 						errorCond.Reason = smith_v1.ResourceReasonRetriableError
 						inProgressCond.Status = smith_v1.ConditionTrue
+						waitingOn = append(waitingOn, fmt.Sprintf("%s (retrying after error)", res.Name))
 					} else {
 						errorCond.Reason = smith_v1.ResourceReasonTerminalError
 					}
+					if resStatus.reason != "" {
+						errorCond.Reason = resStatus.reason
+					}
 					failedResources = append(failedResources, res.Name)
 					retriableResourceErr = retriableResourceErr && resStatus.isRetriableError // Must not continue if at least one error is not retriable
+					if resStatus.isRetriableError {
+						retryClasses[resourceRetryClass(resStatus.err)] = struct{}{}
+					}
 				default:
 					blockedCond.Status = smith_v1.ConditionUnknown
 					inProgressCond.Status = smith_v1.ConditionUnknown
@@ -377,15 +960,73 @@ func (st *bundleSyncTask) handleProcessResult(retriable bool, processErr error)
 			bundleUpdated = updateResourceCondition(st.bundle, res.Name, &inProgressCond) || bundleUpdated
 			bundleUpdated = updateResourceCondition(st.bundle, res.Name, &readyCond) || bundleUpdated
 			bundleUpdated = updateResourceCondition(st.bundle, res.Name, &errorCond) || bundleUpdated
-			resourceStatuses = append(resourceStatuses, smith_v1.ResourceStatus{
-				Name:       res.Name,
-				Conditions: []smith_v1.ResourceCondition{blockedCond, inProgressCond, readyCond, errorCond},
-			})
+			resStatus := smith_v1.ResourceStatus{
+				Name:              res.Name,
+				Conditions:        []smith_v1.ResourceCondition{blockedCond, inProgressCond, readyCond, errorCond},
+				ProgressStartTime: progressStart,
+			}
+			setResourceState(&resStatus, &blockedCond, &inProgressCond, &readyCond, &errorCond)
+			var diff *smith_v1.ResourceDiff
+			if resInfo, ok := st.processedResources[res.Name]; ok {
+				diff = resInfo.diff
+			}
+			resStatus.LastDiff = st.resourceDiff(diff, oldStatus)
+			resStatus.ObjectName = st.generatedObjectName(res.Name, oldStatus)
+			resStatus.ResolvedObject = st.resolvedObject(&res, oldStatus)
+			if oldStatus == nil || oldStatus.State != resStatus.State || oldStatus.Message != resStatus.Message || diff != nil ||
+				oldStatus.ObjectName != resStatus.ObjectName || !resolvedObjectEqual(oldStatus.ResolvedObject, resStatus.ResolvedObject) ||
+				!progressStartEqual(oldStatus.ProgressStartTime, resStatus.ProgressStartTime) {
+				bundleUpdated = true
+			}
+			resourceStatuses = append(resourceStatuses, resStatus)
 		}
 
+		bundleUpdated = st.updateWarnings() || bundleUpdated
+		bundleUpdated = st.updateOutputs() || bundleUpdated
+		bundleUpdated = st.updateObservedGeneration() || bundleUpdated
+		bundleUpdated = st.validatedGenerationUpdated || st.planUpdated || st.pruningUpdated || bundleUpdated
+
 		if processErr == nil && len(failedResources) > 0 {
 			processErr = errors.Errorf("error processing resource(s): %q", failedResources)
 			retriable = retriableResourceErr
+		} else if processErr != nil && retriable {
+			retryClasses[processRetryClass(processErr)] = struct{}{}
+		}
+		if processErr == nil {
+			if exportRetriable, exportErr := st.exportOutputs(); exportErr != nil {
+				processErr = errors.Wrap(exportErr, "failed to export outputs")
+				retriable = exportRetriable
+				retryClasses[smith_v1.RetryClassResourceError] = struct{}{}
+			}
+		}
+		retryNotAllowed := false
+		if processErr != nil && retriable && !api_errors.IsConflict(errors.Cause(processErr)) {
+			if class, ok := notAllowedRetryClass(st.bundle.Spec.RetryPolicy, retryClasses); ok {
+				processErr = errors.Wrapf(processErr, "not retrying %s errors according to the retry policy", class)
+				retriable = false
+				retryNotAllowed = true
+			}
+		}
+		retriesExhausted := false
+		if processErr != nil && retriable && st.exhaustedRetries > 0 {
+			processErr = errors.Wrapf(processErr, "giving up after %d retries", st.exhaustedRetries)
+			retriable = false
+			retriesExhausted = true
+		}
+
+		var retryStatus *smith_v1.RetryStatus
+		if processErr != nil && retriable {
+			if retry := st.scheduleRetry(time.Now()); retry != nil {
+				retryStatus = &smith_v1.RetryStatus{
+					Attempts: int32(retry.attempts),
+					// Status only has a precision of seconds
+					NextRetryTime: meta_v1.NewTime(retry.at.Truncate(time.Second)),
+				}
+			}
+		}
+		if !retryStatusEqual(st.bundle.Status.Retry, retryStatus) {
+			st.bundle.Status.Retry = retryStatus
+			bundleUpdated = true
 		}
 
 		// Bundle conditions
@@ -402,7 +1043,15 @@ func (st *bundleSyncTask) handleProcessResult(retriable bool, processErr error)
 		} else {
 			errorCond.Status = smith_v1.ConditionTrue
 			errorCond.Message = processErr.Error()
-			if retriable {
+			if _, ok := errors.Cause(processErr).(*preflightError); ok {
+				errorCond.Reason = smith_v1.BundleReasonPreflightFailed
+			} else if retryNotAllowed {
+				errorCond.Reason = smith_v1.BundleReasonRetryNotAllowed
+			} else if _, ok := errors.Cause(processErr).(*referenceResolutionError); ok && !retriable {
+				errorCond.Reason = smith_v1.BundleReasonReferenceResolutionFailed
+			} else if retriesExhausted {
+				errorCond.Reason = smith_v1.BundleReasonRetriesExhausted
+			} else if retriable {
 				errorCond.Reason = smith_v1.BundleReasonRetriableError
 				inProgressCond.Status = smith_v1.ConditionTrue
 			} else {
@@ -410,14 +1059,68 @@ func (st *bundleSyncTask) handleProcessResult(retriable bool, processErr error)
 			}
 		}
 
+		if errorCond.Status == smith_v1.ConditionTrue && errorCond.Reason != smith_v1.BundleReasonRetriableError {
+			_, oldErrorCond := st.bundle.GetCondition(smith_v1.BundleError)
+			if oldErrorCond == nil || oldErrorCond.Status != errorCond.Status || oldErrorCond.Message != errorCond.Message {
+				recordEvent(st.recorder, st.bundle, core_v1.EventTypeWarning, EventReasonTerminalError, "%s", errorCond.Message)
+			}
+		}
+
+		revisionRecorded := false
+		if readyCond.Status == smith_v1.ConditionTrue {
+			revisionRecorded = st.recordReadyRevision()
+			bundleUpdated = revisionRecorded || bundleUpdated
+		}
+		if !revisionRecorded {
+			// Revisions expire even if no new spec makes the Bundle ready
+			st.expireRevisions()
+		}
+		bundleUpdated = st.rollback(&errorCond) || bundleUpdated
+
+		timedOutCond, progressStartUpdated := st.checkProgressDeadline(readyCond.Status == smith_v1.ConditionTrue, time.Now())
+		bundleUpdated = progressStartUpdated || bundleUpdated
+
 		bundleUpdated = updateBundleCondition(st.bundle, &inProgressCond) || bundleUpdated
 		bundleUpdated = updateBundleCondition(st.bundle, &readyCond) || bundleUpdated
 		bundleUpdated = updateBundleCondition(st.bundle, &errorCond) || bundleUpdated
+		conditions := []smith_v1.BundleCondition{inProgressCond, readyCond, errorCond}
+		if timedOutCond != nil {
+			_, oldTimedOutCond := st.bundle.GetCondition(smith_v1.BundleTimedOut)
+			bundleUpdated = updateBundleCondition(st.bundle, timedOutCond) || bundleUpdated
+			if timedOutCond.Status == smith_v1.ConditionTrue && (oldTimedOutCond == nil || oldTimedOutCond.Status != smith_v1.ConditionTrue) {
+				st.logger.Warn("Bundle has exceeded its progress deadline", zap.String("message", timedOutCond.Message))
+				recordEvent(st.recorder, st.bundle, core_v1.EventTypeWarning, smith_v1.BundleReasonProgressDeadlineExceeded, "%s", timedOutCond.Message)
+			}
+			conditions = append(conditions, *timedOutCond)
+		}
+		if pruneDeferredCond := st.pruneDeferredCondition(); pruneDeferredCond != nil {
+			bundleUpdated = updateBundleCondition(st.bundle, pruneDeferredCond) || bundleUpdated
+			conditions = append(conditions, *pruneDeferredCond)
+		}
+		if _, oldPausedCond := st.bundle.GetCondition(smith_v1.BundlePaused); oldPausedCond != nil {
+			// Bundle has been resumed
+			pausedCond := smith_v1.BundleCondition{Type: smith_v1.BundlePaused, Status: smith_v1.ConditionFalse}
+			bundleUpdated = updateBundleCondition(st.bundle, &pausedCond) || bundleUpdated
+			conditions = append(conditions, pausedCond)
+		}
+
+		// Fields for querying with JSONPath
+		resourcesSummary := fmt.Sprintf("%d/%d", readyResources, len(st.bundle.Spec.Resources))
+		blocked := blockedByFailures(st.bundle.Spec.Resources, st.processedResources)
+		summary := bundleSummary(readyResources, len(st.bundle.Spec.Resources), failedResources, blocked, waitingOn, &readyCond, &errorCond, timedOutCond)
+		if st.bundle.Status.Ready != readyCond.Status || !reflect.DeepEqual(st.bundle.Status.FailedResources, failedResources) ||
+			st.bundle.Status.Resources != resourcesSummary || st.bundle.Status.Summary != summary {
+			st.bundle.Status.Ready = readyCond.Status
+			st.bundle.Status.FailedResources = failedResources
+			st.bundle.Status.Resources = resourcesSummary
+			st.bundle.Status.Summary = summary
+			bundleUpdated = true
+		}
 
 		// Update the bundle status
 		if bundleUpdated {
 			st.bundle.Status.ResourceStatuses = resourceStatuses
-			st.bundle.Status.Conditions = []smith_v1.BundleCondition{inProgressCond, readyCond, errorCond}
+			st.bundle.Status.Conditions = conditions
 		}
 
 		obj2deleteUpdated, err := st.updateObjectsToDeleteStatus()
@@ -427,6 +1130,35 @@ func (st *bundleSyncTask) handleProcessResult(retriable bool, processErr error)
 		} else {
 			bundleUpdated = obj2deleteUpdated || bundleUpdated
 		}
+	} else {
+		// Bundle is being deleted
+		bundleUpdated = st.deletionReportUpdated || st.planUpdated
+		bundleUpdated = st.updateObservedGeneration() || bundleUpdated
+		if st.awaitingObjectsDeletion {
+			obj2deleteUpdated, err := st.updateObjectsToDeleteStatus()
+			if err != nil {
+				// Just log the error and continue
+				st.logger.Error("Error updating ObjectsToDelete status field", zap.Error(err))
+			} else {
+				bundleUpdated = obj2deleteUpdated || bundleUpdated
+			}
+		}
+		if st.awaitingDeletionConfirmation {
+			inProgressCond := smith_v1.BundleCondition{Type: smith_v1.BundleInProgress, Status: smith_v1.ConditionFalse}
+			readyCond := smith_v1.BundleCondition{Type: smith_v1.BundleReady, Status: smith_v1.ConditionFalse}
+			errorCond := smith_v1.BundleCondition{
+				Type:    smith_v1.BundleError,
+				Status:  smith_v1.ConditionTrue,
+				Reason:  smith_v1.BundleReasonDeletionNotConfirmed,
+				Message: fmt.Sprintf("deletion of a Bundle labeled %s=true must be confirmed with the %s=true annotation", smith.ProductionLabel, smith.DeletionConfirmedAnnotation),
+			}
+			bundleUpdated = updateBundleCondition(st.bundle, &inProgressCond) || bundleUpdated
+			bundleUpdated = updateBundleCondition(st.bundle, &readyCond) || bundleUpdated
+			bundleUpdated = updateBundleCondition(st.bundle, &errorCond) || bundleUpdated
+			if bundleUpdated {
+				st.bundle.Status.Conditions = []smith_v1.BundleCondition{inProgressCond, readyCond, errorCond}
+			}
+		}
 	}
 
 	if bundleUpdated {
@@ -440,6 +1172,38 @@ func (st *bundleSyncTask) handleProcessResult(retriable bool, processErr error)
 	return retriable, processErr
 }
 
+// scheduleRetry schedules processing of the Bundle after it has failed with a retriable error.
+// Returns nil if retries are not scheduled by the controller.
+func (st *bundleSyncTask) scheduleRetry(now time.Time) *retrySchedule {
+	if st.retryBackoff == nil || st.retry != nil {
+		return st.retry
+	}
+	key := ctrl.QueueKey{
+		Namespace: st.bundle.Namespace,
+		Name:      st.bundle.Name,
+	}
+	var budget func() time.Duration
+	if st.retryBudget != nil {
+		budget = func() time.Duration {
+			delay := st.retryBudget.reserve(key.Namespace)
+			if delay > 0 {
+				st.logger.Sugar().Debugf("Retry budget of namespace is exhausted, deferring retry by up to %s", delay)
+			}
+			return delay
+		}
+	}
+	retry := st.retryBackoff.schedule(key, st.bundle.Annotations[smith.RequeueAnnotation], now, budget)
+	st.retry = &retry
+	return st.retry
+}
+
+func retryStatusEqual(a, b *smith_v1.RetryStatus) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Attempts == b.Attempts && a.NextRetryTime.Equal(&b.NextRetryTime)
+}
+
 func (st *bundleSyncTask) updateObjectsToDeleteStatus() (bool /* bundleUpdated */, error) {
 	if st.objectsToDelete == nil {
 		err := st.findObjectsToDelete()
@@ -448,13 +1212,20 @@ func (st *bundleSyncTask) updateObjectsToDeleteStatus() (bool /* bundleUpdated *
 		}
 	}
 	newToDelete := make([]smith_v1.ObjectToDelete, 0, len(st.objectsToDelete))
-	for ref := range st.objectsToDelete {
-		newToDelete = append(newToDelete, smith_v1.ObjectToDelete{
-			Group:   ref.Group,
-			Version: ref.Version,
-			Kind:    ref.Kind,
-			Name:    ref.Name,
-		})
+	for ref, obj := range st.objectsToDelete {
+		toDelete := smith_v1.ObjectToDelete{
+			Group:              ref.Group,
+			Version:            ref.Version,
+			Kind:               ref.Kind,
+			Name:               ref.Name,
+			Namespace:          ref.Namespace,
+			BlockingFinalizers: blockingFinalizers(obj.(meta_v1.Object)),
+		}
+		if failure, _ := st.pruneBackoff.get(st.bundle.UID, ref); failure.attempts > 0 {
+			toDelete.FailedDeletionAttempts = failure.attempts
+			toDelete.LastDeletionError = failure.err.Error()
+		}
+		newToDelete = append(newToDelete, toDelete)
 	}
 	// Sort them to ensure map iteration order and the order of informers we got the date from does not influence the result.
 	sort.Slice(newToDelete, func(i, j int) bool {
@@ -478,6 +1249,12 @@ func (st *bundleSyncTask) updateObjectsToDeleteStatus() (bool /* bundleUpdated *
 		if a.Kind > b.Kind {
 			return false
 		}
+		if a.Namespace < b.Namespace {
+			return true
+		}
+		if a.Namespace > b.Namespace {
+			return false
+		}
 		if a.Name < b.Name {
 			return true
 		}
@@ -494,6 +1271,176 @@ func (st *bundleSyncTask) updateObjectsToDeleteStatus() (bool /* bundleUpdated *
 	return false, nil
 }
 
+// bundleSummary returns a short description of the state of a Bundle for the status.summary field.
+// timedOutCond may be nil. waitingOn describes resources the Bundle is waiting on, they are listed if the Bundle is
+// in progress or has timed out. If resources failed, the resources blocked by them and the resources of independent
+// branches that are still being worked on are listed too.
+func bundleSummary(ready, total int, failedResources, blocked []smith_v1.ResourceName, waitingOn []string, readyCond, errorCond, timedOutCond *smith_v1.BundleCondition) string {
+	switch {
+	case readyCond.Status == smith_v1.ConditionTrue:
+		return fmt.Sprintf("Ready: %d/%d resources ready", ready, total)
+	case timedOutCond != nil && timedOutCond.Status == smith_v1.ConditionTrue && errorCond.Status != smith_v1.ConditionTrue:
+		return fmt.Sprintf("Timed out: %d/%d resources ready", ready, total) + waitingOnSummary(waitingOn)
+	case errorCond.Status != smith_v1.ConditionTrue:
+		return fmt.Sprintf("In progress: %d/%d resources ready", ready, total) + waitingOnSummary(waitingOn)
+	case len(failedResources) > 0:
+		summary := fmt.Sprintf("Error: %d/%d resources ready, failed: %s", ready, total, joinResourceNames(failedResources))
+		if len(blocked) > 0 {
+			summary += "; blocked: " + joinResourceNames(blocked)
+		}
+		return summary + waitingOnSummary(waitingOn)
+	default:
+		return fmt.Sprintf("Error: %d/%d resources ready, %s", ready, total, errorCond.Reason)
+	}
+}
+
+func joinResourceNames(names []smith_v1.ResourceName) string {
+	strs := make([]string, 0, len(names))
+	for _, name := range names {
+		strs = append(strs, string(name))
+	}
+	return strings.Join(strs, ", ")
+}
+
+// blockedByFailures returns names of resources that are blocked by a dependency that failed, directly or through
+// other blocked dependencies, in the order of the resources. Resources that are only waiting for dependencies to
+// become ready are not included.
+func blockedByFailures(resources []smith_v1.Resource, processedResources map[smith_v1.ResourceName]*resourceInfo) []smith_v1.ResourceName {
+	memo := make(map[smith_v1.ResourceName]bool, len(resources))
+	var isBlocked func(name smith_v1.ResourceName) bool
+	isBlocked = func(name smith_v1.ResourceName) bool {
+		if blocked, ok := memo[name]; ok {
+			return blocked
+		}
+		memo[name] = false // Graph is acyclic, this only guards against malformed input
+		resInfo, ok := processedResources[name]
+		if !ok {
+			return false
+		}
+		notReady, ok := resInfo.status.(resourceStatusDependenciesNotReady)
+		if !ok {
+			return false
+		}
+		for _, dep := range notReady.dependencies {
+			depInfo, ok := processedResources[dep]
+			if !ok {
+				continue
+			}
+			if _, failed := depInfo.status.(resourceStatusError); failed || isBlocked(dep) {
+				memo[name] = true
+				return true
+			}
+		}
+		return false
+	}
+	var blocked []smith_v1.ResourceName
+	for _, res := range resources {
+		if isBlocked(res.Name) {
+			blocked = append(blocked, res.Name)
+		}
+	}
+	return blocked
+}
+
+// maxWaitingOnInSummary is the number of resources the summary of a Bundle lists as the ones it is waiting on.
+const maxWaitingOnInSummary = 3
+
+func waitingOnSummary(waitingOn []string) string {
+	switch {
+	case len(waitingOn) == 0:
+		return ""
+	case len(waitingOn) > maxWaitingOnInSummary:
+		return fmt.Sprintf("; waiting on %s and %d more", strings.Join(waitingOn[:maxWaitingOnInSummary], ", "), len(waitingOn)-maxWaitingOnInSummary)
+	default:
+		return "; waiting on " + strings.Join(waitingOn, ", ")
+	}
+}
+
+// checkProgressDeadline tracks since when the Bundle has not been ready and checks that against its progress deadline.
+// Returns the TimedOut condition if the Bundle has a progress deadline and true if the progress start time was updated.
+func (st *bundleSyncTask) checkProgressDeadline(ready bool, now time.Time) (*smith_v1.BundleCondition, bool /* progressStartUpdated */) {
+	progressStartUpdated := false
+	if ready {
+		if st.bundle.Status.ProgressStartTime != nil {
+			st.bundle.Status.ProgressStartTime = nil
+			progressStartUpdated = true
+		}
+	} else if st.bundle.Status.ProgressStartTime == nil || st.validatedGenerationUpdated {
+		// New spec restarts the clock
+		progressStart := meta_v1.NewTime(now)
+		st.bundle.Status.ProgressStartTime = &progressStart
+		progressStartUpdated = true
+	}
+	deadlineSeconds := st.bundle.Spec.ProgressDeadlineSeconds
+	if deadlineSeconds == nil {
+		return nil, progressStartUpdated
+	}
+	timedOutCond := &smith_v1.BundleCondition{Type: smith_v1.BundleTimedOut, Status: smith_v1.ConditionFalse}
+	if !ready {
+		deadline := time.Duration(*deadlineSeconds) * time.Second
+		elapsed := now.Sub(st.bundle.Status.ProgressStartTime.Time)
+		if elapsed >= deadline {
+			timedOutCond.Status = smith_v1.ConditionTrue
+			timedOutCond.Reason = smith_v1.BundleReasonProgressDeadlineExceeded
+			timedOutCond.Message = fmt.Sprintf("resources have not become ready within %s", deadline)
+		} else {
+			// Nothing may happen to the Bundle until the deadline passes, check it again then
+			st.requeueNoLaterThan(deadline - elapsed)
+		}
+	}
+	return timedOutCond, progressStartUpdated
+}
+
+// updatePausedCondition sets the Paused condition and the summary of a paused Bundle.
+// Returns true if the status has changed.
+func (st *bundleSyncTask) updatePausedCondition() bool {
+	pausedCond := smith_v1.BundleCondition{
+		Type:    smith_v1.BundlePaused,
+		Status:  smith_v1.ConditionTrue,
+		Message: fmt.Sprintf("processing is paused with the %s=true annotation", smith.PausedAnnotation),
+	}
+	updated := updateBundleCondition(st.bundle, &pausedCond)
+	if updated {
+		if i, _ := st.bundle.GetCondition(smith_v1.BundlePaused); i >= 0 {
+			st.bundle.Status.Conditions[i] = pausedCond
+		} else {
+			st.bundle.Status.Conditions = append(st.bundle.Status.Conditions, pausedCond)
+		}
+	}
+	summary := fmt.Sprintf("Paused: %s resources ready", st.bundle.Status.Resources)
+	if st.bundle.Status.Resources == "" {
+		summary = "Paused"
+	}
+	if st.bundle.Status.Summary != summary {
+		st.bundle.Status.Summary = summary
+		updated = true
+	}
+	return updated
+}
+
+// pruneDeferredCondition returns the PruneDeferred condition. Returns nil if pruning has never been deferred.
+func (st *bundleSyncTask) pruneDeferredCondition() *smith_v1.BundleCondition {
+	if st.pruneDeferred {
+		return &smith_v1.BundleCondition{
+			Type:    smith_v1.BundlePruneDeferred,
+			Status:  smith_v1.ConditionTrue,
+			Reason:  smith_v1.BundleReasonInformersNotSynced,
+			Message: "objects removed from the Bundle are not deleted until informers have synced",
+		}
+	}
+	if _, oldCond := st.bundle.GetCondition(smith_v1.BundlePruneDeferred); oldCond != nil {
+		return &smith_v1.BundleCondition{Type: smith_v1.BundlePruneDeferred, Status: smith_v1.ConditionFalse}
+	}
+	return nil
+}
+
+// requeueNoLaterThan makes sure the Bundle is processed again within the delay.
+func (st *bundleSyncTask) requeueNoLaterThan(delay time.Duration) {
+	if delay > 0 && (st.requeueAfter == 0 || delay < st.requeueAfter) {
+		st.requeueAfter = delay
+	}
+}
+
 func (st *bundleSyncTask) isBundleReady() bool {
 	for _, res := range st.bundle.Spec.Resources {
 		res := st.processedResources[res.Name]
@@ -506,7 +1453,9 @@ func (st *bundleSyncTask) isBundleReady() bool {
 
 type objectRef struct {
 	schema.GroupVersionKind
-	Name string
+	// Namespace of the object if it is not the namespace of the Bundle.
+	Namespace string
+	Name      string
 }
 
 // updateBundleCondition updates passed condition by fetching information from an existing resource condition if present.
@@ -542,6 +1491,30 @@ func updateBundleCondition(b *smith_v1.Bundle, condition *smith_v1.BundleConditi
 	return !isEqual
 }
 
+// setResourceState sets fields of the resource status that summarize its conditions.
+func setResourceState(status *smith_v1.ResourceStatus, blockedCond, inProgressCond, readyCond, errorCond *smith_v1.ResourceCondition) {
+	var cond *smith_v1.ResourceCondition
+	switch {
+	case errorCond.Status == smith_v1.ConditionTrue:
+		status.State = smith_v1.ResourceStateError
+		cond = errorCond
+	case readyCond.Status == smith_v1.ConditionTrue:
+		status.State = smith_v1.ResourceStateReady
+		cond = readyCond
+	case blockedCond.Status == smith_v1.ConditionTrue:
+		status.State = smith_v1.ResourceStateBlocked
+		cond = blockedCond
+	case inProgressCond.Status == smith_v1.ConditionTrue:
+		status.State = smith_v1.ResourceStateInProgress
+		cond = inProgressCond
+	default:
+		status.State = smith_v1.ResourceStateUnknown
+		cond = readyCond
+	}
+	status.Message = cond.Message
+	status.LastTransitionTime = cond.LastTransitionTime
+}
+
 // updateResourceCondition updates passed condition by fetching information from an existing resource condition if present.
 // Sets LastTransitionTime to now if the status has changed.
 // Returns true if resource condition in the bundle does not match and needs to be updated.
@@ -590,8 +1563,12 @@ func sortBundle(bundle *smith_v1.Bundle) (*graph.Graph, []graph.V, error) {
 	}
 
 	for _, res := range bundle.Spec.Resources {
-		for _, reference := range res.References {
-			if err := g.AddEdge(res.Name, reference.Resource); err != nil {
+		deps, err := resourceDependencies(res, g)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, dep := range deps {
+			if err := g.AddEdge(res.Name, dep); err != nil {
 				return nil, nil, err
 			}
 		}
@@ -604,3 +1581,41 @@ func sortBundle(bundle *smith_v1.Bundle) (*graph.Graph, []graph.V, error) {
 
 	return g, sorted, nil
 }
+
+// resourceDependencies returns a de-duplicated list of resources the resource references or has in quorums,
+// in order of appearance.
+// An error is returned if a reference or a quorum points at a resource that is not in the graph.
+func resourceDependencies(res smith_v1.Resource, g *graph.Graph) ([]smith_v1.ResourceName, error) {
+	deps := make([]smith_v1.ResourceName, 0, len(res.References))
+	seen := make(map[smith_v1.ResourceName]struct{}, len(res.References))
+	for _, reference := range res.References {
+		if reference.IsExternal() {
+			continue
+		}
+		if !g.ContainsVertex(reference.Resource) {
+			return nil, errors.Errorf("resource %q references non-existent resource %q", res.Name, reference.Resource)
+		}
+		if _, ok := seen[reference.Resource]; ok {
+			continue
+		}
+		seen[reference.Resource] = struct{}{}
+		deps = append(deps, reference.Resource)
+	}
+	for _, quorum := range res.Quorums {
+		if quorum.MinReady < 1 || quorum.MinReady > len(quorum.Resources) {
+			return nil, errors.Errorf("resource %q has a quorum with minReady %d but it must be between 1 and the number of resources in the quorum (%d)",
+				res.Name, quorum.MinReady, len(quorum.Resources))
+		}
+		for _, member := range quorum.Resources {
+			if !g.ContainsVertex(member) {
+				return nil, errors.Errorf("resource %q has non-existent resource %q in a quorum", res.Name, member)
+			}
+			if _, ok := seen[member]; ok {
+				continue
+			}
+			seen[member] = struct{}{}
+			deps = append(deps, member)
+		}
+	}
+	return deps, nil
+}
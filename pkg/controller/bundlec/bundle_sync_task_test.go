@@ -0,0 +1,391 @@
+package bundlec
+
+import (
+	"testing"
+	"time"
+
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	apps_v1 "k8s.io/api/apps/v1"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestPreflightFailsWithoutApplying(t *testing.T) {
+	t.Parallel()
+	bundle := &smith_v1.Bundle{
+		Spec: smith_v1.BundleSpec{
+			Resources: []smith_v1.Resource{
+				{
+					Name: "a",
+					Spec: smith_v1.ResourceSpec{
+						Object: &core_v1.ConfigMap{
+							TypeMeta: meta_v1.TypeMeta{
+								Kind:       "ConfigMap",
+								APIVersion: core_v1.SchemeGroupVersion.String(),
+							},
+						},
+					},
+				},
+				{
+					Name: "b",
+					References: []smith_v1.Reference{
+						{
+							Name:     "a-ref",
+							Resource: "a",
+							Example:  "example",
+						},
+					},
+					Spec: smith_v1.ResourceSpec{
+						Plugin: &smith_v1.PluginSpec{
+							Name: "missing",
+						},
+					},
+				},
+			},
+		},
+	}
+	_, sorted, err := sortBundle(bundle)
+	require.NoError(t, err)
+	resourceMap := make(map[smith_v1.ResourceName]smith_v1.Resource, len(bundle.Spec.Resources))
+	for _, res := range bundle.Spec.Resources {
+		resourceMap[res.Name] = res
+	}
+	st := bundleSyncTask{
+		logger:             zap.NewNop(),
+		bundle:             bundle,
+		processedResources: make(map[smith_v1.ResourceName]*resourceInfo),
+	}
+
+	err = st.preflight(sorted, resourceMap)
+	require.Error(t, err)
+	preflightErr, ok := errors.Cause(err).(*preflightError)
+	require.True(t, ok)
+	assert.Equal(t, []smith_v1.ResourceName{"b"}, preflightErr.failedResources)
+
+	require.Len(t, st.processedResources, 1)
+	_, ok = st.processedResources["b"].status.(resourceStatusError)
+	assert.True(t, ok)
+}
+
+func TestBundleSummary(t *testing.T) {
+	t.Parallel()
+	trueCond := &smith_v1.BundleCondition{Status: smith_v1.ConditionTrue}
+	falseCond := &smith_v1.BundleCondition{Status: smith_v1.ConditionFalse}
+	errorCond := &smith_v1.BundleCondition{Status: smith_v1.ConditionTrue, Reason: smith_v1.BundleReasonPreflightFailed}
+
+	assert.Equal(t, "Ready: 2/2 resources ready", bundleSummary(2, 2, nil, nil, nil, trueCond, falseCond, nil))
+	assert.Equal(t, "In progress: 1/2 resources ready", bundleSummary(1, 2, nil, nil, nil, falseCond, falseCond, nil))
+	assert.Equal(t, "Error: 0/3 resources ready, failed: a, b", bundleSummary(0, 3, []smith_v1.ResourceName{"a", "b"}, nil, nil, falseCond, errorCond, nil))
+	assert.Equal(t, "Error: 0/3 resources ready, PreflightFailed", bundleSummary(0, 3, nil, nil, nil, falseCond, errorCond, nil))
+	assert.Equal(t, "Timed out: 1/2 resources ready", bundleSummary(1, 2, nil, nil, nil, falseCond, falseCond, trueCond))
+	assert.Equal(t, "In progress: 1/2 resources ready", bundleSummary(1, 2, nil, nil, nil, falseCond, falseCond, falseCond))
+
+	waitingOn := []string{"db (ServiceInstance not ready)"}
+	assert.Equal(t, "In progress: 7/9 resources ready; waiting on db (ServiceInstance not ready)",
+		bundleSummary(7, 9, nil, nil, waitingOn, falseCond, falseCond, nil))
+	assert.Equal(t, "Timed out: 7/9 resources ready; waiting on db (ServiceInstance not ready)",
+		bundleSummary(7, 9, nil, nil, waitingOn, falseCond, falseCond, trueCond))
+	assert.Equal(t, "In progress: 0/4 resources ready; waiting on a, b, c and 1 more",
+		bundleSummary(0, 4, nil, nil, []string{"a", "b", "c", "d"}, falseCond, falseCond, nil))
+	assert.Equal(t, "Error: 1/5 resources ready, failed: a; blocked: b, c; waiting on d (ConfigMap not ready)",
+		bundleSummary(1, 5, []smith_v1.ResourceName{"a"}, []smith_v1.ResourceName{"b", "c"}, []string{"d (ConfigMap not ready)"}, falseCond, errorCond, nil))
+}
+
+func TestBlockedByFailures(t *testing.T) {
+	t.Parallel()
+	resources := []smith_v1.Resource{{Name: "c"}, {Name: "b"}, {Name: "a"}, {Name: "d"}, {Name: "e"}, {Name: "f"}}
+	processed := map[smith_v1.ResourceName]*resourceInfo{
+		"a": {status: resourceStatusError{err: errors.New("boom")}},
+		"b": {status: resourceStatusDependenciesNotReady{dependencies: []smith_v1.ResourceName{"a"}}},
+		"c": {status: resourceStatusDependenciesNotReady{dependencies: []smith_v1.ResourceName{"b"}}},
+		"d": {status: resourceStatusInProgress{}},
+		"e": {status: resourceStatusDependenciesNotReady{dependencies: []smith_v1.ResourceName{"d"}}},
+		"f": {status: resourceStatusReady{}},
+	}
+
+	assert.Equal(t, []smith_v1.ResourceName{"c", "b"}, blockedByFailures(resources, processed))
+	assert.Empty(t, blockedByFailures(resources, map[smith_v1.ResourceName]*resourceInfo{}))
+}
+
+func TestNamespaceTerminatingRemovesFinalizer(t *testing.T) {
+	t.Parallel()
+	now := meta_v1.Now()
+	bundle := &smith_v1.Bundle{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Labels:            map[string]string{smith.ProductionLabel: "true"},
+			Finalizers:        []string{FinalizerDeleteResources},
+			DeletionTimestamp: &now,
+		},
+	}
+	st := bundleSyncTask{
+		logger:                      zap.NewNop(),
+		bundle:                      bundle,
+		requireDeletionConfirmation: true,
+		namespaceTerminating:        true,
+	}
+
+	retriable, err := st.processDeleted()
+	require.NoError(t, err)
+	assert.False(t, retriable)
+	assert.Empty(t, st.newFinalizers)
+	assert.False(t, st.awaitingDeletionConfirmation)
+}
+
+func TestNamespaceTerminatingSkipsProcessing(t *testing.T) {
+	t.Parallel()
+	st := bundleSyncTask{
+		logger:               zap.NewNop(),
+		bundle:               &smith_v1.Bundle{},
+		namespaceTerminating: true,
+	}
+
+	retriable, err := st.processNormal()
+	require.NoError(t, err)
+	assert.False(t, retriable)
+	assert.Nil(t, st.newFinalizers) // finalizer is not added
+	assert.Nil(t, st.processedResources)
+}
+
+func TestPausedSkipsProcessing(t *testing.T) {
+	t.Parallel()
+	st := bundleSyncTask{
+		logger: zap.NewNop(),
+		bundle: &smith_v1.Bundle{},
+		paused: true,
+	}
+
+	retriable, err := st.processNormal()
+	require.NoError(t, err)
+	assert.False(t, retriable)
+	assert.Nil(t, st.newFinalizers) // finalizer is not added
+	assert.Nil(t, st.processedResources)
+}
+
+func TestUpdateObservedGeneration(t *testing.T) {
+	t.Parallel()
+	st := bundleSyncTask{
+		bundle: &smith_v1.Bundle{
+			ObjectMeta: meta_v1.ObjectMeta{Generation: 2},
+			Status:     smith_v1.BundleStatus{ObservedGeneration: 1, ValidatedGeneration: 1},
+		},
+	}
+
+	assert.True(t, st.updateObservedGeneration())
+	assert.EqualValues(t, 2, st.bundle.Status.ObservedGeneration)
+	// Validation is tracked separately
+	assert.EqualValues(t, 1, st.bundle.Status.ValidatedGeneration)
+
+	assert.False(t, st.updateObservedGeneration())
+}
+
+func TestUpdatePausedCondition(t *testing.T) {
+	t.Parallel()
+	st := bundleSyncTask{
+		logger: zap.NewNop(),
+		bundle: &smith_v1.Bundle{
+			Status: smith_v1.BundleStatus{
+				Conditions: []smith_v1.BundleCondition{
+					{Type: smith_v1.BundleReady, Status: smith_v1.ConditionTrue},
+				},
+				Resources: "2/2",
+				Summary:   "Ready: 2/2 resources ready",
+			},
+		},
+	}
+
+	assert.True(t, st.updatePausedCondition())
+	require.Len(t, st.bundle.Status.Conditions, 2)
+	_, readyCond := st.bundle.GetCondition(smith_v1.BundleReady)
+	require.NotNil(t, readyCond)
+	assert.Equal(t, smith_v1.ConditionTrue, readyCond.Status)
+	_, pausedCond := st.bundle.GetCondition(smith_v1.BundlePaused)
+	require.NotNil(t, pausedCond)
+	assert.Equal(t, smith_v1.ConditionTrue, pausedCond.Status)
+	assert.Equal(t, "Paused: 2/2 resources ready", st.bundle.Status.Summary)
+
+	// Nothing changes while the Bundle stays paused
+	assert.False(t, st.updatePausedCondition())
+	assert.Len(t, st.bundle.Status.Conditions, 2)
+}
+
+func TestUpdateWarnings(t *testing.T) {
+	t.Parallel()
+	st := bundleSyncTask{
+		logger: zap.NewNop(),
+		rc:     fakeReadyChecker{},
+		bundle: &smith_v1.Bundle{
+			Spec: smith_v1.BundleSpec{
+				Resources: []smith_v1.Resource{
+					{
+						Name: "a",
+						Spec: smith_v1.ResourceSpec{
+							Object: orderedDeletionConfigMap("a"),
+						},
+					},
+					{
+						Name:       "b",
+						References: []smith_v1.Reference{{Resource: "a"}, {Resource: "a"}},
+						Spec: smith_v1.ResourceSpec{
+							Object: orderedDeletionConfigMap("b"),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	assert.True(t, st.updateWarnings())
+	require.Len(t, st.bundle.Status.Warnings, 1)
+	assert.Equal(t, smith_v1.WarningReasonUnusedDependency, st.bundle.Status.Warnings[0].Reason)
+	assert.EqualValues(t, "b", st.bundle.Status.Warnings[0].Resource)
+
+	// Warnings are only updated when they change
+	assert.False(t, st.updateWarnings())
+
+	st.bundle.Spec.Resources[1].References = st.bundle.Spec.Resources[1].References[:1]
+	assert.True(t, st.updateWarnings())
+	assert.Empty(t, st.bundle.Status.Warnings)
+}
+
+func TestProgressDeadline(t *testing.T) {
+	t.Parallel()
+	deadline := int32(60)
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	st := bundleSyncTask{
+		logger: zap.NewNop(),
+		bundle: &smith_v1.Bundle{
+			Spec: smith_v1.BundleSpec{
+				ProgressDeadlineSeconds: &deadline,
+			},
+		},
+	}
+
+	// Not ready, clock starts
+	cond, updated := st.checkProgressDeadline(false, start)
+	require.NotNil(t, cond)
+	assert.True(t, updated)
+	assert.Equal(t, smith_v1.ConditionFalse, cond.Status)
+	require.NotNil(t, st.bundle.Status.ProgressStartTime)
+	assert.True(t, start.Equal(st.bundle.Status.ProgressStartTime.Time))
+	assert.Equal(t, 60*time.Second, st.requeueAfter)
+
+	// Still within the deadline
+	st.requeueAfter = 0
+	cond, updated = st.checkProgressDeadline(false, start.Add(20*time.Second))
+	assert.False(t, updated)
+	assert.Equal(t, smith_v1.ConditionFalse, cond.Status)
+	assert.Equal(t, 40*time.Second, st.requeueAfter)
+
+	// Deadline exceeded
+	st.requeueAfter = 0
+	cond, updated = st.checkProgressDeadline(false, start.Add(60*time.Second))
+	assert.False(t, updated)
+	assert.Equal(t, smith_v1.ConditionTrue, cond.Status)
+	assert.Equal(t, smith_v1.BundleReasonProgressDeadlineExceeded, cond.Reason)
+	assert.Zero(t, st.requeueAfter)
+
+	// New generation restarts the clock
+	st.validatedGenerationUpdated = true
+	cond, updated = st.checkProgressDeadline(false, start.Add(90*time.Second))
+	assert.True(t, updated)
+	assert.Equal(t, smith_v1.ConditionFalse, cond.Status)
+	assert.Equal(t, 60*time.Second, st.requeueAfter)
+
+	// Ready, clock stops
+	cond, updated = st.checkProgressDeadline(true, start.Add(100*time.Second))
+	assert.True(t, updated)
+	assert.Equal(t, smith_v1.ConditionFalse, cond.Status)
+	assert.Nil(t, st.bundle.Status.ProgressStartTime)
+}
+
+func TestNoProgressDeadline(t *testing.T) {
+	t.Parallel()
+	st := bundleSyncTask{
+		logger: zap.NewNop(),
+		bundle: &smith_v1.Bundle{},
+	}
+
+	cond, updated := st.checkProgressDeadline(false, time.Now())
+	assert.Nil(t, cond)
+	assert.True(t, updated)
+	assert.NotNil(t, st.bundle.Status.ProgressStartTime)
+	assert.Zero(t, st.requeueAfter)
+}
+
+func TestSetResourceState(t *testing.T) {
+	t.Parallel()
+	transition := meta_v1.NewTime(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC))
+	trueCond := func(msg string) *smith_v1.ResourceCondition {
+		return &smith_v1.ResourceCondition{Status: smith_v1.ConditionTrue, Message: msg, LastTransitionTime: transition}
+	}
+	falseCond := func() *smith_v1.ResourceCondition {
+		return &smith_v1.ResourceCondition{Status: smith_v1.ConditionFalse}
+	}
+	unknownCond := func() *smith_v1.ResourceCondition {
+		return &smith_v1.ResourceCondition{Status: smith_v1.ConditionUnknown}
+	}
+
+	var status smith_v1.ResourceStatus
+	setResourceState(&status, falseCond(), trueCond("retrying"), falseCond(), trueCond("boom"))
+	assert.Equal(t, smith_v1.ResourceStateError, status.State)
+	assert.Equal(t, "boom", status.Message)
+	assert.True(t, transition.Equal(&status.LastTransitionTime))
+
+	setResourceState(&status, trueCond(`Not ready: ["a"]`), falseCond(), falseCond(), falseCond())
+	assert.Equal(t, smith_v1.ResourceStateBlocked, status.State)
+	assert.Equal(t, `Not ready: ["a"]`, status.Message)
+
+	setResourceState(&status, falseCond(), trueCond(""), falseCond(), falseCond())
+	assert.Equal(t, smith_v1.ResourceStateInProgress, status.State)
+	assert.Empty(t, status.Message)
+
+	setResourceState(&status, falseCond(), falseCond(), trueCond(""), falseCond())
+	assert.Equal(t, smith_v1.ResourceStateReady, status.State)
+
+	setResourceState(&status, unknownCond(), unknownCond(), unknownCond(), unknownCond())
+	assert.Equal(t, smith_v1.ResourceStateUnknown, status.State)
+}
+
+func TestPruneDeferredUntilInformersSynced(t *testing.T) {
+	t.Parallel()
+	deploymentGVK := apps_v1.SchemeGroupVersion.WithKind("Deployment")
+	st := bundleSyncTask{
+		logger: zap.NewNop(),
+		bundle: &smith_v1.Bundle{},
+		// Informers are not started so they have not synced
+		store:              specSourceStore(t),
+		processedResources: map[smith_v1.ResourceName]*resourceInfo{},
+		objectsToDelete: map[objectRef]runtime.Object{
+			{GroupVersionKind: configMapGVK, Name: "map1"}: dryRunConfigMap(nil),
+			// Objects of kinds without an informer cannot be in the Store, the kind does not block pruning
+			{GroupVersionKind: deploymentGVK, Name: "d1"}: &apps_v1.Deployment{},
+		},
+	}
+	assert.Equal(t, []schema.GroupVersionKind{configMapGVK}, st.relevantGVKs())
+
+	retriable, err := st.pruneRemovedObjects()
+	require.NoError(t, err)
+	assert.False(t, retriable)
+	assert.True(t, st.pruneDeferred)
+	assert.Equal(t, pruneDeferredRequeueDelay, st.requeueAfter)
+	cond := st.pruneDeferredCondition()
+	require.NotNil(t, cond)
+	assert.Equal(t, smith_v1.ConditionTrue, cond.Status)
+	assert.Equal(t, smith_v1.BundleReasonInformersNotSynced, cond.Reason)
+
+	// Condition is reset once pruning is not deferred anymore
+	st.bundle.Status.Conditions = []smith_v1.BundleCondition{*cond}
+	st.pruneDeferred = false
+	assert.Equal(t, &smith_v1.BundleCondition{Type: smith_v1.BundlePruneDeferred, Status: smith_v1.ConditionFalse}, st.pruneDeferredCondition())
+
+	// Condition is not set if pruning has never been deferred
+	st.bundle.Status.Conditions = nil
+	assert.Nil(t, st.pruneDeferredCondition())
+}
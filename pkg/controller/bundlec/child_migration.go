@@ -0,0 +1,49 @@
+package bundlec
+
+import (
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/atlassian/smith/pkg/migration"
+	"github.com/pkg/errors"
+)
+
+const (
+	// EventReasonObjectMigrated is the reason of the Event recorded when an object is rewritten at the version
+	// its resource has been migrated to.
+	EventReasonObjectMigrated = "ObjectMigrated"
+)
+
+// resources returns resources of the Bundle. Specs of resources with specFrom are read from their sources.
+// If migration rules are configured, objects are converted to the versions the rules migrate them to so that existing
+// objects are rewritten in place at the new version.
+// The Bundle is not mutated, its spec is migrated with smithctl migrate-bundle using the same rules.
+// Names of objects generated from metadata.generateName are filled in once they are known.
+func (st *bundleSyncTask) resources() ([]smith_v1.Resource, error) {
+	if st.resolvedResources != nil {
+		return st.resolvedResources, nil
+	}
+	resources, err := st.resolveSpecFrom(st.bundle.Spec.Resources)
+	if err != nil {
+		return nil, err
+	}
+	if st.migrations != nil {
+		var migrations []migration.Migration
+		resources, migrations, err = st.migrations.MigrateResources(resources)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to migrate resources")
+		}
+		for _, m := range migrations {
+			st.logger.Sugar().Debugf("Resource %q declares version %s, processing it at version %s", m.Resource, m.From.Version, m.To.Version)
+			if st.migratedFrom == nil {
+				st.migratedFrom = make(map[smith_v1.ResourceName]string, len(migrations))
+			}
+			st.migratedFrom[m.Resource] = m.From.GroupVersion().String()
+		}
+	}
+	// Objects are looked up at the versions they are processed at
+	resources, err = st.resolveGeneratedNames(resources)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to resolve generated names")
+	}
+	st.resolvedResources = resources
+	return resources, nil
+}
@@ -0,0 +1,65 @@
+package bundlec
+
+import (
+	"sync"
+
+	"github.com/atlassian/ctrl"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	queueAddResultQueued    = "queued"
+	queueAddResultCoalesced = "coalesced"
+)
+
+// CoalescingWorkQueue counts Bundles that are added to the work queue while they are already pending.
+// The work queue holds at most one pending entry per key, so such additions are coalesced into the pending entry
+// instead of causing another run. A key stops being pending once its processing starts, additions during processing
+// schedule a single follow-up run. Keys added with a delay or rate limited are passed through and not tracked.
+// Keys of Bundles that are deleted before they are processed stay pending, at most one entry per deleted Bundle.
+type CoalescingWorkQueue struct {
+	ctrl.WorkQueueProducer
+	adds *prometheus.CounterVec
+
+	mx      sync.Mutex
+	pending map[ctrl.QueueKey]struct{}
+}
+
+func NewCoalescingWorkQueue(queue ctrl.WorkQueueProducer) *CoalescingWorkQueue {
+	return &CoalescingWorkQueue{
+		WorkQueueProducer: queue,
+		adds: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "smith",
+			Subsystem: "bundle",
+			Name:      "queue_adds_total",
+			Help:      "Number of Bundles added to the work queue by result: queued or coalesced (already pending)",
+		}, []string{"result"}),
+		pending: make(map[ctrl.QueueKey]struct{}),
+	}
+}
+
+// RegisterMetrics registers metrics of the queue with the registerer.
+func (q *CoalescingWorkQueue) RegisterMetrics(registerer prometheus.Registerer) error {
+	return errors.WithStack(registerer.Register(q.adds))
+}
+
+func (q *CoalescingWorkQueue) Add(key ctrl.QueueKey) {
+	q.mx.Lock()
+	_, coalesced := q.pending[key]
+	q.pending[key] = struct{}{}
+	q.mx.Unlock()
+	if coalesced {
+		q.adds.WithLabelValues(queueAddResultCoalesced).Inc()
+	} else {
+		q.adds.WithLabelValues(queueAddResultQueued).Inc()
+	}
+	q.WorkQueueProducer.Add(key)
+}
+
+// Started marks the key as no longer pending because its processing has started.
+func (q *CoalescingWorkQueue) Started(key ctrl.QueueKey) {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	delete(q.pending, key)
+}
@@ -0,0 +1,43 @@
+package bundlec
+
+import (
+	"testing"
+
+	"github.com/atlassian/ctrl"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoalescingWorkQueueCountsPendingAdds(t *testing.T) {
+	t.Parallel()
+	fake := &fakeWorkQueue{}
+	queue := NewCoalescingWorkQueue(fake)
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, queue.RegisterMetrics(registry))
+
+	b1 := ctrl.QueueKey{Namespace: "ns", Name: "b1"}
+	b2 := ctrl.QueueKey{Namespace: "ns", Name: "b2"}
+	queue.Add(b1)
+	queue.Add(b2)
+	queue.Add(b1) // b1 is still pending
+	queue.Started(b1)
+	queue.Add(b1) // b1 is being processed, needs another run
+
+	// All additions are passed through, the work queue does the coalescing
+	assert.Equal(t, []ctrl.QueueKey{b1, b2, b1, b1}, fake.added)
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 1)
+	assert.Equal(t, "smith_bundle_queue_adds_total", families[0].GetName())
+	counts := make(map[string]float64)
+	for _, metric := range families[0].GetMetric() {
+		require.Len(t, metric.GetLabel(), 1)
+		counts[metric.GetLabel()[0].GetValue()] = metric.GetCounter().GetValue()
+	}
+	assert.Equal(t, map[string]float64{
+		queueAddResultQueued:    3,
+		queueAddResultCoalesced: 1,
+	}, counts)
+}
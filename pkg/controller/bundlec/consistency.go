@@ -0,0 +1,281 @@
+package bundlec
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/atlassian/ctrl"
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"go.uber.org/zap"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ConsistencyProblem is a kind of inconsistency in ownership metadata of an object.
+type ConsistencyProblem string
+
+const (
+	// ConsistencyMissingBundleNameLabel means the object is controlled by a Bundle but does not have
+	// the BundleNameLabel or the label has a different value.
+	ConsistencyMissingBundleNameLabel ConsistencyProblem = "MissingBundleNameLabel"
+	// ConsistencyMissingControllerReference means the object has the BundleNameLabel but is not controlled
+	// by a Bundle.
+	ConsistencyMissingControllerReference ConsistencyProblem = "MissingControllerReference"
+	// ConsistencyStaleControllerReference means the object is controlled by a Bundle that does not exist anymore.
+	ConsistencyStaleControllerReference ConsistencyProblem = "StaleControllerReference"
+	// ConsistencyDanglingOwnerReference means the object has an owner reference to a dependency that does not
+	// exist anymore.
+	ConsistencyDanglingOwnerReference ConsistencyProblem = "DanglingOwnerReference"
+)
+
+// ConsistencyIssue describes an inconsistency in ownership metadata of an object.
+type ConsistencyIssue struct {
+	Group     string             `json:"group"`
+	Version   string             `json:"version"`
+	Kind      string             `json:"kind"`
+	Namespace string             `json:"namespace"`
+	Name      string             `json:"name"`
+	Problem   ConsistencyProblem `json:"problem"`
+	Message   string             `json:"message"`
+	// Bundle is the name of the Bundle the object belongs to, if known.
+	Bundle string `json:"bundle,omitempty"`
+	// Queued is true if the Bundle was queued for processing to repair the issue.
+	Queued bool `json:"queued,omitempty"`
+}
+
+// ConsistencyReport is the outcome of a consistency check.
+type ConsistencyReport struct {
+	Time time.Time `json:"time"`
+	// Objects is the number of checked objects.
+	Objects int `json:"objects"`
+	// Issues sorted by namespace, name and kind.
+	Issues []ConsistencyIssue `json:"issues,omitempty"`
+}
+
+// ConsistencyStore gives access to objects the controller watches.
+type ConsistencyStore interface {
+	Get(gvk schema.GroupVersionKind, namespace, name string) (obj runtime.Object, exists bool, err error)
+	// GetInformers gets all registered Informers.
+	GetInformers() map[schema.GroupVersionKind]cache.SharedIndexInformer
+}
+
+// ConsistencyChecker scans objects in the informer caches for inconsistent ownership metadata.
+// Repairs are done by queueing the Bundle for processing rather than by updating objects directly because processing
+// sets labels and owner references of objects to the desired state. Only issues that processing of the Bundle fixes
+// are repaired, e.g. a missing label or an owner reference to a dependency that has been re-created. Objects that
+// are labeled but not controlled by a Bundle are only reported because taking control of them is not safe.
+type ConsistencyChecker struct {
+	Logger      *zap.Logger
+	Store       ConsistencyStore
+	BundleStore BundleStore
+	WorkQueue   ctrl.WorkQueueProducer
+	// RepairStaleOwnerReferences means objects controlled by a previous incarnation of a Bundle are repaired
+	// by queueing the current incarnation, which re-parents them.
+	RepairStaleOwnerReferences bool
+
+	mx   sync.Mutex
+	last *ConsistencyReport
+}
+
+// Run checks consistency periodically until the context is done.
+func (c *ConsistencyChecker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.Check(true)
+		}
+	}
+}
+
+// Check checks ownership metadata of all objects in the informer caches.
+// If repair is true, Bundles with issues that processing fixes are queued.
+func (c *ConsistencyChecker) Check(repair bool) *ConsistencyReport {
+	report := &ConsistencyReport{
+		Time: time.Now(),
+	}
+	queued := make(map[ctrl.QueueKey]struct{})
+	informers := c.Store.GetInformers()
+	for gvk, inf := range informers {
+		if gvk == smith_v1.BundleGVK {
+			continue
+		}
+		for _, obj := range inf.GetStore().List() {
+			m, ok := obj.(meta_v1.Object)
+			if !ok {
+				continue
+			}
+			report.Objects++
+			for _, issue := range c.checkObject(informers, gvk, m) {
+				if repair && issue.Queued {
+					key := ctrl.QueueKey{Namespace: issue.Namespace, Name: issue.Bundle}
+					if _, ok := queued[key]; !ok {
+						queued[key] = struct{}{}
+						c.WorkQueue.Add(key)
+					}
+				} else {
+					issue.Queued = false
+				}
+				report.Issues = append(report.Issues, issue)
+			}
+		}
+	}
+	sort.Slice(report.Issues, func(i, j int) bool {
+		a, b := report.Issues[i], report.Issues[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Kind < b.Kind
+	})
+	for _, issue := range report.Issues {
+		c.Logger.Info("Inconsistent ownership metadata",
+			zap.String("problem", string(issue.Problem)),
+			zap.String("kind", issue.Kind),
+			zap.String("namespace", issue.Namespace),
+			zap.String("name", issue.Name),
+			zap.String("bundle", issue.Bundle),
+			zap.Bool("queued", issue.Queued),
+			zap.String("message", issue.Message))
+	}
+	if repair {
+		c.mx.Lock()
+		c.last = report
+		c.mx.Unlock()
+	}
+	return report
+}
+
+// checkObject returns issues with the object. Issues that processing of the Bundle fixes are marked as queued.
+func (c *ConsistencyChecker) checkObject(informers map[schema.GroupVersionKind]cache.SharedIndexInformer, gvk schema.GroupVersionKind, obj meta_v1.Object) []ConsistencyIssue {
+	newIssue := func(problem ConsistencyProblem, bundleName string, queue bool, format string, args ...interface{}) ConsistencyIssue {
+		return ConsistencyIssue{
+			Group:     gvk.Group,
+			Version:   gvk.Version,
+			Kind:      gvk.Kind,
+			Namespace: obj.GetNamespace(),
+			Name:      obj.GetName(),
+			Problem:   problem,
+			Message:   fmt.Sprintf(format, args...),
+			Bundle:    bundleName,
+			Queued:    queue,
+		}
+	}
+	labelValue, labeled := obj.GetLabels()[smith.BundleNameLabel]
+	ref := meta_v1.GetControllerOf(obj)
+	if ref == nil || ref.APIVersion != smith_v1.BundleResourceGroupVersion || ref.Kind != smith_v1.BundleResourceKind {
+		if !labeled {
+			return nil
+		}
+		if ref == nil && objectDeletionPolicy(obj) == smith_v1.DeletionPolicyRetain {
+			// Kept by the Bundle according to its deletion policy
+			return nil
+		}
+		if _, tracked := obj.GetLabels()[smith.BundleUIDLabel]; ref == nil && tracked {
+			// Tracked by a Bundle in another namespace, owner references across namespaces are not allowed
+			return nil
+		}
+		message := "object is labeled but not controlled by a Bundle"
+		if ref != nil {
+			message = fmt.Sprintf("object is labeled but controlled by %s %q", ref.Kind, ref.Name)
+		}
+		return []ConsistencyIssue{newIssue(ConsistencyMissingControllerReference, labelValue, false, "%s", message)}
+	}
+
+	bundle, err := c.BundleStore.Get(obj.GetNamespace(), ref.Name)
+	if err != nil {
+		c.Logger.Error("Failed to get Bundle", zap.Error(err))
+		return nil
+	}
+	if bundle == nil || bundle.UID != ref.UID {
+		// Garbage collector deletes the object unless a Bundle with the same name takes control of it
+		queue := false
+		if bundle != nil && c.RepairStaleOwnerReferences {
+			bundles, err := c.BundleStore.GetBundlesByObject(gvk.GroupKind(), obj.GetNamespace(), obj.GetName())
+			if err != nil {
+				c.Logger.Error("Failed to get Bundles by object", zap.Error(err))
+				return nil
+			}
+			for _, b := range bundles {
+				if b.UID == bundle.UID {
+					queue = true
+					break
+				}
+			}
+		}
+		return []ConsistencyIssue{newIssue(ConsistencyStaleControllerReference, ref.Name, queue,
+			"object is controlled by Bundle %q (uid=%s) that does not exist", ref.Name, ref.UID)}
+	}
+	if bundle.DeletionTimestamp != nil {
+		// Objects are being deleted together with the Bundle
+		return nil
+	}
+
+	var issues []ConsistencyIssue
+	if labelValue != smith.LabelValue(bundle.Name) {
+		issues = append(issues, newIssue(ConsistencyMissingBundleNameLabel, bundle.Name, true,
+			"label %s=%q does not match the controlling Bundle", smith.BundleNameLabel, labelValue))
+	}
+	for _, owner := range obj.GetOwnerReferences() {
+		if owner.Controller != nil && *owner.Controller {
+			continue
+		}
+		ownerGVK := schema.FromAPIVersionAndKind(owner.APIVersion, owner.Kind)
+		if _, ok := informers[ownerGVK]; !ok {
+			// Cannot tell if the owner exists
+			continue
+		}
+		ownerObj, exists, err := c.Store.Get(ownerGVK, obj.GetNamespace(), owner.Name)
+		if err != nil {
+			c.Logger.Error("Failed to get owner", zap.Error(err))
+			continue
+		}
+		if exists {
+			if m, ok := ownerObj.(meta_v1.Object); ok && m.GetUID() == owner.UID {
+				continue
+			}
+		}
+		issues = append(issues, newIssue(ConsistencyDanglingOwnerReference, bundle.Name, true,
+			"owner reference to %s %q (uid=%s) that does not exist", owner.Kind, owner.Name, owner.UID))
+	}
+	return issues
+}
+
+// ServeHTTP serves consistency reports.
+// GET returns the report of the last periodic check, or runs a check without repairing anything if there was none
+// yet. POST runs a check and repairs what is safe to repair.
+func (c *ConsistencyChecker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var report *ConsistencyReport
+	switch r.Method {
+	case http.MethodGet:
+		c.mx.Lock()
+		report = c.last
+		c.mx.Unlock()
+		if report == nil {
+			report = c.Check(false)
+		}
+	case http.MethodPost:
+		report = c.Check(true)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		c.Logger.Debug("Failed to write consistency report", zap.Error(err))
+	}
+}
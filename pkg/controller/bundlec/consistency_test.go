@@ -0,0 +1,161 @@
+package bundlec
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/atlassian/ctrl"
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/atlassian/smith/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+)
+
+type fakeWorkQueue struct {
+	ctrl.WorkQueueProducer
+	added []ctrl.QueueKey
+}
+
+func (q *fakeWorkQueue) Add(key ctrl.QueueKey) {
+	q.added = append(q.added, key)
+}
+
+func child(name string, labels map[string]string, owners ...meta_v1.OwnerReference) *core_v1.ConfigMap {
+	return &core_v1.ConfigMap{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Namespace:       "ns",
+			Name:            name,
+			UID:             types.UID(name + "-uid"),
+			Labels:          labels,
+			OwnerReferences: owners,
+		},
+	}
+}
+
+func bundleRef(name string, uid types.UID) meta_v1.OwnerReference {
+	trueVar := true
+	return meta_v1.OwnerReference{
+		APIVersion: smith_v1.BundleResourceGroupVersion,
+		Kind:       smith_v1.BundleResourceKind,
+		Name:       name,
+		UID:        uid,
+		Controller: &trueVar,
+	}
+}
+
+func configMapRef(name string, uid types.UID) meta_v1.OwnerReference {
+	return meta_v1.OwnerReference{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Name:       name,
+		UID:        uid,
+	}
+}
+
+func TestConsistencyChecker(t *testing.T) {
+	t.Parallel()
+	configMapGVK := core_v1.SchemeGroupVersion.WithKind("ConfigMap")
+	labels := map[string]string{smith.BundleNameLabel: "b1"}
+
+	multi := store.NewMulti()
+	bundleInf := cache.NewSharedIndexInformer(&cache.ListWatch{}, &smith_v1.Bundle{}, 0, cache.Indexers{})
+	cmInf := cache.NewSharedIndexInformer(&cache.ListWatch{}, &core_v1.ConfigMap{}, 0, cache.Indexers{})
+	bs, err := store.NewBundle(bundleInf, multi, nil)
+	require.NoError(t, err)
+	require.NoError(t, multi.AddInformer(smith_v1.BundleGVK, bundleInf))
+	require.NoError(t, multi.AddInformer(configMapGVK, cmInf))
+
+	require.NoError(t, bundleInf.GetStore().Add(&smith_v1.Bundle{
+		ObjectMeta: meta_v1.ObjectMeta{Namespace: "ns", Name: "b1", UID: "b1-uid"},
+	}))
+	for _, obj := range []*core_v1.ConfigMap{
+		child("ok", labels, bundleRef("b1", "b1-uid"), configMapRef("dep", "dep-uid")),
+		child("dep", labels, bundleRef("b1", "b1-uid")),
+		child("not-labeled", nil, bundleRef("b1", "b1-uid")),
+		child("dangling", labels, bundleRef("b1", "b1-uid"), configMapRef("dep", "old-dep-uid")),
+		child("not-controlled", labels),
+		child("stale", labels, bundleRef("b1", "old-b1-uid")),
+		child("unrelated", nil),
+	} {
+		require.NoError(t, cmInf.GetStore().Add(obj))
+	}
+
+	queue := &fakeWorkQueue{}
+	checker := &ConsistencyChecker{
+		Logger:      zaptest.NewLogger(t),
+		Store:       multi,
+		BundleStore: bs,
+		WorkQueue:   queue,
+	}
+
+	report := checker.Check(false)
+	assert.Equal(t, 7, report.Objects)
+	assert.Empty(t, queue.added)
+	var problems []ConsistencyProblem
+	for _, issue := range report.Issues {
+		problems = append(problems, issue.Problem)
+		assert.False(t, issue.Queued)
+	}
+	assert.Equal(t, []ConsistencyProblem{
+		ConsistencyDanglingOwnerReference,     // dangling
+		ConsistencyMissingControllerReference, // not-controlled
+		ConsistencyMissingBundleNameLabel,     // not-labeled
+		ConsistencyStaleControllerReference,   // stale
+	}, problems)
+
+	report = checker.Check(true)
+	assert.Equal(t, []ctrl.QueueKey{{Namespace: "ns", Name: "b1"}}, queue.added)
+	queued := make(map[string]bool)
+	for _, issue := range report.Issues {
+		queued[issue.Name] = issue.Queued
+	}
+	assert.Equal(t, map[string]bool{
+		"dangling":       true,
+		"not-labeled":    true,
+		"not-controlled": false,
+		"stale":          false,
+	}, queued)
+}
+
+func TestConsistencyCheckerLongBundleName(t *testing.T) {
+	t.Parallel()
+	configMapGVK := core_v1.SchemeGroupVersion.WithKind("ConfigMap")
+	name := strings.Repeat("b", 100)
+
+	multi := store.NewMulti()
+	bundleInf := cache.NewSharedIndexInformer(&cache.ListWatch{}, &smith_v1.Bundle{}, 0, cache.Indexers{})
+	cmInf := cache.NewSharedIndexInformer(&cache.ListWatch{}, &core_v1.ConfigMap{}, 0, cache.Indexers{})
+	bs, err := store.NewBundle(bundleInf, multi, nil)
+	require.NoError(t, err)
+	require.NoError(t, multi.AddInformer(smith_v1.BundleGVK, bundleInf))
+	require.NoError(t, multi.AddInformer(configMapGVK, cmInf))
+
+	require.NoError(t, bundleInf.GetStore().Add(&smith_v1.Bundle{
+		ObjectMeta: meta_v1.ObjectMeta{Namespace: "ns", Name: name, UID: "b-uid"},
+	}))
+	for _, obj := range []*core_v1.ConfigMap{
+		child("ok", map[string]string{smith.BundleNameLabel: smith.LabelValue(name)}, bundleRef(name, "b-uid")),
+		child("full-name", map[string]string{smith.BundleNameLabel: name}, bundleRef(name, "b-uid")),
+	} {
+		require.NoError(t, cmInf.GetStore().Add(obj))
+	}
+
+	checker := &ConsistencyChecker{
+		Logger:      zaptest.NewLogger(t),
+		Store:       multi,
+		BundleStore: bs,
+		WorkQueue:   &fakeWorkQueue{},
+	}
+
+	report := checker.Check(false)
+	assert.Equal(t, 2, report.Objects)
+	require.Len(t, report.Issues, 1)
+	assert.Equal(t, "full-name", report.Issues[0].Name)
+	assert.Equal(t, ConsistencyMissingBundleNameLabel, report.Issues[0].Problem)
+}
@@ -9,12 +9,16 @@ import (
 	"github.com/atlassian/ctrl"
 	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
 	smithClient_v1 "github.com/atlassian/smith/pkg/client/clientset_generated/clientset/typed/smith/v1"
+	smith_v1lst "github.com/atlassian/smith/pkg/client/listers_generated/smith/v1"
+	"github.com/atlassian/smith/pkg/migration"
 	"github.com/atlassian/smith/pkg/plugin"
 	"github.com/atlassian/smith/pkg/store"
+	"github.com/atlassian/smith/pkg/tracing"
 	"go.uber.org/zap"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 )
 
 type Controller struct {
@@ -28,6 +32,8 @@ type Controller struct {
 
 	crdContext       context.Context
 	crdContextCancel context.CancelFunc
+	// Kinds of CRDs that were watched and then deleted
+	removedAPIs *removedAPIs
 
 	Logger *zap.Logger
 
@@ -39,21 +45,131 @@ type Controller struct {
 	Store        Store
 	SpecCheck    SpecCheck
 	WorkQueue    ctrl.WorkQueueProducer
+	Recorder     record.EventRecorder
+	// Namespaces is used to detect termination of Bundle namespaces and to find their zone affinity. May be nil.
+	Namespaces NamespaceGetter
+	// ApplyClient makes the controller update objects using server-side apply instead of full updates. May be nil.
+	ApplyClient ApplyClient
+	// DryRunClient makes pre-flight validation submit changes of objects to the API server with server-side dry-run.
+	// May be nil.
+	DryRunClient DryRunClient
+	// StrictClient makes the controller create and update objects of Bundles annotated with the
+	// StrictFieldValidationAnnotation with strict server-side field validation. May be nil.
+	StrictClient StrictClient
+	// SecretResolver resolves references to external secret stores. May be nil.
+	SecretResolver SecretResolver
 
 	// CRD
 	CrdResyncPeriod time.Duration
 	resourceHandler cache.ResourceEventHandler
 	Namespace       string
+	// WatchNamespaces limits the controller to Bundles and objects in the namespaces. Namespace is used if empty.
+	WatchNamespaces []string
 
 	PluginContainers map[smith_v1.PluginName]plugin.PluginContainer
 	Scheme           *runtime.Scheme
 
 	Catalog *store.Catalog
+
+	// Finalizers that are removed from pruned objects if they block deletion for longer than FinalizerRemovalTimeout.
+	ForceRemovableFinalizers []string
+	// FinalizerRemovalTimeout of zero disables forced removal of finalizers.
+	FinalizerRemovalTimeout time.Duration
+	// RequireDeletionConfirmation makes deletion of production Bundles wait for a confirmation annotation.
+	RequireDeletionConfirmation bool
+	// RepairStaleOwnerReferences makes the controller re-parent objects controlled by a previous incarnation of a Bundle.
+	RepairStaleOwnerReferences bool
+	// StrictOwnership makes the controller require the BundleNameLabel of objects to agree with their controller
+	// owner references before managing or pruning them.
+	StrictOwnership bool
+	// OrderedDeletion makes the controller delete objects of deleted Bundles in reverse dependency order and wait
+	// for them to be gone before removing the finalizer of the Bundle.
+	OrderedDeletion bool
+	// StrictReferenceResolution makes the controller resolve references of all resources of a Bundle before
+	// creating or updating any of them.
+	StrictReferenceResolution bool
+	// CrossNamespaceResources makes the controller manage objects of resources that declare a namespace other than
+	// the namespace of their Bundle. Such objects are tracked with labels instead of owner references.
+	CrossNamespaceResources bool
+	// FieldOwnership means external changes of fields set from the spec are reported as
+	// EventReasonObjectModifiedExternally Events. Must match the mode of SpecCheck.
+	FieldOwnership bool
+	// DryRun makes the controller plan changes to objects of all Bundles instead of making them, including deletion of
+	// objects of deleted Bundles.
+	DryRun bool
+	// Migrations make the controller rewrite objects at the versions they are migrated to. May be nil.
+	Migrations *migration.Rules
+
+	// Minimum interval between processing of healthy Bundles during the initial re-assert. Zero disables throttling.
+	InitialReassertInterval time.Duration
+	reassert                *reassertThrottle
+
+	// ResyncPeriod is how often Bundles are processed again without any events, so that out-of-band changes of their
+	// objects are reverted. Overridden per Bundle by the ResyncPeriodAnnotation. Zero disables periodic re-sync.
+	ResyncPeriod time.Duration
+
+	// Failed attempts to delete pruned objects
+	pruneBackoff *pruneBackoff
+
+	// PruneBatchSize limits how many pruned objects of a Bundle are deleted at once, with at least PruneBatchInterval
+	// between batches. Zero means all pruned objects are deleted straight away.
+	PruneBatchSize     int
+	PruneBatchInterval time.Duration
+	pruneRateLimiter   *pruneRateLimiter
+
+	// Bundles that failed with a retriable error are requeued after a delay that starts at RetryBaseDelay and doubles
+	// with every consecutive failure up to RetryMaxDelay. After MaxRetries consecutive failures the error is treated
+	// as terminal. Zero RetryBaseDelay leaves retries to the work queue. Zero MaxRetries means there is no limit.
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+	MaxRetries     int
+	retryBackoff   *retryBackoff
+	// RetryBudget limits the rate of retries per namespace. Only used with RetryBaseDelay set. May be nil.
+	RetryBudget *NamespaceRetryBudget
+
+	// Zones makes the controller only process Bundles assigned to its zone. May be nil.
+	Zones *ZoneAssignment
+	// Shards makes the controller only process Bundles assigned to its shard. May be nil.
+	Shards *ShardAssignment
+
+	// RevisionClient is used to record specs that made Bundles ready as BundleRevisions, keeping up to
+	// RevisionHistoryLimit of them per Bundle for up to RevisionTTL. Zero RevisionHistoryLimit disables revision
+	// history, zero RevisionTTL means revisions do not expire. Bundles can override both.
+	// RevisionLister reads BundleRevisions from the informer cache, revision history is disabled without it.
+	RevisionClient       smithClient_v1.BundleRevisionsGetter
+	RevisionLister       smith_v1lst.BundleRevisionLister
+	RevisionHistoryLimit int
+	RevisionTTL          time.Duration
+
+	// Named mutexes held by Bundles that are being processed
+	syncMutexes syncMutexes
+
+	// SyncStats tracks processing of Bundles. May be nil.
+	SyncStats *SyncStats
+	// Health tracks readiness of the controller and progress of its workers. May be nil.
+	Health *HealthChecker
+	// Tracer traces syncs of Bundles. May be nil.
+	Tracer *tracing.Tracer
+
+	// ConsistencyChecker checks ownership metadata of objects every ConsistencyCheckInterval. May be nil.
+	ConsistencyChecker       *ConsistencyChecker
+	ConsistencyCheckInterval time.Duration
+	// OrphanAuditor audits objects of deleted Bundles and objects Bundles do not define anymore every
+	// OrphanAuditInterval. May be nil.
+	OrphanAuditor       *OrphanAuditor
+	OrphanAuditInterval time.Duration
 }
 
 // Prepare prepares the controller to be run.
 func (c *Controller) Prepare(crdInf cache.SharedIndexInformer, resourceInfs map[schema.GroupVersionKind]cache.SharedIndexInformer) {
 	c.crdContext, c.crdContextCancel = context.WithCancel(context.Background())
+	c.removedAPIs = newRemovedAPIs()
+	c.reassert = newReassertThrottle(c.InitialReassertInterval)
+	c.pruneBackoff = newPruneBackoff()
+	c.pruneRateLimiter = newPruneRateLimiter(c.PruneBatchSize, c.PruneBatchInterval)
+	if c.RetryBaseDelay > 0 {
+		c.retryBackoff = newRetryBackoff(c.RetryBaseDelay, c.RetryMaxDelay, c.MaxRetries)
+	}
 	c.resourceHandler = &ctrl.ControlledResourceHandler{
 		Logger:          c.Logger,
 		WorkQueue:       c.WorkQueue,
@@ -65,8 +181,32 @@ func (c *Controller) Prepare(crdInf cache.SharedIndexInformer, resourceInfs map[
 		watchers:   make(map[string]watchState),
 	})
 
-	for _, resourceInf := range resourceInfs {
+	for gvk, resourceInf := range resourceInfs {
 		resourceInf.AddEventHandler(c.resourceHandler)
+		resourceInf.AddEventHandler(c.externalObjectHandler(gvk.GroupKind()))
+	}
+	// Bundles are rebuilt when ConfigMaps and Secrets their resources read specs from change
+	for _, gvk := range []schema.GroupVersionKind{configMapGVK, secretGVK} {
+		if resourceInf, ok := resourceInfs[gvk]; ok {
+			resourceInf.AddEventHandler(&watchedObjectEventHandler{
+				logger:     c.Logger,
+				workQueue:  c.WorkQueue,
+				gk:         gvk.GroupKind(),
+				getBundles: c.BundleStore.GetBundlesBySpecSource,
+				dependency: "it reads specs from",
+			})
+		}
+	}
+}
+
+// externalObjectHandler returns a handler that rebuilds Bundles when external objects they wait for change.
+func (c *Controller) externalObjectHandler(gk schema.GroupKind) cache.ResourceEventHandler {
+	return &watchedObjectEventHandler{
+		logger:     c.Logger,
+		workQueue:  c.WorkQueue,
+		gk:         gk,
+		getBundles: c.BundleStore.GetBundlesByExternalObject,
+		dependency: "it waits for",
 	}
 }
 
@@ -85,6 +225,20 @@ func (c *Controller) Run(ctx context.Context) {
 	defer c.Logger.Info("Shutting down Bundle controller")
 
 	c.ReadyForWork()
+	if c.Health != nil {
+		c.Health.started()
+	}
+
+	if c.ConsistencyChecker != nil && c.ConsistencyCheckInterval > 0 {
+		c.wg.StartWithContext(ctx, func(ctx context.Context) {
+			c.ConsistencyChecker.Run(ctx, c.ConsistencyCheckInterval)
+		})
+	}
+	if c.OrphanAuditor != nil && c.OrphanAuditInterval > 0 {
+		c.wg.StartWithContext(ctx, func(ctx context.Context) {
+			c.OrphanAuditor.Run(ctx, c.OrphanAuditInterval)
+		})
+	}
 
 	<-ctx.Done()
 }
@@ -2,24 +2,36 @@ package bundlec
 
 import (
 	"context"
+	"sync"
 
 	"github.com/atlassian/ctrl"
 	ctrlLogz "github.com/atlassian/ctrl/logz"
 	"github.com/atlassian/smith"
 	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
 	"github.com/atlassian/smith/pkg/resources"
+	"github.com/atlassian/smith/pkg/store"
+	"github.com/pkg/errors"
 	"go.uber.org/zap"
+	core_v1 "k8s.io/api/core/v1"
 	apiext_v1b1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/tools/cache"
 )
 
+const (
+	// EventReasonMalformedCrd is the reason of an Event recorded for a CRD that is ignored because it is malformed.
+	EventReasonMalformedCrd = "MalformedCRD"
+)
+
 type watchState struct {
 	cancel context.CancelFunc
+	// gvk is the version of the CRD that is being watched.
+	gvk schema.GroupVersionKind
 }
 
 // crdEventHandler handles events for objects with Kind: CustomResourceDefinition.
@@ -63,6 +75,10 @@ func (h *crdEventHandler) OnUpdate(oldObj, newObj interface{}) {
 	}
 }
 
+// OnDelete handles deleted CRDs.
+// The watch is removed and Bundles that use the CRD are rebuilt. Their resources of that kind become blocked with the
+// MissingAPI reason until the CRD is created again and OnAdd re-establishes the watch. Only kinds of CRDs that were
+// watched are blocked, resources of kinds that have never been watched fail instead.
 func (h *crdEventHandler) OnDelete(obj interface{}) {
 	crd, ok := obj.(*apiext_v1b1.CustomResourceDefinition)
 	if !ok {
@@ -79,20 +95,34 @@ func (h *crdEventHandler) OnDelete(obj interface{}) {
 	}
 	logger := h.loggerForCRD(crd)
 	if h.ensureNoWatch(logger, crd) {
+		h.removedAPIs.removed(schema.GroupKind{Group: crd.Spec.Group, Kind: crd.Spec.Names.Kind})
 		// Rebuild only if the watch was removed. Otherwise it is pointless.
 		h.rebuildBundles(logger, crd, "deleted")
 	}
 }
 
 // ensureWatch ensures there is a watch for CRs of a CRD.
+// If the version of the CRD has changed, e.g. because its storage version was migrated, the watch is replaced with
+// a watch for the new version.
 // Returns true if a watch was found or set up successfully and false if there is no watch and it was not set up for
 // some reason.
 func (h *crdEventHandler) ensureWatch(logger *zap.Logger, crd *apiext_v1b1.CustomResourceDefinition) bool {
 	if crd.Name == smith_v1.BundleResourceName {
 		return false
 	}
-	if _, ok := h.watchers[crd.Name]; ok {
-		return true
+	if crdWatch, ok := h.watchers[crd.Name]; ok {
+		if crdWatch.gvk.Version == crd.Spec.Version {
+			return true
+		}
+		logger.Sugar().Infof("Version of CRD has changed from %s to %s, replacing watch", crdWatch.gvk.Version, crd.Spec.Version)
+		h.ensureNoWatch(logger, crd)
+	}
+	if err := validateCrd(crd); err != nil {
+		logger.Warn("Not adding a watch for CRD because it is malformed", zap.Error(err))
+		if h.Recorder != nil {
+			h.Recorder.Eventf(crd, core_v1.EventTypeWarning, EventReasonMalformedCrd, "Ignoring malformed CRD: %v", err)
+		}
+		return false
 	}
 	if !resources.IsCrdConditionTrue(crd, apiext_v1b1.Established) {
 		logger.Info("Not adding a watch for CRD because it hasn't been established")
@@ -108,33 +138,47 @@ func (h *crdEventHandler) ensureWatch(logger *zap.Logger, crd *apiext_v1b1.Custo
 		Kind:    crd.Spec.Names.Kind,
 	}
 	logger.Info("Configuring watch for CRD")
-	res, err := h.SmartClient.ForGVK(gvk, h.Namespace)
-	if err != nil {
-		logger.Error("Failed to get client for CRD", zap.Error(err))
-		return false
+	h.invalidateSmartClient(gvk.GroupKind())
+	namespaces := h.WatchNamespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{h.Namespace}
 	}
-	crdInf := cache.NewSharedIndexInformer(&cache.ListWatch{
-		ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
-			return res.List(options)
-		},
-		WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
-			return res.Watch(options)
-		},
-	}, &unstructured.Unstructured{}, h.CrdResyncPeriod, cache.Indexers{})
+	resClients := make(map[string]dynamic.ResourceInterface, len(namespaces))
+	for _, namespace := range namespaces {
+		res, err := h.SmartClient.ForGVK(gvk, namespace)
+		if err != nil {
+			logger.Error("Failed to get client for CRD", zap.Error(err))
+			return false
+		}
+		resClients[namespace] = res
+	}
+	crdInf := store.NewMultiNamespaceInformer(namespaces, func(namespace string) cache.SharedIndexInformer {
+		res := resClients[namespace]
+		return cache.NewSharedIndexInformer(&cache.ListWatch{
+			ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+				return res.List(options)
+			},
+			WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+				return res.Watch(options)
+			},
+		}, &unstructured.Unstructured{}, h.CrdResyncPeriod, cache.Indexers{})
+	})
 	h.wgLock.Lock()
 	defer h.wgLock.Unlock()
 	if h.stopping {
 		return false
 	}
 	crdInf.AddEventHandler(h.resourceHandler)
-	err = h.Store.AddInformer(gvk, crdInf)
+	crdInf.AddEventHandler(h.externalObjectHandler(gvk.GroupKind()))
+	err := h.Store.AddInformer(gvk, crdInf)
 	if err != nil {
 		logger.Error("Failed to add informer for CRD to multisore", zap.Error(err))
 		return false
 	}
 	ctx, cancel := context.WithCancel(h.crdContext)
-	h.watchers[crd.Name] = watchState{cancel: cancel}
+	h.watchers[crd.Name] = watchState{cancel: cancel, gvk: gvk}
 	h.wg.StartWithChannel(ctx.Done(), crdInf.Run)
+	h.removedAPIs.restored(gvk.GroupKind())
 	return true
 }
 
@@ -149,15 +193,23 @@ func (h *crdEventHandler) ensureNoWatch(logger *zap.Logger, crd *apiext_v1b1.Cus
 	logger.Info("Removing watch for CRD")
 	crdWatch.cancel()
 	delete(h.watchers, crd.Name)
-	gvk := schema.GroupVersionKind{
-		Group:   crd.Spec.Group,
-		Version: crd.Spec.Version,
-		Kind:    crd.Spec.Names.Kind,
-	}
-	h.Store.RemoveInformer(gvk)
+	h.Store.RemoveInformer(crdWatch.gvk)
+	h.invalidateSmartClient(crdWatch.gvk.GroupKind())
 	return true
 }
 
+// invalidateSmartClient makes the SmartClient forget a possibly stale mapping of the kind
+// because the API for it has just been added or removed.
+func (h *crdEventHandler) invalidateSmartClient(gk schema.GroupKind) {
+	if inv, ok := h.SmartClient.(SmartClientInvalidator); ok {
+		inv.InvalidateGroupKind(gk)
+	}
+}
+
+// rebuildBundles enqueues Bundles that have resources defined by the CRD.
+// Bursts of events do not cause redundant back-to-back processing: the work queue holds at most one pending
+// entry per Bundle and, if the Bundle is being processed, schedules a single follow-up run. Additions that are
+// coalesced are counted if the work queue is a CoalescingWorkQueue.
 func (h *crdEventHandler) rebuildBundles(logger *zap.Logger, crd *apiext_v1b1.CustomResourceDefinition, addUpdateDelete string) {
 	bundles, err := h.BundleStore.GetBundlesByCrd(crd)
 	if err != nil {
@@ -175,6 +227,30 @@ func (h *crdEventHandler) rebuildBundles(logger *zap.Logger, crd *apiext_v1b1.Cu
 	}
 }
 
+// validateCrd checks that the CRD has all the fields required to watch its CRs.
+// CRDs come from the cluster and must not be trusted to be well-formed.
+func validateCrd(crd *apiext_v1b1.CustomResourceDefinition) error {
+	if crd.Spec.Group == "" {
+		return errors.New("group is empty")
+	}
+	if crd.Spec.Version == "" {
+		return errors.New("version is empty")
+	}
+	if crd.Spec.Names.Kind == "" {
+		return errors.New("kind is empty")
+	}
+	if crd.Spec.Names.Plural == "" {
+		return errors.New("plural name is empty")
+	}
+	if _, err := schema.ParseGroupVersion(crd.Spec.Group + "/" + crd.Spec.Version); err != nil {
+		return errors.Wrap(err, "invalid group or version")
+	}
+	if expected := crd.Spec.Names.Plural + "." + crd.Spec.Group; crd.Name != expected {
+		return errors.Errorf("name %q does not match plural name and group, expected %q", crd.Name, expected)
+	}
+	return nil
+}
+
 func supportEnabled(crd *apiext_v1b1.CustomResourceDefinition) bool {
 	return crd.Annotations[smith.CrdSupportEnabled] == "true"
 }
@@ -184,3 +260,44 @@ func (h *crdEventHandler) loggerForCRD(obj *apiext_v1b1.CustomResourceDefinition
 	return h.Logger.With(ctrlLogz.Object(obj),
 		ctrlLogz.ObjectGk(apiext_v1b1.SchemeGroupVersion.WithKind("CustomResourceDefinition").GroupKind()))
 }
+
+// removedAPIs tracks kinds of CRDs that were watched and then deleted. Resources of such kinds are blocked with the
+// MissingAPI reason until the CRD is back. Methods are safe to call on a nil receiver, no kinds are removed then.
+type removedAPIs struct {
+	mx    sync.RWMutex
+	kinds map[schema.GroupKind]struct{}
+}
+
+func newRemovedAPIs() *removedAPIs {
+	return &removedAPIs{
+		kinds: make(map[schema.GroupKind]struct{}),
+	}
+}
+
+func (r *removedAPIs) removed(gk schema.GroupKind) {
+	if r == nil {
+		return
+	}
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	r.kinds[gk] = struct{}{}
+}
+
+func (r *removedAPIs) restored(gk schema.GroupKind) {
+	if r == nil {
+		return
+	}
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	delete(r.kinds, gk)
+}
+
+func (r *removedAPIs) isRemoved(gk schema.GroupKind) bool {
+	if r == nil {
+		return false
+	}
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+	_, ok := r.kinds[gk]
+	return ok
+}
@@ -0,0 +1,261 @@
+package bundlec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/atlassian/ctrl"
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/atlassian/smith/pkg/store"
+	"github.com/google/gofuzz"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	apiext_v1b1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+)
+
+type failingSmartClient struct{}
+
+func (failingSmartClient) ForGVK(gvk schema.GroupVersionKind, namespace string) (dynamic.ResourceInterface, error) {
+	return nil, errors.New("no client")
+}
+
+// watchingSmartClient returns clients that list no objects and watch forever.
+type watchingSmartClient struct{}
+
+func (watchingSmartClient) ForGVK(gvk schema.GroupVersionKind, namespace string) (dynamic.ResourceInterface, error) {
+	return watchingResourceClient{}, nil
+}
+
+type watchingResourceClient struct {
+	dynamic.ResourceInterface
+}
+
+func (watchingResourceClient) List(opts meta_v1.ListOptions) (runtime.Object, error) {
+	return &unstructured.UnstructuredList{}, nil
+}
+
+func (watchingResourceClient) Watch(opts meta_v1.ListOptions) (watch.Interface, error) {
+	return watch.NewFake(), nil
+}
+
+type crdBundleStore struct {
+	BundleStore
+	bundles []*smith_v1.Bundle
+}
+
+func (s crdBundleStore) GetBundlesByCrd(*apiext_v1b1.CustomResourceDefinition) ([]*smith_v1.Bundle, error) {
+	return s.bundles, nil
+}
+
+func (s crdBundleStore) GetBundlesByExternalObject(gk schema.GroupKind, namespace, name string) ([]*smith_v1.Bundle, error) {
+	return nil, nil
+}
+
+func validCrd() *apiext_v1b1.CustomResourceDefinition {
+	return &apiext_v1b1.CustomResourceDefinition{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name: "widgets.example.com",
+		},
+		Spec: apiext_v1b1.CustomResourceDefinitionSpec{
+			Group:   "example.com",
+			Version: "v1",
+			Names: apiext_v1b1.CustomResourceDefinitionNames{
+				Plural: "widgets",
+				Kind:   "Widget",
+			},
+		},
+	}
+}
+
+func TestValidateCrd(t *testing.T) {
+	t.Parallel()
+	assert.NoError(t, validateCrd(validCrd()))
+
+	cases := map[string]func(*apiext_v1b1.CustomResourceDefinition){
+		"empty group":         func(crd *apiext_v1b1.CustomResourceDefinition) { crd.Spec.Group = "" },
+		"empty version":       func(crd *apiext_v1b1.CustomResourceDefinition) { crd.Spec.Version = "" },
+		"empty kind":          func(crd *apiext_v1b1.CustomResourceDefinition) { crd.Spec.Names.Kind = "" },
+		"empty plural":        func(crd *apiext_v1b1.CustomResourceDefinition) { crd.Spec.Names.Plural = "" },
+		"invalid version":     func(crd *apiext_v1b1.CustomResourceDefinition) { crd.Spec.Version = "v1/v2" },
+		"mismatching name":    func(crd *apiext_v1b1.CustomResourceDefinition) { crd.Name = "widgets" },
+		"name without plural": func(crd *apiext_v1b1.CustomResourceDefinition) { crd.Name = "example.com" },
+	}
+	for name, mutate := range cases {
+		mutate := mutate
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			crd := validCrd()
+			mutate(crd)
+			assert.Error(t, validateCrd(crd))
+		})
+	}
+}
+
+func TestCrdEventHandlerMalformedCrdRecordsEvent(t *testing.T) {
+	t.Parallel()
+	recorder := record.NewFakeRecorder(1)
+	h := &crdEventHandler{
+		Controller: &Controller{
+			Logger:   zap.NewNop(),
+			Recorder: recorder,
+		},
+		watchers: make(map[string]watchState),
+	}
+	crd := validCrd()
+	crd.Spec.Names.Kind = ""
+
+	assert.False(t, h.ensureWatch(zap.NewNop(), crd))
+	assert.Empty(t, h.watchers)
+	assert.Len(t, recorder.Events, 1)
+}
+
+func TestCrdEventHandlerFuzz(t *testing.T) {
+	t.Parallel()
+	h := &crdEventHandler{
+		Controller: &Controller{
+			Logger:      zap.NewNop(),
+			SmartClient: failingSmartClient{},
+			Recorder:    record.NewFakeRecorder(1000),
+		},
+		watchers: make(map[string]watchState),
+	}
+	f := fuzz.New().NilChance(0.2).NumElements(0, 3).Funcs(
+		// Schema is irrelevant and deeply recursive, leave it empty
+		func(v *apiext_v1b1.CustomResourceValidation, c fuzz.Continue) {},
+	)
+	for i := 0; i < 500; i++ {
+		crd := &apiext_v1b1.CustomResourceDefinition{}
+		f.Fuzz(crd)
+		assert.NotPanics(t, func() {
+			validateCrd(crd)
+			h.ensureWatch(h.Logger, crd)
+			h.ensureNoWatch(h.Logger, crd)
+		})
+	}
+}
+
+func TestCrdEventHandlerReplacesWatchOnVersionChange(t *testing.T) {
+	t.Parallel()
+	multi := store.NewMulti()
+	oldGVK := schema.GroupVersionKind{Group: "example.com", Version: "v1alpha1", Kind: "Widget"}
+	require.NoError(t, multi.AddInformer(oldGVK, cache.NewSharedIndexInformer(&cache.ListWatch{}, &unstructured.Unstructured{}, 0, cache.Indexers{})))
+	cancelled := false
+	h := &crdEventHandler{
+		Controller: &Controller{
+			Logger:      zap.NewNop(),
+			SmartClient: failingSmartClient{},
+			Store:       multi,
+		},
+		watchers: map[string]watchState{
+			"widgets.example.com": {
+				cancel: func() { cancelled = true },
+				gvk:    oldGVK,
+			},
+		},
+	}
+
+	// Watch for the same version is kept
+	crd := validCrd()
+	crd.Spec.Version = "v1alpha1"
+	assert.True(t, h.ensureWatch(zap.NewNop(), crd))
+	assert.False(t, cancelled)
+
+	// Watch for the old version is removed. The new CRD is not established so there is no new watch.
+	assert.False(t, h.ensureWatch(zap.NewNop(), validCrd()))
+	assert.True(t, cancelled)
+	assert.Empty(t, h.watchers)
+	assert.False(t, multi.HasInformer(oldGVK))
+}
+
+func TestCrdEventHandlerBlocksResourcesOfDeletedCrd(t *testing.T) {
+	t.Parallel()
+	bundle := &smith_v1.Bundle{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Namespace: "ns",
+			Name:      "b1",
+		},
+	}
+	queue := &fakeWorkQueue{}
+	ctx, cancel := context.WithCancel(context.Background())
+	h := &crdEventHandler{
+		Controller: &Controller{
+			Logger:          zap.NewNop(),
+			SmartClient:     watchingSmartClient{},
+			Store:           store.NewMulti(),
+			BundleStore:     crdBundleStore{bundles: []*smith_v1.Bundle{bundle}},
+			WorkQueue:       queue,
+			Namespace:       "ns",
+			crdContext:      ctx,
+			removedAPIs:     newRemovedAPIs(),
+			resourceHandler: cache.ResourceEventHandlerFuncs{},
+		},
+		watchers: make(map[string]watchState),
+	}
+	defer h.wg.Wait()
+	defer cancel()
+
+	crd := validCrd()
+	crd.Annotations = map[string]string{
+		smith.CrdSupportEnabled: "true",
+	}
+	crd.Status.Conditions = []apiext_v1b1.CustomResourceDefinitionCondition{
+		{Type: apiext_v1b1.Established, Status: apiext_v1b1.ConditionTrue},
+		{Type: apiext_v1b1.NamesAccepted, Status: apiext_v1b1.ConditionTrue},
+	}
+	res := &smith_v1.Resource{
+		Name: "w",
+		Spec: smith_v1.ResourceSpec{
+			Object: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": "example.com/v1",
+					"kind":       "Widget",
+					"metadata": map[string]interface{}{
+						"name": "widget1",
+					},
+				},
+			},
+		},
+	}
+	rst := &resourceSyncTask{
+		logger:      zap.NewNop(),
+		store:       h.Store,
+		bundle:      bundle,
+		removedAPIs: h.removedAPIs,
+	}
+	gvk := schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"}
+
+	// A kind that has never been watched is an error, not a missing API
+	_, status := rst.getActualObject(res)
+	require.IsType(t, resourceStatusError{}, status)
+	assert.EqualError(t, status.(resourceStatusError).err, "failed to get object from the Store: no informer for example.com/v1, Kind=Widget is registered")
+
+	h.OnAdd(crd)
+	obj, status := rst.getActualObject(res)
+	assert.Nil(t, obj)
+	assert.Nil(t, status)
+
+	// Resources are blocked once the CRD is deleted
+	h.OnDelete(crd)
+	assert.Equal(t, []ctrl.QueueKey{{Namespace: "ns", Name: "b1"}, {Namespace: "ns", Name: "b1"}}, queue.added)
+	_, status = rst.getActualObject(res)
+	assert.Equal(t, resourceStatusMissingAPI{gvk: gvk}, status)
+
+	// And unblocked once it is back
+	h.OnAdd(crd)
+	assert.True(t, h.Store.HasInformer(gvk))
+	obj, status = rst.getActualObject(res)
+	assert.Nil(t, obj)
+	assert.Nil(t, status)
+	assert.False(t, h.removedAPIs.isRemoved(gvk.GroupKind()))
+}
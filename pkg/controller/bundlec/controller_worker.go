@@ -1,28 +1,128 @@
 package bundlec
 
 import (
+	"strconv"
+	"time"
+
 	"github.com/atlassian/ctrl"
+	"github.com/atlassian/smith"
 	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/atlassian/smith/pkg/tracing"
+	"github.com/pkg/errors"
 	"go.uber.org/zap"
+	core_v1 "k8s.io/api/core/v1"
+	api_errors "k8s.io/apimachinery/pkg/api/errors"
 )
 
 func (c *Controller) Process(pctx *ctrl.ProcessContext) (retriableRet bool, errRet error) {
-	return c.ProcessBundle(pctx.Logger, pctx.Object.(*smith_v1.Bundle))
+	bundle := pctx.Object.(*smith_v1.Bundle)
+	key := ctrl.QueueKey{
+		Namespace: bundle.Namespace,
+		Name:      bundle.Name,
+	}
+	if q, ok := c.WorkQueue.(*CoalescingWorkQueue); ok {
+		// Additions from now on need another run
+		q.Started(key)
+	}
+	if c.Health != nil {
+		syncFinished := c.Health.syncStarted()
+		defer syncFinished()
+	}
+	if c.Shards != nil && !c.Shards.Owns(bundle) {
+		pctx.Logger.Debug("Not processing Bundle assigned to another shard")
+		return false, nil
+	}
+	if c.Zones != nil {
+		if zone := c.Zones.AssignedZone(bundle, c.getNamespace(pctx.Logger, bundle.Namespace)); zone != c.Zones.Zone() {
+			pctx.Logger.Sugar().Debugf("Not processing Bundle assigned to zone %q", zone)
+			return false, nil
+		}
+	}
+	if delay := c.reassert.delay(key, isHealthy(bundle)); delay > 0 {
+		pctx.Logger.Sugar().Debugf("Deferring initial re-assert of healthy Bundle by %s", delay)
+		c.WorkQueue.AddAfter(key, delay)
+		return false, nil
+	}
+	if mutex := bundle.Annotations[smith.SyncMutexAnnotation]; mutex != "" {
+		if !c.syncMutexes.tryLock(mutex, key) {
+			pctx.Logger.Sugar().Debugf("Deferring processing of Bundle because sync mutex %q is held by another Bundle", mutex)
+			c.WorkQueue.AddAfter(key, syncMutexRetryDelay)
+			return false, nil
+		}
+		defer c.syncMutexes.unlock(mutex)
+	}
+	if c.SyncStats != nil {
+		syncFinished := c.SyncStats.syncStarted()
+		defer syncFinished()
+	}
+	return c.ProcessBundle(pctx.Logger, bundle)
 }
 
 // ProcessBundle is only visible for testing purposes. Should not be called directly.
 func (c *Controller) ProcessBundle(logger *zap.Logger, bundle *smith_v1.Bundle) (retriableRet bool, errRet error) {
+	key := ctrl.QueueKey{
+		Namespace: bundle.Namespace,
+		Name:      bundle.Name,
+	}
+	span := c.Tracer.StartSpan("Bundle.sync",
+		tracing.String("bundle.namespace", bundle.Namespace),
+		tracing.String("bundle.name", bundle.Name),
+		tracing.String("bundle.deleted", strconv.FormatBool(bundle.DeletionTimestamp != nil)))
+	defer func() {
+		span.SetError(errRet)
+		span.Finish()
+	}()
 	st := bundleSyncTask{
 		logger:           logger,
+		span:             span,
 		bundleClient:     c.BundleClient,
 		smartClient:      c.SmartClient,
+		applyClient:      c.ApplyClient,
+		dryRunClient:     c.DryRunClient,
+		secretResolver:   c.SecretResolver,
 		rc:               c.Rc,
 		store:            c.Store,
+		removedAPIs:      c.removedAPIs,
 		specCheck:        c.SpecCheck,
 		bundle:           bundle,
 		pluginContainers: c.PluginContainers,
 		scheme:           c.Scheme,
 		catalog:          c.Catalog,
+
+		forceRemovableFinalizers: c.ForceRemovableFinalizers,
+		finalizerRemovalTimeout:  c.FinalizerRemovalTimeout,
+
+		requireDeletionConfirmation: c.RequireDeletionConfirmation,
+		repairStaleOwnerReferences:  c.RepairStaleOwnerReferences,
+		strictOwnership:             c.StrictOwnership,
+		orderedDeletion:             c.OrderedDeletion,
+		strictReferenceResolution:   c.StrictReferenceResolution,
+		crossNamespaceResources:     c.CrossNamespaceResources,
+		fieldOwnership:              c.FieldOwnership,
+		recorder:                    c.Recorder,
+
+		namespaceTerminating: c.isNamespaceTerminating(logger, bundle.Namespace),
+		paused:               bundle.Annotations[smith.PausedAnnotation] == "true",
+		pruneBackoff:         c.pruneBackoff,
+		pruneRateLimiter:     c.pruneRateLimiter,
+		dryRun:               c.DryRun || bundle.Annotations[smith.DryRunAnnotation] == "true",
+		planDeletions:        c.DryRun,
+		migrations:           c.Migrations,
+		retryBackoff:         c.retryBackoff,
+		retryBudget:          c.RetryBudget,
+		syncStats:            c.SyncStats,
+		revisionClient:       c.RevisionClient,
+		revisionLister:       c.RevisionLister,
+		revisionHistoryLimit: c.RevisionHistoryLimit,
+		revisionTTL:          c.RevisionTTL,
+	}
+	if c.StrictClient != nil && bundle.Annotations[smith.StrictFieldValidationAnnotation] == "true" {
+		st.strictClient = c.StrictClient
+	}
+	if c.retryBackoff != nil {
+		c.retryBackoff.requeueRequested(key, bundle.Annotations[smith.RequeueAnnotation])
+		c.retryBackoff.setPolicy(key, bundle.Spec.RetryPolicy)
+		st.exhaustedRetries = c.retryBackoff.exhausted(key)
 	}
 
 	var retriable bool
@@ -32,5 +132,56 @@ func (c *Controller) ProcessBundle(logger *zap.Logger, bundle *smith_v1.Bundle)
 	} else {
 		retriable, err = st.processNormal()
 	}
-	return st.handleProcessResult(retriable, err)
+	retriable, err = st.handleProcessResult(retriable, err)
+	if st.bundle.DeletionTimestamp == nil {
+		if period := resyncPeriod(logger, bundle, c.ResyncPeriod); period > 0 {
+			st.requeueNoLaterThan(period)
+		}
+	}
+	if st.requeueAfter > 0 && c.WorkQueue != nil {
+		c.WorkQueue.AddAfter(key, st.requeueAfter)
+	}
+	if c.retryBackoff != nil && !api_errors.IsConflict(errors.Cause(err)) {
+		// Conflicts are left to the work queue because they are not failures of the Bundle
+		if err != nil && retriable {
+			// The retry is usually scheduled already to be reflected in the status
+			delay := time.Until(st.scheduleRetry(time.Now()).at)
+			logger.Sugar().Debugf("Retrying processing of Bundle in %s", delay)
+			c.WorkQueue.AddAfter(key, delay)
+			return false, err
+		}
+		c.retryBackoff.forget(key)
+	}
+	return retriable, err
+}
+
+// getNamespace returns the namespace or nil if it cannot be found.
+func (c *Controller) getNamespace(logger *zap.Logger, namespace string) *core_v1.Namespace {
+	if c.Namespaces == nil {
+		return nil
+	}
+	ns, err := c.Namespaces.Get(namespace)
+	if err != nil {
+		if !api_errors.IsNotFound(err) {
+			logger.Error("Failed to get namespace of Bundle", zap.Error(err))
+		}
+		return nil
+	}
+	return ns
+}
+
+// isNamespaceTerminating returns true if the namespace is being deleted or is gone already.
+func (c *Controller) isNamespaceTerminating(logger *zap.Logger, namespace string) bool {
+	if c.Namespaces == nil {
+		return false
+	}
+	ns, err := c.Namespaces.Get(namespace)
+	if err != nil {
+		if api_errors.IsNotFound(err) {
+			return true
+		}
+		logger.Error("Failed to get namespace of Bundle", zap.Error(err))
+		return false
+	}
+	return ns.DeletionTimestamp != nil || ns.Status.Phase == core_v1.NamespaceTerminating
 }
@@ -93,7 +93,7 @@ func TestBundleSortMissingDependency(t *testing.T) {
 		},
 	}
 	_, sorted, err := sortBundle(&bundle)
-	require.EqualError(t, err, "vertex \"x\" not found", "%v", sorted)
+	require.EqualError(t, err, "resource \"a\" references non-existent resource \"x\"", "%v", sorted)
 }
 
 func TestBundleSortSelfReference(t *testing.T) {
@@ -137,3 +137,92 @@ func TestBundleSortSelfReference(t *testing.T) {
 	_, sorted, err := sortBundle(&bundle)
 	require.EqualError(t, err, "cycle error: [a a]", "%v", sorted)
 }
+
+func TestBundleSortDuplicateReferences(t *testing.T) {
+	t.Parallel()
+	bundle := smith_v1.Bundle{
+		Spec: smith_v1.BundleSpec{
+			Resources: []smith_v1.Resource{
+				{
+					Name: "a",
+					References: []smith_v1.Reference{
+						{
+							Name:     "b-name",
+							Resource: "b",
+							Path:     "metadata.name",
+						},
+						{
+							Name:     "b-uid",
+							Resource: "b",
+							Path:     "metadata.uid",
+						},
+					},
+				},
+				{
+					Name: "b",
+				},
+			},
+		},
+	}
+	g, sorted, err := sortBundle(&bundle)
+	require.NoError(t, err)
+
+	assert.EqualValues(t, []graph.V{smith_v1.ResourceName("b"), smith_v1.ResourceName("a")}, sorted)
+	assert.Len(t, g.Vertices[smith_v1.ResourceName("a")].Edges(), 1)
+}
+
+func TestBundleSortQuorum(t *testing.T) {
+	t.Parallel()
+	bundle := smith_v1.Bundle{
+		Spec: smith_v1.BundleSpec{
+			Resources: []smith_v1.Resource{
+				{
+					Name: "app",
+					Quorums: []smith_v1.Quorum{
+						{
+							Resources: []smith_v1.ResourceName{"broker1", "broker2", "broker3"},
+							MinReady:  2,
+						},
+					},
+				},
+				{
+					Name: "broker1",
+				},
+				{
+					Name: "broker2",
+				},
+				{
+					Name: "broker3",
+				},
+			},
+		},
+	}
+	_, sorted, err := sortBundle(&bundle)
+	require.NoError(t, err)
+
+	assert.EqualValues(t, []graph.V{smith_v1.ResourceName("broker1"), smith_v1.ResourceName("broker2"), smith_v1.ResourceName("broker3"), smith_v1.ResourceName("app")}, sorted)
+}
+
+func TestBundleSortInvalidQuorum(t *testing.T) {
+	t.Parallel()
+	bundle := smith_v1.Bundle{
+		Spec: smith_v1.BundleSpec{
+			Resources: []smith_v1.Resource{
+				{
+					Name: "app",
+					Quorums: []smith_v1.Quorum{
+						{
+							Resources: []smith_v1.ResourceName{"broker1"},
+							MinReady:  2,
+						},
+					},
+				},
+				{
+					Name: "broker1",
+				},
+			},
+		},
+	}
+	_, _, err := sortBundle(&bundle)
+	require.EqualError(t, err, `resource "app" has a quorum with minReady 2 but it must be between 1 and the number of resources in the quorum (1)`)
+}
@@ -0,0 +1,171 @@
+package bundlec
+
+import (
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/pkg/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Objects in other namespaces than the namespace of their Bundle cannot have owner references to it, owner
+// references across namespaces are not supported by the garbage collector. Such objects are labeled with
+// the name, namespace and UID of the Bundle instead and are deleted by the controller when the Bundle is deleted.
+
+// objectRefOf returns a reference to the object. Namespace is only set if it is not the namespace of the Bundle.
+func (st *bundleSyncTask) objectRefOf(obj runtime.Object) objectRef {
+	m := obj.(meta_v1.Object)
+	ref := objectRef{
+		GroupVersionKind: obj.GetObjectKind().GroupVersionKind(),
+		Name:             m.GetName(),
+	}
+	if m.GetNamespace() != st.bundle.Namespace {
+		ref.Namespace = m.GetNamespace()
+	}
+	return ref
+}
+
+// namespaceOf returns the namespace of the referenced object.
+func (st *bundleSyncTask) namespaceOf(ref objectRef) string {
+	if ref.Namespace == "" {
+		return st.bundle.Namespace
+	}
+	return ref.Namespace
+}
+
+// resourceNamespace returns the namespace the resource declares for its object. It is empty if the object is in
+// the namespace of the Bundle, like the namespace of an objectRef.
+func resourceNamespace(res *smith_v1.Resource, bundle *smith_v1.Bundle) string {
+	if res.Namespace == bundle.Namespace {
+		return ""
+	}
+	return res.Namespace
+}
+
+// childObjects returns objects controlled by the Bundle and objects tracked by it in other namespaces.
+func (st *bundleSyncTask) childObjects() ([]runtime.Object, error) {
+	objs, err := st.store.ObjectsControlledBy(st.bundle.Namespace, st.bundle.UID)
+	if err != nil {
+		return nil, err
+	}
+	tracked, err := st.crossNamespaceObjects()
+	if err != nil {
+		return nil, err
+	}
+	return append(objs, tracked...), nil
+}
+
+// crossNamespaceObjects returns objects tracked by the Bundle in other namespaces than the namespace of the Bundle.
+// They are returned even if cross-namespace resources are disabled so that objects created before are not leaked.
+func (st *bundleSyncTask) crossNamespaceObjects() ([]runtime.Object, error) {
+	objs, err := st.store.ObjectsTrackedBy(st.bundle.UID)
+	if err != nil {
+		return nil, err
+	}
+	var result []runtime.Object
+	for _, obj := range objs {
+		if obj.(meta_v1.Object).GetNamespace() != st.bundle.Namespace {
+			result = append(result, obj)
+		}
+	}
+	return result, nil
+}
+
+// deleteCrossNamespaceObjects deletes objects tracked by the Bundle in other namespaces.
+func (st *bundleSyncTask) deleteCrossNamespaceObjects() (retriableError bool, e error) {
+	objs, err := st.crossNamespaceObjects()
+	if err != nil {
+		return false, err
+	}
+	return st.deleteObjects(objs)
+}
+
+// targetNamespace returns the namespace of the object of the resource.
+// Error is returned if it is another namespace than the namespace of the Bundle and cross-namespace resources are
+// disabled.
+func (st *resourceSyncTask) targetNamespace(res *smith_v1.Resource) (string, error) {
+	if res.Namespace == "" || res.Namespace == st.bundle.Namespace {
+		return st.bundle.Namespace, nil
+	}
+	if !st.crossNamespaceResources {
+		return "", errors.Errorf("resource declares namespace %q but objects in other namespaces than the namespace of the Bundle are not allowed", res.Namespace)
+	}
+	return res.Namespace, nil
+}
+
+// objectNamespace returns the namespace of the evaluated spec of an object.
+func (st *resourceSyncTask) objectNamespace(spec *unstructured.Unstructured) string {
+	if namespace := spec.GetNamespace(); namespace != "" {
+		return namespace
+	}
+	return st.bundle.Namespace
+}
+
+// setTrackingLabels labels an object in another namespace than the namespace of the Bundle with the namespace and
+// the UID of the Bundle. The name of the Bundle is set like for all other objects.
+func setTrackingLabels(bundle *smith_v1.Bundle, obj *unstructured.Unstructured) {
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string, 2)
+	}
+	labels[smith.BundleNamespaceLabel] = bundle.Namespace
+	labels[smith.BundleUIDLabel] = string(bundle.UID)
+	obj.SetLabels(labels)
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string, 1)
+	}
+	annotations[smith.BundleNameAnnotation] = bundle.Name
+	obj.SetAnnotations(annotations)
+}
+
+// isTrackedBy returns true if the object is labeled with the UID of the Bundle.
+func isTrackedBy(obj meta_v1.Object, bundle *smith_v1.Bundle) bool {
+	return obj.GetLabels()[smith.BundleUIDLabel] == string(bundle.UID)
+}
+
+// isManagedBy returns true if the object is controlled by the Bundle or, if it is in another namespace than
+// the namespace of the Bundle, tracked by it.
+func isManagedBy(obj meta_v1.Object, bundle *smith_v1.Bundle) bool {
+	if obj.GetNamespace() != bundle.Namespace {
+		return isTrackedBy(obj, bundle)
+	}
+	return meta_v1.IsControlledBy(obj, bundle)
+}
+
+// isTrackedByPreviousIncarnation returns true if the object is labeled with the name and namespace of the Bundle but
+// with a different UID.
+func isTrackedByPreviousIncarnation(obj meta_v1.Object, bundle *smith_v1.Bundle) bool {
+	labels := obj.GetLabels()
+	uid, ok := labels[smith.BundleUIDLabel]
+	return ok && uid != string(bundle.UID) &&
+		labels[smith.BundleNameLabel] == smith.LabelValue(bundle.Name) &&
+		labels[smith.BundleNamespaceLabel] == bundle.Namespace
+}
+
+// checkTrackedBy checks that an object in another namespace than the namespace of the Bundle is managed by it.
+// Stale labels of objects tracked by a previous incarnation of the Bundle are replaced when the objects are updated.
+// Objects that are not tracked by any Bundle are adopted if adoptUntracked is true.
+func (st *resourceSyncTask) checkTrackedBy(obj meta_v1.Object, adoptUntracked bool) (adopt bool, e error) {
+	labels := obj.GetLabels()
+	switch {
+	case isTrackedBy(obj, st.bundle):
+		return false, nil
+	case labels[smith.BundleUIDLabel] == "" && isRetainedBy(obj, st.bundle) && labels[smith.BundleNamespaceLabel] == st.bundle.Namespace:
+		return true, nil
+	case isTrackedByPreviousIncarnation(obj, st.bundle):
+		if st.repairStaleOwnerReferences {
+			return true, nil
+		}
+		return false, errors.Errorf("object is tracked by a previous incarnation of the Bundle (uid=%s), not by the Bundle (uid=%s)",
+			labels[smith.BundleUIDLabel], st.bundle.UID)
+	case labels[smith.BundleUIDLabel] != "":
+		return false, errors.Errorf("object is tracked by Bundle %s/%s (uid=%s), not by the Bundle (uid=%s)",
+			labels[smith.BundleNamespaceLabel], labels[smith.BundleNameLabel], labels[smith.BundleUIDLabel], st.bundle.UID)
+	case adoptUntracked:
+		return true, nil
+	default:
+		return false, errors.Errorf("object is not tracked by the Bundle and does not have the %s label", smith.BundleUIDLabel)
+	}
+}
@@ -0,0 +1,172 @@
+package bundlec
+
+import (
+	"testing"
+
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func crossNamespaceBundle() *smith_v1.Bundle {
+	return &smith_v1.Bundle{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "bundle1",
+			Namespace: "ns1",
+			UID:       "uid1",
+		},
+	}
+}
+
+func crossNamespaceConfigMap(namespace, name string, labels map[string]string) *core_v1.ConfigMap {
+	return &core_v1.ConfigMap{
+		TypeMeta: meta_v1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: core_v1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+	}
+}
+
+func TestEvalSpecCrossNamespace(t *testing.T) {
+	t.Parallel()
+	bundle := crossNamespaceBundle()
+	res := &smith_v1.Resource{
+		Name:      "cm",
+		Namespace: "ns2",
+		Spec: smith_v1.ResourceSpec{
+			Object: crossNamespaceConfigMap("", "cm", nil),
+		},
+	}
+	st := resourceSyncTask{
+		logger:                  zap.NewNop(),
+		bundle:                  bundle,
+		processedResources:      map[smith_v1.ResourceName]*resourceInfo{},
+		crossNamespaceResources: true,
+	}
+
+	obj, err := st.evalSpec(res, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "ns2", obj.GetNamespace())
+	assert.Empty(t, obj.GetOwnerReferences())
+	assert.Equal(t, map[string]string{
+		smith.BundleNameLabel:      "bundle1",
+		smith.BundleNamespaceLabel: "ns1",
+		smith.BundleUIDLabel:       "uid1",
+	}, obj.GetLabels())
+	assert.Equal(t, map[string]string{smith.BundleNameAnnotation: "bundle1"}, obj.GetAnnotations())
+
+	st.crossNamespaceResources = false
+	_, err = st.evalSpec(res, nil)
+	assert.EqualError(t, err, `resource declares namespace "ns2" but objects in other namespaces than the namespace of the Bundle are not allowed`)
+}
+
+func TestCheckTrackedBy(t *testing.T) {
+	t.Parallel()
+	bundle := crossNamespaceBundle()
+	testcases := map[string]struct {
+		labels         map[string]string
+		repair         bool
+		adoptUntracked bool
+		adopt          bool
+		err            bool
+	}{
+		"tracked": {
+			labels: map[string]string{smith.BundleUIDLabel: "uid1"},
+		},
+		"previous incarnation": {
+			labels: map[string]string{smith.BundleUIDLabel: "uid0", smith.BundleNameLabel: "bundle1", smith.BundleNamespaceLabel: "ns1"},
+			err:    true,
+		},
+		"previous incarnation repaired": {
+			labels: map[string]string{smith.BundleUIDLabel: "uid0", smith.BundleNameLabel: "bundle1", smith.BundleNamespaceLabel: "ns1"},
+			repair: true,
+			adopt:  true,
+		},
+		"other Bundle": {
+			labels: map[string]string{smith.BundleUIDLabel: "uid2", smith.BundleNameLabel: "bundle2", smith.BundleNamespaceLabel: "ns1"},
+			repair: true,
+			err:    true,
+		},
+		"other Bundle with adoption": {
+			labels:         map[string]string{smith.BundleUIDLabel: "uid2", smith.BundleNameLabel: "bundle2", smith.BundleNamespaceLabel: "ns1"},
+			adoptUntracked: true,
+			err:            true,
+		},
+		"not tracked": {
+			err: true,
+		},
+		"not tracked adopted": {
+			adoptUntracked: true,
+			adopt:          true,
+		},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			st := resourceSyncTask{
+				bundle:                     bundle,
+				repairStaleOwnerReferences: tc.repair,
+			}
+			adopt, err := st.checkTrackedBy(crossNamespaceConfigMap("ns2", "cm", tc.labels), tc.adoptUntracked)
+			if tc.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.adopt, adopt)
+		})
+	}
+}
+
+func TestFindObjectsToDeleteCrossNamespace(t *testing.T) {
+	t.Parallel()
+	bundle := crossNamespaceBundle()
+	bundle.Spec.Resources = []smith_v1.Resource{
+		{
+			Name:      "defined",
+			Namespace: "ns2",
+			Spec: smith_v1.ResourceSpec{
+				Object: crossNamespaceConfigMap("", "defined", nil),
+			},
+		},
+	}
+	tracked := map[string]string{smith.BundleUIDLabel: "uid1"}
+	st := &bundleSyncTask{
+		logger: zap.NewNop(),
+		store: fakeStore{
+			tracked: map[types.UID][]runtime.Object{
+				"uid1": {
+					crossNamespaceConfigMap("ns2", "defined", tracked),
+					crossNamespaceConfigMap("ns3", "defined", tracked),
+					crossNamespaceConfigMap("ns2", "removed", tracked),
+					// Objects in the namespace of the Bundle are found by their owner references
+					crossNamespaceConfigMap("ns1", "labeled", tracked),
+				},
+			},
+		},
+		bundle: bundle,
+	}
+
+	require.NoError(t, st.findObjectsToDelete())
+	gvk := core_v1.SchemeGroupVersion.WithKind("ConfigMap")
+	var refs []objectRef
+	for ref := range st.objectsToDelete {
+		refs = append(refs, ref)
+	}
+	assert.ElementsMatch(t, []objectRef{
+		{GroupVersionKind: gvk, Namespace: "ns3", Name: "defined"},
+		{GroupVersionKind: gvk, Namespace: "ns2", Name: "removed"},
+	}, refs)
+}
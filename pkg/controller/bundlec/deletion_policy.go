@@ -0,0 +1,110 @@
+package bundlec
+
+import (
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/atlassian/smith/pkg/util"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	api_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// objectDeletionPolicy returns the deletion policy recorded on the object.
+// The policy is read from the object rather than from the resource because objects of resources that have been
+// removed from the Bundle are deleted too.
+func objectDeletionPolicy(obj meta_v1.Object) smith_v1.DeletionPolicy {
+	switch policy := smith_v1.DeletionPolicy(obj.GetAnnotations()[smith.DeletionPolicyAnnotation]); policy {
+	case smith_v1.DeletionPolicyOrphan, smith_v1.DeletionPolicyRetain:
+		return policy
+	default:
+		return smith_v1.DeletionPolicyDelete
+	}
+}
+
+// applyDeletionPolicy records the deletion policy of the resource in the DeletionPolicyAnnotation of the spec.
+// Objects of resources without a policy only get the annotation if the actual object has it already, so that
+// a policy that has been removed from the resource is reset. Actual object may be nil.
+func applyDeletionPolicy(policy smith_v1.DeletionPolicy, actual runtime.Object, obj *unstructured.Unstructured) error {
+	switch policy {
+	case "", smith_v1.DeletionPolicyDelete:
+		if actual == nil {
+			return nil
+		}
+		if _, ok := actual.(meta_v1.Object).GetAnnotations()[smith.DeletionPolicyAnnotation]; !ok {
+			return nil
+		}
+		policy = smith_v1.DeletionPolicyDelete
+	case smith_v1.DeletionPolicyOrphan, smith_v1.DeletionPolicyRetain:
+	default:
+		return errors.Errorf("unsupported deletion policy %q", policy)
+	}
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string, 1)
+	}
+	annotations[smith.DeletionPolicyAnnotation] = string(policy)
+	obj.SetAnnotations(annotations)
+	return nil
+}
+
+// isRetainedBy returns true if the object has been kept by a Bundle with the same name according to the Retain
+// deletion policy and can be adopted by the Bundle.
+func isRetainedBy(obj meta_v1.Object, bundle *smith_v1.Bundle) bool {
+	return meta_v1.GetControllerOf(obj) == nil &&
+		objectDeletionPolicy(obj) == smith_v1.DeletionPolicyRetain &&
+		obj.GetLabels()[smith.BundleNameLabel] == smith.LabelValue(bundle.Name)
+}
+
+// releaseObject keeps an object that would otherwise be deleted. Owner references to the Bundle and to other objects
+// controlled by it are removed so that the garbage collector does not delete the object. The BundleUIDLabel is removed
+// so that the object is not deleted together with the Bundle if it is in another namespace. With the Orphan policy
+// the BundleNameLabel, the BundleNamespaceLabel and the DeletionPolicyAnnotation are removed too.
+func (st *bundleSyncTask) releaseObject(logger *zap.Logger, ref objectRef, obj runtime.Object, policy smith_v1.DeletionPolicy) error {
+	controlled, err := st.store.ObjectsControlledBy(st.bundle.Namespace, st.bundle.UID)
+	if err != nil {
+		return err
+	}
+	owners := make(map[types.UID]struct{}, len(controlled)+1)
+	owners[st.bundle.UID] = struct{}{}
+	for _, c := range controlled {
+		owners[c.(meta_v1.Object).GetUID()] = struct{}{}
+	}
+	objUnstr, err := util.RuntimeToUnstructured(obj)
+	if err != nil {
+		return err
+	}
+	var ownerRefs []meta_v1.OwnerReference
+	for _, ownerRef := range objUnstr.GetOwnerReferences() {
+		if _, ok := owners[ownerRef.UID]; !ok {
+			ownerRefs = append(ownerRefs, ownerRef)
+		}
+	}
+	objUnstr.SetOwnerReferences(ownerRefs)
+	labels := objUnstr.GetLabels()
+	delete(labels, smith.BundleUIDLabel)
+	if policy == smith_v1.DeletionPolicyOrphan {
+		delete(labels, smith.BundleNameLabel)
+		delete(labels, smith.BundleNamespaceLabel)
+	}
+	objUnstr.SetLabels(labels)
+	if policy == smith_v1.DeletionPolicyOrphan {
+		annotations := objUnstr.GetAnnotations()
+		delete(annotations, smith.DeletionPolicyAnnotation)
+		delete(annotations, smith.BundleNameAnnotation)
+		objUnstr.SetAnnotations(annotations)
+	}
+	resClient, err := st.smartClient.ForGVK(ref.GroupVersionKind, st.namespaceOf(ref))
+	if err != nil {
+		return err
+	}
+	logger.Sugar().Infof("Keeping object according to its %s deletion policy", policy)
+	_, err = resClient.Update(objUnstr)
+	if err != nil && !api_errors.IsNotFound(err) {
+		return errors.Wrap(err, "failed to update object")
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package bundlec
+
+import (
+	"testing"
+
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+)
+
+type updatingSmartClient struct {
+	updated *[]*unstructured.Unstructured
+}
+
+func (c updatingSmartClient) ForGVK(gvk schema.GroupVersionKind, namespace string) (dynamic.ResourceInterface, error) {
+	return updatingResourceClient{updated: c.updated}, nil
+}
+
+type updatingResourceClient struct {
+	dynamic.ResourceInterface
+	updated *[]*unstructured.Unstructured
+}
+
+func (c updatingResourceClient) Update(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	*c.updated = append(*c.updated, obj)
+	return obj, nil
+}
+
+func TestApplyDeletionPolicy(t *testing.T) {
+	t.Parallel()
+	annotated := &core_v1.ConfigMap{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Annotations: map[string]string{smith.DeletionPolicyAnnotation: string(smith_v1.DeletionPolicyRetain)},
+		},
+	}
+	testcases := []struct {
+		name     string
+		policy   smith_v1.DeletionPolicy
+		actual   runtime.Object
+		expected map[string]string
+	}{
+		{name: "no policy"},
+		{name: "no policy and no annotation", actual: &core_v1.ConfigMap{}},
+		{name: "explicit delete", policy: smith_v1.DeletionPolicyDelete},
+		{
+			name:     "policy removed",
+			actual:   annotated,
+			expected: map[string]string{smith.DeletionPolicyAnnotation: string(smith_v1.DeletionPolicyDelete)},
+		},
+		{
+			name:     "retain",
+			policy:   smith_v1.DeletionPolicyRetain,
+			expected: map[string]string{smith.DeletionPolicyAnnotation: string(smith_v1.DeletionPolicyRetain)},
+		},
+		{
+			name:     "orphan",
+			policy:   smith_v1.DeletionPolicyOrphan,
+			actual:   annotated,
+			expected: map[string]string{smith.DeletionPolicyAnnotation: string(smith_v1.DeletionPolicyOrphan)},
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			require.NoError(t, applyDeletionPolicy(tc.policy, tc.actual, obj))
+			assert.Equal(t, tc.expected, obj.GetAnnotations())
+		})
+	}
+}
+
+func TestApplyDeletionPolicyUnsupported(t *testing.T) {
+	t.Parallel()
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	assert.EqualError(t, applyDeletionPolicy("Keep", nil, obj), `unsupported deletion policy "Keep"`)
+}
+
+func TestIsRetainedBy(t *testing.T) {
+	t.Parallel()
+	bundle := &smith_v1.Bundle{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name: "bundle1",
+			UID:  "bundle-uid",
+		},
+	}
+	retained := &core_v1.ConfigMap{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Labels:      map[string]string{smith.BundleNameLabel: "bundle1"},
+			Annotations: map[string]string{smith.DeletionPolicyAnnotation: string(smith_v1.DeletionPolicyRetain)},
+		},
+	}
+	assert.True(t, isRetainedBy(retained, bundle))
+
+	otherBundle := retained.DeepCopy()
+	otherBundle.Labels[smith.BundleNameLabel] = "bundle2"
+	assert.False(t, isRetainedBy(otherBundle, bundle))
+
+	orphaned := retained.DeepCopy()
+	orphaned.Annotations[smith.DeletionPolicyAnnotation] = string(smith_v1.DeletionPolicyOrphan)
+	assert.False(t, isRetainedBy(orphaned, bundle))
+
+	controlled := retained.DeepCopy()
+	trueVar := true
+	controlled.OwnerReferences = []meta_v1.OwnerReference{{Name: "bundle1", UID: "old-uid", Controller: &trueVar}}
+	assert.False(t, isRetainedBy(controlled, bundle))
+}
+
+func TestReleaseObject(t *testing.T) {
+	t.Parallel()
+	trueVar := true
+	bundle := &smith_v1.Bundle{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "bundle1",
+			Namespace: defaultNamespace,
+			UID:       "bundle-uid",
+		},
+	}
+	dependency := orderedDeletionConfigMap("dependency")
+	obj := orderedDeletionConfigMap("db")
+	obj.Labels = map[string]string{smith.BundleNameLabel: "bundle1", "app": "db"}
+	obj.Annotations = map[string]string{smith.DeletionPolicyAnnotation: string(smith_v1.DeletionPolicyOrphan)}
+	obj.OwnerReferences = []meta_v1.OwnerReference{
+		{Name: "bundle1", UID: bundle.UID, Controller: &trueVar},
+		{Name: "dependency", UID: dependency.UID},
+		{Name: "unrelated", UID: "unrelated-uid"},
+	}
+	ref := objectRef{GroupVersionKind: core_v1.SchemeGroupVersion.WithKind("ConfigMap"), Name: "db"}
+
+	for _, policy := range []smith_v1.DeletionPolicy{smith_v1.DeletionPolicyOrphan, smith_v1.DeletionPolicyRetain} {
+		var updated []*unstructured.Unstructured
+		st := &bundleSyncTask{
+			logger:      zap.NewNop(),
+			smartClient: updatingSmartClient{updated: &updated},
+			store: fakeStore{
+				controlled: map[types.UID][]runtime.Object{
+					bundle.UID: {dependency, obj},
+				},
+			},
+			bundle: bundle,
+		}
+		require.NoError(t, st.releaseObject(st.logger, ref, obj, policy))
+		require.Len(t, updated, 1)
+		assert.Equal(t, []meta_v1.OwnerReference{{Name: "unrelated", UID: "unrelated-uid"}}, updated[0].GetOwnerReferences(), policy)
+		if policy == smith_v1.DeletionPolicyOrphan {
+			assert.Equal(t, map[string]string{"app": "db"}, updated[0].GetLabels())
+			assert.Empty(t, updated[0].GetAnnotations())
+		} else {
+			assert.Equal(t, obj.Labels, updated[0].GetLabels())
+			assert.Equal(t, obj.Annotations, updated[0].GetAnnotations())
+		}
+	}
+}
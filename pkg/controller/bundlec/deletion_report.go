@@ -0,0 +1,118 @@
+package bundlec
+
+import (
+	"sort"
+
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// deletionReport computes what is going to happen to objects related to the Bundle once it is deleted.
+// Only objects of kinds that Smith has informers for are taken into account.
+func (st *bundleSyncTask) deletionReport() (*smith_v1.DeletionReport, error) {
+	report := &smith_v1.DeletionReport{}
+	children, err := st.childObjects()
+	if err != nil {
+		return nil, err
+	}
+	visited := map[types.UID]struct{}{
+		st.bundle.UID: {},
+	}
+	queue := make([]runtime.Object, 0, len(children))
+	for _, obj := range children {
+		m := obj.(meta_v1.Object)
+		visited[m.GetUID()] = struct{}{}
+		ref := objectReference(obj.GetObjectKind().GroupVersionKind(), m.GetName())
+		if objectDeletionPolicy(m) != smith_v1.DeletionPolicyDelete {
+			// Released instead of being deleted, objects it controls are kept too
+			report.Released = append(report.Released, ref)
+			continue
+		}
+		report.Children = append(report.Children, ref)
+		queue = append(queue, obj)
+	}
+	for len(queue) > 0 {
+		parent := queue[0].(meta_v1.Object)
+		queue = queue[1:]
+		dependents, err := st.store.ObjectsControlledBy(parent.GetNamespace(), parent.GetUID())
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range dependents {
+			m := obj.(meta_v1.Object)
+			if _, ok := visited[m.GetUID()]; ok {
+				continue
+			}
+			visited[m.GetUID()] = struct{}{}
+			report.Cascaded = append(report.Cascaded, objectReference(obj.GetObjectKind().GroupVersionKind(), m.GetName()))
+			queue = append(queue, obj)
+		}
+	}
+	resources, err := st.resources()
+	if err != nil {
+		// Sources of specs may be gone already, report what is known rather than blocking deletion
+		st.logger.Warn("Failed to get resources for deletion report", zap.Error(err))
+		resources = st.bundle.Spec.Resources
+	}
+	for i := range resources {
+		res := &resources[i]
+		gvk, name, ok := resourceObject(res, st.pluginContainers)
+		if !ok {
+			continue
+		}
+		namespace := res.Namespace
+		if namespace == "" {
+			namespace = st.bundle.Namespace
+		}
+		obj, exists, err := st.store.Get(gvk, namespace, name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get object for resource %q", res.Name)
+		}
+		if !exists {
+			continue
+		}
+		if !isManagedBy(obj.(meta_v1.Object), st.bundle) {
+			report.Retained = append(report.Retained, objectReference(gvk, name))
+		}
+	}
+	sortObjectReferences(report.Children)
+	sortObjectReferences(report.Cascaded)
+	sortObjectReferences(report.Retained)
+	sortObjectReferences(report.Released)
+	return report, nil
+}
+
+// deletionConfirmed returns false if deletion of the Bundle should wait for confirmation.
+func (st *bundleSyncTask) deletionConfirmed() bool {
+	return !st.requireDeletionConfirmation ||
+		st.bundle.Labels[smith.ProductionLabel] != "true" ||
+		st.bundle.Annotations[smith.DeletionConfirmedAnnotation] == "true"
+}
+
+func objectReference(gvk schema.GroupVersionKind, name string) smith_v1.ObjectReference {
+	return smith_v1.ObjectReference{
+		Group:   gvk.Group,
+		Version: gvk.Version,
+		Kind:    gvk.Kind,
+		Name:    name,
+	}
+}
+
+func sortObjectReferences(refs []smith_v1.ObjectReference) {
+	sort.Slice(refs, func(i, j int) bool {
+		a, b := refs[i], refs[j]
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+}
@@ -0,0 +1,232 @@
+package bundlec
+
+import (
+	"testing"
+
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	apps_v1 "k8s.io/api/apps/v1"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestDeletionReport(t *testing.T) {
+	t.Parallel()
+	tr := true
+	controlledBy := func(uid types.UID) []meta_v1.OwnerReference {
+		return []meta_v1.OwnerReference{
+			{
+				Name:       "owner",
+				UID:        uid,
+				Controller: &tr,
+			},
+		}
+	}
+	deployment := &apps_v1.Deployment{
+		TypeMeta: meta_v1.TypeMeta{
+			Kind:       "Deployment",
+			APIVersion: apps_v1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:            "deployment1",
+			Namespace:       "ns",
+			UID:             "deployment-uid",
+			OwnerReferences: controlledBy("bundle-uid"),
+		},
+	}
+	replicaSet := &apps_v1.ReplicaSet{
+		TypeMeta: meta_v1.TypeMeta{
+			Kind:       "ReplicaSet",
+			APIVersion: apps_v1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:            "rs1",
+			Namespace:       "ns",
+			UID:             "rs-uid",
+			OwnerReferences: controlledBy("deployment-uid"),
+		},
+	}
+	foreignConfigMap := &core_v1.ConfigMap{
+		TypeMeta: meta_v1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: core_v1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "map1",
+			Namespace: "ns",
+			UID:       "map-uid",
+		},
+	}
+	retainedSecret := &core_v1.Secret{
+		TypeMeta: meta_v1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: core_v1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:            "secret1",
+			Namespace:       "ns",
+			UID:             "secret-uid",
+			OwnerReferences: controlledBy("bundle-uid"),
+			Annotations: map[string]string{
+				smith.DeletionPolicyAnnotation: string(smith_v1.DeletionPolicyRetain),
+			},
+		},
+	}
+	secretDependent := &core_v1.ConfigMap{
+		TypeMeta: meta_v1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: core_v1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:            "dependent1",
+			Namespace:       "ns",
+			UID:             "dependent-uid",
+			OwnerReferences: controlledBy("secret-uid"),
+		},
+	}
+	st := bundleSyncTask{
+		bundle: &smith_v1.Bundle{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:      "bundle1",
+				Namespace: "ns",
+				UID:       "bundle-uid",
+			},
+			Spec: smith_v1.BundleSpec{
+				Resources: []smith_v1.Resource{
+					{
+						Name: "deployment",
+						Spec: smith_v1.ResourceSpec{
+							Object: deployment,
+						},
+					},
+					{
+						Name: "map",
+						Spec: smith_v1.ResourceSpec{
+							Object: foreignConfigMap,
+						},
+					},
+					{
+						Name:           "secret",
+						DeletionPolicy: smith_v1.DeletionPolicyRetain,
+						Spec: smith_v1.ResourceSpec{
+							Object: retainedSecret,
+						},
+					},
+				},
+			},
+		},
+		store: fakeStore{
+			responses: map[string]runtime.Object{
+				"deployment1": deployment,
+				"map1":        foreignConfigMap,
+				"secret1":     retainedSecret,
+			},
+			controlled: map[types.UID][]runtime.Object{
+				"bundle-uid":     {deployment, retainedSecret},
+				"deployment-uid": {replicaSet},
+				"secret-uid":     {secretDependent},
+			},
+		},
+	}
+
+	report, err := st.deletionReport()
+	require.NoError(t, err)
+	assert.Equal(t, &smith_v1.DeletionReport{
+		Children: []smith_v1.ObjectReference{
+			{Group: "apps", Version: "v1", Kind: "Deployment", Name: "deployment1"},
+		},
+		Cascaded: []smith_v1.ObjectReference{
+			{Group: "apps", Version: "v1", Kind: "ReplicaSet", Name: "rs1"},
+		},
+		Retained: []smith_v1.ObjectReference{
+			{Group: "", Version: "v1", Kind: "ConfigMap", Name: "map1"},
+		},
+		Released: []smith_v1.ObjectReference{
+			{Group: "", Version: "v1", Kind: "Secret", Name: "secret1"},
+		},
+	}, report)
+}
+
+func TestDeletionConfirmed(t *testing.T) {
+	t.Parallel()
+	inputs := []struct {
+		name        string
+		require     bool
+		labels      map[string]string
+		annotations map[string]string
+		confirmed   bool
+	}{
+		{name: "not required", require: false, labels: map[string]string{smith.ProductionLabel: "true"}, confirmed: true},
+		{name: "not production", require: true, confirmed: true},
+		{name: "not confirmed", require: true, labels: map[string]string{smith.ProductionLabel: "true"}, confirmed: false},
+		{
+			name:        "confirmed",
+			require:     true,
+			labels:      map[string]string{smith.ProductionLabel: "true"},
+			annotations: map[string]string{smith.DeletionConfirmedAnnotation: "true"},
+			confirmed:   true,
+		},
+	}
+	for _, input := range inputs {
+		input := input
+		t.Run(input.name, func(t *testing.T) {
+			t.Parallel()
+			st := bundleSyncTask{
+				bundle: &smith_v1.Bundle{
+					ObjectMeta: meta_v1.ObjectMeta{
+						Labels:      input.labels,
+						Annotations: input.annotations,
+					},
+				},
+				requireDeletionConfirmation: input.require,
+			}
+			assert.Equal(t, input.confirmed, st.deletionConfirmed())
+		})
+	}
+}
+
+func TestProcessDeletedAwaitsConfirmation(t *testing.T) {
+	t.Parallel()
+	testcases := map[string][]string{
+		"background propagation":             {FinalizerDeleteResources},
+		"foreground propagation":             {FinalizerDeleteResources, meta_v1.FinalizerDeleteDependents},
+		"foreground without Smith finalizer": {meta_v1.FinalizerDeleteDependents},
+	}
+	for name, finalizers := range testcases {
+		finalizers := finalizers
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			now := meta_v1.Now()
+			var deleted []string
+			st := bundleSyncTask{
+				logger:      zaptest.NewLogger(t),
+				smartClient: deletingSmartClient{deleted: &deleted},
+				store:       fakeStore{},
+				bundle: &smith_v1.Bundle{
+					ObjectMeta: meta_v1.ObjectMeta{
+						Name:              "bundle1",
+						Namespace:         "ns",
+						UID:               "bundle-uid",
+						DeletionTimestamp: &now,
+						Finalizers:        finalizers,
+						Labels:            map[string]string{smith.ProductionLabel: "true"},
+					},
+				},
+				requireDeletionConfirmation: true,
+			}
+
+			retriable, err := st.processDeleted()
+			require.NoError(t, err)
+			assert.False(t, retriable)
+			assert.True(t, st.awaitingDeletionConfirmation)
+			assert.NotNil(t, st.bundle.Status.DeletionReport)
+			assert.Nil(t, st.newFinalizers)
+			assert.Empty(t, deleted)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package bundlec
+
+import (
+	"sort"
+	"strconv"
+)
+
+// droppedFields returns paths of fields which are present in the desired object but are missing in the actual one.
+// API server silently drops unknown fields so this is a way to detect mistakes in the object specification.
+func droppedFields(desired, actual map[string]interface{}) []string {
+	var result []string
+	collectDroppedFields("", desired, actual, &result)
+	sort.Strings(result)
+	return result
+}
+
+func collectDroppedFields(path string, desired, actual interface{}, result *[]string) {
+	switch d := desired.(type) {
+	case map[string]interface{}:
+		a, ok := actual.(map[string]interface{})
+		if !ok {
+			return
+		}
+		for key, dValue := range d {
+			fieldPath := key
+			if path != "" {
+				fieldPath = path + "." + key
+			}
+			aValue, ok := a[key]
+			if !ok {
+				if dValue != nil {
+					*result = append(*result, fieldPath)
+				}
+				continue
+			}
+			collectDroppedFields(fieldPath, dValue, aValue, result)
+		}
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok || len(a) != len(d) {
+			// Cannot match elements reliably
+			return
+		}
+		for i := range d {
+			collectDroppedFields(path+"["+strconv.Itoa(i)+"]", d[i], a[i], result)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package bundlec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDroppedFields(t *testing.T) {
+	t.Parallel()
+	desired := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name": "x",
+		},
+		"spec": map[string]interface{}{
+			"replicas": 1,
+			"replica":  1,
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name": "c",
+							"imag": "img",
+						},
+					},
+				},
+			},
+			"nothing": nil,
+		},
+	}
+	actual := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name": "x",
+			"uid":  "123",
+		},
+		"spec": map[string]interface{}{
+			"replicas": 1,
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name": "c",
+						},
+					},
+				},
+			},
+		},
+	}
+	assert.Equal(t, []string{"spec.replica", "spec.template.spec.containers[0].imag"}, droppedFields(desired, actual))
+}
+
+func TestDroppedFieldsNone(t *testing.T) {
+	t.Parallel()
+	obj := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"a": []interface{}{"b"},
+		},
+	}
+	assert.Empty(t, droppedFields(obj, obj))
+}
@@ -0,0 +1,198 @@
+package bundlec
+
+import (
+	"reflect"
+	"sort"
+
+	ctrlLogz "github.com/atlassian/ctrl/logz"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/atlassian/smith/pkg/util"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/diff"
+)
+
+const (
+	// EventReasonDryRunPlan is the reason of the Event recorded when the plan of a Bundle processed in dry-run mode
+	// changes.
+	EventReasonDryRunPlan = "DryRunPlan"
+
+	// maxPlannedDiffLength limits the size of diffs stored in the Bundle status.
+	maxPlannedDiffLength = 4096
+)
+
+// planResource computes the change that processing of the resource would make to its object without making it.
+// The returned status reflects the actual object because the desired one is not applied.
+func (st *resourceSyncTask) planResource(res *smith_v1.Resource, spec *unstructured.Unstructured, actual runtime.Object) resourceInfo {
+	change := plannedChange(res.Name, spec.GroupVersionKind(), spec.GetName())
+	if actual == nil {
+		st.logger.Info("Object not found, would create it", ctrlLogz.Object(spec))
+		change.Action = smith_v1.PlannedActionCreate
+		return resourceInfo{
+			status:        resourceStatusInProgress{},
+			plannedChange: &change,
+		}
+	}
+	actualUnstr, err := util.RuntimeToUnstructured(actual)
+	if err != nil {
+		return resourceInfo{
+			status: resourceStatusError{
+				err: err,
+			},
+		}
+	}
+
+	if isRunToCompletion(spec.GroupVersionKind().GroupKind()) {
+		checksum, err := jobSpecChecksum(spec)
+		if err != nil {
+			return resourceInfo{
+				status: resourceStatusError{
+					err: err,
+				},
+			}
+		}
+		actualChecksum, ok := actualUnstr.GetAnnotations()[jobSpecChecksumAnnotation]
+		if ok && actualChecksum == checksum {
+			return st.checkReadiness(actualUnstr)
+		}
+		change.Action = smith_v1.PlannedActionUpdate
+		if ok {
+			change.Message = "Job spec has changed, Job would be deleted and created again to re-run it"
+		} else {
+			change.Message = "Job metadata would be updated"
+		}
+	} else {
+		updated, match, err := st.specCheck.CompareActualVsSpec(spec, actual)
+		if err != nil {
+			return resourceInfo{
+				status: resourceStatusError{
+					err: errors.Wrap(err, "specification check failed"),
+				},
+			}
+		}
+		if match {
+			return st.checkReadiness(updated)
+		}
+		change.Action = smith_v1.PlannedActionUpdate
+		change.Diff = truncateDiff(diff.ObjectReflectDiff(actualUnstr.Object, updated.Object))
+	}
+	st.logger.Info("Object would be updated", ctrlLogz.Object(spec))
+	resInfo := st.checkReadiness(actualUnstr)
+	resInfo.plannedChange = &change
+	return resInfo
+}
+
+// planDependenciesNotReady returns a change with an unknown action for a resource that cannot be evaluated
+// because its dependencies are not ready.
+func (st *resourceSyncTask) planDependenciesNotReady(res *smith_v1.Resource) *smith_v1.PlannedChange {
+	gvk, name, ok := resourceObject(res, st.pluginContainers)
+	if !ok {
+		return nil
+	}
+	change := plannedChange(res.Name, gvk, name)
+	change.Action = smith_v1.PlannedActionUnknown
+	change.Message = "dependencies are not ready or would be created first"
+	return &change
+}
+
+// computePlan collects changes to objects of processed resources and objects that would be deleted.
+func (st *bundleSyncTask) computePlan(resourceNames []smith_v1.ResourceName, deletions bool) *smith_v1.Plan {
+	plan := &smith_v1.Plan{
+		ObservedGeneration: st.bundle.Generation,
+	}
+	for _, resName := range resourceNames {
+		if resInfo, ok := st.processedResources[resName]; ok && resInfo.plannedChange != nil {
+			plan.Changes = append(plan.Changes, *resInfo.plannedChange)
+		}
+	}
+	if deletions {
+		deleted := make([]smith_v1.PlannedChange, 0, len(st.objectsToDelete))
+		for ref, obj := range st.objectsToDelete {
+			if objectDeletionPolicy(obj.(meta_v1.Object)) != smith_v1.DeletionPolicyDelete {
+				// Kept according to its deletion policy
+				continue
+			}
+			change := plannedChange("", ref.GroupVersionKind, ref.Name)
+			change.Action = smith_v1.PlannedActionDelete
+			deleted = append(deleted, change)
+		}
+		sort.Slice(deleted, func(i, j int) bool {
+			a, b := deleted[i], deleted[j]
+			if a.Group != b.Group {
+				return a.Group < b.Group
+			}
+			if a.Kind != b.Kind {
+				return a.Kind < b.Kind
+			}
+			return a.Name < b.Name
+		})
+		plan.Changes = append(plan.Changes, deleted...)
+	}
+	return plan
+}
+
+// planDeletion records objects of the deleted Bundle in the plan instead of deleting them. The finalizer is kept so
+// that the objects are deleted once the controller is not running in dry-run mode anymore.
+func (st *bundleSyncTask) planDeletion() error {
+	objs, err := st.childObjects()
+	if err != nil {
+		return err
+	}
+	st.objectsToDelete = make(map[objectRef]runtime.Object, len(objs))
+	for _, obj := range objs {
+		st.objectsToDelete[st.objectRefOf(obj)] = obj
+	}
+	st.logger.Info("Not deleting objects of the Bundle because of dry-run mode", zap.Int("objects", len(objs)))
+	st.setPlan(st.computePlan(nil, true))
+	return nil
+}
+
+// setPlan sets the plan in the Bundle status and records an Event if it has changed.
+func (st *bundleSyncTask) setPlan(plan *smith_v1.Plan) {
+	if reflect.DeepEqual(st.bundle.Status.Plan, plan) {
+		return
+	}
+	st.bundle.Status.Plan = plan
+	st.planUpdated = true
+	if plan == nil {
+		return
+	}
+	var created, updated, deleted, unknown int
+	for _, change := range plan.Changes {
+		switch change.Action {
+		case smith_v1.PlannedActionCreate:
+			created++
+		case smith_v1.PlannedActionUpdate:
+			updated++
+		case smith_v1.PlannedActionDelete:
+			deleted++
+		default:
+			unknown++
+		}
+	}
+	recordEvent(st.recorder, st.bundle, core_v1.EventTypeNormal, EventReasonDryRunPlan,
+		"Dry-run: would create %d, update %d and delete %d object(s), %d object(s) cannot be planned yet",
+		created, updated, deleted, unknown)
+}
+
+func plannedChange(resName smith_v1.ResourceName, gvk schema.GroupVersionKind, name string) smith_v1.PlannedChange {
+	return smith_v1.PlannedChange{
+		Resource: resName,
+		Group:    gvk.Group,
+		Version:  gvk.Version,
+		Kind:     gvk.Kind,
+		Name:     name,
+	}
+}
+
+func truncateDiff(d string) string {
+	if len(d) <= maxPlannedDiffLength {
+		return d
+	}
+	return d[:maxPlannedDiffLength] + "\n... (truncated)"
+}
@@ -0,0 +1,206 @@
+package bundlec
+
+import (
+	"testing"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/atlassian/smith/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+)
+
+// fakeSpecCheck considers objects matching if their "data" fields are equal.
+type fakeSpecCheck struct{}
+
+func (fakeSpecCheck) CompareActualVsSpec(spec, actual runtime.Object) (*unstructured.Unstructured, bool, error) {
+	specUnstr, err := util.RuntimeToUnstructured(spec)
+	if err != nil {
+		return nil, false, err
+	}
+	actualUnstr, err := util.RuntimeToUnstructured(actual)
+	if err != nil {
+		return nil, false, err
+	}
+	match := equality.Semantic.DeepEqual(specUnstr.Object["data"], actualUnstr.Object["data"])
+	return specUnstr, match, nil
+}
+
+type fakeReadyChecker struct{}
+
+func (fakeReadyChecker) IsReady(*unstructured.Unstructured) (bool, bool, error) {
+	return true, false, nil
+}
+
+func dryRunConfigMap(data map[string]string) *core_v1.ConfigMap {
+	return &core_v1.ConfigMap{
+		TypeMeta: meta_v1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: core_v1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name: "map1",
+		},
+		Data: data,
+	}
+}
+
+func TestPlanResource(t *testing.T) {
+	t.Parallel()
+	st := &resourceSyncTask{
+		logger:    zap.NewNop(),
+		rc:        fakeReadyChecker{},
+		specCheck: fakeSpecCheck{},
+		bundle:    &smith_v1.Bundle{},
+		dryRun:    true,
+	}
+	res := &smith_v1.Resource{Name: "map"}
+	spec, err := util.RuntimeToUnstructured(dryRunConfigMap(map[string]string{"a": "b"}))
+	require.NoError(t, err)
+
+	resInfo := st.planResource(res, spec, nil)
+	assert.IsType(t, resourceStatusInProgress{}, resInfo.status)
+	require.NotNil(t, resInfo.plannedChange)
+	assert.Equal(t, smith_v1.PlannedChange{
+		Resource: "map",
+		Version:  "v1",
+		Kind:     "ConfigMap",
+		Name:     "map1",
+		Action:   smith_v1.PlannedActionCreate,
+	}, *resInfo.plannedChange)
+
+	resInfo = st.planResource(res, spec, dryRunConfigMap(map[string]string{"a": "c"}))
+	assert.IsType(t, resourceStatusReady{}, resInfo.status)
+	require.NotNil(t, resInfo.plannedChange)
+	assert.Equal(t, smith_v1.PlannedActionUpdate, resInfo.plannedChange.Action)
+	assert.NotEmpty(t, resInfo.plannedChange.Diff)
+
+	resInfo = st.planResource(res, spec, dryRunConfigMap(map[string]string{"a": "b"}))
+	assert.IsType(t, resourceStatusReady{}, resInfo.status)
+	assert.Nil(t, resInfo.plannedChange)
+}
+
+func TestSetPlan(t *testing.T) {
+	t.Parallel()
+	recorder := record.NewFakeRecorder(2)
+	st := &bundleSyncTask{
+		bundle: &smith_v1.Bundle{
+			ObjectMeta: meta_v1.ObjectMeta{Generation: 2},
+		},
+		recorder: recorder,
+		processedResources: map[smith_v1.ResourceName]*resourceInfo{
+			"a": {
+				status:        resourceStatusInProgress{},
+				plannedChange: &smith_v1.PlannedChange{Resource: "a", Action: smith_v1.PlannedActionCreate},
+			},
+			"b": {
+				status: resourceStatusReady{},
+			},
+		},
+		objectsToDelete: map[objectRef]runtime.Object{
+			{GroupVersionKind: schema.GroupVersionKind{Version: "v1", Kind: "Secret"}, Name: "s2"}: &core_v1.Secret{},
+			{GroupVersionKind: schema.GroupVersionKind{Version: "v1", Kind: "Secret"}, Name: "s1"}: &core_v1.Secret{},
+		},
+	}
+
+	plan := st.computePlan([]smith_v1.ResourceName{"a", "b"}, true)
+	assert.Equal(t, &smith_v1.Plan{
+		ObservedGeneration: 2,
+		Changes: []smith_v1.PlannedChange{
+			{Resource: "a", Action: smith_v1.PlannedActionCreate},
+			{Version: "v1", Kind: "Secret", Name: "s1", Action: smith_v1.PlannedActionDelete},
+			{Version: "v1", Kind: "Secret", Name: "s2", Action: smith_v1.PlannedActionDelete},
+		},
+	}, plan)
+	assert.Len(t, st.computePlan([]smith_v1.ResourceName{"a", "b"}, false).Changes, 1)
+
+	st.setPlan(plan)
+	assert.True(t, st.planUpdated)
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, "would create 1, update 0 and delete 2 object(s)")
+
+	// Same plan is not recorded again
+	st.planUpdated = false
+	st.setPlan(st.computePlan([]smith_v1.ResourceName{"a", "b"}, true))
+	assert.False(t, st.planUpdated)
+	assert.Empty(t, recorder.Events)
+
+	// Plan is cleared once dry-run mode is turned off
+	st.setPlan(nil)
+	assert.True(t, st.planUpdated)
+	assert.Nil(t, st.bundle.Status.Plan)
+	assert.Empty(t, recorder.Events)
+}
+
+func TestProcessDeletedPlansDeletion(t *testing.T) {
+	t.Parallel()
+	now := meta_v1.Now()
+	cm := dryRunConfigMap(nil)
+	cm.Namespace = "ns"
+	st := &bundleSyncTask{
+		logger:   zap.NewNop(),
+		recorder: record.NewFakeRecorder(1),
+		store: fakeStore{
+			controlled: map[types.UID][]runtime.Object{
+				"b1-uid": {cm},
+			},
+		},
+		bundle: &smith_v1.Bundle{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Namespace:         "ns",
+				Name:              "b1",
+				UID:               "b1-uid",
+				DeletionTimestamp: &now,
+				Finalizers:        []string{FinalizerDeleteResources},
+			},
+		},
+		dryRun:        true,
+		planDeletions: true,
+	}
+
+	retriable, err := st.processDeleted()
+	require.NoError(t, err)
+	assert.False(t, retriable)
+	// Finalizer is kept
+	assert.Nil(t, st.newFinalizers)
+	assert.True(t, st.planUpdated)
+	require.NotNil(t, st.bundle.Status.Plan)
+	assert.Equal(t, []smith_v1.PlannedChange{
+		{Version: "v1", Kind: "ConfigMap", Name: "map1", Action: smith_v1.PlannedActionDelete},
+	}, st.bundle.Status.Plan.Changes)
+}
+
+func TestDryRunDoesNotRecordAdoption(t *testing.T) {
+	t.Parallel()
+	recorder := record.NewFakeRecorder(1)
+	st := &resourceSyncTask{
+		logger:   zap.NewNop(),
+		recorder: recorder,
+		store:    specSourceStore(t, child("map1", nil)),
+		bundle: &smith_v1.Bundle{
+			ObjectMeta: meta_v1.ObjectMeta{Namespace: "ns", Name: "b1", UID: "b1-uid"},
+		},
+		dryRun: true,
+	}
+	res := dryRunResource("map1", nil)
+	res.Adopt = true
+
+	actual, status := st.getActualObject(&res)
+	assert.Nil(t, status)
+	assert.NotNil(t, actual)
+	assert.Empty(t, recorder.Events)
+
+	st.dryRun = false
+	_, status = st.getActualObject(&res)
+	assert.Nil(t, status)
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, EventReasonObjectAdopted)
+}
@@ -0,0 +1,47 @@
+package bundlec
+
+import (
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// Reasons of Events recorded on Bundles.
+const (
+	// EventReasonObjectCreated is the reason of the Event recorded when an object of a resource is created.
+	EventReasonObjectCreated = "ObjectCreated"
+	// EventReasonObjectUpdated is the reason of the Event recorded when an object of a resource is updated to match
+	// the spec of the resource.
+	EventReasonObjectUpdated = "ObjectUpdated"
+	// EventReasonObjectModifiedExternally is the reason of the Event recorded when fields of an object that were set
+	// from the spec in field ownership mode have been changed by something else and are reverted.
+	EventReasonObjectModifiedExternally = "ObjectModifiedExternally"
+	// EventReasonObjectDeleted is the reason of the Event recorded when an object that is no longer defined in
+	// the Bundle is deleted.
+	EventReasonObjectDeleted = "ObjectDeleted"
+	// EventReasonObjectKept is the reason of the Event recorded when an object that is no longer defined in
+	// the Bundle is kept according to its deletion policy.
+	EventReasonObjectKept = "ObjectKept"
+	// EventReasonObjectAdopted is the reason of the Event recorded when an object retained by a Bundle with the same
+	// name is adopted.
+	EventReasonObjectAdopted = "ObjectAdopted"
+	// EventReasonObjectConflict is the reason of the Event recorded when an object of a resource exists but is not
+	// controlled by the Bundle.
+	EventReasonObjectConflict = "ObjectConflict"
+	// EventReasonTerminalError is the reason of the Event recorded when the Bundle ends up in a state that requires
+	// intervention to get out of.
+	EventReasonTerminalError = "TerminalError"
+	// EventReasonRolledBack is the reason of the Event recorded when the spec of the Bundle is replaced with the last
+	// spec that made it ready.
+	EventReasonRolledBack = "RolledBack"
+	// EventReasonRollbackFailed is the reason of the Event recorded when the Bundle is annotated for rollback but
+	// the spec to roll back to cannot be found.
+	EventReasonRollbackFailed = "RollbackFailed"
+)
+
+// recordEvent records an Event on the Bundle. Nothing is recorded if there is no recorder.
+func recordEvent(recorder record.EventRecorder, bundle *smith_v1.Bundle, eventType, reason, messageFmt string, args ...interface{}) {
+	if recorder == nil {
+		return
+	}
+	recorder.Eventf(bundle, eventType, reason, messageFmt, args...)
+}
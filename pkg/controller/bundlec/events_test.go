@@ -0,0 +1,26 @@
+package bundlec
+
+import (
+	"testing"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestRecordEvent(t *testing.T) {
+	t.Parallel()
+	recorder := record.NewFakeRecorder(1)
+	recordEvent(recorder, &smith_v1.Bundle{}, core_v1.EventTypeNormal, EventReasonObjectCreated, "Created %s %q", "ConfigMap", "cm1")
+	require.Len(t, recorder.Events, 1)
+	assert.Equal(t, `Normal ObjectCreated Created ConfigMap "cm1"`, <-recorder.Events)
+}
+
+func TestRecordEventWithoutRecorder(t *testing.T) {
+	t.Parallel()
+	assert.NotPanics(t, func() {
+		recordEvent(nil, &smith_v1.Bundle{}, core_v1.EventTypeWarning, EventReasonTerminalError, "boom")
+	})
+}
@@ -0,0 +1,82 @@
+package bundlec
+
+import (
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/atlassian/smith/pkg/readychecker"
+	"github.com/atlassian/smith/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// observeExternalObject checks if the object an external resource waits for exists and is ready.
+// The object is never created, updated or deleted and it is not required to be controlled by the Bundle.
+func (st *resourceSyncTask) observeExternalObject(res *smith_v1.Resource) resourceInfo {
+	resInfo := st.externalObjectInfo(res)
+	resInfo.external = true
+	return resInfo
+}
+
+func (st *resourceSyncTask) externalObjectInfo(res *smith_v1.Resource) resourceInfo {
+	external := res.Spec.External
+	gvk := external.GroupVersionKind()
+	namespace, err := st.targetNamespace(res)
+	if err != nil {
+		return resourceInfo{
+			status: resourceStatusError{
+				err: err,
+			},
+		}
+	}
+	if !st.store.HasInformer(gvk) {
+		// CRD may not have been created yet. Processing will resume once it is there.
+		st.logger.Sugar().Debugf("No informer for %s, external resource is blocked", gvk)
+		return resourceInfo{
+			status: resourceStatusMissingAPI{
+				gvk: gvk,
+			},
+		}
+	}
+	actual, exists, err := st.store.Get(gvk, namespace, external.Name)
+	if err != nil {
+		return resourceInfo{
+			status: resourceStatusError{
+				err: errors.Wrap(err, "failed to get object from the Store"),
+			},
+		}
+	}
+	if !exists {
+		st.logger.Sugar().Infof("Waiting for external %s %q to be created", gvk.Kind, external.Name)
+		return resourceInfo{
+			status: resourceStatusInProgress{},
+		}
+	}
+	actualUnstr, err := util.RuntimeToUnstructured(actual)
+	if err != nil {
+		return resourceInfo{
+			status: resourceStatusError{
+				err: err,
+			},
+		}
+	}
+	if external.ReadyWhen == "" {
+		return st.checkReadiness(actualUnstr)
+	}
+	ready, err := readychecker.CheckReadyWhen(external.ReadyWhen, actualUnstr)
+	if err != nil {
+		return resourceInfo{
+			actual: actualUnstr,
+			status: resourceStatusError{
+				err: errors.Wrap(err, "invalid readyWhen expression of external object"),
+			},
+		}
+	}
+	if !ready {
+		return resourceInfo{
+			actual: actualUnstr,
+			status: resourceStatusInProgress{},
+		}
+	}
+	return resourceInfo{
+		actual: actualUnstr,
+		status: resourceStatusReady{},
+	}
+}
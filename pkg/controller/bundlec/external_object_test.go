@@ -0,0 +1,176 @@
+package bundlec
+
+import (
+	"testing"
+
+	"github.com/atlassian/ctrl"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/atlassian/smith/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+)
+
+func externalConfigMapResource(name smith_v1.ResourceName, objectName, readyWhen string) smith_v1.Resource {
+	return smith_v1.Resource{
+		Name: name,
+		Spec: smith_v1.ResourceSpec{
+			External: &smith_v1.ExternalObject{
+				APIVersion: "v1",
+				Kind:       "ConfigMap",
+				Name:       objectName,
+				ReadyWhen:  readyWhen,
+			},
+		},
+	}
+}
+
+func TestObserveExternalObject(t *testing.T) {
+	t.Parallel()
+	ready := orderedDeletionConfigMap("ready")
+	ready.Data = map[string]string{"phase": "Ready"}
+	pending := orderedDeletionConfigMap("pending")
+	pending.Data = map[string]string{"phase": "Pending"}
+	multi := specSourceStore(t, ready, pending)
+	testcases := map[string]struct {
+		res    smith_v1.Resource
+		status resourceStatus
+	}{
+		"missing": {
+			res:    externalConfigMapResource("a", "missing", ""),
+			status: resourceStatusInProgress{},
+		},
+		"ready by expression": {
+			res:    externalConfigMapResource("a", "ready", `$.data.phase == "Ready"`),
+			status: resourceStatusReady{},
+		},
+		"not ready by expression": {
+			res:    externalConfigMapResource("a", "pending", `$.data.phase == "Ready"`),
+			status: resourceStatusInProgress{},
+		},
+		"ready by built-in check": {
+			res:    externalConfigMapResource("a", "pending", ""),
+			status: resourceStatusReady{},
+		},
+		"missing API": {
+			res: smith_v1.Resource{
+				Name: "a",
+				Spec: smith_v1.ResourceSpec{
+					External: &smith_v1.ExternalObject{APIVersion: "example.com/v1", Kind: "Database", Name: "db1"},
+				},
+			},
+			status: resourceStatusMissingAPI{gvk: schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Database"}},
+		},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			st := resourceSyncTask{
+				logger: zaptest.NewLogger(t),
+				rc:     fakeReadyChecker{},
+				store:  multi,
+				bundle: &smith_v1.Bundle{
+					ObjectMeta: meta_v1.ObjectMeta{Namespace: defaultNamespace, Name: "b1"},
+				},
+				processedResources: make(map[smith_v1.ResourceName]*resourceInfo),
+			}
+			resInfo := st.processResource(&tc.res)
+			assert.Equal(t, tc.status, resInfo.status)
+		})
+	}
+}
+
+func TestExternalObjectBlocksDependents(t *testing.T) {
+	t.Parallel()
+	st := resourceSyncTask{
+		logger: zap.NewNop(),
+		rc:     fakeReadyChecker{},
+		store:  specSourceStore(t),
+		bundle: &smith_v1.Bundle{
+			ObjectMeta: meta_v1.ObjectMeta{Namespace: defaultNamespace, Name: "b1"},
+		},
+		processedResources: make(map[smith_v1.ResourceName]*resourceInfo),
+	}
+	external := externalConfigMapResource("a", "missing", "")
+	resInfo := st.processResource(&external)
+	st.processedResources[external.Name] = &resInfo
+
+	dependent := smith_v1.Resource{
+		Name:       "b",
+		References: []smith_v1.Reference{{Resource: "a"}},
+		Spec: smith_v1.ResourceSpec{
+			Object: orderedDeletionConfigMap("b"),
+		},
+	}
+	resInfo = st.processResource(&dependent)
+	assert.Equal(t, resourceStatusDependenciesNotReady{dependencies: []smith_v1.ResourceName{"a"}}, resInfo.status)
+}
+
+func TestExternalObjectIsNotOwner(t *testing.T) {
+	t.Parallel()
+	platformConfig := orderedDeletionConfigMap("platform-config")
+	st := resourceSyncTask{
+		logger: zap.NewNop(),
+		rc:     fakeReadyChecker{},
+		store:  specSourceStore(t, platformConfig),
+		bundle: &smith_v1.Bundle{
+			ObjectMeta: meta_v1.ObjectMeta{Namespace: defaultNamespace, Name: "b1", UID: "b1-uid"},
+		},
+		processedResources: make(map[smith_v1.ResourceName]*resourceInfo),
+	}
+	external := externalConfigMapResource("a", "platform-config", "")
+	resInfo := st.processResource(&external)
+	require.True(t, resInfo.isReady())
+	st.processedResources[external.Name] = &resInfo
+
+	dependent := smith_v1.Resource{
+		Name:       "b",
+		References: []smith_v1.Reference{{Resource: "a"}},
+		Spec: smith_v1.ResourceSpec{
+			Object: orderedDeletionConfigMap("b"),
+		},
+	}
+	spec, err := st.evalSpec(&dependent, nil)
+	require.NoError(t, err)
+	refs := spec.GetOwnerReferences()
+	require.Len(t, refs, 1)
+	assert.Equal(t, "b1", refs[0].Name)
+}
+
+func TestExternalObjectEventHandler(t *testing.T) {
+	t.Parallel()
+	bundleInf := cache.NewSharedIndexInformer(&cache.ListWatch{}, &smith_v1.Bundle{}, 0, cache.Indexers{})
+	bs, err := store.NewBundle(bundleInf, store.NewMulti(), nil)
+	require.NoError(t, err)
+	require.NoError(t, bundleInf.GetStore().Add(&smith_v1.Bundle{
+		ObjectMeta: meta_v1.ObjectMeta{Namespace: "ns", Name: "b1"},
+		Spec: smith_v1.BundleSpec{
+			Resources: []smith_v1.Resource{
+				externalConfigMapResource("a", "platform-config", ""),
+			},
+		},
+	}))
+	queue := &fakeWorkQueue{}
+	handler := &watchedObjectEventHandler{
+		logger:     zaptest.NewLogger(t),
+		workQueue:  queue,
+		gk:         configMapGVK.GroupKind(),
+		getBundles: bs.GetBundlesByExternalObject,
+		dependency: "it waits for",
+	}
+
+	other := orderedDeletionConfigMap("other")
+	other.Namespace = "ns"
+	handler.OnAdd(other)
+	assert.Empty(t, queue.added)
+
+	cm := orderedDeletionConfigMap("platform-config")
+	cm.Namespace = "ns"
+	handler.OnAdd(cm)
+	assert.Equal(t, []ctrl.QueueKey{{Namespace: "ns", Name: "b1"}}, queue.added)
+}
@@ -0,0 +1,143 @@
+package bundlec
+
+import (
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Objects of resources without metadata.name are named by the server from metadata.generateName when they are
+// created. The generated name is recorded in the objectName field of the resource status and used to get and update
+// the object afterwards. Objects are annotated with the name of their resource so that the generated name can be
+// found again if the status was not updated, e.g. because the controller was restarted right after the object
+// was created.
+
+// generatedNameKey identifies the object of a resource with a generated name.
+type generatedNameKey struct {
+	schema.GroupKind
+	namespace string
+	resource  smith_v1.ResourceName
+}
+
+// hasGeneratedName returns true if the name of the object of the resource is generated by the server.
+func hasGeneratedName(res *smith_v1.Resource) bool {
+	if res.Spec.Object == nil {
+		return false
+	}
+	m := res.Spec.Object.(meta_v1.Object)
+	return m.GetName() == "" && m.GetGenerateName() != ""
+}
+
+// resolveGeneratedNames returns resources with names of objects generated from metadata.generateName set to
+// the generated names. Names are read from the status of the Bundle or, if they are not recorded there, from
+// annotations of objects of the Bundle. Names of objects that have not been created yet are left empty.
+// Objects of all resources with generated names are annotated with the names of their resources.
+// Resources are not mutated, changed resources are copied.
+func (st *bundleSyncTask) resolveGeneratedNames(resources []smith_v1.Resource) ([]smith_v1.Resource, error) {
+	var result []smith_v1.Resource
+	var annotated map[generatedNameKey]meta_v1.Object
+	for i := range resources {
+		res := &resources[i]
+		if !hasGeneratedName(res) {
+			continue
+		}
+		if result == nil {
+			result = make([]smith_v1.Resource, len(resources))
+			copy(result, resources)
+		}
+		obj := res.Spec.Object.DeepCopyObject()
+		m := obj.(meta_v1.Object)
+		annotations := make(map[string]string, len(m.GetAnnotations())+1)
+		for k, v := range m.GetAnnotations() {
+			annotations[k] = v
+		}
+		annotations[smith.ResourceAnnotation] = string(res.Name)
+		m.SetAnnotations(annotations)
+
+		var name string
+		if _, status := st.bundle.Status.GetResourceStatus(res.Name); status != nil {
+			name = status.ObjectName
+		}
+		if name == "" {
+			if annotated == nil {
+				var err error
+				annotated, err = st.annotatedObjects()
+				if err != nil {
+					return nil, err
+				}
+			}
+			key := generatedNameKey{
+				GroupKind: obj.GetObjectKind().GroupVersionKind().GroupKind(),
+				namespace: resourceNamespace(res, st.bundle),
+				resource:  res.Name,
+			}
+			if actual, ok := annotated[key]; ok {
+				name = actual.GetName()
+				st.logger.Sugar().Infof("Found object %q of resource %q with a generated name by annotation", name, res.Name)
+			}
+		}
+		m.SetName(name)
+		result[i].Spec.Object = obj
+		if st.generatedNames == nil {
+			st.generatedNames = make(map[smith_v1.ResourceName]string)
+		}
+		st.generatedNames[res.Name] = name
+	}
+	if result == nil {
+		return resources, nil
+	}
+	return result, nil
+}
+
+// annotatedObjects returns objects of the Bundle annotated with names of their resources.
+// If there are several objects for a resource, the oldest one is returned.
+func (st *bundleSyncTask) annotatedObjects() (map[generatedNameKey]meta_v1.Object, error) {
+	objs, err := st.childObjects()
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[generatedNameKey]meta_v1.Object)
+	for _, obj := range objs {
+		m := obj.(meta_v1.Object)
+		resName, ok := m.GetAnnotations()[smith.ResourceAnnotation]
+		if !ok {
+			continue
+		}
+		ref := st.objectRefOf(obj)
+		key := generatedNameKey{
+			GroupKind: ref.GroupKind(),
+			namespace: ref.Namespace,
+			resource:  smith_v1.ResourceName(resName),
+		}
+		if existing, ok := result[key]; ok {
+			existingTime := existing.GetCreationTimestamp()
+			creationTime := m.GetCreationTimestamp()
+			if existingTime.Before(&creationTime) || existingTime.Equal(&creationTime) && existing.GetName() < m.GetName() {
+				continue
+			}
+		}
+		result[key] = m
+	}
+	return result, nil
+}
+
+// generatedObjectName returns the name of the object of the resource to record in its status.
+// The name is only recorded for resources with generated names.
+func (st *bundleSyncTask) generatedObjectName(resName smith_v1.ResourceName, oldStatus *smith_v1.ResourceStatus) string {
+	if st.resolvedResources == nil {
+		// Resources were not resolved, e.g. because a spec source is missing. Keep what is known.
+		if oldStatus != nil {
+			return oldStatus.ObjectName
+		}
+		return ""
+	}
+	name, ok := st.generatedNames[resName]
+	if !ok {
+		return ""
+	}
+	if resInfo, ok := st.processedResources[resName]; ok && resInfo.actual != nil {
+		return resInfo.actual.GetName()
+	}
+	return name
+}
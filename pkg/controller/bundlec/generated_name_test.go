@@ -0,0 +1,202 @@
+package bundlec
+
+import (
+	"testing"
+	"time"
+
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func generatedNameBundle(objectName string) *smith_v1.Bundle {
+	bundle := &smith_v1.Bundle{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "bundle1",
+			Namespace: "ns1",
+			UID:       "uid1",
+		},
+		Spec: smith_v1.BundleSpec{
+			Resources: []smith_v1.Resource{
+				{
+					Name: "cm",
+					Spec: smith_v1.ResourceSpec{
+						Object: &core_v1.ConfigMap{
+							TypeMeta: meta_v1.TypeMeta{
+								Kind:       "ConfigMap",
+								APIVersion: core_v1.SchemeGroupVersion.String(),
+							},
+							ObjectMeta: meta_v1.ObjectMeta{
+								GenerateName: "cm-",
+							},
+						},
+					},
+				},
+				{
+					Name: "fixed",
+					Spec: smith_v1.ResourceSpec{
+						Object: crossNamespaceConfigMap("", "fixed", nil),
+					},
+				},
+			},
+		},
+	}
+	if objectName != "" {
+		bundle.Status.ResourceStatuses = []smith_v1.ResourceStatus{
+			{Name: "cm", ObjectName: objectName},
+		}
+	}
+	return bundle
+}
+
+func generatedNameConfigMap(name, resName string, created time.Time) runtime.Object {
+	cm := crossNamespaceConfigMap("ns1", name, nil)
+	cm.CreationTimestamp = meta_v1.NewTime(created)
+	if resName != "" {
+		cm.Annotations = map[string]string{smith.ResourceAnnotation: resName}
+	}
+	return cm
+}
+
+func TestResolveGeneratedNames(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	testcases := map[string]struct {
+		objectName string
+		objs       []runtime.Object
+		name       string
+	}{
+		"from status": {
+			objectName: "cm-abcde",
+			name:       "cm-abcde",
+		},
+		"from annotation": {
+			objs: []runtime.Object{
+				generatedNameConfigMap("cm-newer", "cm", now),
+				generatedNameConfigMap("cm-older", "cm", now.Add(-time.Minute)),
+				generatedNameConfigMap("cm-other", "other", now.Add(-time.Hour)),
+			},
+			name: "cm-older",
+		},
+		"not created": {
+			objs: []runtime.Object{
+				generatedNameConfigMap("fixed", "", now),
+			},
+		},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			bundle := generatedNameBundle(tc.objectName)
+			st := &bundleSyncTask{
+				logger: zap.NewNop(),
+				store: fakeStore{
+					controlled: map[types.UID][]runtime.Object{
+						"uid1": tc.objs,
+					},
+				},
+				bundle: bundle,
+			}
+			resources, err := st.resources()
+			require.NoError(t, err)
+			require.Len(t, resources, 2)
+			m := resources[0].Spec.Object.(meta_v1.Object)
+			assert.Equal(t, tc.name, m.GetName())
+			assert.Equal(t, "cm-", m.GetGenerateName())
+			assert.Equal(t, map[string]string{smith.ResourceAnnotation: "cm"}, m.GetAnnotations())
+			assert.Equal(t, map[smith_v1.ResourceName]string{"cm": tc.name}, st.generatedNames)
+			// Resources with fixed names and the Bundle are not changed
+			assert.Equal(t, bundle.Spec.Resources[1], resources[1])
+			original := bundle.Spec.Resources[0].Spec.Object.(meta_v1.Object)
+			assert.Empty(t, original.GetName())
+			assert.Empty(t, original.GetAnnotations())
+		})
+	}
+}
+
+func TestGeneratedObjectName(t *testing.T) {
+	t.Parallel()
+	oldStatus := &smith_v1.ResourceStatus{Name: "cm", ObjectName: "cm-abcde"}
+	actual := &unstructured.Unstructured{}
+	actual.SetName("cm-fghij")
+
+	st := &bundleSyncTask{}
+	assert.Equal(t, "cm-abcde", st.generatedObjectName("cm", oldStatus), "resources were not resolved")
+
+	st = &bundleSyncTask{
+		resolvedResources: []smith_v1.Resource{},
+		generatedNames:    map[smith_v1.ResourceName]string{"cm": ""},
+		processedResources: map[smith_v1.ResourceName]*resourceInfo{
+			"cm": {actual: actual},
+		},
+	}
+	assert.Equal(t, "cm-fghij", st.generatedObjectName("cm", oldStatus), "object was created")
+	assert.Empty(t, st.generatedObjectName("fixed", nil), "name is not generated")
+}
+
+func TestFindObjectsToDeleteGeneratedNames(t *testing.T) {
+	t.Parallel()
+	tr := true
+	controlled := func(name, resName string) runtime.Object {
+		cm := generatedNameConfigMap(name, resName, time.Now()).(*core_v1.ConfigMap)
+		cm.OwnerReferences = []meta_v1.OwnerReference{
+			{
+				APIVersion: smith_v1.BundleResourceGroupVersion,
+				Kind:       smith_v1.BundleResourceKind,
+				Name:       "bundle1",
+				UID:        "uid1",
+				Controller: &tr,
+			},
+		}
+		return cm
+	}
+	objs := []runtime.Object{
+		controlled("cm-abcde", "cm"),
+		controlled("cm-fghij", "cm"),
+		controlled("fixed", ""),
+		controlled("removed", ""),
+	}
+	names := func(st *bundleSyncTask) []string {
+		var result []string
+		for ref := range st.objectsToDelete {
+			result = append(result, ref.Name)
+		}
+		return result
+	}
+
+	// Extra objects of a resource are pruned once its name is known
+	st := &bundleSyncTask{
+		logger: zap.NewNop(),
+		store: fakeStore{
+			controlled: map[types.UID][]runtime.Object{
+				"uid1": objs,
+			},
+		},
+		bundle: generatedNameBundle("cm-abcde"),
+	}
+	require.NoError(t, st.findObjectsToDelete())
+	assert.ElementsMatch(t, []string{"cm-fghij", "removed"}, names(st))
+
+	// Objects of a resource are not pruned while its name is not known
+	bundle := generatedNameBundle("")
+	st = &bundleSyncTask{
+		logger: zap.NewNop(),
+		store: fakeStore{
+			controlled: map[types.UID][]runtime.Object{
+				"uid1": objs,
+			},
+		},
+		bundle:            bundle,
+		resolvedResources: bundle.Spec.Resources,
+	}
+	require.NoError(t, st.findObjectsToDelete())
+	assert.ElementsMatch(t, []string{"removed"}, names(st))
+}
@@ -0,0 +1,87 @@
+package bundlec
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/atlassian/smith/pkg/util/graph"
+)
+
+// stateColors are fill colors of resources in the dependency graph by state.
+var stateColors = map[smith_v1.ResourceState]string{
+	smith_v1.ResourceStateReady:      "palegreen",
+	smith_v1.ResourceStateInProgress: "lightgoldenrod",
+	smith_v1.ResourceStateBlocked:    "orange",
+	smith_v1.ResourceStateError:      "salmon",
+	smith_v1.ResourceStateUnknown:    "lightgrey",
+}
+
+// WriteBundleDOT renders the dependency graph of the Bundle in the Graphviz DOT format.
+// Resources are labeled and colored with their state from the status of the Bundle, edges point from resources to
+// the resources they depend on. References to external secret stores are not dependencies.
+func WriteBundleDOT(w io.Writer, bundle *smith_v1.Bundle) error {
+	g := graph.NewGraph(len(bundle.Spec.Resources))
+	for _, res := range bundle.Spec.Resources {
+		g.AddVertex(graph.V(res.Name), nil)
+	}
+	for _, res := range bundle.Spec.Resources {
+		deps, err := resourceDependencies(res, g)
+		if err != nil {
+			return err
+		}
+		for _, dep := range deps {
+			if err := g.AddEdge(res.Name, dep); err != nil {
+				return err
+			}
+		}
+	}
+	return g.WriteDOT(w, bundle.Namespace+"/"+bundle.Name, func(name graph.V, data graph.D) graph.Attributes {
+		state := smith_v1.ResourceStateUnknown
+		if _, resStatus := bundle.Status.GetResourceStatus(name.(smith_v1.ResourceName)); resStatus != nil && resStatus.State != "" {
+			state = resStatus.State
+		}
+		return graph.Attributes{
+			"label":     string(name.(smith_v1.ResourceName)) + "\n" + string(state),
+			"style":     "filled",
+			"fillcolor": stateColors[state],
+		}
+	})
+}
+
+// GraphHandler serves dependency graphs of Bundles in the DOT format.
+type GraphHandler struct {
+	BundleStore BundleStore
+}
+
+// ServeHTTP serves the graph of the Bundle identified by the namespace and name query parameters.
+func (h *GraphHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	params := r.URL.Query()
+	namespace, name := params.Get("namespace"), params.Get("name")
+	if namespace == "" || name == "" {
+		http.Error(w, "namespace and name query parameters are required", http.StatusBadRequest)
+		return
+	}
+	bundle, err := h.BundleStore.Get(namespace, name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if bundle == nil {
+		http.Error(w, "Bundle not found", http.StatusNotFound)
+		return
+	}
+	var buf bytes.Buffer
+	if err = WriteBundleDOT(&buf, bundle); err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	w.Header().Set("Content-Type", "text/vnd.graphviz")
+	w.Write(buf.Bytes()) // Nothing can be done if write fails
+}
@@ -0,0 +1,109 @@
+package bundlec
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeBundleStore struct {
+	BundleStore
+	bundles map[string]*smith_v1.Bundle
+}
+
+func (s fakeBundleStore) Get(namespace, bundleName string) (*smith_v1.Bundle, error) {
+	return s.bundles[namespace+"/"+bundleName], nil
+}
+
+func graphBundle() *smith_v1.Bundle {
+	return &smith_v1.Bundle{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Namespace: "ns",
+			Name:      "b",
+		},
+		Spec: smith_v1.BundleSpec{
+			Resources: []smith_v1.Resource{
+				{
+					Name: "db",
+				},
+				{
+					Name: "app",
+					References: []smith_v1.Reference{
+						{Resource: "db"},
+						{Name: "password", Source: "vault:secret/db#password"},
+					},
+					Quorums: []smith_v1.Quorum{
+						{Resources: []smith_v1.ResourceName{"db", "cache"}, MinReady: 1},
+					},
+				},
+				{
+					Name: "cache",
+				},
+			},
+		},
+		Status: smith_v1.BundleStatus{
+			ResourceStatuses: []smith_v1.ResourceStatus{
+				{Name: "db", State: smith_v1.ResourceStateReady},
+				{Name: "app", State: smith_v1.ResourceStateBlocked},
+			},
+		},
+	}
+}
+
+func TestWriteBundleDOT(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	require.NoError(t, WriteBundleDOT(&buf, graphBundle()))
+	assert.Equal(t, `digraph "ns/b" {
+	"db" ["fillcolor"="palegreen", "label"="db\nReady", "style"="filled"];
+	"app" ["fillcolor"="orange", "label"="app\nBlocked", "style"="filled"];
+	"cache" ["fillcolor"="lightgrey", "label"="cache\nUnknown", "style"="filled"];
+	"app" -> "cache";
+	"app" -> "db";
+}
+`, buf.String())
+}
+
+func TestWriteBundleDOTMissingDependency(t *testing.T) {
+	t.Parallel()
+	bundle := graphBundle()
+	bundle.Spec.Resources = bundle.Spec.Resources[:2]
+	var buf bytes.Buffer
+	err := WriteBundleDOT(&buf, bundle)
+	require.EqualError(t, err, `resource "app" has non-existent resource "cache" in a quorum`)
+}
+
+func TestGraphHandler(t *testing.T) {
+	t.Parallel()
+	h := &GraphHandler{
+		BundleStore: fakeBundleStore{
+			bundles: map[string]*smith_v1.Bundle{
+				"ns/b": graphBundle(),
+			},
+		},
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/graph?namespace=ns&name=b", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/vnd.graphviz", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), `"app" -> "db";`)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/graph?namespace=ns&name=other", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/graph?namespace=ns", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/debug/graph?namespace=ns&name=b", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
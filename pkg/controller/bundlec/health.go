@@ -0,0 +1,151 @@
+package bundlec
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/atlassian/smith/pkg/resources"
+	"github.com/pkg/errors"
+	apiext_v1b1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+)
+
+// HealthChecker tells if the controller is ready to process Bundles and if its workers are making progress.
+type HealthChecker struct {
+	// hasSynced returns true if all informers of the controller have synced.
+	hasSynced func() bool
+	// getCrd gets a CRD by name from the CRD informer's store.
+	getCrd func(name string) (interface{}, bool, error)
+	// listBundles lists Bundles known to the controller, e.g. the List method of the Bundle informer's store.
+	listBundles func() []interface{}
+	// stuckTimeout is how long a sync may run and how long pending Bundles may wait for a sync to finish before
+	// the controller is considered stuck.
+	stuckTimeout time.Duration
+	now          func() time.Time
+
+	mx      sync.Mutex
+	running bool
+	// inFlight are start times of syncs that are being processed right now.
+	inFlight     map[uint64]time.Time
+	nextSync     uint64
+	lastProgress time.Time
+}
+
+func NewHealthChecker(hasSynced func() bool, getCrd func(name string) (interface{}, bool, error), listBundles func() []interface{}, stuckTimeout time.Duration) *HealthChecker {
+	return &HealthChecker{
+		hasSynced:    hasSynced,
+		getCrd:       getCrd,
+		listBundles:  listBundles,
+		stuckTimeout: stuckTimeout,
+		now:          time.Now,
+		inFlight:     make(map[uint64]time.Time),
+	}
+}
+
+// started records that the controller has started processing Bundles.
+func (h *HealthChecker) started() {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	h.running = true
+	h.lastProgress = h.now()
+}
+
+// syncStarted records the start of a sync. The returned function must be called once the sync is finished.
+func (h *HealthChecker) syncStarted() func() {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	id := h.nextSync
+	h.nextSync++
+	h.inFlight[id] = h.now()
+	return func() {
+		h.mx.Lock()
+		defer h.mx.Unlock()
+		delete(h.inFlight, id)
+		h.lastProgress = h.now()
+	}
+}
+
+// Ready returns an error if the controller has not started yet, informers have not synced or the Bundle CRD is not
+// established.
+func (h *HealthChecker) Ready() error {
+	h.mx.Lock()
+	running := h.running
+	h.mx.Unlock()
+	if !running {
+		return errors.New("controller has not started")
+	}
+	if !h.hasSynced() {
+		return errors.New("informers have not synced")
+	}
+	obj, exists, err := h.getCrd(smith_v1.BundleResourceName)
+	if err != nil {
+		return errors.Wrap(err, "failed to get Bundle CRD")
+	}
+	if !exists {
+		return errors.Errorf("CRD %s does not exist", smith_v1.BundleResourceName)
+	}
+	if !resources.IsCrdConditionTrue(obj.(*apiext_v1b1.CustomResourceDefinition), apiext_v1b1.Established) {
+		return errors.Errorf("CRD %s is not established", smith_v1.BundleResourceName)
+	}
+	return nil
+}
+
+// Healthy returns an error if a worker is stuck: a sync has been running for longer than the stuck timeout or
+// there are Bundles waiting to be processed and no sync has finished within the stuck timeout.
+// Zero stuck timeout disables the check.
+func (h *HealthChecker) Healthy() error {
+	if h.stuckTimeout <= 0 {
+		return nil
+	}
+	pending := false
+	for _, obj := range h.listBundles() {
+		if isPendingBundle(obj.(*smith_v1.Bundle)) {
+			pending = true
+			break
+		}
+	}
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	if !h.running {
+		return nil
+	}
+	now := h.now()
+	for _, start := range h.inFlight {
+		if since := now.Sub(start); since > h.stuckTimeout {
+			return errors.Errorf("a sync has been running for %s", since)
+		}
+	}
+	if since := now.Sub(h.lastProgress); pending && since > h.stuckTimeout {
+		return errors.Errorf("no sync has finished for %s while Bundles are pending", since)
+	}
+	return nil
+}
+
+// ReadyHandler serves the readiness of the controller.
+func (h *HealthChecker) ReadyHandler() http.Handler {
+	return healthHandler(h.Ready)
+}
+
+// HealthyHandler serves the health of the controller.
+func (h *HealthChecker) HealthyHandler() http.Handler {
+	return healthHandler(h.Healthy)
+}
+
+// healthHandler responds with 200 if the check passes and with 503 and the error otherwise.
+type healthHandler func() error
+
+func (f healthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if err := f(); err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(err.Error())) // Nothing can be done if write fails
+		return
+	}
+	w.Write([]byte("ok")) // Nothing can be done if write fails
+}
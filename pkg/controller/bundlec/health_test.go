@@ -0,0 +1,94 @@
+package bundlec
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/stretchr/testify/assert"
+	apiext_v1b1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestHealthCheckerReady(t *testing.T) {
+	t.Parallel()
+	synced := false
+	var crd *apiext_v1b1.CustomResourceDefinition
+	h := NewHealthChecker(func() bool {
+		return synced
+	}, func(name string) (interface{}, bool, error) {
+		assert.Equal(t, smith_v1.BundleResourceName, name)
+		if crd == nil {
+			return nil, false, nil
+		}
+		return crd, true, nil
+	}, nil, 0)
+
+	assert.EqualError(t, h.Ready(), "controller has not started")
+	h.started()
+	assert.EqualError(t, h.Ready(), "informers have not synced")
+	synced = true
+	assert.EqualError(t, h.Ready(), "CRD bundles.smith.atlassian.com does not exist")
+	crd = &apiext_v1b1.CustomResourceDefinition{}
+	assert.EqualError(t, h.Ready(), "CRD bundles.smith.atlassian.com is not established")
+	crd.Status.Conditions = []apiext_v1b1.CustomResourceDefinitionCondition{
+		{Type: apiext_v1b1.Established, Status: apiext_v1b1.ConditionTrue},
+	}
+	assert.NoError(t, h.Ready())
+}
+
+func TestHealthCheckerHealthy(t *testing.T) {
+	t.Parallel()
+	var bundles []interface{}
+	h := NewHealthChecker(nil, nil, func() []interface{} {
+		return bundles
+	}, time.Minute)
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	h.now = func() time.Time {
+		return now
+	}
+	h.started()
+
+	// Sync running for too long
+	finished := h.syncStarted()
+	now = now.Add(2 * time.Minute)
+	assert.EqualError(t, h.Healthy(), "a sync has been running for 2m0s")
+	finished()
+	assert.NoError(t, h.Healthy())
+
+	// Idle controller is healthy
+	now = now.Add(2 * time.Minute)
+	assert.NoError(t, h.Healthy())
+
+	// Pending Bundles and no progress
+	bundles = []interface{}{
+		&smith_v1.Bundle{
+			ObjectMeta: meta_v1.ObjectMeta{Generation: 2},
+			Status:     smith_v1.BundleStatus{ObservedGeneration: 1},
+		},
+	}
+	assert.EqualError(t, h.Healthy(), "no sync has finished for 2m0s while Bundles are pending")
+	h.syncStarted()()
+	assert.NoError(t, h.Healthy())
+}
+
+func TestHealthHandler(t *testing.T) {
+	t.Parallel()
+	h := NewHealthChecker(nil, nil, nil, 0)
+
+	rec := httptest.NewRecorder()
+	h.ReadyHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "controller has not started", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	h.HealthyHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "ok", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	h.HealthyHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/healthz", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
@@ -0,0 +1,44 @@
+package bundlec
+
+import (
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// applyIdentityPolicies sets annotations from all policies that match the object's API group.
+// It is an error if the object already has one of those annotations with a different value.
+func applyIdentityPolicies(policies []smith_v1.IdentityPolicy, obj *unstructured.Unstructured) error {
+	group := obj.GroupVersionKind().Group
+	var annotations map[string]string
+	for _, policy := range policies {
+		if !containsString(policy.Groups, group) {
+			continue
+		}
+		if annotations == nil {
+			annotations = obj.GetAnnotations()
+			if annotations == nil {
+				annotations = make(map[string]string, len(policy.Annotations))
+			}
+		}
+		for key, value := range policy.Annotations {
+			if existing, ok := annotations[key]; ok && existing != value {
+				return errors.Errorf("annotation %q is set to %q but identity policy requires %q", key, existing, value)
+			}
+			annotations[key] = value
+		}
+	}
+	if annotations != nil {
+		obj.SetAnnotations(annotations)
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package bundlec
+
+import (
+	"testing"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestApplyIdentityPolicies(t *testing.T) {
+	t.Parallel()
+	policies := []smith_v1.IdentityPolicy{
+		{
+			Groups:      []string{"iam.cnrm.cloud.google.com", "sql.cnrm.cloud.google.com"},
+			Annotations: map[string]string{"cnrm.cloud.google.com/project-id": "p1"},
+		},
+	}
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("sql.cnrm.cloud.google.com/v1beta1")
+	obj.SetKind("SQLInstance")
+	obj.SetAnnotations(map[string]string{"a": "b"})
+
+	require.NoError(t, applyIdentityPolicies(policies, obj))
+	assert.Equal(t, map[string]string{"a": "b", "cnrm.cloud.google.com/project-id": "p1"}, obj.GetAnnotations())
+}
+
+func TestApplyIdentityPoliciesIgnoresOtherGroups(t *testing.T) {
+	t.Parallel()
+	policies := []smith_v1.IdentityPolicy{
+		{
+			Groups:      []string{"iam.cnrm.cloud.google.com"},
+			Annotations: map[string]string{"cnrm.cloud.google.com/project-id": "p1"},
+		},
+	}
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("ConfigMap")
+
+	require.NoError(t, applyIdentityPolicies(policies, obj))
+	assert.Empty(t, obj.GetAnnotations())
+}
+
+func TestApplyIdentityPoliciesConflict(t *testing.T) {
+	t.Parallel()
+	policies := []smith_v1.IdentityPolicy{
+		{
+			Groups:      []string{"iam.cnrm.cloud.google.com"},
+			Annotations: map[string]string{"cnrm.cloud.google.com/project-id": "p1"},
+		},
+	}
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("iam.cnrm.cloud.google.com/v1beta1")
+	obj.SetKind("IAMServiceAccount")
+	obj.SetAnnotations(map[string]string{"cnrm.cloud.google.com/project-id": "p2"})
+
+	err := applyIdentityPolicies(policies, obj)
+	require.EqualError(t, err, `annotation "cnrm.cloud.google.com/project-id" is set to "p2" but identity policy requires "p1"`)
+}
@@ -0,0 +1,31 @@
+package bundlec
+
+import (
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/atlassian/smith/pkg/speccheck"
+	"github.com/atlassian/smith/pkg/util"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ignoreFields takes the values of the fields the resource ignores from the actual object, so that the object is not
+// updated when only they differ from the spec. Actual may be nil, then the spec is used to create the object as is.
+func ignoreFields(res *smith_v1.Resource, spec *unstructured.Unstructured, actual runtime.Object) (*unstructured.Unstructured, error) {
+	if len(res.IgnoreFields) == 0 || actual == nil {
+		return spec, nil
+	}
+	fields := make([][]string, 0, len(res.IgnoreFields))
+	for _, path := range res.IgnoreFields {
+		field, err := speccheck.ParseFieldPath(path)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid ignored field")
+		}
+		fields = append(fields, field)
+	}
+	actualUnstr, err := util.RuntimeToUnstructured(actual)
+	if err != nil {
+		return nil, err
+	}
+	return speccheck.IgnoreFields(spec, actualUnstr, fields), nil
+}
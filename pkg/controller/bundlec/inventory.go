@@ -0,0 +1,245 @@
+package bundlec
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/atlassian/smith/pkg/plugin"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// InventoryKind is a kind of objects defined by Bundles.
+type InventoryKind struct {
+	Group   string `json:"group"`
+	Version string `json:"version"`
+	Kind    string `json:"kind"`
+}
+
+// InventoryEntry describes a Bundle in the inventory.
+type InventoryEntry struct {
+	Namespace string                   `json:"namespace"`
+	Name      string                   `json:"name"`
+	Labels    map[string]string        `json:"labels,omitempty"`
+	Ready     smith_v1.ConditionStatus `json:"ready,omitempty"`
+	// Kinds of objects defined by the Bundle, either directly or via plugins. Sorted.
+	Kinds []InventoryKind `json:"kinds,omitempty"`
+	// Plugins used by the Bundle. Sorted.
+	Plugins []smith_v1.PluginName `json:"plugins,omitempty"`
+}
+
+// InventoryQuery selects Bundles from the inventory. Empty fields match all Bundles.
+type InventoryQuery struct {
+	Namespace string
+	Selector  labels.Selector
+	Ready     smith_v1.ConditionStatus
+	// Group, Version and Kind match Bundles that define objects of a matching kind.
+	Group   string
+	Version string
+	Kind    string
+	Plugin  smith_v1.PluginName
+}
+
+// Inventory is a view of all Bundles known to the controller by namespace, readiness, kinds of objects and plugins
+// they use. It answers questions like "which Bundles still define v1beta1 Ingresses?" from the informer cache
+// without querying the API server.
+type Inventory struct {
+	// listBundles lists Bundles known to the controller, e.g. the List method of the Bundle informer's store.
+	listBundles      func() []interface{}
+	pluginContainers map[smith_v1.PluginName]plugin.PluginContainer
+
+	kindBundlesDesc   *prometheus.Desc
+	pluginBundlesDesc *prometheus.Desc
+}
+
+func NewInventory(listBundles func() []interface{}, pluginContainers map[smith_v1.PluginName]plugin.PluginContainer) *Inventory {
+	return &Inventory{
+		listBundles:      listBundles,
+		pluginContainers: pluginContainers,
+		kindBundlesDesc: prometheus.NewDesc("smith_inventory_kind_bundles",
+			"Number of Bundles that define objects of a kind", []string{"group", "version", "kind"}, nil),
+		pluginBundlesDesc: prometheus.NewDesc("smith_inventory_plugin_bundles",
+			"Number of Bundles that use a plugin", []string{"plugin"}, nil),
+	}
+}
+
+// RegisterMetrics registers metrics of the inventory with the registerer.
+// Metrics have a label per kind and plugin in use so they are opt-in.
+func (inv *Inventory) RegisterMetrics(registerer prometheus.Registerer) error {
+	return errors.WithStack(registerer.Register(inv))
+}
+
+// Describe implements prometheus.Collector.
+func (inv *Inventory) Describe(ch chan<- *prometheus.Desc) {
+	ch <- inv.kindBundlesDesc
+	ch <- inv.pluginBundlesDesc
+}
+
+// Collect implements prometheus.Collector.
+func (inv *Inventory) Collect(ch chan<- prometheus.Metric) {
+	kinds := make(map[InventoryKind]int)
+	plugins := make(map[smith_v1.PluginName]int)
+	for _, entry := range inv.List(InventoryQuery{}) {
+		for _, kind := range entry.Kinds {
+			kinds[kind]++
+		}
+		for _, pluginName := range entry.Plugins {
+			plugins[pluginName]++
+		}
+	}
+	for kind, count := range kinds {
+		ch <- prometheus.MustNewConstMetric(inv.kindBundlesDesc, prometheus.GaugeValue, float64(count), kind.Group, kind.Version, kind.Kind)
+	}
+	for pluginName, count := range plugins {
+		ch <- prometheus.MustNewConstMetric(inv.pluginBundlesDesc, prometheus.GaugeValue, float64(count), string(pluginName))
+	}
+}
+
+// List returns Bundles that match the query sorted by namespace and name.
+func (inv *Inventory) List(query InventoryQuery) []InventoryEntry {
+	var result []InventoryEntry
+	for _, obj := range inv.listBundles() {
+		bundle := obj.(*smith_v1.Bundle)
+		if query.Namespace != "" && bundle.Namespace != query.Namespace {
+			continue
+		}
+		if query.Selector != nil && !query.Selector.Matches(labels.Set(bundle.Labels)) {
+			continue
+		}
+		if query.Ready != "" && bundle.Status.Ready != query.Ready {
+			continue
+		}
+		entry := inv.entry(bundle)
+		if !entry.matches(query) {
+			continue
+		}
+		result = append(result, entry)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return result
+}
+
+func (inv *Inventory) entry(bundle *smith_v1.Bundle) InventoryEntry {
+	kindSet := make(map[InventoryKind]struct{}, len(bundle.Spec.Resources))
+	pluginSet := make(map[smith_v1.PluginName]struct{})
+	for _, res := range bundle.Spec.Resources {
+		var gvk schema.GroupVersionKind
+		if res.Spec.Object != nil {
+			gvk = res.Spec.Object.GetObjectKind().GroupVersionKind()
+		} else if res.Spec.Plugin != nil {
+			pluginSet[res.Spec.Plugin.Name] = struct{}{}
+			p, ok := inv.pluginContainers[res.Spec.Plugin.Name]
+			if !ok {
+				// Unknown plugin, the kind of its object cannot be determined
+				continue
+			}
+			gvk = p.Plugin.Describe().GVK
+		} else if res.Spec.External != nil {
+			gvk = res.Spec.External.GroupVersionKind()
+		} else {
+			// Invalid resource, ignore
+			continue
+		}
+		kindSet[InventoryKind{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind}] = struct{}{}
+	}
+	entry := InventoryEntry{
+		Namespace: bundle.Namespace,
+		Name:      bundle.Name,
+		Labels:    bundle.Labels,
+		Ready:     bundle.Status.Ready,
+	}
+	for kind := range kindSet {
+		entry.Kinds = append(entry.Kinds, kind)
+	}
+	sort.Slice(entry.Kinds, func(i, j int) bool {
+		a, b := entry.Kinds[i], entry.Kinds[j]
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+		if a.Version != b.Version {
+			return a.Version < b.Version
+		}
+		return a.Kind < b.Kind
+	})
+	for pluginName := range pluginSet {
+		entry.Plugins = append(entry.Plugins, pluginName)
+	}
+	sort.Slice(entry.Plugins, func(i, j int) bool {
+		return entry.Plugins[i] < entry.Plugins[j]
+	})
+	return entry
+}
+
+func (e *InventoryEntry) matches(query InventoryQuery) bool {
+	if query.Plugin != "" {
+		found := false
+		for _, pluginName := range e.Plugins {
+			if pluginName == query.Plugin {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if query.Group == "" && query.Version == "" && query.Kind == "" {
+		return true
+	}
+	for _, kind := range e.Kinds {
+		if (query.Group == "" || kind.Group == query.Group) &&
+			(query.Version == "" || kind.Version == query.Version) &&
+			(query.Kind == "" || kind.Kind == query.Kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// ServeHTTP serves Bundles that match the query as JSON.
+// Supported query parameters are namespace, labelSelector, ready, group, version, kind and plugin.
+func (inv *Inventory) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	params := r.URL.Query()
+	query := InventoryQuery{
+		Namespace: params.Get("namespace"),
+		Ready:     smith_v1.ConditionStatus(params.Get("ready")),
+		Group:     params.Get("group"),
+		Version:   params.Get("version"),
+		Kind:      params.Get("kind"),
+		Plugin:    smith_v1.PluginName(params.Get("plugin")),
+	}
+	if selector := params.Get("labelSelector"); selector != "" {
+		var err error
+		query.Selector, err = labels.Parse(selector)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	entries := inv.List(query)
+	if entries == nil {
+		entries = []InventoryEntry{}
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data) // Nothing can be done if write fails
+}
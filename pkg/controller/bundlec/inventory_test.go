@@ -0,0 +1,160 @@
+package bundlec
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/atlassian/smith/pkg/plugin"
+	"github.com/atlassian/smith/pkg/plugin/smoke"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func objectResource(name smith_v1.ResourceName, apiVersion, kind string) smith_v1.Resource {
+	return smith_v1.Resource{
+		Name: name,
+		Spec: smith_v1.ResourceSpec{
+			Object: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": apiVersion,
+					"kind":       kind,
+				},
+			},
+		},
+	}
+}
+
+func pluginResource(name smith_v1.ResourceName, pluginName smith_v1.PluginName) smith_v1.Resource {
+	return smith_v1.Resource{
+		Name: name,
+		Spec: smith_v1.ResourceSpec{
+			Plugin: &smith_v1.PluginSpec{
+				Name: pluginName,
+			},
+		},
+	}
+}
+
+func testInventory(t *testing.T) *Inventory {
+	pc, err := plugin.NewPluginContainer(smoke.NewConfigMap)
+	require.NoError(t, err)
+	bundles := []interface{}{
+		&smith_v1.Bundle{
+			ObjectMeta: meta_v1.ObjectMeta{Namespace: "ns2", Name: "legacy", Labels: map[string]string{"team": "a"}},
+			Spec: smith_v1.BundleSpec{
+				Resources: []smith_v1.Resource{
+					objectResource("ing", "extensions/v1beta1", "Ingress"),
+					objectResource("svc", "v1", "Service"),
+					pluginResource("unknown", "missing"),
+				},
+			},
+			Status: smith_v1.BundleStatus{Ready: smith_v1.ConditionTrue},
+		},
+		&smith_v1.Bundle{
+			ObjectMeta: meta_v1.ObjectMeta{Namespace: "ns1", Name: "current", Labels: map[string]string{"team": "b"}},
+			Spec: smith_v1.BundleSpec{
+				Resources: []smith_v1.Resource{
+					objectResource("ing", "networking.k8s.io/v1beta1", "Ingress"),
+					pluginResource("cm1", smoke.ConfigMapPluginName),
+					pluginResource("cm2", smoke.ConfigMapPluginName),
+				},
+			},
+			Status: smith_v1.BundleStatus{Ready: smith_v1.ConditionFalse},
+		},
+	}
+	return NewInventory(func() []interface{} {
+		return bundles
+	}, map[smith_v1.PluginName]plugin.PluginContainer{
+		smoke.ConfigMapPluginName: pc,
+	})
+}
+
+func TestInventoryList(t *testing.T) {
+	t.Parallel()
+	inv := testInventory(t)
+
+	entries := inv.List(InventoryQuery{})
+	require.Len(t, entries, 2)
+	assert.Equal(t, InventoryEntry{
+		Namespace: "ns1",
+		Name:      "current",
+		Labels:    map[string]string{"team": "b"},
+		Ready:     smith_v1.ConditionFalse,
+		Kinds: []InventoryKind{
+			{Group: "", Version: "v1", Kind: "ConfigMap"},
+			{Group: "networking.k8s.io", Version: "v1beta1", Kind: "Ingress"},
+		},
+		Plugins: []smith_v1.PluginName{smoke.ConfigMapPluginName},
+	}, entries[0])
+	assert.Equal(t, []smith_v1.PluginName{"missing"}, entries[1].Plugins)
+
+	entries = inv.List(InventoryQuery{Group: "extensions", Version: "v1beta1", Kind: "Ingress"})
+	require.Len(t, entries, 1)
+	assert.Equal(t, "legacy", entries[0].Name)
+
+	entries = inv.List(InventoryQuery{Kind: "Ingress"})
+	assert.Len(t, entries, 2)
+
+	entries = inv.List(InventoryQuery{Selector: labels.SelectorFromSet(labels.Set{"team": "b"})})
+	require.Len(t, entries, 1)
+	assert.Equal(t, "current", entries[0].Name)
+
+	entries = inv.List(InventoryQuery{Ready: smith_v1.ConditionTrue})
+	require.Len(t, entries, 1)
+	assert.Equal(t, "legacy", entries[0].Name)
+
+	entries = inv.List(InventoryQuery{Namespace: "ns1", Plugin: "missing"})
+	assert.Empty(t, entries)
+}
+
+func TestInventoryServeHTTP(t *testing.T) {
+	t.Parallel()
+	inv := testInventory(t)
+
+	w := httptest.NewRecorder()
+	inv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/inventory?labelSelector=team+in+(a)&version=v1beta1", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var entries []InventoryEntry
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &entries))
+	require.Len(t, entries, 1)
+	assert.Equal(t, "legacy", entries[0].Name)
+
+	w = httptest.NewRecorder()
+	inv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/inventory?plugin=none", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "[]", w.Body.String())
+
+	w = httptest.NewRecorder()
+	inv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/inventory?labelSelector=!!", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	inv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/debug/inventory", strings.NewReader("")))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestInventoryMetrics(t *testing.T) {
+	t.Parallel()
+	inv := testInventory(t)
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, inv.RegisterMetrics(registry))
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	counts := make(map[string]int)
+	for _, family := range families {
+		counts[family.GetName()] = len(family.GetMetric())
+	}
+	// Ingress (2 versions), Service, ConfigMap
+	assert.Equal(t, 4, counts["smith_inventory_kind_bundles"])
+	// missing, smoke-configmap
+	assert.Equal(t, 2, counts["smith_inventory_plugin_bundles"])
+}
@@ -0,0 +1,196 @@
+package bundlec
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	ctrlLogz "github.com/atlassian/ctrl/logz"
+	"github.com/atlassian/smith"
+	"github.com/atlassian/smith/pkg/client/smart"
+	"github.com/atlassian/smith/pkg/util"
+	"github.com/pkg/errors"
+	batch_v1 "k8s.io/api/batch/v1"
+	api_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	// jobSpecChecksumAnnotation holds a checksum of the spec the Job was created from.
+	jobSpecChecksumAnnotation = smith.Domain + "/jobSpecChecksum"
+)
+
+var jobGK = schema.GroupKind{Group: batch_v1.GroupName, Kind: "Job"}
+
+// isRunToCompletion returns true for kinds of objects that run to completion and cannot be updated in place.
+func isRunToCompletion(gk schema.GroupKind) bool {
+	return gk == jobGK
+}
+
+// syncJob creates a Job if it does not exist and re-runs it if its spec has changed.
+// The spec of a Job is never updated because its pod template is immutable, only labels and annotations are. A spec change is detected using a checksum
+// annotation rather than by comparing objects because the server populates many fields of a Job (selector, labels,
+// defaults). To re-run a Job it is deleted and created again on a subsequent iteration once the deletion is observed.
+func (st *resourceSyncTask) syncJob(spec *unstructured.Unstructured, actual runtime.Object) resourceInfo {
+	checksum, err := jobSpecChecksum(spec)
+	if err != nil {
+		return resourceInfo{
+			status: resourceStatusError{
+				err: err,
+			},
+		}
+	}
+	gvk := spec.GroupVersionKind()
+	resClient, err := st.smartClient.ForGVK(gvk, st.objectNamespace(spec))
+	if err != nil {
+		if smart.IsScopeMismatch(err) {
+			return resourceInfo{
+				status: scopeMismatch(gvk, err),
+			}
+		}
+		return resourceInfo{
+			status: resourceStatusError{
+				err: errors.Wrapf(err, "failed to get the client for %q", gvk),
+			},
+		}
+	}
+
+	if actual == nil {
+		spec.SetAnnotations(withJobSpecChecksum(spec.GetAnnotations(), checksum))
+		st.logger.Info("Job not found, creating", ctrlLogz.Object(spec))
+		created, retriable, err := st.createResource(resClient, spec)
+		if err != nil {
+			return resourceInfo{
+				status: resourceStatusError{
+					err:              err,
+					isRetriableError: retriable,
+				},
+			}
+		}
+		return st.checkReadiness(created)
+	}
+
+	actualUnstr, err := util.RuntimeToUnstructured(actual)
+	if err != nil {
+		return resourceInfo{
+			status: resourceStatusError{
+				err: err,
+			},
+		}
+	}
+	if actualUnstr.GetDeletionTimestamp() != nil {
+		st.logger.Info("Job is being deleted, waiting to re-create it", ctrlLogz.Object(spec))
+		return resourceInfo{
+			actual: actualUnstr,
+			status: resourceStatusInProgress{},
+		}
+	}
+	actualChecksum, ok := actualUnstr.GetAnnotations()[jobSpecChecksumAnnotation]
+	if !ok || (actualChecksum == checksum && !isManagedBy(actualUnstr, st.bundle)) {
+		// Job was created before checksums were introduced or is being re-parented.
+		// Adopt it as is, metadata can be updated in place.
+		st.logger.Info("Updating Job metadata", ctrlLogz.Object(spec))
+		mergeJobMetadata(spec, actualUnstr, checksum)
+		actualUnstr.SetOwnerReferences(spec.GetOwnerReferences())
+		if actualUnstr.GetNamespace() != st.bundle.Namespace {
+			setTrackingLabels(st.bundle, actualUnstr)
+		}
+		return st.updateJobMetadata(resClient, actualUnstr)
+	}
+	if actualChecksum == checksum {
+		if !mergeJobMetadata(spec, actualUnstr, checksum) {
+			return st.checkReadiness(actualUnstr)
+		}
+		// Labels and annotations are not covered by the checksum and can be updated without re-running the Job
+		st.logger.Info("Job metadata has changed, updating", ctrlLogz.Object(spec))
+		return st.updateJobMetadata(resClient, actualUnstr)
+	}
+
+	st.logger.Info("Job spec has changed, deleting Job to re-run it", ctrlLogz.Object(spec))
+	uid := actualUnstr.GetUID()
+	policy := meta_v1.DeletePropagationForeground
+	err = resClient.Delete(actualUnstr.GetName(), &meta_v1.DeleteOptions{
+		Preconditions: &meta_v1.Preconditions{
+			UID: &uid,
+		},
+		PropagationPolicy: &policy,
+	})
+	if err != nil && !api_errors.IsNotFound(err) && !api_errors.IsConflict(err) {
+		return resourceInfo{
+			status: resourceStatusError{
+				err:              errors.Wrap(err, "failed to delete Job to re-run it"),
+				isRetriableError: true,
+			},
+		}
+	}
+	return resourceInfo{
+		actual: actualUnstr,
+		status: resourceStatusInProgress{},
+	}
+}
+
+func (st *resourceSyncTask) updateJobMetadata(resClient dynamic.ResourceInterface, actual *unstructured.Unstructured) resourceInfo {
+	updated, err := resClient.Update(actual)
+	if err != nil {
+		return resourceInfo{
+			status: resourceStatusError{
+				err:              errors.Wrap(err, "failed to update Job metadata"),
+				isRetriableError: !api_errors.IsConflict(err),
+			},
+		}
+	}
+	return st.checkReadiness(updated)
+}
+
+// mergeJobMetadata sets labels and annotations of the spec and the checksum annotation on the actual Job.
+// Labels and annotations that are not in the spec are left intact because they may have been set by the server or
+// by other controllers. Returns true if the actual object was modified.
+func mergeJobMetadata(spec, actual *unstructured.Unstructured, checksum string) bool {
+	labels, labelsChanged := mergeMissing(actual.GetLabels(), spec.GetLabels())
+	annotations, annotationsChanged := mergeMissing(actual.GetAnnotations(), withJobSpecChecksum(spec.GetAnnotations(), checksum))
+	if labelsChanged {
+		actual.SetLabels(labels)
+	}
+	if annotationsChanged {
+		actual.SetAnnotations(annotations)
+	}
+	return labelsChanged || annotationsChanged
+}
+
+// mergeMissing returns a copy of actual with entries of desired that are missing or have different values.
+func mergeMissing(actual, desired map[string]string) (map[string]string, bool) {
+	changed := false
+	for k, v := range desired {
+		if existing, ok := actual[k]; !ok || existing != v {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return actual, false
+	}
+	return mergeLabels(actual, desired), true
+}
+
+// jobSpecChecksum returns a checksum of the "spec" field of the object.
+func jobSpecChecksum(obj *unstructured.Unstructured) (string, error) {
+	data, err := json.Marshal(obj.Object["spec"]) // Map keys are sorted so the encoding is stable
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal Job spec")
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func withJobSpecChecksum(annotations map[string]string, checksum string) map[string]string {
+	result := make(map[string]string, len(annotations)+1)
+	for k, v := range annotations {
+		result[k] = v
+	}
+	result[jobSpecChecksumAnnotation] = checksum
+	return result
+}
@@ -0,0 +1,125 @@
+package bundlec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestJobSpecChecksum(t *testing.T) {
+	t.Parallel()
+	job := func(image string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "batch/v1",
+				"kind":       "Job",
+				"metadata": map[string]interface{}{
+					"name": "job1",
+				},
+				"spec": map[string]interface{}{
+					"backoffLimit": int64(2),
+					"template": map[string]interface{}{
+						"spec": map[string]interface{}{
+							"containers": []interface{}{
+								map[string]interface{}{
+									"name":  "c1",
+									"image": image,
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	sum1, err := jobSpecChecksum(job("image:1"))
+	require.NoError(t, err)
+	sum2, err := jobSpecChecksum(job("image:1"))
+	require.NoError(t, err)
+	assert.Equal(t, sum1, sum2)
+
+	labeled := job("image:1")
+	labeled.SetLabels(map[string]string{"a": "b"})
+	sumLabeled, err := jobSpecChecksum(labeled)
+	require.NoError(t, err)
+	assert.Equal(t, sum1, sumLabeled, "metadata must not affect the checksum")
+
+	sumChanged, err := jobSpecChecksum(job("image:2"))
+	require.NoError(t, err)
+	assert.NotEqual(t, sum1, sumChanged)
+}
+
+func TestWithJobSpecChecksum(t *testing.T) {
+	t.Parallel()
+	annotations := map[string]string{"a": "b"}
+	result := withJobSpecChecksum(annotations, "sum")
+	assert.Equal(t, map[string]string{"a": "b", jobSpecChecksumAnnotation: "sum"}, result)
+	assert.Equal(t, map[string]string{"a": "b"}, annotations, "input must not be mutated")
+}
+
+func TestMergeJobMetadata(t *testing.T) {
+	t.Parallel()
+	job := func(labels, annotations map[string]string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "batch/v1",
+				"kind":       "Job",
+				"metadata": map[string]interface{}{
+					"name": "job1",
+				},
+			},
+		}
+		if labels != nil {
+			obj.SetLabels(labels)
+		}
+		if annotations != nil {
+			obj.SetAnnotations(annotations)
+		}
+		return obj
+	}
+	testcases := map[string]struct {
+		spec                *unstructured.Unstructured
+		actual              *unstructured.Unstructured
+		changed             bool
+		expectedLabels      map[string]string
+		expectedAnnotations map[string]string
+	}{
+		"unchanged": {
+			spec:                job(map[string]string{"a": "1"}, map[string]string{"b": "2"}),
+			actual:              job(map[string]string{"a": "1", "controller-uid": "x"}, map[string]string{"b": "2", jobSpecChecksumAnnotation: "sum"}),
+			expectedLabels:      map[string]string{"a": "1", "controller-uid": "x"},
+			expectedAnnotations: map[string]string{"b": "2", jobSpecChecksumAnnotation: "sum"},
+		},
+		"label added": {
+			spec:                job(map[string]string{"a": "1", "team": "x"}, nil),
+			actual:              job(map[string]string{"a": "1"}, map[string]string{jobSpecChecksumAnnotation: "sum"}),
+			changed:             true,
+			expectedLabels:      map[string]string{"a": "1", "team": "x"},
+			expectedAnnotations: map[string]string{jobSpecChecksumAnnotation: "sum"},
+		},
+		"annotation changed": {
+			spec:                job(nil, map[string]string{"b": "3"}),
+			actual:              job(nil, map[string]string{"b": "2", jobSpecChecksumAnnotation: "sum"}),
+			changed:             true,
+			expectedAnnotations: map[string]string{"b": "3", jobSpecChecksumAnnotation: "sum"},
+		},
+		"checksum missing": {
+			spec:                job(nil, nil),
+			actual:              job(nil, nil),
+			changed:             true,
+			expectedAnnotations: map[string]string{jobSpecChecksumAnnotation: "sum"},
+		},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.changed, mergeJobMetadata(tc.spec, tc.actual, "sum"))
+			assert.Equal(t, tc.expectedLabels, tc.actual.GetLabels())
+			assert.Equal(t, tc.expectedAnnotations, tc.actual.GetAnnotations())
+		})
+	}
+}
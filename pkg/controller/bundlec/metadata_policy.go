@@ -0,0 +1,64 @@
+package bundlec
+
+import (
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/pkg/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// setOwnerReferences sets owner references to the Bundle and to objects of referenced resources according to
+// the metadata policy. Policy may be nil.
+func setOwnerReferences(policy *smith_v1.MetadataPolicy, bundle *smith_v1.Bundle, dependencies []*unstructured.Unstructured, obj *unstructured.Unstructured) error {
+	trueRef := true
+	blockOwnerDeletion := true
+	if policy != nil && policy.BlockOwnerDeletion != nil {
+		blockOwnerDeletion = *policy.BlockOwnerDeletion
+	}
+	refs := obj.GetOwnerReferences()
+	for i, ref := range refs {
+		if ref.Controller != nil && *ref.Controller {
+			return errors.Errorf("cannot create resource with controller owner reference %v", ref)
+		}
+		refs[i].BlockOwnerDeletion = &blockOwnerDeletion
+	}
+	// Hardcode APIVersion/Kind because of https://github.com/kubernetes/client-go/issues/60
+	refs = append(refs, meta_v1.OwnerReference{
+		APIVersion:         smith_v1.BundleResourceGroupVersion,
+		Kind:               smith_v1.BundleResourceKind,
+		Name:               bundle.Name,
+		UID:                bundle.UID,
+		Controller:         &trueRef,
+		BlockOwnerDeletion: &blockOwnerDeletion,
+	})
+	if policy == nil || !policy.SkipReferenceOwnerReferences {
+		for _, dep := range dependencies {
+			refs = append(refs, meta_v1.OwnerReference{
+				APIVersion:         dep.GetAPIVersion(),
+				Kind:               dep.GetKind(),
+				Name:               dep.GetName(),
+				UID:                dep.GetUID(),
+				BlockOwnerDeletion: &blockOwnerDeletion,
+			})
+		}
+	}
+	obj.SetOwnerReferences(refs)
+	return nil
+}
+
+// applyMetadataPolicyFinalizers adds finalizers from the metadata policy to the object. Policy may be nil.
+func applyMetadataPolicyFinalizers(policy *smith_v1.MetadataPolicy, obj *unstructured.Unstructured) {
+	if policy == nil || len(policy.Finalizers) == 0 {
+		return
+	}
+	finalizers := obj.GetFinalizers()
+	existing := sets.NewString(finalizers...)
+	for _, finalizer := range policy.Finalizers {
+		if !existing.Has(finalizer) {
+			existing.Insert(finalizer)
+			finalizers = append(finalizers, finalizer)
+		}
+	}
+	obj.SetFinalizers(finalizers)
+}
@@ -0,0 +1,102 @@
+package bundlec
+
+import (
+	"testing"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestSetOwnerReferencesDefaultPolicy(t *testing.T) {
+	t.Parallel()
+	bundle := &smith_v1.Bundle{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name: "b",
+			UID:  "bundle-uid",
+		},
+	}
+	dep := &unstructured.Unstructured{}
+	dep.SetAPIVersion("v1")
+	dep.SetKind("Secret")
+	dep.SetName("s")
+	dep.SetUID("secret-uid")
+	obj := &unstructured.Unstructured{}
+
+	require.NoError(t, setOwnerReferences(nil, bundle, []*unstructured.Unstructured{dep}, obj))
+	refs := obj.GetOwnerReferences()
+	require.Len(t, refs, 2)
+	assert.True(t, meta_v1.IsControlledBy(obj, bundle))
+	assert.EqualValues(t, "secret-uid", refs[1].UID)
+	for _, ref := range refs {
+		require.NotNil(t, ref.BlockOwnerDeletion)
+		assert.True(t, *ref.BlockOwnerDeletion)
+	}
+}
+
+func TestSetOwnerReferencesCustomPolicy(t *testing.T) {
+	t.Parallel()
+	falseRef := false
+	policy := &smith_v1.MetadataPolicy{
+		SkipReferenceOwnerReferences: true,
+		BlockOwnerDeletion:           &falseRef,
+	}
+	bundle := &smith_v1.Bundle{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name: "b",
+			UID:  "bundle-uid",
+		},
+	}
+	dep := &unstructured.Unstructured{}
+	dep.SetUID("secret-uid")
+	obj := &unstructured.Unstructured{}
+	obj.SetOwnerReferences([]meta_v1.OwnerReference{
+		{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+			Name:       "cm",
+			UID:        "cm-uid",
+		},
+	})
+
+	require.NoError(t, setOwnerReferences(policy, bundle, []*unstructured.Unstructured{dep}, obj))
+	refs := obj.GetOwnerReferences()
+	require.Len(t, refs, 2) // no reference to the dependency
+	assert.EqualValues(t, "cm-uid", refs[0].UID)
+	assert.True(t, meta_v1.IsControlledBy(obj, bundle))
+	for _, ref := range refs {
+		require.NotNil(t, ref.BlockOwnerDeletion)
+		assert.False(t, *ref.BlockOwnerDeletion)
+	}
+}
+
+func TestSetOwnerReferencesRejectsControllerReference(t *testing.T) {
+	t.Parallel()
+	trueRef := true
+	obj := &unstructured.Unstructured{}
+	obj.SetOwnerReferences([]meta_v1.OwnerReference{
+		{
+			Name:       "other",
+			Controller: &trueRef,
+		},
+	})
+
+	assert.Error(t, setOwnerReferences(nil, &smith_v1.Bundle{}, nil, obj))
+}
+
+func TestApplyMetadataPolicyFinalizers(t *testing.T) {
+	t.Parallel()
+	policy := &smith_v1.MetadataPolicy{
+		Finalizers: []string{"example.com/a", "example.com/b"},
+	}
+	obj := &unstructured.Unstructured{}
+	obj.SetFinalizers([]string{"example.com/b", "example.com/c"})
+
+	applyMetadataPolicyFinalizers(policy, obj)
+	assert.Equal(t, []string{"example.com/b", "example.com/c", "example.com/a"}, obj.GetFinalizers())
+
+	applyMetadataPolicyFinalizers(nil, obj)
+	assert.Equal(t, []string{"example.com/b", "example.com/c", "example.com/a"}, obj.GetFinalizers())
+}
@@ -0,0 +1,91 @@
+package bundlec
+
+import (
+	"encoding/json"
+	"reflect"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// A Bundle can be a resource of another Bundle. Outputs of the nested Bundle are published in its status so that
+// resources of the outer Bundle can reference them with the status.outputs.<name> path.
+
+// nestedBundleFields are fields of the spec of a nested Bundle that are processed by the nested Bundle itself.
+// References and parameters in them are not resolved by the outer Bundle, values are passed to the nested Bundle
+// via its parameters instead.
+var nestedBundleFields = []string{"resources", "outputs"}
+
+// detachNestedBundleFields removes fields that are processed by the nested Bundle from the spec of an object if it
+// is a Bundle. The returned function puts them back.
+func detachNestedBundleFields(obj map[string]interface{}) (reattach func()) {
+	u := unstructured.Unstructured{Object: obj}
+	if u.GroupVersionKind().GroupKind() != smith_v1.BundleGVK.GroupKind() {
+		return func() {}
+	}
+	spec, ok := obj["spec"].(map[string]interface{})
+	if !ok {
+		return func() {}
+	}
+	detached := make(map[string]interface{}, len(nestedBundleFields))
+	for _, name := range nestedBundleFields {
+		if value, ok := spec[name]; ok {
+			detached[name] = value
+			delete(spec, name)
+		}
+	}
+	return func() {
+		for name, value := range detached {
+			spec[name] = value
+		}
+	}
+}
+
+// updateOutputs sets outputs of the Bundle in its status. Returns true if they have changed.
+func (st *bundleSyncTask) updateOutputs() bool {
+	var outputs map[string]string
+	for _, output := range st.bundle.Spec.Outputs {
+		resInfo := st.processedResources[output.Resource]
+		if resInfo == nil || !resInfo.isReady() {
+			continue
+		}
+		value, err := outputValue(st.processedResources, output)
+		if err != nil {
+			// An output that cannot be evaluated is not published, the nested Bundle is still processed
+			st.logger.Warn("Failed to evaluate output", zap.String("output", output.Name), zap.Error(err))
+			continue
+		}
+		if outputs == nil {
+			outputs = make(map[string]string, len(st.bundle.Spec.Outputs))
+		}
+		outputs[output.Name] = value
+	}
+	if reflect.DeepEqual(st.bundle.Status.Outputs, outputs) {
+		return false
+	}
+	st.bundle.Status.Outputs = outputs
+	return true
+}
+
+// outputValue returns the value of the field of the object the output points to. Values that are not strings are
+// JSON encoded.
+func outputValue(resInfos map[smith_v1.ResourceName]*resourceInfo, output smith_v1.Output) (string, error) {
+	value, err := resolveReference(resInfos, smith_v1.Reference{
+		Name:     smith_v1.ReferenceName(output.Name),
+		Resource: output.Resource,
+		Path:     output.Path,
+	})
+	if err != nil {
+		return "", err
+	}
+	if str, ok := value.(string); ok {
+		return str, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to encode value")
+	}
+	return string(data), nil
+}
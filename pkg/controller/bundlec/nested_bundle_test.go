@@ -0,0 +1,120 @@
+package bundlec
+
+import (
+	"testing"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestUpdateOutputs(t *testing.T) {
+	t.Parallel()
+	service := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Service",
+			"spec": map[string]interface{}{
+				"clusterIP": "10.0.0.1",
+				"ports": []interface{}{
+					map[string]interface{}{"port": int64(80)},
+				},
+			},
+		},
+	}
+	st := &bundleSyncTask{
+		logger: zap.NewNop(),
+		bundle: &smith_v1.Bundle{
+			Spec: smith_v1.BundleSpec{
+				Outputs: []smith_v1.Output{
+					{Name: "ip", Resource: "svc", Path: "spec.clusterIP"},
+					{Name: "ports", Resource: "svc", Path: "spec.ports"},
+					{Name: "missing", Resource: "svc", Path: "spec.type"},
+					{Name: "notReady", Resource: "deployment", Path: "metadata.name"},
+				},
+			},
+		},
+		processedResources: map[smith_v1.ResourceName]*resourceInfo{
+			"svc": {
+				actual: service,
+				status: resourceStatusReady{},
+			},
+			"deployment": {
+				status: resourceStatusInProgress{},
+			},
+		},
+	}
+
+	assert.True(t, st.updateOutputs())
+	assert.Equal(t, map[string]string{
+		"ip":    "10.0.0.1",
+		"ports": `[{"port":80}]`,
+	}, st.bundle.Status.Outputs)
+
+	// Outputs are only updated when they change
+	assert.False(t, st.updateOutputs())
+
+	st.bundle.Spec.Outputs = nil
+	assert.True(t, st.updateOutputs())
+	assert.Nil(t, st.bundle.Status.Outputs)
+}
+
+func TestEvalSpecNestedBundle(t *testing.T) {
+	t.Parallel()
+	nestedResources := []interface{}{
+		map[string]interface{}{
+			"name": "config",
+			"spec": map[string]interface{}{
+				"object": map[string]interface{}{
+					"apiVersion": "v1",
+					"kind":       "ConfigMap",
+					"metadata": map[string]interface{}{
+						"name": "config",
+					},
+					"data": map[string]interface{}{
+						"env":  "${params.env}",
+						"host": "!{host}",
+					},
+				},
+			},
+		},
+	}
+	res := &smith_v1.Resource{
+		Name: "nested",
+		Spec: smith_v1.ResourceSpec{
+			Object: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": smith_v1.BundleResourceGroupVersion,
+					"kind":       smith_v1.BundleResourceKind,
+					"metadata": map[string]interface{}{
+						"name": "nested",
+					},
+					"spec": map[string]interface{}{
+						"parameters": map[string]interface{}{
+							"env": "${params.environment}",
+						},
+						"resources": nestedResources,
+					},
+				},
+			},
+		},
+	}
+	st := resourceSyncTask{
+		logger: zap.NewNop(),
+		bundle: &smith_v1.Bundle{
+			Spec: smith_v1.BundleSpec{
+				Parameters: map[string]string{"environment": "prod"},
+			},
+		},
+		processedResources: map[smith_v1.ResourceName]*resourceInfo{},
+	}
+
+	obj, err := st.evalSpec(res, nil)
+	require.NoError(t, err)
+	// Parameters of the nested Bundle are substituted, its resources are left for the nested Bundle to process
+	spec := obj.Object["spec"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"env": "prod"}, spec["parameters"])
+	assert.Equal(t, nestedResources, spec["resources"])
+}
@@ -0,0 +1,88 @@
+package bundlec
+
+import (
+	ctrlLogz "github.com/atlassian/ctrl/logz"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// deleteResourcesInOrder deletes objects controlled by the Bundle in reverse dependency order.
+// Objects that are not defined in the Bundle are deleted first. An object of a resource is only deleted once objects
+// of all resources that depend on it are gone. Returns true once there are no objects left.
+// The Bundle is processed again when its objects are deleted so there is no need to poll.
+func (st *bundleSyncTask) deleteResourcesInOrder() (done, retriableError bool, e error) {
+	objs, err := st.childObjects()
+	if err != nil {
+		return false, false, err
+	}
+	st.objectsToDelete = make(map[objectRef]runtime.Object, len(objs))
+	if len(objs) == 0 {
+		return true, false, nil
+	}
+
+	// Objects are matched by group, kind and name regardless of the version, like when pruning.
+	type groupKindName struct {
+		schema.GroupKind
+		namespace string
+		name      string
+	}
+	present := make(map[groupKindName]struct{}, len(objs))
+	for _, obj := range objs {
+		ref := st.objectRefOf(obj)
+		st.objectsToDelete[ref] = obj
+		present[groupKindName{GroupKind: ref.GroupKind(), namespace: ref.Namespace, name: ref.Name}] = struct{}{}
+	}
+	resources, err := st.resources()
+	if err != nil {
+		// Sources of specs may be gone already, deletion must not get stuck
+		st.logger.Warn("Failed to get resources, objects of resources without specs are deleted regardless of dependencies", zap.Error(err))
+		resources = st.bundle.Spec.Resources
+	}
+	resourceObjects := make(map[smith_v1.ResourceName]groupKindName, len(resources))
+	for i := range resources {
+		res := &resources[i]
+		if gvk, name, ok := resourceObject(res, st.pluginContainers); ok {
+			resourceObjects[res.Name] = groupKindName{GroupKind: gvk.GroupKind(), namespace: resourceNamespace(res, st.bundle), name: name}
+		}
+	}
+
+	// Objects of dependencies are blocked while objects of resources that depend on them are present
+	blocked := make(map[groupKindName]struct{})
+	if g, _, err := sortBundle(st.bundle); err != nil {
+		// Should not happen because the Bundle has been processed before, but deletion must not get stuck
+		st.logger.Warn("Failed to sort resources, deleting objects regardless of dependencies", zap.Error(err))
+	} else {
+		for _, res := range st.bundle.Spec.Resources {
+			obj, ok := resourceObjects[res.Name]
+			if !ok {
+				continue
+			}
+			if _, ok = present[obj]; !ok {
+				continue
+			}
+			for _, dep := range g.Vertices[res.Name].Edges() {
+				if depObj, ok := resourceObjects[dep.(smith_v1.ResourceName)]; ok {
+					blocked[depObj] = struct{}{}
+				}
+			}
+		}
+	}
+	toDelete := make([]runtime.Object, 0, len(objs))
+	for _, obj := range objs {
+		ref := st.objectRefOf(obj)
+		if _, ok := blocked[groupKindName{GroupKind: ref.GroupKind(), namespace: ref.Namespace, name: ref.Name}]; ok {
+			st.logger.Debug("Object is waiting for objects of dependent resources to be deleted",
+				ctrlLogz.ObjectGk(ref.GroupKind()), ctrlLogz.ObjectName(ref.Name))
+			continue
+		}
+		toDelete = append(toDelete, obj)
+	}
+	retriable, err := st.deleteObjects(toDelete)
+	if err != nil {
+		return false, retriable, err
+	}
+	st.logger.Info("Waiting for objects to be deleted", zap.Int("remaining", len(objs)))
+	return false, false, nil
+}
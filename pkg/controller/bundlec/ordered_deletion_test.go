@@ -0,0 +1,124 @@
+package bundlec
+
+import (
+	"testing"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+)
+
+type deletingSmartClient struct {
+	deleted *[]string
+}
+
+func (c deletingSmartClient) ForGVK(gvk schema.GroupVersionKind, namespace string) (dynamic.ResourceInterface, error) {
+	return deletingResourceClient{deleted: c.deleted}, nil
+}
+
+type deletingResourceClient struct {
+	dynamic.ResourceInterface
+	deleted *[]string
+}
+
+func (c deletingResourceClient) Delete(name string, opts *meta_v1.DeleteOptions) error {
+	*c.deleted = append(*c.deleted, name)
+	return nil
+}
+
+func orderedDeletionConfigMap(name string) *core_v1.ConfigMap {
+	return &core_v1.ConfigMap{
+		TypeMeta: meta_v1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: core_v1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      name,
+			Namespace: defaultNamespace,
+			UID:       types.UID(name + "-uid"),
+		},
+	}
+}
+
+func TestDeleteResourcesInOrder(t *testing.T) {
+	t.Parallel()
+	bundle := &smith_v1.Bundle{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "bundle1",
+			Namespace: defaultNamespace,
+			UID:       "bundle-uid",
+		},
+		Spec: smith_v1.BundleSpec{
+			Resources: []smith_v1.Resource{
+				{
+					Name: "a",
+					Spec: smith_v1.ResourceSpec{Object: orderedDeletionConfigMap("a")},
+				},
+				{
+					Name:       "b",
+					References: []smith_v1.Reference{{Resource: "a"}},
+					Spec:       smith_v1.ResourceSpec{Object: orderedDeletionConfigMap("b")},
+				},
+				{
+					Name:       "c",
+					References: []smith_v1.Reference{{Resource: "b"}},
+					Spec:       smith_v1.ResourceSpec{Object: orderedDeletionConfigMap("c")},
+				},
+			},
+		},
+	}
+	now := meta_v1.Now()
+	deletingC := orderedDeletionConfigMap("c")
+	deletingC.DeletionTimestamp = &now
+
+	steps := []struct {
+		name    string
+		objects []runtime.Object
+		deleted []string
+		done    bool
+	}{
+		{
+			name:    "dependents and orphans first",
+			objects: []runtime.Object{orderedDeletionConfigMap("a"), orderedDeletionConfigMap("b"), orderedDeletionConfigMap("c"), orderedDeletionConfigMap("orphan")},
+			deleted: []string{"c", "orphan"},
+		},
+		{
+			name:    "wait for dependents to be gone",
+			objects: []runtime.Object{orderedDeletionConfigMap("a"), orderedDeletionConfigMap("b"), deletingC},
+		},
+		{
+			name:    "dependents gone",
+			objects: []runtime.Object{orderedDeletionConfigMap("a"), orderedDeletionConfigMap("b")},
+			deleted: []string{"b"},
+		},
+		{
+			name: "all gone",
+			done: true,
+		},
+	}
+	for _, step := range steps {
+		var deleted []string
+		st := &bundleSyncTask{
+			logger:      zap.NewNop(),
+			smartClient: deletingSmartClient{deleted: &deleted},
+			store: fakeStore{
+				controlled: map[types.UID][]runtime.Object{
+					bundle.UID: step.objects,
+				},
+			},
+			bundle: bundle,
+		}
+		done, _, err := st.deleteResourcesInOrder()
+		require.NoError(t, err, step.name)
+		assert.Equal(t, step.done, done, step.name)
+		assert.Equal(t, step.deleted, deleted, step.name)
+		assert.Len(t, st.objectsToDelete, len(step.objects), step.name)
+	}
+}
@@ -0,0 +1,336 @@
+package bundlec
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/atlassian/ctrl"
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	api_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// OrphanProblem is the reason an object is considered orphaned.
+type OrphanProblem string
+
+const (
+	// OrphanBundleNotFound means the Bundle that managed the object does not exist anymore. The garbage collector
+	// deletes such objects in the namespace of the Bundle, objects in other namespaces are only deleted by Smith and
+	// are left behind if the controller was not running when the Bundle was deleted.
+	OrphanBundleNotFound OrphanProblem = "BundleNotFound"
+	// OrphanNotDefined means the Bundle that manages the object exists but does not define it anymore.
+	OrphanNotDefined OrphanProblem = "NotDefined"
+)
+
+// Orphan is an object managed by a Bundle that does not exist anymore or does not define the object anymore.
+type Orphan struct {
+	Group     string        `json:"group"`
+	Version   string        `json:"version"`
+	Kind      string        `json:"kind"`
+	Namespace string        `json:"namespace"`
+	Name      string        `json:"name"`
+	Problem   OrphanProblem `json:"problem"`
+	// BundleNamespace and Bundle identify the Bundle that manages or managed the object.
+	BundleNamespace string `json:"bundleNamespace"`
+	Bundle          string `json:"bundle"`
+	// Queued is true if the Bundle was queued for processing, which prunes objects it does not define.
+	Queued bool `json:"queued,omitempty"`
+	// Deleted is true if the object was deleted by the audit.
+	Deleted bool `json:"deleted,omitempty"`
+	// Error is why the object could not be deleted.
+	Error string `json:"error,omitempty"`
+}
+
+// OrphanReport is the outcome of an orphan audit.
+type OrphanReport struct {
+	Time time.Time `json:"time"`
+	// Objects is the number of checked objects.
+	Objects int `json:"objects"`
+	// Orphans sorted by namespace, name and kind.
+	Orphans []Orphan `json:"orphans,omitempty"`
+}
+
+// OrphanAuditor scans objects in the informer caches for objects labeled with the BundleNameLabel that are not
+// managed by a Bundle anymore. Objects whose Bundle does not define them anymore are repaired by queueing the Bundle,
+// processing prunes them according to the pruning settings of the Bundle. Objects whose Bundle does not exist are
+// only deleted if requested, with foreground propagation like the controller deletes objects. Objects kept by their
+// deletion policy are skipped, as are objects labeled but not controlled by a Bundle, the consistency check reports them.
+type OrphanAuditor struct {
+	Logger      *zap.Logger
+	Store       ConsistencyStore
+	BundleStore BundleStore
+	SmartClient SmartClient
+	WorkQueue   ctrl.WorkQueueProducer
+	// Namespaces where Bundles are watched. Objects of Bundles in other namespaces are skipped because it is not
+	// known if the Bundles exist. Empty means all namespaces are watched.
+	Namespaces []string
+	// HasSynced returns true once the informer caches have synced. Audits are refused until then. May be nil.
+	HasSynced func() bool
+	// DeleteOrphans means periodic audits delete objects whose Bundle does not exist.
+	DeleteOrphans bool
+
+	mx   sync.Mutex
+	last *OrphanReport
+}
+
+// Run audits objects periodically until the context is done.
+func (a *OrphanAuditor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.Audit(true, a.DeleteOrphans)
+		}
+	}
+}
+
+// Audit finds orphaned objects in the informer caches.
+// If repair is true, Bundles that do not define their objects anymore are queued. If deleteOrphans is true, objects
+// whose Bundle does not exist are deleted.
+func (a *OrphanAuditor) Audit(repair, deleteOrphans bool) *OrphanReport {
+	report := &OrphanReport{
+		Time: time.Now(),
+	}
+	queued := make(map[ctrl.QueueKey]struct{})
+	for gvk, inf := range a.Store.GetInformers() {
+		for _, obj := range inf.GetStore().List() {
+			m, ok := obj.(meta_v1.Object)
+			if !ok {
+				continue
+			}
+			report.Objects++
+			orphan := a.checkObject(gvk, m)
+			if orphan == nil {
+				continue
+			}
+			switch orphan.Problem {
+			case OrphanNotDefined:
+				if repair {
+					key := ctrl.QueueKey{Namespace: orphan.BundleNamespace, Name: orphan.Bundle}
+					if _, ok := queued[key]; !ok {
+						queued[key] = struct{}{}
+						a.WorkQueue.Add(key)
+					}
+					orphan.Queued = true
+				}
+			case OrphanBundleNotFound:
+				if deleteOrphans {
+					if err := a.deleteOrphan(gvk, m); err != nil {
+						orphan.Error = err.Error()
+					} else {
+						orphan.Deleted = true
+					}
+				}
+			}
+			report.Orphans = append(report.Orphans, *orphan)
+		}
+	}
+	sort.Slice(report.Orphans, func(i, j int) bool {
+		x, y := report.Orphans[i], report.Orphans[j]
+		if x.Namespace != y.Namespace {
+			return x.Namespace < y.Namespace
+		}
+		if x.Name != y.Name {
+			return x.Name < y.Name
+		}
+		return x.Kind < y.Kind
+	})
+	for _, orphan := range report.Orphans {
+		a.Logger.Info("Orphaned object",
+			zap.String("problem", string(orphan.Problem)),
+			zap.String("kind", orphan.Kind),
+			zap.String("namespace", orphan.Namespace),
+			zap.String("name", orphan.Name),
+			zap.String("bundle_namespace", orphan.BundleNamespace),
+			zap.String("bundle", orphan.Bundle),
+			zap.Bool("queued", orphan.Queued),
+			zap.Bool("deleted", orphan.Deleted),
+			zap.String("error", orphan.Error))
+	}
+	if repair {
+		a.mx.Lock()
+		a.last = report
+		a.mx.Unlock()
+	}
+	return report
+}
+
+// checkObject returns the orphan if the object is orphaned, nil otherwise.
+func (a *OrphanAuditor) checkObject(gvk schema.GroupVersionKind, obj meta_v1.Object) *Orphan {
+	if obj.GetDeletionTimestamp() != nil || objectDeletionPolicy(obj) != smith_v1.DeletionPolicyDelete {
+		return nil
+	}
+	bundleNamespace, bundleName, bundleUID, ok := managingBundle(obj)
+	if !ok || !a.watches(bundleNamespace) {
+		return nil
+	}
+	newOrphan := func(problem OrphanProblem) *Orphan {
+		return &Orphan{
+			Group:           gvk.Group,
+			Version:         gvk.Version,
+			Kind:            gvk.Kind,
+			Namespace:       obj.GetNamespace(),
+			Name:            obj.GetName(),
+			Problem:         problem,
+			BundleNamespace: bundleNamespace,
+			Bundle:          bundleName,
+		}
+	}
+	bundle, err := a.BundleStore.Get(bundleNamespace, bundleName)
+	if err != nil {
+		a.Logger.Error("Failed to get Bundle", zap.Error(err))
+		return nil
+	}
+	if bundle != nil && bundle.DeletionTimestamp != nil {
+		// Objects are being deleted together with the Bundle
+		return nil
+	}
+	defined, err := a.definedBy(bundle, gvk.GroupKind(), obj)
+	if err != nil {
+		a.Logger.Error("Failed to get Bundles by object", zap.Error(err))
+		return nil
+	}
+	switch {
+	case bundle == nil || bundle.UID != bundleUID:
+		if defined {
+			// The current incarnation of the Bundle defines the object, the consistency check reports it
+			return nil
+		}
+		return newOrphan(OrphanBundleNotFound)
+	case !defined:
+		return newOrphan(OrphanNotDefined)
+	default:
+		return nil
+	}
+}
+
+// definedBy returns true if the Bundle defines the object. Bundle may be nil.
+func (a *OrphanAuditor) definedBy(bundle *smith_v1.Bundle, gk schema.GroupKind, obj meta_v1.Object) (bool, error) {
+	if bundle == nil {
+		return false, nil
+	}
+	// The object outputs are exported to is not a resource but is managed by the Bundle
+	if ref, ok := outputsExportRef(bundle); ok && ref.GroupKind() == gk &&
+		obj.GetNamespace() == bundle.Namespace && obj.GetName() == ref.Name {
+		return true, nil
+	}
+	bundles, err := a.BundleStore.GetBundlesByObject(gk, obj.GetNamespace(), obj.GetName())
+	if err != nil {
+		return false, err
+	}
+	for _, b := range bundles {
+		if b.UID == bundle.UID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// watches returns true if Bundles in the namespace are watched.
+func (a *OrphanAuditor) watches(namespace string) bool {
+	if len(a.Namespaces) == 0 {
+		return true
+	}
+	for _, ns := range a.Namespaces {
+		if ns == meta_v1.NamespaceAll || ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteOrphan deletes the object unless it has been re-created since it was audited.
+func (a *OrphanAuditor) deleteOrphan(gvk schema.GroupVersionKind, obj meta_v1.Object) error {
+	resClient, err := a.SmartClient.ForGVK(gvk, obj.GetNamespace())
+	if err != nil {
+		return err
+	}
+	uid := obj.GetUID()
+	policy := meta_v1.DeletePropagationForeground
+	err = resClient.Delete(obj.GetName(), &meta_v1.DeleteOptions{
+		Preconditions: &meta_v1.Preconditions{
+			UID: &uid,
+		},
+		PropagationPolicy: &policy,
+	})
+	if err != nil && !api_errors.IsNotFound(err) && !api_errors.IsConflict(err) {
+		// not found means object has been deleted already
+		// conflict means it has been deleted and re-created (UID does not match)
+		return errors.Wrapf(err, "failed to delete %s %q", gvk.Kind, obj.GetName())
+	}
+	return nil
+}
+
+// managingBundle returns the Bundle that manages the object: the Bundle that controls it or, for objects in other
+// namespaces than the namespace of their Bundle, the Bundle it is tracked by. ok is false if the object is not
+// managed by a Bundle.
+func managingBundle(obj meta_v1.Object) (namespace, name string, uid types.UID, ok bool) {
+	labels := obj.GetLabels()
+	if _, labeled := labels[smith.BundleNameLabel]; !labeled {
+		return "", "", "", false
+	}
+	if ref := meta_v1.GetControllerOf(obj); ref != nil {
+		if ref.APIVersion != smith_v1.BundleResourceGroupVersion || ref.Kind != smith_v1.BundleResourceKind {
+			return "", "", "", false
+		}
+		return obj.GetNamespace(), ref.Name, ref.UID, true
+	}
+	trackedBy, tracked := labels[smith.BundleUIDLabel]
+	if !tracked {
+		// Labeled but not controlled by a Bundle, reported by the consistency check
+		return "", "", "", false
+	}
+	namespace = labels[smith.BundleNamespaceLabel]
+	if namespace == "" {
+		namespace = obj.GetNamespace()
+	}
+	// The label may have a truncated name, the annotation has the full name
+	name, ok = obj.GetAnnotations()[smith.BundleNameAnnotation]
+	if !ok {
+		name = labels[smith.BundleNameLabel]
+	}
+	return namespace, name, types.UID(trackedBy), true
+}
+
+// ServeHTTP serves orphan reports.
+// GET returns the report of the last periodic audit, or runs an audit without repairing anything if there was none
+// yet. POST runs an audit and queues Bundles of objects they do not define anymore. POST with delete=true also
+// deletes objects whose Bundle does not exist.
+func (a *OrphanAuditor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if a.HasSynced != nil && !a.HasSynced() {
+		http.Error(w, "informer caches have not synced yet", http.StatusServiceUnavailable)
+		return
+	}
+	var report *OrphanReport
+	switch r.Method {
+	case http.MethodGet:
+		a.mx.Lock()
+		report = a.last
+		a.mx.Unlock()
+		if report == nil {
+			report = a.Audit(false, false)
+		}
+	case http.MethodPost:
+		report = a.Audit(true, r.URL.Query().Get("delete") == "true")
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		a.Logger.Debug("Failed to write orphan report", zap.Error(err))
+	}
+}
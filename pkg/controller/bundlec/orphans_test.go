@@ -0,0 +1,256 @@
+package bundlec
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/atlassian/ctrl"
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/atlassian/smith/pkg/store"
+	"github.com/atlassian/smith/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestOrphanAuditor(t *testing.T) {
+	t.Parallel()
+	configMapGVK := core_v1.SchemeGroupVersion.WithKind("ConfigMap")
+	labels := map[string]string{smith.BundleNameLabel: "b1"}
+	gone := map[string]string{smith.BundleNameLabel: "b2"}
+
+	multi := store.NewMulti()
+	bundleInf := cache.NewSharedIndexInformer(&cache.ListWatch{}, &smith_v1.Bundle{}, 0, cache.Indexers{})
+	cmInf := cache.NewSharedIndexInformer(&cache.ListWatch{}, &core_v1.ConfigMap{}, 0, cache.Indexers{})
+	bs, err := store.NewBundle(bundleInf, multi, nil)
+	require.NoError(t, err)
+	require.NoError(t, multi.AddInformer(configMapGVK, cmInf))
+
+	require.NoError(t, bundleInf.GetStore().Add(&smith_v1.Bundle{
+		ObjectMeta: meta_v1.ObjectMeta{Namespace: "ns", Name: "b1", UID: "b1-uid"},
+		Spec: smith_v1.BundleSpec{
+			Resources: []smith_v1.Resource{
+				{
+					Name: "defined",
+					Spec: smith_v1.ResourceSpec{
+						Object: crossNamespaceConfigMap("", "defined", nil),
+					},
+				},
+			},
+		},
+	}))
+	retained := child("retained", gone, bundleRef("b2", "b2-uid"))
+	retained.Annotations = map[string]string{smith.DeletionPolicyAnnotation: string(smith_v1.DeletionPolicyRetain)}
+	tracked := child("tracked", map[string]string{
+		smith.BundleNameLabel:      "b3",
+		smith.BundleNamespaceLabel: "ns",
+		smith.BundleUIDLabel:       "b3-uid",
+	})
+	tracked.Namespace = "other"
+	unwatched := child("unwatched", map[string]string{
+		smith.BundleNameLabel:      "b4",
+		smith.BundleNamespaceLabel: "unwatched",
+		smith.BundleUIDLabel:       "b4-uid",
+	})
+	unwatched.Namespace = "other"
+	for _, obj := range []*core_v1.ConfigMap{
+		child("defined", labels, bundleRef("b1", "b1-uid")),
+		child("removed", labels, bundleRef("b1", "b1-uid")),
+		child("gone", gone, bundleRef("b2", "b2-uid")),
+		child("previous", labels, bundleRef("b1", "old-b1-uid")),
+		child("not-labeled", nil, bundleRef("b2", "b2-uid")),
+		child("not-controlled", gone),
+		retained,
+		tracked,
+		unwatched,
+	} {
+		require.NoError(t, cmInf.GetStore().Add(obj))
+	}
+
+	queue := &fakeWorkQueue{}
+	var deleted []string
+	auditor := &OrphanAuditor{
+		Logger:      zaptest.NewLogger(t),
+		Store:       multi,
+		BundleStore: bs,
+		SmartClient: deletingSmartClient{deleted: &deleted},
+		WorkQueue:   queue,
+		Namespaces:  []string{"ns", "other"},
+	}
+
+	report := auditor.Audit(false, false)
+	assert.Equal(t, 9, report.Objects)
+	assert.Empty(t, queue.added)
+	assert.Empty(t, deleted)
+	problems := make(map[string]OrphanProblem)
+	for _, orphan := range report.Orphans {
+		problems[orphan.Name] = orphan.Problem
+		assert.False(t, orphan.Queued)
+		assert.False(t, orphan.Deleted)
+	}
+	assert.Equal(t, map[string]OrphanProblem{
+		"gone":     OrphanBundleNotFound,
+		"previous": OrphanBundleNotFound,
+		"removed":  OrphanNotDefined,
+		"tracked":  OrphanBundleNotFound,
+	}, problems)
+
+	report = auditor.Audit(true, true)
+	assert.Equal(t, []ctrl.QueueKey{{Namespace: "ns", Name: "b1"}}, queue.added)
+	assert.ElementsMatch(t, []string{"gone", "previous", "tracked"}, deleted)
+	for _, orphan := range report.Orphans {
+		assert.Equal(t, orphan.Problem == OrphanNotDefined, orphan.Queued, orphan.Name)
+		assert.Equal(t, orphan.Problem == OrphanBundleNotFound, orphan.Deleted, orphan.Name)
+	}
+}
+
+func TestOrphanAuditorLongBundleName(t *testing.T) {
+	t.Parallel()
+	configMapGVK := core_v1.SchemeGroupVersion.WithKind("ConfigMap")
+	name := strings.Repeat("b", 100)
+	bundle := &smith_v1.Bundle{
+		ObjectMeta: meta_v1.ObjectMeta{Namespace: "ns", Name: name, UID: "b-uid"},
+		Spec: smith_v1.BundleSpec{
+			Resources: []smith_v1.Resource{
+				{
+					Name:      "tracked",
+					Namespace: "other",
+					Spec: smith_v1.ResourceSpec{
+						Object: crossNamespaceConfigMap("other", "tracked", nil),
+					},
+				},
+			},
+		},
+	}
+
+	multi := store.NewMulti()
+	bundleInf := cache.NewSharedIndexInformer(&cache.ListWatch{}, &smith_v1.Bundle{}, 0, cache.Indexers{})
+	cmInf := cache.NewSharedIndexInformer(&cache.ListWatch{}, &core_v1.ConfigMap{}, 0, cache.Indexers{})
+	bs, err := store.NewBundle(bundleInf, multi, nil)
+	require.NoError(t, err)
+	require.NoError(t, multi.AddInformer(configMapGVK, cmInf))
+	require.NoError(t, bundleInf.GetStore().Add(bundle))
+
+	// Labels and annotations are set the same way the controller sets them
+	obj, err := util.RuntimeToUnstructured(crossNamespaceConfigMap("other", "tracked", nil))
+	require.NoError(t, err)
+	obj.SetLabels(map[string]string{smith.BundleNameLabel: smith.LabelValue(name)})
+	setTrackingLabels(bundle, obj)
+	tracked := &core_v1.ConfigMap{}
+	require.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, tracked))
+	require.NoError(t, cmInf.GetStore().Add(tracked))
+
+	auditor := &OrphanAuditor{
+		Logger:      zaptest.NewLogger(t),
+		Store:       multi,
+		BundleStore: bs,
+		WorkQueue:   &fakeWorkQueue{},
+	}
+
+	report := auditor.Audit(false, false)
+	assert.Equal(t, 1, report.Objects)
+	assert.Empty(t, report.Orphans)
+}
+
+func TestOrphanAuditorSpecFrom(t *testing.T) {
+	t.Parallel()
+	configMapGVK := core_v1.SchemeGroupVersion.WithKind("ConfigMap")
+	labels := map[string]string{smith.BundleNameLabel: "b1"}
+
+	multi := store.NewMulti()
+	bundleInf := cache.NewSharedIndexInformer(&cache.ListWatch{}, &smith_v1.Bundle{}, 0, cache.Indexers{})
+	cmInf := cache.NewSharedIndexInformer(&cache.ListWatch{}, &core_v1.ConfigMap{}, 0, cache.Indexers{})
+	bs, err := store.NewBundle(bundleInf, multi, nil)
+	require.NoError(t, err)
+	require.NoError(t, multi.AddInformer(configMapGVK, cmInf))
+
+	require.NoError(t, bundleInf.GetStore().Add(&smith_v1.Bundle{
+		ObjectMeta: meta_v1.ObjectMeta{Namespace: "ns", Name: "b1", UID: "b1-uid"},
+		Spec: smith_v1.BundleSpec{
+			Resources: []smith_v1.Resource{
+				specFromResource("from-source", smith_v1.SpecSource{
+					ConfigMapKeyRef: &smith_v1.KeyReference{Name: "manifests", Key: "config.yaml"},
+				}),
+			},
+		},
+		Status: smith_v1.BundleStatus{
+			ResourceStatuses: []smith_v1.ResourceStatus{
+				{
+					Name: "from-source",
+					ResolvedObject: &smith_v1.ResolvedObject{
+						APIVersion: "v1",
+						Kind:       "ConfigMap",
+						Name:       "from-source",
+					},
+				},
+			},
+		},
+	}))
+	for _, obj := range []*core_v1.ConfigMap{
+		child("from-source", labels, bundleRef("b1", "b1-uid")),
+		child("removed", labels, bundleRef("b1", "b1-uid")),
+	} {
+		require.NoError(t, cmInf.GetStore().Add(obj))
+	}
+
+	queue := &fakeWorkQueue{}
+	auditor := &OrphanAuditor{
+		Logger:      zaptest.NewLogger(t),
+		Store:       multi,
+		BundleStore: bs,
+		WorkQueue:   queue,
+	}
+
+	report := auditor.Audit(true, false)
+	assert.Equal(t, 2, report.Objects)
+	require.Len(t, report.Orphans, 1)
+	assert.Equal(t, "removed", report.Orphans[0].Name)
+	assert.Equal(t, OrphanNotDefined, report.Orphans[0].Problem)
+	assert.Equal(t, []ctrl.QueueKey{{Namespace: "ns", Name: "b1"}}, queue.added)
+}
+
+func TestOrphanAuditorOutputsExport(t *testing.T) {
+	t.Parallel()
+	configMapGVK := core_v1.SchemeGroupVersion.WithKind("ConfigMap")
+	labels := map[string]string{smith.BundleNameLabel: "b1"}
+
+	multi := store.NewMulti()
+	bundleInf := cache.NewSharedIndexInformer(&cache.ListWatch{}, &smith_v1.Bundle{}, 0, cache.Indexers{})
+	cmInf := cache.NewSharedIndexInformer(&cache.ListWatch{}, &core_v1.ConfigMap{}, 0, cache.Indexers{})
+	bs, err := store.NewBundle(bundleInf, multi, nil)
+	require.NoError(t, err)
+	require.NoError(t, multi.AddInformer(configMapGVK, cmInf))
+
+	require.NoError(t, bundleInf.GetStore().Add(&smith_v1.Bundle{
+		ObjectMeta: meta_v1.ObjectMeta{Namespace: "ns", Name: "b1", UID: "b1-uid"},
+		Spec: smith_v1.BundleSpec{
+			OutputsExport: &smith_v1.OutputsExport{
+				Kind: smith_v1.OutputsExportConfigMap,
+			},
+		},
+	}))
+	for _, obj := range []*core_v1.ConfigMap{
+		child("b1-outputs", labels, bundleRef("b1", "b1-uid")),
+		child("removed", labels, bundleRef("b1", "b1-uid")),
+	} {
+		require.NoError(t, cmInf.GetStore().Add(obj))
+	}
+
+	auditor := &OrphanAuditor{
+		Logger:      zaptest.NewLogger(t),
+		Store:       multi,
+		BundleStore: bs,
+		WorkQueue:   &fakeWorkQueue{},
+	}
+
+	report := auditor.Audit(false, false)
+	assert.Equal(t, 2, report.Objects)
+	require.Len(t, report.Orphans, 1)
+	assert.Equal(t, "removed", report.Orphans[0].Name)
+	assert.Equal(t, OrphanNotDefined, report.Orphans[0].Problem)
+}
@@ -0,0 +1,155 @@
+package bundlec
+
+import (
+	"reflect"
+
+	ctrlLogz "github.com/atlassian/ctrl/logz"
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/atlassian/smith/pkg/util"
+	"github.com/pkg/errors"
+	core_v1 "k8s.io/api/core/v1"
+	api_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Outputs of a Bundle can be exported to a ConfigMap or a Secret in the namespace of the Bundle so that consumers
+// outside of the Bundle can read them. The object is controlled by the Bundle and is not a resource of it, its data
+// mirrors the outputs in the status of the Bundle.
+
+// outputsExportRef returns the reference to the object outputs of the Bundle are exported to.
+// Returns false if outputs are not exported.
+func outputsExportRef(bundle *smith_v1.Bundle) (objectRef, bool) {
+	export := bundle.Spec.OutputsExport
+	if export == nil {
+		return objectRef{}, false
+	}
+	var ref objectRef
+	switch export.Kind {
+	case smith_v1.OutputsExportConfigMap:
+		ref.GroupVersionKind = configMapGVK
+	case smith_v1.OutputsExportSecret:
+		ref.GroupVersionKind = secretGVK
+	default:
+		return objectRef{}, false
+	}
+	ref.Name = export.ObjectName(bundle.Name)
+	return ref, true
+}
+
+// exportOutputs creates or updates the object outputs of the Bundle are exported to so that its data matches
+// the outputs in the status of the Bundle. Nothing is done if outputs are not exported or if the Bundle is processed
+// in dry-run mode.
+func (st *bundleSyncTask) exportOutputs() (retriableError bool, e error) {
+	if st.dryRun {
+		return false, nil
+	}
+	export := st.bundle.Spec.OutputsExport
+	ref, ok := outputsExportRef(st.bundle)
+	if !ok {
+		if export != nil {
+			return false, errors.Errorf("unsupported kind of outputs export %q", export.Kind)
+		}
+		return false, nil
+	}
+	desired, err := st.outputsExportObject(ref)
+	if err != nil {
+		return false, err
+	}
+	actual, exists, err := st.store.Get(ref.GroupVersionKind, st.bundle.Namespace, ref.Name)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get %s %q from the Store", ref.Kind, ref.Name)
+	}
+	resClient, err := st.smartClient.ForGVK(ref.GroupVersionKind, st.bundle.Namespace)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get the client for %q", ref.GroupVersionKind)
+	}
+	logger := st.logger.With(ctrlLogz.ObjectGk(ref.GroupKind()), ctrlLogz.ObjectName(ref.Name))
+	if !exists {
+		if _, err = resClient.Create(desired); err != nil {
+			if api_errors.IsAlreadyExists(err) {
+				// Processing is resumed once the object is in the Store
+				return true, errors.Wrapf(err, "%s %q of exported outputs found, but not in Store yet", ref.Kind, ref.Name)
+			}
+			return true, errors.Wrapf(err, "failed to create %s %q of exported outputs", ref.Kind, ref.Name)
+		}
+		logger.Info("Created object of exported outputs")
+		recordEvent(st.recorder, st.bundle, core_v1.EventTypeNormal, EventReasonObjectCreated,
+			"Created %s %q of exported outputs", ref.Kind, ref.Name)
+		return false, nil
+	}
+	actualUnstr, err := util.RuntimeToUnstructured(actual)
+	if err != nil {
+		return false, err
+	}
+	if controller := meta_v1.GetControllerOf(actualUnstr); controller == nil || controller.UID != st.bundle.UID {
+		return false, errors.Errorf("%s %q of exported outputs exists and is not controlled by the Bundle", ref.Kind, ref.Name)
+	}
+	if reflect.DeepEqual(actualUnstr.Object["data"], desired.Object["data"]) {
+		return false, nil
+	}
+	updated := actualUnstr.DeepCopy()
+	if data, ok := desired.Object["data"]; ok {
+		updated.Object["data"] = data
+	} else {
+		delete(updated.Object, "data")
+	}
+	if _, err = resClient.Update(updated); err != nil {
+		return true, errors.Wrapf(err, "failed to update %s %q of exported outputs", ref.Kind, ref.Name)
+	}
+	logger.Info("Updated object of exported outputs")
+	recordEvent(st.recorder, st.bundle, core_v1.EventTypeNormal, EventReasonObjectUpdated,
+		"Updated %s %q of exported outputs", ref.Kind, ref.Name)
+	return false, nil
+}
+
+// outputsExportObject returns the object outputs of the Bundle are exported to with outputs from the status as data.
+func (st *bundleSyncTask) outputsExportObject(ref objectRef) (*unstructured.Unstructured, error) {
+	trueVar := true
+	meta := meta_v1.ObjectMeta{
+		Name:      ref.Name,
+		Namespace: st.bundle.Namespace,
+		Labels: map[string]string{
+			smith.BundleNameLabel: smith.LabelValue(st.bundle.Name),
+		},
+		OwnerReferences: []meta_v1.OwnerReference{
+			{
+				APIVersion:         smith_v1.BundleResourceGroupVersion,
+				Kind:               smith_v1.BundleResourceKind,
+				Name:               st.bundle.Name,
+				UID:                st.bundle.UID,
+				Controller:         &trueVar,
+				BlockOwnerDeletion: &trueVar,
+			},
+		},
+	}
+	typeMeta := meta_v1.TypeMeta{
+		APIVersion: ref.GroupVersion().String(),
+		Kind:       ref.Kind,
+	}
+	var obj runtime.Object
+	if ref.GroupVersionKind == secretGVK {
+		var data map[string][]byte
+		if outputs := st.bundle.Status.Outputs; len(outputs) > 0 {
+			data = make(map[string][]byte, len(outputs))
+			for name, value := range outputs {
+				data[name] = []byte(value)
+			}
+		}
+		obj = &core_v1.Secret{
+			TypeMeta:   typeMeta,
+			ObjectMeta: meta,
+			Type:       core_v1.SecretTypeOpaque,
+			Data:       data,
+		}
+	} else {
+		obj = &core_v1.ConfigMap{
+			TypeMeta:   typeMeta,
+			ObjectMeta: meta,
+			Data:       st.bundle.Status.Outputs,
+		}
+	}
+	return util.RuntimeToUnstructured(obj)
+}
@@ -0,0 +1,186 @@
+package bundlec
+
+import (
+	"testing"
+
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+type writingSmartClient struct {
+	created *[]*unstructured.Unstructured
+	updated *[]*unstructured.Unstructured
+}
+
+func (c writingSmartClient) ForGVK(gvk schema.GroupVersionKind, namespace string) (dynamic.ResourceInterface, error) {
+	return writingResourceClient{
+		ResourceInterface: updatingResourceClient{updated: c.updated},
+		created:           c.created,
+	}, nil
+}
+
+type writingResourceClient struct {
+	dynamic.ResourceInterface
+	created *[]*unstructured.Unstructured
+}
+
+func (c writingResourceClient) Create(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	*c.created = append(*c.created, obj)
+	return obj, nil
+}
+
+func outputsExportBundle(kind smith_v1.OutputsExportKind, outputs map[string]string) *smith_v1.Bundle {
+	return &smith_v1.Bundle{
+		ObjectMeta: meta_v1.ObjectMeta{Namespace: defaultNamespace, Name: "b1", UID: "b1-uid"},
+		Spec: smith_v1.BundleSpec{
+			OutputsExport: &smith_v1.OutputsExport{Kind: kind},
+		},
+		Status: smith_v1.BundleStatus{
+			Outputs: outputs,
+		},
+	}
+}
+
+func controlledBy(bundle *smith_v1.Bundle) []meta_v1.OwnerReference {
+	trueVar := true
+	return []meta_v1.OwnerReference{
+		{
+			APIVersion: smith_v1.BundleResourceGroupVersion,
+			Kind:       smith_v1.BundleResourceKind,
+			Name:       bundle.Name,
+			UID:        bundle.UID,
+			Controller: &trueVar,
+		},
+	}
+}
+
+func TestExportOutputsCreatesConfigMap(t *testing.T) {
+	t.Parallel()
+	var created, updated []*unstructured.Unstructured
+	st := &bundleSyncTask{
+		logger:      zap.NewNop(),
+		store:       specSourceStore(t),
+		smartClient: writingSmartClient{created: &created, updated: &updated},
+		bundle:      outputsExportBundle(smith_v1.OutputsExportConfigMap, map[string]string{"url": "https://db1"}),
+	}
+	retriable, err := st.exportOutputs()
+	require.NoError(t, err)
+	assert.False(t, retriable)
+	assert.Empty(t, updated)
+	require.Len(t, created, 1)
+	cm := created[0]
+	assert.Equal(t, "ConfigMap", cm.GetKind())
+	assert.Equal(t, "b1-outputs", cm.GetName())
+	assert.Equal(t, defaultNamespace, cm.GetNamespace())
+	assert.Equal(t, "b1", cm.GetLabels()[smith.BundleNameLabel])
+	controller := meta_v1.GetControllerOf(cm)
+	require.NotNil(t, controller)
+	assert.EqualValues(t, "b1-uid", controller.UID)
+	assert.Equal(t, map[string]interface{}{"url": "https://db1"}, cm.Object["data"])
+}
+
+func TestExportOutputsUpdatesSecret(t *testing.T) {
+	t.Parallel()
+	bundle := outputsExportBundle(smith_v1.OutputsExportSecret, map[string]string{"password": "s3cr3t"})
+	bundle.Spec.OutputsExport.Name = "db-credentials"
+	stale := &core_v1.Secret{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Namespace:       defaultNamespace,
+			Name:            "db-credentials",
+			OwnerReferences: controlledBy(bundle),
+		},
+		Data: map[string][]byte{"password": []byte("old")},
+	}
+	var created, updated []*unstructured.Unstructured
+	st := &bundleSyncTask{
+		logger:      zap.NewNop(),
+		store:       specSourceStore(t, stale),
+		smartClient: writingSmartClient{created: &created, updated: &updated},
+		bundle:      bundle,
+	}
+	retriable, err := st.exportOutputs()
+	require.NoError(t, err)
+	assert.False(t, retriable)
+	assert.Empty(t, created)
+	require.Len(t, updated, 1)
+	// Data of Secrets is base64 encoded
+	assert.Equal(t, map[string]interface{}{"password": "czNjcjN0"}, updated[0].Object["data"])
+}
+
+func TestExportOutputsNoChanges(t *testing.T) {
+	t.Parallel()
+	bundle := outputsExportBundle(smith_v1.OutputsExportConfigMap, map[string]string{"url": "https://db1"})
+	current := &core_v1.ConfigMap{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Namespace:       defaultNamespace,
+			Name:            "b1-outputs",
+			OwnerReferences: controlledBy(bundle),
+		},
+		Data: map[string]string{"url": "https://db1"},
+	}
+	testcases := map[string]struct {
+		bundle *smith_v1.Bundle
+		dryRun bool
+	}{
+		"up to date": {
+			bundle: bundle,
+		},
+		"not exported": {
+			bundle: &smith_v1.Bundle{
+				ObjectMeta: meta_v1.ObjectMeta{Namespace: defaultNamespace, Name: "b1", UID: "b1-uid"},
+			},
+		},
+		"dry-run": {
+			bundle: outputsExportBundle(smith_v1.OutputsExportSecret, map[string]string{"url": "https://db1"}),
+			dryRun: true,
+		},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var created, updated []*unstructured.Unstructured
+			st := &bundleSyncTask{
+				logger:      zap.NewNop(),
+				store:       specSourceStore(t, current),
+				smartClient: writingSmartClient{created: &created, updated: &updated},
+				bundle:      tc.bundle,
+				dryRun:      tc.dryRun,
+			}
+			_, err := st.exportOutputs()
+			require.NoError(t, err)
+			assert.Empty(t, created)
+			assert.Empty(t, updated)
+		})
+	}
+}
+
+func TestExportOutputsNotControlled(t *testing.T) {
+	t.Parallel()
+	foreign := &core_v1.ConfigMap{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Namespace: defaultNamespace,
+			Name:      "b1-outputs",
+		},
+	}
+	var created, updated []*unstructured.Unstructured
+	st := &bundleSyncTask{
+		logger:      zap.NewNop(),
+		store:       specSourceStore(t, foreign),
+		smartClient: writingSmartClient{created: &created, updated: &updated},
+		bundle:      outputsExportBundle(smith_v1.OutputsExportConfigMap, map[string]string{"url": "https://db1"}),
+	}
+	retriable, err := st.exportOutputs()
+	assert.EqualError(t, err, `ConfigMap "b1-outputs" of exported outputs exists and is not controlled by the Bundle`)
+	assert.False(t, retriable)
+	assert.Empty(t, created)
+	assert.Empty(t, updated)
+}
@@ -0,0 +1,60 @@
+package bundlec
+
+import (
+	"sort"
+
+	"github.com/atlassian/smith/pkg/refexpr"
+	"github.com/pkg/errors"
+)
+
+// substituteParameters substitutes parameters of the Bundle for references to them in all strings of the spec.
+// The spec is mutated in place. Error is returned if the spec references parameters that are not declared.
+func substituteParameters(spec map[string]interface{}, parameters map[string]string) error {
+	undeclared := make(map[string]struct{})
+	substituteObject(spec, parameters, undeclared)
+	if len(undeclared) > 0 {
+		names := make([]string, 0, len(undeclared))
+		for name := range undeclared {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return errors.Errorf("spec references parameters that are not declared in the Bundle: %q", names)
+	}
+	return nil
+}
+
+func substituteObject(obj map[string]interface{}, parameters map[string]string, undeclared map[string]struct{}) {
+	for key, value := range obj {
+		obj[key] = substituteValue(value, parameters, undeclared)
+	}
+}
+
+func substituteValue(value interface{}, parameters map[string]string, undeclared map[string]struct{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return substituteString(v, parameters, undeclared)
+	case map[string]interface{}:
+		substituteObject(v, parameters, undeclared)
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = substituteValue(elem, parameters, undeclared)
+		}
+	}
+	return value
+}
+
+func substituteString(value string, parameters map[string]string, undeclared map[string]struct{}) string {
+	return refexpr.ParameterReference.ReplaceAllStringFunc(value, func(ref string) string {
+		if ref[1] == '$' {
+			// Escaped
+			return ref[1:]
+		}
+		name := refexpr.ParameterReference.FindStringSubmatch(ref)[1]
+		param, ok := parameters[name]
+		if !ok {
+			undeclared[name] = struct{}{}
+			return ref
+		}
+		return param
+	})
+}
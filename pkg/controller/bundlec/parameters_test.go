@@ -0,0 +1,53 @@
+package bundlec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubstituteParameters(t *testing.T) {
+	t.Parallel()
+	spec := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name": "app-${params.environment}",
+		},
+		"data": map[string]interface{}{
+			"url":     "https://${params.host}:${params.port}/",
+			"escaped": "$${params.environment}",
+			"ref":     "!{ref}",
+			"count":   int64(3),
+		},
+		"list": []interface{}{"${params.environment}", map[string]interface{}{"x": "${params.host}"}},
+	}
+	err := substituteParameters(spec, map[string]string{
+		"environment": "prod",
+		"host":        "example.com",
+		"port":        "443",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name": "app-prod",
+		},
+		"data": map[string]interface{}{
+			"url":     "https://example.com:443/",
+			"escaped": "${params.environment}",
+			"ref":     "!{ref}",
+			"count":   int64(3),
+		},
+		"list": []interface{}{"prod", map[string]interface{}{"x": "example.com"}},
+	}, spec)
+}
+
+func TestSubstituteParametersUndeclared(t *testing.T) {
+	t.Parallel()
+	spec := map[string]interface{}{
+		"a": "${params.b}-${params.a}",
+		"b": "${params.b}",
+		"c": "${params.c}",
+	}
+	err := substituteParameters(spec, map[string]string{"c": "x"})
+	assert.EqualError(t, err, `spec references parameters that are not declared in the Bundle: ["a" "b"]`)
+}
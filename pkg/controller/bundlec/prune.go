@@ -0,0 +1,248 @@
+package bundlec
+
+import (
+	"sync"
+	"time"
+
+	"github.com/atlassian/smith/pkg/util"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	api_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	pruneBackoffInitial = 1 * time.Second
+	pruneBackoffMax     = 5 * time.Minute
+)
+
+// pruneFailure describes failed attempts to delete a pruned object.
+type pruneFailure struct {
+	attempts  int32
+	err       error
+	retriable bool
+	next      time.Time
+}
+
+type pruneKey struct {
+	bundle types.UID
+	ref    objectRef
+}
+
+// pruneBackoff tracks failed attempts to delete pruned objects so that each object is retried with exponential
+// backoff rather than on every sync of its Bundle. Objects that failed with a non-retriable error are retried at the
+// maximum interval because the failure may go away once permissions or the cluster configuration are fixed.
+type pruneBackoff struct {
+	now func() time.Time
+
+	mx       sync.Mutex
+	failures map[pruneKey]pruneFailure
+}
+
+func newPruneBackoff() *pruneBackoff {
+	return &pruneBackoff{
+		now:      time.Now,
+		failures: make(map[pruneKey]pruneFailure),
+	}
+}
+
+// get returns failed attempts to delete the object and how long the next attempt should be postponed for.
+// Zero attempts means there were no failures.
+func (b *pruneBackoff) get(bundle types.UID, ref objectRef) (pruneFailure, time.Duration) {
+	if b == nil {
+		return pruneFailure{}, 0
+	}
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	failure := b.failures[pruneKey{bundle: bundle, ref: ref}]
+	if failure.attempts == 0 {
+		return failure, 0
+	}
+	delay := failure.next.Sub(b.now())
+	if delay < 0 {
+		delay = 0
+	}
+	return failure, delay
+}
+
+// failed records a failed attempt to delete the object and returns the delay before the next attempt.
+func (b *pruneBackoff) failed(bundle types.UID, ref objectRef, err error, retriable bool) time.Duration {
+	if b == nil {
+		return 0
+	}
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	key := pruneKey{bundle: bundle, ref: ref}
+	failure := b.failures[key]
+	failure.attempts++
+	failure.err = err
+	failure.retriable = retriable
+	delay := pruneBackoffMax
+	if retriable {
+		delay = pruneBackoffInitial
+		for i := int32(1); i < failure.attempts && delay < pruneBackoffMax; i++ {
+			delay *= 2
+		}
+		if delay > pruneBackoffMax {
+			delay = pruneBackoffMax
+		}
+	}
+	failure.next = b.now().Add(delay)
+	b.failures[key] = failure
+	return delay
+}
+
+// succeeded forgets failed attempts to delete the object.
+func (b *pruneBackoff) succeeded(bundle types.UID, ref objectRef) {
+	if b == nil {
+		return
+	}
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	delete(b.failures, pruneKey{bundle: bundle, ref: ref})
+}
+
+// retain forgets failed attempts for objects of the Bundle that are no longer pending deletion.
+func (b *pruneBackoff) retain(bundle types.UID, objectsToDelete map[objectRef]runtime.Object) {
+	if b == nil {
+		return
+	}
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	for key := range b.failures {
+		if key.bundle != bundle {
+			continue
+		}
+		if _, ok := objectsToDelete[key.ref]; !ok {
+			delete(b.failures, key)
+		}
+	}
+}
+
+// forget forgets failed attempts for all objects of the Bundle.
+func (b *pruneBackoff) forget(bundle types.UID) {
+	b.retain(bundle, nil)
+}
+
+// isRetriableDeleteError returns true if deletion may succeed when retried without any changes to the object,
+// permissions or the cluster configuration.
+func isRetriableDeleteError(err error) bool {
+	err = errors.Cause(err)
+	return !api_errors.IsForbidden(err) &&
+		!api_errors.IsUnauthorized(err) &&
+		!api_errors.IsBadRequest(err) &&
+		!api_errors.IsInvalid(err) &&
+		!api_errors.IsMethodNotSupported(err)
+}
+
+// blockingFinalizers returns third-party finalizers of an object that has been marked for deletion.
+// Finalizers used by the garbage collector are not considered blocking.
+func blockingFinalizers(obj meta_v1.Object) []string {
+	if obj.GetDeletionTimestamp() == nil {
+		return nil
+	}
+	var result []string
+	for _, finalizer := range obj.GetFinalizers() {
+		if finalizer == meta_v1.FinalizerDeleteDependents || finalizer == meta_v1.FinalizerOrphanDependents {
+			continue
+		}
+		result = append(result, finalizer)
+	}
+	return result
+}
+
+// forceRemoveFinalizers removes allow-listed finalizers from an object that has been stuck in deletion for longer
+// than the configured timeout. The Bundle is requeued for when the timeout passes if the object is not stuck for long
+// enough yet.
+func (st *bundleSyncTask) forceRemoveFinalizers(logger *zap.Logger, ref objectRef, obj runtime.Object) error {
+	if st.finalizerRemovalTimeout <= 0 || len(st.forceRemovableFinalizers) == 0 {
+		return nil
+	}
+	m := obj.(meta_v1.Object)
+	removable := make(map[string]struct{}, len(st.forceRemovableFinalizers))
+	for _, finalizer := range st.forceRemovableFinalizers {
+		removable[finalizer] = struct{}{}
+	}
+	var newFinalizers, removed []string
+	for _, finalizer := range m.GetFinalizers() {
+		if _, ok := removable[finalizer]; ok {
+			removed = append(removed, finalizer)
+		} else {
+			newFinalizers = append(newFinalizers, finalizer)
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+	if stuckFor := time.Since(m.GetDeletionTimestamp().Time); stuckFor < st.finalizerRemovalTimeout {
+		st.requeueNoLaterThan(st.finalizerRemovalTimeout - stuckFor)
+		return nil
+	}
+	objUnstr, err := util.RuntimeToUnstructured(obj)
+	if err != nil {
+		return err
+	}
+	objUnstr.SetFinalizers(newFinalizers)
+	resClient, err := st.smartClient.ForGVK(ref.GroupVersionKind, st.namespaceOf(ref))
+	if err != nil {
+		return err
+	}
+	logger.Sugar().Warnf("Object has been stuck in deletion for more than %s, removing finalizers %q", st.finalizerRemovalTimeout, removed)
+	_, err = resClient.Update(objUnstr)
+	return errors.Wrap(err, "failed to update object")
+}
+
+// pruneRateLimiter limits deletion of pruned objects to batches of at most batchSize objects per Bundle with at
+// least interval between batches, so that pruning of many objects does not overload the API server or external
+// systems that react to deletions.
+type pruneRateLimiter struct {
+	batchSize int
+	interval  time.Duration
+	now       func() time.Time
+
+	mx        sync.Mutex
+	nextBatch map[types.UID]time.Time
+}
+
+// newPruneRateLimiter returns nil if batchSize is not positive, i.e. pruning is not rate limited.
+func newPruneRateLimiter(batchSize int, interval time.Duration) *pruneRateLimiter {
+	if batchSize <= 0 {
+		return nil
+	}
+	return &pruneRateLimiter{
+		batchSize: batchSize,
+		interval:  interval,
+		now:       time.Now,
+		nextBatch: make(map[types.UID]time.Time),
+	}
+}
+
+// take starts a new batch of deletions for the Bundle if the previous batch was long enough ago.
+// Returns the number of objects that can be deleted now, when the next batch can be started and how long that is
+// from now. A negative number of objects means there is no limit.
+func (l *pruneRateLimiter) take(bundle types.UID) (int, time.Time, time.Duration) {
+	if l == nil {
+		return -1, time.Time{}, 0
+	}
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	now := l.now()
+	if next := l.nextBatch[bundle]; now.Before(next) {
+		return 0, next, next.Sub(now)
+	}
+	next := now.Add(l.interval)
+	l.nextBatch[bundle] = next
+	return l.batchSize, next, l.interval
+}
+
+// forget forgets the last batch of the Bundle.
+func (l *pruneRateLimiter) forget(bundle types.UID) {
+	if l == nil {
+		return
+	}
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	delete(l.nextBatch, bundle)
+}
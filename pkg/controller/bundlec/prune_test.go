@@ -0,0 +1,365 @@
+package bundlec
+
+import (
+	"testing"
+	"time"
+
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	core_v1 "k8s.io/api/core/v1"
+	api_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestBlockingFinalizers(t *testing.T) {
+	t.Parallel()
+	now := meta_v1.Now()
+	cm := &core_v1.ConfigMap{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:              "cm",
+			DeletionTimestamp: &now,
+			Finalizers:        []string{meta_v1.FinalizerDeleteDependents, "example.com/cleanup", meta_v1.FinalizerOrphanDependents},
+		},
+	}
+	assert.Equal(t, []string{"example.com/cleanup"}, blockingFinalizers(cm))
+}
+
+func TestBlockingFinalizersNotDeleted(t *testing.T) {
+	t.Parallel()
+	cm := &core_v1.ConfigMap{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:       "cm",
+			Finalizers: []string{"example.com/cleanup"},
+		},
+	}
+	assert.Empty(t, blockingFinalizers(cm))
+}
+
+func TestForceRemoveFinalizers(t *testing.T) {
+	t.Parallel()
+	ref := objectRef{GroupVersionKind: core_v1.SchemeGroupVersion.WithKind("ConfigMap"), Name: "cm"}
+	stuck := func(since time.Duration, finalizers ...string) *core_v1.ConfigMap {
+		deletionTimestamp := meta_v1.NewTime(time.Now().Add(-since))
+		return &core_v1.ConfigMap{
+			TypeMeta: meta_v1.TypeMeta{
+				Kind:       "ConfigMap",
+				APIVersion: core_v1.SchemeGroupVersion.String(),
+			},
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:              "cm",
+				DeletionTimestamp: &deletionTimestamp,
+				Finalizers:        finalizers,
+			},
+		}
+	}
+	testcases := map[string]struct {
+		obj *core_v1.ConfigMap
+		// expectFinalizers is nil if the object is not updated
+		expectFinalizers []string
+		expectRequeue    bool
+	}{
+		"removes allow-listed finalizers after timeout": {
+			obj:              stuck(2*time.Hour, "example.com/stuck", "example.com/other"),
+			expectFinalizers: []string{"example.com/other"},
+		},
+		"requeues before timeout": {
+			obj:           stuck(30*time.Minute, "example.com/stuck"),
+			expectRequeue: true,
+		},
+		"ignores other finalizers": {
+			obj: stuck(30*time.Minute, "example.com/other"),
+		},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var updated []*unstructured.Unstructured
+			st := bundleSyncTask{
+				smartClient:              updatingSmartClient{updated: &updated},
+				bundle:                   &smith_v1.Bundle{ObjectMeta: meta_v1.ObjectMeta{Namespace: "ns"}},
+				finalizerRemovalTimeout:  time.Hour,
+				forceRemovableFinalizers: []string{"example.com/stuck"},
+			}
+
+			require.NoError(t, st.forceRemoveFinalizers(zap.NewNop(), ref, tc.obj))
+
+			if tc.expectFinalizers == nil {
+				assert.Empty(t, updated)
+			} else {
+				require.Len(t, updated, 1)
+				assert.Equal(t, tc.expectFinalizers, updated[0].GetFinalizers())
+			}
+			if tc.expectRequeue {
+				assert.True(t, st.requeueAfter > 0 && st.requeueAfter <= 30*time.Minute, "requeueAfter = %s", st.requeueAfter)
+			} else {
+				assert.Zero(t, st.requeueAfter)
+			}
+		})
+	}
+}
+
+func TestPruneBackoff(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	backoff := newPruneBackoff()
+	backoff.now = func() time.Time { return now }
+	ref := objectRef{GroupVersionKind: core_v1.SchemeGroupVersion.WithKind("ConfigMap"), Name: "cm"}
+	otherRef := objectRef{GroupVersionKind: core_v1.SchemeGroupVersion.WithKind("ConfigMap"), Name: "other"}
+	errFailed := errors.New("failed")
+
+	failure, delay := backoff.get("uid", ref)
+	assert.Zero(t, failure.attempts)
+	assert.Zero(t, delay)
+
+	assert.Equal(t, 1*time.Second, backoff.failed("uid", ref, errFailed, true))
+	assert.Equal(t, 2*time.Second, backoff.failed("uid", ref, errFailed, true))
+	assert.Equal(t, 4*time.Second, backoff.failed("uid", ref, errFailed, true))
+	for i := 0; i < 20; i++ {
+		backoff.failed("uid", ref, errFailed, true)
+	}
+	assert.Equal(t, pruneBackoffMax, backoff.failed("uid", ref, errFailed, true))
+
+	failure, delay = backoff.get("uid", ref)
+	assert.EqualValues(t, 24, failure.attempts)
+	assert.Equal(t, errFailed, failure.err)
+	assert.True(t, failure.retriable)
+	assert.Equal(t, pruneBackoffMax, delay)
+
+	now = now.Add(pruneBackoffMax)
+	_, delay = backoff.get("uid", ref)
+	assert.Zero(t, delay)
+
+	// Non-retriable failures are retried at the maximum interval
+	assert.Equal(t, pruneBackoffMax, backoff.failed("uid", otherRef, errFailed, false))
+	assert.Equal(t, pruneBackoffMax, backoff.failed("other-uid", otherRef, errFailed, false))
+
+	backoff.succeeded("uid", ref)
+	failure, _ = backoff.get("uid", ref)
+	assert.Zero(t, failure.attempts)
+
+	backoff.retain("uid", map[objectRef]runtime.Object{})
+	failure, _ = backoff.get("uid", otherRef)
+	assert.Zero(t, failure.attempts)
+	failure, _ = backoff.get("other-uid", otherRef)
+	assert.EqualValues(t, 1, failure.attempts)
+
+	backoff.forget("other-uid")
+	failure, _ = backoff.get("other-uid", otherRef)
+	assert.Zero(t, failure.attempts)
+}
+
+func TestPruneBackoffNil(t *testing.T) {
+	t.Parallel()
+	var backoff *pruneBackoff
+	ref := objectRef{GroupVersionKind: core_v1.SchemeGroupVersion.WithKind("ConfigMap"), Name: "cm"}
+	assert.Zero(t, backoff.failed("uid", ref, errors.New("failed"), true))
+	failure, delay := backoff.get("uid", ref)
+	assert.Zero(t, failure.attempts)
+	assert.Zero(t, delay)
+	backoff.succeeded("uid", ref)
+	backoff.forget("uid")
+}
+
+func TestIsRetriableDeleteError(t *testing.T) {
+	t.Parallel()
+	gr := schema.GroupResource{Resource: "configmaps"}
+	assert.True(t, isRetriableDeleteError(errors.New("connection refused")))
+	assert.True(t, isRetriableDeleteError(api_errors.NewServerTimeout(gr, "delete", 1)))
+	assert.True(t, isRetriableDeleteError(errors.Wrap(api_errors.NewInternalError(errors.New("boom")), "failed")))
+	assert.False(t, isRetriableDeleteError(api_errors.NewForbidden(gr, "cm", errors.New("denied"))))
+	assert.False(t, isRetriableDeleteError(errors.Wrap(api_errors.NewBadRequest("bad"), "failed")))
+}
+
+func TestDeleteRemovedResourcesBacksOff(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	backoff := newPruneBackoff()
+	backoff.now = func() time.Time { return now }
+	ref := objectRef{GroupVersionKind: core_v1.SchemeGroupVersion.WithKind("ConfigMap"), Name: "cm"}
+	st := bundleSyncTask{
+		logger:       zap.NewNop(),
+		smartClient:  failingSmartClient{},
+		bundle:       &smith_v1.Bundle{ObjectMeta: meta_v1.ObjectMeta{UID: "uid"}},
+		pruneBackoff: backoff,
+		objectsToDelete: map[objectRef]runtime.Object{
+			ref: &core_v1.ConfigMap{ObjectMeta: meta_v1.ObjectMeta{Name: "cm"}},
+		},
+	}
+
+	// Failure to get a client is not retriable
+	retriable, err := st.deleteRemovedResources()
+	require.EqualError(t, err, `failed to get client for ConfigMap "cm": no client`)
+	assert.False(t, retriable)
+	assert.Equal(t, pruneBackoffMax, st.requeueAfter)
+
+	updated, err := st.updateObjectsToDeleteStatus()
+	require.NoError(t, err)
+	assert.True(t, updated)
+	require.Len(t, st.bundle.Status.ObjectsToDelete, 1)
+	assert.EqualValues(t, 1, st.bundle.Status.ObjectsToDelete[0].FailedDeletionAttempts)
+	assert.Equal(t, `failed to get client for ConfigMap "cm": no client`, st.bundle.Status.ObjectsToDelete[0].LastDeletionError)
+
+	// Deletion is postponed but the last error is still reported
+	st.requeueAfter = 0
+	now = now.Add(time.Minute)
+	retriable, err = st.deleteRemovedResources()
+	require.EqualError(t, err, `failed to get client for ConfigMap "cm": no client`)
+	assert.False(t, retriable)
+	assert.Equal(t, pruneBackoffMax-time.Minute, st.requeueAfter)
+	failure, _ := backoff.get("uid", ref)
+	assert.EqualValues(t, 1, failure.attempts)
+
+	// Deletion is attempted again once backoff has passed
+	now = now.Add(pruneBackoffMax)
+	_, err = st.deleteRemovedResources()
+	require.Error(t, err)
+	failure, _ = backoff.get("uid", ref)
+	assert.EqualValues(t, 2, failure.attempts)
+
+	// Objects that are gone are forgotten
+	st.objectsToDelete = map[objectRef]runtime.Object{}
+	retriable, err = st.deleteRemovedResources()
+	require.NoError(t, err)
+	assert.False(t, retriable)
+	failure, _ = backoff.get("uid", ref)
+	assert.Zero(t, failure.attempts)
+}
+
+func TestPruneRateLimiter(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := newPruneRateLimiter(10, time.Minute)
+	limiter.now = func() time.Time { return now }
+
+	batch, next, delay := limiter.take("uid")
+	assert.Equal(t, 10, batch)
+	assert.Equal(t, now.Add(time.Minute), next)
+	assert.Equal(t, time.Minute, delay)
+
+	// Batches of other Bundles are not affected
+	batch, _, _ = limiter.take("other")
+	assert.Equal(t, 10, batch)
+
+	now = now.Add(20 * time.Second)
+	batch, next, delay = limiter.take("uid")
+	assert.Zero(t, batch)
+	assert.Equal(t, now.Add(40*time.Second), next)
+	assert.Equal(t, 40*time.Second, delay)
+
+	now = now.Add(40 * time.Second)
+	batch, _, _ = limiter.take("uid")
+	assert.Equal(t, 10, batch)
+
+	limiter.forget("uid")
+	batch, _, _ = limiter.take("uid")
+	assert.Equal(t, 10, batch)
+}
+
+func TestPruneRateLimiterDisabled(t *testing.T) {
+	t.Parallel()
+	limiter := newPruneRateLimiter(0, time.Minute)
+	assert.Nil(t, limiter)
+	batch, next, delay := limiter.take("uid")
+	assert.Equal(t, -1, batch)
+	assert.Zero(t, next)
+	assert.Zero(t, delay)
+	limiter.forget("uid")
+}
+
+func TestDeleteRemovedResourcesInBatches(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := newPruneRateLimiter(2, time.Minute)
+	limiter.now = func() time.Time { return now }
+	var deleted []string
+	st := bundleSyncTask{
+		logger:           zap.NewNop(),
+		smartClient:      deletingSmartClient{deleted: &deleted},
+		bundle:           &smith_v1.Bundle{ObjectMeta: meta_v1.ObjectMeta{UID: "uid"}},
+		pruneRateLimiter: limiter,
+		objectsToDelete:  map[objectRef]runtime.Object{},
+	}
+	for _, name := range []string{"cm4", "cm2", "cm0", "cm3", "cm1"} {
+		ref := objectRef{GroupVersionKind: core_v1.SchemeGroupVersion.WithKind("ConfigMap"), Name: name}
+		st.objectsToDelete[ref] = &core_v1.ConfigMap{ObjectMeta: meta_v1.ObjectMeta{Name: name}}
+	}
+	markDeleted := func() {
+		deletionTimestamp := meta_v1.NewTime(now)
+		for ref, obj := range st.objectsToDelete {
+			for _, name := range deleted {
+				if ref.Name == name {
+					obj.(*core_v1.ConfigMap).DeletionTimestamp = &deletionTimestamp
+				}
+			}
+		}
+	}
+
+	_, err := st.deleteRemovedResources()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cm0", "cm1"}, deleted)
+	assert.Equal(t, time.Minute, st.requeueAfter)
+	assert.True(t, st.pruningUpdated)
+	nextBatchTime := meta_v1.NewTime(now.Add(time.Minute))
+	assert.Equal(t, &smith_v1.PruningStatus{Pending: 3, NextBatchTime: &nextBatchTime}, st.bundle.Status.Pruning)
+	markDeleted()
+
+	// Next batch is not started before the interval has passed
+	st.pruningUpdated = false
+	st.requeueAfter = 0
+	now = now.Add(30 * time.Second)
+	_, err = st.deleteRemovedResources()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cm0", "cm1"}, deleted)
+	assert.Equal(t, 30*time.Second, st.requeueAfter)
+	assert.False(t, st.pruningUpdated)
+
+	now = now.Add(30 * time.Second)
+	_, err = st.deleteRemovedResources()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cm0", "cm1", "cm2", "cm3"}, deleted)
+	markDeleted()
+
+	now = now.Add(time.Minute)
+	_, err = st.deleteRemovedResources()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cm0", "cm1", "cm2", "cm3", "cm4"}, deleted)
+	assert.Nil(t, st.bundle.Status.Pruning)
+}
+
+func TestDeleteRemovedResourcesPaused(t *testing.T) {
+	t.Parallel()
+	var deleted []string
+	ref := objectRef{GroupVersionKind: core_v1.SchemeGroupVersion.WithKind("ConfigMap"), Name: "cm"}
+	st := bundleSyncTask{
+		logger:      zap.NewNop(),
+		smartClient: deletingSmartClient{deleted: &deleted},
+		bundle: &smith_v1.Bundle{
+			ObjectMeta: meta_v1.ObjectMeta{
+				UID:         "uid",
+				Annotations: map[string]string{smith.PrunePausedAnnotation: "true"},
+			},
+		},
+		objectsToDelete: map[objectRef]runtime.Object{
+			ref: &core_v1.ConfigMap{ObjectMeta: meta_v1.ObjectMeta{Name: "cm"}},
+		},
+	}
+
+	_, err := st.deleteRemovedResources()
+	require.NoError(t, err)
+	assert.Empty(t, deleted)
+	assert.Equal(t, &smith_v1.PruningStatus{Pending: 1, Paused: true}, st.bundle.Status.Pruning)
+
+	delete(st.bundle.Annotations, smith.PrunePausedAnnotation)
+	_, err = st.deleteRemovedResources()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cm"}, deleted)
+	assert.Nil(t, st.bundle.Status.Pruning)
+}
@@ -0,0 +1,49 @@
+package bundlec
+
+import (
+	"time"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/pkg/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// checkReadinessTimeout tracks since when the object of a resource with a readiness timeout has not been ready and
+// puts the resource into the Error state once the timeout has passed. Resources that do not depend on it are
+// processed as usual, resources that depend on it stay blocked. Returns the progress start time of the resource,
+// nil if it has no readiness timeout or its object is not waited for.
+func (st *bundleSyncTask) checkReadinessTimeout(res *smith_v1.Resource, resInfo *resourceInfo, oldStatus *smith_v1.ResourceStatus, now time.Time) *meta_v1.Time {
+	if res.ReadinessTimeoutSeconds == nil {
+		return nil
+	}
+	if _, ok := resInfo.status.(resourceStatusInProgress); !ok {
+		return nil
+	}
+	var progressStart meta_v1.Time
+	if oldStatus != nil && oldStatus.ProgressStartTime != nil && !st.validatedGenerationUpdated {
+		progressStart = *oldStatus.ProgressStartTime
+	} else {
+		// New spec restarts the clock. Status only has a precision of seconds.
+		progressStart = meta_v1.NewTime(now.Truncate(time.Second))
+	}
+	timeout := time.Duration(*res.ReadinessTimeoutSeconds) * time.Second
+	elapsed := now.Sub(progressStart.Time)
+	if elapsed >= timeout {
+		resInfo.status = resourceStatusError{
+			err:    errors.Errorf("%s has not become ready within the readiness timeout of %s", resInfo.kind(), timeout),
+			reason: smith_v1.ResourceReasonReadinessTimeout,
+		}
+	} else {
+		// Nothing may happen to the object until the timeout passes, check it again then
+		st.requeueNoLaterThan(timeout - elapsed)
+	}
+	return &progressStart
+}
+
+// progressStartEqual returns true if both times are nil or equal.
+func progressStartEqual(a, b *meta_v1.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(b)
+}
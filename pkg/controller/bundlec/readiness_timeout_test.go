@@ -0,0 +1,81 @@
+package bundlec
+
+import (
+	"testing"
+	"time"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReadinessTimeout(t *testing.T) {
+	t.Parallel()
+	timeout := int32(60)
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	res := &smith_v1.Resource{
+		Name:                    "res1",
+		ReadinessTimeoutSeconds: &timeout,
+	}
+	st := bundleSyncTask{
+		logger: zap.NewNop(),
+		bundle: &smith_v1.Bundle{},
+	}
+
+	// Not ready, clock starts
+	resInfo := &resourceInfo{status: resourceStatusInProgress{}}
+	progressStart := st.checkReadinessTimeout(res, resInfo, nil, start)
+	require.NotNil(t, progressStart)
+	assert.True(t, start.Equal(progressStart.Time))
+	assert.Equal(t, resourceStatusInProgress{}, resInfo.status)
+	assert.Equal(t, 60*time.Second, st.requeueAfter)
+	oldStatus := &smith_v1.ResourceStatus{Name: res.Name, ProgressStartTime: progressStart}
+
+	// Still within the timeout
+	st.requeueAfter = 0
+	progressStart = st.checkReadinessTimeout(res, resInfo, oldStatus, start.Add(20*time.Second))
+	assert.True(t, progressStartEqual(oldStatus.ProgressStartTime, progressStart))
+	assert.Equal(t, resourceStatusInProgress{}, resInfo.status)
+	assert.Equal(t, 40*time.Second, st.requeueAfter)
+
+	// Timeout passed, clock keeps running so that the resource stays in the Error state
+	st.requeueAfter = 0
+	progressStart = st.checkReadinessTimeout(res, resInfo, oldStatus, start.Add(60*time.Second))
+	assert.True(t, progressStartEqual(oldStatus.ProgressStartTime, progressStart))
+	require.IsType(t, resourceStatusError{}, resInfo.status)
+	resErr := resInfo.status.(resourceStatusError)
+	assert.False(t, resErr.isRetriableError)
+	assert.Equal(t, smith_v1.ResourceReasonReadinessTimeout, resErr.reason)
+	assert.EqualError(t, resErr.err, "object has not become ready within the readiness timeout of 1m0s")
+	assert.Zero(t, st.requeueAfter)
+
+	// New generation restarts the clock
+	st.validatedGenerationUpdated = true
+	resInfo = &resourceInfo{status: resourceStatusInProgress{}}
+	progressStart = st.checkReadinessTimeout(res, resInfo, oldStatus, start.Add(90*time.Second))
+	require.NotNil(t, progressStart)
+	assert.True(t, start.Add(90*time.Second).Equal(progressStart.Time))
+	assert.Equal(t, resourceStatusInProgress{}, resInfo.status)
+
+	// Ready, clock stops
+	resInfo = &resourceInfo{status: resourceStatusReady{}}
+	assert.Nil(t, st.checkReadinessTimeout(res, resInfo, oldStatus, start.Add(100*time.Second)))
+	assert.Equal(t, resourceStatusReady{}, resInfo.status)
+}
+
+func TestNoReadinessTimeout(t *testing.T) {
+	t.Parallel()
+	st := bundleSyncTask{
+		logger: zap.NewNop(),
+		bundle: &smith_v1.Bundle{},
+	}
+	start := meta_v1.NewTime(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC))
+	resInfo := &resourceInfo{status: resourceStatusInProgress{}}
+	oldStatus := &smith_v1.ResourceStatus{Name: "res1", ProgressStartTime: &start}
+
+	assert.Nil(t, st.checkReadinessTimeout(&smith_v1.Resource{Name: "res1"}, resInfo, oldStatus, start.Add(time.Hour)))
+	assert.Equal(t, resourceStatusInProgress{}, resInfo.status)
+	assert.Zero(t, st.requeueAfter)
+}
@@ -0,0 +1,72 @@
+package bundlec
+
+import (
+	"sync"
+	"time"
+
+	"github.com/atlassian/ctrl"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+)
+
+// reassertThrottle spreads the initial re-assert of healthy Bundles over time.
+// When the controller starts (e.g. after gaining leadership) all existing Bundles are queued at once.
+// Bundles that are not healthy are processed straight away, healthy ones are deferred so that at most one of them
+// is processed per interval. Each Bundle is deferred at most once. The throttle switches itself off once the
+// initial backlog has been worked through.
+type reassertThrottle struct {
+	interval time.Duration
+	now      func() time.Time
+
+	mx   sync.Mutex
+	done bool
+	next time.Time
+	seen map[ctrl.QueueKey]struct{}
+}
+
+func newReassertThrottle(interval time.Duration) *reassertThrottle {
+	return &reassertThrottle{
+		interval: interval,
+		now:      time.Now,
+		done:     interval <= 0,
+		seen:     make(map[ctrl.QueueKey]struct{}),
+	}
+}
+
+// delay returns how long processing of the Bundle should be postponed for.
+func (t *reassertThrottle) delay(key ctrl.QueueKey, healthy bool) time.Duration {
+	if t == nil {
+		return 0
+	}
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	if t.done {
+		return 0
+	}
+	if _, ok := t.seen[key]; ok {
+		// Already deferred once or processed
+		return 0
+	}
+	t.seen[key] = struct{}{}
+	if !healthy {
+		return 0
+	}
+	now := t.now()
+	if !t.next.After(now) {
+		if !t.next.IsZero() {
+			// Schedule has drained - initial re-assert is over
+			t.done = true
+			t.seen = nil
+			return 0
+		}
+		t.next = now
+	}
+	d := t.next.Sub(now)
+	t.next = t.next.Add(t.interval)
+	return d
+}
+
+// isHealthy returns true if the Bundle was ready when last processed and its spec has not changed since then.
+func isHealthy(bundle *smith_v1.Bundle) bool {
+	return bundle.Status.Ready == smith_v1.ConditionTrue &&
+		bundle.Generation == bundle.Status.ObservedGeneration
+}
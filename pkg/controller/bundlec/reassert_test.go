@@ -0,0 +1,45 @@
+package bundlec
+
+import (
+	"testing"
+	"time"
+
+	"github.com/atlassian/ctrl"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReassertThrottle(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(1000, 0)
+	throttle := newReassertThrottle(time.Second)
+	throttle.now = func() time.Time {
+		return now
+	}
+	key := func(name string) ctrl.QueueKey {
+		return ctrl.QueueKey{Namespace: "ns", Name: name}
+	}
+
+	assert.Zero(t, throttle.delay(key("h1"), true))
+	assert.Equal(t, time.Second, throttle.delay(key("h2"), true))
+	assert.Zero(t, throttle.delay(key("broken"), false), "unhealthy Bundles must not be delayed")
+	assert.Equal(t, 2*time.Second, throttle.delay(key("h3"), true))
+
+	// Deferred Bundle comes back
+	now = now.Add(time.Second)
+	assert.Zero(t, throttle.delay(key("h2"), true))
+
+	// Schedule has drained
+	now = now.Add(5 * time.Second)
+	assert.Zero(t, throttle.delay(key("h4"), true))
+	assert.Zero(t, throttle.delay(key("h5"), true))
+}
+
+func TestReassertThrottleDisabled(t *testing.T) {
+	t.Parallel()
+	throttle := newReassertThrottle(0)
+	for i := 0; i < 3; i++ {
+		assert.Zero(t, throttle.delay(ctrl.QueueKey{Namespace: "ns", Name: "b"}, true))
+	}
+	var nilThrottle *reassertThrottle
+	assert.Zero(t, nilThrottle.delay(ctrl.QueueKey{Namespace: "ns", Name: "b"}, true))
+}
@@ -0,0 +1,102 @@
+package bundlec
+
+import (
+	"fmt"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/atlassian/smith/pkg/util"
+	"github.com/atlassian/smith/pkg/util/graph"
+	"github.com/pkg/errors"
+)
+
+// resolveReferences resolves and validates references of all resources that are ready to be processed before any
+// of them are created or updated. The actual state of objects is only observed, so a resource is ready to be
+// processed if objects of its dependencies exist and are ready already.
+// Resources with references that cannot be resolved are recorded in processedResources with an error status.
+func (st *bundleSyncTask) resolveReferences(sorted []graph.V, resourceMap map[smith_v1.ResourceName]smith_v1.Resource) (retriableError bool, e error) {
+	observed := make(map[smith_v1.ResourceName]*resourceInfo, len(sorted))
+	var failedResources []smith_v1.ResourceName
+	for _, resName := range sorted {
+		resourceName := resName.(smith_v1.ResourceName)
+		res := resourceMap[resourceName]
+		rst := st.newResourceSyncTask(st.resourceLogger(&res))
+		rst.processedResources = observed
+		// Events are recorded when the resource is applied
+		rst.recorder = nil
+		if notReady := rst.checkAllDependenciesAreReady(&res); len(notReady) > 0 {
+			observed[resourceName] = &resourceInfo{
+				status: resourceStatusDependenciesNotReady{
+					dependencies: notReady,
+				},
+			}
+			continue
+		}
+		if err := rst.resolveResourceReferences(&res); err != nil {
+			isRetriable := isExternalReferenceError(errors.Cause(err))
+			st.processedResources[resourceName] = &resourceInfo{
+				status: resourceStatusError{
+					err:              err,
+					isRetriableError: isRetriable,
+				},
+			}
+			failedResources = append(failedResources, resourceName)
+			retriableError = retriableError || isRetriable
+		}
+		if res.Spec.External != nil {
+			resInfo := rst.observeExternalObject(&res)
+			observed[resourceName] = &resInfo
+			continue
+		}
+		actual, status := rst.getActualObject(&res)
+		var resInfo resourceInfo
+		switch {
+		case status != nil:
+			resInfo = resourceInfo{status: status}
+		case actual == nil:
+			resInfo = resourceInfo{status: resourceStatusInProgress{}}
+		default:
+			resInfo = rst.observeResource(actual)
+		}
+		observed[resourceName] = &resInfo
+	}
+	if len(failedResources) > 0 {
+		return retriableError, &referenceResolutionError{failedResources: failedResources}
+	}
+	return false, nil
+}
+
+// resolveResourceReferences substitutes references in a copy of the spec of the resource.
+func (st *resourceSyncTask) resolveResourceReferences(res *smith_v1.Resource) error {
+	res = res.DeepCopy() // Spec processor mutates in place
+	var objectOrPluginSpec map[string]interface{}
+	if res.Spec.Object != nil {
+		specUnstr, err := util.RuntimeToUnstructured(res.Spec.Object)
+		if err != nil {
+			return err
+		}
+		objectOrPluginSpec = specUnstr.Object
+		// Resources of a nested Bundle are resolved by the nested Bundle
+		detachNestedBundleFields(objectOrPluginSpec)
+	} else if res.Spec.Plugin != nil {
+		objectOrPluginSpec = res.Spec.Plugin.Spec
+	} else if res.Spec.External != nil {
+		// External objects have no spec to resolve references in
+		return nil
+	} else {
+		return errors.New(`neither "object" nor "plugin" field is specified`)
+	}
+	sp, err := newSpec(st.processedResources, res.References, st.secretResolver)
+	if err != nil {
+		return err
+	}
+	return sp.ProcessObject(objectOrPluginSpec)
+}
+
+// referenceResolutionError means references of some resources could not be resolved and nothing was created or updated.
+type referenceResolutionError struct {
+	failedResources []smith_v1.ResourceName
+}
+
+func (e *referenceResolutionError) Error() string {
+	return fmt.Sprintf("failed to resolve references of resource(s), no changes were applied: %q", e.failedResources)
+}
@@ -0,0 +1,126 @@
+package bundlec
+
+import (
+	"testing"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type notReadyChecker struct{}
+
+func (notReadyChecker) IsReady(*unstructured.Unstructured) (bool, bool, error) {
+	return false, false, nil
+}
+
+func TestResolveReferencesReportsAllFailures(t *testing.T) {
+	t.Parallel()
+	trueVar := true
+	bundle := &smith_v1.Bundle{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "bundle1",
+			Namespace: defaultNamespace,
+			UID:       "bundle-uid",
+		},
+	}
+	responses := make(map[string]runtime.Object)
+	resourceMap := make(map[smith_v1.ResourceName]smith_v1.Resource)
+	for name, path := range map[string]string{"a": "", "b": "data.missing", "c": "data.x", "d": "data.y"} {
+		cm := orderedDeletionConfigMap(name)
+		cm.OwnerReferences = []meta_v1.OwnerReference{{Name: bundle.Name, UID: bundle.UID, Controller: &trueVar}}
+		cm.Data = map[string]string{"x": "1"}
+		responses[name] = cm
+		res := smith_v1.Resource{
+			Name: smith_v1.ResourceName(name),
+			Spec: smith_v1.ResourceSpec{
+				Object: orderedDeletionConfigMap(name),
+			},
+		}
+		if path != "" {
+			res.References = []smith_v1.Reference{{Name: "ref", Resource: "a", Path: path}}
+			res.Spec.Object.(*core_v1.ConfigMap).Data = map[string]string{"x": "!{ref}"}
+		}
+		resourceMap[res.Name] = res
+		bundle.Spec.Resources = append(bundle.Spec.Resources, res)
+	}
+	_, sorted, err := sortBundle(bundle)
+	require.NoError(t, err)
+	st := bundleSyncTask{
+		logger:             zap.NewNop(),
+		rc:                 fakeReadyChecker{},
+		store:              fakeStore{responses: responses},
+		bundle:             bundle,
+		processedResources: make(map[smith_v1.ResourceName]*resourceInfo),
+	}
+
+	retriable, err := st.resolveReferences(sorted, resourceMap)
+	require.Error(t, err)
+	assert.False(t, retriable)
+	resolutionErr, ok := errors.Cause(err).(*referenceResolutionError)
+	require.True(t, ok)
+	assert.ElementsMatch(t, []smith_v1.ResourceName{"b", "d"}, resolutionErr.failedResources)
+
+	require.Len(t, st.processedResources, 2)
+	for _, name := range []smith_v1.ResourceName{"b", "d"} {
+		_, ok = st.processedResources[name].status.(resourceStatusError)
+		assert.True(t, ok, name)
+	}
+}
+
+func TestResolveReferencesSkipsResourcesWithDependenciesNotReady(t *testing.T) {
+	t.Parallel()
+	trueVar := true
+	bundle := &smith_v1.Bundle{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "bundle1",
+			Namespace: defaultNamespace,
+			UID:       "bundle-uid",
+		},
+		Spec: smith_v1.BundleSpec{
+			Resources: []smith_v1.Resource{
+				{
+					Name: "a",
+					Spec: smith_v1.ResourceSpec{
+						Object: orderedDeletionConfigMap("a"),
+					},
+				},
+				{
+					Name:       "b",
+					References: []smith_v1.Reference{{Name: "ref", Resource: "a", Path: "data.missing"}},
+					Spec: smith_v1.ResourceSpec{
+						Object: orderedDeletionConfigMap("b"),
+					},
+				},
+			},
+		},
+	}
+	resourceMap := make(map[smith_v1.ResourceName]smith_v1.Resource, len(bundle.Spec.Resources))
+	for _, res := range bundle.Spec.Resources {
+		resourceMap[res.Name] = res
+	}
+	a := orderedDeletionConfigMap("a")
+	a.OwnerReferences = []meta_v1.OwnerReference{{Name: bundle.Name, UID: bundle.UID, Controller: &trueVar}}
+	_, sorted, err := sortBundle(bundle)
+	require.NoError(t, err)
+	st := bundleSyncTask{
+		logger: zap.NewNop(),
+		rc:     notReadyChecker{},
+		store: fakeStore{
+			responses: map[string]runtime.Object{"a": a},
+		},
+		bundle:             bundle,
+		processedResources: make(map[smith_v1.ResourceName]*resourceInfo),
+	}
+
+	retriable, err := st.resolveReferences(sorted, resourceMap)
+	require.NoError(t, err)
+	assert.False(t, retriable)
+	assert.Empty(t, st.processedResources)
+}
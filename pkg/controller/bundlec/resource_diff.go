@@ -0,0 +1,52 @@
+package bundlec
+
+import (
+	"fmt"
+	"strings"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// maxDiffFields is the maximum number of changed fields stored in the status of a resource.
+	maxDiffFields = 20
+)
+
+// truncateFields limits the number of fields, the last element says how many fields were omitted.
+func truncateFields(fields []string) []string {
+	if len(fields) <= maxDiffFields {
+		return fields
+	}
+	truncated := make([]string, maxDiffFields, maxDiffFields+1)
+	copy(truncated, fields)
+	return append(truncated, fmt.Sprintf("... and %d more", len(fields)-maxDiffFields))
+}
+
+// describeFields formats fields for an Event message.
+func describeFields(fields []string) string {
+	if len(fields) == 0 {
+		return "no changed fields detected"
+	}
+	return "changed fields: " + strings.Join(truncateFields(fields), ", ")
+}
+
+// resourceDiff returns the diff summary to store in the status of a resource. diff is the summary of the update made
+// during this sync, nil if the object was not updated. The previous summary is retained if there was no update.
+func (st *bundleSyncTask) resourceDiff(diff *smith_v1.ResourceDiff, oldStatus *smith_v1.ResourceStatus) *smith_v1.ResourceDiff {
+	var oldDiff *smith_v1.ResourceDiff
+	if oldStatus != nil {
+		oldDiff = oldStatus.LastDiff
+	}
+	if diff == nil {
+		return oldDiff
+	}
+	result := diff.DeepCopy()
+	result.ObservedGeneration = st.bundle.Generation
+	result.LastUpdateTime = meta_v1.Now()
+	result.Updates = 1
+	if oldDiff != nil && oldDiff.ObservedGeneration == st.bundle.Generation {
+		result.Updates = oldDiff.Updates + 1
+	}
+	return result
+}
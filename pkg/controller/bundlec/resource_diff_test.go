@@ -0,0 +1,60 @@
+package bundlec
+
+import (
+	"fmt"
+	"testing"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestTruncateFields(t *testing.T) {
+	t.Parallel()
+	fields := make([]string, maxDiffFields+5)
+	for i := range fields {
+		fields[i] = fmt.Sprintf("spec.f%d", i)
+	}
+	truncated := truncateFields(fields)
+	require.Len(t, truncated, maxDiffFields+1)
+	assert.Equal(t, fields[:maxDiffFields], truncated[:maxDiffFields])
+	assert.Equal(t, "... and 5 more", truncated[maxDiffFields])
+
+	assert.Equal(t, []string{"spec.a"}, truncateFields([]string{"spec.a"}))
+}
+
+func TestResourceDiff(t *testing.T) {
+	t.Parallel()
+	st := &bundleSyncTask{
+		bundle: &smith_v1.Bundle{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Generation: 3,
+			},
+		},
+	}
+
+	// No update and no previous summary
+	assert.Nil(t, st.resourceDiff(nil, nil))
+
+	// First update at the generation
+	diff := st.resourceDiff(&smith_v1.ResourceDiff{Fields: []string{"spec.replicas"}}, &smith_v1.ResourceStatus{})
+	require.NotNil(t, diff)
+	assert.Equal(t, []string{"spec.replicas"}, diff.Fields)
+	assert.EqualValues(t, 1, diff.Updates)
+	assert.EqualValues(t, 3, diff.ObservedGeneration)
+
+	// Repeated update at the same generation
+	old := &smith_v1.ResourceStatus{LastDiff: diff}
+	diff = st.resourceDiff(&smith_v1.ResourceDiff{Fields: []string{"spec.replicas"}}, old)
+	assert.EqualValues(t, 2, diff.Updates)
+
+	// Previous summary is retained if there was no update
+	assert.Equal(t, old.LastDiff, st.resourceDiff(nil, old))
+
+	// Count restarts at a new generation
+	st.bundle.Generation = 4
+	diff = st.resourceDiff(&smith_v1.ResourceDiff{}, old)
+	assert.EqualValues(t, 1, diff.Updates)
+	assert.EqualValues(t, 4, diff.ObservedGeneration)
+}
@@ -1,10 +1,16 @@
 package bundlec
 
 import (
+	"strconv"
+
 	ctrlLogz "github.com/atlassian/ctrl/logz"
+	"github.com/atlassian/smith"
 	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/atlassian/smith/pkg/client/smart"
 	"github.com/atlassian/smith/pkg/plugin"
+	"github.com/atlassian/smith/pkg/speccheck"
 	"github.com/atlassian/smith/pkg/store"
+	"github.com/atlassian/smith/pkg/tracing"
 	"github.com/atlassian/smith/pkg/util"
 	sc_v1b1 "github.com/kubernetes-incubator/service-catalog/pkg/apis/servicecatalog/v1beta1"
 	"github.com/pkg/errors"
@@ -15,9 +21,18 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/apimachinery/pkg/util/diff"
 	k8s_json "k8s.io/apimachinery/pkg/util/json"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	// EventReasonStaleOwnerReferenceRepaired is the reason of the Event recorded when an object controlled by a
+	// previous incarnation of a Bundle is re-parented.
+	EventReasonStaleOwnerReferenceRepaired = "StaleOwnerReferenceRepaired"
+
+	// FieldManager is the name Smith applies objects under when server-side apply is used.
+	FieldManager = "smith"
 )
 
 // resourceStatus is one of "resourceStatus*" structs.
@@ -29,6 +44,13 @@ type resourceStatusDependenciesNotReady struct {
 	dependencies []smith_v1.ResourceName
 }
 
+// resourceStatusMissingAPI means resource processing is blocked because the API for its kind is not available.
+// This happens if the CRD for the resource was observed and then deleted, or if the CRD an external resource
+// waits for has not been created yet.
+type resourceStatusMissingAPI struct {
+	gvk schema.GroupVersionKind
+}
+
 // resourceStatusInProgress means resource is being processed by its controller.
 type resourceStatusInProgress struct {
 }
@@ -41,6 +63,8 @@ type resourceStatusReady struct {
 type resourceStatusError struct {
 	err              error
 	isRetriableError bool
+	// reason overrides the reason of the Error condition. Optional.
+	reason string
 }
 
 type resourceInfo struct {
@@ -49,6 +73,15 @@ type resourceInfo struct {
 
 	// if actual is a ServiceBinding, we resolve the secret once it's been processed.
 	serviceBindingSecret *core_v1.Secret
+
+	// plannedChange is the change that would be made to the object in dry-run mode. Nil if there is none.
+	plannedChange *smith_v1.PlannedChange
+
+	// diff summarizes the update of the object made because it differed from the spec. Nil if it was not updated.
+	diff *smith_v1.ResourceDiff
+
+	// external means the object is not managed by the Bundle, it is only waited for.
+	external bool
 }
 
 func (ri *resourceInfo) isReady() bool {
@@ -56,6 +89,14 @@ func (ri *resourceInfo) isReady() bool {
 	return ok
 }
 
+// kind returns the kind of the object of the resource or "object" if it is not known yet.
+func (ri *resourceInfo) kind() string {
+	if ri.actual == nil || ri.actual.GetKind() == "" {
+		return "object"
+	}
+	return ri.actual.GetKind()
+}
+
 func (ri *resourceInfo) fetchError() (bool, error) {
 	if rse, ok := ri.status.(resourceStatusError); ok {
 		return rse.isRetriableError, rse.err
@@ -64,8 +105,15 @@ func (ri *resourceInfo) fetchError() (bool, error) {
 }
 
 type resourceSyncTask struct {
-	logger             *zap.Logger
-	smartClient        SmartClient
+	logger *zap.Logger
+	// span of the processing of the resource. May be nil.
+	span         *tracing.Span
+	smartClient  SmartClient
+	applyClient  ApplyClient
+	dryRunClient DryRunClient
+	// strictClient is set if the Bundle opted into strict field validation. May be nil.
+	strictClient       StrictClient
+	secretResolver     SecretResolver
 	rc                 ReadyChecker
 	store              Store
 	specCheck          SpecCheck
@@ -74,6 +122,26 @@ type resourceSyncTask struct {
 	pluginContainers   map[smith_v1.PluginName]plugin.PluginContainer
 	scheme             *runtime.Scheme
 	catalog            *store.Catalog
+	// Kinds of CRDs that were watched and then deleted. May be nil.
+	removedAPIs *removedAPIs
+
+	// observeOnly means the object is not created or updated, only its state is observed.
+	observeOnly bool
+	// dryRun means the object is not created or updated, the change that would be made is planned instead.
+	dryRun bool
+	// migratedFrom is the API version declared in the Bundle if the object has been migrated to another version.
+	migratedFrom string
+	// diff is set by updateResource once the object has been updated.
+	diff *smith_v1.ResourceDiff
+	// repairStaleOwnerReferences means objects controlled by a previous incarnation of the Bundle are re-parented.
+	repairStaleOwnerReferences bool
+	// strictOwnership means objects labeled with the name of another Bundle are not managed.
+	strictOwnership bool
+	// crossNamespaceResources means the resource may declare a namespace other than the namespace of the Bundle.
+	crossNamespaceResources bool
+	// fieldOwnership means external changes of fields set from the spec are reported.
+	fieldOwnership bool
+	recorder       record.EventRecorder
 }
 
 func (st *resourceSyncTask) processResource(res *smith_v1.Resource) resourceInfo {
@@ -97,11 +165,20 @@ func (st *resourceSyncTask) processResource(res *smith_v1.Resource) resourceInfo
 	notReadyDependencies := st.checkAllDependenciesAreReady(res)
 	if len(notReadyDependencies) > 0 {
 		st.logger.Sugar().Infof("Dependencies required by resource but not ready: %q", notReadyDependencies)
-		return resourceInfo{
+		resInfo := resourceInfo{
 			status: resourceStatusDependenciesNotReady{
 				dependencies: notReadyDependencies,
 			},
 		}
+		if st.dryRun {
+			resInfo.plannedChange = st.planDependenciesNotReady(res)
+		}
+		return resInfo
+	}
+
+	// External objects are only observed
+	if res.Spec.External != nil {
+		return st.observeExternalObject(res)
 	}
 
 	// Try to get the resource. We do a read first to avoid generating unnecessary events.
@@ -111,19 +188,23 @@ func (st *resourceSyncTask) processResource(res *smith_v1.Resource) resourceInfo
 			status: status,
 		}
 	}
+	if st.observeOnly {
+		return st.observeResource(actual)
+	}
 
 	// Eval spec
 	spec, err := st.evalSpec(res, actual)
 	if err != nil {
 		return resourceInfo{
 			status: resourceStatusError{
-				err: err,
+				err:              err,
+				isRetriableError: isExternalReferenceError(errors.Cause(err)),
 			},
 		}
 	}
 
 	// Force Service Catalog to update service instances when secrets they depend change
-	spec, err = st.forceServiceInstanceUpdates(spec, actual, st.bundle.Namespace)
+	spec, err = st.forceServiceInstanceUpdates(spec, actual, st.objectNamespace(spec))
 	if err != nil {
 		return resourceInfo{
 			status: resourceStatusError{
@@ -132,9 +213,43 @@ func (st *resourceSyncTask) processResource(res *smith_v1.Resource) resourceInfo
 		}
 	}
 
+	// Merge spec into the actual object according to the update strategy
+	spec, err = st.applyUpdateStrategy(res, spec, actual)
+	if err != nil {
+		return resourceInfo{
+			status: resourceStatusError{
+				err: err,
+			},
+		}
+	}
+
+	// Fields the resource ignores are taken from the actual object
+	spec, err = ignoreFields(res, spec, actual)
+	if err != nil {
+		return resourceInfo{
+			status: resourceStatusError{
+				err: err,
+			},
+		}
+	}
+
+	if st.dryRun {
+		return st.planResource(res, spec, actual)
+	}
+
+	// Jobs and similar objects cannot be updated in place
+	if isRunToCompletion(spec.GroupVersionKind().GroupKind()) {
+		return st.syncJob(spec, actual)
+	}
+
 	// Create or update resource
 	resUpdated, retriable, err := st.createOrUpdate(spec, actual)
 	if err != nil {
+		if smart.IsScopeMismatch(err) {
+			return resourceInfo{
+				status: scopeMismatch(spec.GroupVersionKind(), err),
+			}
+		}
 		return resourceInfo{
 			actual: resUpdated,
 			status: resourceStatusError{
@@ -144,6 +259,14 @@ func (st *resourceSyncTask) processResource(res *smith_v1.Resource) resourceInfo
 		}
 	}
 
+	if st.applyClient != nil && actual != nil {
+		// Fields owned by other field managers are preserved by server-side apply, so a difference
+		// from the spec is expected and is not a sign of an infinite update cycle.
+		resInfo := st.checkReadiness(resUpdated)
+		resInfo.diff = st.diff
+		return resInfo
+	}
+
 	// Check if the resource actually matches the spec to detect infinite update cycles
 	updatedSpec, match, err := st.specCheck.CompareActualVsSpec(spec, resUpdated)
 	if err != nil {
@@ -151,22 +274,56 @@ func (st *resourceSyncTask) processResource(res *smith_v1.Resource) resourceInfo
 			status: resourceStatusError{
 				err: errors.Wrap(err, "specification re-check failed"),
 			},
+			diff: st.diff,
 		}
 	}
 	if !match {
-		st.logger.Sugar().Warnf("Objects are different after specification re-check:\n%s",
-			diff.ObjectReflectDiff(updatedSpec.Object, resUpdated.Object))
+		drift := speccheck.ComputeDrift(resUpdated, updatedSpec)
+		st.logger.Warn("Objects are different after specification re-check", zap.Strings("fields", drift.Fields))
+		err = errors.New("specification of the created/updated object does not match the desired spec")
+		// Without strict field validation the API server drops unknown fields silently so detect them ourselves
+		if dropped := droppedFields(spec.Object, resUpdated.Object); len(dropped) > 0 {
+			err = errors.Wrapf(err, "fields were dropped by the server (unknown or misspelled?): %q", dropped)
+		}
 		return resourceInfo{
 			status: resourceStatusError{
-				err: errors.New("specification of the created/updated object does not match the desired spec"),
+				err: err,
 			},
+			diff: st.diff,
 		}
 	}
 
+	resInfo := st.checkReadiness(resUpdated)
+	resInfo.diff = st.diff
+	return resInfo
+}
+
+// observeResource checks readiness of the existing object without creating or updating it.
+// Used for resources that are not the target of a partial sync.
+func (st *resourceSyncTask) observeResource(actual runtime.Object) resourceInfo {
+	if actual == nil {
+		st.logger.Info("Object not found, not creating because of partial sync")
+		return resourceInfo{
+			status: resourceStatusInProgress{},
+		}
+	}
+	actualUnstr, err := util.RuntimeToUnstructured(actual)
+	if err != nil {
+		return resourceInfo{
+			status: resourceStatusError{
+				err: err,
+			},
+		}
+	}
+	return st.checkReadiness(actualUnstr)
+}
+
+// checkReadiness checks if the object is ready and extracts its outputs.
+func (st *resourceSyncTask) checkReadiness(obj *unstructured.Unstructured) resourceInfo {
 	// Check if resource is ready
-	if ready, retriable, err := st.rc.IsReady(resUpdated); err != nil {
+	if ready, retriable, err := st.rc.IsReady(obj); err != nil {
 		return resourceInfo{
-			actual: resUpdated,
+			actual: obj,
 			status: resourceStatusError{
 				err:              errors.Wrap(err, "readiness check failed"),
 				isRetriableError: retriable,
@@ -174,56 +331,78 @@ func (st *resourceSyncTask) processResource(res *smith_v1.Resource) resourceInfo
 		}
 	} else if !ready {
 		return resourceInfo{
-			actual: resUpdated,
+			actual: obj,
 			status: resourceStatusInProgress{},
 		}
 	}
 
 	// Augment with binding output (used for references)
-	bindingSecret, err := st.maybeExtractBindingSecret(resUpdated)
+	bindingSecret, retriable, err := st.maybeExtractBindingSecret(obj)
 	if err != nil {
 		return resourceInfo{
-			actual: resUpdated,
+			actual: obj,
 			status: resourceStatusError{
-				err: err,
+				err:              err,
+				isRetriableError: retriable,
 			},
 		}
 	}
 
 	return resourceInfo{
-		actual:               resUpdated,
+		actual:               obj,
 		status:               resourceStatusReady{},
 		serviceBindingSecret: bindingSecret,
 	}
 }
 
-func (st *resourceSyncTask) maybeExtractBindingSecret(obj *unstructured.Unstructured) (*core_v1.Secret, error) {
+// maybeExtractBindingSecret returns the output Secret of a ready ServiceBinding.
+// A missing Secret is a retriable error because Service Catalog creates the Secret right before marking the binding
+// ready and it may not be in the informer cache yet. The Secret is controlled by the ServiceBinding so an event for
+// it does not trigger processing of the Bundle.
+func (st *resourceSyncTask) maybeExtractBindingSecret(obj *unstructured.Unstructured) (secretRet *core_v1.Secret, retriableError bool, e error) {
 	if obj.GroupVersionKind() != sc_v1b1.SchemeGroupVersion.WithKind("ServiceBinding") {
-		return nil, nil
+		return nil, false, nil
 	}
 	actual, err := st.scheme.ConvertToVersion(obj, sc_v1b1.SchemeGroupVersion)
 	if err != nil {
-		return nil, errors.WithStack(err)
+		return nil, false, errors.WithStack(err)
 	}
 	serviceBinding := actual.(*sc_v1b1.ServiceBinding)
 	secret, exists, err := st.store.Get(core_v1.SchemeGroupVersion.WithKind("Secret"), serviceBinding.Namespace, serviceBinding.Spec.SecretName)
 	if err != nil {
-		return nil, errors.Wrap(err, "error finding output Secret")
+		return nil, false, errors.Wrap(err, "error finding output Secret")
 	}
 	if !exists {
-		return nil, errors.New("cannot find output Secret")
+		return nil, true, errors.Errorf("cannot find output Secret %q", serviceBinding.Spec.SecretName)
 	}
-	return secret.(*core_v1.Secret), nil
+	return secret.(*core_v1.Secret), false, nil
 }
 
 func (st *resourceSyncTask) checkAllDependenciesAreReady(res *smith_v1.Resource) []smith_v1.ResourceName {
 	// No len here because dependencies can occur more than once in reference list
 	notReadyDependenciesSet := make(map[smith_v1.ResourceName]struct{})
 	for _, reference := range res.References {
+		if reference.IsExternal() {
+			continue
+		}
 		if !st.processedResources[reference.Resource].isReady() {
 			notReadyDependenciesSet[reference.Resource] = struct{}{}
 		}
 	}
+	// Quorum is satisfied if enough resources are ready. Otherwise all not ready resources of it are reported.
+	for _, quorum := range res.Quorums {
+		var notReady []smith_v1.ResourceName
+		for _, member := range quorum.Resources {
+			if !st.processedResources[member].isReady() {
+				notReady = append(notReady, member)
+			}
+		}
+		if len(quorum.Resources)-len(notReady) < quorum.MinReady {
+			for _, member := range notReady {
+				notReadyDependenciesSet[member] = struct{}{}
+			}
+		}
+	}
 	notReadyDependencies := make([]smith_v1.ResourceName, 0, len(notReadyDependenciesSet))
 	for resourceName := range notReadyDependenciesSet {
 		notReadyDependencies = append(notReadyDependencies, resourceName)
@@ -250,7 +429,19 @@ func (st *resourceSyncTask) getActualObject(res *smith_v1.Resource) (runtime.Obj
 			err: errors.New(`neither "object" nor "plugin" field is specified`),
 		}
 	}
-	actual, exists, err := st.store.Get(gvk, st.bundle.Namespace, name)
+	namespace, err := st.targetNamespace(res)
+	if err != nil {
+		return nil, resourceStatusError{err: err}
+	}
+	if st.removedAPIs.isRemoved(gvk.GroupKind()) && !st.store.HasInformer(gvk) {
+		// CRD has been deleted. Processing will resume once it is back.
+		// Kinds without an informer for other reasons fail below.
+		st.logger.Sugar().Debugf("CRD for %s has been deleted, resource is blocked", gvk)
+		return nil, resourceStatusMissingAPI{
+			gvk: gvk,
+		}
+	}
+	actual, exists, err := st.store.Get(gvk, namespace, name)
 	if err != nil {
 		return nil, resourceStatusError{
 			err: errors.Wrap(err, "failed to get object from the Store"),
@@ -268,21 +459,86 @@ func (st *resourceSyncTask) getActualObject(res *smith_v1.Resource) (runtime.Obj
 		}
 	}
 
+	// Objects in other namespaces are tracked with labels because they cannot have owner references to the Bundle
+	if namespace != st.bundle.Namespace {
+		adopt, err := st.checkTrackedBy(actualMeta, res.Adopt)
+		if err != nil {
+			recordEvent(st.recorder, st.bundle, core_v1.EventTypeWarning, EventReasonObjectConflict, "Cannot manage %s %q in namespace %q: %v", gvk.Kind, name, namespace, err)
+			return nil, resourceStatusError{err: err}
+		}
+		if adopt {
+			// Labels are replaced when the object is updated
+			st.logger.Info("Object in another namespace is not tracked by the Bundle, adopting it")
+			st.recordChangeEvent(EventReasonObjectAdopted,
+				"Adopting %s %q in namespace %q", gvk.Kind, name, namespace)
+		}
+		return actual, nil
+	}
+
 	// Check that this bundle controls the object
 	if !meta_v1.IsControlledBy(actualMeta, st.bundle) {
 		ref := meta_v1.GetControllerOf(actualMeta)
 		var err error
-		if ref == nil {
+		if isRetainedBy(actualMeta, st.bundle) {
+			// Owner references are set when the object is updated
+			st.logger.Info("Object has been retained by a Bundle with the same name, adopting it")
+			st.recordChangeEvent(EventReasonObjectAdopted,
+				"Adopting %s %q retained by a Bundle with the same name", gvk.Kind, name)
+			return actual, nil
+		} else if ref == nil && res.Adopt {
+			// Owner references are set when the object is updated
+			st.logger.Info("Object does not have a controller, adopting it")
+			st.recordChangeEvent(EventReasonObjectAdopted,
+				"Adopting %s %q that does not have a controller", gvk.Kind, name)
+			return actual, nil
+		} else if ref == nil {
 			err = errors.New("object is not controlled by the Bundle and does not have a controller at all")
+		} else if isPreviousIncarnation(ref, st.bundle) {
+			if st.repairStaleOwnerReferences {
+				// Owner references are replaced when the object is updated
+				st.logger.Sugar().Infof("Object is controlled by a previous incarnation of the Bundle (uid=%s), re-parenting it", ref.UID)
+				st.recordChangeEvent(EventReasonStaleOwnerReferenceRepaired,
+					"Re-parenting %s %q controlled by a previous incarnation of the Bundle (uid=%s)", gvk.Kind, name, ref.UID)
+				return actual, nil
+			}
+			err = errors.Errorf("object is controlled by a previous incarnation of the Bundle (uid=%s), not by the Bundle (uid=%s)",
+				ref.UID, st.bundle.UID)
 		} else {
 			err = errors.Errorf("object is controlled by apiVersion=%s, kind=%s, name=%s, uid=%s, not by the Bundle (uid=%s)",
 				ref.APIVersion, ref.Kind, ref.Name, ref.UID, st.bundle.UID)
 		}
+		recordEvent(st.recorder, st.bundle, core_v1.EventTypeWarning, EventReasonObjectConflict, "Cannot manage %s %q: %v", gvk.Kind, name, err)
 		return nil, resourceStatusError{err: err}
 	}
+
+	// A missing label is set when the object is updated
+	if st.strictOwnership {
+		if message, mismatch := ownershipMismatch(st.bundle, actualMeta, true); mismatch {
+			recordEvent(st.recorder, st.bundle, core_v1.EventTypeWarning, EventReasonOwnershipMismatch, "Cannot manage %s %q: %s", gvk.Kind, name, message)
+			return nil, resourceStatusError{err: errors.New(message)}
+		}
+	}
 	return actual, nil
 }
 
+// recordChangeEvent records an Event about a change that is made to the object when it is updated, e.g. adoption.
+// Nothing is recorded in dry-run mode because the change is only planned.
+func (st *resourceSyncTask) recordChangeEvent(reason, messageFmt string, args ...interface{}) {
+	if st.dryRun {
+		return
+	}
+	recordEvent(st.recorder, st.bundle, core_v1.EventTypeNormal, reason, messageFmt, args...)
+}
+
+// isPreviousIncarnation returns true if the owner reference points at a Bundle with the same name but a different UID.
+// Names are unique within a namespace so such a Bundle has been deleted and re-created.
+func isPreviousIncarnation(ref *meta_v1.OwnerReference, bundle *smith_v1.Bundle) bool {
+	return ref.APIVersion == smith_v1.BundleResourceGroupVersion &&
+		ref.Kind == smith_v1.BundleResourceKind &&
+		ref.Name == bundle.Name &&
+		ref.UID != bundle.UID
+}
+
 // prevalidate does as much validation as possible before doing any real work.
 func (st *resourceSyncTask) prevalidate(res *smith_v1.Resource) error {
 	sp, err := newExamplesSpec(res.References)
@@ -322,6 +578,9 @@ func (st *resourceSyncTask) prevalidate(res *smith_v1.Resource) error {
 					return errors.Wrap(err, "unable to unmarshal ServiceInstance resource parameters as object")
 				}
 
+				if err := substituteParameters(parameters, st.bundle.Spec.Parameters); err != nil {
+					return err
+				}
 				if err := sp.ProcessObject(parameters); err != nil {
 					return err
 				}
@@ -341,6 +600,9 @@ func (st *resourceSyncTask) prevalidate(res *smith_v1.Resource) error {
 		// (low priority, not currently used)
 	} else if res.Spec.Plugin != nil {
 		if res.Spec.Plugin.Spec != nil {
+			if err := substituteParameters(res.Spec.Plugin.Spec, st.bundle.Spec.Parameters); err != nil {
+				return err
+			}
 			if err := sp.ProcessObject(res.Spec.Plugin.Spec); err != nil {
 				return err
 			}
@@ -375,15 +637,31 @@ func (st *resourceSyncTask) evalSpec(res *smith_v1.Resource, actual runtime.Obje
 		return nil, errors.New(`neither "object" nor "plugin" field is specified`)
 	}
 
+	// Resources of a nested Bundle are processed by the nested Bundle
+	reattach := func() {}
+	if res.Spec.Object != nil {
+		reattach = detachNestedBundleFields(objectOrPluginSpec)
+	}
+
+	// Substitute parameters
+	if err := substituteParameters(objectOrPluginSpec, st.bundle.Spec.Parameters); err != nil {
+		return nil, err
+	}
+
 	// Process references
-	sp, err := newSpec(st.processedResources, res.References)
+	sp, err := newSpec(st.processedResources, res.References, st.secretResolver)
 	if err != nil {
 		return nil, err
 	}
 	if err := sp.ProcessObject(objectOrPluginSpec); err != nil {
 		return nil, err
 	}
+	reattach()
 
+	namespace, err := st.targetNamespace(res)
+	if err != nil {
+		return nil, err
+	}
 	var obj *unstructured.Unstructured
 	if res.Spec.Object != nil {
 		obj = &unstructured.Unstructured{
@@ -391,52 +669,66 @@ func (st *resourceSyncTask) evalSpec(res *smith_v1.Resource, actual runtime.Obje
 		}
 	} else if res.Spec.Plugin != nil {
 		var err error
-		obj, err = st.evalPluginSpec(res, actual)
+		obj, err = st.evalPluginSpec(res, namespace, actual)
 		if err != nil {
 			return nil, err
 		}
 	} else {
 		return nil, errors.New(`neither "object" nor "plugin" field is specified`)
 	}
+	if objNamespace := obj.GetNamespace(); objNamespace != "" && objNamespace != namespace {
+		return nil, errors.Errorf("object is in namespace %q but the resource is in namespace %q", objNamespace, namespace)
+	}
+	crossNamespace := namespace != st.bundle.Namespace
+	if crossNamespace {
+		obj.SetNamespace(namespace)
+	}
 
 	// Update label to point at the parent bundle
-	obj.SetLabels(mergeLabels(st.bundle.Labels, obj.GetLabels()))
-
-	// Update OwnerReferences
-	trueRef := true
-	refs := obj.GetOwnerReferences()
-	for i, ref := range refs {
-		if ref.Controller != nil && *ref.Controller {
-			return nil, errors.Errorf("cannot create resource with controller owner reference %v", ref)
-		}
-		refs[i].BlockOwnerDeletion = &trueRef
-	}
-	// Hardcode APIVersion/Kind because of https://github.com/kubernetes/client-go/issues/60
-	refs = append(refs, meta_v1.OwnerReference{
-		APIVersion:         smith_v1.BundleResourceGroupVersion,
-		Kind:               smith_v1.BundleResourceKind,
-		Name:               st.bundle.Name,
-		UID:                st.bundle.UID,
-		Controller:         &trueRef,
-		BlockOwnerDeletion: &trueRef,
-	})
-	for _, dep := range res.References {
-		processedObj := st.processedResources[dep.Resource].actual // this is ok because we've checked earlier that resources contains all dependencies
-		refs = append(refs, meta_v1.OwnerReference{
-			APIVersion:         processedObj.GetAPIVersion(),
-			Kind:               processedObj.GetKind(),
-			Name:               processedObj.GetName(),
-			UID:                processedObj.GetUID(),
-			BlockOwnerDeletion: &trueRef,
-		})
-	}
-	obj.SetOwnerReferences(refs)
+	obj.SetLabels(mergeLabels(st.bundle.Labels, obj.GetLabels(), map[string]string{
+		smith.BundleNameLabel: smith.LabelValue(st.bundle.Name),
+	}))
+	if crossNamespace {
+		setTrackingLabels(st.bundle, obj)
+	}
+
+	// Apply cloud identity annotations
+	if err := applyIdentityPolicies(st.bundle.Spec.IdentityPolicies, obj); err != nil {
+		return nil, err
+	}
+
+	// Update OwnerReferences. Owner references across namespaces are not allowed.
+	if !crossNamespace {
+		dependencies := make([]*unstructured.Unstructured, 0, len(res.References))
+		for _, dep := range res.References {
+			if dep.IsExternal() {
+				continue
+			}
+			depInfo := st.processedResources[dep.Resource] // this is ok because we've checked earlier that resources contains all dependencies
+			if depInfo.external {
+				// Objects must not be garbage collected when an object Smith does not manage is deleted
+				continue
+			}
+			depActual := depInfo.actual
+			if depActual != nil && depActual.GetNamespace() != namespace {
+				continue
+			}
+			dependencies = append(dependencies, depActual)
+		}
+		if err := setOwnerReferences(res.MetadataPolicy, st.bundle, dependencies, obj); err != nil {
+			return nil, err
+		}
+	}
+	applyMetadataPolicyFinalizers(res.MetadataPolicy, obj)
+	if err := applyDeletionPolicy(res.DeletionPolicy, actual, obj); err != nil {
+		return nil, err
+	}
 
 	return obj, nil
 }
 
 // evalPluginSpec evaluates the plugin resource specification and returns the result.
-func (st *resourceSyncTask) evalPluginSpec(res *smith_v1.Resource, actual runtime.Object) (*unstructured.Unstructured, error) {
+func (st *resourceSyncTask) evalPluginSpec(res *smith_v1.Resource, namespace string, actual runtime.Object) (*unstructured.Unstructured, error) {
 	pluginContainer, ok := st.pluginContainers[res.Spec.Plugin.Name]
 	if !ok {
 		return nil, errors.Errorf("no such plugin %q", res.Spec.Plugin.Name)
@@ -453,18 +745,20 @@ func (st *resourceSyncTask) evalPluginSpec(res *smith_v1.Resource, actual runtim
 	}
 
 	result, err := pluginContainer.Plugin.Process(res.Spec.Plugin.Spec, &plugin.Context{
-		Namespace:    st.bundle.Namespace,
-		Actual:       actual,
-		Dependencies: dependencies,
+		Namespace:      namespace,
+		Actual:         actual,
+		Dependencies:   dependencies,
+		DryRun:         st.dryRun,
+		IdempotencyKey: idempotencyKey(st.bundle, res.Name),
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Make sure plugin is returning us something that obeys the PluginSpec.
-	object, err := util.RuntimeToUnstructured(result.Object)
+	// Make sure plugin is returning us something that obeys the plugin contract.
+	object, err := pluginContainer.ValidateResult(result)
 	if err != nil {
-		return nil, errors.Wrap(err, "plugin output cannot be converted from runtime.Object")
+		return nil, errors.Wrapf(err, "plugin %q produced invalid output", res.Spec.Plugin.Name)
 	}
 	expectedGVK := pluginContainer.Plugin.Describe().GVK
 	if object.GroupVersionKind() != expectedGVK {
@@ -479,6 +773,9 @@ func (st *resourceSyncTask) evalPluginSpec(res *smith_v1.Resource, actual runtim
 func (st *resourceSyncTask) prepareDependencies(references []smith_v1.Reference) (map[smith_v1.ResourceName]plugin.Dependency, error) {
 	dependencies := make(map[smith_v1.ResourceName]plugin.Dependency)
 	for _, reference := range references {
+		if reference.IsExternal() {
+			continue
+		}
 		if _, ok := dependencies[reference.Resource]; ok {
 			// References could refer to the same resource as a previous one.
 			continue
@@ -536,7 +833,16 @@ func (st *resourceSyncTask) prepareServiceBindingDependency(dependency *plugin.D
 func (st *resourceSyncTask) createOrUpdate(spec *unstructured.Unstructured, actual runtime.Object) (actualRet *unstructured.Unstructured, retriableRet bool, e error) {
 	// Prepare client
 	gvk := spec.GroupVersionKind()
-	resClient, err := st.smartClient.ForGVK(gvk, st.bundle.Namespace)
+	span := st.span.StartChild("Object.createOrUpdate",
+		tracing.String("object.gvk", gvk.String()),
+		tracing.String("object.namespace", st.objectNamespace(spec)),
+		tracing.String("object.name", spec.GetName()),
+		tracing.String("object.exists", strconv.FormatBool(actual != nil)))
+	defer func() {
+		span.SetError(e)
+		span.Finish()
+	}()
+	resClient, err := st.smartClient.ForGVK(gvk, st.objectNamespace(spec))
 	if err != nil {
 		return nil, false, errors.Wrapf(err, "failed to get the client for %q", gvk)
 	}
@@ -550,9 +856,16 @@ func (st *resourceSyncTask) createOrUpdate(spec *unstructured.Unstructured, actu
 
 func (st *resourceSyncTask) createResource(resClient dynamic.ResourceInterface, spec *unstructured.Unstructured) (actualRet *unstructured.Unstructured, retriableError bool, e error) {
 	gvk := spec.GroupVersionKind()
-	response, err := resClient.Create(spec)
+	var response *unstructured.Unstructured
+	var err error
+	if st.strictClient != nil {
+		response, err = st.strictClient.StrictCreate(spec, st.objectNamespace(spec))
+	} else {
+		response, err = resClient.Create(spec)
+	}
 	if err == nil {
 		st.logger.Info("Object created", ctrlLogz.ObjectGk(gvk.GroupKind()), ctrlLogz.Object(spec))
+		recordEvent(st.recorder, st.bundle, core_v1.EventTypeNormal, EventReasonObjectCreated, "Created %s %q", gvk.Kind, spec.GetName())
 		return response, false, nil
 	}
 	if api_errors.IsAlreadyExists(err) {
@@ -560,6 +873,10 @@ func (st *resourceSyncTask) createResource(resClient dynamic.ResourceInterface,
 		err = api_errors.NewConflict(schema.GroupResource{Group: gvk.Group, Resource: gvk.Kind}, spec.GetName(), err)
 		return nil, false, errors.Wrap(err, "object found, but not in Store yet (will re-process)")
 	}
+	if st.strictClient != nil && api_errors.IsBadRequest(err) {
+		// Unknown or duplicate fields, retrying does not help until the spec is fixed
+		return nil, false, errors.Wrap(err, "object was rejected by strict field validation")
+	}
 	// Unexpected error, will retry
 	return nil, true, err
 }
@@ -575,21 +892,88 @@ func (st *resourceSyncTask) updateResource(resClient dynamic.ResourceInterface,
 		st.logger.Info("Object has correct spec", ctrlLogz.Object(spec))
 		return updated, false, nil
 	}
+	actualUnstr, err := util.RuntimeToUnstructured(actual)
+	if err != nil {
+		return nil, false, err
+	}
+	drift := speccheck.ComputeDrift(actualUnstr, updated)
+	var modifiedExternally []string
+	if st.fieldOwnership {
+		modifiedExternally, err = speccheck.ExternallyModifiedFields(actualUnstr)
+		if err != nil {
+			st.logger.Warn("Failed to detect external changes of the object", ctrlLogz.Object(spec), zap.Error(err))
+		}
+	}
 
 	// Update if different
-	updated, err = resClient.Update(updated)
+	switch {
+	case st.applyClient != nil:
+		updated, err = st.applyResource(spec)
+	case st.strictClient != nil:
+		updated, err = st.strictClient.StrictUpdate(updated, st.objectNamespace(spec))
+	default:
+		updated, err = resClient.Update(updated)
+	}
 	if err != nil {
 		if api_errors.IsConflict(err) {
 			// We let the next processKey() iteration, triggered by someone else updating the resource, finish the work.
 			return nil, false, errors.Wrap(err, "object update resulted in conflict (will re-process)")
 		}
+		if st.strictClient != nil && st.applyClient == nil && api_errors.IsBadRequest(err) {
+			// Unknown or duplicate fields, retrying does not help until the spec is fixed
+			return nil, false, errors.Wrap(err, "object was rejected by strict field validation")
+		}
 		// Unexpected error, will retry
 		return nil, true, err
 	}
-	st.logger.Info("Object updated", ctrlLogz.Object(spec))
+	st.logger.Info("Object updated", ctrlLogz.Object(spec), zap.Strings("fields", drift.Fields))
+	st.diff = &smith_v1.ResourceDiff{
+		Fields: truncateFields(drift.Fields),
+	}
+	recordEvent(st.recorder, st.bundle, core_v1.EventTypeNormal, EventReasonObjectUpdated, "Updated %s %q, %s", spec.GetKind(), spec.GetName(), describeFields(drift.Fields))
+	if len(modifiedExternally) > 0 {
+		st.logger.Warn("Reverted external changes of the object", ctrlLogz.Object(spec), zap.Strings("fields", modifiedExternally))
+		recordEvent(st.recorder, st.bundle, core_v1.EventTypeWarning, EventReasonObjectModifiedExternally,
+			"Reverted changes of %s %q made by something else, %s", spec.GetKind(), spec.GetName(), describeFields(modifiedExternally))
+	}
+	if st.migratedFrom != "" {
+		recordEvent(st.recorder, st.bundle, core_v1.EventTypeNormal, EventReasonObjectMigrated,
+			"Rewrote %s %q at %s, the Bundle declares it at %s", spec.GetKind(), spec.GetName(), spec.GetAPIVersion(), st.migratedFrom)
+	}
 	return updated, false, nil
 }
 
+// applyResource applies the spec using server-side apply. Unlike an update it does not need the resource version
+// of the actual object, so it does not fail on conflict if the object has been changed concurrently, and it
+// preserves fields set by other controllers that are not in the spec.
+func (st *resourceSyncTask) applyResource(spec *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	obj := spec.DeepCopy()
+	delete(obj.Object, "status")
+	obj.SetResourceVersion("")
+	return st.applyClient.Apply(obj, st.objectNamespace(spec), FieldManager)
+}
+
+// idempotencyKey returns a key that identifies a resource of a particular incarnation of a Bundle.
+func idempotencyKey(bundle *smith_v1.Bundle, resName smith_v1.ResourceName) string {
+	return string(bundle.UID) + "/" + string(resName)
+}
+
+// resourceObject returns the GVK and the name of the object of the resource.
+// Returns false if the resource is invoking a plugin that is not available.
+func resourceObject(res *smith_v1.Resource, pluginContainers map[smith_v1.PluginName]plugin.PluginContainer) (schema.GroupVersionKind, string, bool) {
+	if res.Spec.Object != nil {
+		return res.Spec.Object.GetObjectKind().GroupVersionKind(), res.Spec.Object.(meta_v1.Object).GetName(), true
+	}
+	if res.Spec.Plugin != nil {
+		pluginContainer, ok := pluginContainers[res.Spec.Plugin.Name]
+		if !ok {
+			return schema.GroupVersionKind{}, "", false
+		}
+		return pluginContainer.Plugin.Describe().GVK, res.Spec.Plugin.ObjectName, true
+	}
+	return schema.GroupVersionKind{}, "", false
+}
+
 func mergeLabels(labels ...map[string]string) map[string]string {
 	result := make(map[string]string)
 	for _, m := range labels {
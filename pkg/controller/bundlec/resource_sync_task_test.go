@@ -0,0 +1,321 @@
+package bundlec
+
+import (
+	"sort"
+	"testing"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/atlassian/smith/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+	core_v1 "k8s.io/api/core/v1"
+	api_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestCheckAllDependenciesAreReadyQuorum(t *testing.T) {
+	t.Parallel()
+	res := &smith_v1.Resource{
+		Name: "app",
+		Quorums: []smith_v1.Quorum{
+			{
+				Resources: []smith_v1.ResourceName{"broker1", "broker2", "broker3"},
+				MinReady:  2,
+			},
+		},
+	}
+	st := resourceSyncTask{
+		processedResources: map[smith_v1.ResourceName]*resourceInfo{
+			"broker1": {status: resourceStatusReady{}},
+			"broker2": {status: resourceStatusInProgress{}},
+			"broker3": {status: resourceStatusInProgress{}},
+		},
+	}
+	notReady := st.checkAllDependenciesAreReady(res)
+	sort.Slice(notReady, func(i, j int) bool {
+		return notReady[i] < notReady[j]
+	})
+	assert.Equal(t, []smith_v1.ResourceName{"broker2", "broker3"}, notReady)
+
+	st.processedResources["broker3"] = &resourceInfo{status: resourceStatusReady{}}
+	assert.Empty(t, st.checkAllDependenciesAreReady(res))
+}
+
+func TestGetActualObjectPreviousIncarnation(t *testing.T) {
+	t.Parallel()
+	tr := true
+	configMap := &core_v1.ConfigMap{
+		TypeMeta: meta_v1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: core_v1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name: "map1",
+			OwnerReferences: []meta_v1.OwnerReference{
+				{
+					APIVersion: smith_v1.BundleResourceGroupVersion,
+					Kind:       smith_v1.BundleResourceKind,
+					Name:       "bundle1",
+					UID:        "old-uid",
+					Controller: &tr,
+				},
+			},
+		},
+	}
+	res := &smith_v1.Resource{
+		Name: "map",
+		Spec: smith_v1.ResourceSpec{
+			Object: &core_v1.ConfigMap{
+				TypeMeta:   configMap.TypeMeta,
+				ObjectMeta: meta_v1.ObjectMeta{Name: "map1"},
+			},
+		},
+	}
+	newTask := func(repair bool, recorder record.EventRecorder) *resourceSyncTask {
+		return &resourceSyncTask{
+			logger: zap.NewNop(),
+			store: fakeStore{
+				responses: map[string]runtime.Object{
+					"map1": configMap,
+				},
+			},
+			bundle: &smith_v1.Bundle{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name: "bundle1",
+					UID:  "new-uid",
+				},
+			},
+			repairStaleOwnerReferences: repair,
+			recorder:                   recorder,
+		}
+	}
+
+	_, status := newTask(false, nil).getActualObject(res)
+	require.IsType(t, resourceStatusError{}, status)
+	assert.Contains(t, status.(resourceStatusError).err.Error(), "previous incarnation")
+
+	recorder := record.NewFakeRecorder(1)
+	actual, status := newTask(true, recorder).getActualObject(res)
+	require.Nil(t, status)
+	assert.Equal(t, configMap, actual)
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, EventReasonStaleOwnerReferenceRepaired)
+}
+
+func TestGetActualObjectAdopt(t *testing.T) {
+	t.Parallel()
+	tr := true
+	unowned := &core_v1.ConfigMap{
+		TypeMeta: meta_v1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: core_v1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name: "map1",
+		},
+	}
+	controlled := unowned.DeepCopy()
+	controlled.Name = "map2"
+	controlled.OwnerReferences = []meta_v1.OwnerReference{
+		{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+			Name:       "app",
+			UID:        "app-uid",
+			Controller: &tr,
+		},
+	}
+	resource := func(objectName string, adopt bool) *smith_v1.Resource {
+		return &smith_v1.Resource{
+			Name:  "map",
+			Adopt: adopt,
+			Spec: smith_v1.ResourceSpec{
+				Object: &core_v1.ConfigMap{
+					TypeMeta:   unowned.TypeMeta,
+					ObjectMeta: meta_v1.ObjectMeta{Name: objectName},
+				},
+			},
+		}
+	}
+	newTask := func(recorder record.EventRecorder) *resourceSyncTask {
+		return &resourceSyncTask{
+			logger: zap.NewNop(),
+			store: fakeStore{
+				responses: map[string]runtime.Object{
+					"map1": unowned,
+					"map2": controlled,
+				},
+			},
+			bundle: &smith_v1.Bundle{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name: "bundle1",
+					UID:  "uid1",
+				},
+			},
+			recorder: recorder,
+		}
+	}
+
+	_, status := newTask(nil).getActualObject(resource("map1", false))
+	require.IsType(t, resourceStatusError{}, status)
+	assert.Contains(t, status.(resourceStatusError).err.Error(), "does not have a controller at all")
+
+	recorder := record.NewFakeRecorder(1)
+	actual, status := newTask(recorder).getActualObject(resource("map1", true))
+	require.Nil(t, status)
+	assert.Equal(t, unowned, actual)
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, EventReasonObjectAdopted)
+
+	// Objects controlled by something else are never adopted
+	_, status = newTask(nil).getActualObject(resource("map2", true))
+	require.IsType(t, resourceStatusError{}, status)
+	assert.Contains(t, status.(resourceStatusError).err.Error(), "kind=Deployment")
+}
+
+type fakeApplyClient struct {
+	namespace    string
+	fieldManager string
+	applied      *unstructured.Unstructured
+}
+
+func (c *fakeApplyClient) Apply(obj *unstructured.Unstructured, namespace, fieldManager string) (*unstructured.Unstructured, error) {
+	c.namespace = namespace
+	c.fieldManager = fieldManager
+	c.applied = obj
+	return obj, nil
+}
+
+func TestApplyResource(t *testing.T) {
+	t.Parallel()
+	spec := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name":            "map1",
+				"resourceVersion": "123",
+			},
+			"data": map[string]interface{}{
+				"a": "b",
+			},
+			"status": map[string]interface{}{
+				"x": "y",
+			},
+		},
+	}
+	applyClient := &fakeApplyClient{}
+	st := &resourceSyncTask{
+		applyClient: applyClient,
+		bundle: &smith_v1.Bundle{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:      "bundle1",
+				Namespace: "ns1",
+			},
+		},
+	}
+
+	_, err := st.applyResource(spec)
+	require.NoError(t, err)
+
+	assert.Equal(t, "ns1", applyClient.namespace)
+	assert.Equal(t, FieldManager, applyClient.fieldManager)
+	require.NotNil(t, applyClient.applied)
+	assert.Empty(t, applyClient.applied.GetResourceVersion())
+	assert.NotContains(t, applyClient.applied.Object, "status")
+	assert.Equal(t, map[string]interface{}{"a": "b"}, applyClient.applied.Object["data"])
+
+	// Spec must not be mutated
+	assert.Equal(t, "123", spec.GetResourceVersion())
+	assert.Contains(t, spec.Object, "status")
+}
+
+type fakeStrictClient struct {
+	err     error
+	created []string
+	updated []string
+}
+
+func (c *fakeStrictClient) StrictCreate(obj *unstructured.Unstructured, namespace string) (*unstructured.Unstructured, error) {
+	c.created = append(c.created, namespace+"/"+obj.GetName())
+	return obj, c.err
+}
+
+func (c *fakeStrictClient) StrictUpdate(obj *unstructured.Unstructured, namespace string) (*unstructured.Unstructured, error) {
+	c.updated = append(c.updated, namespace+"/"+obj.GetName())
+	return obj, c.err
+}
+
+func TestStrictFieldValidation(t *testing.T) {
+	t.Parallel()
+	rejected := api_errors.NewBadRequest(`ConfigMap in version "v1" cannot be handled as a ConfigMap: strict decoding error: unknown field "datta"`)
+	testcases := map[string]struct {
+		update    bool
+		err       error
+		expectErr string
+		retriable bool
+	}{
+		"create": {},
+		"update": {
+			update: true,
+		},
+		"create rejected": {
+			err:       rejected,
+			expectErr: "object was rejected by strict field validation",
+		},
+		"update rejected": {
+			update:    true,
+			err:       rejected,
+			expectErr: "object was rejected by strict field validation",
+		},
+		"create failed": {
+			err:       api_errors.NewInternalError(errors.New("etcd is down")),
+			expectErr: "etcd is down",
+			retriable: true,
+		},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			strictClient := &fakeStrictClient{err: tc.err}
+			st := &resourceSyncTask{
+				logger:       zaptest.NewLogger(t),
+				strictClient: strictClient,
+				specCheck:    fakeSpecCheck{},
+				bundle: &smith_v1.Bundle{
+					ObjectMeta: meta_v1.ObjectMeta{
+						Name:      "bundle1",
+						Namespace: "ns1",
+					},
+				},
+			}
+			spec, err := util.RuntimeToUnstructured(dryRunConfigMap(map[string]string{"a": "b"}))
+			require.NoError(t, err)
+			var retriable bool
+			if tc.update {
+				// The dynamic client is not used with strict field validation
+				_, retriable, err = st.updateResource(nil, spec, dryRunConfigMap(map[string]string{"a": "c"}))
+				assert.Equal(t, []string{"ns1/map1"}, strictClient.updated)
+				assert.Empty(t, strictClient.created)
+			} else {
+				_, retriable, err = st.createResource(nil, spec)
+				assert.Equal(t, []string{"ns1/map1"}, strictClient.created)
+				assert.Empty(t, strictClient.updated)
+			}
+			if tc.expectErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectErr)
+			}
+			assert.Equal(t, tc.retriable, retriable)
+		})
+	}
+}
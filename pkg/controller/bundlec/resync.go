@@ -0,0 +1,32 @@
+package bundlec
+
+import (
+	"time"
+
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"go.uber.org/zap"
+)
+
+// minResyncPeriod is the shortest period Bundles are re-synced with, so that a typo in an annotation does not make
+// the controller process a Bundle in a hot loop.
+const minResyncPeriod = 10 * time.Second
+
+// resyncPeriod returns how long after a sync the Bundle is processed again even if nothing has changed, so that
+// out-of-band changes of its objects that were not noticed are reverted. The ResyncPeriodAnnotation overrides the
+// period of the controller, invalid values of the annotation are ignored. Zero means no periodic re-sync.
+func resyncPeriod(logger *zap.Logger, bundle *smith_v1.Bundle, defaultPeriod time.Duration) time.Duration {
+	period := defaultPeriod
+	if value, ok := bundle.Annotations[smith.ResyncPeriodAnnotation]; ok {
+		p, err := time.ParseDuration(value)
+		if err != nil || p < 0 {
+			logger.Sugar().Warnf("Ignoring invalid value %q of %s annotation", value, smith.ResyncPeriodAnnotation)
+		} else {
+			period = p
+		}
+	}
+	if period > 0 && period < minResyncPeriod {
+		period = minResyncPeriod
+	}
+	return period
+}
@@ -0,0 +1,69 @@
+package bundlec
+
+import (
+	"testing"
+	"time"
+
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestResyncPeriod(t *testing.T) {
+	t.Parallel()
+	testcases := map[string]struct {
+		annotations   map[string]string
+		defaultPeriod time.Duration
+		expected      time.Duration
+	}{
+		"disabled": {
+			expected: 0,
+		},
+		"default": {
+			defaultPeriod: 10 * time.Minute,
+			expected:      10 * time.Minute,
+		},
+		"override": {
+			annotations:   map[string]string{smith.ResyncPeriodAnnotation: "2m"},
+			defaultPeriod: 10 * time.Minute,
+			expected:      2 * time.Minute,
+		},
+		"override without default": {
+			annotations: map[string]string{smith.ResyncPeriodAnnotation: "1h"},
+			expected:    time.Hour,
+		},
+		"disabled by annotation": {
+			annotations:   map[string]string{smith.ResyncPeriodAnnotation: "0"},
+			defaultPeriod: 10 * time.Minute,
+			expected:      0,
+		},
+		"invalid annotation": {
+			annotations:   map[string]string{smith.ResyncPeriodAnnotation: "often"},
+			defaultPeriod: 10 * time.Minute,
+			expected:      10 * time.Minute,
+		},
+		"negative annotation": {
+			annotations:   map[string]string{smith.ResyncPeriodAnnotation: "-1m"},
+			defaultPeriod: 10 * time.Minute,
+			expected:      10 * time.Minute,
+		},
+		"too short": {
+			annotations: map[string]string{smith.ResyncPeriodAnnotation: "1ms"},
+			expected:    minResyncPeriod,
+		},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			bundle := &smith_v1.Bundle{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Annotations: tc.annotations,
+				},
+			}
+			assert.Equal(t, tc.expected, resyncPeriod(zaptest.NewLogger(t), bundle, tc.defaultPeriod))
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package bundlec
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/atlassian/ctrl"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+)
+
+// retryBackoff tracks consecutive failed attempts to process Bundles. A Bundle that failed with a retriable error is
+// requeued after an exponentially growing delay. Once it has been retried maxRetries times the error is treated as
+// terminal. The count is reset when processing succeeds or fails with a terminal error.
+// Attempts made before the scheduled retry, e.g. because one of the objects of the Bundle has changed, do not count
+// so that updates of the Bundle status do not escalate the backoff.
+// Delays and the number of retries can be overridden per Bundle with a retry policy.
+type retryBackoff struct {
+	defaults retryParams
+
+	mx        sync.Mutex
+	failures  map[ctrl.QueueKey]int
+	schedules map[ctrl.QueueKey]retrySchedule
+	// policies are parameters of Bundles that have a retry policy.
+	policies map[ctrl.QueueKey]retryParams
+}
+
+// retryParams are parameters of the backoff of a Bundle.
+type retryParams struct {
+	baseDelay time.Duration
+	maxDelay  time.Duration
+	// Zero means there is no limit.
+	maxRetries int
+}
+
+// retrySchedule is when a Bundle that failed with a retriable error is processed again.
+type retrySchedule struct {
+	// attempts is the number of consecutive failed attempts.
+	attempts int
+	at       time.Time
+	// requeue is the value of the RequeueAnnotation when the retry was scheduled.
+	requeue string
+}
+
+func newRetryBackoff(baseDelay, maxDelay time.Duration, maxRetries int) *retryBackoff {
+	return &retryBackoff{
+		defaults: retryParams{
+			baseDelay:  baseDelay,
+			maxDelay:   maxDelay,
+			maxRetries: maxRetries,
+		},
+		failures:  make(map[ctrl.QueueKey]int),
+		schedules: make(map[ctrl.QueueKey]retrySchedule),
+		policies:  make(map[ctrl.QueueKey]retryParams),
+	}
+}
+
+// setPolicy sets the retry policy of the Bundle until its failed attempts are forgotten. Nil policy means
+// the defaults are used.
+func (b *retryBackoff) setPolicy(key ctrl.QueueKey, policy *smith_v1.RetryPolicy) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	if policy == nil {
+		delete(b.policies, key)
+		return
+	}
+	params := b.defaults
+	if policy.MaxAttempts != nil {
+		params.maxRetries = int(*policy.MaxAttempts)
+	}
+	if policy.BaseDelaySeconds > 0 {
+		params.baseDelay = time.Duration(policy.BaseDelaySeconds) * time.Second
+	}
+	if policy.MaxDelaySeconds > 0 {
+		params.maxDelay = time.Duration(policy.MaxDelaySeconds) * time.Second
+	}
+	b.policies[key] = params
+}
+
+// requeueRequested resets the number of failed attempts if the value of the RequeueAnnotation has changed since
+// the retry was scheduled.
+func (b *retryBackoff) requeueRequested(key ctrl.QueueKey, requeue string) {
+	b.mx.Lock()
+	schedule, ok := b.schedules[key]
+	b.mx.Unlock()
+	if ok && schedule.requeue != requeue {
+		b.forget(key)
+	}
+}
+
+// schedule records a failed attempt to process the Bundle and returns when it should be processed again.
+// If a retry is already scheduled for later, it is returned as is. budget is called to get the delay imposed by
+// the retry budget on top of the backoff and may be nil.
+func (b *retryBackoff) schedule(key ctrl.QueueKey, requeue string, now time.Time, budget func() time.Duration) retrySchedule {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	if schedule, ok := b.schedules[key]; ok && now.Before(schedule.at) {
+		return schedule
+	}
+	delay := b.failedLocked(key)
+	if budget != nil {
+		if budgetDelay := budget(); budgetDelay > delay {
+			delay = budgetDelay
+		}
+	}
+	schedule := retrySchedule{
+		attempts: b.failures[key],
+		at:       now.Add(delay),
+		requeue:  requeue,
+	}
+	b.schedules[key] = schedule
+	return schedule
+}
+
+// exhausted returns the number of retries if the Bundle must not be retried anymore and zero otherwise.
+func (b *retryBackoff) exhausted(key ctrl.QueueKey) int {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	maxRetries := b.paramsLocked(key).maxRetries
+	if maxRetries <= 0 {
+		return 0
+	}
+	if retries := b.failures[key]; retries >= maxRetries {
+		return retries
+	}
+	return 0
+}
+
+// failed records a failed attempt to process the Bundle and returns the delay before the next attempt.
+func (b *retryBackoff) failed(key ctrl.QueueKey) time.Duration {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	return b.failedLocked(key)
+}
+
+func (b *retryBackoff) failedLocked(key ctrl.QueueKey) time.Duration {
+	params := b.paramsLocked(key)
+	exp := b.failures[key]
+	b.failures[key] = exp + 1
+	// Same as the exponential failure rate limiter of the work queue
+	backoff := float64(params.baseDelay.Nanoseconds()) * math.Pow(2, float64(exp))
+	if backoff > math.MaxInt64 {
+		return params.maxDelay
+	}
+	delay := time.Duration(backoff)
+	if delay > params.maxDelay {
+		return params.maxDelay
+	}
+	return delay
+}
+
+func (b *retryBackoff) paramsLocked(key ctrl.QueueKey) retryParams {
+	if params, ok := b.policies[key]; ok {
+		return params
+	}
+	return b.defaults
+}
+
+// forget resets the number of failed attempts to process the Bundle.
+func (b *retryBackoff) forget(key ctrl.QueueKey) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	delete(b.failures, key)
+	delete(b.schedules, key)
+	delete(b.policies, key)
+}
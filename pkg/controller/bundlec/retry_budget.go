@@ -0,0 +1,102 @@
+package bundlec
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+)
+
+// NamespaceRetryBudget limits the rate at which Bundles of a namespace are retried after failing with a retriable
+// error. Each namespace has a token bucket, a retry takes a token and is deferred until one is available. This way
+// a namespace full of failing Bundles gets a bounded share of worker time and API calls and cannot starve Bundles
+// of other namespaces.
+type NamespaceRetryBudget struct {
+	limit rate.Limit
+	burst int
+	now   func() time.Time
+
+	exhausted    *prometheus.CounterVec
+	deferredTime *prometheus.CounterVec
+
+	mx        sync.Mutex
+	buckets   map[string]*rate.Limiter
+	lastSweep time.Time
+}
+
+// NewNamespaceRetryBudget returns a budget that allows qps retries per second per namespace with bursts of up to burst
+// retries.
+func NewNamespaceRetryBudget(qps float64, burst int) (*NamespaceRetryBudget, error) {
+	if qps <= 0 {
+		return nil, errors.Errorf("retry budget QPS must be positive, got %f", qps)
+	}
+	if burst < 1 {
+		return nil, errors.Errorf("retry budget burst must be at least 1, got %d", burst)
+	}
+	return &NamespaceRetryBudget{
+		limit: rate.Limit(qps),
+		burst: burst,
+		now:   time.Now,
+		exhausted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "smith",
+			Subsystem: "bundle",
+			Name:      "retry_budget_exhausted_total",
+			Help:      "Number of retries of Bundles deferred because the retry budget of their namespace was exhausted",
+		}, []string{"namespace"}),
+		deferredTime: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "smith",
+			Subsystem: "bundle",
+			Name:      "retry_budget_deferred_seconds_total",
+			Help:      "Total time retries of Bundles were deferred for because the retry budget of their namespace was exhausted",
+		}, []string{"namespace"}),
+		buckets: make(map[string]*rate.Limiter),
+	}, nil
+}
+
+// RegisterMetrics registers metrics of the budget with the registerer.
+func (b *NamespaceRetryBudget) RegisterMetrics(registerer prometheus.Registerer) error {
+	for _, c := range []prometheus.Collector{b.exhausted, b.deferredTime} {
+		if err := registerer.Register(c); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// reserve takes a token from the bucket of the namespace and returns how long the retry must be deferred for
+// until the token is available.
+func (b *NamespaceRetryBudget) reserve(namespace string) time.Duration {
+	now := b.now()
+	b.mx.Lock()
+	b.sweep(now)
+	bucket, ok := b.buckets[namespace]
+	if !ok {
+		bucket = rate.NewLimiter(b.limit, b.burst)
+		b.buckets[namespace] = bucket
+	}
+	delay := bucket.ReserveN(now, 1).DelayFrom(now)
+	b.mx.Unlock()
+	if delay > 0 {
+		b.exhausted.WithLabelValues(namespace).Inc()
+		b.deferredTime.WithLabelValues(namespace).Add(delay.Seconds())
+	}
+	return delay
+}
+
+// sweep drops buckets that are full. A full bucket is indistinguishable from a new one, so this only bounds memory
+// usage. Buckets are swept at most once per the time it takes to refill a bucket.
+// Must be called with the mutex held.
+func (b *NamespaceRetryBudget) sweep(now time.Time) {
+	refill := time.Duration(float64(b.burst) / float64(b.limit) * float64(time.Second))
+	if now.Sub(b.lastSweep) < refill {
+		return
+	}
+	b.lastSweep = now
+	for namespace, bucket := range b.buckets {
+		if bucket.AllowN(now, b.burst) {
+			delete(b.buckets, namespace)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package bundlec
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamespaceRetryBudget(t *testing.T) {
+	t.Parallel()
+	b, err := NewNamespaceRetryBudget(1, 2)
+	require.NoError(t, err)
+	now := time.Unix(1000, 0)
+	b.now = func() time.Time { return now }
+
+	// Burst is available straight away
+	assert.Zero(t, b.reserve("ns1"))
+	assert.Zero(t, b.reserve("ns1"))
+	// Budget is exhausted, retries are spread out
+	assert.Equal(t, 1*time.Second, b.reserve("ns1"))
+	assert.Equal(t, 2*time.Second, b.reserve("ns1"))
+
+	// Other namespaces are not affected
+	assert.Zero(t, b.reserve("ns2"))
+
+	// Tokens are replenished over time
+	now = now.Add(3 * time.Second)
+	assert.Zero(t, b.reserve("ns1"))
+	assert.Equal(t, 1*time.Second, b.reserve("ns1"))
+}
+
+func TestNamespaceRetryBudgetSweep(t *testing.T) {
+	t.Parallel()
+	b, err := NewNamespaceRetryBudget(1, 1)
+	require.NoError(t, err)
+	now := time.Unix(1000, 0)
+	b.now = func() time.Time { return now }
+
+	b.reserve("ns1")
+	b.reserve("ns2")
+	b.reserve("ns2")
+	now = now.Add(1500 * time.Millisecond)
+	// ns1 is full and is swept, ns2 still owes a token
+	b.reserve("ns3")
+	assert.Len(t, b.buckets, 2)
+	assert.Contains(t, b.buckets, "ns2")
+	assert.Contains(t, b.buckets, "ns3")
+}
+
+func TestNewNamespaceRetryBudgetValidation(t *testing.T) {
+	t.Parallel()
+	_, err := NewNamespaceRetryBudget(0, 1)
+	assert.Error(t, err)
+	_, err = NewNamespaceRetryBudget(1, 0)
+	assert.Error(t, err)
+}
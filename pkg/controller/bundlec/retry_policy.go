@@ -0,0 +1,51 @@
+package bundlec
+
+import (
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/pkg/errors"
+)
+
+// allRetryClasses are all retry classes in the order they are checked against a retry policy.
+var allRetryClasses = []smith_v1.RetryClass{
+	smith_v1.RetryClassResourceError,
+	smith_v1.RetryClassExternalReference,
+	smith_v1.RetryClassPruning,
+}
+
+// resourceRetryClass returns the class of a retriable error of a resource.
+func resourceRetryClass(err error) smith_v1.RetryClass {
+	if isExternalReferenceError(errors.Cause(err)) {
+		return smith_v1.RetryClassExternalReference
+	}
+	return smith_v1.RetryClassResourceError
+}
+
+// processRetryClass returns the class of a retriable error of processing that is not an error of a resource.
+// Such errors are failures to resolve references before applying resources and failures to prune objects.
+func processRetryClass(err error) smith_v1.RetryClass {
+	if _, ok := errors.Cause(err).(*referenceResolutionError); ok {
+		return smith_v1.RetryClassExternalReference
+	}
+	return smith_v1.RetryClassPruning
+}
+
+// notAllowedRetryClass returns the first of the classes that the retry policy does not allow to retry.
+// A Bundle is only retried if errors of all classes it failed with may be retried.
+func notAllowedRetryClass(policy *smith_v1.RetryPolicy, classes map[smith_v1.RetryClass]struct{}) (smith_v1.RetryClass, bool) {
+	if policy == nil || len(policy.RetryOn) == 0 {
+		return "", false
+	}
+	allowed := make(map[smith_v1.RetryClass]struct{}, len(policy.RetryOn))
+	for _, class := range policy.RetryOn {
+		allowed[class] = struct{}{}
+	}
+	for _, class := range allRetryClasses {
+		if _, ok := classes[class]; !ok {
+			continue
+		}
+		if _, ok := allowed[class]; !ok {
+			return class, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,57 @@
+package bundlec
+
+import (
+	"testing"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryClasses(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, smith_v1.RetryClassResourceError, resourceRetryClass(errors.New("boom")))
+	assert.Equal(t, smith_v1.RetryClassExternalReference, resourceRetryClass(errors.Wrap(&externalReferenceError{}, "boom")))
+	assert.Equal(t, smith_v1.RetryClassExternalReference, processRetryClass(errors.Wrap(&referenceResolutionError{}, "boom")))
+	assert.Equal(t, smith_v1.RetryClassPruning, processRetryClass(errors.New("boom")))
+}
+
+func TestNotAllowedRetryClass(t *testing.T) {
+	t.Parallel()
+	classes := map[smith_v1.RetryClass]struct{}{
+		smith_v1.RetryClassResourceError:     {},
+		smith_v1.RetryClassExternalReference: {},
+	}
+	testcases := map[string]struct {
+		policy  *smith_v1.RetryPolicy
+		class   smith_v1.RetryClass
+		refused bool
+	}{
+		"no policy": {},
+		"all classes": {
+			policy: &smith_v1.RetryPolicy{},
+		},
+		"allowed": {
+			policy: &smith_v1.RetryPolicy{RetryOn: []smith_v1.RetryClass{smith_v1.RetryClassExternalReference, smith_v1.RetryClassResourceError}},
+		},
+		"one not allowed": {
+			policy:  &smith_v1.RetryPolicy{RetryOn: []smith_v1.RetryClass{smith_v1.RetryClassResourceError}},
+			class:   smith_v1.RetryClassExternalReference,
+			refused: true,
+		},
+		"none allowed": {
+			policy:  &smith_v1.RetryPolicy{RetryOn: []smith_v1.RetryClass{smith_v1.RetryClassPruning}},
+			class:   smith_v1.RetryClassResourceError,
+			refused: true,
+		},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			class, refused := notAllowedRetryClass(tc.policy, classes)
+			assert.Equal(t, tc.refused, refused)
+			assert.Equal(t, tc.class, class)
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package bundlec
+
+import (
+	"testing"
+	"time"
+
+	"github.com/atlassian/ctrl"
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRetryBackoff(t *testing.T) {
+	t.Parallel()
+	b := newRetryBackoff(time.Second, 5*time.Second, 3)
+	key := ctrl.QueueKey{Namespace: "ns", Name: "b"}
+	other := ctrl.QueueKey{Namespace: "ns", Name: "other"}
+
+	assert.Zero(t, b.exhausted(key))
+	assert.Equal(t, 1*time.Second, b.failed(key))
+	assert.Equal(t, 2*time.Second, b.failed(key))
+	assert.Zero(t, b.exhausted(key))
+	assert.Equal(t, 4*time.Second, b.failed(key))
+	assert.Equal(t, 3, b.exhausted(key))
+
+	// Other Bundles are not affected
+	assert.Zero(t, b.exhausted(other))
+	assert.Equal(t, 1*time.Second, b.failed(other))
+
+	// Delay is capped
+	assert.Equal(t, 5*time.Second, b.failed(key))
+
+	b.forget(key)
+	assert.Zero(t, b.exhausted(key))
+	assert.Equal(t, 1*time.Second, b.failed(key))
+}
+
+func TestRetryBackoffUnlimited(t *testing.T) {
+	t.Parallel()
+	b := newRetryBackoff(time.Second, time.Minute, 0)
+	key := ctrl.QueueKey{Namespace: "ns", Name: "b"}
+	for i := 0; i < 100; i++ {
+		b.failed(key)
+	}
+	assert.Zero(t, b.exhausted(key))
+}
+
+func TestRetryBackoffSchedule(t *testing.T) {
+	t.Parallel()
+	b := newRetryBackoff(time.Second, time.Minute, 0)
+	key := ctrl.QueueKey{Namespace: "ns", Name: "b"}
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	s := b.schedule(key, "", now, nil)
+	assert.Equal(t, 1, s.attempts)
+	assert.Equal(t, now.Add(time.Second), s.at)
+
+	// Attempts before the scheduled retry do not count
+	assert.Equal(t, s, b.schedule(key, "", now.Add(500*time.Millisecond), nil))
+
+	s = b.schedule(key, "", now.Add(time.Second), nil)
+	assert.Equal(t, 2, s.attempts)
+	assert.Equal(t, now.Add(3*time.Second), s.at)
+
+	// Budget defers the retry
+	s = b.schedule(key, "", now.Add(3*time.Second), func() time.Duration {
+		return 10 * time.Second
+	})
+	assert.Equal(t, 3, s.attempts)
+	assert.Equal(t, now.Add(13*time.Second), s.at)
+
+	b.forget(key)
+	s = b.schedule(key, "", now.Add(4*time.Second), nil)
+	assert.Equal(t, 1, s.attempts)
+	assert.Equal(t, now.Add(5*time.Second), s.at)
+}
+
+func TestRetryBackoffRequeueRequested(t *testing.T) {
+	t.Parallel()
+	b := newRetryBackoff(time.Second, time.Minute, 2)
+	key := ctrl.QueueKey{Namespace: "ns", Name: "b"}
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	b.schedule(key, "1", now, nil)
+	b.schedule(key, "1", now.Add(time.Second), nil)
+	assert.Equal(t, 2, b.exhausted(key))
+
+	// Same value does not reset the backoff
+	b.requeueRequested(key, "1")
+	assert.Equal(t, 2, b.exhausted(key))
+
+	b.requeueRequested(key, "2")
+	assert.Zero(t, b.exhausted(key))
+	s := b.schedule(key, "2", now.Add(2*time.Second), nil)
+	assert.Equal(t, 1, s.attempts)
+	assert.Equal(t, "2", s.requeue)
+}
+
+func TestScheduleRetry(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	st := bundleSyncTask{
+		logger: zap.NewNop(),
+		bundle: &smith_v1.Bundle{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Namespace:   "ns",
+				Name:        "b",
+				Annotations: map[string]string{smith.RequeueAnnotation: "1"},
+			},
+		},
+	}
+	assert.Nil(t, st.scheduleRetry(now))
+
+	st.retryBackoff = newRetryBackoff(time.Second, time.Minute, 0)
+	retry := st.scheduleRetry(now)
+	require.NotNil(t, retry)
+	assert.Equal(t, 1, retry.attempts)
+	assert.Equal(t, "1", retry.requeue)
+	// Scheduled once per processing
+	assert.True(t, retry == st.scheduleRetry(now.Add(time.Hour)))
+}
+
+func TestRetryBackoffPolicy(t *testing.T) {
+	t.Parallel()
+	b := newRetryBackoff(time.Second, time.Minute, 0)
+	key := ctrl.QueueKey{Namespace: "ns", Name: "b"}
+	other := ctrl.QueueKey{Namespace: "ns", Name: "other"}
+	maxAttempts := int32(2)
+	b.setPolicy(key, &smith_v1.RetryPolicy{
+		MaxAttempts:      &maxAttempts,
+		BaseDelaySeconds: 10,
+		MaxDelaySeconds:  15,
+	})
+
+	assert.Equal(t, 10*time.Second, b.failed(key))
+	assert.Zero(t, b.exhausted(key))
+	assert.Equal(t, 15*time.Second, b.failed(key))
+	assert.Equal(t, 2, b.exhausted(key))
+
+	// Other Bundles use the defaults
+	assert.Equal(t, 1*time.Second, b.failed(other))
+	assert.Equal(t, 2*time.Second, b.failed(other))
+	assert.Zero(t, b.exhausted(other))
+
+	// Zero means there is no limit
+	maxAttempts = 0
+	b.setPolicy(key, &smith_v1.RetryPolicy{MaxAttempts: &maxAttempts})
+	assert.Zero(t, b.exhausted(key))
+	assert.Equal(t, 4*time.Second, b.failed(key))
+
+	b.setPolicy(key, nil)
+	assert.Equal(t, 8*time.Second, b.failed(key))
+}
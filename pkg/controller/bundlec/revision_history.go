@@ -0,0 +1,213 @@
+package bundlec
+
+import (
+	"sort"
+	"time"
+
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	api_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// revisionName returns the name of the BundleRevision of a spec of the Bundle. Identical specs of different
+// generations share a BundleRevision. Names of long Bundles are truncated so that the name is a valid object name.
+func revisionName(bundleName, hash string) string {
+	return smith.TruncateName(bundleName, validation.DNS1123SubdomainMaxLength-len(hash)-1) + "-" + hash
+}
+
+// historyLimit returns the number of BundleRevisions kept for the Bundle, from its spec or the controller default.
+func (st *bundleSyncTask) historyLimit() int {
+	if maxHistory := st.bundle.Spec.MaxHistory; maxHistory != nil {
+		return int(*maxHistory)
+	}
+	return st.revisionHistoryLimit
+}
+
+// historyTTL returns how long BundleRevisions of the Bundle are kept for after their specs last made it ready,
+// from its spec or the controller default. Zero means revisions do not expire.
+func (st *bundleSyncTask) historyTTL() time.Duration {
+	if ttl := st.bundle.Spec.HistoryTTLSeconds; ttl != nil {
+		return time.Duration(*ttl) * time.Second
+	}
+	return st.revisionTTL
+}
+
+// revisionHistoryEnabled returns true if BundleRevisions are recorded for the Bundle.
+func (st *bundleSyncTask) revisionHistoryEnabled() bool {
+	return st.revisionClient != nil && st.revisionLister != nil && st.historyLimit() > 0
+}
+
+// recordRevision creates or updates the BundleRevision of the snapshot and deletes the oldest revisions of the Bundle
+// over the history limit. Nothing is done if revision history is disabled.
+func (st *bundleSyncTask) recordRevision(snapshot *smith_v1.BundleSnapshot) error {
+	if !st.revisionHistoryEnabled() {
+		return nil
+	}
+	client := st.revisionClient.BundleRevisions(st.bundle.Namespace)
+	name := revisionName(st.bundle.Name, snapshot.Hash)
+	now := meta_v1.Now()
+	existing, err := client.Get(name, meta_v1.GetOptions{})
+	switch {
+	case err == nil:
+		if controller := meta_v1.GetControllerOf(existing); controller == nil || controller.UID != st.bundle.UID {
+			return errors.Errorf("BundleRevision %q is not controlled by the Bundle", name)
+		}
+		// The spec made the Bundle ready again, it expires later
+		existing.Revision = snapshot.Generation
+		existing.ReadyTime = now
+		if st.recordedRevision, err = client.Update(existing); err != nil {
+			return errors.Wrapf(err, "failed to update BundleRevision %q", name)
+		}
+	case api_errors.IsNotFound(err):
+		trueVar := true
+		revision := &smith_v1.BundleRevision{
+			TypeMeta: meta_v1.TypeMeta{
+				Kind:       smith_v1.BundleRevisionResourceKind,
+				APIVersion: smith_v1.BundleResourceGroupVersion,
+			},
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:      name,
+				Namespace: st.bundle.Namespace,
+				Labels: map[string]string{
+					smith.RevisionOfLabel: smith.LabelValue(st.bundle.Name),
+				},
+				OwnerReferences: []meta_v1.OwnerReference{
+					{
+						APIVersion:         smith_v1.BundleResourceGroupVersion,
+						Kind:               smith_v1.BundleResourceKind,
+						Name:               st.bundle.Name,
+						UID:                st.bundle.UID,
+						Controller:         &trueVar,
+						BlockOwnerDeletion: &trueVar,
+					},
+				},
+			},
+			Revision:  snapshot.Generation,
+			Hash:      snapshot.Hash,
+			ReadyTime: now,
+			Spec:      *snapshot.Spec.DeepCopy(),
+		}
+		if st.recordedRevision, err = client.Create(revision); err != nil {
+			return errors.Wrapf(err, "failed to create BundleRevision %q", name)
+		}
+		st.logger.Sugar().Infof("Created BundleRevision %q of generation %d", name, snapshot.Generation)
+	default:
+		return errors.Wrapf(err, "failed to get BundleRevision %q", name)
+	}
+	return st.pruneRevisions()
+}
+
+// revisions returns BundleRevisions controlled by the Bundle, the most recent first. BundleRevisions are read from
+// the informer cache, the BundleRevision recorded during the sync is included even if the cache has not observed it yet.
+func (st *bundleSyncTask) revisions() ([]smith_v1.BundleRevision, error) {
+	selector := labels.SelectorFromSet(labels.Set{smith.RevisionOfLabel: smith.LabelValue(st.bundle.Name)})
+	list, err := st.revisionLister.BundleRevisions(st.bundle.Namespace).List(selector)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list BundleRevisions")
+	}
+	recorded := st.recordedRevision
+	revisions := make([]smith_v1.BundleRevision, 0, len(list)+1)
+	for _, revision := range list {
+		if recorded != nil && revision.Name == recorded.Name {
+			continue
+		}
+		// A Bundle with the same name could have been deleted and re-created
+		if controller := meta_v1.GetControllerOf(revision); controller != nil && controller.UID == st.bundle.UID {
+			revisions = append(revisions, *revision.DeepCopy())
+		}
+	}
+	if recorded != nil {
+		revisions = append(revisions, *recorded)
+	}
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Revision > revisions[j].Revision
+	})
+	return revisions, nil
+}
+
+// expireRevisions deletes BundleRevisions of the Bundle that have expired. It is used when no revision was recorded
+// during the sync, otherwise recordRevision takes care of it. Failures are logged and retried on the next sync,
+// they do not fail the Bundle.
+func (st *bundleSyncTask) expireRevisions() {
+	if st.dryRun || !st.revisionHistoryEnabled() || st.historyTTL() <= 0 {
+		return
+	}
+	if err := st.pruneRevisions(); err != nil {
+		st.logger.Error("Failed to delete expired BundleRevisions", zap.Error(err))
+	}
+}
+
+// pruneRevisions deletes the oldest BundleRevisions of the Bundle over the history limit and, if the Bundle has
+// a history TTL, BundleRevisions that have expired. The most recent BundleRevision never expires so that the Bundle
+// can always be rolled back to it. The Bundle is requeued for the next expiry.
+func (st *bundleSyncTask) pruneRevisions() error {
+	revisions, err := st.revisions()
+	if err != nil {
+		return err
+	}
+	limit := st.historyLimit()
+	ttl := st.historyTTL()
+	now := time.Now()
+	client := st.revisionClient.BundleRevisions(st.bundle.Namespace)
+	for i, revision := range revisions {
+		var reason string
+		switch {
+		case i >= limit:
+			reason = "over the history limit"
+		case i > 0 && ttl > 0:
+			expiry := revisionReadyTime(&revision).Add(ttl)
+			if expiry.After(now) {
+				st.requeueNoLaterThan(expiry.Sub(now))
+				continue
+			}
+			reason = "that has expired"
+		default:
+			continue
+		}
+		err = client.Delete(revision.Name, &meta_v1.DeleteOptions{
+			Preconditions: &meta_v1.Preconditions{
+				UID: &revision.UID,
+			},
+		})
+		if err != nil && !api_errors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete BundleRevision %q", revision.Name)
+		}
+		st.logger.Sugar().Infof("Deleted BundleRevision %q of generation %d %s", revision.Name, revision.Revision, reason)
+	}
+	return nil
+}
+
+// revisionReadyTime returns when the spec of the BundleRevision last made the Bundle ready. Revisions recorded before
+// the time was tracked fall back to their creation time.
+func revisionReadyTime(revision *smith_v1.BundleRevision) time.Time {
+	if revision.ReadyTime.IsZero() {
+		return revision.CreationTimestamp.Time
+	}
+	return revision.ReadyTime.Time
+}
+
+// findRevision returns the snapshot of the BundleRevision of the Bundle with the revision number.
+func (st *bundleSyncTask) findRevision(number int64) (*smith_v1.BundleSnapshot, error) {
+	if !st.revisionHistoryEnabled() {
+		return nil, invalidRollbackError{errors.New("revision history is disabled")}
+	}
+	revisions, err := st.revisions()
+	if err != nil {
+		return nil, err
+	}
+	for _, revision := range revisions {
+		if revision.Revision == number {
+			return &smith_v1.BundleSnapshot{
+				Generation: revision.Revision,
+				Hash:       revision.Hash,
+				Spec:       revision.Spec,
+			}, nil
+		}
+	}
+	return nil, invalidRollbackError{errors.Errorf("revision %d not found", number)}
+}
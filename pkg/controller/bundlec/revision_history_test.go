@@ -0,0 +1,310 @@
+package bundlec
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	smithFake "github.com/atlassian/smith/pkg/client/clientset_generated/clientset/fake"
+	smithClient_v1 "github.com/atlassian/smith/pkg/client/clientset_generated/clientset/typed/smith/v1"
+	smith_v1lst "github.com/atlassian/smith/pkg/client/listers_generated/smith/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+)
+
+// clientRevisionLister lists BundleRevisions with the client, like an informer cache that is always up to date.
+type clientRevisionLister struct {
+	client    smithClient_v1.BundleRevisionsGetter
+	namespace string
+}
+
+func (l clientRevisionLister) List(selector labels.Selector) ([]*smith_v1.BundleRevision, error) {
+	list, err := l.client.BundleRevisions(l.namespace).List(meta_v1.ListOptions{
+		LabelSelector: selector.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*smith_v1.BundleRevision, 0, len(list.Items))
+	for i := range list.Items {
+		result = append(result, &list.Items[i])
+	}
+	return result, nil
+}
+
+func (l clientRevisionLister) BundleRevisions(namespace string) smith_v1lst.BundleRevisionNamespaceLister {
+	return clientRevisionLister{client: l.client, namespace: namespace}
+}
+
+func (l clientRevisionLister) Get(name string) (*smith_v1.BundleRevision, error) {
+	return l.client.BundleRevisions(l.namespace).Get(name, meta_v1.GetOptions{})
+}
+
+func revisionHistoryTask(t *testing.T, limit int) (*bundleSyncTask, *smithFake.Clientset) {
+	client := smithFake.NewSimpleClientset()
+	bundle := rollbackBundle(1, smith_v1.Resource{Name: "a"})
+	bundle.UID = "b1-uid"
+	return &bundleSyncTask{
+		logger:               zaptest.NewLogger(t),
+		bundle:               bundle,
+		revisionClient:       client.SmithV1(),
+		revisionLister:       clientRevisionLister{client: client.SmithV1()},
+		revisionHistoryLimit: limit,
+	}, client
+}
+
+func TestRevisionHistory(t *testing.T) {
+	t.Parallel()
+	st, client := revisionHistoryTask(t, 2)
+	names := make(map[int64]string)
+	for generation := int64(1); generation <= 3; generation++ {
+		st.bundle.Generation = generation
+		st.bundle.Spec.Resources = append(st.bundle.Spec.Resources, smith_v1.Resource{Name: smith_v1.ResourceName(string(rune('a' + generation)))})
+		require.True(t, st.recordReadyRevision())
+		last := st.bundle.Status.LastReadyRevision
+		names[generation] = revisionName(st.bundle.Name, last.Hash)
+		revision, err := client.SmithV1().BundleRevisions(defaultNamespace).Get(names[generation], meta_v1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, generation, revision.Revision)
+		assert.Equal(t, last.Hash, revision.Hash)
+		assert.Equal(t, st.bundle.Spec, revision.Spec)
+		assert.Equal(t, st.bundle.Name, revision.Labels[smith.RevisionOfLabel])
+		assert.Equal(t, st.bundle.Name+"-"+last.Hash, revision.Name)
+		require.NotNil(t, meta_v1.GetControllerOf(revision))
+		assert.Equal(t, st.bundle.UID, meta_v1.GetControllerOf(revision).UID)
+	}
+
+	revisions, err := st.revisions()
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	assert.EqualValues(t, 3, revisions[0].Revision)
+	assert.EqualValues(t, 2, revisions[1].Revision)
+	_, err = client.SmithV1().BundleRevisions(defaultNamespace).Get(names[1], meta_v1.GetOptions{})
+	assert.Error(t, err)
+
+	snapshot, err := st.findRevision(2)
+	require.NoError(t, err)
+	assert.Len(t, snapshot.Spec.Resources, 3)
+	_, err = st.findRevision(1)
+	assert.EqualError(t, err, "revision 1 not found")
+}
+
+func TestRevisionHistoryLongBundleName(t *testing.T) {
+	t.Parallel()
+	st, client := revisionHistoryTask(t, 2)
+	st.bundle.Name = strings.Repeat("b", validation.DNS1123SubdomainMaxLength)
+	require.True(t, st.recordReadyRevision())
+
+	list, err := client.SmithV1().BundleRevisions(defaultNamespace).List(meta_v1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, list.Items, 1)
+	revision := list.Items[0]
+	assert.Empty(t, validation.IsDNS1123Subdomain(revision.Name))
+	assert.True(t, strings.HasSuffix(revision.Name, "-"+st.bundle.Status.LastReadyRevision.Hash))
+	assert.Empty(t, validation.IsValidLabelValue(revision.Labels[smith.RevisionOfLabel]))
+
+	revisions, err := st.revisions()
+	require.NoError(t, err)
+	assert.Len(t, revisions, 1)
+}
+
+func TestRevisionHistoryStaleCache(t *testing.T) {
+	t.Parallel()
+	st, client := revisionHistoryTask(t, 1)
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	st.revisionLister = smith_v1lst.NewBundleRevisionLister(indexer)
+	trueVar := true
+	old := &smith_v1.BundleRevision{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Namespace: defaultNamespace,
+			Name:      "b1-old",
+			Labels: map[string]string{
+				smith.RevisionOfLabel: st.bundle.Name,
+			},
+			OwnerReferences: []meta_v1.OwnerReference{
+				{
+					Name:       st.bundle.Name,
+					UID:        st.bundle.UID,
+					Controller: &trueVar,
+				},
+			},
+		},
+		Revision: 0,
+	}
+	require.NoError(t, indexer.Add(old))
+	_, err := client.SmithV1().BundleRevisions(defaultNamespace).Create(old)
+	require.NoError(t, err)
+
+	// The cache has not observed the created revision, it is counted against the limit anyway
+	require.True(t, st.recordReadyRevision())
+	list, err := client.SmithV1().BundleRevisions(defaultNamespace).List(meta_v1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, list.Items, 1)
+	assert.EqualValues(t, 1, list.Items[0].Revision)
+
+	revisions, err := st.revisions()
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	assert.EqualValues(t, 1, revisions[0].Revision)
+	assert.Equal(t, "b1-old", revisions[1].Name)
+}
+
+func TestRevisionHistorySameSpec(t *testing.T) {
+	t.Parallel()
+	st, client := revisionHistoryTask(t, 5)
+	require.True(t, st.recordReadyRevision())
+	st.bundle.Generation = 2
+	require.True(t, st.recordReadyRevision())
+
+	list, err := client.SmithV1().BundleRevisions(defaultNamespace).List(meta_v1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, list.Items, 1)
+	assert.EqualValues(t, 2, list.Items[0].Revision)
+}
+
+func TestRevisionHistoryDisabled(t *testing.T) {
+	t.Parallel()
+	st, client := revisionHistoryTask(t, 0)
+	require.True(t, st.recordReadyRevision())
+	list, err := client.SmithV1().BundleRevisions(defaultNamespace).List(meta_v1.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, list.Items)
+	_, err = st.findRevision(1)
+	assert.EqualError(t, err, "revision history is disabled")
+}
+
+func TestRevisionHistoryMaxHistory(t *testing.T) {
+	t.Parallel()
+	one := int32(1)
+	zero := int32(0)
+	testcases := map[string]struct {
+		limit      int
+		maxHistory *int32
+		revisions  int
+	}{
+		"enabled by the Bundle": {
+			limit:      0,
+			maxHistory: &one,
+			revisions:  1,
+		},
+		"disabled by the Bundle": {
+			limit:      5,
+			maxHistory: &zero,
+			revisions:  0,
+		},
+		"controller limit": {
+			limit:     5,
+			revisions: 2,
+		},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			st, client := revisionHistoryTask(t, tc.limit)
+			st.bundle.Spec.MaxHistory = tc.maxHistory
+			require.True(t, st.recordReadyRevision())
+			st.bundle.Generation = 2
+			st.bundle.Spec.Resources = append(st.bundle.Spec.Resources, smith_v1.Resource{Name: "b"})
+			require.True(t, st.recordReadyRevision())
+
+			list, err := client.SmithV1().BundleRevisions(defaultNamespace).List(meta_v1.ListOptions{})
+			require.NoError(t, err)
+			assert.Len(t, list.Items, tc.revisions)
+		})
+	}
+}
+
+func TestRevisionHistoryTTL(t *testing.T) {
+	t.Parallel()
+	st, client := revisionHistoryTask(t, 5)
+	names := make(map[int64]string)
+	for generation := int64(1); generation <= 3; generation++ {
+		st.bundle.Generation = generation
+		st.bundle.Spec.Resources = append(st.bundle.Spec.Resources, smith_v1.Resource{Name: smith_v1.ResourceName(string(rune('a' + generation)))})
+		require.True(t, st.recordReadyRevision())
+		names[generation] = revisionName(st.bundle.Name, st.bundle.Status.LastReadyRevision.Hash)
+	}
+	revisionsClient := client.SmithV1().BundleRevisions(defaultNamespace)
+	setReadyTime := func(generation int64, readyTime time.Time) {
+		revision, err := revisionsClient.Get(names[generation], meta_v1.GetOptions{})
+		require.NoError(t, err)
+		assert.False(t, revision.ReadyTime.IsZero())
+		revision.ReadyTime = meta_v1.NewTime(readyTime)
+		_, err = revisionsClient.Update(revision)
+		require.NoError(t, err)
+	}
+	now := time.Now()
+	// The most recent revision never expires
+	setReadyTime(1, now.Add(-2*time.Hour))
+	setReadyTime(2, now.Add(-30*time.Minute))
+	setReadyTime(3, now.Add(-2*time.Hour))
+
+	// No TTL
+	st.expireRevisions()
+	revisions, err := st.revisions()
+	require.NoError(t, err)
+	assert.Len(t, revisions, 3)
+	assert.Zero(t, st.requeueAfter)
+
+	ttl := int64(3600)
+	st.bundle.Spec.HistoryTTLSeconds = &ttl
+	st.expireRevisions()
+	revisions, err = st.revisions()
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	assert.EqualValues(t, 3, revisions[0].Revision)
+	assert.EqualValues(t, 2, revisions[1].Revision)
+	// Processed again when revision 2 expires
+	assert.True(t, st.requeueAfter > 29*time.Minute && st.requeueAfter <= 30*time.Minute, st.requeueAfter)
+
+	// Nothing is deleted in dry-run mode
+	setReadyTime(2, now.Add(-2*time.Hour))
+	st.dryRun = true
+	st.expireRevisions()
+	revisions, err = st.revisions()
+	require.NoError(t, err)
+	assert.Len(t, revisions, 2)
+}
+
+func TestRollbackToRevision(t *testing.T) {
+	t.Parallel()
+	st, _ := revisionHistoryTask(t, 5)
+	require.True(t, st.recordReadyRevision())
+	st.bundle.Generation = 2
+	st.bundle.Spec.Resources = append(st.bundle.Spec.Resources, smith_v1.Resource{Name: "b"})
+	require.True(t, st.recordReadyRevision())
+	st.bundle.Generation = 3
+	st.bundle.Spec.Resources = append(st.bundle.Spec.Resources, smith_v1.Resource{Name: "broken"})
+
+	recorder := record.NewFakeRecorder(2)
+	st.recorder = recorder
+	terminal := smith_v1.BundleCondition{
+		Type:   smith_v1.BundleError,
+		Status: smith_v1.ConditionTrue,
+		Reason: smith_v1.BundleReasonTerminalError,
+	}
+
+	st.bundle.Annotations = map[string]string{smith.RollbackAnnotation: "7"}
+	assert.True(t, st.rollback(&terminal))
+	require.Len(t, recorder.Events, 1)
+	assert.Equal(t, "Warning RollbackFailed Not rolling back: revision 7 not found", <-recorder.Events)
+	assert.Empty(t, st.bundle.Annotations, "annotation of a missing revision is removed")
+	assert.False(t, st.rollback(&terminal))
+	assert.Empty(t, recorder.Events)
+
+	st.bundle.Annotations[smith.RollbackAnnotation] = "1"
+	require.True(t, st.rollback(&terminal))
+	assert.Equal(t, []smith_v1.Resource{{Name: "a"}}, st.bundle.Spec.Resources)
+	assert.Empty(t, st.bundle.Annotations)
+	require.Len(t, recorder.Events, 1)
+	<-recorder.Events
+}
@@ -0,0 +1,120 @@
+package bundlec
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	core_v1 "k8s.io/api/core/v1"
+)
+
+// revisionHashLength is the number of hex digits of the hash of a spec that are recorded in the status.
+const revisionHashLength = 16
+
+// invalidRollbackError means the value of the RollbackAnnotation is invalid or refers to a revision that does not
+// exist, as opposed to a failure to look up the revision.
+type invalidRollbackError struct {
+	error
+}
+
+// specHash returns a hash of the spec of a Bundle.
+func specHash(spec *smith_v1.BundleSpec) (string, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal Bundle spec")
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:revisionHashLength], nil
+}
+
+// recordReadyRevision records the current spec of the Bundle as the last one that made it ready.
+// Returns true if the status has changed.
+func (st *bundleSyncTask) recordReadyRevision() bool {
+	if st.dryRun || st.bundle.Annotations[smith.SyncOnlyResourceAnnotation] != "" {
+		// Only some of the changes were applied
+		return false
+	}
+	last := st.bundle.Status.LastReadyRevision
+	if last != nil && last.Generation == st.bundle.Generation {
+		return false
+	}
+	hash, err := specHash(&st.bundle.Spec)
+	if err != nil {
+		// Rollback is best effort, keep the old revision
+		st.logger.Error("Failed to record ready revision", zap.Error(err))
+		return false
+	}
+	snapshot := &smith_v1.BundleSnapshot{
+		Generation: st.bundle.Generation,
+		Hash:       hash,
+		Spec:       *st.bundle.Spec.DeepCopy(),
+	}
+	if err = st.recordRevision(snapshot); err != nil {
+		// Status is left as it was so that recording is attempted again next time
+		st.logger.Error("Failed to record revision history", zap.Error(err))
+		return false
+	}
+	st.bundle.Status.LastReadyRevision = snapshot
+	return true
+}
+
+// rollback replaces the spec of the Bundle with an earlier spec if the Bundle is annotated for rollback and
+// errorCond is a terminal error. The annotation is removed so that the rollback only happens once.
+// Returns true if the Bundle has changed.
+func (st *bundleSyncTask) rollback(errorCond *smith_v1.BundleCondition) bool {
+	value := st.bundle.Annotations[smith.RollbackAnnotation]
+	if value == "" || st.dryRun {
+		return false
+	}
+	if errorCond.Status != smith_v1.ConditionTrue || errorCond.Reason == smith_v1.BundleReasonRetriableError {
+		// Not stuck, the spec may still become ready
+		return false
+	}
+	target, err := st.rollbackTarget(value)
+	if err != nil {
+		st.logger.Error("Not rolling back", zap.Error(err))
+		recordEvent(st.recorder, st.bundle, core_v1.EventTypeWarning, EventReasonRollbackFailed, "Not rolling back: %v", err)
+		if _, ok := err.(invalidRollbackError); ok {
+			// Rollback would fail on every sync until the annotation is changed, the Event is only recorded once
+			delete(st.bundle.Annotations, smith.RollbackAnnotation)
+			return true
+		}
+		return false
+	}
+	if target == nil {
+		st.logger.Info("Not rolling back because the Bundle has never been ready")
+		return false
+	}
+	if target.Generation == st.bundle.Generation {
+		st.logger.Info("Not rolling back because the current spec is the one to roll back to")
+		return false
+	}
+	st.logger.Sugar().Infof("Rolling back from generation %d to the spec of generation %d (%s)", st.bundle.Generation, target.Generation, target.Hash)
+	recordEvent(st.recorder, st.bundle, core_v1.EventTypeNormal, EventReasonRolledBack,
+		"Rolled back from generation %d to the spec of generation %d (%s)", st.bundle.Generation, target.Generation, target.Hash)
+	st.bundle.Spec = *target.Spec.DeepCopy()
+	delete(st.bundle.Annotations, smith.RollbackAnnotation)
+	return true
+}
+
+// rollbackTarget returns the spec to roll back to according to the value of the RollbackAnnotation: the last spec
+// that made the Bundle ready for "true" or the spec of a revision from the revision history for a revision number.
+// Returns nil if the value is "true" and the Bundle has never been ready.
+func (st *bundleSyncTask) rollbackTarget(value string) (*smith_v1.BundleSnapshot, error) {
+	if value == "true" {
+		return st.bundle.Status.LastReadyRevision, nil
+	}
+	number, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || number < 1 {
+		return nil, invalidRollbackError{errors.Errorf("value of the %s annotation must be \"true\" or a revision number, got %q", smith.RollbackAnnotation, value)}
+	}
+	if last := st.bundle.Status.LastReadyRevision; last != nil && last.Generation == number {
+		return last, nil
+	}
+	return st.findRevision(number)
+}
@@ -0,0 +1,143 @@
+package bundlec
+
+import (
+	"testing"
+
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func rollbackBundle(generation int64, resources ...smith_v1.Resource) *smith_v1.Bundle {
+	return &smith_v1.Bundle{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Namespace:  defaultNamespace,
+			Name:       "b1",
+			Generation: generation,
+		},
+		Spec: smith_v1.BundleSpec{
+			Resources: resources,
+		},
+	}
+}
+
+func TestRecordReadyRevision(t *testing.T) {
+	t.Parallel()
+	bundle := rollbackBundle(2, smith_v1.Resource{Name: "a"})
+	st := bundleSyncTask{
+		logger: zaptest.NewLogger(t),
+		bundle: bundle,
+	}
+	require.True(t, st.recordReadyRevision())
+	revision := bundle.Status.LastReadyRevision
+	require.NotNil(t, revision)
+	assert.EqualValues(t, 2, revision.Generation)
+	assert.Len(t, revision.Hash, revisionHashLength)
+	assert.Equal(t, bundle.Spec, revision.Spec)
+
+	// Same generation is recorded once
+	assert.False(t, st.recordReadyRevision())
+
+	// Identical specs have the same hash
+	bundle.Generation = 3
+	require.True(t, st.recordReadyRevision())
+	assert.Equal(t, revision.Hash, bundle.Status.LastReadyRevision.Hash)
+
+	bundle.Generation = 4
+	bundle.Spec.Resources = append(bundle.Spec.Resources, smith_v1.Resource{Name: "b"})
+	require.True(t, st.recordReadyRevision())
+	assert.NotEqual(t, revision.Hash, bundle.Status.LastReadyRevision.Hash)
+}
+
+func TestRecordReadyRevisionSkipsPartialSync(t *testing.T) {
+	t.Parallel()
+	bundle := rollbackBundle(2, smith_v1.Resource{Name: "a"})
+	bundle.Annotations = map[string]string{smith.SyncOnlyResourceAnnotation: "a"}
+	st := bundleSyncTask{
+		logger: zaptest.NewLogger(t),
+		bundle: bundle,
+	}
+	assert.False(t, st.recordReadyRevision())
+	assert.Nil(t, bundle.Status.LastReadyRevision)
+}
+
+func TestRollback(t *testing.T) {
+	t.Parallel()
+	terminal := smith_v1.BundleCondition{
+		Type:   smith_v1.BundleError,
+		Status: smith_v1.ConditionTrue,
+		Reason: smith_v1.BundleReasonTerminalError,
+	}
+	retriable := smith_v1.BundleCondition{
+		Type:   smith_v1.BundleError,
+		Status: smith_v1.ConditionTrue,
+		Reason: smith_v1.BundleReasonRetriableError,
+	}
+	readySpec := smith_v1.BundleSpec{Resources: []smith_v1.Resource{{Name: "a"}}}
+	testcases := map[string]struct {
+		annotation  string
+		errorCond   smith_v1.BundleCondition
+		revision    *smith_v1.BundleSnapshot
+		rolledBack  bool
+		annotations map[string]string
+	}{
+		"terminal error": {
+			annotation:  "true",
+			errorCond:   terminal,
+			revision:    &smith_v1.BundleSnapshot{Generation: 1, Hash: "abc", Spec: readySpec},
+			rolledBack:  true,
+			annotations: map[string]string{},
+		},
+		"not annotated": {
+			errorCond:   terminal,
+			revision:    &smith_v1.BundleSnapshot{Generation: 1, Hash: "abc", Spec: readySpec},
+			annotations: map[string]string{smith.RollbackAnnotation: ""},
+		},
+		"retriable error": {
+			annotation:  "true",
+			errorCond:   retriable,
+			revision:    &smith_v1.BundleSnapshot{Generation: 1, Hash: "abc", Spec: readySpec},
+			annotations: map[string]string{smith.RollbackAnnotation: "true"},
+		},
+		"never ready": {
+			annotation:  "true",
+			errorCond:   terminal,
+			annotations: map[string]string{smith.RollbackAnnotation: "true"},
+		},
+		"current spec was ready": {
+			annotation:  "true",
+			errorCond:   terminal,
+			revision:    &smith_v1.BundleSnapshot{Generation: 2, Hash: "abc", Spec: readySpec},
+			annotations: map[string]string{smith.RollbackAnnotation: "true"},
+		},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			bundle := rollbackBundle(2, smith_v1.Resource{Name: "a"}, smith_v1.Resource{Name: "broken"})
+			bundle.Annotations = map[string]string{smith.RollbackAnnotation: tc.annotation}
+			bundle.Status.LastReadyRevision = tc.revision
+			recorder := record.NewFakeRecorder(1)
+			st := bundleSyncTask{
+				logger:   zaptest.NewLogger(t),
+				bundle:   bundle,
+				recorder: recorder,
+			}
+			assert.Equal(t, tc.rolledBack, st.rollback(&tc.errorCond))
+			assert.Equal(t, tc.annotations, bundle.Annotations)
+			if tc.rolledBack {
+				assert.Equal(t, readySpec, bundle.Spec)
+				require.Len(t, recorder.Events, 1)
+				assert.Equal(t, "Normal RolledBack Rolled back from generation 2 to the spec of generation 1 (abc)", <-recorder.Events)
+			} else {
+				assert.Len(t, bundle.Spec.Resources, 2)
+				assert.Empty(t, recorder.Events)
+			}
+		})
+	}
+}
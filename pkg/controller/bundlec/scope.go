@@ -0,0 +1,18 @@
+package bundlec
+
+import (
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// scopeMismatch returns the status of a resource of a cluster-scoped kind. Retrying does not help so
+// it is a terminal error with a dedicated reason and a message that explains how to fix it.
+// err must be a smart.ScopeMismatchError or must have it as the cause.
+func scopeMismatch(gvk schema.GroupVersionKind, err error) resourceStatusError {
+	return resourceStatusError{
+		err: errors.Wrapf(errors.Cause(err), "%s is cluster-scoped but objects of a Bundle are created in the namespace of the Bundle. "+
+			"Objects of cluster-scoped kinds cannot be managed by a Bundle, remove the resource from the Bundle and manage the object separately", gvk.Kind),
+		reason: smith_v1.ResourceReasonScopeMismatch,
+	}
+}
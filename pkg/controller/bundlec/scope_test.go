@@ -0,0 +1,58 @@
+package bundlec
+
+import (
+	"testing"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/atlassian/smith/pkg/client/smart"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+type clusterScopedSmartClient struct{}
+
+func (clusterScopedSmartClient) ForGVK(gvk schema.GroupVersionKind, namespace string) (dynamic.ResourceInterface, error) {
+	return nil, &smart.ScopeMismatchError{
+		GVK:       gvk,
+		Namespace: namespace,
+	}
+}
+
+func TestCreateOrUpdateScopeMismatch(t *testing.T) {
+	t.Parallel()
+	st := &resourceSyncTask{
+		logger:      zap.NewNop(),
+		smartClient: clusterScopedSmartClient{},
+		bundle: &smith_v1.Bundle{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:      "bundle1",
+				Namespace: "ns1",
+			},
+		},
+	}
+	spec := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "rbac.authorization.k8s.io/v1",
+			"kind":       "ClusterRole",
+			"metadata": map[string]interface{}{
+				"name": "role1",
+			},
+		},
+	}
+
+	_, retriable, err := st.createOrUpdate(spec, nil)
+	require.Error(t, err)
+	assert.False(t, retriable)
+	require.True(t, smart.IsScopeMismatch(err))
+
+	status := scopeMismatch(spec.GroupVersionKind(), err)
+	assert.Equal(t, smith_v1.ResourceReasonScopeMismatch, status.reason)
+	assert.False(t, status.isRetriableError)
+	assert.Contains(t, status.err.Error(), "ClusterRole is cluster-scoped")
+	assert.Contains(t, status.err.Error(), `cannot be used in namespace "ns1"`)
+}
@@ -0,0 +1,57 @@
+package bundlec
+
+import (
+	ctrlLogz "github.com/atlassian/ctrl/logz"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	api_errors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// serverDryRun submits the change of the object of the resource to the API server with server-side dry-run so that
+// admission and validation failures are detected before any object of the Bundle is created or updated.
+// Only resources with ready dependencies can be evaluated and only objects that would be created or updated are
+// submitted, other resources are skipped. Objects of resources with dependencies that are not ready yet are validated
+// by the API server when they are created or updated. The returned resourceInfo is the observed state of the
+// resource that dependent resources are evaluated against.
+func (st *resourceSyncTask) serverDryRun(res *smith_v1.Resource) (resourceInfo, error) {
+	observed, evaluated := st.evaluate(res)
+	if evaluated.Err != nil {
+		// Reported when the resource is processed
+		st.logger.Debug("Skipping server-side dry-run", zap.Error(evaluated.Err))
+		return observed, nil
+	}
+	spec := evaluated.Spec
+	namespace := st.objectNamespace(spec)
+	if evaluated.Actual == nil {
+		st.logger.Debug("Validating creation of object with server-side dry-run", ctrlLogz.Object(spec))
+		return observed, st.dryRunRejection(st.dryRunClient.DryRunCreate(spec, namespace))
+	}
+	// Jobs and similar objects are not updated in place
+	if isRunToCompletion(spec.GroupVersionKind().GroupKind()) {
+		return observed, nil
+	}
+	updated, match, err := st.specCheck.CompareActualVsSpec(spec, evaluated.Actual.DeepCopy())
+	if err != nil {
+		st.logger.Debug("Skipping server-side dry-run, specification check failed", zap.Error(err))
+		return observed, nil
+	}
+	if match {
+		return observed, nil
+	}
+	st.logger.Debug("Validating update of object with server-side dry-run", ctrlLogz.Object(spec))
+	return observed, st.dryRunRejection(st.dryRunClient.DryRunUpdate(updated, namespace))
+}
+
+// dryRunRejection returns an error if the API server rejected the change of the object. Other failures, e.g. the API
+// server not supporting dry-run, do not fail pre-flight validation.
+func (st *resourceSyncTask) dryRunRejection(err error) error {
+	if err == nil {
+		return nil
+	}
+	if api_errors.IsInvalid(err) || api_errors.IsBadRequest(err) || api_errors.IsForbidden(err) {
+		return errors.Wrap(err, "rejected by server-side dry-run")
+	}
+	st.logger.Warn("Server-side dry-run failed", zap.Error(err))
+	return nil
+}
@@ -0,0 +1,155 @@
+package bundlec
+
+import (
+	"testing"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	core_v1 "k8s.io/api/core/v1"
+	api_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// fakeDryRunClient records dry-run requests and returns the configured error for objects by name.
+type fakeDryRunClient struct {
+	requests []string
+	errs     map[string]error
+}
+
+func (c *fakeDryRunClient) DryRunCreate(obj *unstructured.Unstructured, namespace string) error {
+	c.requests = append(c.requests, "create "+namespace+"/"+obj.GetName())
+	return c.errs[obj.GetName()]
+}
+
+func (c *fakeDryRunClient) DryRunUpdate(obj *unstructured.Unstructured, namespace string) error {
+	c.requests = append(c.requests, "update "+namespace+"/"+obj.GetName())
+	return c.errs[obj.GetName()]
+}
+
+func dryRunResource(name smith_v1.ResourceName, data map[string]string, dependencies ...smith_v1.ResourceName) smith_v1.Resource {
+	cm := crossNamespaceConfigMap("", string(name), nil)
+	cm.Data = data
+	res := smith_v1.Resource{
+		Name: name,
+		Spec: smith_v1.ResourceSpec{
+			Object: cm,
+		},
+	}
+	for _, dep := range dependencies {
+		res.References = append(res.References, smith_v1.Reference{Resource: dep})
+	}
+	return res
+}
+
+func TestPreflightServerDryRun(t *testing.T) {
+	t.Parallel()
+	existing := func(name string, data map[string]string) *core_v1.ConfigMap {
+		cm := child(name, nil, bundleRef("b1", "b1-uid"))
+		cm.Data = data
+		return cm
+	}
+	bundle := &smith_v1.Bundle{
+		ObjectMeta: meta_v1.ObjectMeta{Namespace: "ns", Name: "b1", UID: "b1-uid"},
+		Spec: smith_v1.BundleSpec{
+			Resources: []smith_v1.Resource{
+				// Does not exist, is created
+				dryRunResource("a", map[string]string{"x": "1"}),
+				// Exists and is changed, rejected by the server
+				dryRunResource("b", map[string]string{"x": "2"}),
+				// Exists and is unchanged
+				dryRunResource("c", map[string]string{"x": "1"}),
+				// Dependency does not exist yet
+				dryRunResource("d", nil, "a"),
+				// Dependency is ready, the server is not reachable
+				dryRunResource("e", nil, "c"),
+			},
+		},
+	}
+	_, sorted, err := sortBundle(bundle)
+	require.NoError(t, err)
+	resourceMap := make(map[smith_v1.ResourceName]smith_v1.Resource, len(bundle.Spec.Resources))
+	for _, res := range bundle.Spec.Resources {
+		resourceMap[res.Name] = res
+	}
+	dryRunClient := &fakeDryRunClient{
+		errs: map[string]error{
+			"b": api_errors.NewInvalid(schema.GroupKind{Kind: "ConfigMap"}, "b", nil),
+			"e": errors.New("connection refused"),
+		},
+	}
+	st := bundleSyncTask{
+		logger: zaptest.NewLogger(t),
+		store: specSourceStore(t,
+			existing("b", map[string]string{"x": "1"}),
+			existing("c", map[string]string{"x": "1"}),
+		),
+		specCheck:          fakeSpecCheck{},
+		rc:                 fakeReadyChecker{},
+		dryRunClient:       dryRunClient,
+		bundle:             bundle,
+		processedResources: make(map[smith_v1.ResourceName]*resourceInfo),
+	}
+
+	err = st.preflight(sorted, resourceMap)
+	require.Error(t, err)
+	preflightErr, ok := errors.Cause(err).(*preflightError)
+	require.True(t, ok)
+	assert.Equal(t, []smith_v1.ResourceName{"b"}, preflightErr.failedResources)
+
+	assert.ElementsMatch(t, []string{"create ns/a", "update ns/b", "create ns/e"}, dryRunClient.requests)
+	require.Len(t, st.processedResources, 1)
+	status, ok := st.processedResources["b"].status.(resourceStatusError)
+	require.True(t, ok)
+	assert.True(t, api_errors.IsInvalid(errors.Cause(status.err)))
+}
+
+func TestPreflightServerDryRunFailedDependency(t *testing.T) {
+	t.Parallel()
+	bundle := &smith_v1.Bundle{
+		ObjectMeta: meta_v1.ObjectMeta{Namespace: "ns", Name: "b1", UID: "b1-uid"},
+		Spec: smith_v1.BundleSpec{
+			Resources: []smith_v1.Resource{
+				// Fails prevalidation
+				{
+					Name: "a",
+					Spec: smith_v1.ResourceSpec{
+						Plugin: &smith_v1.PluginSpec{
+							Name:       "missing",
+							ObjectName: "a",
+						},
+					},
+				},
+				dryRunResource("b", nil, "a"),
+			},
+		},
+	}
+	_, sorted, err := sortBundle(bundle)
+	require.NoError(t, err)
+	resourceMap := make(map[smith_v1.ResourceName]smith_v1.Resource, len(bundle.Spec.Resources))
+	for _, res := range bundle.Spec.Resources {
+		resourceMap[res.Name] = res
+	}
+	dryRunClient := &fakeDryRunClient{}
+	st := bundleSyncTask{
+		logger:             zaptest.NewLogger(t),
+		store:              specSourceStore(t),
+		specCheck:          fakeSpecCheck{},
+		rc:                 fakeReadyChecker{},
+		dryRunClient:       dryRunClient,
+		bundle:             bundle,
+		processedResources: make(map[smith_v1.ResourceName]*resourceInfo),
+	}
+
+	err = st.preflight(sorted, resourceMap)
+	require.Error(t, err)
+	preflightErr, ok := errors.Cause(err).(*preflightError)
+	require.True(t, ok)
+	assert.Equal(t, []smith_v1.ResourceName{"a"}, preflightErr.failedResources)
+	// Dependent resource is skipped because its dependency is not ready
+	assert.Empty(t, dryRunClient.requests)
+}
@@ -25,7 +25,9 @@ const (
 )
 
 type fakeStore struct {
-	responses map[string]runtime.Object
+	responses  map[string]runtime.Object
+	controlled map[types.UID][]runtime.Object
+	tracked    map[types.UID][]runtime.Object
 }
 
 func (f fakeStore) Get(gvk schema.GroupVersionKind, namespace, name string) (obj runtime.Object, exists bool, err error) {
@@ -37,7 +39,11 @@ func (f fakeStore) Get(gvk schema.GroupVersionKind, namespace, name string) (obj
 }
 
 func (f fakeStore) ObjectsControlledBy(namespace string, uid types.UID) ([]runtime.Object, error) {
-	return nil, nil
+	return f.controlled[uid], nil
+}
+
+func (f fakeStore) ObjectsTrackedBy(uid types.UID) ([]runtime.Object, error) {
+	return f.tracked[uid], nil
 }
 
 func (f fakeStore) AddInformer(schema.GroupVersionKind, cache.SharedIndexInformer) error {
@@ -48,6 +54,14 @@ func (f fakeStore) RemoveInformer(schema.GroupVersionKind) bool {
 	return false
 }
 
+func (f fakeStore) HasInformer(schema.GroupVersionKind) bool {
+	return true
+}
+
+func (f fakeStore) HasSynced(...schema.GroupVersionKind) bool {
+	return true
+}
+
 func serviceInstanceUnmarshal(t *testing.T, spec *unstructured.Unstructured) *sc_v1b1.ServiceInstance {
 	var instanceSpec sc_v1b1.ServiceInstance
 	err := util.ConvertType(scheme(t), spec, &instanceSpec)
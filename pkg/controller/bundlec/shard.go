@@ -0,0 +1,82 @@
+package bundlec
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"strconv"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ShardAssignment splits Bundles between several deployments of the controller. Each deployment owns a shard and only
+// processes Bundles assigned to it. Bundles are assigned to shards by a hash of their namespace and name modulo the
+// number of shards, so all deployments agree on the assignment without coordination.
+type ShardAssignment struct {
+	shard  int
+	shards int
+	info   prometheus.Gauge
+}
+
+// NewShardAssignment returns the assignment for a controller that owns shard out of shards, numbered from zero.
+func NewShardAssignment(shard, shards int) (*ShardAssignment, error) {
+	if shards < 1 {
+		return nil, errors.Errorf("number of shards must be positive, got %d", shards)
+	}
+	if shard < 0 || shard >= shards {
+		return nil, errors.Errorf("shard must be between 0 and %d, got %d", shards-1, shard)
+	}
+	return &ShardAssignment{
+		shard:  shard,
+		shards: shards,
+		info: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "smith",
+			Subsystem: "bundle",
+			Name:      "shard_info",
+			Help:      "Shard of Bundles owned by the controller",
+			ConstLabels: prometheus.Labels{
+				"shard":  strconv.Itoa(shard),
+				"shards": strconv.Itoa(shards),
+			},
+		}),
+	}, nil
+}
+
+// Shard returns the shard owned by the controller.
+func (s *ShardAssignment) Shard() int {
+	return s.shard
+}
+
+// AssignedShard returns the shard the Bundle with the namespace and name is assigned to.
+func (s *ShardAssignment) AssignedShard(namespace, name string) int {
+	h := sha256.Sum256([]byte(namespace + "/" + name))
+	return int(binary.BigEndian.Uint64(h[:8]) % uint64(s.shards))
+}
+
+// Owns returns true if the Bundle is assigned to the shard of the controller.
+func (s *ShardAssignment) Owns(bundle *smith_v1.Bundle) bool {
+	return s.AssignedShard(bundle.Namespace, bundle.Name) == s.shard
+}
+
+// FilterBundles wraps a function that lists Bundles, e.g. the List method of the Bundle informer's store, to only
+// return Bundles owned by the shard. Used to make statistics and health checks only account for owned Bundles.
+func (s *ShardAssignment) FilterBundles(listBundles func() []interface{}) func() []interface{} {
+	return func() []interface{} {
+		all := listBundles()
+		owned := make([]interface{}, 0, len(all)/s.shards+1)
+		for _, obj := range all {
+			if s.Owns(obj.(*smith_v1.Bundle)) {
+				owned = append(owned, obj)
+			}
+		}
+		return owned
+	}
+}
+
+// RegisterMetrics registers the smith_bundle_shard_info metric with the shard and the number of shards as labels.
+// Metrics of the controller can be joined with it to be broken down by shard.
+func (s *ShardAssignment) RegisterMetrics(registerer prometheus.Registerer) error {
+	s.info.Set(1)
+	return errors.WithStack(registerer.Register(s.info))
+}
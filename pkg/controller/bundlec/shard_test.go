@@ -0,0 +1,90 @@
+package bundlec
+
+import (
+	"fmt"
+	"testing"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNewShardAssignment(t *testing.T) {
+	t.Parallel()
+	s, err := NewShardAssignment(2, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 2, s.Shard())
+
+	testcases := map[string]struct {
+		shard  int
+		shards int
+		err    string
+	}{
+		"no shards": {
+			shard:  0,
+			shards: 0,
+			err:    "number of shards must be positive, got 0",
+		},
+		"shard out of range": {
+			shard:  3,
+			shards: 3,
+			err:    "shard must be between 0 and 2, got 3",
+		},
+		"negative shard": {
+			shard:  -1,
+			shards: 3,
+			err:    "shard must be between 0 and 2, got -1",
+		},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			_, err := NewShardAssignment(tc.shard, tc.shards)
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestShardsOwnEachBundleOnce(t *testing.T) {
+	t.Parallel()
+	const shardCount = 4
+	shards := make([]*ShardAssignment, 0, shardCount)
+	for i := 0; i < shardCount; i++ {
+		s, err := NewShardAssignment(i, shardCount)
+		require.NoError(t, err)
+		shards = append(shards, s)
+	}
+	owned := make([]int, shardCount)
+	var bundles []interface{}
+	for i := 0; i < 400; i++ {
+		bundle := &smith_v1.Bundle{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Namespace: fmt.Sprintf("ns%d", i%10),
+				Name:      fmt.Sprintf("b%d", i),
+			},
+		}
+		bundles = append(bundles, bundle)
+		var owners int
+		for j, s := range shards {
+			if s.Owns(bundle) {
+				owners++
+				owned[j]++
+				// Assignment is deterministic
+				assert.Equal(t, j, shards[(j+1)%shardCount].AssignedShard(bundle.Namespace, bundle.Name))
+			}
+		}
+		assert.Equal(t, 1, owners, "%s/%s", bundle.Namespace, bundle.Name)
+	}
+	for j, count := range owned {
+		// Bundles are spread across shards
+		assert.True(t, count > 50, "shard %d owns %d Bundles", j, count)
+	}
+
+	listed := shards[1].FilterBundles(func() []interface{} { return bundles })()
+	assert.Len(t, listed, owned[1])
+	for _, obj := range listed {
+		assert.True(t, shards[1].Owns(obj.(*smith_v1.Bundle)))
+	}
+}
@@ -0,0 +1,135 @@
+package bundlec
+
+import (
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/atlassian/smith/pkg/plugin"
+	"github.com/atlassian/smith/pkg/util"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// SpecEvaluator evaluates specs of resources of Bundles the way the controller does, against objects in the Store,
+// without creating or updating anything. Parameters, references, template functions, specFrom and generated names
+// are processed like when the Bundle is applied. Used by tools that compare objects of a Bundle with its spec.
+type SpecEvaluator struct {
+	Logger *zap.Logger
+	Store  Store
+	Rc     ReadyChecker
+	// SecretResolver resolves references to external secret stores. May be nil.
+	SecretResolver   SecretResolver
+	PluginContainers map[smith_v1.PluginName]plugin.PluginContainer
+
+	CrossNamespaceResources bool
+}
+
+// EvaluatedResource is a resource of a Bundle with its evaluated spec.
+type EvaluatedResource struct {
+	Name smith_v1.ResourceName
+	// Spec is the object the controller would create or update the object of the resource with. Nil if Err is set.
+	Spec *unstructured.Unstructured
+	// Actual is the existing object of the resource. Nil if it does not exist.
+	Actual *unstructured.Unstructured
+	// Err is why the spec could not be evaluated, e.g. dependencies of the resource are not ready.
+	Err error
+}
+
+// Evaluate evaluates specs of all resources of the Bundle in the order the controller processes them.
+func (e *SpecEvaluator) Evaluate(bundle *smith_v1.Bundle) ([]EvaluatedResource, error) {
+	st := bundleSyncTask{
+		logger:                  e.Logger,
+		bundle:                  bundle,
+		store:                   e.Store,
+		rc:                      e.Rc,
+		secretResolver:          e.SecretResolver,
+		pluginContainers:        e.PluginContainers,
+		crossNamespaceResources: e.CrossNamespaceResources,
+		processedResources:      make(map[smith_v1.ResourceName]*resourceInfo, len(bundle.Spec.Resources)),
+	}
+	resources, err := st.resources()
+	if err != nil {
+		return nil, err
+	}
+	resourceMap := make(map[smith_v1.ResourceName]smith_v1.Resource, len(resources))
+	for _, res := range resources {
+		resourceMap[res.Name] = res
+	}
+	_, sorted, err := sortBundle(bundle)
+	if err != nil {
+		return nil, errors.Wrap(err, "topological sort of resources failed")
+	}
+	result := make([]EvaluatedResource, 0, len(sorted))
+	for _, resName := range sorted {
+		resourceName := resName.(smith_v1.ResourceName)
+		res := resourceMap[resourceName]
+		rst := st.newResourceSyncTask(st.resourceLogger(&res))
+		resInfo, evaluated := rst.evaluate(&res)
+		st.processedResources[resourceName] = &resInfo
+		result = append(result, evaluated)
+	}
+	return result, nil
+}
+
+// evaluate evaluates the spec of the resource against the actual object without creating or updating it.
+// The returned resourceInfo is the observed state of the resource that dependent resources are evaluated against.
+func (st *resourceSyncTask) evaluate(res *smith_v1.Resource) (resourceInfo, EvaluatedResource) {
+	evaluated := EvaluatedResource{
+		Name: res.Name,
+	}
+	if notReady := st.checkAllDependenciesAreReady(res); len(notReady) > 0 {
+		evaluated.Err = errors.Errorf("dependencies are not ready: %q", notReady)
+		return resourceInfo{
+			status: resourceStatusDependenciesNotReady{
+				dependencies: notReady,
+			},
+		}, evaluated
+	}
+	if res.Spec.External != nil {
+		evaluated.Err = errors.New("external objects are only observed")
+		return st.observeExternalObject(res), evaluated
+	}
+	actual, status := st.getActualObject(res)
+	switch s := status.(type) {
+	case nil:
+	case resourceStatusError:
+		evaluated.Err = s.err
+		return resourceInfo{status: status}, evaluated
+	case resourceStatusMissingAPI:
+		evaluated.Err = errors.Errorf("API of %s is not available", s.gvk)
+		return resourceInfo{status: status}, evaluated
+	default:
+		evaluated.Err = errors.Errorf("unexpected status %T", status)
+		return resourceInfo{status: status}, evaluated
+	}
+	observed := resourceInfo{status: resourceStatusInProgress{}}
+	if actual != nil {
+		actualUnstr, err := util.RuntimeToUnstructured(actual)
+		if err != nil {
+			evaluated.Err = err
+			return resourceInfo{status: resourceStatusError{err: err}}, evaluated
+		}
+		evaluated.Actual = actualUnstr
+		observed = st.checkReadiness(actualUnstr)
+	}
+	evaluated.Spec, evaluated.Err = st.desiredSpec(res, actual)
+	return observed, evaluated
+}
+
+// desiredSpec evaluates the spec of the resource and merges it with the actual object the way it is done before
+// the object is created or updated. Actual may be nil.
+func (st *resourceSyncTask) desiredSpec(res *smith_v1.Resource, actual runtime.Object) (*unstructured.Unstructured, error) {
+	spec, err := st.evalSpec(res, actual)
+	if err != nil {
+		return nil, err
+	}
+	spec, err = st.forceServiceInstanceUpdates(spec, actual, st.objectNamespace(spec))
+	if err != nil {
+		return nil, err
+	}
+	spec, err = st.applyUpdateStrategy(res, spec, actual)
+	if err != nil {
+		return nil, err
+	}
+	return ignoreFields(res, spec, actual)
+}
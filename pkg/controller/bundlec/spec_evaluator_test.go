@@ -0,0 +1,77 @@
+package bundlec
+
+import (
+	"testing"
+
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSpecEvaluator(t *testing.T) {
+	t.Parallel()
+	a := child("a", nil, bundleRef("b1", "b1-uid"))
+	a.Data = map[string]string{"host": "example.com"}
+	bRes := dryRunResource("b", map[string]string{
+		"host": "!{a-host}",
+		"url":  "https://${params.environment}.example.com/${params.path}",
+	})
+	bRes.References = []smith_v1.Reference{
+		{
+			Name:     "a-host",
+			Resource: "a",
+			Path:     "data.host",
+		},
+	}
+	bundle := &smith_v1.Bundle{
+		ObjectMeta: meta_v1.ObjectMeta{Namespace: "ns", Name: "b1", UID: "b1-uid"},
+		Spec: smith_v1.BundleSpec{
+			Parameters: map[string]string{
+				"environment": "prod",
+				"path":        "api",
+			},
+			Resources: []smith_v1.Resource{
+				dryRunResource("a", map[string]string{"host": "example.com"}),
+				bRes,
+				// Dependency does not exist yet
+				dryRunResource("c", nil, "b"),
+			},
+		},
+	}
+	e := &SpecEvaluator{
+		Logger: zaptest.NewLogger(t),
+		Store:  specSourceStore(t, a),
+		Rc:     fakeReadyChecker{},
+	}
+
+	evaluated, err := e.Evaluate(bundle)
+	require.NoError(t, err)
+	require.Len(t, evaluated, 3)
+	byName := make(map[smith_v1.ResourceName]EvaluatedResource, len(evaluated))
+	for _, res := range evaluated {
+		byName[res.Name] = res
+	}
+
+	resA := byName["a"]
+	require.NoError(t, resA.Err)
+	require.NotNil(t, resA.Actual)
+	assert.Equal(t, "a", resA.Actual.GetName())
+
+	resB := byName["b"]
+	require.NoError(t, resB.Err)
+	assert.Nil(t, resB.Actual)
+	assert.Equal(t, map[string]interface{}{
+		"host": "example.com",
+		"url":  "https://prod.example.com/api",
+	}, resB.Spec.Object["data"])
+	assert.Equal(t, smith.LabelValue("b1"), resB.Spec.GetLabels()[smith.BundleNameLabel])
+	require.Len(t, resB.Spec.GetOwnerReferences(), 2)
+	assert.Equal(t, bundle.UID, resB.Spec.GetOwnerReferences()[0].UID)
+
+	resC := byName["c"]
+	assert.EqualError(t, resC.Err, `dependencies are not ready: ["b"]`)
+	assert.Nil(t, resC.Spec)
+}
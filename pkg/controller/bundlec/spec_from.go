@@ -0,0 +1,125 @@
+package bundlec
+
+import (
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var (
+	configMapGVK = core_v1.SchemeGroupVersion.WithKind("ConfigMap")
+	secretGVK    = core_v1.SchemeGroupVersion.WithKind("Secret")
+)
+
+// resolveSpecFrom returns the resources with specs of resources that have specFrom read from their sources.
+// The argument is not mutated, resources are returned as is if none of them have specFrom.
+func (st *bundleSyncTask) resolveSpecFrom(resources []smith_v1.Resource) ([]smith_v1.Resource, error) {
+	var result []smith_v1.Resource
+	for i := range resources {
+		res := &resources[i]
+		if res.SpecFrom == nil {
+			continue
+		}
+		if result == nil {
+			result = make([]smith_v1.Resource, len(resources))
+			copy(result, resources)
+		}
+		obj, err := st.readSpecSource(res.SpecFrom)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read spec of resource %q", res.Name)
+		}
+		result[i].Spec = smith_v1.ResourceSpec{
+			Object: obj,
+		}
+	}
+	if result == nil {
+		return resources, nil
+	}
+	return result, nil
+}
+
+// readSpecSource reads the manifest of an object from a key of a ConfigMap or a Secret in the namespace of the Bundle.
+func (st *bundleSyncTask) readSpecSource(source *smith_v1.SpecSource) (*unstructured.Unstructured, error) {
+	var data []byte
+	switch {
+	case source.ConfigMapKeyRef != nil:
+		ref := source.ConfigMapKeyRef
+		obj, exists, err := st.store.Get(configMapGVK, st.bundle.Namespace, ref.Name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failure retrieving ConfigMap %q", ref.Name)
+		}
+		if !exists {
+			return nil, errors.Errorf("ConfigMap %q not found", ref.Name)
+		}
+		value, ok := obj.(*core_v1.ConfigMap).Data[ref.Key]
+		if !ok {
+			return nil, errors.Errorf("key %q not found in ConfigMap %q", ref.Key, ref.Name)
+		}
+		data = []byte(value)
+	case source.SecretKeyRef != nil:
+		ref := source.SecretKeyRef
+		obj, exists, err := st.store.Get(secretGVK, st.bundle.Namespace, ref.Name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failure retrieving Secret %q", ref.Name)
+		}
+		if !exists {
+			return nil, errors.Errorf("Secret %q not found", ref.Name)
+		}
+		data = obj.(*core_v1.Secret).Data[ref.Key]
+		if data == nil {
+			return nil, errors.Errorf("key %q not found in Secret %q", ref.Key, ref.Name)
+		}
+	default:
+		return nil, errors.New(`neither "configMapKeyRef" nor "secretKeyRef" field is specified`)
+	}
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse manifest")
+	}
+	obj := &unstructured.Unstructured{}
+	if err = obj.UnmarshalJSON(jsonData); err != nil {
+		return nil, errors.Wrap(err, "failed to parse manifest")
+	}
+	if obj.GetName() == "" && obj.GetGenerateName() == "" {
+		return nil, errors.New("object name or generateName is required")
+	}
+	return obj, nil
+}
+
+// resolvedObject returns the object of the resource to record in its status.
+// The object is only recorded for resources with specFrom.
+func (st *bundleSyncTask) resolvedObject(res *smith_v1.Resource, oldStatus *smith_v1.ResourceStatus) *smith_v1.ResolvedObject {
+	if res.SpecFrom == nil {
+		return nil
+	}
+	if st.resolvedResources == nil {
+		// Resources were not resolved, e.g. because a spec source is missing. Keep what is known.
+		if oldStatus != nil {
+			return oldStatus.ResolvedObject
+		}
+		return nil
+	}
+	for i := range st.resolvedResources {
+		resolved := &st.resolvedResources[i]
+		if resolved.Name != res.Name || resolved.Spec.Object == nil {
+			continue
+		}
+		apiVersion, kind := resolved.Spec.Object.GetObjectKind().GroupVersionKind().ToAPIVersionAndKind()
+		return &smith_v1.ResolvedObject{
+			APIVersion: apiVersion,
+			Kind:       kind,
+			Name:       resolved.Spec.Object.(meta_v1.Object).GetName(),
+		}
+	}
+	return nil
+}
+
+func resolvedObjectEqual(a, b *smith_v1.ResolvedObject) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
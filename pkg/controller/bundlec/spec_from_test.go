@@ -0,0 +1,226 @@
+package bundlec
+
+import (
+	"testing"
+
+	"github.com/atlassian/ctrl"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/atlassian/smith/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+)
+
+func specSourceStore(t *testing.T, objs ...interface{}) *store.Multi {
+	multi := store.NewMulti()
+	cmInf := cache.NewSharedIndexInformer(&cache.ListWatch{}, &core_v1.ConfigMap{}, 0, cache.Indexers{})
+	secretInf := cache.NewSharedIndexInformer(&cache.ListWatch{}, &core_v1.Secret{}, 0, cache.Indexers{})
+	require.NoError(t, multi.AddInformer(configMapGVK, cmInf))
+	require.NoError(t, multi.AddInformer(secretGVK, secretInf))
+	for _, obj := range objs {
+		switch obj.(type) {
+		case *core_v1.ConfigMap:
+			require.NoError(t, cmInf.GetStore().Add(obj))
+		case *core_v1.Secret:
+			require.NoError(t, secretInf.GetStore().Add(obj))
+		}
+	}
+	return multi
+}
+
+func specFromResource(name smith_v1.ResourceName, source smith_v1.SpecSource) smith_v1.Resource {
+	return smith_v1.Resource{
+		Name:     name,
+		SpecFrom: &source,
+	}
+}
+
+func TestResolveSpecFrom(t *testing.T) {
+	t.Parallel()
+	st := bundleSyncTask{
+		store: specSourceStore(t,
+			&core_v1.ConfigMap{
+				ObjectMeta: meta_v1.ObjectMeta{Namespace: "ns", Name: "manifests"},
+				Data: map[string]string{
+					"deployment.yaml": "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: d1\nspec:\n  replicas: 3\n",
+				},
+			},
+			&core_v1.Secret{
+				ObjectMeta: meta_v1.ObjectMeta{Namespace: "ns", Name: "manifests"},
+				Data: map[string][]byte{
+					"config.json": []byte(`{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "c1"}, "data": {"a": "b"}}`),
+				},
+			},
+		),
+		bundle: &smith_v1.Bundle{
+			ObjectMeta: meta_v1.ObjectMeta{Namespace: "ns", Name: "b1"},
+			Spec: smith_v1.BundleSpec{
+				Resources: []smith_v1.Resource{
+					{
+						Name: "inline",
+						Spec: smith_v1.ResourceSpec{
+							Object: crossNamespaceConfigMap("", "inline", nil),
+						},
+					},
+					specFromResource("deployment", smith_v1.SpecSource{
+						ConfigMapKeyRef: &smith_v1.KeyReference{Name: "manifests", Key: "deployment.yaml"},
+					}),
+					specFromResource("config", smith_v1.SpecSource{
+						SecretKeyRef: &smith_v1.KeyReference{Name: "manifests", Key: "config.json"},
+					}),
+				},
+			},
+		},
+	}
+
+	resources, err := st.resources()
+	require.NoError(t, err)
+	require.Len(t, resources, 3)
+	assert.Equal(t, st.bundle.Spec.Resources[0], resources[0])
+	assert.Equal(t, &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]interface{}{
+				"name": "d1",
+			},
+			"spec": map[string]interface{}{
+				"replicas": int64(3),
+			},
+		},
+	}, resources[1].Spec.Object)
+	require.IsType(t, &unstructured.Unstructured{}, resources[2].Spec.Object)
+	cm := resources[2].Spec.Object.(*unstructured.Unstructured)
+	assert.Equal(t, "c1", cm.GetName())
+	assert.Equal(t, "ConfigMap", cm.GetKind())
+
+	// The Bundle is not mutated
+	assert.Nil(t, st.bundle.Spec.Resources[1].Spec.Object)
+	assert.Nil(t, st.bundle.Spec.Resources[2].Spec.Object)
+
+	// Objects of resources with specFrom are recorded in the status
+	assert.Nil(t, st.resolvedObject(&st.bundle.Spec.Resources[0], nil))
+	assert.Equal(t, &smith_v1.ResolvedObject{
+		APIVersion: "apps/v1",
+		Kind:       "Deployment",
+		Name:       "d1",
+	}, st.resolvedObject(&st.bundle.Spec.Resources[1], nil))
+
+	// What is known is kept if resources were not resolved
+	old := &smith_v1.ResourceStatus{
+		Name:           "deployment",
+		ResolvedObject: &smith_v1.ResolvedObject{APIVersion: "apps/v1", Kind: "Deployment", Name: "d0"},
+	}
+	st.resolvedResources = nil
+	assert.Equal(t, old.ResolvedObject, st.resolvedObject(&st.bundle.Spec.Resources[1], old))
+}
+
+func TestResolveSpecFromErrors(t *testing.T) {
+	t.Parallel()
+	multi := specSourceStore(t, &core_v1.ConfigMap{
+		ObjectMeta: meta_v1.ObjectMeta{Namespace: "ns", Name: "manifests"},
+		Data: map[string]string{
+			"nameless.yaml": "apiVersion: v1\nkind: ConfigMap\n",
+			"invalid.yaml":  "kind: [",
+		},
+	})
+	testcases := map[string]struct {
+		source smith_v1.SpecSource
+		err    string
+	}{
+		"missing ConfigMap": {
+			source: smith_v1.SpecSource{ConfigMapKeyRef: &smith_v1.KeyReference{Name: "missing", Key: "a"}},
+			err:    `failed to read spec of resource "a": ConfigMap "missing" not found`,
+		},
+		"missing key": {
+			source: smith_v1.SpecSource{ConfigMapKeyRef: &smith_v1.KeyReference{Name: "manifests", Key: "missing"}},
+			err:    `failed to read spec of resource "a": key "missing" not found in ConfigMap "manifests"`,
+		},
+		"missing Secret": {
+			source: smith_v1.SpecSource{SecretKeyRef: &smith_v1.KeyReference{Name: "manifests", Key: "a"}},
+			err:    `failed to read spec of resource "a": Secret "manifests" not found`,
+		},
+		"nameless object": {
+			source: smith_v1.SpecSource{ConfigMapKeyRef: &smith_v1.KeyReference{Name: "manifests", Key: "nameless.yaml"}},
+			err:    `failed to read spec of resource "a": object name or generateName is required`,
+		},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			st := bundleSyncTask{
+				store: multi,
+				bundle: &smith_v1.Bundle{
+					ObjectMeta: meta_v1.ObjectMeta{Namespace: "ns", Name: "b1"},
+					Spec: smith_v1.BundleSpec{
+						Resources: []smith_v1.Resource{specFromResource("a", tc.source)},
+					},
+				},
+			}
+			_, err := st.resources()
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+
+	st := bundleSyncTask{
+		store: multi,
+		bundle: &smith_v1.Bundle{
+			ObjectMeta: meta_v1.ObjectMeta{Namespace: "ns", Name: "b1"},
+			Spec: smith_v1.BundleSpec{
+				Resources: []smith_v1.Resource{specFromResource("a", smith_v1.SpecSource{
+					ConfigMapKeyRef: &smith_v1.KeyReference{Name: "manifests", Key: "invalid.yaml"},
+				})},
+			},
+		},
+	}
+	_, err := st.resources()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `failed to read spec of resource "a": failed to parse manifest`)
+}
+
+func TestSpecSourceEventHandler(t *testing.T) {
+	t.Parallel()
+	bundleInf := cache.NewSharedIndexInformer(&cache.ListWatch{}, &smith_v1.Bundle{}, 0, cache.Indexers{})
+	bs, err := store.NewBundle(bundleInf, store.NewMulti(), nil)
+	require.NoError(t, err)
+	require.NoError(t, bundleInf.GetStore().Add(&smith_v1.Bundle{
+		ObjectMeta: meta_v1.ObjectMeta{Namespace: "ns", Name: "b1"},
+		Spec: smith_v1.BundleSpec{
+			Resources: []smith_v1.Resource{
+				specFromResource("a", smith_v1.SpecSource{
+					ConfigMapKeyRef: &smith_v1.KeyReference{Name: "manifests", Key: "a.yaml"},
+				}),
+			},
+		},
+	}))
+	queue := &fakeWorkQueue{}
+	cmHandler := &watchedObjectEventHandler{
+		logger:     zaptest.NewLogger(t),
+		workQueue:  queue,
+		gk:         configMapGVK.GroupKind(),
+		getBundles: bs.GetBundlesBySpecSource,
+		dependency: "it reads specs from",
+	}
+	secretHandler := &watchedObjectEventHandler{
+		logger:     zaptest.NewLogger(t),
+		workQueue:  queue,
+		gk:         secretGVK.GroupKind(),
+		getBundles: bs.GetBundlesBySpecSource,
+		dependency: "it reads specs from",
+	}
+
+	secret := &core_v1.Secret{ObjectMeta: meta_v1.ObjectMeta{Namespace: "ns", Name: "manifests"}}
+	secretHandler.OnAdd(secret)
+	cmHandler.OnAdd(&core_v1.ConfigMap{ObjectMeta: meta_v1.ObjectMeta{Namespace: "other", Name: "manifests"}})
+	assert.Empty(t, queue.added)
+
+	cm := &core_v1.ConfigMap{ObjectMeta: meta_v1.ObjectMeta{Namespace: "ns", Name: "manifests"}}
+	cmHandler.OnUpdate(cm, cm)
+	cmHandler.OnDelete(cache.DeletedFinalStateUnknown{Key: "ns/manifests", Obj: cm})
+	assert.Equal(t, []ctrl.QueueKey{{Namespace: "ns", Name: "b1"}, {Namespace: "ns", Name: "b1"}}, queue.added)
+}
@@ -3,22 +3,22 @@ package bundlec
 import (
 	"fmt"
 	"reflect"
-	"regexp"
+	"strings"
 	"unicode/utf8"
 
 	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/atlassian/smith/pkg/refexpr"
 	"github.com/atlassian/smith/pkg/resources"
 	"github.com/pkg/errors"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 )
 
-var (
-	// ?s allows us to match multiline expressions.
-	reference = regexp.MustCompile(`(?s)^(!+)\{(.+)}$`)
-)
-
 type specProcessor struct {
 	variables map[smith_v1.ReferenceName]interface{}
+	// resources inline references are resolved against. Nil if the spec is processed with examples.
+	resources map[smith_v1.ResourceName]*resourceInfo
+	// dependencies of the resource. Inline references may only point at them.
+	dependencies map[smith_v1.ResourceName]struct{}
 }
 
 // noExampleError occurs when we try to process the spec with examples rather
@@ -47,8 +47,38 @@ func isNoExampleError(err error) bool {
 	}
 }
 
-func newSpec(resources map[smith_v1.ResourceName]*resourceInfo, references []smith_v1.Reference) (*specProcessor, error) {
+// externalReferenceError occurs when a reference to an external secret store cannot be resolved.
+// The store may be temporarily unavailable so such errors are retriable.
+type externalReferenceError struct {
+	referenceName smith_v1.ReferenceName
+	err           error
+}
+
+func (e *externalReferenceError) Error() string {
+	return fmt.Sprintf("failed to resolve reference %q: %v", e.referenceName, e.err)
+}
+
+func isExternalReferenceError(err error) bool {
+	switch typedErr := err.(type) {
+	case utilerrors.Aggregate:
+		for _, e := range typedErr.Errors() {
+			if _, ok := errors.Cause(e).(*externalReferenceError); ok {
+				return true
+			}
+		}
+		return false
+	case *externalReferenceError:
+		return true
+	default:
+		return false
+	}
+}
+
+func newSpec(resources map[smith_v1.ResourceName]*resourceInfo, references []smith_v1.Reference, secretResolver SecretResolver) (*specProcessor, error) {
 	variables, err := resolveAllReferences(references, func(reference smith_v1.Reference) (interface{}, error) {
+		if reference.IsExternal() {
+			return resolveExternalReference(secretResolver, reference)
+		}
 		return resolveReference(resources, reference)
 	})
 
@@ -56,8 +86,17 @@ func newSpec(resources map[smith_v1.ResourceName]*resourceInfo, references []smi
 		return nil, err
 	}
 
+	dependencies := make(map[smith_v1.ResourceName]struct{}, len(references))
+	for _, reference := range references {
+		if reference.Resource != "" {
+			dependencies[reference.Resource] = struct{}{}
+		}
+	}
+
 	return &specProcessor{
-		variables: variables,
+		variables:    variables,
+		resources:    resources,
+		dependencies: dependencies,
 	}, nil
 }
 
@@ -149,13 +188,30 @@ func (sp *specProcessor) ProcessValue(value interface{}, path ...string) (interf
 }
 
 func (sp *specProcessor) ProcessString(value string, path ...string) (interface{}, error) {
-	match := reference.FindStringSubmatch(value)
+	match := refexpr.Reference.FindStringSubmatch(value)
 	if match == nil {
 		return value, nil
 	}
 
 	// TODO escaping.
 
+	if inline := refexpr.InlineReference.FindStringSubmatch(match[2]); inline != nil {
+		return sp.resolveInlineReference(match[2], smith_v1.ResourceName(inline[1]), inline[2], inline[3])
+	}
+
+	if refexpr.IsExpression(match[2]) {
+		// Expressions are evaluated to strings
+		expr, err := refexpr.Parse(match[2])
+		if err != nil {
+			return nil, err
+		}
+		values := make(map[string]interface{}, len(sp.variables))
+		for name, value := range sp.variables {
+			values[string(name)] = value
+		}
+		return expr.Evaluate(values)
+	}
+
 	reference, allowed := sp.variables[smith_v1.ReferenceName(match[2])]
 	if !allowed {
 		return nil, errors.Errorf("reference does not exist in resource references block: %s", match[2])
@@ -164,6 +220,39 @@ func (sp *specProcessor) ProcessString(value string, path ...string) (interface{
 	return reference, nil
 }
 
+// resolveInlineReference resolves a reference to a field of a dependency that is not declared in the references
+// block. The path is a JsonPath, the "$." prefix is optional.
+func (sp *specProcessor) resolveInlineReference(contents string, resource smith_v1.ResourceName, modifier, path string) (interface{}, error) {
+	if sp.resources == nil {
+		// Inline references do not have examples
+		return nil, errors.WithStack(&noExampleError{referenceName: smith_v1.ReferenceName(contents)})
+	}
+	if _, ok := sp.dependencies[resource]; !ok {
+		return nil, errors.Errorf("inline reference %q points at resource %q that is not a dependency, declare it in resource references block", contents, resource)
+	}
+	if strings.HasPrefix(path, "$.") {
+		path = path[len("$."):]
+	}
+	// Errors name the reference after its contents so that it is clear which use failed
+	return resolveReference(sp.resources, smith_v1.Reference{
+		Name:     smith_v1.ReferenceName(contents),
+		Resource: resource,
+		Path:     path,
+		Modifier: modifier,
+	})
+}
+
+func resolveExternalReference(secretResolver SecretResolver, reference smith_v1.Reference) (interface{}, error) {
+	if secretResolver == nil {
+		return nil, errors.Errorf("reference %q points at an external secret store but no secret stores are configured", reference.Name)
+	}
+	value, err := secretResolver.Resolve(reference.Source)
+	if err != nil {
+		return nil, errors.WithStack(&externalReferenceError{referenceName: reference.Name, err: err})
+	}
+	return value, nil
+}
+
 func resolveReference(resInfos map[smith_v1.ResourceName]*resourceInfo, reference smith_v1.Reference) (interface{}, error) {
 	resInfo := resInfos[reference.Resource]
 	if resInfo == nil {
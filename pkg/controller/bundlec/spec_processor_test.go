@@ -5,7 +5,7 @@ import (
 	"testing"
 
 	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
-
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	core_v1 "k8s.io/api/core/v1"
@@ -49,7 +49,7 @@ func TestSpecProcessor(t *testing.T) {
 			Resource: "res1",
 			Path:     "a.object",
 		},
-	})
+	}, nil)
 	require.NoError(t, err)
 	obj := map[string]interface{}{
 		"ref": map[string]interface{}{
@@ -155,7 +155,7 @@ func TestSpecProcessorBindSecret(t *testing.T) {
 			Path:     "Data.password",
 			Modifier: "bindsecret",
 		},
-	})
+	}, nil)
 	require.NoError(t, err)
 	obj := map[string]interface{}{
 		"ref": map[string]interface{}{
@@ -174,6 +174,66 @@ func TestSpecProcessorBindSecret(t *testing.T) {
 	assert.Equal(t, expected, obj)
 }
 
+type fakeSecretResolver map[string]interface{}
+
+func (r fakeSecretResolver) Resolve(source string) (interface{}, error) {
+	value, ok := r[source]
+	if !ok {
+		return nil, errors.New("secret store is unavailable")
+	}
+	return value, nil
+}
+
+func TestSpecProcessorExternalReference(t *testing.T) {
+	t.Parallel()
+	sp, err := newSpec(processedResources(), []smith_v1.Reference{
+		{
+			Name:     "res1aint",
+			Resource: "res1",
+			Path:     "a.int",
+		},
+		{
+			Name:   "password",
+			Source: "vault:secret/db#password",
+		},
+	}, fakeSecretResolver{
+		"vault:secret/db#password": "pass1",
+	})
+	require.NoError(t, err)
+	obj := map[string]interface{}{
+		"ref": map[string]interface{}{
+			"int":    "!{res1aint}",
+			"secret": "!{password}",
+		},
+	}
+	expected := map[string]interface{}{
+		"ref": map[string]interface{}{
+			"int":    42,
+			"secret": "pass1",
+		},
+	}
+
+	require.NoError(t, sp.ProcessObject(obj))
+	assert.Equal(t, expected, obj)
+}
+
+func TestSpecProcessorExternalReferenceErrors(t *testing.T) {
+	t.Parallel()
+	references := []smith_v1.Reference{
+		{
+			Name:   "password",
+			Source: "vault:secret/db#password",
+		},
+	}
+	_, err := newSpec(processedResources(), references, nil)
+	require.Error(t, err)
+	assert.False(t, isExternalReferenceError(errors.Cause(err)))
+
+	_, err = newSpec(processedResources(), references, fakeSecretResolver{})
+	require.EqualError(t, err, `failed to resolve reference "password": secret store is unavailable`)
+	assert.True(t, isExternalReferenceError(errors.Cause(err)))
+}
+
 func TestSpecProcessorBindSecretWithJsonField(t *testing.T) {
 	// We don't convert the Secret to unstructured so we have base64 decoded 'stuff'.
 	// However, kubernetes jsonpath is smart (crazy?) enough to use both the json
@@ -191,7 +251,7 @@ func TestSpecProcessorBindSecretWithJsonField(t *testing.T) {
 			Path:     "data.password",
 			Modifier: "bindsecret",
 		},
-	})
+	}, nil)
 	require.NoError(t, err)
 	obj := map[string]interface{}{
 		"ref": map[string]interface{}{
@@ -247,6 +307,130 @@ func TestSpecProcessorExamples(t *testing.T) {
 	assert.Equal(t, expected, obj)
 }
 
+func TestSpecProcessorInlineReferences(t *testing.T) {
+	t.Parallel()
+	sp, err := newSpec(processedResources(), []smith_v1.Reference{
+		{
+			Resource: "res1",
+		},
+		{
+			Resource: "resbinding",
+		},
+	}, nil)
+	require.NoError(t, err)
+	obj := map[string]interface{}{
+		"ref": map[string]interface{}{
+			"string":   "!{res1#$.a.string}",
+			"int":      "!{res1#a.int}",
+			"slice":    `!{res1#$.a.slice[?(@.label=="label2")].value}`,
+			"object":   "!{res1#$.a.object}",
+			"password": "!{resbinding:bindsecret#data.password}",
+			"text":     "res1#a.string",
+		},
+	}
+	expected := map[string]interface{}{
+		"ref": map[string]interface{}{
+			"string": "string1",
+			"int":    42,
+			"slice":  "value2",
+			"object": map[string]interface{}{
+				"a": 1,
+				"b": "str",
+			},
+			"password": "secret",
+			"text":     "res1#a.string",
+		},
+	}
+
+	require.NoError(t, sp.ProcessObject(obj))
+	assert.Equal(t, expected, obj)
+}
+
+func TestSpecProcessorInlineReferenceErrors(t *testing.T) {
+	t.Parallel()
+	sp, err := newSpec(processedResources(), []smith_v1.Reference{
+		{
+			Resource: "res1",
+		},
+	}, nil)
+	require.NoError(t, err)
+	testcases := map[string]struct {
+		value string
+		err   string
+	}{
+		"missing field": {
+			value: "!{res1#$.status.loadBalancer.ingress[0].ip}",
+			err:   `failed to process reference "res1#$.status.loadBalancer.ingress[0].ip": JsonPath execute error: status is not found`,
+		},
+		"not a dependency": {
+			value: "!{resX#$.a.string}",
+			err:   `inline reference "resX#$.a.string" points at resource "resX" that is not a dependency, declare it in resource references block`,
+		},
+		"unknown modifier": {
+			value: "!{res1:someotherthing#data.password}",
+			err:   `reference modifier "someotherthing" not understood for "res1"`,
+		},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			_, err := sp.ProcessString(tc.value)
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+
+	examplesSp, err := newExamplesSpec(nil)
+	require.NoError(t, err)
+	_, err = examplesSp.ProcessString("!{res1#$.a.string}")
+	assert.True(t, isNoExampleError(err))
+}
+
+func TestSpecProcessorExpressions(t *testing.T) {
+	t.Parallel()
+	sp, err := newSpec(processedResources(), []smith_v1.Reference{
+		{
+			Name:     "res1astring",
+			Resource: "res1",
+			Path:     "a.string",
+		},
+		{
+			Name:     "res1aint",
+			Resource: "res1",
+			Path:     "a.int",
+		},
+		{
+			Name:     "res1-object",
+			Resource: "res1",
+			Path:     "a.object",
+		},
+	}, nil)
+	require.NoError(t, err)
+	obj := map[string]interface{}{
+		"ref": map[string]interface{}{
+			"dsn":     `!{printf "postgres://%s@db:%v" .res1astring .res1aint}`,
+			"encoded": "!{.res1astring | base64}",
+			"json":    `!{index . "res1-object" | toJson}`,
+			"plain":   "!{res1aint}",
+		},
+	}
+	expected := map[string]interface{}{
+		"ref": map[string]interface{}{
+			"dsn":     "postgres://string1@db:42",
+			"encoded": "c3RyaW5nMQ==",
+			"json":    `{"a":1,"b":"str"}`,
+			// Names of references are replaced with values as is
+			"plain": 42,
+		},
+	}
+
+	require.NoError(t, sp.ProcessObject(obj))
+	assert.Equal(t, expected, obj)
+
+	_, err = sp.ProcessString("!{.undeclared | base64}")
+	assert.Error(t, err)
+}
+
 func TestSpecProcessorErrors(t *testing.T) {
 	t.Parallel()
 	inputs := []struct {
@@ -323,7 +507,7 @@ func TestSpecProcessorErrors(t *testing.T) {
 			if input.examplesOnly {
 				_, err = newExamplesSpec([]smith_v1.Reference{input.reference})
 			} else {
-				_, err = newSpec(processedResources(), []smith_v1.Reference{input.reference})
+				_, err = newSpec(processedResources(), []smith_v1.Reference{input.reference}, nil)
 			}
 			assert.EqualError(t, err, input.err)
 		})
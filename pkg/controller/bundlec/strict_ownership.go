@@ -0,0 +1,32 @@
+package bundlec
+
+import (
+	"fmt"
+
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// EventReasonOwnershipMismatch is the reason of the Event recorded in strict ownership mode when the controller
+	// owner reference and the BundleNameLabel of an object do not agree.
+	EventReasonOwnershipMismatch = "OwnershipMismatch"
+)
+
+// ownershipMismatch checks that the BundleNameLabel of an object controlled by the Bundle points at the Bundle.
+// In strict ownership mode an object is only considered to belong to the Bundle if both agree.
+// allowMissing means an object without the label is not a mismatch, e.g. because it is going to be labeled.
+func ownershipMismatch(bundle *smith_v1.Bundle, obj meta_v1.Object, allowMissing bool) (string, bool) {
+	labelValue, labeled := obj.GetLabels()[smith.BundleNameLabel]
+	if !labeled {
+		if allowMissing {
+			return "", false
+		}
+		return fmt.Sprintf("object is controlled by the Bundle but does not have the %s label", smith.BundleNameLabel), true
+	}
+	if labelValue != smith.LabelValue(bundle.Name) {
+		return fmt.Sprintf("object is controlled by the Bundle but is labeled %s=%q", smith.BundleNameLabel, labelValue), true
+	}
+	return "", false
+}
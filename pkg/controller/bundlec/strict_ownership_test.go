@@ -0,0 +1,110 @@
+package bundlec
+
+import (
+	"testing"
+
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestFindObjectsToDeleteStrictOwnership(t *testing.T) {
+	t.Parallel()
+	tr := true
+	bundle := &smith_v1.Bundle{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "bundle1",
+			Namespace: "ns1",
+			UID:       "uid1",
+		},
+	}
+	configMap := func(name string, labels map[string]string) runtime.Object {
+		return &core_v1.ConfigMap{
+			TypeMeta: meta_v1.TypeMeta{
+				Kind:       "ConfigMap",
+				APIVersion: core_v1.SchemeGroupVersion.String(),
+			},
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:      name,
+				Namespace: "ns1",
+				Labels:    labels,
+				OwnerReferences: []meta_v1.OwnerReference{
+					{
+						APIVersion: smith_v1.BundleResourceGroupVersion,
+						Kind:       smith_v1.BundleResourceKind,
+						Name:       "bundle1",
+						UID:        "uid1",
+						Controller: &tr,
+					},
+				},
+			},
+		}
+	}
+	objs := []runtime.Object{
+		configMap("labeled", map[string]string{smith.BundleNameLabel: "bundle1"}),
+		configMap("unlabeled", nil),
+		configMap("other", map[string]string{smith.BundleNameLabel: "bundle2"}),
+	}
+	newTask := func(strict bool, recorder record.EventRecorder) *bundleSyncTask {
+		return &bundleSyncTask{
+			logger: zap.NewNop(),
+			store: fakeStore{
+				controlled: map[types.UID][]runtime.Object{
+					"uid1": objs,
+				},
+			},
+			bundle:          bundle,
+			strictOwnership: strict,
+			recorder:        recorder,
+		}
+	}
+	names := func(st *bundleSyncTask) []string {
+		var result []string
+		for ref := range st.objectsToDelete {
+			result = append(result, ref.Name)
+		}
+		return result
+	}
+
+	st := newTask(false, nil)
+	require.NoError(t, st.findObjectsToDelete())
+	assert.ElementsMatch(t, []string{"labeled", "unlabeled", "other"}, names(st))
+
+	recorder := record.NewFakeRecorder(2)
+	st = newTask(true, recorder)
+	require.NoError(t, st.findObjectsToDelete())
+	assert.ElementsMatch(t, []string{"labeled"}, names(st))
+	require.Len(t, recorder.Events, 2)
+	assert.Contains(t, <-recorder.Events, EventReasonOwnershipMismatch)
+}
+
+func TestOwnershipMismatch(t *testing.T) {
+	t.Parallel()
+	bundle := &smith_v1.Bundle{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name: "bundle1",
+		},
+	}
+	obj := &meta_v1.ObjectMeta{}
+
+	_, mismatch := ownershipMismatch(bundle, obj, true)
+	assert.False(t, mismatch)
+	_, mismatch = ownershipMismatch(bundle, obj, false)
+	assert.True(t, mismatch)
+
+	obj.Labels = map[string]string{smith.BundleNameLabel: "bundle2"}
+	message, mismatch := ownershipMismatch(bundle, obj, true)
+	assert.True(t, mismatch)
+	assert.Contains(t, message, "bundle2")
+
+	obj.Labels[smith.BundleNameLabel] = "bundle1"
+	_, mismatch = ownershipMismatch(bundle, obj, false)
+	assert.False(t, mismatch)
+}
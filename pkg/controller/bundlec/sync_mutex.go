@@ -0,0 +1,42 @@
+package bundlec
+
+import (
+	"sync"
+	"time"
+
+	"github.com/atlassian/ctrl"
+)
+
+const (
+	// syncMutexRetryDelay is how long processing of a Bundle is postponed for if its mutex is held by another Bundle.
+	syncMutexRetryDelay = time.Second
+)
+
+// syncMutexes serializes processing of Bundles that share a named mutex.
+// Mutexes are not blocking - a Bundle that cannot acquire its mutex is re-queued
+// so that workers are not held up waiting. The zero value is ready to use.
+type syncMutexes struct {
+	mx      sync.Mutex
+	holders map[string]ctrl.QueueKey // mutex name -> Bundle holding it
+}
+
+// tryLock acquires the named mutex for the Bundle. Returns false if the mutex is held by another Bundle.
+func (m *syncMutexes) tryLock(name string, key ctrl.QueueKey) bool {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	if holder, ok := m.holders[name]; ok {
+		return holder == key
+	}
+	if m.holders == nil {
+		m.holders = make(map[string]ctrl.QueueKey)
+	}
+	m.holders[name] = key
+	return true
+}
+
+// unlock releases the named mutex.
+func (m *syncMutexes) unlock(name string) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	delete(m.holders, name)
+}
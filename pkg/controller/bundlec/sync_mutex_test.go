@@ -0,0 +1,22 @@
+package bundlec
+
+import (
+	"testing"
+
+	"github.com/atlassian/ctrl"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncMutexes(t *testing.T) {
+	t.Parallel()
+	var m syncMutexes
+	a := ctrl.QueueKey{Namespace: "ns1", Name: "a"}
+	b := ctrl.QueueKey{Namespace: "ns2", Name: "b"}
+
+	assert.True(t, m.tryLock("db1", a))
+	assert.False(t, m.tryLock("db1", b)) // held by another Bundle, even in another namespace
+	assert.True(t, m.tryLock("db2", b))  // different mutex
+
+	m.unlock("db1")
+	assert.True(t, m.tryLock("db1", b))
+}
@@ -0,0 +1,172 @@
+package bundlec
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// syncLatencyWindow is the number of most recent syncs the average sync latency is computed over.
+	syncLatencyWindow = 100
+)
+
+// SyncStatsSnapshot is the state of Bundle processing at a point in time.
+// The shape is suitable for external autoscalers that read a JSON document, e.g. the KEDA metrics-api scaler.
+type SyncStatsSnapshot struct {
+	// PendingBundles is the number of Bundles with a spec generation that has not been observed yet.
+	PendingBundles int `json:"pendingBundles"`
+	// InFlight is the number of Bundles that are being processed right now.
+	InFlight int `json:"inFlight"`
+	// AverageSyncLatencySeconds is the average duration of the most recent syncs.
+	AverageSyncLatencySeconds float64 `json:"averageSyncLatencySeconds"`
+}
+
+// SyncStats tracks processing of Bundles to provide signals for autoscaling of the controller.
+type SyncStats struct {
+	// listBundles lists Bundles known to the controller, e.g. the List method of the Bundle informer's store.
+	listBundles func() []interface{}
+	now         func() time.Time
+
+	syncDuration   prometheus.Histogram
+	inFlightGauge  prometheus.Gauge
+	pendingBundles prometheus.GaugeFunc
+	pruneDeferrals prometheus.Counter
+
+	mx        sync.Mutex
+	inFlight  int
+	latencies [syncLatencyWindow]time.Duration
+	next      int
+	count     int
+}
+
+func NewSyncStats(listBundles func() []interface{}) *SyncStats {
+	s := &SyncStats{
+		listBundles: listBundles,
+		now:         time.Now,
+		syncDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: "smith",
+			Subsystem: "bundle",
+			Name:      "sync_duration_seconds",
+			Help:      "Duration of Bundle syncs",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 2, 12),
+		}),
+		inFlightGauge: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "smith",
+			Subsystem: "bundle",
+			Name:      "syncs_in_flight",
+			Help:      "Number of Bundles being processed",
+		}),
+	}
+	s.pruneDeferrals = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "smith",
+		Subsystem: "bundle",
+		Name:      "prune_deferred_total",
+		Help:      "Number of syncs that did not delete objects removed from Bundles because informers had not synced yet",
+	})
+	s.pendingBundles = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "smith",
+		Subsystem: "bundle",
+		Name:      "pending",
+		Help:      "Number of Bundles with a spec generation that has not been observed yet",
+	}, func() float64 {
+		return float64(s.countPendingBundles())
+	})
+	return s
+}
+
+// RegisterMetrics registers metrics of the stats with the registerer.
+func (s *SyncStats) RegisterMetrics(registerer prometheus.Registerer) error {
+	for _, c := range []prometheus.Collector{s.syncDuration, s.inFlightGauge, s.pendingBundles, s.pruneDeferrals} {
+		if err := registerer.Register(c); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// syncStarted records the start of a sync. The returned function must be called once the sync is finished.
+func (s *SyncStats) syncStarted() func() {
+	start := s.now()
+	s.mx.Lock()
+	s.inFlight++
+	s.mx.Unlock()
+	s.inFlightGauge.Inc()
+	return func() {
+		duration := s.now().Sub(start)
+		s.inFlightGauge.Dec()
+		s.syncDuration.Observe(duration.Seconds())
+		s.mx.Lock()
+		defer s.mx.Unlock()
+		s.inFlight--
+		s.latencies[s.next] = duration
+		s.next = (s.next + 1) % syncLatencyWindow
+		if s.count < syncLatencyWindow {
+			s.count++
+		}
+	}
+}
+
+// pruneDeferred records a sync that did not delete objects removed from the Bundle because informers had not synced.
+func (s *SyncStats) pruneDeferred() {
+	if s == nil {
+		return
+	}
+	s.pruneDeferrals.Inc()
+}
+
+// Snapshot returns the current state of Bundle processing.
+func (s *SyncStats) Snapshot() SyncStatsSnapshot {
+	pending := s.countPendingBundles()
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	var total time.Duration
+	for i := 0; i < s.count; i++ {
+		total += s.latencies[i]
+	}
+	var average float64
+	if s.count > 0 {
+		average = (total / time.Duration(s.count)).Seconds()
+	}
+	return SyncStatsSnapshot{
+		PendingBundles:            pending,
+		InFlight:                  s.inFlight,
+		AverageSyncLatencySeconds: average,
+	}
+}
+
+func (s *SyncStats) countPendingBundles() int {
+	pending := 0
+	for _, obj := range s.listBundles() {
+		if isPendingBundle(obj.(*smith_v1.Bundle)) {
+			pending++
+		}
+	}
+	return pending
+}
+
+// isPendingBundle returns true if the spec generation of the Bundle has not been observed yet.
+func isPendingBundle(bundle *smith_v1.Bundle) bool {
+	return bundle.DeletionTimestamp == nil && bundle.Generation > bundle.Status.ObservedGeneration
+}
+
+// ServeHTTP serves the snapshot as JSON.
+func (s *SyncStats) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	data, err := json.Marshal(s.Snapshot())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data) // Nothing can be done if write fails
+}
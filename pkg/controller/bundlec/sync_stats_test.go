@@ -0,0 +1,91 @@
+package bundlec
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSyncStats(t *testing.T) {
+	t.Parallel()
+	deleted := meta_v1.Now()
+	bundles := []interface{}{
+		&smith_v1.Bundle{ // pending
+			ObjectMeta: meta_v1.ObjectMeta{Generation: 2},
+			Status:     smith_v1.BundleStatus{ObservedGeneration: 1},
+		},
+		&smith_v1.Bundle{ // up to date
+			ObjectMeta: meta_v1.ObjectMeta{Generation: 2},
+			Status:     smith_v1.BundleStatus{ObservedGeneration: 2},
+		},
+		&smith_v1.Bundle{ // being deleted
+			ObjectMeta: meta_v1.ObjectMeta{Generation: 2, DeletionTimestamp: &deleted},
+			Status:     smith_v1.BundleStatus{ObservedGeneration: 1},
+		},
+	}
+	s := NewSyncStats(func() []interface{} {
+		return bundles
+	})
+	require.NoError(t, s.RegisterMetrics(prometheus.NewPedanticRegistry()))
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time {
+		return now
+	}
+
+	assert.Equal(t, SyncStatsSnapshot{PendingBundles: 1}, s.Snapshot())
+
+	first := s.syncStarted()
+	second := s.syncStarted()
+	assert.Equal(t, 2, s.Snapshot().InFlight)
+
+	now = now.Add(time.Second)
+	first()
+	now = now.Add(2 * time.Second)
+	second()
+	assert.Equal(t, SyncStatsSnapshot{PendingBundles: 1, AverageSyncLatencySeconds: 2}, s.Snapshot())
+}
+
+func TestSyncStatsLatencyWindow(t *testing.T) {
+	t.Parallel()
+	s := NewSyncStats(func() []interface{} {
+		return nil
+	})
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time {
+		return now
+	}
+	for i := 0; i < syncLatencyWindow; i++ {
+		done := s.syncStarted()
+		now = now.Add(time.Hour)
+		done()
+	}
+	for i := 0; i < syncLatencyWindow; i++ {
+		done := s.syncStarted()
+		now = now.Add(time.Second)
+		done()
+	}
+	assert.Equal(t, 1.0, s.Snapshot().AverageSyncLatencySeconds)
+}
+
+func TestSyncStatsServeHTTP(t *testing.T) {
+	t.Parallel()
+	s := NewSyncStats(func() []interface{} {
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/autoscaling", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"pendingBundles":0,"inFlight":0,"averageSyncLatencySeconds":0}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/autoscaling", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
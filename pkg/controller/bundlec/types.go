@@ -3,6 +3,7 @@ package bundlec
 import (
 	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
 
+	core_v1 "k8s.io/api/core/v1"
 	apiext_v1b1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -23,8 +24,14 @@ type ReadyChecker interface {
 type Store interface {
 	Get(gvk schema.GroupVersionKind, namespace, name string) (obj runtime.Object, exists bool, err error)
 	ObjectsControlledBy(namespace string, uid types.UID) ([]runtime.Object, error)
+	// ObjectsTrackedBy returns objects in all namespaces labeled with the UID of a Bundle.
+	ObjectsTrackedBy(uid types.UID) ([]runtime.Object, error)
 	AddInformer(schema.GroupVersionKind, cache.SharedIndexInformer) error
 	RemoveInformer(schema.GroupVersionKind) bool
+	// HasInformer returns true if an Informer for the specified GVK is registered.
+	HasInformer(schema.GroupVersionKind) bool
+	// HasSynced returns true if Informers for all specified GVKs are registered and have synced.
+	HasSynced(...schema.GroupVersionKind) bool
 }
 
 type BundleStore interface {
@@ -34,8 +41,47 @@ type BundleStore interface {
 	GetBundlesByCrd(*apiext_v1b1.CustomResourceDefinition) ([]*smith_v1.Bundle, error)
 	// GetBundlesByObject returns Bundles which have a resource of a particular group/kind with a name in a namespace.
 	GetBundlesByObject(gk schema.GroupKind, namespace, name string) ([]*smith_v1.Bundle, error)
+	// GetBundlesBySpecSource returns Bundles which have a resource that reads its spec from a ConfigMap or a Secret
+	// of a particular group/kind with a name in a namespace.
+	GetBundlesBySpecSource(gk schema.GroupKind, namespace, name string) ([]*smith_v1.Bundle, error)
+	// GetBundlesByExternalObject returns Bundles which have an external resource that waits for an object
+	// of a particular group/kind with a name in a namespace.
+	GetBundlesByExternalObject(gk schema.GroupKind, namespace, name string) ([]*smith_v1.Bundle, error)
+}
+
+// NamespaceGetter gets Namespaces by name.
+type NamespaceGetter interface {
+	Get(name string) (*core_v1.Namespace, error)
 }
 
 type SmartClient interface {
 	ForGVK(gvk schema.GroupVersionKind, namespace string) (dynamic.ResourceInterface, error)
 }
+
+// ApplyClient applies objects using server-side apply.
+type ApplyClient interface {
+	Apply(obj *unstructured.Unstructured, namespace, fieldManager string) (*unstructured.Unstructured, error)
+}
+
+// DryRunClient validates creates and updates of objects with server-side dry-run.
+type DryRunClient interface {
+	DryRunCreate(obj *unstructured.Unstructured, namespace string) error
+	DryRunUpdate(obj *unstructured.Unstructured, namespace string) error
+}
+
+// StrictClient creates and updates objects with strict server-side field validation.
+type StrictClient interface {
+	StrictCreate(obj *unstructured.Unstructured, namespace string) (*unstructured.Unstructured, error)
+	StrictUpdate(obj *unstructured.Unstructured, namespace string) (*unstructured.Unstructured, error)
+}
+
+// SecretResolver resolves references to secrets in external secret stores.
+type SecretResolver interface {
+	Resolve(source string) (interface{}, error)
+}
+
+// SmartClientInvalidator is implemented by SmartClients that cache API discovery information.
+type SmartClientInvalidator interface {
+	// InvalidateGroupKind makes the client forget whatever it knows about the kind.
+	InvalidateGroupKind(gk schema.GroupKind)
+}
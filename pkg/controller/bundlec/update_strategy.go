@@ -0,0 +1,97 @@
+package bundlec
+
+import (
+	"encoding/json"
+
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/atlassian/smith/pkg/speccheck"
+	"github.com/atlassian/smith/pkg/util"
+	"github.com/pkg/errors"
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8s_json "k8s.io/apimachinery/pkg/util/json"
+)
+
+// applyUpdateStrategy merges the spec into the actual object according to the update strategy of the resource.
+// The returned spec is compared with the actual object and used to update it as usual.
+// Metadata is not merged, spec check takes care of it.
+// The strategy is not used for objects that cannot be updated in place, with server-side apply, which merges
+// objects on its own, and in field ownership mode, in which spec check merges objects like the merge strategy does.
+// Secrets are not subject to field ownership.
+func (st *resourceSyncTask) applyUpdateStrategy(res *smith_v1.Resource, spec *unstructured.Unstructured, actual runtime.Object) (*unstructured.Unstructured, error) {
+	gk := spec.GroupVersionKind().GroupKind()
+	isSecret := gk.Group == core_v1.GroupName && gk.Kind == "Secret"
+	if st.applyClient != nil || st.fieldOwnership && !isSecret || isRunToCompletion(gk) {
+		return spec, nil
+	}
+	return mergeSpec(res.UpdateStrategy, spec, actual)
+}
+
+// mergeSpec merges the spec into the actual object. Actual may be nil.
+// With the merge strategy the applied fields are recorded in the LastAppliedAnnotation of the spec.
+func mergeSpec(strategy smith_v1.UpdateStrategy, spec *unstructured.Unstructured, actual runtime.Object) (*unstructured.Unstructured, error) {
+	switch strategy {
+	case "", smith_v1.UpdateStrategyReplace:
+		return spec, nil
+	case smith_v1.UpdateStrategyPatch, smith_v1.UpdateStrategyMerge:
+	default:
+		return nil, errors.Errorf("unknown update strategy %q", strategy)
+	}
+	applied := mergedFields(spec.Object)
+	if strategy == smith_v1.UpdateStrategyMerge {
+		gk := spec.GroupVersionKind().GroupKind()
+		if gk.Group == core_v1.GroupName && gk.Kind == "Secret" {
+			// Data of the Secret would end up in the annotation
+			return nil, errors.Errorf("%q update strategy cannot be used for Secrets", strategy)
+		}
+		data, err := json.Marshal(applied)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to marshal applied configuration")
+		}
+		annotations := spec.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string, 1)
+		}
+		annotations[smith.LastAppliedAnnotation] = string(data)
+		spec.SetAnnotations(annotations)
+	}
+	if actual == nil {
+		return spec, nil
+	}
+	actualUnstr, err := util.RuntimeToUnstructured(actual)
+	if err != nil {
+		return nil, err
+	}
+	var original map[string]interface{}
+	if strategy == smith_v1.UpdateStrategyMerge {
+		if lastApplied, ok := actualUnstr.GetAnnotations()[smith.LastAppliedAnnotation]; ok {
+			if err = k8s_json.Unmarshal([]byte(lastApplied), &original); err != nil {
+				return nil, errors.Wrapf(err, "failed to unmarshal %s annotation", smith.LastAppliedAnnotation)
+			}
+		}
+	}
+	merged := speccheck.ThreeWayMerge(original, applied, mergedFields(actualUnstr.Object))
+	for _, field := range []string{"kind", "apiVersion", "metadata"} {
+		if value, ok := spec.Object[field]; ok {
+			merged[field] = value
+		}
+	}
+	return &unstructured.Unstructured{
+		Object: merged,
+	}, nil
+}
+
+// mergedFields returns top level fields of an object that are merged by update strategies.
+func mergedFields(obj map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(obj))
+	for field, value := range obj {
+		switch field {
+		case "kind", "apiVersion", "metadata", "status":
+			continue
+		}
+		result[field] = value
+	}
+	return result
+}
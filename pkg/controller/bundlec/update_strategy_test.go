@@ -0,0 +1,90 @@
+package bundlec
+
+import (
+	"testing"
+
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func configMapUnstructured(annotations map[string]interface{}, data map[string]interface{}) *unstructured.Unstructured {
+	metadata := map[string]interface{}{
+		"name": "map1",
+	}
+	if annotations != nil {
+		metadata["annotations"] = annotations
+	}
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   metadata,
+			"data":       data,
+		},
+	}
+}
+
+func TestMergeSpecReplace(t *testing.T) {
+	t.Parallel()
+	spec := configMapUnstructured(nil, map[string]interface{}{"a": "1"})
+	actual := configMapUnstructured(nil, map[string]interface{}{"b": "2"})
+
+	merged, err := mergeSpec("", spec, actual)
+	require.NoError(t, err)
+	assert.True(t, spec == merged)
+
+	_, err = mergeSpec("unknown", spec, actual)
+	assert.Error(t, err)
+}
+
+func TestMergeSpecPatch(t *testing.T) {
+	t.Parallel()
+	spec := configMapUnstructured(nil, map[string]interface{}{"a": "1"})
+	actual := configMapUnstructured(nil, map[string]interface{}{"a": "0", "b": "2"})
+
+	merged, err := mergeSpec(smith_v1.UpdateStrategyPatch, spec, actual)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": "1", "b": "2"}, merged.Object["data"])
+	assert.Equal(t, spec.Object["metadata"], merged.Object["metadata"])
+	assert.Empty(t, merged.GetAnnotations())
+}
+
+func TestMergeSpecMerge(t *testing.T) {
+	t.Parallel()
+	spec := configMapUnstructured(nil, map[string]interface{}{"a": "1"})
+	actual := configMapUnstructured(map[string]interface{}{
+		smith.LastAppliedAnnotation: `{"data":{"a":"0","removed":"x"}}`,
+	}, map[string]interface{}{
+		"a":       "0",
+		"removed": "x",
+		"added":   "by someone else",
+	})
+
+	merged, err := mergeSpec(smith_v1.UpdateStrategyMerge, spec, actual)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": "1", "added": "by someone else"}, merged.Object["data"])
+	assert.Equal(t, `{"data":{"a":"1"}}`, merged.GetAnnotations()[smith.LastAppliedAnnotation])
+
+	// Nothing is removed without the annotation
+	actual = configMapUnstructured(nil, map[string]interface{}{"removed": "x"})
+	merged, err = mergeSpec(smith_v1.UpdateStrategyMerge, configMapUnstructured(nil, map[string]interface{}{"a": "1"}), actual)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": "1", "removed": "x"}, merged.Object["data"])
+
+	// Object is created with the annotation
+	merged, err = mergeSpec(smith_v1.UpdateStrategyMerge, configMapUnstructured(nil, map[string]interface{}{"a": "1"}), nil)
+	require.NoError(t, err)
+	assert.Equal(t, `{"data":{"a":"1"}}`, merged.GetAnnotations()[smith.LastAppliedAnnotation])
+}
+
+func TestMergeSpecMergeSecret(t *testing.T) {
+	t.Parallel()
+	spec := configMapUnstructured(nil, map[string]interface{}{"a": "MQ=="})
+	spec.SetKind("Secret")
+
+	_, err := mergeSpec(smith_v1.UpdateStrategyMerge, spec, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,52 @@
+package bundlec
+
+import (
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// WarningMetrics exports the number of warnings in the status of Bundles by reason, so that platform teams can track
+// adoption of deprecated API versions and other issues that do not fail processing.
+type WarningMetrics struct {
+	// listBundles lists Bundles known to the controller, e.g. the List method of the Bundle informer's store.
+	listBundles func() []interface{}
+
+	warningsDesc *prometheus.Desc
+}
+
+func NewWarningMetrics(listBundles func() []interface{}) *WarningMetrics {
+	return &WarningMetrics{
+		listBundles: listBundles,
+		warningsDesc: prometheus.NewDesc("smith_bundle_warnings",
+			"Number of warnings in the status of Bundles", []string{"reason"}, nil),
+	}
+}
+
+// RegisterMetrics registers the metrics with the registerer.
+func (m *WarningMetrics) RegisterMetrics(registerer prometheus.Registerer) error {
+	return errors.WithStack(registerer.Register(m))
+}
+
+// Describe implements prometheus.Collector.
+func (m *WarningMetrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- m.warningsDesc
+}
+
+// Collect implements prometheus.Collector.
+func (m *WarningMetrics) Collect(ch chan<- prometheus.Metric) {
+	for reason, count := range m.count() {
+		ch <- prometheus.MustNewConstMetric(m.warningsDesc, prometheus.GaugeValue, float64(count), reason)
+	}
+}
+
+func (m *WarningMetrics) count() map[string]int {
+	counts := make(map[string]int)
+	for _, obj := range m.listBundles() {
+		bundle := obj.(*smith_v1.Bundle)
+		for _, warning := range bundle.Status.Warnings {
+			counts[warning.Reason]++
+		}
+	}
+	return counts
+}
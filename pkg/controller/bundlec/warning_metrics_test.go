@@ -0,0 +1,52 @@
+package bundlec
+
+import (
+	"testing"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWarningMetrics(t *testing.T) {
+	t.Parallel()
+	bundles := []interface{}{
+		&smith_v1.Bundle{
+			Status: smith_v1.BundleStatus{
+				Warnings: []smith_v1.BundleWarning{
+					{Resource: "a", Reason: smith_v1.WarningReasonDeprecatedAPIVersion},
+					{Resource: "b", Reason: smith_v1.WarningReasonDeprecatedAPIVersion},
+				},
+			},
+		},
+		&smith_v1.Bundle{
+			Status: smith_v1.BundleStatus{
+				Warnings: []smith_v1.BundleWarning{
+					{Resource: "a", Reason: smith_v1.WarningReasonDeprecatedAPIVersion},
+					{Resource: "a", Reason: smith_v1.WarningReasonNoReadinessRule},
+				},
+			},
+		},
+		&smith_v1.Bundle{},
+	}
+	m := NewWarningMetrics(func() []interface{} {
+		return bundles
+	})
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, m.RegisterMetrics(registry))
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 1)
+	assert.Equal(t, "smith_bundle_warnings", families[0].GetName())
+	counts := make(map[string]float64)
+	for _, metric := range families[0].GetMetric() {
+		require.Len(t, metric.GetLabel(), 1)
+		counts[metric.GetLabel()[0].GetValue()] = metric.GetGauge().GetValue()
+	}
+	assert.Equal(t, map[string]float64{
+		smith_v1.WarningReasonDeprecatedAPIVersion: 3,
+		smith_v1.WarningReasonNoReadinessRule:      1,
+	}, counts)
+}
@@ -0,0 +1,60 @@
+package bundlec
+
+import (
+	"github.com/atlassian/ctrl"
+	ctrlLogz "github.com/atlassian/ctrl/logz"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"go.uber.org/zap"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+)
+
+// watchedObjectEventHandler rebuilds Bundles when objects they depend on but do not control change,
+// e.g. ConfigMaps and Secrets their resources read specs from or external objects they wait for.
+type watchedObjectEventHandler struct {
+	logger    *zap.Logger
+	workQueue ctrl.WorkQueueProducer
+	gk        schema.GroupKind
+	// getBundles returns Bundles that depend on the object with a name in a namespace.
+	getBundles func(gk schema.GroupKind, namespace, name string) ([]*smith_v1.Bundle, error)
+	// dependency describes how Bundles depend on the object, e.g. "it reads specs from".
+	dependency string
+}
+
+func (h *watchedObjectEventHandler) OnAdd(obj interface{}) {
+	h.rebuildBundles(obj, "added")
+}
+
+func (h *watchedObjectEventHandler) OnUpdate(oldObj, newObj interface{}) {
+	h.rebuildBundles(newObj, "updated")
+}
+
+func (h *watchedObjectEventHandler) OnDelete(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	h.rebuildBundles(obj, "deleted")
+}
+
+func (h *watchedObjectEventHandler) rebuildBundles(obj interface{}, addUpdateDelete string) {
+	m, ok := obj.(meta_v1.Object)
+	if !ok {
+		h.logger.Sugar().Errorf("Event with unrecognized object type: %T", obj)
+		return
+	}
+	bundles, err := h.getBundles(h.gk, m.GetNamespace(), m.GetName())
+	if err != nil {
+		h.logger.Error("Failed to get bundles by watched object", zap.Error(err))
+		return
+	}
+	for _, bundle := range bundles {
+		h.logger.
+			With(ctrlLogz.Namespace(bundle), ctrlLogz.Controller(bundle)).
+			Sugar().Infof("Rebuilding bundle because %s %q %s was %s", h.gk.Kind, m.GetName(), h.dependency, addUpdateDelete)
+		h.workQueue.Add(ctrl.QueueKey{
+			Namespace: bundle.Namespace,
+			Name:      bundle.Name,
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package bundlec
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"sort"
+
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/pkg/errors"
+	core_v1 "k8s.io/api/core/v1"
+)
+
+// ZoneAssignment assigns Bundles to the zones controllers run in. A controller only processes Bundles assigned to
+// its own zone.
+// Bundles are assigned to the zone they declare affinity to with the ZoneAffinityAnnotation, otherwise to the zone
+// their namespace declares affinity to. Bundles without affinity or with affinity to a zone without a controller are
+// spread across all zones using rendezvous hashing of their namespace and name, so that adding or removing a zone only
+// moves Bundles to or from that zone.
+type ZoneAssignment struct {
+	zone  string
+	zones []string
+}
+
+// NewZoneAssignment returns the assignment for a controller running in zone. Zones are all zones controllers run in.
+func NewZoneAssignment(zone string, zones []string) (*ZoneAssignment, error) {
+	if zone == "" {
+		return nil, errors.New("zone must not be empty")
+	}
+	set := make(map[string]struct{}, len(zones)+1)
+	set[zone] = struct{}{}
+	for _, z := range zones {
+		if z == "" {
+			return nil, errors.New("zones must not be empty")
+		}
+		set[z] = struct{}{}
+	}
+	all := make([]string, 0, len(set))
+	for z := range set {
+		all = append(all, z)
+	}
+	sort.Strings(all)
+	return &ZoneAssignment{
+		zone:  zone,
+		zones: all,
+	}, nil
+}
+
+// Zone returns the zone of the controller.
+func (z *ZoneAssignment) Zone() string {
+	return z.zone
+}
+
+// AssignedZone returns the zone the Bundle is assigned to. Namespace of the Bundle may be nil if it is not known.
+func (z *ZoneAssignment) AssignedZone(bundle *smith_v1.Bundle, namespace *core_v1.Namespace) string {
+	if zone, ok := z.affinity(bundle.Annotations); ok {
+		return zone
+	}
+	if namespace != nil {
+		if zone, ok := z.affinity(namespace.Annotations); ok {
+			return zone
+		}
+	}
+	var assigned string
+	var max uint64
+	for _, zone := range z.zones {
+		h := sha256.Sum256([]byte(zone + "/" + bundle.Namespace + "/" + bundle.Name))
+		if sum := binary.BigEndian.Uint64(h[:8]); assigned == "" || sum > max {
+			assigned = zone
+			max = sum
+		}
+	}
+	return assigned
+}
+
+// affinity returns the zone from the annotations if a controller runs in it.
+func (z *ZoneAssignment) affinity(annotations map[string]string) (string, bool) {
+	zone := annotations[smith.ZoneAffinityAnnotation]
+	if zone == "" {
+		return "", false
+	}
+	i := sort.SearchStrings(z.zones, zone)
+	return zone, i < len(z.zones) && z.zones[i] == zone
+}
@@ -0,0 +1,105 @@
+package bundlec
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func zoneBundle(name, affinity string) *smith_v1.Bundle {
+	bundle := &smith_v1.Bundle{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Namespace: "ns1",
+			Name:      name,
+		},
+	}
+	if affinity != "" {
+		bundle.Annotations = map[string]string{
+			smith.ZoneAffinityAnnotation: affinity,
+		}
+	}
+	return bundle
+}
+
+func TestNewZoneAssignment(t *testing.T) {
+	t.Parallel()
+	z, err := NewZoneAssignment("b", []string{"c", "a", "c"})
+	require.NoError(t, err)
+	assert.Equal(t, "b", z.Zone())
+	assert.Equal(t, []string{"a", "b", "c"}, z.zones)
+
+	_, err = NewZoneAssignment("", []string{"a"})
+	assert.Error(t, err)
+	_, err = NewZoneAssignment("a", []string{"a", ""})
+	assert.Error(t, err)
+}
+
+func TestAssignedZoneAffinity(t *testing.T) {
+	t.Parallel()
+	z, err := NewZoneAssignment("a", []string{"a", "b"})
+	require.NoError(t, err)
+	ns := &core_v1.Namespace{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name: "ns1",
+			Annotations: map[string]string{
+				smith.ZoneAffinityAnnotation: "b",
+			},
+		},
+	}
+
+	assert.Equal(t, "a", z.AssignedZone(zoneBundle("bundle1", "a"), nil))
+	assert.Equal(t, "b", z.AssignedZone(zoneBundle("bundle1", "b"), nil))
+	// Bundle affinity takes precedence over namespace affinity
+	assert.Equal(t, "a", z.AssignedZone(zoneBundle("bundle1", "a"), ns))
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, "b", z.AssignedZone(zoneBundle(fmt.Sprintf("bundle%d", i), ""), ns))
+	}
+}
+
+func TestAssignedZoneWithoutAffinity(t *testing.T) {
+	t.Parallel()
+	zones := []string{"a", "b", "c"}
+	assignments := make(map[string]*ZoneAssignment, len(zones))
+	for _, zone := range zones {
+		z, err := NewZoneAssignment(zone, zones)
+		require.NoError(t, err)
+		assignments[zone] = z
+	}
+	perZone := make(map[string]int, len(zones))
+	for i := 0; i < 300; i++ {
+		// Unknown zones are treated like no affinity
+		bundle := zoneBundle(fmt.Sprintf("bundle%d", i), "")
+		if i%2 == 0 {
+			bundle = zoneBundle(fmt.Sprintf("bundle%d", i), "unknown")
+		}
+		// All controllers must agree
+		zone := assignments["a"].AssignedZone(bundle, nil)
+		assert.Equal(t, zone, assignments["b"].AssignedZone(bundle, nil))
+		assert.Equal(t, zone, assignments["c"].AssignedZone(bundle, nil))
+		perZone[zone]++
+	}
+	for _, zone := range zones {
+		assert.True(t, perZone[zone] > 50, "zone %q has %d Bundles", zone, perZone[zone])
+	}
+}
+
+func TestAssignedZoneStableWhenZoneAdded(t *testing.T) {
+	t.Parallel()
+	before, err := NewZoneAssignment("a", []string{"a", "b"})
+	require.NoError(t, err)
+	after, err := NewZoneAssignment("a", []string{"a", "b", "c"})
+	require.NoError(t, err)
+	for i := 0; i < 100; i++ {
+		bundle := zoneBundle(fmt.Sprintf("bundle%d", i), "")
+		if zone := after.AssignedZone(bundle, nil); zone != "c" {
+			// Bundles only move to the new zone
+			assert.Equal(t, before.AssignedZone(bundle, nil), zone)
+		}
+	}
+}
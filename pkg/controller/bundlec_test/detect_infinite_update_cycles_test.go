@@ -81,6 +81,11 @@ func TestDetectInfiniteUpdateCycles(t *testing.T) {
 				assert.Equal(t, smith_v1.ResourceReasonTerminalError, resCond.Reason)
 				assert.Equal(t, "specification of the created/updated object does not match the desired spec", resCond.Message)
 			}
+			_, resStatus := updateBundle.Status.GetResourceStatus(mapNeedsAnUpdate)
+			require.NotNil(t, resStatus)
+			require.NotNil(t, resStatus.LastDiff)
+			assert.EqualValues(t, 1, resStatus.LastDiff.Updates)
+			assert.NotEmpty(t, resStatus.LastDiff.Fields)
 		},
 	}
 	tc.run(t)
@@ -3,6 +3,7 @@ package bundlec_test
 import (
 	"testing"
 
+	"github.com/atlassian/smith"
 	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
 	"github.com/atlassian/smith/pkg/controller/bundlec"
 	sc_v1b1 "github.com/kubernetes-incubator/service-catalog/pkg/apis/servicecatalog/v1beta1"
@@ -55,6 +56,9 @@ func TestResolveBindingSecretReferences(t *testing.T) {
 					Annotations: map[string]string{
 						"Secret": "bla",
 					},
+					Labels: map[string]string{
+						smith.BundleNameLabel: bundle1,
+					},
 					OwnerReferences: []meta_v1.OwnerReference{
 						{
 							APIVersion:         smith_v1.BundleResourceGroupVersion,
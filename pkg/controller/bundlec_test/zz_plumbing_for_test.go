@@ -11,6 +11,7 @@ import (
 
 	"github.com/ash2k/stager"
 	"github.com/atlassian/ctrl"
+	"github.com/atlassian/smith"
 	"github.com/atlassian/smith/cmd/smith/app"
 	"github.com/atlassian/smith/examples/sleeper"
 	sleeper_v1 "github.com/atlassian/smith/examples/sleeper/pkg/apis/sleeper/v1"
@@ -649,6 +650,9 @@ func serviceInstance(ready, inProgress, error bool) *sc_v1b1.ServiceInstance {
 			Name:      si1,
 			Namespace: testNamespace,
 			UID:       si1uid,
+			Labels: map[string]string{
+				smith.BundleNameLabel: bundle1,
+			},
 			OwnerReferences: []meta_v1.OwnerReference{
 				{
 					APIVersion:         smith_v1.BundleResourceGroupVersion,
@@ -699,6 +703,9 @@ func serviceBinding(ready, inProgress, error bool) *sc_v1b1.ServiceBinding {
 			Name:      sb1,
 			Namespace: testNamespace,
 			UID:       sb1uid,
+			Labels: map[string]string{
+				smith.BundleNameLabel: bundle1,
+			},
 			OwnerReferences: []meta_v1.OwnerReference{
 				{
 					APIVersion:         smith_v1.BundleResourceGroupVersion,
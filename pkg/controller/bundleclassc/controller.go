@@ -0,0 +1,112 @@
+package bundleclassc
+
+import (
+	"context"
+
+	"github.com/atlassian/ctrl"
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	smithClient_v1 "github.com/atlassian/smith/pkg/client/clientset_generated/clientset/typed/smith/v1"
+	smith_v1lst "github.com/atlassian/smith/pkg/client/listers_generated/smith/v1"
+	"go.uber.org/zap"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Controller instantiates a Bundle from each BundleClass in every namespace that matches the class' selector.
+type Controller struct {
+	Logger *zap.Logger
+
+	ReadyForWork func()
+	BundleClient smithClient_v1.BundlesGetter
+	WorkQueue    ctrl.WorkQueueProducer
+
+	BundleInf    cache.SharedIndexInformer
+	NamespaceInf cache.SharedIndexInformer
+
+	BundleClasses smith_v1lst.BundleClassLister
+	Bundles       smith_v1lst.BundleLister
+
+	// Namespace to restrict created Bundles to. meta_v1.NamespaceAll means all namespaces.
+	Namespace string
+}
+
+// Prepare prepares the controller to be run.
+func (c *Controller) Prepare() {
+	// Any change to a Namespace may change which BundleClasses select it
+	c.NamespaceInf.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			c.enqueueAllClasses()
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			c.enqueueAllClasses()
+		},
+		DeleteFunc: func(obj interface{}) {
+			c.enqueueAllClasses()
+		},
+	})
+	// Bundles created from a class are enqueued so that changes to them are reverted
+	c.BundleInf.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			c.enqueueClassOfBundle(obj)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			c.enqueueClassOfBundle(newObj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			c.enqueueClassOfBundle(obj)
+		},
+	})
+}
+
+// Run begins watching and syncing.
+// All informers must be synced before this method is invoked.
+func (c *Controller) Run(ctx context.Context) {
+	c.Logger.Info("Starting BundleClass controller")
+	defer c.Logger.Info("Shutting down BundleClass controller")
+
+	c.ReadyForWork()
+
+	<-ctx.Done()
+}
+
+func (c *Controller) enqueueAllClasses() {
+	classes, err := c.BundleClasses.List(labels.Everything())
+	if err != nil {
+		c.Logger.Error("Failed to list BundleClasses", zap.Error(err))
+		return
+	}
+	for _, class := range classes {
+		c.WorkQueue.Add(ctrl.QueueKey{
+			Namespace: meta_v1.NamespaceNone,
+			Name:      class.Name,
+		})
+	}
+}
+
+func (c *Controller) enqueueClassOfBundle(obj interface{}) {
+	var bundle *smith_v1.Bundle
+	switch o := obj.(type) {
+	case *smith_v1.Bundle:
+		bundle = o
+	case cache.DeletedFinalStateUnknown:
+		var ok bool
+		bundle, ok = o.Obj.(*smith_v1.Bundle)
+		if !ok {
+			c.Logger.Sugar().Errorf("Delete tombstone with unrecognized object type: %T", o.Obj)
+			return
+		}
+	default:
+		c.Logger.Sugar().Errorf("Event with unrecognized object type: %T", obj)
+		return
+	}
+	className, ok := bundle.Labels[smith.BundleClassLabel]
+	if !ok {
+		return
+	}
+	c.WorkQueue.Add(ctrl.QueueKey{
+		Namespace: meta_v1.NamespaceNone,
+		Name:      className,
+	})
+}
@@ -0,0 +1,193 @@
+package bundleclassc
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/atlassian/ctrl"
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	core_v1 "k8s.io/api/core/v1"
+	api_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func (c *Controller) Process(pctx *ctrl.ProcessContext) (retriableRet bool, errRet error) {
+	return c.ProcessBundleClass(pctx.Logger, pctx.Object.(*smith_v1.BundleClass))
+}
+
+// ProcessBundleClass is only visible for testing purposes. Should not be called directly.
+func (c *Controller) ProcessBundleClass(logger *zap.Logger, class *smith_v1.BundleClass) (retriableRet bool, errRet error) {
+	if class.DeletionTimestamp != nil {
+		// Bundles are owned by the class and will be deleted by the garbage collector
+		return false, nil
+	}
+	selector, err := meta_v1.LabelSelectorAsSelector(&class.Spec.NamespaceSelector)
+	if err != nil {
+		return false, errors.Wrap(err, "invalid namespace selector")
+	}
+
+	// Find matching namespaces
+	matching := make(map[string]*core_v1.Namespace)
+	for _, obj := range c.NamespaceInf.GetIndexer().List() {
+		namespace := obj.(*core_v1.Namespace)
+		if c.Namespace != meta_v1.NamespaceAll && namespace.Name != c.Namespace {
+			continue
+		}
+		if namespace.DeletionTimestamp != nil || !selector.Matches(labels.Set(namespace.Labels)) {
+			continue
+		}
+		matching[namespace.Name] = namespace
+	}
+
+	// Create/update Bundles in matching namespaces
+	var failedNamespaces []string
+	retriable := true
+	for _, namespace := range matching {
+		nsRetriable, nsErr := c.ensureBundle(logger, class, namespace)
+		if nsErr != nil {
+			logger.Error("Failed to sync Bundle", zap.String("namespace", namespace.Name), zap.Error(nsErr))
+			failedNamespaces = append(failedNamespaces, namespace.Name)
+			retriable = retriable && nsRetriable
+		}
+	}
+
+	// Delete Bundles from namespaces that no longer match
+	bundles, err := c.Bundles.List(labels.Everything())
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	for _, bundle := range bundles {
+		if _, ok := matching[bundle.Namespace]; ok || !isControlledBy(bundle, class.UID) || bundle.DeletionTimestamp != nil {
+			continue
+		}
+		logger.Sugar().Infof("Deleting Bundle from namespace %q that no longer matches the selector", bundle.Namespace)
+		policy := meta_v1.DeletePropagationForeground
+		err = c.BundleClient.Bundles(bundle.Namespace).Delete(bundle.Name, &meta_v1.DeleteOptions{
+			Preconditions: &meta_v1.Preconditions{
+				UID: &bundle.UID,
+			},
+			PropagationPolicy: &policy,
+		})
+		if err != nil && !api_errors.IsNotFound(err) {
+			logger.Error("Failed to delete Bundle", zap.String("namespace", bundle.Namespace), zap.Error(err))
+			failedNamespaces = append(failedNamespaces, bundle.Namespace)
+		}
+	}
+
+	if len(failedNamespaces) > 0 {
+		sort.Strings(failedNamespaces)
+		return retriable, errors.Errorf("failed to sync Bundle in namespace(s): %q", failedNamespaces)
+	}
+	return false, nil
+}
+
+func (c *Controller) ensureBundle(logger *zap.Logger, class *smith_v1.BundleClass, namespace *core_v1.Namespace) (retriableRet bool, errRet error) {
+	desired, err := bundleForNamespace(class, namespace)
+	if err != nil {
+		return false, err
+	}
+	bundlesClient := c.BundleClient.Bundles(namespace.Name)
+	existing, err := c.Bundles.Bundles(namespace.Name).Get(desired.Name)
+	if api_errors.IsNotFound(err) {
+		logger.Sugar().Infof("Creating Bundle in namespace %q", namespace.Name)
+		_, err = bundlesClient.Create(desired)
+		if err != nil {
+			if api_errors.IsAlreadyExists(err) {
+				// Will be re-processed once the informer catches up
+				return false, nil
+			}
+			return true, errors.Wrap(err, "failed to create Bundle")
+		}
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	if !isControlledBy(existing, class.UID) {
+		return false, errors.Errorf("Bundle %q already exists and is not controlled by the BundleClass", existing.Name)
+	}
+	if reflect.DeepEqual(existing.Spec, desired.Spec) &&
+		isSubset(desired.Labels, existing.Labels) &&
+		isSubset(desired.Annotations, existing.Annotations) {
+		return false, nil
+	}
+	updated := existing.DeepCopy()
+	updated.Spec = desired.Spec
+	updated.Labels = mergeMaps(existing.Labels, desired.Labels)
+	updated.Annotations = mergeMaps(existing.Annotations, desired.Annotations)
+	logger.Sugar().Infof("Updating Bundle in namespace %q", namespace.Name)
+	_, err = bundlesClient.Update(updated)
+	if err != nil {
+		if api_errors.IsConflict(err) {
+			// Will be re-processed once the informer catches up
+			return false, nil
+		}
+		return true, errors.Wrap(err, "failed to update Bundle")
+	}
+	return false, nil
+}
+
+// bundleForNamespace constructs a Bundle from the class' template for the namespace.
+func bundleForNamespace(class *smith_v1.BundleClass, namespace *core_v1.Namespace) (*smith_v1.Bundle, error) {
+	template := class.Spec.Template.DeepCopy()
+	if err := expandValues(&template.Spec, namespaceValues(namespace)); err != nil {
+		return nil, err
+	}
+	trueRef := true
+	bundleLabels := mergeMaps(template.Labels, map[string]string{
+		smith.BundleClassLabel: class.Name,
+	})
+	return &smith_v1.Bundle{
+		TypeMeta: meta_v1.TypeMeta{
+			Kind:       smith_v1.BundleResourceKind,
+			APIVersion: smith_v1.BundleResourceGroupVersion,
+		},
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        class.Name,
+			Namespace:   namespace.Name,
+			Labels:      bundleLabels,
+			Annotations: template.Annotations,
+			OwnerReferences: []meta_v1.OwnerReference{
+				{
+					APIVersion:         smith_v1.BundleResourceGroupVersion,
+					Kind:               smith_v1.BundleClassResourceKind,
+					Name:               class.Name,
+					UID:                class.UID,
+					Controller:         &trueRef,
+					BlockOwnerDeletion: &trueRef,
+				},
+			},
+		},
+		Spec: template.Spec,
+	}, nil
+}
+
+func isControlledBy(bundle *smith_v1.Bundle, uid types.UID) bool {
+	ref := meta_v1.GetControllerOf(bundle)
+	return ref != nil && ref.UID == uid
+}
+
+// isSubset returns true if all key/value pairs from subset are in set.
+func isSubset(subset, set map[string]string) bool {
+	for k, v := range subset {
+		if setV, ok := set[k]; !ok || setV != v {
+			return false
+		}
+	}
+	return true
+}
+
+func mergeMaps(maps ...map[string]string) map[string]string {
+	result := make(map[string]string)
+	for _, m := range maps {
+		for k, v := range m {
+			result[k] = v
+		}
+	}
+	return result
+}
@@ -0,0 +1,252 @@
+package bundleclassc
+
+import (
+	"testing"
+
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	smithFake "github.com/atlassian/smith/pkg/client/clientset_generated/clientset/fake"
+	smith_v1lst "github.com/atlassian/smith/pkg/client/listers_generated/smith/v1"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	core_v1 "k8s.io/api/core/v1"
+	api_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	kube_testing "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+)
+
+func testClass() *smith_v1.BundleClass {
+	return &smith_v1.BundleClass{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name: "class1",
+			UID:  "class-uid",
+		},
+		Spec: smith_v1.BundleClassSpec{
+			NamespaceSelector: meta_v1.LabelSelector{
+				MatchLabels: map[string]string{"team": "a"},
+			},
+			Template: smith_v1.BundleTemplate{
+				Labels: map[string]string{"app": "class1"},
+				Spec: smith_v1.BundleSpec{
+					Resources: []smith_v1.Resource{
+						{
+							Name: "cm",
+							Spec: smith_v1.ResourceSpec{
+								Object: &unstructured.Unstructured{
+									Object: map[string]interface{}{
+										"apiVersion": "v1",
+										"kind":       "ConfigMap",
+										"metadata": map[string]interface{}{
+											"name": "#{namespace}-config",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func testNamespace(name, team string) *core_v1.Namespace {
+	return &core_v1.Namespace{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{"team": team},
+		},
+	}
+}
+
+// testBundle returns the Bundle the class creates in the namespace.
+func testBundle(t *testing.T, namespace *core_v1.Namespace) *smith_v1.Bundle {
+	bundle, err := bundleForNamespace(testClass(), namespace)
+	require.NoError(t, err)
+	bundle.UID = types.UID("bundle-uid-" + namespace.Name)
+	return bundle
+}
+
+func TestProcessBundleClass(t *testing.T) {
+	t.Parallel()
+	teamA := testNamespace("team-a", "a")
+	teamB := testNamespace("team-b", "b")
+	now := meta_v1.Now()
+
+	testcases := map[string]struct {
+		namespaces []*core_v1.Namespace
+		bundles    []*smith_v1.Bundle
+		// restrictTo is the namespace the controller is restricted to
+		restrictTo string
+		deleted    bool
+		reactors   map[string]kube_testing.ReactionFunc
+		actions    []string
+		err        string
+		retriable  bool
+		check      func(*testing.T, []kube_testing.Action)
+	}{
+		"creates Bundle in matching namespace": {
+			namespaces: []*core_v1.Namespace{teamA, teamB},
+			actions:    []string{"create team-a"},
+			check: func(t *testing.T, actions []kube_testing.Action) {
+				bundle := actions[0].(kube_testing.CreateAction).GetObject().(*smith_v1.Bundle)
+				assert.Equal(t, "class1", bundle.Name)
+				assert.Equal(t, map[string]string{"app": "class1", smith.BundleClassLabel: "class1"}, bundle.Labels)
+				ref := meta_v1.GetControllerOf(bundle)
+				require.NotNil(t, ref)
+				assert.EqualValues(t, "class-uid", ref.UID)
+				obj := bundle.Spec.Resources[0].Spec.Object.(*unstructured.Unstructured)
+				assert.Equal(t, "team-a-config", obj.GetName())
+			},
+		},
+		"leaves up to date Bundle alone": {
+			namespaces: []*core_v1.Namespace{teamA},
+			bundles:    []*smith_v1.Bundle{testBundle(t, teamA)},
+		},
+		"updates changed Bundle": {
+			namespaces: []*core_v1.Namespace{teamA},
+			bundles: []*smith_v1.Bundle{func() *smith_v1.Bundle {
+				bundle := testBundle(t, teamA)
+				bundle.Labels["extra"] = "kept"
+				bundle.Spec.Resources = nil
+				return bundle
+			}()},
+			actions: []string{"update team-a"},
+			check: func(t *testing.T, actions []kube_testing.Action) {
+				bundle := actions[0].(kube_testing.UpdateAction).GetObject().(*smith_v1.Bundle)
+				assert.Equal(t, testBundle(t, teamA).Spec, bundle.Spec)
+				assert.Equal(t, "kept", bundle.Labels["extra"])
+				assert.Equal(t, "class1", bundle.Labels[smith.BundleClassLabel])
+			},
+		},
+		"refuses to take over Bundle not controlled by the class": {
+			namespaces: []*core_v1.Namespace{teamA},
+			bundles: []*smith_v1.Bundle{func() *smith_v1.Bundle {
+				bundle := testBundle(t, teamA)
+				bundle.OwnerReferences = nil
+				bundle.Spec.Resources = nil
+				return bundle
+			}()},
+			err: `failed to sync Bundle in namespace(s): ["team-a"]`,
+		},
+		"update conflict is re-processed later": {
+			namespaces: []*core_v1.Namespace{teamA},
+			bundles: []*smith_v1.Bundle{func() *smith_v1.Bundle {
+				bundle := testBundle(t, teamA)
+				bundle.Spec.Resources = nil
+				return bundle
+			}()},
+			reactors: map[string]kube_testing.ReactionFunc{
+				"update": func(action kube_testing.Action) (bool, runtime.Object, error) {
+					return true, nil, api_errors.NewConflict(schema.GroupResource{Group: smith.GroupName, Resource: smith_v1.BundleResourcePlural}, "class1", errors.New("changed"))
+				},
+			},
+			actions: []string{"update team-a"},
+		},
+		"create already exists is re-processed later": {
+			namespaces: []*core_v1.Namespace{teamA},
+			reactors: map[string]kube_testing.ReactionFunc{
+				"create": func(action kube_testing.Action) (bool, runtime.Object, error) {
+					return true, nil, api_errors.NewAlreadyExists(schema.GroupResource{Group: smith.GroupName, Resource: smith_v1.BundleResourcePlural}, "class1")
+				},
+			},
+			actions: []string{"create team-a"},
+		},
+		"create failure is retriable": {
+			namespaces: []*core_v1.Namespace{teamA},
+			reactors: map[string]kube_testing.ReactionFunc{
+				"create": func(action kube_testing.Action) (bool, runtime.Object, error) {
+					return true, nil, api_errors.NewInternalError(errors.New("etcd is down"))
+				},
+			},
+			actions:   []string{"create team-a"},
+			err:       `failed to sync Bundle in namespace(s): ["team-a"]`,
+			retriable: true,
+		},
+		"deletes Bundle from namespace that no longer matches": {
+			namespaces: []*core_v1.Namespace{teamB},
+			bundles:    []*smith_v1.Bundle{testBundle(t, teamB)},
+			actions:    []string{"delete team-b"},
+		},
+		"does not delete Bundle of another class": {
+			namespaces: []*core_v1.Namespace{teamB},
+			bundles: []*smith_v1.Bundle{func() *smith_v1.Bundle {
+				bundle := testBundle(t, teamB)
+				bundle.OwnerReferences[0].UID = "other-class-uid"
+				return bundle
+			}()},
+		},
+		"skips namespace being deleted": {
+			namespaces: []*core_v1.Namespace{func() *core_v1.Namespace {
+				ns := teamA.DeepCopy()
+				ns.DeletionTimestamp = &now
+				return ns
+			}()},
+		},
+		"skips namespaces the controller is restricted from": {
+			namespaces: []*core_v1.Namespace{teamA},
+			restrictTo: "team-c",
+		},
+		"does nothing for deleted class": {
+			namespaces: []*core_v1.Namespace{teamA},
+			bundles:    []*smith_v1.Bundle{testBundle(t, teamB)},
+			deleted:    true,
+		},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			objs := make([]runtime.Object, 0, len(tc.bundles))
+			bundleIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			for _, bundle := range tc.bundles {
+				objs = append(objs, bundle)
+				require.NoError(t, bundleIndexer.Add(bundle))
+			}
+			client := smithFake.NewSimpleClientset(objs...)
+			for verb, reactor := range tc.reactors {
+				client.PrependReactor(verb, smith_v1.BundleResourcePlural, reactor)
+			}
+			namespaceInf := cache.NewSharedIndexInformer(&cache.ListWatch{}, &core_v1.Namespace{}, 0, cache.Indexers{})
+			for _, ns := range tc.namespaces {
+				require.NoError(t, namespaceInf.GetIndexer().Add(ns))
+			}
+			c := &Controller{
+				Logger:       zaptest.NewLogger(t),
+				BundleClient: client.SmithV1(),
+				NamespaceInf: namespaceInf,
+				Bundles:      smith_v1lst.NewBundleLister(bundleIndexer),
+				Namespace:    tc.restrictTo,
+			}
+			class := testClass()
+			if tc.deleted {
+				class.DeletionTimestamp = &now
+			}
+
+			retriable, err := c.ProcessBundleClass(c.Logger, class)
+			if tc.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.err)
+			}
+			assert.Equal(t, tc.retriable, retriable)
+
+			actions := client.Actions()
+			var verbs []string
+			for _, action := range actions {
+				verbs = append(verbs, action.GetVerb()+" "+action.GetNamespace())
+			}
+			assert.Equal(t, tc.actions, verbs)
+			if tc.check != nil {
+				tc.check(t, actions)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package bundleclassc
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/pkg/errors"
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// NamespaceValue is the name of the value that is always set to the name of the namespace.
+	NamespaceValue = "namespace"
+)
+
+var placeholderRegex = regexp.MustCompile(`#\{([A-Za-z0-9_.-]+)\}`)
+
+// namespaceValues returns values for a namespace.
+// Values are taken from Namespace annotations with smith.BundleClassValueAnnotationPrefix prefix.
+func namespaceValues(namespace *core_v1.Namespace) map[string]string {
+	values := map[string]string{
+		NamespaceValue: namespace.Name,
+	}
+	for k, v := range namespace.Annotations {
+		if strings.HasPrefix(k, smith.BundleClassValueAnnotationPrefix) {
+			values[strings.TrimPrefix(k, smith.BundleClassValueAnnotationPrefix)] = v
+		}
+	}
+	return values
+}
+
+// expandValues replaces "#{name}" placeholders in all strings of objects and plugin specs with values.
+// Mutates the spec.
+func expandValues(spec *smith_v1.BundleSpec, values map[string]string) error {
+	for i := range spec.Resources {
+		res := &spec.Resources[i]
+		if res.Spec.Object != nil {
+			u, ok := res.Spec.Object.(*unstructured.Unstructured)
+			if !ok {
+				return errors.Errorf("resource %q: unexpected object type %T", res.Name, res.Spec.Object)
+			}
+			expanded, err := expandValuesInValue(u.Object, values)
+			if err != nil {
+				return errors.Wrapf(err, "resource %q", res.Name)
+			}
+			u.Object = expanded.(map[string]interface{})
+		}
+		if res.Spec.Plugin != nil && res.Spec.Plugin.Spec != nil {
+			expanded, err := expandValuesInValue(res.Spec.Plugin.Spec, values)
+			if err != nil {
+				return errors.Wrapf(err, "resource %q", res.Name)
+			}
+			res.Spec.Plugin.Spec = expanded.(map[string]interface{})
+		}
+	}
+	return nil
+}
+
+func expandValuesInValue(value interface{}, values map[string]string) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return expandValuesInString(v, values)
+	case map[string]interface{}:
+		for key, val := range v {
+			expanded, err := expandValuesInValue(val, values)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = expanded
+		}
+		return v, nil
+	case []interface{}:
+		for i, val := range v {
+			expanded, err := expandValuesInValue(val, values)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = expanded
+		}
+		return v, nil
+	default:
+		return value, nil
+	}
+}
+
+func expandValuesInString(s string, values map[string]string) (string, error) {
+	var missing string
+	result := placeholderRegex.ReplaceAllStringFunc(s, func(placeholder string) string {
+		name := placeholderRegex.FindStringSubmatch(placeholder)[1]
+		value, ok := values[name]
+		if !ok {
+			if missing == "" {
+				missing = name
+			}
+			return placeholder
+		}
+		return value
+	})
+	if missing != "" {
+		return "", errors.Errorf("value %q is not defined", missing)
+	}
+	return result, nil
+}
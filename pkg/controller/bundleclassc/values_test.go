@@ -0,0 +1,93 @@
+package bundleclassc
+
+import (
+	"testing"
+
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestExpandValues(t *testing.T) {
+	t.Parallel()
+	namespace := &core_v1.Namespace{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name: "team1",
+			Annotations: map[string]string{
+				smith.BundleClassValueAnnotationPrefix + "owner": "alice",
+				"unrelated": "x",
+			},
+		},
+	}
+	spec := smith_v1.BundleSpec{
+		Resources: []smith_v1.Resource{
+			{
+				Name: "cm",
+				Spec: smith_v1.ResourceSpec{
+					Object: &unstructured.Unstructured{
+						Object: map[string]interface{}{
+							"apiVersion": "v1",
+							"kind":       "ConfigMap",
+							"metadata": map[string]interface{}{
+								"name": "#{namespace}-config",
+							},
+							"data": map[string]interface{}{
+								"owner": "#{owner} (#{namespace})",
+								"list":  []interface{}{"#{owner}", int64(1)},
+							},
+						},
+					},
+				},
+			},
+			{
+				Name: "p",
+				Spec: smith_v1.ResourceSpec{
+					Plugin: &smith_v1.PluginSpec{
+						Name:       "plugin",
+						ObjectName: "p",
+						Spec: map[string]interface{}{
+							"owner": "#{owner}",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	require.NoError(t, expandValues(&spec, namespaceValues(namespace)))
+
+	obj := spec.Resources[0].Spec.Object.(*unstructured.Unstructured)
+	assert.Equal(t, "team1-config", obj.GetName())
+	assert.Equal(t, map[string]interface{}{
+		"owner": "alice (team1)",
+		"list":  []interface{}{"alice", int64(1)},
+	}, obj.Object["data"])
+	assert.Equal(t, map[string]interface{}{"owner": "alice"}, spec.Resources[1].Spec.Plugin.Spec)
+}
+
+func TestExpandValuesMissingValue(t *testing.T) {
+	t.Parallel()
+	spec := smith_v1.BundleSpec{
+		Resources: []smith_v1.Resource{
+			{
+				Name: "cm",
+				Spec: smith_v1.ResourceSpec{
+					Object: &unstructured.Unstructured{
+						Object: map[string]interface{}{
+							"data": map[string]interface{}{
+								"owner": "#{owner}",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	err := expandValues(&spec, map[string]string{NamespaceValue: "team1"})
+	require.EqualError(t, err, `resource "cm": value "owner" is not defined`)
+}
@@ -0,0 +1,74 @@
+package helmimport
+
+import (
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/pkg/errors"
+	api_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/dynamic"
+)
+
+// SmartClient returns clients for objects of a kind, e.g. smart.DynamicClient.
+type SmartClient interface {
+	ForGVK(gvk schema.GroupVersionKind, namespace string) (dynamic.ResourceInterface, error)
+}
+
+// Adopt makes the Bundle the controller of live objects so that Smith manages them instead of refusing to touch
+// objects that it does not control. The Bundle must have been created already because its UID is needed.
+// Objects that do not exist are skipped, Smith creates them. Objects controlled by something else are not adopted.
+// Returns names of adopted resources.
+func Adopt(client SmartClient, bundle *smith_v1.Bundle, objects []*unstructured.Unstructured) ([]smith_v1.ResourceName, error) {
+	if bundle.UID == "" {
+		return nil, errors.New("bundle must be created before adopting objects")
+	}
+	var adopted []smith_v1.ResourceName
+	var errs []error
+	for _, obj := range objects {
+		ok, err := adoptObject(client, bundle, obj)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to adopt %s %q", obj.GetKind(), obj.GetName()))
+			continue
+		}
+		if ok {
+			adopted = append(adopted, resourceName(obj))
+		}
+	}
+	return adopted, utilerrors.NewAggregate(errs)
+}
+
+func adoptObject(client SmartClient, bundle *smith_v1.Bundle, obj *unstructured.Unstructured) (bool /* adopted */, error) {
+	resClient, err := client.ForGVK(obj.GroupVersionKind(), bundle.Namespace)
+	if err != nil {
+		return false, err
+	}
+	actual, err := resClient.Get(obj.GetName(), meta_v1.GetOptions{})
+	if err != nil {
+		if api_errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, errors.WithStack(err)
+	}
+	if controller := meta_v1.GetControllerOf(actual); controller != nil {
+		if controller.UID == bundle.UID {
+			// Adopted already
+			return false, nil
+		}
+		return false, errors.Errorf("object is controlled by %s %q", controller.Kind, controller.Name)
+	}
+	trueVar := true
+	actual.SetOwnerReferences(append(actual.GetOwnerReferences(), meta_v1.OwnerReference{
+		APIVersion:         smith_v1.BundleResourceGroupVersion,
+		Kind:               smith_v1.BundleResourceKind,
+		Name:               bundle.Name,
+		UID:                bundle.UID,
+		Controller:         &trueVar,
+		BlockOwnerDeletion: &trueVar,
+	}))
+	if _, err = resClient.Update(actual); err != nil {
+		return false, errors.WithStack(err)
+	}
+	return true, nil
+}
@@ -0,0 +1,87 @@
+package helmimport
+
+import (
+	"testing"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	api_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// fakeResources serves objects by name. Methods that are not overridden panic.
+type fakeResources struct {
+	dynamic.ResourceInterface
+	objects map[string]*unstructured.Unstructured
+	updated []string
+}
+
+func (f *fakeResources) ForGVK(gvk schema.GroupVersionKind, namespace string) (dynamic.ResourceInterface, error) {
+	return f, nil
+}
+
+func (f *fakeResources) Get(name string, opts meta_v1.GetOptions) (*unstructured.Unstructured, error) {
+	obj, ok := f.objects[name]
+	if !ok {
+		return nil, api_errors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, name)
+	}
+	return obj.DeepCopy(), nil
+}
+
+func (f *fakeResources) Update(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	f.objects[obj.GetName()] = obj
+	f.updated = append(f.updated, obj.GetName())
+	return obj, nil
+}
+
+func configMap(name string, owners ...meta_v1.OwnerReference) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("ConfigMap")
+	obj.SetName(name)
+	obj.SetOwnerReferences(owners)
+	return obj
+}
+
+func TestAdopt(t *testing.T) {
+	t.Parallel()
+	trueVar := true
+	bundle := &smith_v1.Bundle{
+		ObjectMeta: meta_v1.ObjectMeta{Namespace: "ns", Name: "web", UID: "bundle-uid"},
+	}
+	otherOwner := meta_v1.OwnerReference{Kind: "Deployment", Name: "other", UID: "other-uid", Controller: &trueVar}
+	fake := &fakeResources{
+		objects: map[string]*unstructured.Unstructured{
+			"live":       configMap("live", meta_v1.OwnerReference{Kind: "Thing", Name: "non-controller", UID: "thing-uid"}),
+			"adopted":    configMap("adopted", meta_v1.OwnerReference{Kind: smith_v1.BundleResourceKind, Name: "web", UID: "bundle-uid", Controller: &trueVar}),
+			"controlled": configMap("controlled", otherOwner),
+		},
+	}
+
+	adopted, err := Adopt(fake, bundle, []*unstructured.Unstructured{
+		configMap("live"),
+		configMap("adopted"),
+		configMap("controlled"),
+		configMap("missing"),
+	})
+	require.EqualError(t, err, `failed to adopt ConfigMap "controlled": object is controlled by Deployment "other"`)
+	assert.Equal(t, []smith_v1.ResourceName{"configmap-live"}, adopted)
+	assert.Equal(t, []string{"live"}, fake.updated)
+
+	live := fake.objects["live"]
+	require.Len(t, live.GetOwnerReferences(), 2)
+	controller := meta_v1.GetControllerOf(live)
+	require.NotNil(t, controller)
+	assert.Equal(t, smith_v1.BundleResourceGroupVersion, controller.APIVersion)
+	assert.EqualValues(t, "bundle-uid", controller.UID)
+}
+
+func TestAdoptRequiresCreatedBundle(t *testing.T) {
+	t.Parallel()
+	_, err := Adopt(&fakeResources{}, &smith_v1.Bundle{}, nil)
+	require.Error(t, err)
+}
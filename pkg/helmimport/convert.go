@@ -0,0 +1,221 @@
+// Package helmimport converts rendered manifests of Helm releases into Bundles.
+// The manifest is what `helm get manifest <release>` prints: YAML documents separated by `---` lines. Values are not
+// needed because templates have already been rendered.
+package helmimport
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	ready_types "github.com/atlassian/smith/pkg/readychecker/types"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ExistsReadyWhen is the readiness expression set on objects of kinds that Smith has no readiness check for.
+// Such objects are considered ready as soon as they exist.
+const ExistsReadyWhen = `$.metadata.uid != ""`
+
+// clusterScopedKinds are kinds that cannot be part of a Bundle because Bundles only manage objects in their namespace.
+var clusterScopedKinds = map[schema.GroupKind]struct{}{
+	{Group: "", Kind: "Namespace"}:                                                  {},
+	{Group: "", Kind: "PersistentVolume"}:                                           {},
+	{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"}:                       {},
+	{Group: "rbac.authorization.k8s.io", Kind: "ClusterRoleBinding"}:                {},
+	{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}:               {},
+	{Group: "storage.k8s.io", Kind: "StorageClass"}:                                 {},
+	{Group: "scheduling.k8s.io", Kind: "PriorityClass"}:                             {},
+	{Group: "extensions", Kind: "PodSecurityPolicy"}:                                {},
+	{Group: "policy", Kind: "PodSecurityPolicy"}:                                    {},
+	{Group: "admissionregistration.k8s.io", Kind: "ValidatingWebhookConfiguration"}: {},
+	{Group: "admissionregistration.k8s.io", Kind: "MutatingWebhookConfiguration"}:   {},
+	{Group: "apiregistration.k8s.io", Kind: "APIService"}:                           {},
+	{Group: "servicecatalog.k8s.io", Kind: "ClusterServiceBroker"}:                  {},
+}
+
+// Options configure conversion of a manifest.
+type Options struct {
+	// BundleName is the name of the Bundle.
+	BundleName string
+	// Namespace is the namespace of the Bundle. Objects in other namespaces are skipped.
+	// Objects without a namespace are assumed to be in this namespace.
+	Namespace string
+	// ReadyCheckedKinds are kinds that Smith has a readiness check for in addition to the built-in ones,
+	// e.g. CRDs with readiness annotations. Objects of other kinds get the ExistsReadyWhen annotation.
+	ReadyCheckedKinds []schema.GroupKind
+}
+
+// Result is the outcome of a conversion.
+type Result struct {
+	Bundle *smith_v1.Bundle
+	// Objects are the objects of the Bundle in the order they appear in the manifest.
+	Objects []*unstructured.Unstructured
+	// Warnings describe objects that were skipped or may need attention.
+	Warnings []string
+}
+
+// Convert converts a rendered manifest into a Bundle.
+// Each object becomes a resource. Dependencies between objects are inferred from well-known fields, e.g. a Deployment
+// depends on ConfigMaps and Secrets its pods mount and an Ingress depends on Services it routes to.
+func Convert(manifest []byte, opts Options) (*Result, error) {
+	if opts.BundleName == "" {
+		return nil, errors.New("bundle name is required")
+	}
+	if opts.Namespace == "" {
+		return nil, errors.New("namespace is required")
+	}
+	docs, err := splitManifest(manifest)
+	if err != nil {
+		return nil, err
+	}
+	readyChecked := make(map[schema.GroupKind]struct{})
+	for gk := range ready_types.MainKnownTypes {
+		readyChecked[gk] = struct{}{}
+	}
+	for gk := range ready_types.ServiceCatalogKnownTypes {
+		readyChecked[gk] = struct{}{}
+	}
+	for _, gk := range opts.ReadyCheckedKinds {
+		readyChecked[gk] = struct{}{}
+	}
+
+	result := &Result{
+		Bundle: &smith_v1.Bundle{
+			TypeMeta: meta_v1.TypeMeta{
+				Kind:       smith_v1.BundleResourceKind,
+				APIVersion: smith_v1.BundleResourceGroupVersion,
+			},
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:      opts.BundleName,
+				Namespace: opts.Namespace,
+			},
+		},
+	}
+	resourceNames := make(map[objectKey]smith_v1.ResourceName)
+	for _, doc := range docs {
+		obj, err := decodeObject(doc)
+		if err != nil {
+			return nil, err
+		}
+		if obj == nil {
+			continue
+		}
+		gvk := obj.GroupVersionKind()
+		if _, ok := clusterScopedKinds[gvk.GroupKind()]; ok {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("skipped cluster-scoped %s %q", gvk.Kind, obj.GetName()))
+			continue
+		}
+		if ns := obj.GetNamespace(); ns != "" && ns != opts.Namespace {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("skipped %s %q in namespace %q", gvk.Kind, obj.GetName(), ns))
+			continue
+		}
+		obj.SetNamespace("")
+		key := objectKey{kind: gvk.Kind, name: obj.GetName()}
+		if _, ok := resourceNames[key]; ok {
+			return nil, errors.Errorf("duplicate %s %q", gvk.Kind, obj.GetName())
+		}
+		resourceNames[key] = resourceName(obj)
+		if _, ok := readyChecked[gvk.GroupKind()]; !ok {
+			if _, ok := obj.GetAnnotations()[smith.ReadyWhenAnnotation]; !ok {
+				annotations := obj.GetAnnotations()
+				if annotations == nil {
+					annotations = make(map[string]string, 1)
+				}
+				annotations[smith.ReadyWhenAnnotation] = ExistsReadyWhen
+				obj.SetAnnotations(annotations)
+			}
+		}
+		result.Objects = append(result.Objects, obj)
+	}
+
+	for _, obj := range result.Objects {
+		res := smith_v1.Resource{
+			Name: resourceNames[objectKey{kind: obj.GetKind(), name: obj.GetName()}],
+			Spec: smith_v1.ResourceSpec{
+				Object: obj,
+			},
+		}
+		deps := make(map[smith_v1.ResourceName]struct{})
+		for _, dep := range dependenciesOf(obj) {
+			depName, ok := resourceNames[dep]
+			if !ok {
+				// Not part of the release, e.g. a Secret created by hand
+				continue
+			}
+			if depName != res.Name {
+				deps[depName] = struct{}{}
+			}
+		}
+		for depName := range deps {
+			res.References = append(res.References, smith_v1.Reference{Resource: depName})
+		}
+		sort.Slice(res.References, func(i, j int) bool {
+			return res.References[i].Resource < res.References[j].Resource
+		})
+		result.Bundle.Spec.Resources = append(result.Bundle.Spec.Resources, res)
+	}
+	return result, nil
+}
+
+// objectKey identifies an object in a manifest. Group is not used because references between objects only name
+// the kind, e.g. a RoleBinding refers to a Role by kind and name.
+type objectKey struct {
+	kind string
+	name string
+}
+
+// resourceName returns the name of the resource for an object, e.g. "deployment-web".
+func resourceName(obj *unstructured.Unstructured) smith_v1.ResourceName {
+	return smith_v1.ResourceName(strings.ToLower(obj.GetKind()) + "-" + obj.GetName())
+}
+
+// splitManifest splits a multi-document YAML manifest into documents.
+func splitManifest(manifest []byte) ([][]byte, error) {
+	var docs [][]byte
+	var current bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(manifest))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(manifest)+1)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if strings.TrimRight(string(line), " \t") == "---" {
+			docs = append(docs, append([]byte(nil), current.Bytes()...))
+			current.Reset()
+			continue
+		}
+		current.Write(line)
+		current.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read manifest")
+	}
+	docs = append(docs, current.Bytes())
+	return docs, nil
+}
+
+// decodeObject decodes a YAML document into an object. Returns nil if the document is empty.
+func decodeObject(doc []byte) (*unstructured.Unstructured, error) {
+	data, err := yaml.YAMLToJSON(doc)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse YAML document")
+	}
+	if len(data) == 0 || string(data) == "null" {
+		// Only comments or whitespace, e.g. a template that rendered to nothing
+		return nil, nil
+	}
+	obj := &unstructured.Unstructured{}
+	if err = obj.UnmarshalJSON(data); err != nil {
+		return nil, errors.Wrap(err, "failed to decode object")
+	}
+	if obj.GetName() == "" {
+		return nil, errors.Errorf("%s object without a name", obj.GetKind())
+	}
+	return obj, nil
+}
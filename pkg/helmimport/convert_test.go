@@ -0,0 +1,187 @@
+package helmimport
+
+import (
+	"testing"
+
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const manifest = `
+---
+# Source: web/templates/config.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web-config
+  namespace: team
+data:
+  key: value
+---
+# Source: web/templates/empty.yaml
+---
+# Source: web/templates/sa.yaml
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: web
+---
+# Source: web/templates/role.yaml
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: web
+rules: []
+---
+# Source: web/templates/rolebinding.yaml
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: web
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: web
+subjects:
+- kind: ServiceAccount
+  name: web
+---
+# Source: web/templates/clusterrole.yaml
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: web
+---
+# Source: web/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      serviceAccountName: web
+      volumes:
+      - name: config
+        configMap:
+          name: web-config
+      - name: tls
+        secret:
+          secretName: created-by-hand
+      containers:
+      - name: web
+        image: nginx
+---
+# Source: web/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+---
+# Source: web/templates/ingress.yaml
+apiVersion: extensions/v1beta1
+kind: Ingress
+metadata:
+  name: web
+spec:
+  rules:
+  - http:
+      paths:
+      - backend:
+          serviceName: web
+          servicePort: 80
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: elsewhere
+  namespace: other
+`
+
+func TestConvert(t *testing.T) {
+	t.Parallel()
+	result, err := Convert([]byte(manifest), Options{
+		BundleName: "web",
+		Namespace:  "team",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`skipped cluster-scoped ClusterRole "web"`,
+		`skipped ConfigMap "elsewhere" in namespace "other"`,
+	}, result.Warnings)
+
+	bundle := result.Bundle
+	assert.Equal(t, "web", bundle.Name)
+	assert.Equal(t, "team", bundle.Namespace)
+
+	resources := make(map[smith_v1.ResourceName]smith_v1.Resource, len(bundle.Spec.Resources))
+	var names []smith_v1.ResourceName
+	for _, res := range bundle.Spec.Resources {
+		resources[res.Name] = res
+		names = append(names, res.Name)
+	}
+	assert.Equal(t, []smith_v1.ResourceName{
+		"configmap-web-config",
+		"serviceaccount-web",
+		"role-web",
+		"rolebinding-web",
+		"deployment-web",
+		"service-web",
+		"ingress-web",
+	}, names)
+
+	assert.Equal(t, []smith_v1.Reference{
+		{Resource: "configmap-web-config"},
+		{Resource: "serviceaccount-web"},
+	}, resources["deployment-web"].References)
+	assert.Equal(t, []smith_v1.Reference{
+		{Resource: "role-web"},
+		{Resource: "serviceaccount-web"},
+	}, resources["rolebinding-web"].References)
+	assert.Equal(t, []smith_v1.Reference{
+		{Resource: "service-web"},
+	}, resources["ingress-web"].References)
+	assert.Empty(t, resources["configmap-web-config"].References)
+
+	cm := resources["configmap-web-config"].Spec.Object.(*unstructured.Unstructured)
+	assert.Empty(t, cm.GetNamespace())
+	assert.NotContains(t, cm.GetAnnotations(), smith.ReadyWhenAnnotation)
+
+	// No built-in readiness check for Roles
+	role := resources["role-web"].Spec.Object.(*unstructured.Unstructured)
+	assert.Equal(t, ExistsReadyWhen, role.GetAnnotations()[smith.ReadyWhenAnnotation])
+}
+
+func TestConvertDuplicate(t *testing.T) {
+	t.Parallel()
+	_, err := Convert([]byte(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+`), Options{
+		BundleName: "b",
+		Namespace:  "ns",
+	})
+	require.EqualError(t, err, `duplicate ConfigMap "a"`)
+}
+
+func TestConvertRequiresName(t *testing.T) {
+	t.Parallel()
+	_, err := Convert([]byte(`
+apiVersion: v1
+kind: ConfigMap
+`), Options{
+		BundleName: "b",
+		Namespace:  "ns",
+	})
+	require.EqualError(t, err, `ConfigMap object without a name`)
+}
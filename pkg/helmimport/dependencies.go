@@ -0,0 +1,156 @@
+package helmimport
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// podSpecPaths are paths to pod specs in objects of kinds that run pods.
+var podSpecPaths = map[string][]string{
+	"Pod":         {"spec"},
+	"ReplicaSet":  {"spec", "template", "spec"},
+	"Deployment":  {"spec", "template", "spec"},
+	"StatefulSet": {"spec", "template", "spec"},
+	"DaemonSet":   {"spec", "template", "spec"},
+	"Job":         {"spec", "template", "spec"},
+	"CronJob":     {"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// dependenciesOf returns objects that the object refers to by name in well-known fields.
+func dependenciesOf(obj *unstructured.Unstructured) []objectKey {
+	var deps []objectKey
+	if path, ok := podSpecPaths[obj.GetKind()]; ok {
+		if podSpec, ok := nestedMap(obj.Object, path...); ok {
+			deps = append(deps, podSpecDependencies(podSpec)...)
+		}
+	}
+	switch obj.GetKind() {
+	case "Ingress":
+		deps = append(deps, ingressDependencies(obj.Object)...)
+	case "RoleBinding":
+		deps = append(deps, roleBindingDependencies(obj.Object)...)
+	case "HorizontalPodAutoscaler":
+		if ref, ok := nestedMap(obj.Object, "spec", "scaleTargetRef"); ok {
+			deps = appendKey(deps, stringField(ref, "kind"), stringField(ref, "name"))
+		}
+	case "ServiceBinding":
+		if ref, ok := nestedMap(obj.Object, "spec", "instanceRef"); ok {
+			deps = appendKey(deps, "ServiceInstance", stringField(ref, "name"))
+		}
+	}
+	return deps
+}
+
+func podSpecDependencies(podSpec map[string]interface{}) []objectKey {
+	var deps []objectKey
+	deps = appendKey(deps, "ServiceAccount", stringField(podSpec, "serviceAccountName"))
+	for _, secret := range mapSlice(podSpec["imagePullSecrets"]) {
+		deps = appendKey(deps, "Secret", stringField(secret, "name"))
+	}
+	for _, volume := range mapSlice(podSpec["volumes"]) {
+		if cm, ok := volume["configMap"].(map[string]interface{}); ok {
+			deps = appendKey(deps, "ConfigMap", stringField(cm, "name"))
+		}
+		if secret, ok := volume["secret"].(map[string]interface{}); ok {
+			deps = appendKey(deps, "Secret", stringField(secret, "secretName"))
+		}
+		if pvc, ok := volume["persistentVolumeClaim"].(map[string]interface{}); ok {
+			deps = appendKey(deps, "PersistentVolumeClaim", stringField(pvc, "claimName"))
+		}
+	}
+	containers := append(mapSlice(podSpec["initContainers"]), mapSlice(podSpec["containers"])...)
+	for _, container := range containers {
+		for _, envFrom := range mapSlice(container["envFrom"]) {
+			if ref, ok := envFrom["configMapRef"].(map[string]interface{}); ok {
+				deps = appendKey(deps, "ConfigMap", stringField(ref, "name"))
+			}
+			if ref, ok := envFrom["secretRef"].(map[string]interface{}); ok {
+				deps = appendKey(deps, "Secret", stringField(ref, "name"))
+			}
+		}
+		for _, env := range mapSlice(container["env"]) {
+			valueFrom, ok := env["valueFrom"].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if ref, ok := valueFrom["configMapKeyRef"].(map[string]interface{}); ok {
+				deps = appendKey(deps, "ConfigMap", stringField(ref, "name"))
+			}
+			if ref, ok := valueFrom["secretKeyRef"].(map[string]interface{}); ok {
+				deps = appendKey(deps, "Secret", stringField(ref, "name"))
+			}
+		}
+	}
+	return deps
+}
+
+func ingressDependencies(obj map[string]interface{}) []objectKey {
+	var deps []objectKey
+	if backend, ok := nestedMap(obj, "spec", "backend"); ok {
+		deps = appendKey(deps, "Service", stringField(backend, "serviceName"))
+	}
+	spec, _ := nestedMap(obj, "spec")
+	for _, tls := range mapSlice(spec["tls"]) {
+		deps = appendKey(deps, "Secret", stringField(tls, "secretName"))
+	}
+	for _, rule := range mapSlice(spec["rules"]) {
+		http, ok := rule["http"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, path := range mapSlice(http["paths"]) {
+			if backend, ok := path["backend"].(map[string]interface{}); ok {
+				deps = appendKey(deps, "Service", stringField(backend, "serviceName"))
+			}
+		}
+	}
+	return deps
+}
+
+func roleBindingDependencies(obj map[string]interface{}) []objectKey {
+	var deps []objectKey
+	if roleRef, ok := nestedMap(obj, "roleRef"); ok && stringField(roleRef, "kind") == "Role" {
+		deps = appendKey(deps, "Role", stringField(roleRef, "name"))
+	}
+	for _, subject := range mapSlice(obj["subjects"]) {
+		if stringField(subject, "kind") == "ServiceAccount" {
+			// Subjects in other namespaces are not part of the release, they are skipped because they are not found
+			deps = appendKey(deps, "ServiceAccount", stringField(subject, "name"))
+		}
+	}
+	return deps
+}
+
+func appendKey(deps []objectKey, kind, name string) []objectKey {
+	if kind == "" || name == "" {
+		return deps
+	}
+	return append(deps, objectKey{kind: kind, name: name})
+}
+
+func nestedMap(obj map[string]interface{}, fields ...string) (map[string]interface{}, bool) {
+	m := obj
+	for _, field := range fields {
+		var ok bool
+		m, ok = m[field].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+	}
+	return m, true
+}
+
+func mapSlice(value interface{}) []map[string]interface{} {
+	list, _ := value.([]interface{})
+	result := make([]map[string]interface{}, 0, len(list))
+	for _, item := range list {
+		if m, ok := item.(map[string]interface{}); ok {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+func stringField(obj map[string]interface{}, field string) string {
+	s, _ := obj[field].(string)
+	return s
+}
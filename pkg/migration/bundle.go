@@ -0,0 +1,86 @@
+package migration
+
+import (
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Migration describes a resource whose object has been converted to another version.
+type Migration struct {
+	Resource smith_v1.ResourceName
+	From     schema.GroupVersionKind
+	To       schema.GroupVersionKind
+}
+
+// MigrateResources converts objects of resources to the latest versions reachable via the rules and rewrites paths
+// of references to fields of converted objects. The passed resources are not mutated, the returned slice shares
+// resources that are not affected with it. Objects produced by plugins are not converted.
+func (r *Rules) MigrateResources(resources []smith_v1.Resource) ([]smith_v1.Resource, []Migration, error) {
+	var migrations []Migration
+	migrated := make(map[smith_v1.ResourceName]schema.GroupVersionKind)
+	result := make([]smith_v1.Resource, len(resources))
+	copy(result, resources)
+	for i := range result {
+		res := &result[i]
+		if res.Spec.Object == nil {
+			continue
+		}
+		from := res.Spec.Object.GetObjectKind().GroupVersionKind()
+		obj, converted, err := r.Convert(res.Spec.Object)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to migrate resource %q", res.Name)
+		}
+		if !converted {
+			continue
+		}
+		res.Spec.Object = obj
+		migrated[res.Name] = from
+		migrations = append(migrations, Migration{
+			Resource: res.Name,
+			From:     from,
+			To:       obj.GetObjectKind().GroupVersionKind(),
+		})
+	}
+	if len(migrations) == 0 {
+		return resources, nil, nil
+	}
+	for i := range result {
+		res := &result[i]
+		var refs []smith_v1.Reference
+		for j, ref := range res.References {
+			from, ok := migrated[ref.Resource]
+			if !ok || ref.Path == "" {
+				continue
+			}
+			path := r.ConvertPath(from, ref.Path)
+			if path == ref.Path {
+				continue
+			}
+			if refs == nil {
+				refs = make([]smith_v1.Reference, len(res.References))
+				copy(refs, res.References)
+			}
+			refs[j].Path = path
+		}
+		if refs != nil {
+			res.References = refs
+		}
+	}
+	return result, migrations, nil
+}
+
+// MigrateBundle returns a copy of the Bundle with resources migrated using MigrateResources.
+// The Bundle itself is returned if no resources are affected.
+func (r *Rules) MigrateBundle(bundle *smith_v1.Bundle) (*smith_v1.Bundle, []Migration, error) {
+	migrated := bundle.DeepCopy()
+	resources, migrations, err := r.MigrateResources(migrated.Spec.Resources)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(migrations) == 0 {
+		return bundle, nil, nil
+	}
+	migrated.Spec.Resources = resources
+	return migrated, migrations, nil
+}
@@ -0,0 +1,90 @@
+package migration
+
+import (
+	"testing"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestMigrateBundle(t *testing.T) {
+	t.Parallel()
+	rules, err := Load([]byte(testRules))
+	require.NoError(t, err)
+
+	bundle := &smith_v1.Bundle{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name: "b1",
+		},
+		Spec: smith_v1.BundleSpec{
+			Resources: []smith_v1.Resource{
+				{
+					Name: "db",
+					Spec: smith_v1.ResourceSpec{
+						Object: database("v1beta1", map[string]interface{}{
+							"planName": "small",
+						}),
+					},
+				},
+				{
+					Name: "app",
+					References: []smith_v1.Reference{
+						{
+							Name:     "host",
+							Resource: "db",
+							Path:     "status.hosts[0]",
+						},
+					},
+					Spec: smith_v1.ResourceSpec{
+						Object: database("v1", map[string]interface{}{}),
+					},
+				},
+			},
+		},
+	}
+	migrated, migrations, err := rules.MigrateBundle(bundle)
+	require.NoError(t, err)
+	assert.Equal(t, []Migration{
+		{
+			Resource: "db",
+			From:     schema.GroupVersionKind{Group: "db.example.com", Version: "v1beta1", Kind: "Database"},
+			To:       schema.GroupVersionKind{Group: "db.example.com", Version: "v1", Kind: "Database"},
+		},
+	}, migrations)
+	assert.Equal(t, database("v1", map[string]interface{}{
+		"tier": map[string]interface{}{
+			"name": "small",
+		},
+	}), migrated.Spec.Resources[0].Spec.Object)
+	assert.Equal(t, "status.endpoints[0]", migrated.Spec.Resources[1].References[0].Path)
+
+	// Original Bundle must not be mutated
+	assert.Equal(t, "db.example.com/v1beta1", bundle.Spec.Resources[0].Spec.Object.GetObjectKind().GroupVersionKind().GroupVersion().String())
+	assert.Equal(t, "status.hosts[0]", bundle.Spec.Resources[1].References[0].Path)
+}
+
+func TestMigrateBundleUnaffected(t *testing.T) {
+	t.Parallel()
+	rules, err := Load([]byte(testRules))
+	require.NoError(t, err)
+
+	bundle := &smith_v1.Bundle{
+		Spec: smith_v1.BundleSpec{
+			Resources: []smith_v1.Resource{
+				{
+					Name: "db",
+					Spec: smith_v1.ResourceSpec{
+						Object: database("v1", map[string]interface{}{}),
+					},
+				},
+			},
+		},
+	}
+	migrated, migrations, err := rules.MigrateBundle(bundle)
+	require.NoError(t, err)
+	assert.Empty(t, migrations)
+	assert.True(t, bundle == migrated)
+}
@@ -0,0 +1,236 @@
+// Package migration converts objects between API versions of a kind according to declared rules.
+// It is used by the Bundle controller to rewrite objects in place after the storage version of their kind changes
+// and by smithctl to rewrite Bundle specs so that they declare the new version.
+package migration
+
+import (
+	"strings"
+
+	"github.com/atlassian/smith/pkg/util"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// maxChainLength limits the number of rules applied to a single object.
+const maxChainLength = 10
+
+// Rule declares how objects of a kind are converted from one API version to another.
+type Rule struct {
+	Group string `json:"group,omitempty"`
+	Kind  string `json:"kind"`
+	// From is the version objects are converted from.
+	From string `json:"from"`
+	// To is the version objects are converted to.
+	To string `json:"to"`
+	// Renames move fields to new paths.
+	Renames []Rename `json:"renames,omitempty"`
+	// Removals are dot separated paths of fields that do not exist in the new version, e.g. "spec.legacyMode".
+	Removals []string `json:"removals,omitempty"`
+}
+
+// Rename moves a field. Paths are dot separated, e.g. "spec.plan" and "spec.planName".
+type Rename struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+func (r *Rule) fromGVK() schema.GroupVersionKind {
+	return schema.GroupVersionKind{Group: r.Group, Version: r.From, Kind: r.Kind}
+}
+
+// Rules is a validated set of rules. Rules can be chained, e.g. v1alpha1 to v1beta1 and v1beta1 to v1.
+type Rules struct {
+	rules map[schema.GroupVersionKind]*Rule
+}
+
+// NewRules validates rules and returns a set of them.
+func NewRules(rules []Rule) (*Rules, error) {
+	result := &Rules{
+		rules: make(map[schema.GroupVersionKind]*Rule, len(rules)),
+	}
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Kind == "" || rule.From == "" || rule.To == "" {
+			return nil, errors.Errorf("rule %d: kind, from and to must be specified", i)
+		}
+		if rule.From == rule.To {
+			return nil, errors.Errorf("rule %d: from and to versions must be different", i)
+		}
+		gvk := rule.fromGVK()
+		if _, ok := result.rules[gvk]; ok {
+			return nil, errors.Errorf("rule %d: more than one rule converts from %s", i, gvk)
+		}
+		for _, rename := range rule.Renames {
+			if err := validatePath(rename.From); err != nil {
+				return nil, errors.Wrapf(err, "rule %d: invalid rename", i)
+			}
+			if err := validatePath(rename.To); err != nil {
+				return nil, errors.Wrapf(err, "rule %d: invalid rename", i)
+			}
+		}
+		for _, removal := range rule.Removals {
+			if err := validatePath(removal); err != nil {
+				return nil, errors.Wrapf(err, "rule %d: invalid removal", i)
+			}
+		}
+		result.rules[gvk] = rule
+	}
+	for gvk := range result.rules {
+		if _, err := result.chain(gvk); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// Load reads a JSON or YAML list of rules.
+func Load(data []byte) (*Rules, error) {
+	var rules []Rule
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal migration rules")
+	}
+	return NewRules(rules)
+}
+
+// chain returns rules that convert objects of the GVK to the latest version, in the order they are applied.
+func (r *Rules) chain(gvk schema.GroupVersionKind) ([]*Rule, error) {
+	var chain []*Rule
+	for {
+		rule, ok := r.rules[gvk]
+		if !ok {
+			return chain, nil
+		}
+		if len(chain) == maxChainLength {
+			return nil, errors.Errorf("rules for %s form a cycle or a chain longer than %d", gvk.GroupKind(), maxChainLength)
+		}
+		chain = append(chain, rule)
+		gvk.Version = rule.To
+	}
+}
+
+// Target returns the GVK objects of the GVK are converted to. The GVK itself is returned if no rule applies.
+func (r *Rules) Target(gvk schema.GroupVersionKind) schema.GroupVersionKind {
+	chain, _ := r.chain(gvk) // Validated in NewRules
+	if len(chain) > 0 {
+		gvk.Version = chain[len(chain)-1].To
+	}
+	return gvk
+}
+
+// Convert returns a copy of the object converted to the latest version reachable via the rules.
+// The object itself and false are returned if no rule applies.
+func (r *Rules) Convert(obj runtime.Object) (runtime.Object, bool, error) {
+	chain, _ := r.chain(obj.GetObjectKind().GroupVersionKind())
+	if len(chain) == 0 {
+		return obj, false, nil
+	}
+	converted, err := util.RuntimeToUnstructured(obj)
+	if err != nil {
+		return nil, false, err
+	}
+	u := converted.Object
+	for _, rule := range chain {
+		for _, rename := range rule.Renames {
+			from := strings.Split(rename.From, ".")
+			value, ok := getField(u, from)
+			if !ok {
+				continue
+			}
+			removeField(u, from)
+			if err := setField(u, strings.Split(rename.To, "."), value); err != nil {
+				return nil, false, errors.Wrapf(err, "failed to move field %q to %q", rename.From, rename.To)
+			}
+		}
+		for _, removal := range rule.Removals {
+			removeField(u, strings.Split(removal, "."))
+		}
+	}
+	gvk := converted.GroupVersionKind()
+	gvk.Version = chain[len(chain)-1].To
+	converted.SetGroupVersionKind(gvk)
+	return converted, true, nil
+}
+
+// ConvertPath rewrites a path to a field of an object of the GVK so that it points at the same field once the object
+// is converted. The path may contain JSONPath array subscripts, e.g. "status.hosts[0].name".
+func (r *Rules) ConvertPath(gvk schema.GroupVersionKind, path string) string {
+	chain, _ := r.chain(gvk)
+	for _, rule := range chain {
+		for _, rename := range rule.Renames {
+			if !strings.HasPrefix(path, rename.From) {
+				continue
+			}
+			if rest := path[len(rename.From):]; rest == "" || rest[0] == '.' || rest[0] == '[' {
+				path = rename.To + rest
+				break
+			}
+		}
+	}
+	return path
+}
+
+func validatePath(path string) error {
+	if path == "" {
+		return errors.New("path must not be empty")
+	}
+	fields := strings.Split(path, ".")
+	for _, field := range fields {
+		if field == "" {
+			return errors.Errorf("path %q has an empty field", path)
+		}
+	}
+	switch fields[0] {
+	case "apiVersion", "kind", "metadata":
+		return errors.Errorf("path %q must not point at %s", path, fields[0])
+	}
+	return nil
+}
+
+func getField(obj map[string]interface{}, fields []string) (interface{}, bool) {
+	var current interface{} = obj
+	for _, field := range fields {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current, ok = m[field]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func setField(obj map[string]interface{}, fields []string, value interface{}) error {
+	m := obj
+	for i, field := range fields[:len(fields)-1] {
+		next, ok := m[field]
+		if !ok {
+			child := make(map[string]interface{})
+			m[field] = child
+			m = child
+			continue
+		}
+		child, ok := next.(map[string]interface{})
+		if !ok {
+			return errors.Errorf("field %q is not an object", strings.Join(fields[:i+1], "."))
+		}
+		m = child
+	}
+	m[fields[len(fields)-1]] = value
+	return nil
+}
+
+func removeField(obj map[string]interface{}, fields []string) {
+	m := obj
+	for _, field := range fields[:len(fields)-1] {
+		child, ok := m[field].(map[string]interface{})
+		if !ok {
+			return
+		}
+		m = child
+	}
+	delete(m, fields[len(fields)-1])
+}
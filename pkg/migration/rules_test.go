@@ -0,0 +1,156 @@
+package migration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const testRules = `
+- group: db.example.com
+  kind: Database
+  from: v1alpha1
+  to: v1beta1
+  renames:
+  - from: spec.plan
+    to: spec.planName
+  removals:
+  - spec.legacyMode
+- group: db.example.com
+  kind: Database
+  from: v1beta1
+  to: v1
+  renames:
+  - from: spec.planName
+    to: spec.tier.name
+  - from: status.hosts
+    to: status.endpoints
+`
+
+func database(version string, spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "db.example.com/" + version,
+			"kind":       "Database",
+			"metadata": map[string]interface{}{
+				"name": "db1",
+			},
+			"spec": spec,
+		},
+	}
+}
+
+func TestConvertAppliesChain(t *testing.T) {
+	t.Parallel()
+	rules, err := Load([]byte(testRules))
+	require.NoError(t, err)
+
+	obj := database("v1alpha1", map[string]interface{}{
+		"plan":       "small",
+		"legacyMode": true,
+		"size":       int64(10),
+	})
+	converted, ok, err := rules.Convert(obj)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, database("v1", map[string]interface{}{
+		"tier": map[string]interface{}{
+			"name": "small",
+		},
+		"size": int64(10),
+	}), converted)
+	assert.Equal(t, "db.example.com/v1alpha1", obj.GetAPIVersion(), "object must not be mutated")
+	assert.Equal(t, "small", obj.Object["spec"].(map[string]interface{})["plan"])
+}
+
+func TestConvertNoRule(t *testing.T) {
+	t.Parallel()
+	rules, err := Load([]byte(testRules))
+	require.NoError(t, err)
+
+	obj := database("v1", map[string]interface{}{})
+	converted, ok, err := rules.Convert(obj)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.True(t, obj == converted)
+}
+
+func TestConvertPath(t *testing.T) {
+	t.Parallel()
+	rules, err := Load([]byte(testRules))
+	require.NoError(t, err)
+	gvk := schema.GroupVersionKind{Group: "db.example.com", Version: "v1alpha1", Kind: "Database"}
+
+	assert.Equal(t, "spec.tier.name", rules.ConvertPath(gvk, "spec.plan"))
+	assert.Equal(t, "status.endpoints[0].address", rules.ConvertPath(gvk, "status.hosts[0].address"))
+	assert.Equal(t, "spec.planner", rules.ConvertPath(gvk, "spec.planner"))
+	assert.Equal(t, "spec.size", rules.ConvertPath(gvk, "spec.size"))
+}
+
+func TestTarget(t *testing.T) {
+	t.Parallel()
+	rules, err := Load([]byte(testRules))
+	require.NoError(t, err)
+
+	gvk := schema.GroupVersionKind{Group: "db.example.com", Version: "v1beta1", Kind: "Database"}
+	assert.Equal(t, "v1", rules.Target(gvk).Version)
+	gvk.Kind = "Cache"
+	assert.Equal(t, gvk, rules.Target(gvk))
+}
+
+func TestNewRulesValidation(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		name  string
+		rules []Rule
+		err   string
+	}{
+		{
+			name:  "missing kind",
+			rules: []Rule{{From: "v1", To: "v2"}},
+			err:   "rule 0: kind, from and to must be specified",
+		},
+		{
+			name:  "same version",
+			rules: []Rule{{Kind: "A", From: "v1", To: "v1"}},
+			err:   "rule 0: from and to versions must be different",
+		},
+		{
+			name: "duplicate",
+			rules: []Rule{
+				{Kind: "A", From: "v1", To: "v2"},
+				{Kind: "A", From: "v1", To: "v3"},
+			},
+			err: "rule 1: more than one rule converts from /v1, Kind=A",
+		},
+		{
+			name: "cycle",
+			rules: []Rule{
+				{Kind: "A", From: "v1", To: "v2"},
+				{Kind: "A", From: "v2", To: "v1"},
+			},
+			err: "rules for A form a cycle or a chain longer than 10",
+		},
+		{
+			name:  "metadata",
+			rules: []Rule{{Kind: "A", From: "v1", To: "v2", Removals: []string{"metadata.labels"}}},
+			err:   `rule 0: invalid removal: path "metadata.labels" must not point at metadata`,
+		},
+		{
+			name:  "empty field",
+			rules: []Rule{{Kind: "A", From: "v1", To: "v2", Renames: []Rename{{From: "spec..a", To: "spec.b"}}}},
+			err:   `rule 0: invalid rename: path "spec..a" has an empty field`,
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := NewRules(tc.rules)
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
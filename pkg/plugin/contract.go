@@ -0,0 +1,234 @@
+package plugin
+
+import (
+	"strings"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/atlassian/smith/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/xeipuuv/gojsonschema"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ContractVersion is the version of the JSON representation of plugin invocations and results.
+// It is bumped when the representation changes in a backwards incompatible way, old versions keep being served
+// until they are removed from the API.
+// See docs/design/plugins.md
+const ContractVersion = "v1"
+
+// InvocationSchema is the JSON schema of an Invocation.
+const InvocationSchema = `{
+  "$schema": "http://json-schema.org/draft-04/schema#",
+  "id": "https://smith.atlassian.com/schemas/plugin/v1/invocation.json",
+  "title": "Invocation",
+  "description": "Input of a plugin: the spec of the resource and snapshots of its dependencies.",
+  "type": "object",
+  "required": ["contractVersion", "plugin", "namespace", "spec"],
+  "properties": {
+    "contractVersion": {"enum": ["v1"]},
+    "plugin": {"type": "string", "minLength": 1},
+    "namespace": {"type": "string", "minLength": 1},
+    "spec": {"type": "object"},
+    "actual": {"$ref": "#/definitions/object"},
+    "dependencies": {
+      "type": "object",
+      "additionalProperties": {"$ref": "#/definitions/dependency"}
+    },
+    "dryRun": {"type": "boolean"},
+    "idempotencyKey": {"type": "string"}
+  },
+  "definitions": {
+    "dependency": {
+      "type": "object",
+      "required": ["actual"],
+      "properties": {
+        "actual": {"$ref": "#/definitions/object"},
+        "outputs": {"type": "array", "items": {"$ref": "#/definitions/object"}},
+        "auxiliary": {"type": "array", "items": {"$ref": "#/definitions/object"}}
+      }
+    },
+    "object": {
+      "type": "object",
+      "required": ["apiVersion", "kind"],
+      "properties": {
+        "apiVersion": {"type": "string", "minLength": 1},
+        "kind": {"type": "string", "minLength": 1},
+        "metadata": {"type": "object"}
+      }
+    }
+  }
+}`
+
+// ResultSchema is the JSON schema of a Result.
+const ResultSchema = `{
+  "$schema": "http://json-schema.org/draft-04/schema#",
+  "id": "https://smith.atlassian.com/schemas/plugin/v1/result.json",
+  "title": "Result",
+  "description": "Output of a plugin: the object to create or update.",
+  "type": "object",
+  "required": ["contractVersion", "object"],
+  "properties": {
+    "contractVersion": {"enum": ["v1"]},
+    "object": {"$ref": "#/definitions/object"}
+  },
+  "definitions": {
+    "object": {
+      "type": "object",
+      "required": ["apiVersion", "kind"],
+      "properties": {
+        "apiVersion": {"type": "string", "minLength": 1},
+        "kind": {"type": "string", "minLength": 1},
+        "metadata": {"type": "object"}
+      }
+    }
+  }
+}`
+
+var (
+	invocationSchema = mustCompileSchema(InvocationSchema)
+	resultSchema     = mustCompileSchema(ResultSchema)
+)
+
+// Invocation is the JSON representation of the input of Process().
+type Invocation struct {
+	ContractVersion string              `json:"contractVersion"`
+	Plugin          smith_v1.PluginName `json:"plugin"`
+	Namespace       string              `json:"namespace"`
+	// Spec is the plugin spec of the resource.
+	Spec map[string]interface{} `json:"spec"`
+	// Actual is the actual object, if it exists.
+	Actual         map[string]interface{}                       `json:"actual,omitempty"`
+	Dependencies   map[smith_v1.ResourceName]DependencySnapshot `json:"dependencies,omitempty"`
+	DryRun         bool                                         `json:"dryRun,omitempty"`
+	IdempotencyKey string                                       `json:"idempotencyKey,omitempty"`
+}
+
+// DependencySnapshot is the JSON representation of a Dependency.
+type DependencySnapshot struct {
+	Actual    map[string]interface{}   `json:"actual"`
+	Outputs   []map[string]interface{} `json:"outputs,omitempty"`
+	Auxiliary []map[string]interface{} `json:"auxiliary,omitempty"`
+}
+
+// Result is the JSON representation of the output of Process().
+type Result struct {
+	ContractVersion string                 `json:"contractVersion"`
+	Object          map[string]interface{} `json:"object"`
+}
+
+// NewInvocation returns the JSON representation of a Process() call.
+func NewInvocation(name smith_v1.PluginName, spec map[string]interface{}, context *Context) (*Invocation, error) {
+	inv := &Invocation{
+		ContractVersion: ContractVersion,
+		Plugin:          name,
+		Namespace:       context.Namespace,
+		Spec:            spec,
+		DryRun:          context.DryRun,
+		IdempotencyKey:  context.IdempotencyKey,
+	}
+	var err error
+	if context.Actual != nil {
+		inv.Actual, err = toMap(context.Actual)
+		if err != nil {
+			return nil, errors.Wrap(err, "actual object")
+		}
+	}
+	if len(context.Dependencies) > 0 {
+		inv.Dependencies = make(map[smith_v1.ResourceName]DependencySnapshot, len(context.Dependencies))
+	}
+	for resName, dep := range context.Dependencies {
+		var snapshot DependencySnapshot
+		snapshot.Actual, err = toMap(dep.Actual)
+		if err != nil {
+			return nil, errors.Wrapf(err, "dependency %q", resName)
+		}
+		snapshot.Outputs, err = toMaps(dep.Outputs)
+		if err != nil {
+			return nil, errors.Wrapf(err, "outputs of dependency %q", resName)
+		}
+		snapshot.Auxiliary, err = toMaps(dep.Auxiliary)
+		if err != nil {
+			return nil, errors.Wrapf(err, "auxiliary objects of dependency %q", resName)
+		}
+		inv.Dependencies[resName] = snapshot
+	}
+	return inv, nil
+}
+
+// NewResult returns the JSON representation of the result of a Process() call.
+func NewResult(result *ProcessResult) (*Result, error) {
+	if result == nil || result.Object == nil {
+		return nil, errors.New("plugin returned no object")
+	}
+	obj, err := toMap(result.Object)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{
+		ContractVersion: ContractVersion,
+		Object:          obj,
+	}, nil
+}
+
+// ValidateInvocation validates the invocation against InvocationSchema.
+func ValidateInvocation(inv *Invocation) error {
+	return validate(invocationSchema, inv, "invocation")
+}
+
+// ValidateResult validates the result against ResultSchema.
+func ValidateResult(result *Result) error {
+	return validate(resultSchema, result, "result")
+}
+
+func toMap(obj runtime.Object) (map[string]interface{}, error) {
+	u, err := util.RuntimeToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	return u.Object, nil
+}
+
+func toMaps(objs []runtime.Object) ([]map[string]interface{}, error) {
+	if len(objs) == 0 {
+		return nil, nil
+	}
+	result := make([]map[string]interface{}, 0, len(objs))
+	for _, obj := range objs {
+		m, err := toMap(obj)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, m)
+	}
+	return result, nil
+}
+
+func mustCompileSchema(schema string) *gojsonschema.Schema {
+	s, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(schema))
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// validate validates the document against the schema. Violations are listed in the error, e.g.
+// "result failed validation against schema: object: apiVersion is required".
+func validate(schema *gojsonschema.Schema, doc interface{}, what string) error {
+	validationResult, err := schema.Validate(gojsonschema.NewGoLoader(doc))
+	if err != nil {
+		return errors.Wrapf(err, "error validating %s", what)
+	}
+
+	if !validationResult.Valid() {
+		validationErrors := validationResult.Errors()
+		msgs := make([]string, 0, len(validationErrors))
+
+		for _, validationErr := range validationErrors {
+			msgs = append(msgs, validationErr.String())
+		}
+
+		return errors.Errorf("%s failed validation against schema: %s", what, strings.Join(msgs, ", "))
+	}
+
+	return nil
+}
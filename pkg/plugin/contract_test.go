@@ -0,0 +1,130 @@
+package plugin
+
+import (
+	"testing"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type testPlugin struct {
+	object runtime.Object
+}
+
+func (p *testPlugin) Describe() *Description {
+	return &Description{
+		Name: "test",
+		GVK:  core_v1.SchemeGroupVersion.WithKind("ConfigMap"),
+		OutputSchema: []byte(`{
+			"type": "object",
+			"required": ["data"]
+		}`),
+	}
+}
+
+func (p *testPlugin) Process(map[string]interface{}, *Context) (*ProcessResult, error) {
+	return &ProcessResult{
+		Object: p.object,
+	}, nil
+}
+
+func configMap(data map[string]string) *core_v1.ConfigMap {
+	return &core_v1.ConfigMap{
+		TypeMeta: meta_v1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name: "cm",
+		},
+		Data: data,
+	}
+}
+
+func TestNewInvocation(t *testing.T) {
+	t.Parallel()
+	inv, err := NewInvocation("test", map[string]interface{}{"a": "b"}, &Context{
+		Namespace: "ns",
+		Dependencies: map[smith_v1.ResourceName]Dependency{
+			"dep": {
+				Actual:  configMap(map[string]string{"x": "y"}),
+				Outputs: []runtime.Object{configMap(nil)},
+			},
+		},
+		IdempotencyKey: "key",
+	})
+	require.NoError(t, err)
+	require.NoError(t, ValidateInvocation(inv))
+	assert.Equal(t, ContractVersion, inv.ContractVersion)
+	assert.Nil(t, inv.Actual)
+	dep := inv.Dependencies["dep"]
+	assert.Equal(t, map[string]interface{}{"x": "y"}, dep.Actual["data"])
+	assert.Len(t, dep.Outputs, 1)
+	assert.Empty(t, dep.Auxiliary)
+}
+
+func TestValidateInvocationErrors(t *testing.T) {
+	t.Parallel()
+	err := ValidateInvocation(&Invocation{
+		ContractVersion: ContractVersion,
+		Plugin:          "test",
+		Namespace:       "ns",
+		Spec:            map[string]interface{}{},
+		Dependencies: map[smith_v1.ResourceName]DependencySnapshot{
+			"dep": {
+				Actual: map[string]interface{}{"apiVersion": "v1"},
+			},
+		},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invocation failed validation against schema: ")
+	assert.Contains(t, err.Error(), "kind is required")
+}
+
+func TestValidateResult(t *testing.T) {
+	t.Parallel()
+	pc, err := NewPluginContainer(func() (Plugin, error) {
+		return &testPlugin{}, nil
+	})
+	require.NoError(t, err)
+
+	obj, err := pc.ValidateResult(&ProcessResult{Object: configMap(map[string]string{"a": "b"})})
+	require.NoError(t, err)
+	assert.Equal(t, schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, obj.GroupVersionKind())
+
+	_, err = pc.ValidateResult(&ProcessResult{Object: configMap(nil)})
+	assert.EqualError(t, err, "object failed validation against schema: (root): data is required")
+
+	_, err = pc.ValidateResult(&ProcessResult{})
+	assert.EqualError(t, err, "plugin returned no object")
+
+	_, err = pc.ValidateResult(&ProcessResult{Object: &unstructured.Unstructured{
+		Object: map[string]interface{}{"kind": "ConfigMap"},
+	}})
+	assert.Error(t, err, "object without apiVersion must be rejected")
+}
+
+func TestNewPluginContainerInvalidOutputSchema(t *testing.T) {
+	t.Parallel()
+	_, err := NewPluginContainer(func() (Plugin, error) {
+		return &invalidOutputSchemaPlugin{}, nil
+	})
+	assert.Error(t, err)
+}
+
+type invalidOutputSchemaPlugin struct {
+	testPlugin
+}
+
+func (p *invalidOutputSchemaPlugin) Describe() *Description {
+	return &Description{
+		Name:         "invalid",
+		OutputSchema: []byte(`{"type": 1}`),
+	}
+}
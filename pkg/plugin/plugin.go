@@ -1,15 +1,15 @@
 package plugin
 
 import (
-	"strings"
-
 	"github.com/pkg/errors"
 	"github.com/xeipuuv/gojsonschema"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 type PluginContainer struct {
-	Plugin Plugin
-	schema *gojsonschema.Schema
+	Plugin       Plugin
+	schema       *gojsonschema.Schema
+	outputSchema *gojsonschema.Schema
 }
 
 func NewPluginContainer(newPlugin NewFunc) (PluginContainer, error) {
@@ -25,10 +25,18 @@ func NewPluginContainer(newPlugin NewFunc) (PluginContainer, error) {
 			return PluginContainer{}, errors.Wrapf(err, "can't use plugin %q due to invalid schema", description.Name)
 		}
 	}
+	var outputSchema *gojsonschema.Schema
+	if description.OutputSchema != nil {
+		outputSchema, err = gojsonschema.NewSchema(gojsonschema.NewBytesLoader(description.OutputSchema))
+		if err != nil {
+			return PluginContainer{}, errors.Wrapf(err, "can't use plugin %q due to invalid output schema", description.Name)
+		}
+	}
 
 	return PluginContainer{
-		Plugin: plugin,
-		schema: schema,
+		Plugin:       plugin,
+		schema:       schema,
+		outputSchema: outputSchema,
 	}, nil
 }
 
@@ -36,23 +44,25 @@ func (pc *PluginContainer) ValidateSpec(pluginSpec map[string]interface{}) error
 	if pc.schema == nil {
 		return nil
 	}
+	return validate(pc.schema, pluginSpec, "spec")
+}
 
-	validationResult, err := pc.schema.Validate(gojsonschema.NewGoLoader(pluginSpec))
+// ValidateResult validates the result of Process() against ResultSchema and the output schema of the plugin.
+// Returns the produced object.
+func (pc *PluginContainer) ValidateResult(result *ProcessResult) (*unstructured.Unstructured, error) {
+	r, err := NewResult(result)
 	if err != nil {
-		return errors.Wrap(err, "error validating plugin spec")
+		return nil, err
 	}
-
-	if !validationResult.Valid() {
-		validationErrors := validationResult.Errors()
-		msgs := make([]string, 0, len(validationErrors))
-
-		for _, validationErr := range validationErrors {
-			msgs = append(msgs, validationErr.String())
+	if err = ValidateResult(r); err != nil {
+		return nil, err
+	}
+	if pc.outputSchema != nil {
+		if err = validate(pc.outputSchema, r.Object, "object"); err != nil {
+			return nil, err
 		}
-
-		return errors.Errorf("spec failed validation against schema: %s",
-			strings.Join(msgs, ", "))
 	}
-
-	return nil
+	return &unstructured.Unstructured{
+		Object: r.Object,
+	}, nil
 }
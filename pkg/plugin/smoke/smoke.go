@@ -0,0 +1,137 @@
+// Package smoke contains plugins that produce canary objects to validate that Smith can manage objects in
+// a namespace. A failure to create a canary or a canary that does not become ready points at a problem with the
+// environment (RBAC, admission control, quotas) rather than with the Bundle itself.
+package smoke
+
+import (
+	"github.com/atlassian/smith/pkg/plugin"
+	"github.com/pkg/errors"
+	batch_v1 "k8s.io/api/batch/v1"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ConfigMapPluginName = "smoke-configmap"
+	JobPluginName       = "smoke-job"
+
+	// DefaultImage is the image of the Job container if none is specified.
+	DefaultImage = "busybox:1.28"
+	// DefaultActiveDeadlineSeconds is how long the Job may run if no deadline is specified.
+	// The Job fails once the deadline is exceeded, e.g. if its pod cannot be created or scheduled.
+	DefaultActiveDeadlineSeconds = 120
+
+	canaryKey   = "smoke"
+	canaryValue = "ok"
+)
+
+// NewConfigMap returns a plugin that produces a ConfigMap.
+// Creating it validates that objects can be written in the namespace, it becoming ready validates that they
+// can be read back.
+func NewConfigMap() (plugin.Plugin, error) {
+	return configMapPlugin{}, nil
+}
+
+// NewJob returns a plugin that produces a Job that runs a container to completion.
+// The Job completing validates that pods can be admitted, scheduled and run in the namespace.
+func NewJob() (plugin.Plugin, error) {
+	return jobPlugin{}, nil
+}
+
+type configMapPlugin struct {
+}
+
+func (configMapPlugin) Describe() *plugin.Description {
+	return &plugin.Description{
+		Name: ConfigMapPluginName,
+		GVK:  core_v1.SchemeGroupVersion.WithKind("ConfigMap"),
+		SpecSchema: []byte(`{
+			"type": "object",
+			"additionalProperties": false
+		}`),
+	}
+}
+
+func (configMapPlugin) Process(spec map[string]interface{}, context *plugin.Context) (*plugin.ProcessResult, error) {
+	return &plugin.ProcessResult{
+		Object: &core_v1.ConfigMap{
+			TypeMeta: meta_v1.TypeMeta{
+				Kind:       "ConfigMap",
+				APIVersion: core_v1.SchemeGroupVersion.String(),
+			},
+			Data: map[string]string{
+				canaryKey: canaryValue,
+			},
+		},
+	}, nil
+}
+
+type jobPlugin struct {
+}
+
+func (jobPlugin) Describe() *plugin.Description {
+	return &plugin.Description{
+		Name: JobPluginName,
+		GVK:  batch_v1.SchemeGroupVersion.WithKind("Job"),
+		SpecSchema: []byte(`{
+			"type": "object",
+			"additionalProperties": false,
+			"properties": {
+				"image": {
+					"type": "string",
+					"minLength": 1
+				},
+				"activeDeadlineSeconds": {
+					"type": "integer",
+					"minimum": 1
+				}
+			}
+		}`),
+	}
+}
+
+func (jobPlugin) Process(spec map[string]interface{}, context *plugin.Context) (*plugin.ProcessResult, error) {
+	image := DefaultImage
+	if i, ok := spec["image"]; ok {
+		image, ok = i.(string)
+		if !ok {
+			return nil, errors.Errorf("image must be a string, got %T", i)
+		}
+	}
+	activeDeadlineSeconds := int64(DefaultActiveDeadlineSeconds)
+	if d, ok := spec["activeDeadlineSeconds"]; ok {
+		switch deadline := d.(type) {
+		case int64:
+			activeDeadlineSeconds = deadline
+		case float64:
+			activeDeadlineSeconds = int64(deadline)
+		default:
+			return nil, errors.Errorf("activeDeadlineSeconds must be an integer, got %T", d)
+		}
+	}
+	backoffLimit := int32(0) // Fail fast, the environment is not going to fix itself
+	return &plugin.ProcessResult{
+		Object: &batch_v1.Job{
+			TypeMeta: meta_v1.TypeMeta{
+				Kind:       "Job",
+				APIVersion: batch_v1.SchemeGroupVersion.String(),
+			},
+			Spec: batch_v1.JobSpec{
+				ActiveDeadlineSeconds: &activeDeadlineSeconds,
+				BackoffLimit:          &backoffLimit,
+				Template: core_v1.PodTemplateSpec{
+					Spec: core_v1.PodSpec{
+						RestartPolicy: core_v1.RestartPolicyNever,
+						Containers: []core_v1.Container{
+							{
+								Name:    "smoke",
+								Image:   image,
+								Command: []string{"true"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}, nil
+}
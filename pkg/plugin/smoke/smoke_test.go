@@ -0,0 +1,45 @@
+package smoke_test
+
+import (
+	"testing"
+
+	"github.com/atlassian/smith/pkg/conformance"
+	"github.com/atlassian/smith/pkg/plugin/smoke"
+)
+
+func TestConfigMapPlugin(t *testing.T) {
+	t.Parallel()
+	conformance.TestPlugin(t, smoke.NewConfigMap,
+		conformance.PluginCase{
+			Name: "empty spec",
+			Spec: map[string]interface{}{},
+		},
+		conformance.PluginCase{
+			Name:        "unknown field",
+			Spec:        map[string]interface{}{"data": "x"},
+			ExpectError: true,
+		},
+	)
+}
+
+func TestJobPlugin(t *testing.T) {
+	t.Parallel()
+	conformance.TestPlugin(t, smoke.NewJob,
+		conformance.PluginCase{
+			Name: "defaults",
+			Spec: map[string]interface{}{},
+		},
+		conformance.PluginCase{
+			Name: "custom image and deadline",
+			Spec: map[string]interface{}{
+				"image":                 "alpine:3.8",
+				"activeDeadlineSeconds": int64(30),
+			},
+		},
+		conformance.PluginCase{
+			Name:        "invalid deadline",
+			Spec:        map[string]interface{}{"activeDeadlineSeconds": int64(0)},
+			ExpectError: true,
+		},
+	)
+}
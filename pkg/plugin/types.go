@@ -28,6 +28,8 @@ type Description struct {
 	GVK  schema.GroupVersionKind
 	// gojsonschema supported schema for the spec (first argument of Process)
 	SpecSchema []byte
+	// gojsonschema supported schema for the produced object (ProcessResult.Object). Optional.
+	OutputSchema []byte
 }
 
 // Context contains contextual information for the Process() call.
@@ -39,6 +41,14 @@ type Context struct {
 	Actual runtime.Object
 	// Dependencies is the map from dependency name to a description of that dependency.
 	Dependencies map[smith_v1.ResourceName]Dependency
+	// DryRun means the returned object is not going to be created or updated.
+	// Process must not cause any external side effects (e.g. provisioning of resources in an external system)
+	// when DryRun is set and must return the same object it would return otherwise.
+	DryRun bool
+	// IdempotencyKey identifies the resource across invocations of Process. It is the same for retries and
+	// updates of the resource and changes if the Bundle is deleted and created again. Plugins that cause external
+	// side effects must pass it to the external system so that repeated invocations do not duplicate them.
+	IdempotencyKey string
 }
 
 // Dependency contains information about a dependency of a resource that a plugin is processing.
@@ -1,6 +1,8 @@
 package readychecker
 
 import (
+	"sync"
+
 	"github.com/atlassian/smith"
 	"github.com/atlassian/smith/pkg/resources"
 
@@ -22,7 +24,10 @@ type CrdStore interface {
 }
 
 type ReadyChecker struct {
-	Store      CrdStore
+	Store CrdStore
+
+	mx sync.RWMutex
+	// KnownTypes must not be mutated directly once ReadyChecker is in use, use Register instead.
 	KnownTypes map[schema.GroupKind]IsObjectReady
 }
 
@@ -42,6 +47,21 @@ func New(store CrdStore, kts ...map[schema.GroupKind]IsObjectReady) *ReadyChecke
 	}
 }
 
+// Register adds a readiness check for objects of the kind.
+// Kinds that have a check registered are not checked using CRD annotations.
+func (rc *ReadyChecker) Register(gk schema.GroupKind, isObjectReady IsObjectReady) error {
+	rc.mx.Lock()
+	defer rc.mx.Unlock()
+	if _, ok := rc.KnownTypes[gk]; ok {
+		return errors.Errorf("readiness check for %s is already registered", gk)
+	}
+	if rc.KnownTypes == nil {
+		rc.KnownTypes = make(map[schema.GroupKind]IsObjectReady)
+	}
+	rc.KnownTypes[gk] = isObjectReady
+	return nil
+}
+
 func (rc *ReadyChecker) IsReady(obj *unstructured.Unstructured) (isReady, retriableError bool, e error) {
 	gvk := obj.GroupVersionKind()
 	gk := gvk.GroupKind()
@@ -50,21 +70,65 @@ func (rc *ReadyChecker) IsReady(obj *unstructured.Unstructured) (isReady, retria
 		return false, false, errors.Errorf("object has empty kind/version: %s", gvk)
 	}
 
-	// 1. Check if it is a known built-in resource
-	if isObjectReady, ok := rc.KnownTypes[gk]; ok {
+	// 1. Check if the object declares its readiness condition itself
+	if expr, ok := obj.GetAnnotations()[smith.ReadyWhenAnnotation]; ok {
+		return rc.checkReadyWhen(expr, obj)
+	}
+
+	// 2. Check if it is a known built-in resource
+	rc.mx.RLock()
+	isObjectReady, ok := rc.KnownTypes[gk]
+	rc.mx.RUnlock()
+	if ok {
 		return isObjectReady(obj)
 	}
 
-	// 2. Check if it is a CRD with path/value annotation
+	// 3. Check if it is a CRD with path/value annotation
 	ready, retriable, err := rc.checkPathValue(gk, obj)
 	if err != nil || ready {
 		return ready, retriable, err
 	}
 
-	// 3. Check if it is a CRD with Kind/GroupVersion annotation
+	// 4. Check if it is a CRD with Kind/GroupVersion annotation
 	return rc.checkForInstance(gk, obj)
 }
 
+// HasReadinessRule returns true if readiness of the object can be determined, i.e. it declares its readiness
+// condition itself, its kind is a known type or a CRD with readiness annotations.
+// Objects of kinds without a CRD are assumed to have a rule, they cannot be processed anyway.
+func (rc *ReadyChecker) HasReadinessRule(obj *unstructured.Unstructured) (bool, error) {
+	if _, ok := obj.GetAnnotations()[smith.ReadyWhenAnnotation]; ok {
+		return true, nil
+	}
+	gk := obj.GroupVersionKind().GroupKind()
+	rc.mx.RLock()
+	_, ok := rc.KnownTypes[gk]
+	rc.mx.RUnlock()
+	if ok {
+		return true, nil
+	}
+	crd, err := rc.Store.Get(gk)
+	if err != nil {
+		return false, err
+	}
+	if crd == nil {
+		return true, nil
+	}
+	return crd.Annotations[smith.CrFieldPathAnnotation] != "" && crd.Annotations[smith.CrFieldValueAnnotation] != "", nil
+}
+
+func (rc *ReadyChecker) checkReadyWhen(expr string, obj *unstructured.Unstructured) (isReady, retriableError bool, e error) {
+	rw, err := parseReadyWhen(expr)
+	if err != nil {
+		return false, false, errors.Wrapf(err, "invalid %s annotation", smith.ReadyWhenAnnotation)
+	}
+	ready, err := rw.evaluate(obj.Object)
+	if err != nil {
+		return false, false, errors.Wrapf(err, "failed to evaluate %s annotation", smith.ReadyWhenAnnotation)
+	}
+	return ready, false, nil
+}
+
 func (rc *ReadyChecker) checkForInstance(gk schema.GroupKind, obj *unstructured.Unstructured) (isReady, retriableError bool, e error) {
 	// TODO Check if it is a CRD with Kind/GroupVersion annotation
 	return false, false, nil
@@ -83,12 +147,14 @@ func (rc *ReadyChecker) checkPathValue(gk schema.GroupKind, obj *unstructured.Un
 	if len(path) == 0 || len(value) == 0 {
 		return false, false, nil
 	}
-	actualValue, err := resources.GetJsonPathString(obj.Object, path)
+	ready, err := pathValueReady(obj.Object, path, value)
+	return ready, false, err
+}
+
+func pathValueReady(obj map[string]interface{}, path, value string) (bool, error) {
+	actualValue, err := resources.GetJsonPathString(obj, path)
 	if err != nil {
-		return false, false, err
-	}
-	if actualValue != value {
-		return false, false, nil
+		return false, err
 	}
-	return true, false, nil
+	return actualValue == value, nil
 }
@@ -0,0 +1,78 @@
+package readychecker
+
+import (
+	"testing"
+
+	"github.com/atlassian/smith"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiext_v1b1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestRegister(t *testing.T) {
+	t.Parallel()
+	gk := schema.GroupKind{Group: "example.com", Kind: "Widget"}
+	rc := New(nil)
+	require.NoError(t, rc.Register(gk, func(obj runtime.Object) (isReady, retriableError bool, e error) {
+		return true, false, nil
+	}))
+	assert.Error(t, rc.Register(gk, func(obj runtime.Object) (isReady, retriableError bool, e error) {
+		return false, false, nil
+	}))
+
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("example.com/v1")
+	obj.SetKind("Widget")
+	ready, _, err := rc.IsReady(obj)
+	require.NoError(t, err)
+	assert.True(t, ready)
+}
+
+type crdStore map[schema.GroupKind]*apiext_v1b1.CustomResourceDefinition
+
+func (s crdStore) Get(gk schema.GroupKind) (*apiext_v1b1.CustomResourceDefinition, error) {
+	return s[gk], nil
+}
+
+func TestHasReadinessRule(t *testing.T) {
+	t.Parallel()
+	rc := New(crdStore{
+		{Group: "example.com", Kind: "Annotated"}: {
+			ObjectMeta: meta_v1.ObjectMeta{
+				Annotations: map[string]string{
+					smith.CrFieldPathAnnotation:  "{$.status.state}",
+					smith.CrFieldValueAnnotation: "Ready",
+				},
+			},
+		},
+		{Group: "example.com", Kind: "Plain"}: {},
+	}, map[schema.GroupKind]IsObjectReady{
+		{Group: "example.com", Kind: "Known"}: func(obj runtime.Object) (isReady, retriableError bool, e error) {
+			return true, false, nil
+		},
+	})
+	testcases := []struct {
+		kind        string
+		annotations map[string]string
+		expected    bool
+	}{
+		{kind: "Known", expected: true},
+		{kind: "Annotated", expected: true},
+		{kind: "Plain", expected: false},
+		{kind: "Plain", annotations: map[string]string{smith.ReadyWhenAnnotation: "$.status.phase == Bound"}, expected: true},
+		{kind: "Unknown", expected: true},
+	}
+	for _, tc := range testcases {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion("example.com/v1")
+		obj.SetKind(tc.kind)
+		obj.SetAnnotations(tc.annotations)
+		hasRule, err := rc.HasReadinessRule(obj)
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, hasRule, tc.kind)
+	}
+}
@@ -0,0 +1,72 @@
+package readychecker
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/atlassian/smith/pkg/resources"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// readyWhen is a parsed readiness expression of the form `<JSONPath> == <value>` or `<JSONPath> != <value>`.
+type readyWhen struct {
+	path   string
+	value  string
+	negate bool
+}
+
+func parseReadyWhen(expr string) (*readyWhen, error) {
+	var rw readyWhen
+	// The first operator separates the path from the value, the value may contain anything
+	i := strings.Index(expr, "==")
+	if j := strings.Index(expr, "!="); j != -1 && (i == -1 || j < i) {
+		i = j
+		rw.negate = true
+	}
+	if i == -1 {
+		return nil, errors.Errorf("expression %q must be of the form <JSONPath> == <value> or <JSONPath> != <value>", expr)
+	}
+	path := strings.TrimSpace(expr[:i])
+	value := strings.TrimSpace(expr[i+2:])
+	if path == "" {
+		return nil, errors.Errorf("expression %q has an empty JSONPath", expr)
+	}
+	if !strings.HasPrefix(path, "{") {
+		path = "{" + path + "}"
+	}
+	rw.path = path
+	if strings.HasPrefix(value, `"`) {
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "expression %q has an invalid quoted value", expr)
+		}
+		value = unquoted
+	}
+	rw.value = value
+	return &rw, nil
+}
+
+func (rw *readyWhen) evaluate(obj map[string]interface{}) (bool, error) {
+	actualValue, err := resources.GetJsonPathString(obj, rw.path)
+	if err != nil {
+		return false, err
+	}
+	return (actualValue == rw.value) != rw.negate, nil
+}
+
+// ValidateReadyWhen returns an error if the readiness expression is invalid.
+func ValidateReadyWhen(expr string) error {
+	_, err := parseReadyWhen(expr)
+	return err
+}
+
+// CheckReadyWhen evaluates the readiness expression against the object. Expressions have the same form as the value
+// of the ReadyWhenAnnotation.
+func CheckReadyWhen(expr string, obj *unstructured.Unstructured) (bool, error) {
+	rw, err := parseReadyWhen(expr)
+	if err != nil {
+		return false, err
+	}
+	return rw.evaluate(obj.Object)
+}
@@ -0,0 +1,100 @@
+package readychecker
+
+import (
+	"testing"
+
+	"github.com/atlassian/smith"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestReadyWhen(t *testing.T) {
+	t.Parallel()
+	obj := map[string]interface{}{
+		"status": map[string]interface{}{
+			"phase":    "Bound",
+			"replicas": int64(3),
+		},
+	}
+	cases := []struct {
+		expr  string
+		ready bool
+	}{
+		{expr: `$.status.phase == "Bound"`, ready: true},
+		{expr: `{$.status.phase}=="Bound"`, ready: true},
+		{expr: `$.status.phase == Bound`, ready: true},
+		{expr: `$.status.phase == "Pending"`, ready: false},
+		{expr: `$.status.phase != "Pending"`, ready: true},
+		{expr: `$.status.replicas == 3`, ready: true},
+		{expr: `$.status.missing == ""`, ready: true},
+		{expr: `$.status.phase == "a!=b"`, ready: false},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.expr, func(t *testing.T) {
+			t.Parallel()
+			rw, err := parseReadyWhen(tc.expr)
+			require.NoError(t, err)
+			ready, err := rw.evaluate(obj)
+			require.NoError(t, err)
+			assert.Equal(t, tc.ready, ready)
+		})
+	}
+}
+
+func TestReadyWhenInvalid(t *testing.T) {
+	t.Parallel()
+	for _, expr := range []string{`$.status.phase`, ` == "Bound"`, `$.status.phase == "Bound`} {
+		_, err := parseReadyWhen(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestCheckReadyWhen(t *testing.T) {
+	t.Parallel()
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"status": map[string]interface{}{
+				"phase": "Active",
+			},
+		},
+	}
+	ready, err := CheckReadyWhen(`$.status.phase == "Active"`, obj)
+	require.NoError(t, err)
+	assert.True(t, ready)
+	ready, err = CheckReadyWhen(`$.status.phase != "Active"`, obj)
+	require.NoError(t, err)
+	assert.False(t, ready)
+	_, err = CheckReadyWhen(`$.status.phase`, obj)
+	assert.Error(t, err)
+
+	assert.NoError(t, ValidateReadyWhen(`$.status.phase == "Active"`))
+	assert.Error(t, ValidateReadyWhen(`$.status.phase`))
+}
+
+func TestIsReadyUsesReadyWhenAnnotation(t *testing.T) {
+	t.Parallel()
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"status": map[string]interface{}{
+				"phase": "Pending",
+			},
+		},
+	}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("PersistentVolumeClaim")
+	obj.SetAnnotations(map[string]string{
+		smith.ReadyWhenAnnotation: `$.status.phase == "Bound"`,
+	})
+	rc := New(nil)
+
+	ready, _, err := rc.IsReady(obj)
+	require.NoError(t, err)
+	assert.False(t, ready)
+
+	obj.Object["status"].(map[string]interface{})["phase"] = "Bound"
+	ready, _, err = rc.IsReady(obj)
+	require.NoError(t, err)
+	assert.True(t, ready)
+}
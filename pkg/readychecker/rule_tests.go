@@ -0,0 +1,100 @@
+package readychecker
+
+import (
+	"encoding/json"
+
+	"github.com/atlassian/smith"
+	"github.com/pkg/errors"
+	apiext_v1b1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+)
+
+// RuleTestCase is a sample object and the readiness verdict expected for it.
+type RuleTestCase struct {
+	Name   string                 `json:"name"`
+	Object map[string]interface{} `json:"object"`
+	Ready  bool                   `json:"ready"`
+}
+
+// Rule is a readiness rule with test cases. Either ReadyWhen, or FieldPath and FieldValue are set. They have
+// the same meaning as the readyWhen object annotation and the CrReadyWhenFieldPath and CrReadyWhenFieldValue
+// CRD annotations.
+type Rule struct {
+	Name       string         `json:"name"`
+	ReadyWhen  string         `json:"readyWhen,omitempty"`
+	FieldPath  string         `json:"fieldPath,omitempty"`
+	FieldValue string         `json:"fieldValue,omitempty"`
+	Tests      []RuleTestCase `json:"tests,omitempty"`
+}
+
+// RuleTestResult is the outcome of a test case.
+type RuleTestResult struct {
+	Case  string
+	Ready bool
+	// Err is set if the rule could not be evaluated against the object.
+	Err error
+	// Passed is true if the rule was evaluated and produced the expected verdict.
+	Passed bool
+}
+
+// RuleFromCrd returns the readiness rule declared by annotations of the CRD with test cases from the
+// CrReadyWhenTests annotation. Returns nil if the CRD does not declare a rule.
+func RuleFromCrd(crd *apiext_v1b1.CustomResourceDefinition) (*Rule, error) {
+	path := crd.Annotations[smith.CrFieldPathAnnotation]
+	value := crd.Annotations[smith.CrFieldValueAnnotation]
+	tests, hasTests := crd.Annotations[smith.CrReadyWhenTestsAnnotation]
+	if len(path) == 0 || len(value) == 0 {
+		if hasTests {
+			return nil, errors.Errorf("CRD %q has %s annotation but does not declare a readiness rule", crd.Name, smith.CrReadyWhenTestsAnnotation)
+		}
+		return nil, nil
+	}
+	rule := &Rule{
+		Name:       crd.Name,
+		FieldPath:  path,
+		FieldValue: value,
+	}
+	if hasTests {
+		if err := json.Unmarshal([]byte(tests), &rule.Tests); err != nil {
+			return nil, errors.Wrapf(err, "invalid %s annotation on CRD %q", smith.CrReadyWhenTestsAnnotation, crd.Name)
+		}
+	}
+	return rule, nil
+}
+
+// Run evaluates the rule against objects of all test cases.
+// Returns an error if the rule itself is invalid.
+func (r *Rule) Run() ([]RuleTestResult, error) {
+	isReady, err := r.compile()
+	if err != nil {
+		return nil, err
+	}
+	results := make([]RuleTestResult, 0, len(r.Tests))
+	for _, tc := range r.Tests {
+		result := RuleTestResult{
+			Case: tc.Name,
+		}
+		result.Ready, result.Err = isReady(tc.Object)
+		result.Passed = result.Err == nil && result.Ready == tc.Ready
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (r *Rule) compile() (func(map[string]interface{}) (bool, error), error) {
+	switch {
+	case r.ReadyWhen != "" && (r.FieldPath != "" || r.FieldValue != ""):
+		return nil, errors.Errorf("rule %q must have either readyWhen or fieldPath and fieldValue, not both", r.Name)
+	case r.ReadyWhen != "":
+		rw, err := parseReadyWhen(r.ReadyWhen)
+		if err != nil {
+			return nil, errors.Wrapf(err, "rule %q is invalid", r.Name)
+		}
+		return rw.evaluate, nil
+	case r.FieldPath != "" && r.FieldValue != "":
+		return func(obj map[string]interface{}) (bool, error) {
+			return pathValueReady(obj, r.FieldPath, r.FieldValue)
+		}, nil
+	default:
+		return nil, errors.Errorf("rule %q must have either readyWhen or both fieldPath and fieldValue", r.Name)
+	}
+}
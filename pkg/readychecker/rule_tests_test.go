@@ -0,0 +1,85 @@
+package readychecker
+
+import (
+	"testing"
+
+	"github.com/atlassian/smith"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiext_v1b1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func withPhase(phase string) map[string]interface{} {
+	return map[string]interface{}{
+		"status": map[string]interface{}{
+			"phase": phase,
+		},
+	}
+}
+
+func TestRuleRun(t *testing.T) {
+	t.Parallel()
+	rule := Rule{
+		Name:      "database",
+		ReadyWhen: `$.status.phase == "Ready"`,
+		Tests: []RuleTestCase{
+			{Name: "ready", Object: withPhase("Ready"), Ready: true},
+			{Name: "pending", Object: withPhase("Pending"), Ready: false},
+			{Name: "misclassified", Object: withPhase("Failed"), Ready: true},
+		},
+	}
+	results, err := rule.Run()
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.True(t, results[0].Passed)
+	assert.True(t, results[1].Passed)
+	assert.False(t, results[2].Passed)
+	assert.False(t, results[2].Ready)
+	assert.NoError(t, results[2].Err)
+}
+
+func TestRuleInvalid(t *testing.T) {
+	t.Parallel()
+	for _, rule := range []Rule{
+		{Name: "empty"},
+		{Name: "both", ReadyWhen: `$.a == b`, FieldPath: "$.a", FieldValue: "b"},
+		{Name: "no value", FieldPath: "$.a"},
+		{Name: "bad expression", ReadyWhen: "$.a"},
+	} {
+		_, err := rule.Run()
+		assert.Error(t, err, rule.Name)
+	}
+}
+
+func TestRuleFromCrd(t *testing.T) {
+	t.Parallel()
+	crd := &apiext_v1b1.CustomResourceDefinition{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name: "databases.example.com",
+			Annotations: map[string]string{
+				smith.CrFieldPathAnnotation:      "{$.status.phase}",
+				smith.CrFieldValueAnnotation:     "Ready",
+				smith.CrReadyWhenTestsAnnotation: `[{"name": "ready", "object": {"status": {"phase": "Ready"}}, "ready": true}]`,
+			},
+		},
+	}
+	rule, err := RuleFromCrd(crd)
+	require.NoError(t, err)
+	require.NotNil(t, rule)
+	results, err := rule.Run()
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.True(t, results[0].Passed)
+
+	// Test cases without a rule
+	delete(crd.Annotations, smith.CrFieldValueAnnotation)
+	_, err = RuleFromCrd(crd)
+	assert.Error(t, err)
+
+	// No rule
+	delete(crd.Annotations, smith.CrReadyWhenTestsAnnotation)
+	rule, err = RuleFromCrd(crd)
+	require.NoError(t, err)
+	assert.Nil(t, rule)
+}
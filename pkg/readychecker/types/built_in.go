@@ -1,12 +1,15 @@
 package types
 
 import (
+	"github.com/atlassian/smith/pkg/apis/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
 	"github.com/atlassian/smith/pkg/readychecker"
 	"github.com/atlassian/smith/pkg/util"
 
 	sc_v1b1 "github.com/kubernetes-incubator/service-catalog/pkg/apis/servicecatalog/v1beta1"
 	"github.com/pkg/errors"
 	apps_v1 "k8s.io/api/apps/v1"
+	batch_v1 "k8s.io/api/batch/v1"
 	core_v1 "k8s.io/api/core/v1"
 	ext_v1b1 "k8s.io/api/extensions/v1beta1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -15,19 +18,30 @@ import (
 
 var (
 	MainKnownTypes = map[schema.GroupKind]readychecker.IsObjectReady{
-		{Group: core_v1.GroupName, Kind: "ConfigMap"}:      alwaysReady,
-		{Group: core_v1.GroupName, Kind: "Secret"}:         alwaysReady,
-		{Group: core_v1.GroupName, Kind: "Service"}:        alwaysReady,
-		{Group: core_v1.GroupName, Kind: "ServiceAccount"}: alwaysReady,
-		{Group: apps_v1.GroupName, Kind: "Deployment"}:     isDeploymentReady,
-		{Group: ext_v1b1.GroupName, Kind: "Ingress"}:       alwaysReady,
+		{Group: core_v1.GroupName, Kind: "ConfigMap"}:             alwaysReady,
+		{Group: core_v1.GroupName, Kind: "Secret"}:                alwaysReady,
+		{Group: core_v1.GroupName, Kind: "Service"}:               isServiceReady,
+		{Group: core_v1.GroupName, Kind: "ServiceAccount"}:        alwaysReady,
+		{Group: core_v1.GroupName, Kind: "Endpoints"}:             isEndpointsReady,
+		{Group: core_v1.GroupName, Kind: "PersistentVolumeClaim"}: isPersistentVolumeClaimReady,
+		{Group: apps_v1.GroupName, Kind: "Deployment"}:            isDeploymentReady,
+		{Group: apps_v1.GroupName, Kind: "StatefulSet"}:           isStatefulSetReady,
+		{Group: batch_v1.GroupName, Kind: "Job"}:                  isJobReady,
+		// Many Ingress controllers never populate the status, hence Ingress is always considered ready.
+		// IsIngressReady can be registered instead if status is known to be populated.
+		{Group: ext_v1b1.GroupName, Kind: "Ingress"}: alwaysReady,
+		{Group: smith.GroupName, Kind: "Bundle"}:     isBundleReady,
 	}
 	ServiceCatalogKnownTypes = map[schema.GroupKind]readychecker.IsObjectReady{
 		{Group: sc_v1b1.GroupName, Kind: "ServiceBinding"}:  isScServiceBindingReady,
 		{Group: sc_v1b1.GroupName, Kind: "ServiceInstance"}: isScServiceInstanceReady,
 	}
-	apps_v1_scheme = runtime.NewScheme()
-	sc_v1b1_scheme = runtime.NewScheme()
+	apps_v1_scheme  = runtime.NewScheme()
+	batch_v1_scheme = runtime.NewScheme()
+	core_v1_scheme  = runtime.NewScheme()
+	ext_v1b1_scheme = runtime.NewScheme()
+	sc_v1b1_scheme  = runtime.NewScheme()
+	smith_v1_scheme = runtime.NewScheme()
 )
 
 func init() {
@@ -35,10 +49,26 @@ func init() {
 	if err != nil {
 		panic(err)
 	}
+	err = batch_v1.SchemeBuilder.AddToScheme(batch_v1_scheme)
+	if err != nil {
+		panic(err)
+	}
+	err = core_v1.SchemeBuilder.AddToScheme(core_v1_scheme)
+	if err != nil {
+		panic(err)
+	}
+	err = ext_v1b1.SchemeBuilder.AddToScheme(ext_v1b1_scheme)
+	if err != nil {
+		panic(err)
+	}
 	err = sc_v1b1.SchemeBuilder.AddToScheme(sc_v1b1_scheme)
 	if err != nil {
 		panic(err)
 	}
+	err = smith_v1.SchemeBuilder.AddToScheme(smith_v1_scheme)
+	if err != nil {
+		panic(err)
+	}
 }
 
 func alwaysReady(_ runtime.Object) (isReady, retriableError bool, e error) {
@@ -62,39 +92,154 @@ func isDeploymentReady(obj runtime.Object) (isReady, retriableError bool, e erro
 		deployment.Status.UpdatedReplicas == replicas, false, nil
 }
 
-func isScServiceBindingReady(obj runtime.Object) (isReady, retriableError bool, e error) {
-	var sic sc_v1b1.ServiceBinding
-	if err := util.ConvertType(sc_v1b1_scheme, obj, &sic); err != nil {
+// isStatefulSetReady considers a StatefulSet ready once all its replicas are ready and running the current revision.
+func isStatefulSetReady(obj runtime.Object) (isReady, retriableError bool, e error) {
+	var sts apps_v1.StatefulSet
+	if err := util.ConvertType(apps_v1_scheme, obj, &sts); err != nil {
 		return false, false, err
 	}
-	readyCond := getServiceBindingCondition(&sic, sc_v1b1.ServiceBindingConditionReady)
-	if readyCond != nil && readyCond.Status == sc_v1b1.ConditionTrue {
+
+	replicas := int32(1) // Default value if not specified
+	if sts.Spec.Replicas != nil {
+		replicas = *sts.Spec.Replicas
+	}
+	if sts.Status.ObservedGeneration < sts.Generation || sts.Status.ReadyReplicas != replicas {
+		return false, false, nil
+	}
+	if sts.Spec.UpdateStrategy.Type == apps_v1.OnDeleteStatefulSetStrategyType {
+		// Pods are only updated when deleted manually
 		return true, false, nil
 	}
-	failedCond := getServiceBindingCondition(&sic, sc_v1b1.ServiceBindingConditionFailed)
-	if failedCond != nil && failedCond.Status == sc_v1b1.ConditionTrue {
-		return false, false, errors.Errorf("%s: %s", failedCond.Reason, failedCond.Message)
+	return sts.Status.UpdatedReplicas == replicas && sts.Status.CurrentRevision == sts.Status.UpdateRevision, false, nil
+}
+
+// isPersistentVolumeClaimReady considers a PVC ready once it is bound. A lost PVC is a terminal error.
+func isPersistentVolumeClaimReady(obj runtime.Object) (isReady, retriableError bool, e error) {
+	var pvc core_v1.PersistentVolumeClaim
+	if err := util.ConvertType(core_v1_scheme, obj, &pvc); err != nil {
+		return false, false, err
+	}
+	switch pvc.Status.Phase {
+	case core_v1.ClaimBound:
+		return true, false, nil
+	case core_v1.ClaimLost:
+		return false, false, errors.Errorf("persistent volume %q was lost", pvc.Spec.VolumeName)
 	}
-	// TODO support "unknown" and "in progress"
 	return false, false, nil
+}
 
+// isServiceReady considers a Service of the LoadBalancer type ready once the load balancer has been provisioned.
+// Services of other types are always ready.
+func isServiceReady(obj runtime.Object) (isReady, retriableError bool, e error) {
+	var service core_v1.Service
+	if err := util.ConvertType(core_v1_scheme, obj, &service); err != nil {
+		return false, false, err
+	}
+	if service.Spec.Type != core_v1.ServiceTypeLoadBalancer {
+		return true, false, nil
+	}
+	return len(service.Status.LoadBalancer.Ingress) > 0, false, nil
 }
 
+// isEndpointsReady considers Endpoints ready once there is at least one ready address.
+func isEndpointsReady(obj runtime.Object) (isReady, retriableError bool, e error) {
+	var endpoints core_v1.Endpoints
+	if err := util.ConvertType(core_v1_scheme, obj, &endpoints); err != nil {
+		return false, false, err
+	}
+	for _, subset := range endpoints.Subsets {
+		if len(subset.Addresses) > 0 {
+			return true, false, nil
+		}
+	}
+	return false, false, nil
+}
+
+// IsIngressReady considers an Ingress ready once its load balancer has been provisioned.
+// It is not used by default because many Ingress controllers do not populate the status.
+func IsIngressReady(obj runtime.Object) (isReady, retriableError bool, e error) {
+	var ingress ext_v1b1.Ingress
+	if err := util.ConvertType(ext_v1b1_scheme, obj, &ingress); err != nil {
+		return false, false, err
+	}
+	return len(ingress.Status.LoadBalancer.Ingress) > 0, false, nil
+}
+
+// isJobReady considers a Job ready once it has completed. A failed Job is a terminal error.
+func isJobReady(obj runtime.Object) (isReady, retriableError bool, e error) {
+	var job batch_v1.Job
+	if err := util.ConvertType(batch_v1_scheme, obj, &job); err != nil {
+		return false, false, err
+	}
+	for _, cond := range job.Status.Conditions {
+		if cond.Status != core_v1.ConditionTrue {
+			continue
+		}
+		switch cond.Type {
+		case batch_v1.JobComplete:
+			return true, false, nil
+		case batch_v1.JobFailed:
+			return false, false, errors.Errorf("job failed: %s: %s", cond.Reason, cond.Message)
+		}
+	}
+	return false, false, nil
+}
+
+// isScServiceBindingReady considers a ServiceBinding ready once Service Catalog has reconciled its current generation
+// and the Ready condition is true. The Failed condition is a terminal error.
+func isScServiceBindingReady(obj runtime.Object) (isReady, retriableError bool, e error) {
+	var binding sc_v1b1.ServiceBinding
+	if err := util.ConvertType(sc_v1b1_scheme, obj, &binding); err != nil {
+		return false, false, err
+	}
+	failedCond := getServiceBindingCondition(&binding, sc_v1b1.ServiceBindingConditionFailed)
+	if failedCond != nil && failedCond.Status == sc_v1b1.ConditionTrue {
+		return false, false, errors.Errorf("%s: %s", failedCond.Reason, failedCond.Message)
+	}
+	if binding.Status.AsyncOpInProgress || binding.Status.ReconciledGeneration < binding.Generation {
+		// Ready condition may be left over from the previous generation
+		return false, false, nil
+	}
+	readyCond := getServiceBindingCondition(&binding, sc_v1b1.ServiceBindingConditionReady)
+	return readyCond != nil && readyCond.Status == sc_v1b1.ConditionTrue, false, nil
+}
+
+// isScServiceInstanceReady considers a ServiceInstance ready once Service Catalog has reconciled its current
+// generation and the Ready condition is true. The Failed condition is a terminal error.
 func isScServiceInstanceReady(obj runtime.Object) (isReady, retriableError bool, e error) {
 	var instance sc_v1b1.ServiceInstance
 	if err := util.ConvertType(sc_v1b1_scheme, obj, &instance); err != nil {
 		return false, false, err
 	}
-	readyCond := getServiceInstanceCondition(&instance, sc_v1b1.ServiceInstanceConditionReady)
-	if readyCond != nil && readyCond.Status == sc_v1b1.ConditionTrue {
-		return true, false, nil
-	}
 	failedCond := getServiceInstanceCondition(&instance, sc_v1b1.ServiceInstanceConditionFailed)
 	if failedCond != nil && failedCond.Status == sc_v1b1.ConditionTrue {
 		return false, false, errors.Errorf("%s: %s", failedCond.Reason, failedCond.Message)
 	}
-	// TODO support "unknown" and "in progress"
-	return false, false, nil
+	if instance.Status.AsyncOpInProgress || instance.Status.ReconciledGeneration < instance.Generation {
+		// Provisioning or update is in progress, Ready condition may be left over from the previous generation
+		return false, false, nil
+	}
+	readyCond := getServiceInstanceCondition(&instance, sc_v1b1.ServiceInstanceConditionReady)
+	return readyCond != nil && readyCond.Status == sc_v1b1.ConditionTrue, false, nil
+}
+
+// isBundleReady considers a nested Bundle ready once its controller has observed the current generation and
+// the Ready condition is true. A terminal error of the Bundle is a terminal error of the resource.
+func isBundleReady(obj runtime.Object) (isReady, retriableError bool, e error) {
+	var bundle smith_v1.Bundle
+	if err := util.ConvertType(smith_v1_scheme, obj, &bundle); err != nil {
+		return false, false, err
+	}
+	if bundle.Status.ObservedGeneration < bundle.Generation {
+		// Conditions may be left over from the previous generation
+		return false, false, nil
+	}
+	_, errorCond := bundle.GetCondition(smith_v1.BundleError)
+	if errorCond != nil && errorCond.Status == smith_v1.ConditionTrue && errorCond.Reason != smith_v1.BundleReasonRetriableError {
+		return false, false, errors.Errorf("%s: %s", errorCond.Reason, errorCond.Message)
+	}
+	_, readyCond := bundle.GetCondition(smith_v1.BundleReady)
+	return readyCond != nil && readyCond.Status == smith_v1.ConditionTrue, false, nil
 }
 
 func getServiceInstanceCondition(instance *sc_v1b1.ServiceInstance, conditionType sc_v1b1.ServiceInstanceConditionType) *sc_v1b1.ServiceInstanceCondition {
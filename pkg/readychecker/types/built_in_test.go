@@ -0,0 +1,136 @@
+package types
+
+import (
+	"testing"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	sc_v1b1 "github.com/kubernetes-incubator/service-catalog/pkg/apis/servicecatalog/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestServiceInstanceReady(t *testing.T) {
+	t.Parallel()
+	readyCond := sc_v1b1.ServiceInstanceCondition{Type: sc_v1b1.ServiceInstanceConditionReady, Status: sc_v1b1.ConditionTrue}
+	instance := func(generation, reconciledGeneration int64, asyncOp bool, conds ...sc_v1b1.ServiceInstanceCondition) *sc_v1b1.ServiceInstance {
+		return &sc_v1b1.ServiceInstance{
+			ObjectMeta: meta_v1.ObjectMeta{Generation: generation},
+			Status: sc_v1b1.ServiceInstanceStatus{
+				Conditions:           conds,
+				AsyncOpInProgress:    asyncOp,
+				ReconciledGeneration: reconciledGeneration,
+			},
+		}
+	}
+
+	ready, _, err := isScServiceInstanceReady(instance(2, 2, false, readyCond))
+	require.NoError(t, err)
+	assert.True(t, ready)
+
+	// Ready condition is from the previous generation
+	ready, _, err = isScServiceInstanceReady(instance(3, 2, false, readyCond))
+	require.NoError(t, err)
+	assert.False(t, ready)
+
+	// Update is in progress
+	ready, _, err = isScServiceInstanceReady(instance(2, 2, true, readyCond))
+	require.NoError(t, err)
+	assert.False(t, ready)
+
+	ready, _, err = isScServiceInstanceReady(instance(2, 2, false))
+	require.NoError(t, err)
+	assert.False(t, ready)
+
+	ready, retriable, err := isScServiceInstanceReady(instance(2, 2, false, sc_v1b1.ServiceInstanceCondition{
+		Type:    sc_v1b1.ServiceInstanceConditionFailed,
+		Status:  sc_v1b1.ConditionTrue,
+		Reason:  "ProvisionCallFailed",
+		Message: "broker said no",
+	}))
+	require.EqualError(t, err, "ProvisionCallFailed: broker said no")
+	assert.False(t, ready)
+	assert.False(t, retriable)
+}
+
+func TestServiceBindingReady(t *testing.T) {
+	t.Parallel()
+	readyCond := sc_v1b1.ServiceBindingCondition{Type: sc_v1b1.ServiceBindingConditionReady, Status: sc_v1b1.ConditionTrue}
+	binding := func(generation, reconciledGeneration int64, asyncOp bool, conds ...sc_v1b1.ServiceBindingCondition) *sc_v1b1.ServiceBinding {
+		return &sc_v1b1.ServiceBinding{
+			ObjectMeta: meta_v1.ObjectMeta{Generation: generation},
+			Status: sc_v1b1.ServiceBindingStatus{
+				Conditions:           conds,
+				AsyncOpInProgress:    asyncOp,
+				ReconciledGeneration: reconciledGeneration,
+			},
+		}
+	}
+
+	ready, _, err := isScServiceBindingReady(binding(1, 1, false, readyCond))
+	require.NoError(t, err)
+	assert.True(t, ready)
+
+	ready, _, err = isScServiceBindingReady(binding(1, 0, false, readyCond))
+	require.NoError(t, err)
+	assert.False(t, ready)
+
+	ready, _, err = isScServiceBindingReady(binding(1, 1, true, readyCond))
+	require.NoError(t, err)
+	assert.False(t, ready)
+
+	_, retriable, err := isScServiceBindingReady(binding(1, 1, false, sc_v1b1.ServiceBindingCondition{
+		Type:    sc_v1b1.ServiceBindingConditionFailed,
+		Status:  sc_v1b1.ConditionTrue,
+		Reason:  "BindCallFailed",
+		Message: "broker said no",
+	}))
+	require.EqualError(t, err, "BindCallFailed: broker said no")
+	assert.False(t, retriable)
+}
+
+func TestBundleReady(t *testing.T) {
+	t.Parallel()
+	readyCond := smith_v1.BundleCondition{Type: smith_v1.BundleReady, Status: smith_v1.ConditionTrue}
+	bundle := func(generation, observedGeneration int64, conds ...smith_v1.BundleCondition) *smith_v1.Bundle {
+		return &smith_v1.Bundle{
+			ObjectMeta: meta_v1.ObjectMeta{Generation: generation},
+			Status: smith_v1.BundleStatus{
+				Conditions:         conds,
+				ObservedGeneration: observedGeneration,
+			},
+		}
+	}
+
+	ready, _, err := isBundleReady(bundle(2, 2, readyCond))
+	require.NoError(t, err)
+	assert.True(t, ready)
+
+	// Ready condition is from the previous generation
+	ready, _, err = isBundleReady(bundle(3, 2, readyCond))
+	require.NoError(t, err)
+	assert.False(t, ready)
+
+	ready, _, err = isBundleReady(bundle(2, 2))
+	require.NoError(t, err)
+	assert.False(t, ready)
+
+	// Retriable errors are retried by the controller of the nested Bundle
+	ready, _, err = isBundleReady(bundle(2, 2, smith_v1.BundleCondition{
+		Type:   smith_v1.BundleError,
+		Status: smith_v1.ConditionTrue,
+		Reason: smith_v1.BundleReasonRetriableError,
+	}))
+	require.NoError(t, err)
+	assert.False(t, ready)
+
+	ready, retriable, err := isBundleReady(bundle(2, 2, smith_v1.BundleCondition{
+		Type:    smith_v1.BundleError,
+		Status:  smith_v1.ConditionTrue,
+		Reason:  smith_v1.BundleReasonTerminalError,
+		Message: "bad spec",
+	}))
+	require.EqualError(t, err, "TerminalError: bad spec")
+	assert.False(t, ready)
+	assert.False(t, retriable)
+}
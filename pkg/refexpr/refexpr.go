@@ -0,0 +1,196 @@
+// Package refexpr evaluates reference expressions. A use of references in an object or plugin spec is either the name
+// of a reference, e.g. "!{dbHost}", which is replaced with the value of the reference as is, or an expression that
+// is evaluated to a string, e.g. `!{printf "%s:%v" .dbHost .dbPort}` or "!{.password | base64}".
+//
+// Expressions are pipelines of Go templates. Values of references are accessed as fields of the dot, e.g. ".dbHost",
+// or with the index function if their names are not identifiers, e.g. `index . "db-host"`. In addition to the
+// built-in functions of templates, e.g. printf, the Sprig-style functions listed in Functions are available.
+package refexpr
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/pkg/errors"
+)
+
+// Functions are the functions available in reference expressions in addition to the built-in functions of templates.
+// Arguments are ordered so that the value is last and can be piped in, e.g. `.port | default 5432`.
+var Functions = []string{"base64", "default", "join", "sha256", "toJson", "trim"}
+
+var funcs = template.FuncMap{
+	// base64 returns the standard base64 encoding of the value.
+	"base64": func(value interface{}) string {
+		return base64.StdEncoding.EncodeToString([]byte(toString(value)))
+	},
+	// default returns the default value if the value is empty, i.e. not set, false, zero or an empty string, slice
+	// or map.
+	"default": func(def interface{}, value ...interface{}) interface{} {
+		if len(value) == 0 || isEmpty(value[0]) {
+			return def
+		}
+		return value[0]
+	},
+	// join joins elements of a list with a separator.
+	"join": func(sep string, list interface{}) (string, error) {
+		rv := reflect.ValueOf(list)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return "", errors.Errorf("join expects a list, got %T", list)
+		}
+		elems := make([]string, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			elems = append(elems, toString(rv.Index(i).Interface()))
+		}
+		return strings.Join(elems, sep), nil
+	},
+	// sha256 returns the hex encoded SHA-256 hash of the value.
+	"sha256": func(value interface{}) string {
+		sum := sha256.Sum256([]byte(toString(value)))
+		return hex.EncodeToString(sum[:])
+	},
+	// toJson returns the JSON encoding of the value.
+	"toJson": func(value interface{}) (string, error) {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		return string(data), nil
+	},
+	// trim removes leading and trailing white space from the value.
+	"trim": func(value interface{}) string {
+		return strings.TrimSpace(toString(value))
+	},
+}
+
+// Expression is a parsed reference expression.
+type Expression struct {
+	text       string
+	tmpl       *template.Template
+	references []string
+}
+
+// IsExpression returns true if the contents of "!{...}" are an expression rather than the name of a reference.
+// Names of references cannot start with a dot or a dollar sign and cannot contain white space, pipes, quotes or
+// parentheses.
+func IsExpression(contents string) bool {
+	return strings.HasPrefix(contents, ".") || strings.HasPrefix(contents, "$") || strings.ContainsAny(contents, " \t\n|\"`()")
+}
+
+// Parse parses a reference expression, the contents of "!{...}". The expression must be a single pipeline.
+func Parse(expr string) (*Expression, error) {
+	tmpl, err := template.New("reference").Option("missingkey=error").Funcs(funcs).Parse("{{" + expr + "}}")
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid reference expression %q", expr)
+	}
+	nodes := tmpl.Tree.Root.Nodes
+	if len(nodes) != 1 || nodes[0].Type() != parse.NodeAction {
+		return nil, errors.Errorf("reference expression %q must be a single pipeline", expr)
+	}
+	action := nodes[0].(*parse.ActionNode)
+	if len(action.Pipe.Decl) > 0 {
+		return nil, errors.Errorf("reference expression %q must not declare variables", expr)
+	}
+	names := make(map[string]struct{})
+	collectReferences(action.Pipe, names)
+	references := make([]string, 0, len(names))
+	for name := range names {
+		references = append(references, name)
+	}
+	sort.Strings(references)
+	return &Expression{
+		text:       expr,
+		tmpl:       tmpl,
+		references: references,
+	}, nil
+}
+
+// References returns sorted names of references used in the expression.
+func (e *Expression) References() []string {
+	return e.references
+}
+
+// Evaluate evaluates the expression with values of references. It is an error to use a reference that does not
+// have a value.
+func (e *Expression) Evaluate(values map[string]interface{}) (string, error) {
+	var buf strings.Builder
+	if err := e.tmpl.Execute(&buf, values); err != nil {
+		return "", errors.Wrapf(err, "failed to evaluate reference expression %q", e.text)
+	}
+	return buf.String(), nil
+}
+
+// collectReferences adds names of references used by the node and its children to names.
+func collectReferences(node parse.Node, names map[string]struct{}) {
+	switch n := node.(type) {
+	case *parse.PipeNode:
+		for _, cmd := range n.Cmds {
+			collectReferences(cmd, names)
+		}
+	case *parse.CommandNode:
+		// index . "name"
+		if len(n.Args) >= 3 {
+			if ident, ok := n.Args[0].(*parse.IdentifierNode); ok && ident.Ident == "index" {
+				if _, ok := n.Args[1].(*parse.DotNode); ok {
+					if str, ok := n.Args[2].(*parse.StringNode); ok {
+						names[str.Text] = struct{}{}
+					}
+				}
+			}
+		}
+		for _, arg := range n.Args {
+			collectReferences(arg, names)
+		}
+	case *parse.FieldNode:
+		names[n.Ident[0]] = struct{}{}
+	case *parse.VariableNode:
+		// $.name
+		if len(n.Ident) > 1 && n.Ident[0] == "$" {
+			names[n.Ident[1]] = struct{}{}
+		}
+	case *parse.ChainNode:
+		collectReferences(n.Node, names)
+	}
+}
+
+func toString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func isEmpty(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return rv.Len() == 0
+	case reflect.Bool:
+		return !rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return rv.Float() == 0
+	case reflect.Ptr, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
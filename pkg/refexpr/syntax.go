@@ -0,0 +1,19 @@
+package refexpr
+
+import (
+	"regexp"
+)
+
+var (
+	// Reference matches uses of references in strings of object and plugin specs, e.g. "!{dbHost}". The first
+	// submatch is the leading exclamation marks and the second one is the contents of the braces.
+	// ?s allows us to match multiline expressions.
+	Reference = regexp.MustCompile(`(?s)^(!+)\{(.+)}$`)
+	// InlineReference matches contents of uses of references that point at fields of dependencies directly, in the
+	// "<resource>#<path>" and "<resource>:<modifier>#<path>" forms, e.g. "svc#$.status.loadBalancer.ingress[0].ip".
+	InlineReference = regexp.MustCompile(`(?s)^([-A-Za-z0-9_.]+)(?::([A-Za-z]+))?#(.+)$`)
+	// ParameterReference matches references to parameters of the Bundle in strings, e.g. ${params.environment}.
+	// The submatch is the name of the parameter. A reference prefixed with another $ is escaped and is replaced
+	// with the reference itself.
+	ParameterReference = regexp.MustCompile(`\$?\$\{params\.([^}]*)}`)
+)
@@ -0,0 +1,193 @@
+package resources
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/pkg/errors"
+	apiext_v1b1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// BundleSchemaID is the identifier of the JSON schema of Bundle manifests.
+const BundleSchemaID = "https://smith.atlassian.com/schemas/bundle/v1/bundle.json"
+
+const referenceSyntax = `String values of the form "!{<reference name>}" are replaced with values of references of the resource. ` +
+	`"{{<resource>#<path>}}" is a shorthand for a reference to a path in the object of another resource.`
+
+var runtimeObjectType = reflect.TypeOf((*runtime.Object)(nil)).Elem()
+
+// webhookProperties are properties that are not fields of the Go types but are accepted by the defaulting webhook.
+var webhookProperties = map[reflect.Type]map[string]interface{}{
+	reflect.TypeOf(smith_v1.Resource{}): {
+		"dependsOn": map[string]interface{}{
+			"description": "Resources this resource depends on. Turned into nameless references by the defaulting webhook",
+			"type":        "array",
+			"items":       map[string]interface{}{"type": "string"},
+		},
+	},
+}
+
+// BundleJSONSchema returns the JSON schema of Bundle manifests, suitable for validation of manifests in editors.
+// The structure of the schema is derived from the Go types, constraints and descriptions are taken from the validation
+// schema of the Bundle CRD. An error is returned if the two have diverged.
+func BundleJSONSchema() ([]byte, error) {
+	crdSchema := BundleCrd().Spec.Validation.OpenAPIV3Schema
+	specCrdSchema := crdSchema.Properties["spec"]
+	spec, err := typeSchema(reflect.TypeOf(smith_v1.BundleSpec{}), &specCrdSchema, "spec")
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := schemaMap(objectMetaSchema(&specCrdSchema))
+	if err != nil {
+		return nil, err
+	}
+	schema := map[string]interface{}{
+		"$schema":     "http://json-schema.org/draft-04/schema#",
+		"id":          BundleSchemaID,
+		"title":       smith_v1.BundleResourceKind,
+		"description": "Bundle is a set of resources that Smith manages together.",
+		"type":        "object",
+		"required":    []string{"apiVersion", "kind", "metadata", "spec"},
+		"properties": map[string]interface{}{
+			"apiVersion": map[string]interface{}{"enum": []string{smith_v1.BundleResourceGroupVersion}},
+			"kind":       map[string]interface{}{"enum": []string{smith_v1.BundleResourceKind}},
+			"metadata":   metadata,
+			"spec":       spec,
+			"status": map[string]interface{}{
+				"description": "Status is set by Smith",
+				"type":        "object",
+			},
+		},
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err = enc.Encode(schema); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal Bundle schema into JSON")
+	}
+	return buf.Bytes(), nil
+}
+
+// objectMetaSchema returns the schema of metadata of objects in the Bundle CRD schema. It is used for the
+// metadata of the Bundle itself.
+func objectMetaSchema(specCrdSchema *apiext_v1b1.JSONSchemaProps) *apiext_v1b1.JSONSchemaProps {
+	resourceSpec := specCrdSchema.Properties["resources"].Items.Schema.Properties["spec"]
+	for _, alternative := range resourceSpec.OneOf {
+		if object, ok := alternative.Properties["object"]; ok {
+			metadata := object.Properties["metadata"]
+			return &metadata
+		}
+	}
+	return nil
+}
+
+// typeSchema returns the schema of values of the Go type merged with its schema in the CRD, which may be nil.
+// Structs do not allow properties that are not fields of the type so that typos are reported.
+func typeSchema(t reflect.Type, crdSchema *apiext_v1b1.JSONSchemaProps, path string) (map[string]interface{}, error) {
+	schema, err := schemaMap(crdSchema)
+	if err != nil {
+		return nil, err
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		schema["type"] = "string"
+	case reflect.Bool:
+		schema["type"] = "boolean"
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		schema["type"] = "integer"
+	case reflect.Interface:
+		// Arbitrary JSON or an object, the CRD schema describes what is expected
+		if t == runtimeObjectType {
+			schema["description"] = appendSentence(schema["description"], referenceSyntax)
+		}
+	case reflect.Slice:
+		var itemsCrdSchema *apiext_v1b1.JSONSchemaProps
+		if crdSchema != nil && crdSchema.Items != nil {
+			itemsCrdSchema = crdSchema.Items.Schema
+		}
+		items, err := typeSchema(t.Elem(), itemsCrdSchema, path+"[]")
+		if err != nil {
+			return nil, err
+		}
+		schema["type"] = "array"
+		schema["items"] = items
+	case reflect.Map:
+		schema["type"] = "object"
+		if t.Elem().Kind() == reflect.Interface {
+			schema["description"] = appendSentence(schema["description"], referenceSyntax)
+		} else {
+			values, err := typeSchema(t.Elem(), nil, path+"{}")
+			if err != nil {
+				return nil, err
+			}
+			schema["additionalProperties"] = values
+		}
+	case reflect.Struct:
+		var crdProperties map[string]apiext_v1b1.JSONSchemaProps
+		if crdSchema != nil {
+			crdProperties = crdSchema.Properties
+		}
+		properties := make(map[string]interface{}, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if name == "" || name == "-" {
+				continue
+			}
+			var fieldCrdSchema *apiext_v1b1.JSONSchemaProps
+			if s, ok := crdProperties[name]; ok {
+				fieldCrdSchema = &s
+			}
+			property, err := typeSchema(field.Type, fieldCrdSchema, path+"."+name)
+			if err != nil {
+				return nil, err
+			}
+			properties[name] = property
+		}
+		for name, property := range webhookProperties[t] {
+			properties[name] = property
+		}
+		for name := range crdProperties {
+			if _, ok := properties[name]; !ok {
+				return nil, errors.Errorf("%s.%s is in the CRD schema but there is no such field in %s", path, name, t)
+			}
+		}
+		schema["type"] = "object"
+		schema["properties"] = properties
+		schema["additionalProperties"] = false
+	default:
+		return nil, errors.Errorf("%s: unsupported type %s", path, t)
+	}
+	return schema, nil
+}
+
+// schemaMap converts the CRD schema into a map. Returns an empty map if the schema is nil.
+func schemaMap(crdSchema *apiext_v1b1.JSONSchemaProps) (map[string]interface{}, error) {
+	schema := make(map[string]interface{})
+	if crdSchema == nil {
+		return schema, nil
+	}
+	data, err := json.Marshal(crdSchema)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal CRD schema into JSON")
+	}
+	if err = json.Unmarshal(data, &schema); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal CRD schema from JSON")
+	}
+	return schema, nil
+}
+
+func appendSentence(description interface{}, sentence string) string {
+	if d, ok := description.(string); ok && d != "" {
+		return d + ". " + sentence
+	}
+	return sentence
+}
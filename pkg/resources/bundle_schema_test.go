@@ -0,0 +1,103 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+const validBundle = `{
+  "apiVersion": "smith.atlassian.com/v1",
+  "kind": "Bundle",
+  "metadata": {"name": "bundle1"},
+  "spec": {
+    "progressDeadlineSeconds": 600,
+    "resources": [
+      {
+        "name": "config",
+        "updateStrategy": "patch",
+        "spec": {
+          "object": {
+            "apiVersion": "v1",
+            "kind": "ConfigMap",
+            "metadata": {"name": "config"},
+            "data": {"password": "!{password}"}
+          }
+        },
+        "references": [
+          {"name": "password", "source": "vault:secret/db#password"}
+        ]
+      },
+      {
+        "name": "app",
+        "dependsOn": ["config"],
+        "spec": {
+          "plugin": {
+            "name": "app-plugin",
+            "objectName": "app",
+            "spec": {"config": "{{config#metadata.name}}"}
+          }
+        }
+      }
+    ]
+  }
+}`
+
+func validateBundle(t *testing.T, bundle string) *gojsonschema.Result {
+	schema, err := BundleJSONSchema()
+	require.NoError(t, err)
+	result, err := gojsonschema.Validate(gojsonschema.NewBytesLoader(schema), gojsonschema.NewStringLoader(bundle))
+	require.NoError(t, err)
+	return result
+}
+
+func TestBundleJSONSchemaValid(t *testing.T) {
+	t.Parallel()
+	result := validateBundle(t, validBundle)
+	assert.True(t, result.Valid(), "%v", result.Errors())
+}
+
+func TestBundleJSONSchemaInvalid(t *testing.T) {
+	t.Parallel()
+	testcases := map[string]string{
+		"unknown field": `{
+  "apiVersion": "smith.atlassian.com/v1",
+  "kind": "Bundle",
+  "metadata": {"name": "bundle1"},
+  "spec": {"resources": [{"name": "a", "refrences": [], "spec": {"plugin": {"name": "p", "objectName": "a"}}}]}
+}`,
+		"invalid source": `{
+  "apiVersion": "smith.atlassian.com/v1",
+  "kind": "Bundle",
+  "metadata": {"name": "bundle1"},
+  "spec": {"resources": [{"name": "a", "references": [{"name": "r", "source": "secret"}], "spec": {"plugin": {"name": "p", "objectName": "a"}}}]}
+}`,
+		"invalid update strategy": `{
+  "apiVersion": "smith.atlassian.com/v1",
+  "kind": "Bundle",
+  "metadata": {"name": "bundle1"},
+  "spec": {"resources": [{"name": "a", "updateStrategy": "delete", "spec": {"plugin": {"name": "p", "objectName": "a"}}}]}
+}`,
+		"neither object nor plugin": `{
+  "apiVersion": "smith.atlassian.com/v1",
+  "kind": "Bundle",
+  "metadata": {"name": "bundle1"},
+  "spec": {"resources": [{"name": "a", "spec": {}}]}
+}`,
+		"wrong kind": `{
+  "apiVersion": "smith.atlassian.com/v1",
+  "kind": "BundleClass",
+  "metadata": {"name": "bundle1"},
+  "spec": {}
+}`,
+	}
+	for name, bundle := range testcases {
+		bundle := bundle
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.False(t, validateBundle(t, bundle).Valid())
+		})
+	}
+}
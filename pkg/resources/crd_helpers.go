@@ -143,10 +143,23 @@ func BundleCrd() *apiext_v1b1.CustomResourceDefinition {
 			},
 		},
 	}
+	externalObject := apiext_v1b1.JSONSchemaProps{
+		Description: "Schema for a resource that waits for an object Smith does not create",
+		Type:        "object",
+		Required:    []string{"apiVersion", "kind", "name"},
+		Properties: map[string]apiext_v1b1.JSONSchemaProps{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+			"name":       DNS_SUBDOMAIN,
+			"readyWhen": {
+				Description: "Readiness expression of the form <JSONPath> == <value> or <JSONPath> != <value>",
+				Type:        "string",
+			},
+		},
+	}
 	reference := apiext_v1b1.JSONSchemaProps{
-		Description: "A reference to a path in another resource",
+		Description: "A reference to a path in another resource or to a secret in an external secret store",
 		Type:        "object",
-		Required:    []string{"resource"},
 		Properties: map[string]apiext_v1b1.JSONSchemaProps{
 			"name":     DNS_SUBDOMAIN,
 			"resource": resourceName,
@@ -158,6 +171,55 @@ func BundleCrd() *apiext_v1b1.CustomResourceDefinition {
 				Description: "JSONPath expression used to extract data from resource",
 				Type:        "string",
 			},
+			"source": {
+				Description: "Secret in an external secret store in the <provider>:<name>[#<key>] form",
+				Type:        "string",
+				Pattern:     `^[a-z0-9]+:.+$`,
+			},
+		},
+	}
+	quorum := apiext_v1b1.JSONSchemaProps{
+		Description: "A group of resources where only some of them need to be ready",
+		Type:        "object",
+		Required:    []string{"resources", "minReady"},
+		Properties: map[string]apiext_v1b1.JSONSchemaProps{
+			"resources": {
+				Type: "array",
+				Items: &apiext_v1b1.JSONSchemaPropsOrArray{
+					Schema: &resourceName,
+				},
+			},
+			"minReady": {
+				Type:    "integer",
+				Minimum: float64ptr(1),
+			},
+		},
+	}
+	output := apiext_v1b1.JSONSchemaProps{
+		Description: "Output publishes the value of a field of the object of a resource in the status of the Bundle",
+		Type:        "object",
+		Required:    []string{"name", "resource", "path"},
+		Properties: map[string]apiext_v1b1.JSONSchemaProps{
+			"name": {
+				Type:      "string",
+				MinLength: int64ptr(1),
+			},
+			"resource": resourceName,
+			"path": {
+				Type:      "string",
+				MinLength: int64ptr(1),
+			},
+		},
+	}
+	keyReference := apiext_v1b1.JSONSchemaProps{
+		Type:     "object",
+		Required: []string{"name", "key"},
+		Properties: map[string]apiext_v1b1.JSONSchemaProps{
+			"name": DNS_SUBDOMAIN,
+			"key": {
+				Type:      "string",
+				MinLength: int64ptr(1),
+			},
 		},
 	}
 	resource := apiext_v1b1.JSONSchemaProps{
@@ -172,6 +234,92 @@ func BundleCrd() *apiext_v1b1.CustomResourceDefinition {
 					Schema: &reference,
 				},
 			},
+			"quorums": {
+				Type: "array",
+				Items: &apiext_v1b1.JSONSchemaPropsOrArray{
+					Schema: &quorum,
+				},
+			},
+			"metadataPolicy": {
+				Description: "MetadataPolicy customizes metadata that Smith sets on the object",
+				Type:        "object",
+				Properties: map[string]apiext_v1b1.JSONSchemaProps{
+					"skipReferenceOwnerReferences": {
+						Type: "boolean",
+					},
+					"blockOwnerDeletion": {
+						Type: "boolean",
+					},
+					"finalizers": {
+						Type: "array",
+						Items: &apiext_v1b1.JSONSchemaPropsOrArray{
+							Schema: &apiext_v1b1.JSONSchemaProps{
+								Type: "string",
+							},
+						},
+					},
+				},
+			},
+			"updateStrategy": {
+				Description: "UpdateStrategy is how the object is updated when it differs from the spec",
+				Type:        "string",
+				Enum: []apiext_v1b1.JSON{
+					{Raw: []byte(`"replace"`)},
+					{Raw: []byte(`"patch"`)},
+					{Raw: []byte(`"merge"`)},
+				},
+			},
+			"ignoreFields": {
+				Description: "Paths of fields of the object that are ignored when it is compared with the spec",
+				Type:        "array",
+				Items: &apiext_v1b1.JSONSchemaPropsOrArray{
+					Schema: &apiext_v1b1.JSONSchemaProps{
+						Type:      "string",
+						MinLength: int64ptr(1),
+					},
+				},
+			},
+			"deletionPolicy": {
+				Description: "DeletionPolicy is what happens to the object when the resource is removed from the Bundle or the Bundle is deleted",
+				Type:        "string",
+				Enum: []apiext_v1b1.JSON{
+					{Raw: []byte(`"Delete"`)},
+					{Raw: []byte(`"Orphan"`)},
+					{Raw: []byte(`"Retain"`)},
+				},
+			},
+			"adopt": {
+				Description: "Take control of an existing object that does not have a controller",
+				Type:        "boolean",
+			},
+			"namespace": {
+				Description: "Namespace of the object. Defaults to the namespace of the Bundle",
+				Type:        "string",
+				MinLength:   int64ptr(1),
+				MaxLength:   int64ptr(63),
+				Pattern:     `^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`,
+			},
+			"readinessTimeoutSeconds": {
+				Description: "Number of seconds the object may stay not ready before the resource is put into the Error state",
+				Type:        "integer",
+				Minimum:     float64ptr(1),
+			},
+			"specFrom": {
+				Description: "ConfigMap or Secret in the namespace of the Bundle that holds the manifest of the object",
+				Type:        "object",
+				OneOf: []apiext_v1b1.JSONSchemaProps{
+					{
+						Required: []string{"configMapKeyRef"},
+					},
+					{
+						Required: []string{"secretKeyRef"},
+					},
+				},
+				Properties: map[string]apiext_v1b1.JSONSchemaProps{
+					"configMapKeyRef": keyReference,
+					"secretKeyRef":    keyReference,
+				},
+			},
 			"spec": {
 				Type: "object",
 				OneOf: []apiext_v1b1.JSONSchemaProps{
@@ -187,11 +335,40 @@ func BundleCrd() *apiext_v1b1.CustomResourceDefinition {
 							"plugin": pluginSpec,
 						},
 					},
+					{
+						Required: []string{"external"},
+						Properties: map[string]apiext_v1b1.JSONSchemaProps{
+							"external": externalObject,
+						},
+					},
+					{
+						Description:   "Empty if specFrom is set",
+						MaxProperties: int64ptr(0),
+					},
 				},
 			},
 		},
 	}
 
+	identityPolicy := apiext_v1b1.JSONSchemaProps{
+		Description: "IdentityPolicy describes annotations to be set on all objects of matching API groups",
+		Type:        "object",
+		Required:    []string{"groups", "annotations"},
+		Properties: map[string]apiext_v1b1.JSONSchemaProps{
+			"groups": {
+				Type: "array",
+				Items: &apiext_v1b1.JSONSchemaPropsOrArray{
+					Schema: &apiext_v1b1.JSONSchemaProps{
+						Type: "string",
+					},
+				},
+			},
+			"annotations": {
+				Type: "object",
+			},
+		},
+	}
+
 	return &apiext_v1b1.CustomResourceDefinition{
 		TypeMeta: meta_v1.TypeMeta{
 			Kind:       "CustomResourceDefinition",
@@ -221,6 +398,89 @@ func BundleCrd() *apiext_v1b1.CustomResourceDefinition {
 										Schema: &resource,
 									},
 								},
+								"identityPolicies": {
+									Type: "array",
+									Items: &apiext_v1b1.JSONSchemaPropsOrArray{
+										Schema: &identityPolicy,
+									},
+								},
+								"progressDeadlineSeconds": {
+									Description: "Number of seconds resources of the Bundle may stay not ready before the Bundle is considered timed out",
+									Type:        "integer",
+									Minimum:     float64ptr(1),
+								},
+								"retryPolicy": {
+									Description: "RetryPolicy customizes how processing of the Bundle is retried after it has failed with a retriable error",
+									Type:        "object",
+									Properties: map[string]apiext_v1b1.JSONSchemaProps{
+										"maxAttempts": {
+											Type:    "integer",
+											Minimum: float64ptr(0),
+										},
+										"baseDelaySeconds": {
+											Type:    "integer",
+											Minimum: float64ptr(1),
+										},
+										"maxDelaySeconds": {
+											Type:    "integer",
+											Minimum: float64ptr(1),
+										},
+										"retryOn": {
+											Type: "array",
+											Items: &apiext_v1b1.JSONSchemaPropsOrArray{
+												Schema: &apiext_v1b1.JSONSchemaProps{
+													Type: "string",
+													Enum: []apiext_v1b1.JSON{
+														{Raw: []byte(`"ResourceError"`)},
+														{Raw: []byte(`"ExternalReference"`)},
+														{Raw: []byte(`"Pruning"`)},
+													},
+												},
+											},
+										},
+									},
+								},
+								"parameters": {
+									Description: "Parameters substituted for ${params.<name>} references in object and plugin specs of resources",
+									Type:        "object",
+									// TODO there is a bug in marshling/unmarshaling of AdditionalProperties
+									//AdditionalProperties: &apiext_v1b1.JSONSchemaPropsOrBool{
+									//	Schema: &apiext_v1b1.JSONSchemaProps{
+									//		Type: "string",
+									//	},
+									//},
+								},
+								"outputs": {
+									Type: "array",
+									Items: &apiext_v1b1.JSONSchemaPropsOrArray{
+										Schema: &output,
+									},
+								},
+								"maxHistory": {
+									Description: "Number of BundleRevisions kept for the Bundle",
+									Type:        "integer",
+									Minimum:     float64ptr(0),
+								},
+								"historyTTLSeconds": {
+									Description: "Number of seconds a BundleRevision is kept for after its spec last made the Bundle ready",
+									Type:        "integer",
+									Minimum:     float64ptr(0),
+								},
+								"outputsExport": {
+									Description: "OutputsExport publishes the outputs of the Bundle in a ConfigMap or a Secret",
+									Type:        "object",
+									Required:    []string{"kind"},
+									Properties: map[string]apiext_v1b1.JSONSchemaProps{
+										"kind": {
+											Type: "string",
+											Enum: []apiext_v1b1.JSON{
+												{Raw: []byte(`"ConfigMap"`)},
+												{Raw: []byte(`"Secret"`)},
+											},
+										},
+										"name": DNS_SUBDOMAIN,
+									},
+								},
 							},
 						},
 					},
@@ -230,10 +490,195 @@ func BundleCrd() *apiext_v1b1.CustomResourceDefinition {
 	}
 }
 
+// PrinterColumn describes a column shown by "kubectl get" for a CRD.
+// apiextensions types Smith is built with do not have the additionalPrinterColumns field yet, hence this type.
+type PrinterColumn struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Format      string `json:"format,omitempty"`
+	Description string `json:"description,omitempty"`
+	Priority    int32  `json:"priority,omitempty"`
+	JSONPath    string `json:"JSONPath"`
+}
+
+// BundlePrinterColumns returns additional printer columns of the Bundle CRD.
+func BundlePrinterColumns() []PrinterColumn {
+	return []PrinterColumn{
+		{
+			Name:        "Ready",
+			Type:        "string",
+			Description: "Status of the Ready condition",
+			JSONPath:    ".status.ready",
+		},
+		{
+			Name:        "Resources",
+			Type:        "string",
+			Description: "Number of ready resources out of the total",
+			JSONPath:    ".status.resources",
+		},
+		{
+			Name:     "Age",
+			Type:     "date",
+			JSONPath: ".metadata.creationTimestamp",
+		},
+		{
+			Name:        "Summary",
+			Type:        "string",
+			Description: "Summary of the state of the Bundle",
+			Priority:    1,
+			JSONPath:    ".status.summary",
+		},
+		{
+			Name:        "LastError",
+			Type:        "string",
+			Description: "Message of the Error condition",
+			Priority:    1,
+			JSONPath:    `.status.conditions[?(@.type=="Error")].message`,
+		},
+	}
+}
+
+func BundleClassCrd() *apiext_v1b1.CustomResourceDefinition {
+	bundleSpec := BundleCrd().Spec.Validation.OpenAPIV3Schema.Properties["spec"]
+	labelSelector := apiext_v1b1.JSONSchemaProps{
+		Description: "Label selector for namespaces",
+		Type:        "object",
+		Properties: map[string]apiext_v1b1.JSONSchemaProps{
+			"matchLabels": {
+				Type: "object",
+			},
+			"matchExpressions": {
+				Type: "array",
+				Items: &apiext_v1b1.JSONSchemaPropsOrArray{
+					Schema: &apiext_v1b1.JSONSchemaProps{
+						Type:     "object",
+						Required: []string{"key", "operator"},
+						Properties: map[string]apiext_v1b1.JSONSchemaProps{
+							"key": {
+								Type: "string",
+							},
+							"operator": {
+								Type: "string",
+								Enum: []apiext_v1b1.JSON{
+									{Raw: []byte(`"In"`)},
+									{Raw: []byte(`"NotIn"`)},
+									{Raw: []byte(`"Exists"`)},
+									{Raw: []byte(`"DoesNotExist"`)},
+								},
+							},
+							"values": {
+								Type: "array",
+								Items: &apiext_v1b1.JSONSchemaPropsOrArray{
+									Schema: &apiext_v1b1.JSONSchemaProps{
+										Type: "string",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	return &apiext_v1b1.CustomResourceDefinition{
+		TypeMeta: meta_v1.TypeMeta{
+			Kind:       "CustomResourceDefinition",
+			APIVersion: apiext_v1b1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name: smith_v1.BundleClassResourceName,
+		},
+		Spec: apiext_v1b1.CustomResourceDefinitionSpec{
+			Group:   smith.GroupName,
+			Version: smith_v1.BundleResourceVersion,
+			Names: apiext_v1b1.CustomResourceDefinitionNames{
+				Plural:   smith_v1.BundleClassResourcePlural,
+				Singular: smith_v1.BundleClassResourceSingular,
+				Kind:     smith_v1.BundleClassResourceKind,
+			},
+			Scope: apiext_v1b1.ClusterScoped,
+			Validation: &apiext_v1b1.CustomResourceValidation{
+				OpenAPIV3Schema: &apiext_v1b1.JSONSchemaProps{
+					Properties: map[string]apiext_v1b1.JSONSchemaProps{
+						"spec": {
+							Type:     "object",
+							Required: []string{"namespaceSelector", "template"},
+							Properties: map[string]apiext_v1b1.JSONSchemaProps{
+								"namespaceSelector": labelSelector,
+								"template": {
+									Type:     "object",
+									Required: []string{"spec"},
+									Properties: map[string]apiext_v1b1.JSONSchemaProps{
+										"labels": {
+											Type: "object",
+										},
+										"annotations": {
+											Type: "object",
+										},
+										"spec": bundleSpec,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func BundleRevisionCrd() *apiext_v1b1.CustomResourceDefinition {
+	bundleSpec := BundleCrd().Spec.Validation.OpenAPIV3Schema.Properties["spec"]
+	return &apiext_v1b1.CustomResourceDefinition{
+		TypeMeta: meta_v1.TypeMeta{
+			Kind:       "CustomResourceDefinition",
+			APIVersion: apiext_v1b1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name: smith_v1.BundleRevisionResourceName,
+		},
+		Spec: apiext_v1b1.CustomResourceDefinitionSpec{
+			Group:   smith.GroupName,
+			Version: smith_v1.BundleResourceVersion,
+			Names: apiext_v1b1.CustomResourceDefinitionNames{
+				Plural:   smith_v1.BundleRevisionResourcePlural,
+				Singular: smith_v1.BundleRevisionResourceSingular,
+				Kind:     smith_v1.BundleRevisionResourceKind,
+			},
+			Scope: apiext_v1b1.NamespaceScoped,
+			Validation: &apiext_v1b1.CustomResourceValidation{
+				OpenAPIV3Schema: &apiext_v1b1.JSONSchemaProps{
+					Required: []string{"revision", "hash", "spec"},
+					Properties: map[string]apiext_v1b1.JSONSchemaProps{
+						"revision": {
+							Type:    "integer",
+							Minimum: float64ptr(1),
+						},
+						"hash": {
+							Type:      "string",
+							MinLength: int64ptr(1),
+						},
+						"readyTime": {
+							Type:   "string",
+							Format: "date-time",
+						},
+						"spec": bundleSpec,
+					},
+				},
+			},
+		},
+	}
+}
+
 func int64ptr(val int64) *int64 {
 	return &val
 }
 
+func float64ptr(val float64) *float64 {
+	return &val
+}
+
 func EnsureCrdExistsAndIsEstablished(ctx context.Context, logger *zap.Logger, apiExtClient apiExtClientset.Interface, crdLister apiext_lst_v1b1.CustomResourceDefinitionLister, crd *apiext_v1b1.CustomResourceDefinition) error {
 	err := EnsureCrdExists(ctx, logger, apiExtClient, crdLister, crd)
 	if err != nil {
@@ -0,0 +1,193 @@
+package secretstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	k8s_json "k8s.io/apimachinery/pkg/util/json"
+)
+
+const (
+	// AWSSecretsManagerProviderName is the name sources use to reference secrets in AWS Secrets Manager,
+	// e.g. awssm:prod/db#password.
+	AWSSecretsManagerProviderName = "awssm"
+
+	awsSecretsManagerService = "secretsmanager"
+	awsSigningAlgorithm      = "AWS4-HMAC-SHA256"
+	awsDateFormat            = "20060102T150405Z"
+)
+
+// AWSCredentials are used to sign requests to AWS.
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken of temporary credentials. Optional.
+	SessionToken string
+}
+
+// AWSSecretsManagerProvider reads secrets from AWS Secrets Manager.
+// Names are names or ARNs of secrets. Secrets with a JSON object as the value can be referenced by key, other secrets
+// can only be referenced as a whole.
+type AWSSecretsManagerProvider struct {
+	Region string
+	// Endpoint overrides the endpoint of the region. Optional.
+	Endpoint    string
+	Credentials AWSCredentials
+	Client      *http.Client
+	now         func() time.Time
+}
+
+type getSecretValueResponse struct {
+	SecretString *string `json:"SecretString"`
+	SecretBinary []byte  `json:"SecretBinary"`
+}
+
+type awsErrorResponse struct {
+	Type    string `json:"__type"`
+	Message string `json:"message"`
+}
+
+func (p *AWSSecretsManagerProvider) Read(ctx context.Context, name string) (*Secret, error) {
+	body, err := k8s_json.Marshal(map[string]interface{}{
+		"SecretId": name,
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	endpoint := p.Endpoint
+	if endpoint == "" {
+		endpoint = "https://" + awsSecretsManagerService + "." + p.Region + ".amazonaws.com/"
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	now := time.Now
+	if p.now != nil {
+		now = p.now
+	}
+	signAWSRequest(req, body, p.Region, awsSecretsManagerService, p.Credentials, now())
+
+	respBody, status, err := doRequest(p.Client, req)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		var errResp awsErrorResponse
+		if err = k8s_json.Unmarshal(respBody, &errResp); err != nil || errResp.Type == "" {
+			return nil, errors.Errorf("unexpected status code %d", status)
+		}
+		// Type may be prefixed with a namespace, e.g. "com.amazonaws#ResourceNotFoundException"
+		errType := errResp.Type[strings.LastIndex(errResp.Type, "#")+1:]
+		return nil, errors.Errorf("unexpected status code %d: %s: %s", status, errType, errResp.Message)
+	}
+	var resp getSecretValueResponse
+	if err = k8s_json.Unmarshal(respBody, &resp); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal response")
+	}
+	var value string
+	switch {
+	case resp.SecretString != nil:
+		value = *resp.SecretString
+	case resp.SecretBinary != nil:
+		if !utf8.Valid(resp.SecretBinary) {
+			return nil, errors.New("cannot use non-UTF8 binary secret")
+		}
+		value = string(resp.SecretBinary)
+	default:
+		return nil, errors.New("response has no secret value")
+	}
+	var data map[string]interface{}
+	if err = k8s_json.Unmarshal([]byte(value), &data); err != nil || data == nil {
+		// Not a JSON object, can only be referenced as a whole
+		return &Secret{
+			Value: value,
+		}, nil
+	}
+	return &Secret{
+		Value: data,
+	}, nil
+}
+
+// signAWSRequest signs the request using Signature Version 4.
+// See https://docs.aws.amazon.com/general/latest/gr/sigv4_signing.html
+func signAWSRequest(req *http.Request, body []byte, region, service string, creds AWSCredentials, now time.Time) {
+	amzDate := now.UTC().Format(awsDateFormat)
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headers := map[string]string{
+		"host": req.URL.Host,
+	}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		hexSHA256(body),
+	}, "\n")
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := strings.Join([]string{
+		awsSigningAlgorithm,
+		amzDate,
+		scope,
+		hexSHA256([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := awsSigningKey(creds.SecretAccessKey, date, region, service)
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", awsSigningAlgorithm+" Credential="+creds.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func awsSigningKey(secretAccessKey, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func hexSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
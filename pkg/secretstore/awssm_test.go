@@ -0,0 +1,84 @@
+package secretstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testAWSCredentials = AWSCredentials{
+	AccessKeyID:     "AKIDEXAMPLE",
+	SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+}
+
+func TestAWSSigningKey(t *testing.T) {
+	t.Parallel()
+	// Example from https://docs.aws.amazon.com/general/latest/gr/signature-v4-examples.html
+	key := awsSigningKey(testAWSCredentials.SecretAccessKey, "20150830", "us-east-1", "iam")
+	assert.Equal(t, "c4afb1cc5771d871763a393e44b703571b55cc28424d1a5e86da6ed3c154a4b9", hex.EncodeToString(key))
+}
+
+func TestSignAWSRequest(t *testing.T) {
+	t.Parallel()
+	body := []byte(`{"SecretId":"prod/db"}`)
+	req, err := http.NewRequest(http.MethodPost, "https://secretsmanager.us-east-1.amazonaws.com/", bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+
+	signAWSRequest(req, body, "us-east-1", "secretsmanager", testAWSCredentials, time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	assert.Equal(t, "20150830T123600Z", req.Header.Get("X-Amz-Date"))
+	assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/secretsmanager/aws4_request, "+
+		"SignedHeaders=content-type;host;x-amz-date;x-amz-target, "+
+		"Signature=eabee390746276231c94d4b6d3fe482aa5b5e66bf755866681a3cbf97b3b9645", req.Header.Get("Authorization"))
+}
+
+func TestAWSSecretsManagerRead(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secretsmanager.GetSecretValue", r.Header.Get("X-Amz-Target"))
+		assert.Equal(t, "token1", r.Header.Get("X-Amz-Security-Token"))
+		assert.Contains(t, r.Header.Get("Authorization"), "x-amz-security-token")
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		switch string(body) {
+		case `{"SecretId":"prod/db"}`:
+			w.Write([]byte(`{"Name":"prod/db","SecretString":"{\"password\":\"pass1\"}"}`))
+		case `{"SecretId":"prod/token"}`:
+			w.Write([]byte(`{"Name":"prod/token","SecretBinary":"dG9rZW4x"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"ResourceNotFoundException","message":"Secrets Manager can't find the specified secret."}`))
+		}
+	}))
+	defer srv.Close()
+	creds := testAWSCredentials
+	creds.SessionToken = "token1"
+	p := &AWSSecretsManagerProvider{
+		Region:      "us-east-1",
+		Endpoint:    srv.URL,
+		Credentials: creds,
+		Client:      srv.Client(),
+	}
+
+	secret, err := p.Read(context.Background(), "prod/db")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"password": "pass1"}, secret.Value)
+
+	secret, err = p.Read(context.Background(), "prod/token")
+	require.NoError(t, err)
+	assert.Equal(t, "token1", secret.Value)
+
+	_, err = p.Read(context.Background(), "prod/missing")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ResourceNotFoundException")
+}
@@ -0,0 +1,194 @@
+package secretstore
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Secret is a secret read from an external secret store.
+type Secret struct {
+	// Value of the secret. Usually a map of keys to values.
+	Value interface{}
+	// LeaseID identifies the lease of the secret. Empty if the secret is not leased.
+	LeaseID string
+	// LeaseDuration is how long the secret is valid for. Zero if the store did not say.
+	LeaseDuration time.Duration
+	// Renewable is true if the lease can be renewed.
+	Renewable bool
+}
+
+// Provider reads secrets from an external secret store.
+type Provider interface {
+	Read(ctx context.Context, name string) (*Secret, error)
+}
+
+// Renewer is implemented by Providers that can renew leases of secrets.
+type Renewer interface {
+	// Renew renews the lease of the secret and returns the secret with the new lease.
+	Renew(ctx context.Context, secret *Secret) (*Secret, error)
+}
+
+// Source is a parsed reference to a secret in an external secret store.
+type Source struct {
+	// Provider is the name of the provider, e.g. vault.
+	Provider string
+	// Name of the secret in the store, e.g. secret/db.
+	Name string
+	// Key in the secret. Empty if the whole secret is referenced.
+	Key string
+}
+
+func (s Source) String() string {
+	if s.Key == "" {
+		return s.Provider + ":" + s.Name
+	}
+	return s.Provider + ":" + s.Name + "#" + s.Key
+}
+
+// ParseSource parses a source in the "<provider>:<name>[#<key>]" form.
+func ParseSource(source string) (Source, error) {
+	i := strings.Index(source, ":")
+	if i <= 0 {
+		return Source{}, errors.Errorf("source %q must be in the <provider>:<name>[#<key>] form", source)
+	}
+	s := Source{
+		Provider: source[:i],
+		Name:     source[i+1:],
+	}
+	if j := strings.LastIndex(s.Name, "#"); j >= 0 {
+		s.Key = s.Name[j+1:]
+		s.Name = s.Name[:j]
+		if s.Key == "" {
+			return Source{}, errors.Errorf("source %q has an empty key", source)
+		}
+	}
+	if s.Name == "" {
+		return Source{}, errors.Errorf("source %q has an empty name", source)
+	}
+	return s, nil
+}
+
+type cacheKey struct {
+	provider string
+	name     string
+}
+
+type cacheEntry struct {
+	secret *Secret
+	// renewAt is when the lease should be renewed or the secret re-read.
+	renewAt time.Time
+	// expiresAt is when the lease of the secret expires.
+	expiresAt time.Time
+}
+
+// Resolver resolves sources using a set of providers.
+// Secrets are cached until their lease is about to expire. Renewable leases are renewed when the secret is used after
+// two thirds of the lease have passed, other secrets are read again.
+// Resolver is safe for concurrent use.
+type Resolver struct {
+	providers map[string]Provider
+	// defaultTTL is how long secrets without a lease duration are cached for.
+	defaultTTL time.Duration
+	// timeout of a single request to a provider.
+	timeout time.Duration
+	now     func() time.Time
+
+	mx    sync.Mutex
+	cache map[cacheKey]cacheEntry
+}
+
+// NewResolver returns a new Resolver. Zero defaultTTL disables caching of secrets that are not leased.
+func NewResolver(providers map[string]Provider, defaultTTL, timeout time.Duration) *Resolver {
+	return &Resolver{
+		providers:  providers,
+		defaultTTL: defaultTTL,
+		timeout:    timeout,
+		now:        time.Now,
+		cache:      make(map[cacheKey]cacheEntry),
+	}
+}
+
+// Resolve returns the value the source points at.
+func (r *Resolver) Resolve(source string) (interface{}, error) {
+	s, err := ParseSource(source)
+	if err != nil {
+		return nil, err
+	}
+	provider, ok := r.providers[s.Provider]
+	if !ok {
+		return nil, errors.Errorf("secret store provider %q is not configured", s.Provider)
+	}
+	secret, err := r.get(provider, cacheKey{provider: s.Provider, name: s.Name})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read secret %q from %q", s.Name, s.Provider)
+	}
+	if s.Key == "" {
+		return secret.Value, nil
+	}
+	data, ok := secret.Value.(map[string]interface{})
+	if !ok {
+		return nil, errors.Errorf("secret %q from %q does not have keys", s.Name, s.Provider)
+	}
+	value, ok := data[s.Key]
+	if !ok {
+		return nil, errors.Errorf("secret %q from %q does not have key %q", s.Name, s.Provider, s.Key)
+	}
+	return value, nil
+}
+
+func (r *Resolver) get(provider Provider, key cacheKey) (*Secret, error) {
+	now := r.now()
+	r.mx.Lock()
+	entry, ok := r.cache[key]
+	r.mx.Unlock()
+	if ok && now.Before(entry.renewAt) {
+		return entry.secret, nil
+	}
+
+	ctx, cancel := r.context()
+	defer cancel()
+	var secret *Secret
+	if renewer, isRenewer := provider.(Renewer); ok && isRenewer && entry.secret.Renewable && now.Before(entry.expiresAt) {
+		renewed, err := renewer.Renew(ctx, entry.secret)
+		if err == nil {
+			secret = renewed
+		}
+		// Read the secret again if the lease cannot be renewed
+	}
+	if secret == nil {
+		var err error
+		secret, err = provider.Read(ctx, key.name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	r.store(key, secret, now)
+	return secret, nil
+}
+
+func (r *Resolver) store(key cacheKey, secret *Secret, now time.Time) {
+	entry := cacheEntry{
+		secret: secret,
+	}
+	if secret.LeaseDuration > 0 {
+		entry.expiresAt = now.Add(secret.LeaseDuration)
+		entry.renewAt = now.Add(secret.LeaseDuration * 2 / 3)
+	} else {
+		entry.expiresAt = now.Add(r.defaultTTL)
+		entry.renewAt = entry.expiresAt
+	}
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	r.cache[key] = entry
+}
+
+func (r *Resolver) context() (context.Context, context.CancelFunc) {
+	if r.timeout > 0 {
+		return context.WithTimeout(context.Background(), r.timeout)
+	}
+	return context.WithCancel(context.Background())
+}
@@ -0,0 +1,178 @@
+package secretstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProvider struct {
+	secrets  map[string]*Secret
+	reads    int
+	renewals int
+	renewErr error
+}
+
+func (p *fakeProvider) Read(ctx context.Context, name string) (*Secret, error) {
+	p.reads++
+	secret, ok := p.secrets[name]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return secret, nil
+}
+
+func (p *fakeProvider) Renew(ctx context.Context, secret *Secret) (*Secret, error) {
+	p.renewals++
+	if p.renewErr != nil {
+		return nil, p.renewErr
+	}
+	return secret, nil
+}
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newTestResolver(p Provider, defaultTTL time.Duration) (*Resolver, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)}
+	r := NewResolver(map[string]Provider{"fake": p}, defaultTTL, time.Second)
+	r.now = clock.Now
+	return r, clock
+}
+
+func TestParseSource(t *testing.T) {
+	t.Parallel()
+	s, err := ParseSource("vault:secret/db#password")
+	require.NoError(t, err)
+	assert.Equal(t, Source{Provider: "vault", Name: "secret/db", Key: "password"}, s)
+	assert.Equal(t, "vault:secret/db#password", s.String())
+
+	s, err = ParseSource("awssm:arn:aws:secretsmanager:us-east-1:123456789012:secret:db")
+	require.NoError(t, err)
+	assert.Equal(t, Source{Provider: "awssm", Name: "arn:aws:secretsmanager:us-east-1:123456789012:secret:db"}, s)
+
+	for _, source := range []string{"", "vault", ":secret/db", "vault:", "vault:#key", "vault:secret/db#"} {
+		_, err = ParseSource(source)
+		assert.Error(t, err, source)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	t.Parallel()
+	p := &fakeProvider{
+		secrets: map[string]*Secret{
+			"db":    {Value: map[string]interface{}{"password": "pass1"}},
+			"token": {Value: "token1"},
+		},
+	}
+	r, _ := newTestResolver(p, time.Minute)
+
+	value, err := r.Resolve("fake:db#password")
+	require.NoError(t, err)
+	assert.Equal(t, "pass1", value)
+
+	value, err = r.Resolve("fake:token")
+	require.NoError(t, err)
+	assert.Equal(t, "token1", value)
+
+	_, err = r.Resolve("fake:db#username")
+	assert.EqualError(t, err, `secret "db" from "fake" does not have key "username"`)
+	_, err = r.Resolve("fake:token#key")
+	assert.EqualError(t, err, `secret "token" from "fake" does not have keys`)
+	_, err = r.Resolve("fake:missing")
+	assert.EqualError(t, err, `failed to read secret "missing" from "fake": not found`)
+	_, err = r.Resolve("other:db")
+	assert.EqualError(t, err, `secret store provider "other" is not configured`)
+}
+
+func TestResolveCachesWithDefaultTTL(t *testing.T) {
+	t.Parallel()
+	p := &fakeProvider{
+		secrets: map[string]*Secret{
+			"db": {Value: map[string]interface{}{"password": "pass1"}},
+		},
+	}
+	r, clock := newTestResolver(p, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		_, err := r.Resolve("fake:db#password")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, p.reads)
+
+	clock.now = clock.now.Add(time.Minute)
+	_, err := r.Resolve("fake:db#password")
+	require.NoError(t, err)
+	assert.Equal(t, 2, p.reads)
+	assert.Zero(t, p.renewals)
+}
+
+func TestResolveWithoutCaching(t *testing.T) {
+	t.Parallel()
+	p := &fakeProvider{
+		secrets: map[string]*Secret{
+			"db": {Value: map[string]interface{}{"password": "pass1"}},
+		},
+	}
+	r, _ := newTestResolver(p, 0)
+
+	for i := 0; i < 3; i++ {
+		_, err := r.Resolve("fake:db#password")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 3, p.reads)
+}
+
+func TestResolveRenewsLeases(t *testing.T) {
+	t.Parallel()
+	p := &fakeProvider{
+		secrets: map[string]*Secret{
+			"creds": {
+				Value:         map[string]interface{}{"password": "pass1"},
+				LeaseID:       "lease1",
+				LeaseDuration: 3 * time.Hour,
+				Renewable:     true,
+			},
+		},
+	}
+	r, clock := newTestResolver(p, 0)
+
+	_, err := r.Resolve("fake:creds#password")
+	require.NoError(t, err)
+	clock.now = clock.now.Add(time.Hour)
+	_, err = r.Resolve("fake:creds#password")
+	require.NoError(t, err)
+	assert.Equal(t, 1, p.reads)
+	assert.Zero(t, p.renewals)
+
+	// Renewed after two thirds of the lease
+	clock.now = clock.now.Add(time.Hour)
+	_, err = r.Resolve("fake:creds#password")
+	require.NoError(t, err)
+	assert.Equal(t, 1, p.reads)
+	assert.Equal(t, 1, p.renewals)
+
+	// Read again if the lease cannot be renewed
+	p.renewErr = errors.New("lease not found")
+	clock.now = clock.now.Add(2 * time.Hour)
+	_, err = r.Resolve("fake:creds#password")
+	require.NoError(t, err)
+	assert.Equal(t, 2, p.reads)
+	assert.Equal(t, 2, p.renewals)
+
+	// Read again if the lease has expired
+	clock.now = clock.now.Add(3 * time.Hour)
+	_, err = r.Resolve("fake:creds#password")
+	require.NoError(t, err)
+	assert.Equal(t, 3, p.reads)
+	assert.Equal(t, 2, p.renewals)
+}
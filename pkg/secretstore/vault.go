@@ -0,0 +1,130 @@
+package secretstore
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	k8s_json "k8s.io/apimachinery/pkg/util/json"
+)
+
+const (
+	// VaultProviderName is the name sources use to reference secrets in Vault, e.g. vault:secret/db#password.
+	VaultProviderName = "vault"
+
+	vaultTokenHeader = "X-Vault-Token"
+	// maxResponseSize is the maximum size of a response from a secret store.
+	maxResponseSize = 1024 * 1024
+)
+
+// VaultProvider reads secrets from HashiCorp Vault using its HTTP API.
+// Both versions of the KV secrets engine and dynamic secrets engines are supported. Names are paths of secrets,
+// for version 2 of the KV engine they include the "data/" segment, e.g. secret/data/db.
+type VaultProvider struct {
+	// Address of the Vault server, e.g. https://vault:8200.
+	Address string
+	// Token is used to authenticate to Vault.
+	Token  string
+	Client *http.Client
+}
+
+type vaultResponse struct {
+	LeaseID       string                 `json:"lease_id"`
+	LeaseDuration int64                  `json:"lease_duration"`
+	Renewable     bool                   `json:"renewable"`
+	Data          map[string]interface{} `json:"data"`
+	Errors        []string               `json:"errors"`
+}
+
+func (p *VaultProvider) Read(ctx context.Context, name string) (*Secret, error) {
+	var resp vaultResponse
+	if err := p.do(ctx, http.MethodGet, strings.TrimPrefix(name, "/"), nil, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Data == nil {
+		return nil, errors.New("response has no data")
+	}
+	var value interface{} = resp.Data
+	// Version 2 of the KV engine wraps the secret together with its metadata
+	if data, ok := resp.Data["data"].(map[string]interface{}); ok {
+		if _, ok := resp.Data["metadata"].(map[string]interface{}); ok {
+			value = data
+		}
+	}
+	return &Secret{
+		Value:         value,
+		LeaseID:       resp.LeaseID,
+		LeaseDuration: time.Duration(resp.LeaseDuration) * time.Second,
+		Renewable:     resp.Renewable,
+	}, nil
+}
+
+func (p *VaultProvider) Renew(ctx context.Context, secret *Secret) (*Secret, error) {
+	if secret.LeaseID == "" {
+		return nil, errors.New("secret is not leased")
+	}
+	body, err := k8s_json.Marshal(map[string]interface{}{
+		"lease_id": secret.LeaseID,
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var resp vaultResponse
+	if err := p.do(ctx, http.MethodPut, "sys/leases/renew", body, &resp); err != nil {
+		return nil, err
+	}
+	return &Secret{
+		Value:         secret.Value,
+		LeaseID:       resp.LeaseID,
+		LeaseDuration: time.Duration(resp.LeaseDuration) * time.Second,
+		Renewable:     resp.Renewable,
+	}, nil
+}
+
+func (p *VaultProvider) do(ctx context.Context, method, path string, body []byte, into *vaultResponse) error {
+	req, err := http.NewRequest(method, strings.TrimSuffix(p.Address, "/")+"/v1/"+path, bytes.NewReader(body))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set(vaultTokenHeader, p.Token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	respBody, status, err := doRequest(p.Client, req)
+	if err != nil {
+		return err
+	}
+	if err = k8s_json.Unmarshal(respBody, into); err != nil && status == http.StatusOK {
+		return errors.Wrap(err, "failed to unmarshal response")
+	}
+	if status != http.StatusOK {
+		if len(into.Errors) > 0 {
+			return errors.Errorf("unexpected status code %d: %s", status, strings.Join(into.Errors, "; "))
+		}
+		return errors.Errorf("unexpected status code %d", status)
+	}
+	return nil
+}
+
+// doRequest sends the request and returns the body and the status code of the response.
+func doRequest(client *http.Client, req *http.Request) ([]byte, int, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "failed to read response")
+	}
+	return body, resp.StatusCode, nil
+}
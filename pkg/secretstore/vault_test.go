@@ -0,0 +1,100 @@
+package secretstore
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newVaultServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(vaultTokenHeader) != "token1" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "GET /v1/secret/db":
+			w.Write([]byte(`{"lease_duration":2764800,"data":{"password":"pass1"}}`))
+		case "GET /v1/secret/data/db":
+			w.Write([]byte(`{"data":{"data":{"password":"pass2"},"metadata":{"version":3}}}`))
+		case "GET /v1/database/creds/app":
+			w.Write([]byte(`{"lease_id":"database/creds/app/1","lease_duration":3600,"renewable":true,"data":{"username":"u1","password":"p1"}}`))
+		case "PUT /v1/sys/leases/renew":
+			body, err := ioutil.ReadAll(r.Body)
+			require.NoError(t, err)
+			assert.JSONEq(t, `{"lease_id":"database/creds/app/1"}`, string(body))
+			w.Write([]byte(`{"lease_id":"database/creds/app/1","lease_duration":1800,"renewable":true}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+		}
+	}))
+}
+
+func TestVaultRead(t *testing.T) {
+	t.Parallel()
+	srv := newVaultServer(t)
+	defer srv.Close()
+	p := &VaultProvider{
+		Address: srv.URL,
+		Token:   "token1",
+		Client:  srv.Client(),
+	}
+
+	secret, err := p.Read(context.Background(), "secret/db")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"password": "pass1"}, secret.Value)
+	assert.Equal(t, 2764800*time.Second, secret.LeaseDuration)
+	assert.False(t, secret.Renewable)
+
+	secret, err = p.Read(context.Background(), "secret/data/db")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"password": "pass2"}, secret.Value)
+
+	_, err = p.Read(context.Background(), "secret/missing")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "404")
+}
+
+func TestVaultRenew(t *testing.T) {
+	t.Parallel()
+	srv := newVaultServer(t)
+	defer srv.Close()
+	p := &VaultProvider{
+		Address: srv.URL,
+		Token:   "token1",
+		Client:  srv.Client(),
+	}
+
+	secret, err := p.Read(context.Background(), "database/creds/app")
+	require.NoError(t, err)
+	assert.Equal(t, "database/creds/app/1", secret.LeaseID)
+	assert.True(t, secret.Renewable)
+
+	renewed, err := p.Renew(context.Background(), secret)
+	require.NoError(t, err)
+	assert.Equal(t, secret.Value, renewed.Value)
+	assert.Equal(t, 1800*time.Second, renewed.LeaseDuration)
+}
+
+func TestVaultPermissionDenied(t *testing.T) {
+	t.Parallel()
+	srv := newVaultServer(t)
+	defer srv.Close()
+	p := &VaultProvider{
+		Address: srv.URL,
+		Token:   "token2",
+		Client:  srv.Client(),
+	}
+
+	_, err := p.Read(context.Background(), "secret/db")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "permission denied")
+}
@@ -0,0 +1,91 @@
+package speccheck
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Drift describes how an object differs from its desired state.
+type Drift struct {
+	// Fields are sorted dot separated paths of fields that differ, e.g. "spec.replicas".
+	// Lists are compared as a whole, the path of a list is reported if any of its elements differ.
+	Fields []string
+	// Patch is a JSON merge patch (RFC 7386) that turns the actual object into the desired one.
+	// May contain sensitive data, e.g. values of a Secret.
+	Patch map[string]interface{}
+}
+
+// ComputeDrift compares the actual object with the desired one, e.g. with the updated object returned by
+// CompareActualVsSpec. Kind, API version and status are ignored. Absent fields, nulls and empty maps and lists
+// are considered equal.
+func ComputeDrift(actual, desired *unstructured.Unstructured) *Drift {
+	drift := &Drift{}
+	drift.Patch = mergePatch(withoutIgnoredFields(actual.Object), withoutIgnoredFields(desired.Object), "", &drift.Fields)
+	sort.Strings(drift.Fields)
+	return drift
+}
+
+func withoutIgnoredFields(obj map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(obj))
+	for field, value := range obj {
+		switch field {
+		case "kind", "apiVersion", "status":
+			continue
+		}
+		result[field] = value
+	}
+	return result
+}
+
+func mergePatch(actual, desired map[string]interface{}, path string, fields *[]string) map[string]interface{} {
+	patch := make(map[string]interface{})
+	for field, desiredValue := range desired {
+		actualValue := actual[field]
+		if isEmpty(actualValue) && isEmpty(desiredValue) {
+			continue
+		}
+		fieldPath := field
+		if path != "" {
+			fieldPath = path + "." + field
+		}
+		actualMap, actualIsMap := actualValue.(map[string]interface{})
+		desiredMap, desiredIsMap := desiredValue.(map[string]interface{})
+		if actualIsMap && desiredIsMap {
+			if fieldPatch := mergePatch(actualMap, desiredMap, fieldPath, fields); len(fieldPatch) > 0 {
+				patch[field] = fieldPatch
+			}
+			continue
+		}
+		if !equality.Semantic.DeepEqual(actualValue, desiredValue) {
+			patch[field] = desiredValue
+			*fields = append(*fields, fieldPath)
+		}
+	}
+	for field, actualValue := range actual {
+		if _, ok := desired[field]; ok || isEmpty(actualValue) {
+			continue
+		}
+		fieldPath := field
+		if path != "" {
+			fieldPath = path + "." + field
+		}
+		patch[field] = nil
+		*fields = append(*fields, fieldPath)
+	}
+	return patch
+}
+
+func isEmpty(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	default:
+		return false
+	}
+}
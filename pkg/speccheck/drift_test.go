@@ -0,0 +1,73 @@
+package speccheck
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestComputeDrift(t *testing.T) {
+	t.Parallel()
+	actual := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name":   "cm1",
+				"labels": map[string]interface{}{},
+				"annotations": map[string]interface{}{
+					"a": "1",
+				},
+			},
+			"data": map[string]interface{}{
+				"same":    "x",
+				"changed": "old",
+				"removed": "y",
+			},
+			"list":   []interface{}{"a", "b"},
+			"status": map[string]interface{}{"phase": "Ready"},
+		},
+	}
+	desired := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name":   "cm1",
+				"labels": nil,
+				"annotations": map[string]interface{}{
+					"a": "1",
+				},
+			},
+			"data": map[string]interface{}{
+				"same":    "x",
+				"changed": "new",
+				"added":   "z",
+			},
+			"list": []interface{}{"a", "c"},
+		},
+	}
+	drift := ComputeDrift(actual, desired)
+	assert.Equal(t, []string{"data.added", "data.changed", "data.removed", "list"}, drift.Fields)
+	assert.Equal(t, map[string]interface{}{
+		"data": map[string]interface{}{
+			"changed": "new",
+			"added":   "z",
+			"removed": nil,
+		},
+		"list": []interface{}{"a", "c"},
+	}, drift.Patch)
+}
+
+func TestComputeDriftNoChanges(t *testing.T) {
+	t.Parallel()
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name": "cm1",
+			},
+		},
+	}
+	drift := ComputeDrift(obj, obj.DeepCopy())
+	assert.Empty(t, drift.Fields)
+	assert.Empty(t, drift.Patch)
+}
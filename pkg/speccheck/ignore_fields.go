@@ -0,0 +1,127 @@
+package speccheck
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ParseFieldPath parses a dot separated path of a field, e.g. "spec.replicas". Keys that contain dots are quoted
+// in brackets, e.g. `metadata.annotations["deployment.kubernetes.io/revision"]`. Elements of lists cannot be
+// addressed. Identifying fields of objects, i.e. kind, API version, name and namespace, cannot be ignored.
+func ParseFieldPath(path string) ([]string, error) {
+	var fields []string
+	rest := path
+	for {
+		var field string
+		if i := strings.IndexAny(rest, ".["); i < 0 {
+			field, rest = rest, ""
+		} else {
+			field, rest = rest[:i], rest[i:]
+		}
+		if field == "" {
+			return nil, errors.Errorf("invalid field path %q: empty field name", path)
+		}
+		fields = append(fields, field)
+		for strings.HasPrefix(rest, "[") {
+			key, n, err := parseQuotedKey(rest)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid field path %q", path)
+			}
+			fields = append(fields, key)
+			rest = rest[n:]
+		}
+		if rest == "" {
+			break
+		}
+		if rest[0] != '.' {
+			return nil, errors.Errorf("invalid field path %q: unexpected %q", path, rest[0])
+		}
+		rest = rest[1:]
+	}
+	switch {
+	case len(fields) == 1 && (fields[0] == "kind" || fields[0] == "apiVersion"),
+		len(fields) == 2 && fields[0] == "metadata" && (fields[1] == "name" || fields[1] == "namespace"):
+		return nil, errors.Errorf("field %q cannot be ignored", path)
+	}
+	return fields, nil
+}
+
+// parseQuotedKey parses a key quoted in brackets at the start of the string. Returns the key and the length of
+// the quoted key with the brackets.
+func parseQuotedKey(s string) (string, int, error) {
+	if len(s) < 2 || (s[1] != '"' && s[1] != '\'') {
+		return "", 0, errors.New("keys in brackets must be quoted")
+	}
+	end := strings.IndexByte(s[2:], s[1])
+	if end < 0 || !strings.HasPrefix(s[2+end+1:], "]") {
+		return "", 0, errors.New("unterminated key in brackets")
+	}
+	key := s[2 : 2+end]
+	if key == "" {
+		return "", 0, errors.New("empty key in brackets")
+	}
+	return key, 2 + end + 2, nil
+}
+
+// IgnoreFields copies the values of the fields from the actual object into the spec, or removes them from the spec if
+// the actual object does not have them, so that differences in them are ignored when the objects are compared.
+// Fields are parsed with ParseFieldPath. The spec is returned as is if there are no fields or the object does not
+// exist yet, a modified copy otherwise.
+func IgnoreFields(spec, actual *unstructured.Unstructured, fields [][]string) *unstructured.Unstructured {
+	if len(fields) == 0 || actual == nil {
+		return spec
+	}
+	spec = spec.DeepCopy()
+	for _, path := range fields {
+		if value, ok := nestedValue(actual.Object, path); ok {
+			setNestedValue(spec.Object, runtime.DeepCopyJSONValue(value), path)
+		} else {
+			removeNestedValue(spec.Object, path)
+		}
+	}
+	return spec
+}
+
+func nestedValue(obj map[string]interface{}, path []string) (interface{}, bool) {
+	var value interface{} = obj
+	for _, field := range path {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		value, ok = m[field]
+		if !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// setNestedValue sets the value at the path, creating maps as needed. Values that are in the way are replaced.
+func setNestedValue(obj map[string]interface{}, value interface{}, path []string) {
+	m := obj
+	for _, field := range path[:len(path)-1] {
+		next, ok := m[field].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			m[field] = next
+		}
+		m = next
+	}
+	m[path[len(path)-1]] = value
+}
+
+func removeNestedValue(obj map[string]interface{}, path []string) {
+	m := obj
+	for _, field := range path[:len(path)-1] {
+		next, ok := m[field].(map[string]interface{})
+		if !ok {
+			return
+		}
+		m = next
+	}
+	delete(m, path[len(path)-1])
+}
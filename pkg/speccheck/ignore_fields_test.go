@@ -0,0 +1,118 @@
+package speccheck
+
+import (
+	"testing"
+
+	"github.com/atlassian/smith/pkg/cleanup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestParseFieldPath(t *testing.T) {
+	t.Parallel()
+	testcases := map[string]struct {
+		path   string
+		fields []string
+		err    string
+	}{
+		"simple": {
+			path:   "spec.replicas",
+			fields: []string{"spec", "replicas"},
+		},
+		"quoted key": {
+			path:   `metadata.annotations["deployment.kubernetes.io/revision"]`,
+			fields: []string{"metadata", "annotations", "deployment.kubernetes.io/revision"},
+		},
+		"single quoted keys in the middle": {
+			path:   `data['a.b']['c'].d`,
+			fields: []string{"data", "a.b", "c", "d"},
+		},
+		"empty": {
+			path: "",
+			err:  `invalid field path "": empty field name`,
+		},
+		"trailing dot": {
+			path: "spec.",
+			err:  `invalid field path "spec.": empty field name`,
+		},
+		"unquoted key": {
+			path: "spec[replicas]",
+			err:  `invalid field path "spec[replicas]": keys in brackets must be quoted`,
+		},
+		"unterminated key": {
+			path: `spec["replicas`,
+			err:  `invalid field path "spec[\"replicas": unterminated key in brackets`,
+		},
+		"garbage after key": {
+			path: `spec["a"]b`,
+			err:  `invalid field path "spec[\"a\"]b": unexpected 'b'`,
+		},
+		"name": {
+			path: "metadata.name",
+			err:  `field "metadata.name" cannot be ignored`,
+		},
+		"kind": {
+			path: "kind",
+			err:  `field "kind" cannot be ignored`,
+		},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			fields, err := ParseFieldPath(tc.path)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.fields, fields)
+		})
+	}
+}
+
+func TestIgnoreFields(t *testing.T) {
+	t.Parallel()
+	logger := zaptest.NewLogger(t)
+	defer logger.Sync()
+	sc := SpecCheck{
+		Logger:  logger,
+		Cleaner: cleanup.New(),
+	}
+	ignored := [][]string{
+		{"spec", "replicas"},
+		{"spec", "debug"},
+		{"metadata", "annotations", "example.com/revision"},
+	}
+
+	spec := scalable(map[string]interface{}{
+		"image":    "a",
+		"replicas": int64(1),
+		"debug":    true,
+	})
+	spec.SetAnnotations(map[string]string{"example.com/revision": "1"})
+	actual := scalable(map[string]interface{}{
+		"image":    "a",
+		"replicas": int64(5),
+	})
+	actual.SetAnnotations(map[string]string{"example.com/revision": "3"})
+
+	// Not ignored, the object is updated
+	_, match, err := sc.CompareActualVsSpec(spec.DeepCopy(), actual)
+	require.NoError(t, err)
+	assert.False(t, match)
+
+	ignoringSpec := IgnoreFields(spec, actual, ignored)
+	assert.Equal(t, int64(1), spec.Object["spec"].(map[string]interface{})["replicas"]) // Not mutated
+	assert.Equal(t, map[string]interface{}{
+		"image":    "a",
+		"replicas": int64(5),
+	}, ignoringSpec.Object["spec"])
+	_, match, err = sc.CompareActualVsSpec(ignoringSpec, actual)
+	require.NoError(t, err)
+	assert.True(t, match)
+
+	// Objects are created as specified
+	assert.Equal(t, spec, IgnoreFields(spec, nil, ignored))
+}
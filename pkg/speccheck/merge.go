@@ -0,0 +1,45 @@
+package speccheck
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ThreeWayMerge merges modified into current the way kubectl apply does with JSON merge patches:
+// - fields of modified are set on current, maps are merged recursively and lists are replaced as a whole;
+// - fields that are in original but not in modified are removed from current;
+// - other fields of current are kept.
+// original is the previous version of modified. It may be nil, then no fields are removed.
+// Null values in modified are treated as absent fields. Arguments are not mutated.
+func ThreeWayMerge(original, modified, current map[string]interface{}) map[string]interface{} {
+	result := runtime.DeepCopyJSON(current)
+	if result == nil {
+		result = make(map[string]interface{}, len(modified))
+	}
+	threeWayMerge(original, modified, result)
+	return result
+}
+
+func threeWayMerge(original, modified, result map[string]interface{}) {
+	for field, modifiedValue := range modified {
+		if modifiedValue == nil {
+			continue
+		}
+		modifiedMap, modifiedIsMap := modifiedValue.(map[string]interface{})
+		resultMap, resultIsMap := result[field].(map[string]interface{})
+		if modifiedIsMap && resultIsMap {
+			originalMap, _ := original[field].(map[string]interface{})
+			threeWayMerge(originalMap, modifiedMap, resultMap)
+			continue
+		}
+		result[field] = runtime.DeepCopyJSONValue(modifiedValue)
+	}
+	for field, originalValue := range original {
+		if originalValue == nil {
+			continue
+		}
+		if modifiedValue, ok := modified[field]; ok && modifiedValue != nil {
+			continue
+		}
+		delete(result, field)
+	}
+}
@@ -0,0 +1,82 @@
+package speccheck
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThreeWayMerge(t *testing.T) {
+	t.Parallel()
+	original := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": int64(1),
+			"paused":   true,
+			"strategy": map[string]interface{}{
+				"type": "Recreate",
+			},
+		},
+	}
+	modified := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": int64(2),
+			"strategy": map[string]interface{}{
+				"type": "RollingUpdate",
+			},
+			"ports":    []interface{}{"a"},
+			"selector": nil,
+		},
+	}
+	current := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": int64(1),
+			"paused":   true,
+			"strategy": map[string]interface{}{
+				"type":          "Recreate",
+				"rollingUpdate": "defaulted",
+			},
+			"ports":            []interface{}{"b", "c"},
+			"progressDeadline": int64(600),
+		},
+	}
+
+	result := ThreeWayMerge(original, modified, current)
+
+	assert.Equal(t, map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": int64(2),
+			"strategy": map[string]interface{}{
+				"type":          "RollingUpdate",
+				"rollingUpdate": "defaulted",
+			},
+			"ports":            []interface{}{"a"},
+			"progressDeadline": int64(600),
+		},
+	}, result)
+	// Arguments are not mutated
+	assert.Equal(t, true, current["spec"].(map[string]interface{})["paused"])
+	assert.Equal(t, []interface{}{"b", "c"}, current["spec"].(map[string]interface{})["ports"])
+}
+
+func TestThreeWayMergeWithoutOriginal(t *testing.T) {
+	t.Parallel()
+	modified := map[string]interface{}{
+		"data": map[string]interface{}{
+			"a": "1",
+		},
+	}
+	current := map[string]interface{}{
+		"data": map[string]interface{}{
+			"a": "0",
+			"b": "2",
+		},
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"data": map[string]interface{}{
+			"a": "1",
+			"b": "2",
+		},
+	}, ThreeWayMerge(nil, modified, current))
+	assert.Equal(t, modified, ThreeWayMerge(nil, modified, nil))
+}
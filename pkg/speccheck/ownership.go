@@ -0,0 +1,144 @@
+package speccheck
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+
+	"github.com/atlassian/smith"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// mergeOwnedFields sets the fields of the spec on the updated object instead of replacing its top level fields.
+// Fields that were set from a previous version of the spec but are not in the spec anymore are removed, other fields
+// that are not in the spec are kept, e.g. replicas set by an autoscaler. recorded are the hashes from the
+// ManagedFieldsAnnotation of the actual object, may be nil. Returns the value of the annotation for the spec.
+func mergeOwnedFields(spec, updated *unstructured.Unstructured, recorded map[string]interface{}) (string, error) {
+	specFields := ownedFields(spec.Object)
+	hashes, err := fieldHashes(specFields)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(hashes)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal managed fields")
+	}
+	merged := ThreeWayMerge(recorded, specFields, ownedFields(updated.Object))
+	for field := range ownedFields(updated.Object) {
+		delete(updated.Object, field)
+	}
+	for field, value := range merged {
+		updated.Object[field] = value
+	}
+	return string(data), nil
+}
+
+// ExternallyModifiedFields returns sorted dot separated paths of fields that were set from the spec in field
+// ownership mode and have been changed by something else since, i.e. whose values do not match the hashes recorded
+// in the ManagedFieldsAnnotation. Lists are compared as a whole. Returns nil if the object has no annotation.
+func ExternallyModifiedFields(obj *unstructured.Unstructured) ([]string, error) {
+	recorded, err := recordedFieldHashes(obj)
+	if err != nil {
+		return nil, err
+	}
+	var fields []string
+	if err = modifiedFields(recorded, obj.Object, "", &fields); err != nil {
+		return nil, err
+	}
+	sort.Strings(fields)
+	return fields, nil
+}
+
+// recordedFieldHashes returns the hashes recorded in the ManagedFieldsAnnotation of the object, nil if there is
+// no annotation.
+func recordedFieldHashes(obj *unstructured.Unstructured) (map[string]interface{}, error) {
+	value, ok := obj.GetAnnotations()[smith.ManagedFieldsAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	var hashes map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &hashes); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal %s annotation", smith.ManagedFieldsAnnotation)
+	}
+	return hashes, nil
+}
+
+func modifiedFields(hashes, obj map[string]interface{}, path string, fields *[]string) error {
+	for field, hash := range hashes {
+		fieldPath := field
+		if path != "" {
+			fieldPath = path + "." + field
+		}
+		value := obj[field]
+		if nested, ok := hash.(map[string]interface{}); ok {
+			valueMap, _ := value.(map[string]interface{})
+			if err := modifiedFields(nested, valueMap, fieldPath, fields); err != nil {
+				return err
+			}
+			continue
+		}
+		if isEmpty(value) {
+			*fields = append(*fields, fieldPath)
+			continue
+		}
+		valueHash, err := hashValue(value)
+		if err != nil {
+			return err
+		}
+		if hash != valueHash {
+			*fields = append(*fields, fieldPath)
+		}
+	}
+	return nil
+}
+
+// fieldHashes returns a tree of maps that mirrors the object with hashes of values in place of values that are
+// not maps. Empty values are omitted.
+func fieldHashes(obj map[string]interface{}) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(obj))
+	for field, value := range obj {
+		if isEmpty(value) {
+			continue
+		}
+		if valueMap, ok := value.(map[string]interface{}); ok {
+			nested, err := fieldHashes(valueMap)
+			if err != nil {
+				return nil, err
+			}
+			result[field] = nested
+			continue
+		}
+		hash, err := hashValue(value)
+		if err != nil {
+			return nil, err
+		}
+		result[field] = hash
+	}
+	return result, nil
+}
+
+// hashValue returns a short hash of the JSON representation of the value. Hashes are recorded instead of values to
+// keep the annotation small.
+func hashValue(value interface{}) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal field value")
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+// ownedFields returns top level fields of an object that may be owned by the controller.
+func ownedFields(obj map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(obj))
+	for field, value := range obj {
+		switch field {
+		case "kind", "apiVersion", "metadata", "status":
+			continue
+		}
+		result[field] = value
+	}
+	return result
+}
@@ -0,0 +1,141 @@
+package speccheck
+
+import (
+	"testing"
+
+	"github.com/atlassian/smith"
+	"github.com/atlassian/smith/pkg/cleanup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func scalable(spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "example.com/v1",
+			"kind":       "Scalable",
+			"metadata": map[string]interface{}{
+				"name": "s1",
+			},
+			"spec": spec,
+		},
+	}
+}
+
+func TestFieldOwnershipKeepsFieldsNotInSpec(t *testing.T) {
+	t.Parallel()
+	logger := zaptest.NewLogger(t)
+	defer logger.Sync()
+	sc := SpecCheck{
+		Logger:         logger,
+		Cleaner:        cleanup.New(),
+		FieldOwnership: true,
+	}
+
+	// Replicas are set by an autoscaler
+	actual := scalable(map[string]interface{}{
+		"image":    "a",
+		"replicas": int64(5),
+	})
+	updated, match, err := sc.CompareActualVsSpec(scalable(map[string]interface{}{"image": "a"}), actual)
+	require.NoError(t, err)
+	assert.False(t, match) // Fields are not recorded yet
+	assert.Equal(t, map[string]interface{}{
+		"image":    "a",
+		"replicas": int64(5),
+	}, updated.Object["spec"])
+	assert.Contains(t, updated.GetAnnotations(), smith.ManagedFieldsAnnotation)
+
+	fields, err := ExternallyModifiedFields(updated)
+	require.NoError(t, err)
+	assert.Empty(t, fields)
+
+	// The autoscaler changes replicas again
+	updated.Object["spec"].(map[string]interface{})["replicas"] = int64(7)
+	_, match, err = sc.CompareActualVsSpec(scalable(map[string]interface{}{"image": "a"}), updated)
+	require.NoError(t, err)
+	assert.True(t, match)
+	fields, err = ExternallyModifiedFields(updated)
+	require.NoError(t, err)
+	assert.Empty(t, fields)
+}
+
+func TestFieldOwnershipRevertsExternalChanges(t *testing.T) {
+	t.Parallel()
+	logger := zaptest.NewLogger(t)
+	defer logger.Sync()
+	sc := SpecCheck{
+		Logger:         logger,
+		Cleaner:        cleanup.New(),
+		FieldOwnership: true,
+	}
+	spec := func() *unstructured.Unstructured {
+		return scalable(map[string]interface{}{
+			"image": "a",
+			"env": map[string]interface{}{
+				"A": "1",
+			},
+		})
+	}
+
+	actual, _, err := sc.CompareActualVsSpec(spec(), scalable(map[string]interface{}{}))
+	require.NoError(t, err)
+	actualSpec := actual.Object["spec"].(map[string]interface{})
+	actualSpec["image"] = "b"
+	delete(actualSpec["env"].(map[string]interface{}), "A")
+
+	fields, err := ExternallyModifiedFields(actual)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"spec.env.A", "spec.image"}, fields)
+
+	updated, match, err := sc.CompareActualVsSpec(spec(), actual)
+	require.NoError(t, err)
+	assert.False(t, match)
+	assert.Equal(t, map[string]interface{}{
+		"image": "a",
+		"env": map[string]interface{}{
+			"A": "1",
+		},
+	}, updated.Object["spec"])
+}
+
+func TestFieldOwnershipRemovesFieldsRemovedFromSpec(t *testing.T) {
+	t.Parallel()
+	logger := zaptest.NewLogger(t)
+	defer logger.Sync()
+	sc := SpecCheck{
+		Logger:         logger,
+		Cleaner:        cleanup.New(),
+		FieldOwnership: true,
+	}
+
+	actual, _, err := sc.CompareActualVsSpec(scalable(map[string]interface{}{
+		"image": "a",
+		"debug": true,
+	}), scalable(map[string]interface{}{
+		"replicas": int64(5),
+	}))
+	require.NoError(t, err)
+
+	updated, match, err := sc.CompareActualVsSpec(scalable(map[string]interface{}{"image": "a"}), actual)
+	require.NoError(t, err)
+	assert.False(t, match)
+	assert.Equal(t, map[string]interface{}{
+		"image":    "a",
+		"replicas": int64(5),
+	}, updated.Object["spec"])
+}
+
+func TestExternallyModifiedFieldsWithoutAnnotation(t *testing.T) {
+	t.Parallel()
+	fields, err := ExternallyModifiedFields(scalable(map[string]interface{}{"image": "a"}))
+	require.NoError(t, err)
+	assert.Empty(t, fields)
+
+	obj := scalable(map[string]interface{}{})
+	obj.SetAnnotations(map[string]string{smith.ManagedFieldsAnnotation: "{"})
+	_, err = ExternallyModifiedFields(obj)
+	assert.EqualError(t, err, "failed to unmarshal smith.atlassian.com/managed-fields annotation: unexpected end of JSON input")
+}
@@ -0,0 +1,109 @@
+package speccheck
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Normalizer prepares the desired object for comparison with the actual object of the same kind.
+// Typically it copies server-populated fields from actual into the returned object so that they are ignored.
+// Each implementation is responsible for handling different versions of objects itself.
+type Normalizer interface {
+	Normalize(spec, actual *unstructured.Unstructured) (updatedSpec *unstructured.Unstructured, err error)
+}
+
+// NormalizerFunc is an adapter to use ordinary functions as Normalizers.
+type NormalizerFunc func(spec, actual *unstructured.Unstructured) (updatedSpec *unstructured.Unstructured, err error)
+
+func (f NormalizerFunc) Normalize(spec, actual *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	return f(spec, actual)
+}
+
+// Comparator decides whether the actual object already matches the updated object.
+// updated is the actual object with the desired spec applied on top of it.
+// Each implementation is responsible for handling different versions of objects itself.
+type Comparator interface {
+	Equal(updated, actual *unstructured.Unstructured) (bool, error)
+}
+
+// ComparatorFunc is an adapter to use ordinary functions as Comparators.
+type ComparatorFunc func(updated, actual *unstructured.Unstructured) (bool, error)
+
+func (f ComparatorFunc) Equal(updated, actual *unstructured.Unstructured) (bool, error) {
+	return f(updated, actual)
+}
+
+// Registry holds per-kind comparison strategies.
+// It is safe for concurrent use.
+type Registry struct {
+	mx          sync.RWMutex
+	normalizers map[schema.GroupKind]Normalizer
+	comparators map[schema.GroupKind]Comparator
+	// defaultComparator is used for kinds without a registered Comparator. May be nil.
+	defaultComparator Comparator
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		normalizers: make(map[schema.GroupKind]Normalizer),
+		comparators: make(map[schema.GroupKind]Comparator),
+	}
+}
+
+// RegisterNormalizer registers a Normalizer for objects of the specified kind.
+// Only one Normalizer may be registered per kind.
+func (r *Registry) RegisterNormalizer(gk schema.GroupKind, n Normalizer) error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	if _, ok := r.normalizers[gk]; ok {
+		return errors.Errorf("normalizer for %s is already registered", gk)
+	}
+	r.normalizers[gk] = n
+	return nil
+}
+
+// RegisterComparator registers a Comparator for objects of the specified kind.
+// Only one Comparator may be registered per kind.
+func (r *Registry) RegisterComparator(gk schema.GroupKind, c Comparator) error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	if _, ok := r.comparators[gk]; ok {
+		return errors.Errorf("comparator for %s is already registered", gk)
+	}
+	r.comparators[gk] = c
+	return nil
+}
+
+// SetDefaultComparator sets the Comparator used for kinds that do not have a Comparator registered.
+func (r *Registry) SetDefaultComparator(c Comparator) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	r.defaultComparator = c
+}
+
+// Normalizer returns the Normalizer registered for the kind, if any.
+func (r *Registry) Normalizer(gk schema.GroupKind) (Normalizer, bool) {
+	if r == nil {
+		return nil, false
+	}
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+	n, ok := r.normalizers[gk]
+	return n, ok
+}
+
+// Comparator returns the Comparator registered for the kind or the default one, if any.
+func (r *Registry) Comparator(gk schema.GroupKind) (Comparator, bool) {
+	if r == nil {
+		return nil, false
+	}
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+	if c, ok := r.comparators[gk]; ok {
+		return c, true
+	}
+	return r.defaultComparator, r.defaultComparator != nil
+}
@@ -0,0 +1,106 @@
+package speccheck
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/atlassian/smith/pkg/cleanup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var widgetGK = schema.GroupKind{Group: "example.com", Kind: "Widget"}
+
+func widget(size interface{}, serverField string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "example.com/v1",
+			"kind":       "Widget",
+			"metadata": map[string]interface{}{
+				"name": "widget1",
+			},
+			"spec": map[string]interface{}{
+				"size": size,
+			},
+		},
+	}
+	if serverField != "" {
+		widgetSpec(obj)["serverField"] = serverField
+	}
+	return obj
+}
+
+func widgetSpec(obj *unstructured.Unstructured) map[string]interface{} {
+	return obj.Object["spec"].(map[string]interface{})
+}
+
+func TestRegistryNormalizerIgnoresServerFields(t *testing.T) {
+	t.Parallel()
+	logger := zaptest.NewLogger(t)
+	defer logger.Sync()
+
+	r := NewRegistry()
+	require.NoError(t, r.RegisterNormalizer(widgetGK, NormalizerFunc(func(spec, actual *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+		serverField, ok := widgetSpec(actual)["serverField"]
+		if !ok {
+			return spec, nil
+		}
+		spec = spec.DeepCopy()
+		widgetSpec(spec)["serverField"] = serverField
+		return spec, nil
+	})))
+	sc := SpecCheck{
+		Logger:     logger,
+		Cleaner:    cleanup.New(),
+		Strategies: r,
+	}
+
+	_, match, err := sc.CompareActualVsSpec(widget("1", ""), widget("1", "set by server"))
+	require.NoError(t, err)
+	assert.True(t, match)
+
+	// Without the normalizer the server-populated field is a difference
+	sc.Strategies = nil
+	_, match, err = sc.CompareActualVsSpec(widget("1", ""), widget("1", "set by server"))
+	require.NoError(t, err)
+	assert.False(t, match)
+}
+
+func TestRegistryComparator(t *testing.T) {
+	t.Parallel()
+	logger := zaptest.NewLogger(t)
+	defer logger.Sync()
+
+	r := NewRegistry()
+	// Sizes "1" and 1 are considered equal
+	require.NoError(t, r.RegisterComparator(widgetGK, ComparatorFunc(func(updated, actual *unstructured.Unstructured) (bool, error) {
+		return fmt.Sprint(widgetSpec(updated)["size"]) == fmt.Sprint(widgetSpec(actual)["size"]), nil
+	})))
+	sc := SpecCheck{
+		Logger:     logger,
+		Cleaner:    cleanup.New(),
+		Strategies: r,
+	}
+
+	_, match, err := sc.CompareActualVsSpec(widget("1", ""), widget(int64(1), ""))
+	require.NoError(t, err)
+	assert.True(t, match)
+}
+
+func TestRegistryDuplicateRegistration(t *testing.T) {
+	t.Parallel()
+	r := NewRegistry()
+	n := NormalizerFunc(func(spec, actual *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+		return spec, nil
+	})
+	c := ComparatorFunc(func(updated, actual *unstructured.Unstructured) (bool, error) {
+		return true, nil
+	})
+	require.NoError(t, r.RegisterNormalizer(widgetGK, n))
+	assert.EqualError(t, r.RegisterNormalizer(widgetGK, n), "normalizer for Widget.example.com is already registered")
+	require.NoError(t, r.RegisterComparator(widgetGK, c))
+	assert.EqualError(t, r.RegisterComparator(widgetGK, c), "comparator for Widget.example.com is already registered")
+}
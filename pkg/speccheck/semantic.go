@@ -0,0 +1,125 @@
+package speccheck
+
+import (
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// SemanticComparator compares objects tolerating differences that do not change the meaning of an object.
+// Nil and empty lists/maps are always equal, numbers are compared by value regardless of their Go type.
+type SemanticComparator struct {
+	// MissingAsZero makes a missing field equal to the zero value of its type on the other side
+	// (false, 0, "", empty list or map). Useful for fields defaulted by the server to their zero values.
+	MissingAsZero bool
+	// Quantities makes string values in "limits" and "requests" maps compare as resource quantities,
+	// e.g. "1000m" equals "1" and "1Gi" equals "1024Mi".
+	Quantities bool
+}
+
+func (c SemanticComparator) Equal(updated, actual *unstructured.Unstructured) (bool, error) {
+	return c.equal(updated.Object, actual.Object, false), nil
+}
+
+func (c SemanticComparator) equal(a, b interface{}, quantities bool) bool {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok {
+			return b == nil && len(av) == 0
+		}
+		for k, x := range av {
+			y, found := bv[k]
+			if !found {
+				if c.MissingAsZero && isZero(x) {
+					continue
+				}
+				return false
+			}
+			if !c.equal(x, y, c.Quantities && (k == "limits" || k == "requests")) {
+				return false
+			}
+		}
+		for k, y := range bv {
+			if _, found := av[k]; !found && !(c.MissingAsZero && isZero(y)) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok {
+			return b == nil && len(av) == 0
+		}
+		if len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !c.equal(av[i], bv[i], false) {
+				return false
+			}
+		}
+		return true
+	case nil:
+		return b == nil || isEmptyCollection(b)
+	}
+	if x, ok := toFloat(a); ok {
+		y, ok := toFloat(b)
+		return ok && x == y
+	}
+	if quantities {
+		if x, ok := a.(string); ok {
+			if y, ok := b.(string); ok {
+				qx, errX := resource.ParseQuantity(x)
+				qy, errY := resource.ParseQuantity(y)
+				if errX == nil && errY == nil {
+					return qx.Cmp(qy) == 0
+				}
+			}
+		}
+	}
+	return equality.Semantic.DeepEqual(a, b)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func isEmptyCollection(v interface{}) bool {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		return len(x) == 0
+	case []interface{}:
+		return len(x) == 0
+	}
+	return false
+}
+
+func isZero(v interface{}) bool {
+	switch x := v.(type) {
+	case nil:
+		return true
+	case bool:
+		return !x
+	case string:
+		return x == ""
+	case map[string]interface{}, []interface{}:
+		return isEmptyCollection(x)
+	}
+	if n, ok := toFloat(v); ok {
+		return n == 0
+	}
+	return false
+}
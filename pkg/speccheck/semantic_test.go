@@ -0,0 +1,95 @@
+package speccheck
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestSemanticComparator(t *testing.T) {
+	t.Parallel()
+	c := SemanticComparator{
+		MissingAsZero: true,
+		Quantities:    true,
+	}
+	cases := []struct {
+		name    string
+		updated map[string]interface{}
+		actual  map[string]interface{}
+		equal   bool
+	}{
+		{
+			name:    "nil and empty list",
+			updated: map[string]interface{}{"args": nil},
+			actual:  map[string]interface{}{"args": []interface{}{}},
+			equal:   true,
+		},
+		{
+			name:    "int64 and float64",
+			updated: map[string]interface{}{"replicas": int64(3)},
+			actual:  map[string]interface{}{"replicas": float64(3)},
+			equal:   true,
+		},
+		{
+			name:    "missing false",
+			updated: map[string]interface{}{},
+			actual:  map[string]interface{}{"hostNetwork": false},
+			equal:   true,
+		},
+		{
+			name:    "missing true",
+			updated: map[string]interface{}{},
+			actual:  map[string]interface{}{"hostNetwork": true},
+			equal:   false,
+		},
+		{
+			name: "equivalent quantities",
+			updated: map[string]interface{}{"resources": map[string]interface{}{
+				"limits": map[string]interface{}{"cpu": "1000m", "memory": "1Gi"},
+			}},
+			actual: map[string]interface{}{"resources": map[string]interface{}{
+				"limits": map[string]interface{}{"cpu": "1", "memory": "1024Mi"},
+			}},
+			equal: true,
+		},
+		{
+			name: "different quantities",
+			updated: map[string]interface{}{"resources": map[string]interface{}{
+				"requests": map[string]interface{}{"cpu": "500m"},
+			}},
+			actual: map[string]interface{}{"resources": map[string]interface{}{
+				"requests": map[string]interface{}{"cpu": "1"},
+			}},
+			equal: false,
+		},
+		{
+			name:    "quantity-like strings outside of resources",
+			updated: map[string]interface{}{"value": "1000m"},
+			actual:  map[string]interface{}{"value": "1"},
+			equal:   false,
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			equal, err := c.Equal(&unstructured.Unstructured{Object: tc.updated}, &unstructured.Unstructured{Object: tc.actual})
+			require.NoError(t, err)
+			assert.Equal(t, tc.equal, equal)
+		})
+	}
+}
+
+func TestRegistryDefaultComparator(t *testing.T) {
+	t.Parallel()
+	r := NewRegistry()
+	_, ok := r.Comparator(widgetGK)
+	assert.False(t, ok)
+
+	r.SetDefaultComparator(SemanticComparator{})
+	c, ok := r.Comparator(widgetGK)
+	require.True(t, ok)
+	assert.Equal(t, SemanticComparator{}, c)
+}
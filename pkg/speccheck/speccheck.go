@@ -2,6 +2,7 @@ package speccheck
 
 import (
 	ctrlLogz "github.com/atlassian/ctrl/logz"
+	"github.com/atlassian/smith"
 	"github.com/atlassian/smith/pkg/util"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
@@ -9,7 +10,6 @@ import (
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/util/diff"
 	"k8s.io/apimachinery/pkg/util/sets"
 )
 
@@ -17,6 +17,11 @@ type SpecCheck struct {
 	Logger *zap.Logger
 	// Server fields cleanup
 	Cleaner SpecCleaner
+	// Optional per-kind normalizers and comparators. Applied in addition to Cleaner.
+	Strategies *Registry
+	// FieldOwnership makes the check only set fields of the spec on objects and keep fields that were never set from
+	// the spec. Set fields are recorded in the ManagedFieldsAnnotation. Not used for Secrets.
+	FieldOwnership bool
 }
 
 func (sc *SpecCheck) CompareActualVsSpec(spec, actual runtime.Object) (*unstructured.Unstructured, bool /*match*/, error) {
@@ -59,21 +64,46 @@ func (sc *SpecCheck) compareActualVsSpec(spec, actual *unstructured.Unstructured
 	if err != nil {
 		return nil, false, errors.Wrap(err, "cleanup failed")
 	}
+	gk := spec.GroupVersionKind().GroupKind()
+	if normalizer, ok := sc.Strategies.Normalizer(gk); ok {
+		spec, err = normalizer.Normalize(spec, actualClone)
+		if err != nil {
+			return nil, false, errors.Wrapf(err, "normalization of %s failed", gk)
+		}
+	}
 
 	// 3. Copy data from the spec
-	for field, specValue := range spec.Object {
-		switch field {
-		case "kind", "apiVersion", "metadata", "status":
-			continue
+	isSecret := gk.Group == core_v1.GroupName && gk.Kind == "Secret"
+	var managedFields string
+	if sc.FieldOwnership && !isSecret {
+		recorded, err := recordedFieldHashes(actualClone)
+		if err != nil {
+			// Fields that were removed from the spec are kept until the annotation is rewritten
+			sc.Logger.Warn("Ignoring invalid managed fields annotation", ctrlLogz.Object(spec), zap.Error(err))
+		}
+		managedFields, err = mergeOwnedFields(spec, updated, recorded)
+		if err != nil {
+			return nil, false, err
+		}
+	} else {
+		for field, specValue := range spec.Object {
+			switch field {
+			case "kind", "apiVersion", "metadata", "status":
+				continue
+			}
+			updated.Object[field] = specValue // using the value directly - we've made a copy up the stack so it's ok
 		}
-		updated.Object[field] = specValue // using the value directly - we've made a copy up the stack so it's ok
 	}
 
 	// 4. Some stuff from ObjectMeta
 	// TODO Ignores added annotations/labels. Should be configurable per-object and/or per-object kind?
 	updated.SetName(spec.GetName())
 	updated.SetLabels(spec.GetLabels())
-	updated.SetAnnotations(processAnnotations(spec.GetAnnotations(), updated.GetAnnotations()))
+	annotations := processAnnotations(spec.GetAnnotations(), updated.GetAnnotations())
+	if managedFields != "" {
+		annotations[smith.ManagedFieldsAnnotation] = managedFields
+	}
+	updated.SetAnnotations(annotations)
 	updated.SetOwnerReferences(spec.GetOwnerReferences()) // TODO Is this ok? Check that there is only one controller and it is THIS bundle
 
 	finalizers := sets.NewString(updated.GetFinalizers()...)
@@ -88,16 +118,25 @@ func (sc *SpecCheck) compareActualVsSpec(spec, actual *unstructured.Unstructured
 	// observed the update yet. Like Generation/ObservedGeneration for built-in controllers.
 	delete(updated.Object, "status")
 
-	if !equality.Semantic.DeepEqual(updated.Object, actualClone.Object) {
-		gvk := spec.GroupVersionKind()
+	var equal bool
+	if comparator, ok := sc.Strategies.Comparator(gk); ok {
+		equal, err = comparator.Equal(updated, actualClone)
+		if err != nil {
+			return nil, false, errors.Wrapf(err, "comparison of %s failed", gk)
+		}
+	} else {
+		equal = equality.Semantic.DeepEqual(updated.Object, actualClone.Object)
+	}
 
-		if gvk.Group == core_v1.GroupName && gvk.Kind == "Secret" {
-			sc.Logger.Info("Objects are different: Secret object has changed", ctrlLogz.Object(spec))
+	if !equal {
+		drift := ComputeDrift(actualClone, updated)
+		if isSecret {
+			// Values of a Secret must not be logged
+			sc.Logger.Info("Objects are different: Secret object has changed", ctrlLogz.Object(spec), zap.Strings("fields", drift.Fields))
 			return updated, false, nil
 		}
 
-		sc.Logger.Sugar().Infof("Objects are different: %s",
-			diff.ObjectReflectDiff(updated.Object, actualClone.Object))
+		sc.Logger.Info("Objects are different", ctrlLogz.Object(spec), zap.Strings("fields", drift.Fields), zap.Any("patch", drift.Patch))
 		return updated, false, nil
 	}
 	return actual, true, nil
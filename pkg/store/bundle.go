@@ -15,8 +15,15 @@ import (
 )
 
 const (
-	byCrdGroupKindIndexName = "ByCrdGroupKind"
-	byObjectIndexName       = "ByObject"
+	byCrdGroupKindIndexName   = "ByCrdGroupKind"
+	byObjectIndexName         = "ByObject"
+	bySpecSourceIndexName     = "BySpecSource"
+	byExternalObjectIndexName = "ByExternalObject"
+)
+
+var (
+	configMapGK = schema.GroupKind{Kind: "ConfigMap"}
+	secretGK    = schema.GroupKind{Kind: "Secret"}
 )
 
 type ByNameStore interface {
@@ -36,8 +43,10 @@ func NewBundle(bundleInf cache.SharedIndexInformer, store ByNameStore, pluginCon
 		pluginContainers: pluginContainers,
 	}
 	err := bundleInf.AddIndexers(cache.Indexers{
-		byCrdGroupKindIndexName: bs.byCrdGroupKindIndex,
-		byObjectIndexName:       bs.byObjectIndex,
+		byCrdGroupKindIndexName:   bs.byCrdGroupKindIndex,
+		byObjectIndexName:         bs.byObjectIndex,
+		bySpecSourceIndexName:     bySpecSourceIndex,
+		byExternalObjectIndexName: byExternalObjectIndex,
 	})
 	if err != nil {
 		return nil, err
@@ -65,6 +74,18 @@ func (s *BundleStore) GetBundlesByObject(gk schema.GroupKind, namespace, name st
 	return s.getBundles(byObjectIndexName, byObjectIndexKey(gk, namespace, name))
 }
 
+// GetBundlesBySpecSource returns bundles with resources that read their spec from the ConfigMap or Secret with
+// specified GK, namespace and name.
+func (s *BundleStore) GetBundlesBySpecSource(gk schema.GroupKind, namespace, name string) ([]*smith_v1.Bundle, error) {
+	return s.getBundles(bySpecSourceIndexName, byObjectIndexKey(gk, namespace, name))
+}
+
+// GetBundlesByExternalObject returns bundles with external resources that wait for the object with specified GK,
+// namespace and name.
+func (s *BundleStore) GetBundlesByExternalObject(gk schema.GroupKind, namespace, name string) ([]*smith_v1.Bundle, error) {
+	return s.getBundles(byExternalObjectIndexName, byObjectIndexKey(gk, namespace, name))
+}
+
 func (s *BundleStore) getBundles(indexName, indexKey string) ([]*smith_v1.Bundle, error) {
 	bundles, err := s.bundleByIndex(indexName, indexKey)
 	if err != nil {
@@ -91,6 +112,15 @@ func (s *BundleStore) byCrdGroupKindIndex(obj interface{}) ([]string, error) {
 				continue
 			}
 			gvk = p.Plugin.Describe().GVK
+		} else if resource.Spec.External != nil {
+			gvk = resource.Spec.External.GroupVersionKind()
+		} else if resource.SpecFrom != nil {
+			// Kind is only known once the spec source has been read
+			_, status := bundle.Status.GetResourceStatus(resource.Name)
+			if status == nil || status.ResolvedObject == nil {
+				continue
+			}
+			gvk = status.ResolvedObject.GroupVersionKind()
 		} else {
 			// Invalid object, ignore
 			continue
@@ -126,11 +156,31 @@ func (s *BundleStore) byObjectIndex(obj interface{}) ([]string, error) {
 			}
 			gvk = p.Plugin.Describe().GVK
 			name = resource.Spec.Plugin.ObjectName
+		} else if resource.SpecFrom != nil {
+			// Kind and name are only known once the spec source has been read
+			_, status := bundle.Status.GetResourceStatus(resource.Name)
+			if status == nil || status.ResolvedObject == nil {
+				continue
+			}
+			gvk = status.ResolvedObject.GroupVersionKind()
+			name = status.ResolvedObject.Name
 		} else {
 			// Invalid object, ignore
 			continue
 		}
-		result = append(result, byObjectIndexKey(gvk.GroupKind(), bundle.Namespace, name))
+		if name == "" {
+			// Name is generated by the server from metadata.generateName
+			_, status := bundle.Status.GetResourceStatus(resource.Name)
+			if status == nil || status.ObjectName == "" {
+				continue
+			}
+			name = status.ObjectName
+		}
+		namespace := bundle.Namespace
+		if resource.Namespace != "" {
+			namespace = resource.Namespace
+		}
+		result = append(result, byObjectIndexKey(gvk.GroupKind(), namespace, name))
 	}
 	return result, nil
 }
@@ -138,3 +188,41 @@ func (s *BundleStore) byObjectIndex(obj interface{}) ([]string, error) {
 func byObjectIndexKey(gk schema.GroupKind, namespace, name string) string {
 	return fmt.Sprintf("%s/%s/%s/%s", gk.Group, gk.Kind, namespace, name)
 }
+
+// bySpecSourceIndex indexes Bundles by ConfigMaps and Secrets their resources read their spec from.
+// Spec sources are in the namespace of the Bundle.
+func bySpecSourceIndex(obj interface{}) ([]string, error) {
+	bundle := obj.(*smith_v1.Bundle)
+	var result []string
+	for _, resource := range bundle.Spec.Resources {
+		if resource.SpecFrom == nil {
+			continue
+		}
+		if ref := resource.SpecFrom.ConfigMapKeyRef; ref != nil {
+			result = append(result, byObjectIndexKey(configMapGK, bundle.Namespace, ref.Name))
+		}
+		if ref := resource.SpecFrom.SecretKeyRef; ref != nil {
+			result = append(result, byObjectIndexKey(secretGK, bundle.Namespace, ref.Name))
+		}
+	}
+	return result, nil
+}
+
+// byExternalObjectIndex indexes Bundles by external objects their resources wait for.
+// External objects are not in byObjectIndex because Bundles do not control them.
+func byExternalObjectIndex(obj interface{}) ([]string, error) {
+	bundle := obj.(*smith_v1.Bundle)
+	var result []string
+	for _, resource := range bundle.Spec.Resources {
+		if resource.Spec.External == nil {
+			continue
+		}
+		namespace := bundle.Namespace
+		if resource.Namespace != "" {
+			namespace = resource.Namespace
+		}
+		gk := resource.Spec.External.GroupVersionKind().GroupKind()
+		result = append(result, byObjectIndexKey(gk, namespace, resource.Spec.External.Name))
+	}
+	return result, nil
+}
@@ -43,8 +43,8 @@ func (s *Crd) Get(resource schema.GroupKind) (*apiext_v1b1.CustomResourceDefinit
 		crd.APIVersion = apiext_v1b1.SchemeGroupVersion.String()
 		return crd, nil
 	default:
-		// Must never happen
-		panic(errors.Errorf("multiple CRDs by group %q and kind %q: %s", resource.Group, resource.Kind, objs))
+		// Conflicting CRDs do not get their names accepted but are still present in the informer
+		return nil, errors.Errorf("multiple CRDs by group %q and kind %q", resource.Group, resource.Kind)
 	}
 }
 
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"github.com/atlassian/smith"
 	"github.com/pkg/errors"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -11,6 +12,7 @@ import (
 
 const (
 	ByNamespaceAndControllerUidIndex = "NamespaceUidIndex"
+	ByBundleUidLabelIndex            = "BundleUidLabelIndex"
 )
 
 type Multi struct {
@@ -31,12 +33,17 @@ func (s *Multi) AddInformer(gvk schema.GroupVersionKind, informer cache.SharedIn
 	if _, ok := s.informers[gvk]; ok {
 		return errors.New("informer is already registered")
 	}
-	f := informer.GetIndexer().GetIndexers()[ByNamespaceAndControllerUidIndex]
-	if f == nil {
-		// Informer does not have this index yet i.e. this is the first/sole multistore it is added to.
-		err := informer.AddIndexers(cache.Indexers{
-			ByNamespaceAndControllerUidIndex: byNamespaceAndControllerUidIndex,
-		})
+	indexers := informer.GetIndexer().GetIndexers()
+	newIndexers := cache.Indexers{}
+	// Informer does not have these indexes yet i.e. this is the first/sole multistore it is added to.
+	if indexers[ByNamespaceAndControllerUidIndex] == nil {
+		newIndexers[ByNamespaceAndControllerUidIndex] = byNamespaceAndControllerUidIndex
+	}
+	if indexers[ByBundleUidLabelIndex] == nil {
+		newIndexers[ByBundleUidLabelIndex] = byBundleUidLabelIndex
+	}
+	if len(newIndexers) > 0 {
+		err := informer.AddIndexers(newIndexers)
 		if err != nil {
 			return errors.WithStack(err)
 		}
@@ -46,10 +53,20 @@ func (s *Multi) AddInformer(gvk schema.GroupVersionKind, informer cache.SharedIn
 }
 
 func (s *Multi) ObjectsControlledBy(namespace string, uid types.UID) ([]runtime.Object, error) {
+	return s.objectsByIndex(ByNamespaceAndControllerUidIndex, ByNamespaceAndControllerUidIndexKey(namespace, uid))
+}
+
+// ObjectsTrackedBy returns objects in all namespaces that are labeled with the UID of a Bundle.
+// Objects in other namespaces than the namespace of the Bundle are tracked this way because they
+// cannot have an owner reference to it.
+func (s *Multi) ObjectsTrackedBy(uid types.UID) ([]runtime.Object, error) {
+	return s.objectsByIndex(ByBundleUidLabelIndex, string(uid))
+}
+
+func (s *Multi) objectsByIndex(indexName, indexKey string) ([]runtime.Object, error) {
 	var result []runtime.Object
-	indexKey := ByNamespaceAndControllerUidIndexKey(namespace, uid)
 	for gvk, inf := range s.GetInformers() {
-		objs, err := inf.GetIndexer().ByIndex(ByNamespaceAndControllerUidIndex, indexKey)
+		objs, err := inf.GetIndexer().ByIndex(indexName, indexKey)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to get objects for bundle from %s informer", gvk)
 		}
@@ -74,6 +91,17 @@ func byNamespaceAndControllerUidIndex(obj interface{}) ([]string, error) {
 	return nil, nil
 }
 
+func byBundleUidLabelIndex(obj interface{}) ([]string, error) {
+	if key, ok := obj.(cache.ExplicitKey); ok {
+		return []string{string(key)}, nil
+	}
+	uid := obj.(meta_v1.Object).GetLabels()[smith.BundleUIDLabel]
+	if uid != "" {
+		return []string{uid}, nil
+	}
+	return nil, nil
+}
+
 func ByNamespaceAndControllerUidIndexKey(namespace string, uid types.UID) string {
 	if namespace == meta_v1.NamespaceNone {
 		return string(uid)
@@ -53,6 +53,27 @@ func (s *MultiBasic) GetInformers() map[schema.GroupVersionKind]cache.SharedInde
 	return informers
 }
 
+// HasInformer returns true if an Informer for the specified GVK is registered.
+func (s *MultiBasic) HasInformer(gvk schema.GroupVersionKind) bool {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	_, ok := s.informers[gvk]
+	return ok
+}
+
+// HasSynced returns true if Informers for all specified GVKs are registered and have synced.
+func (s *MultiBasic) HasSynced(gvks ...schema.GroupVersionKind) bool {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	for _, gvk := range gvks {
+		informer := s.informers[gvk]
+		if informer == nil || !informer.HasSynced() {
+			return false
+		}
+	}
+	return true
+}
+
 // Get looks up object of specified GVK in the specified namespace by name.
 // A deep copy of the object is returned so it is safe to modify it.
 func (s *MultiBasic) Get(gvk schema.GroupVersionKind, namespace, name string) (obj runtime.Object, exists bool, e error) {
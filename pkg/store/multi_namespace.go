@@ -0,0 +1,281 @@
+package store
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ash2k/stager/wait"
+	"github.com/pkg/errors"
+	"k8s.io/client-go/tools/cache"
+)
+
+// NewMultiNamespaceInformer returns an informer for objects in the namespaces. It is made of an informer per
+// namespace created by newInformer, so that a controller can be limited to a set of namespaces without permissions
+// to list and watch objects in all namespaces. If there is only one namespace its informer is returned as is.
+func NewMultiNamespaceInformer(namespaces []string, newInformer func(namespace string) cache.SharedIndexInformer) cache.SharedIndexInformer {
+	if len(namespaces) == 1 {
+		return newInformer(namespaces[0])
+	}
+	informers := make(map[string]cache.SharedIndexInformer, len(namespaces))
+	for _, namespace := range namespaces {
+		if _, ok := informers[namespace]; !ok {
+			informers[namespace] = newInformer(namespace)
+		}
+	}
+	return &multiNamespaceInformer{
+		indexer: multiNamespaceIndexer{
+			informers: informers,
+		},
+	}
+}
+
+type multiNamespaceInformer struct {
+	indexer multiNamespaceIndexer
+}
+
+func (i *multiNamespaceInformer) AddEventHandler(handler cache.ResourceEventHandler) {
+	for _, inf := range i.indexer.informers {
+		inf.AddEventHandler(handler)
+	}
+}
+
+func (i *multiNamespaceInformer) AddEventHandlerWithResyncPeriod(handler cache.ResourceEventHandler, resyncPeriod time.Duration) {
+	for _, inf := range i.indexer.informers {
+		inf.AddEventHandlerWithResyncPeriod(handler, resyncPeriod)
+	}
+}
+
+func (i *multiNamespaceInformer) GetStore() cache.Store {
+	return i.indexer
+}
+
+// GetController returns the informer itself because it runs informers of all namespaces.
+func (i *multiNamespaceInformer) GetController() cache.Controller {
+	return i
+}
+
+// Run runs informers of all namespaces until stopCh is closed.
+func (i *multiNamespaceInformer) Run(stopCh <-chan struct{}) {
+	var wg wait.Group
+	defer wg.Wait()
+	for _, inf := range i.indexer.informers {
+		wg.StartWithChannel(stopCh, inf.Run)
+	}
+}
+
+// HasSynced returns true if informers of all namespaces have synced.
+func (i *multiNamespaceInformer) HasSynced() bool {
+	for _, inf := range i.indexer.informers {
+		if !inf.HasSynced() {
+			return false
+		}
+	}
+	return true
+}
+
+// LastSyncResourceVersion returns an empty string because resource versions of different lists are not comparable.
+func (i *multiNamespaceInformer) LastSyncResourceVersion() string {
+	return ""
+}
+
+func (i *multiNamespaceInformer) AddIndexers(indexers cache.Indexers) error {
+	for namespace, inf := range i.indexer.informers {
+		if err := inf.AddIndexers(indexers); err != nil {
+			return errors.Wrapf(err, "failed to add indexers to informer for namespace %q", namespace)
+		}
+	}
+	return nil
+}
+
+func (i *multiNamespaceInformer) GetIndexer() cache.Indexer {
+	return i.indexer
+}
+
+// multiNamespaceIndexer routes operations on objects to the indexer of their namespace and merges results of
+// operations on all objects.
+type multiNamespaceIndexer struct {
+	informers map[string]cache.SharedIndexInformer
+}
+
+// indexerFor returns the indexer for the namespace of the object. Error is returned if the namespace is not watched.
+func (x multiNamespaceIndexer) indexerFor(obj interface{}) (cache.Indexer, error) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return nil, err
+	}
+	indexer, ok, err := x.indexerForKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.Errorf("namespace of object %q is not watched", key)
+	}
+	return indexer, nil
+}
+
+func (x multiNamespaceIndexer) indexerForKey(key string) (cache.Indexer, bool, error) {
+	namespace, _, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return nil, false, err
+	}
+	inf, ok := x.informers[namespace]
+	if !ok {
+		return nil, false, nil
+	}
+	return inf.GetIndexer(), true, nil
+}
+
+func (x multiNamespaceIndexer) Add(obj interface{}) error {
+	indexer, err := x.indexerFor(obj)
+	if err != nil {
+		return err
+	}
+	return indexer.Add(obj)
+}
+
+func (x multiNamespaceIndexer) Update(obj interface{}) error {
+	indexer, err := x.indexerFor(obj)
+	if err != nil {
+		return err
+	}
+	return indexer.Update(obj)
+}
+
+func (x multiNamespaceIndexer) Delete(obj interface{}) error {
+	indexer, err := x.indexerFor(obj)
+	if err != nil {
+		return err
+	}
+	return indexer.Delete(obj)
+}
+
+func (x multiNamespaceIndexer) List() []interface{} {
+	var result []interface{}
+	for _, inf := range x.informers {
+		result = append(result, inf.GetIndexer().List()...)
+	}
+	return result
+}
+
+func (x multiNamespaceIndexer) ListKeys() []string {
+	var result []string
+	for _, inf := range x.informers {
+		result = append(result, inf.GetIndexer().ListKeys()...)
+	}
+	return result
+}
+
+func (x multiNamespaceIndexer) Get(obj interface{}) (interface{}, bool, error) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return nil, false, err
+	}
+	return x.GetByKey(key)
+}
+
+func (x multiNamespaceIndexer) GetByKey(key string) (interface{}, bool, error) {
+	indexer, ok, err := x.indexerForKey(key)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	return indexer.GetByKey(key)
+}
+
+// Replace replaces contents of indexers of all namespaces. Objects in namespaces that are not watched are ignored.
+func (x multiNamespaceIndexer) Replace(objs []interface{}, resourceVersion string) error {
+	byNamespace := make(map[string][]interface{}, len(x.informers))
+	for _, obj := range objs {
+		key, err := cache.MetaNamespaceKeyFunc(obj)
+		if err != nil {
+			return err
+		}
+		namespace, _, err := cache.SplitMetaNamespaceKey(key)
+		if err != nil {
+			return err
+		}
+		byNamespace[namespace] = append(byNamespace[namespace], obj)
+	}
+	for namespace, inf := range x.informers {
+		if err := inf.GetIndexer().Replace(byNamespace[namespace], resourceVersion); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (x multiNamespaceIndexer) Resync() error {
+	for _, inf := range x.informers {
+		if err := inf.GetIndexer().Resync(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (x multiNamespaceIndexer) Index(indexName string, obj interface{}) ([]interface{}, error) {
+	var result []interface{}
+	for _, inf := range x.informers {
+		objs, err := inf.GetIndexer().Index(indexName, obj)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, objs...)
+	}
+	return result, nil
+}
+
+func (x multiNamespaceIndexer) IndexKeys(indexName, indexKey string) ([]string, error) {
+	var result []string
+	for _, inf := range x.informers {
+		keys, err := inf.GetIndexer().IndexKeys(indexName, indexKey)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, keys...)
+	}
+	return result, nil
+}
+
+func (x multiNamespaceIndexer) ListIndexFuncValues(indexName string) []string {
+	values := make(map[string]struct{})
+	for _, inf := range x.informers {
+		for _, value := range inf.GetIndexer().ListIndexFuncValues(indexName) {
+			values[value] = struct{}{}
+		}
+	}
+	result := make([]string, 0, len(values))
+	for value := range values {
+		result = append(result, value)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func (x multiNamespaceIndexer) ByIndex(indexName, indexKey string) ([]interface{}, error) {
+	var result []interface{}
+	for _, inf := range x.informers {
+		objs, err := inf.GetIndexer().ByIndex(indexName, indexKey)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, objs...)
+	}
+	return result, nil
+}
+
+// GetIndexers returns indexers of any of the namespaces. All of them have the same indexers.
+func (x multiNamespaceIndexer) GetIndexers() cache.Indexers {
+	for _, inf := range x.informers {
+		return inf.GetIndexer().GetIndexers()
+	}
+	return cache.Indexers{}
+}
+
+func (x multiNamespaceIndexer) AddIndexers(newIndexers cache.Indexers) error {
+	for namespace, inf := range x.informers {
+		if err := inf.GetIndexer().AddIndexers(newIndexers); err != nil {
+			return errors.Wrapf(err, "failed to add indexers to informer for namespace %q", namespace)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newTestInformer(namespace string) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(&cache.ListWatch{
+		ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+			return &core_v1.ConfigMapList{}, nil
+		},
+		WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+			return watch.NewFake(), nil
+		},
+	}, &core_v1.ConfigMap{}, 0, cache.Indexers{})
+}
+
+func configMap(namespace, name string) *core_v1.ConfigMap {
+	return &core_v1.ConfigMap{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+		},
+	}
+}
+
+func TestMultiNamespaceInformerSingleNamespace(t *testing.T) {
+	t.Parallel()
+	inf := NewMultiNamespaceInformer([]string{"ns1"}, newTestInformer)
+	_, ok := inf.(*multiNamespaceInformer)
+	assert.False(t, ok)
+}
+
+func TestMultiNamespaceIndexer(t *testing.T) {
+	t.Parallel()
+	inf := NewMultiNamespaceInformer([]string{"ns1", "ns2"}, newTestInformer)
+	require.NoError(t, inf.AddIndexers(cache.Indexers{
+		ByNamespaceAndControllerUidIndex: byNamespaceAndControllerUidIndex,
+	}))
+	indexer := inf.GetIndexer()
+	assert.Contains(t, indexer.GetIndexers(), ByNamespaceAndControllerUidIndex)
+
+	require.NoError(t, indexer.Add(configMap("ns1", "a")))
+	require.NoError(t, indexer.Add(configMap("ns2", "b")))
+	assert.Error(t, indexer.Add(configMap("ns3", "c")))
+
+	assert.Len(t, indexer.List(), 2)
+	assert.ElementsMatch(t, []string{"ns1/a", "ns2/b"}, indexer.ListKeys())
+
+	obj, exists, err := indexer.GetByKey("ns2/b")
+	require.NoError(t, err)
+	require.True(t, exists)
+	assert.Equal(t, "b", obj.(*core_v1.ConfigMap).Name)
+
+	_, exists, err = indexer.GetByKey("ns1/b")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	_, exists, err = indexer.GetByKey("ns3/c")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	require.NoError(t, indexer.Replace([]interface{}{configMap("ns1", "d"), configMap("ns3", "e")}, "1"))
+	assert.ElementsMatch(t, []string{"ns1/d"}, indexer.ListKeys())
+}
+
+func TestMultiNamespaceIndexerByIndex(t *testing.T) {
+	t.Parallel()
+	inf := NewMultiNamespaceInformer([]string{"ns1", "ns2"}, newTestInformer)
+	require.NoError(t, inf.AddIndexers(cache.Indexers{
+		ByNamespaceAndControllerUidIndex: byNamespaceAndControllerUidIndex,
+	}))
+	trueVar := true
+	for _, namespace := range []string{"ns1", "ns2"} {
+		cm := configMap(namespace, "a")
+		cm.OwnerReferences = []meta_v1.OwnerReference{{UID: "uid", Controller: &trueVar}}
+		require.NoError(t, inf.GetIndexer().Add(cm))
+	}
+
+	objs, err := inf.GetIndexer().ByIndex(ByNamespaceAndControllerUidIndex, ByNamespaceAndControllerUidIndexKey("ns2", "uid"))
+	require.NoError(t, err)
+	require.Len(t, objs, 1)
+	assert.Equal(t, "ns2", objs[0].(*core_v1.ConfigMap).Namespace)
+	assert.Equal(t, []string{"ns1|uid", "ns2|uid"}, inf.GetIndexer().ListIndexFuncValues(ByNamespaceAndControllerUidIndex))
+}
@@ -0,0 +1,266 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	k8s_json "k8s.io/apimachinery/pkg/util/json"
+)
+
+const (
+	otlpTracesPath = "/v1/traces"
+
+	defaultQueueSize     = 2048
+	defaultBatchSize     = 512
+	defaultFlushInterval = 5 * time.Second
+	shutdownFlushTimeout = 5 * time.Second
+	// maxErrorResponseSize is the maximum size of an error response that is read for the error message.
+	maxErrorResponseSize = 4 * 1024
+
+	// OTLP span kind and status codes
+	spanKindInternal = 1
+	statusCodeError  = 2
+)
+
+// OTLPExporter exports spans to an OpenTelemetry collector using OTLP over HTTP with JSON encoding.
+// Spans are queued and sent in batches. Spans are dropped if the queue is full.
+type OTLPExporter struct {
+	logger *zap.Logger
+	// endpoint is the base URL of the collector, e.g. http://otel-collector:4318.
+	endpoint    string
+	serviceName string
+	client      *http.Client
+
+	queue         chan *Span
+	batchSize     int
+	flushInterval time.Duration
+
+	exportedSpans prometheus.Counter
+	droppedSpans  prometheus.Counter
+}
+
+func NewOTLPExporter(logger *zap.Logger, endpoint, serviceName string, client *http.Client) *OTLPExporter {
+	return &OTLPExporter{
+		logger:        logger,
+		endpoint:      strings.TrimSuffix(endpoint, "/"),
+		serviceName:   serviceName,
+		client:        client,
+		queue:         make(chan *Span, defaultQueueSize),
+		batchSize:     defaultBatchSize,
+		flushInterval: defaultFlushInterval,
+		exportedSpans: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "smith",
+			Subsystem: "tracing",
+			Name:      "exported_spans_total",
+			Help:      "Number of spans exported to the OTLP endpoint",
+		}),
+		droppedSpans: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "smith",
+			Subsystem: "tracing",
+			Name:      "dropped_spans_total",
+			Help:      "Number of spans dropped because the queue was full or the export failed",
+		}),
+	}
+}
+
+// RegisterMetrics registers metrics of the exporter with the registerer.
+func (e *OTLPExporter) RegisterMetrics(registerer prometheus.Registerer) error {
+	for _, c := range []prometheus.Collector{e.exportedSpans, e.droppedSpans} {
+		if err := registerer.Register(c); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// ExportSpan queues the span to be exported.
+func (e *OTLPExporter) ExportSpan(span *Span) {
+	select {
+	case e.queue <- span:
+	default:
+		e.droppedSpans.Inc()
+	}
+}
+
+// Run sends queued spans until the context is done. Spans queued by then are sent before it returns.
+func (e *OTLPExporter) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.flushInterval)
+	defer ticker.Stop()
+	batch := make([]*Span, 0, e.batchSize)
+	for {
+		select {
+		case <-ctx.Done():
+			batch = e.drain(batch)
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownFlushTimeout)
+			defer cancel()
+			e.flush(shutdownCtx, batch)
+			return
+		case span := <-e.queue:
+			batch = append(batch, span)
+			if len(batch) >= e.batchSize {
+				e.flush(ctx, batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			e.flush(ctx, batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+// drain appends spans that are in the queue to the batch.
+func (e *OTLPExporter) drain(batch []*Span) []*Span {
+	for {
+		select {
+		case span := <-e.queue:
+			batch = append(batch, span)
+		default:
+			return batch
+		}
+	}
+}
+
+func (e *OTLPExporter) flush(ctx context.Context, batch []*Span) {
+	if len(batch) == 0 {
+		return
+	}
+	if err := e.send(ctx, batch); err != nil {
+		e.logger.Warn("Failed to export spans", zap.Int("spans", len(batch)), zap.Error(err))
+		e.droppedSpans.Add(float64(len(batch)))
+		return
+	}
+	e.exportedSpans.Add(float64(len(batch)))
+}
+
+func (e *OTLPExporter) send(ctx context.Context, batch []*Span) error {
+	body, err := k8s_json.Marshal(e.request(batch))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req, err := http.NewRequest(http.MethodPost, e.endpoint+otlpTracesPath, bytes.NewReader(body))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := e.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorResponseSize))
+		return errors.Errorf("unexpected response status %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	io.Copy(ioutil.Discard, resp.Body) // Drain the body so that the connection is reused
+	return nil
+}
+
+// OTLP JSON encoding of ExportTraceServiceRequest. 64-bit integers are encoded as strings and ids as hex strings.
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            *otlpStatus     `json:"status,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+func (e *OTLPExporter) request(batch []*Span) otlpRequest {
+	spans := make([]otlpSpan, 0, len(batch))
+	for _, span := range batch {
+		s := otlpSpan{
+			TraceID:           span.TraceID.String(),
+			SpanID:            span.SpanID.String(),
+			Name:              span.Name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.End.UnixNano(), 10),
+			Attributes:        otlpAttributes(span.Attributes),
+		}
+		if !span.ParentID.IsZero() {
+			s.ParentSpanID = span.ParentID.String()
+		}
+		if span.Error != "" {
+			s.Status = &otlpStatus{
+				Code:    statusCodeError,
+				Message: span.Error,
+			}
+		}
+		spans = append(spans, s)
+	}
+	return otlpRequest{
+		ResourceSpans: []otlpResourceSpans{
+			{
+				Resource: otlpResource{
+					Attributes: otlpAttributes([]Attribute{String("service.name", e.serviceName)}),
+				},
+				ScopeSpans: []otlpScopeSpans{
+					{
+						Scope: otlpScope{Name: e.serviceName},
+						Spans: spans,
+					},
+				},
+			},
+		},
+	}
+}
+
+func otlpAttributes(attributes []Attribute) []otlpAttribute {
+	result := make([]otlpAttribute, 0, len(attributes))
+	for _, a := range attributes {
+		result = append(result, otlpAttribute{
+			Key:   a.Key,
+			Value: otlpValue{StringValue: a.Value},
+		})
+	}
+	return result
+}
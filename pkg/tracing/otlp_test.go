@@ -0,0 +1,71 @@
+package tracing
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestOTLPExporter(t *testing.T) {
+	t.Parallel()
+	bodies := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		bodies <- string(body)
+	}))
+	defer srv.Close()
+
+	e := NewOTLPExporter(zaptest.NewLogger(t), srv.URL+"/", "smith", srv.Client())
+	require.NoError(t, e.RegisterMetrics(prometheus.NewPedanticRegistry()))
+	tracer := NewTracer(e)
+	start := time.Unix(1, 0)
+	tracer.now = func() time.Time {
+		return start
+	}
+	root := tracer.StartSpan("root", String("bundle.name", "b1"))
+	root.TraceID = TraceID{1}
+	root.SpanID = SpanID{2}
+	child := root.StartChild("child")
+	child.SpanID = SpanID{3}
+	child.SetError(errors.New("boom"))
+	child.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	e.Run(ctx) // Sends queued spans and returns
+
+	assert.JSONEq(t, `{"resourceSpans":[{
+		"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"smith"}}]},
+		"scopeSpans":[{"scope":{"name":"smith"},"spans":[{
+			"traceId":"01000000000000000000000000000000",
+			"spanId":"0300000000000000",
+			"parentSpanId":"0200000000000000",
+			"name":"child",
+			"kind":1,
+			"startTimeUnixNano":"1000000000",
+			"endTimeUnixNano":"1000000000",
+			"status":{"code":2,"message":"boom"}
+		}]}]
+	}]}`, <-bodies)
+}
+
+func TestOTLPExporterQueueFull(t *testing.T) {
+	t.Parallel()
+	e := NewOTLPExporter(zaptest.NewLogger(t), "http://localhost", "smith", http.DefaultClient)
+	e.queue = make(chan *Span, 1)
+	e.ExportSpan(&Span{})
+	e.ExportSpan(&Span{})
+	assert.Len(t, e.queue, 1)
+}
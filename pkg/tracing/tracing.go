@@ -0,0 +1,155 @@
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// SpanExporter exports finished spans.
+type SpanExporter interface {
+	// ExportSpan is called once a span has ended. It must not block.
+	ExportSpan(*Span)
+}
+
+// Tracer starts spans and hands them to the exporter once they end.
+// Nil Tracer is valid, it starts nil spans that do nothing.
+type Tracer struct {
+	exporter SpanExporter
+	now      func() time.Time
+}
+
+func NewTracer(exporter SpanExporter) *Tracer {
+	return &Tracer{
+		exporter: exporter,
+		now:      time.Now,
+	}
+}
+
+// StartSpan starts a span of a new trace.
+func (t *Tracer) StartSpan(name string, attributes ...Attribute) *Span {
+	if t == nil {
+		return nil
+	}
+	return t.start(newTraceID(), SpanID{}, name, attributes)
+}
+
+func (t *Tracer) start(traceID TraceID, parentID SpanID, name string, attributes []Attribute) *Span {
+	return &Span{
+		tracer:     t,
+		TraceID:    traceID,
+		SpanID:     newSpanID(),
+		ParentID:   parentID,
+		Name:       name,
+		Start:      t.now(),
+		Attributes: attributes,
+	}
+}
+
+// TraceID identifies a trace.
+type TraceID [16]byte
+
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// SpanID identifies a span within a trace.
+type SpanID [8]byte
+
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsZero returns true if the id is not set, e.g. the parent id of a root span.
+func (id SpanID) IsZero() bool {
+	return id == SpanID{}
+}
+
+// Attribute is a key-value pair describing a span.
+type Attribute struct {
+	Key   string
+	Value string
+}
+
+func String(key, value string) Attribute {
+	return Attribute{
+		Key:   key,
+		Value: value,
+	}
+}
+
+// Span is a timed operation. Nil Span is valid, all its methods do nothing.
+// Fields must not be modified once the span has ended.
+type Span struct {
+	tracer *Tracer
+
+	TraceID  TraceID
+	SpanID   SpanID
+	ParentID SpanID
+	Name     string
+	Start    time.Time
+	End      time.Time
+
+	mx         sync.Mutex
+	Attributes []Attribute
+	// Error is the message of the error the operation failed with. Empty if it has not failed.
+	Error string
+	ended bool
+}
+
+// StartChild starts a span of an operation that is part of the operation of the span.
+func (s *Span) StartChild(name string, attributes ...Attribute) *Span {
+	if s == nil {
+		return nil
+	}
+	return s.tracer.start(s.TraceID, s.SpanID, name, attributes)
+}
+
+// SetAttributes adds attributes to the span.
+func (s *Span) SetAttributes(attributes ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.Attributes = append(s.Attributes, attributes...)
+}
+
+// SetError marks the span as failed. Nil error is ignored.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.Error = err.Error()
+}
+
+// Finish ends the span and exports it. Subsequent calls do nothing.
+func (s *Span) Finish() {
+	if s == nil {
+		return
+	}
+	s.mx.Lock()
+	if s.ended {
+		s.mx.Unlock()
+		return
+	}
+	s.ended = true
+	s.End = s.tracer.now()
+	s.mx.Unlock()
+	s.tracer.exporter.ExportSpan(s)
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	rand.Read(id[:]) // Does not fail on supported platforms
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	rand.Read(id[:]) // Does not fail on supported platforms
+	return id
+}
@@ -0,0 +1,59 @@
+package tracing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type exportedSpans []*Span
+
+func (e *exportedSpans) ExportSpan(span *Span) {
+	*e = append(*e, span)
+}
+
+func TestSpans(t *testing.T) {
+	t.Parallel()
+	var exported exportedSpans
+	tracer := NewTracer(&exported)
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	tracer.now = func() time.Time {
+		return now
+	}
+
+	root := tracer.StartSpan("root", String("a", "1"))
+	child := root.StartChild("child")
+	now = now.Add(time.Second)
+	child.SetAttributes(String("b", "2"))
+	child.SetError(errors.New("boom"))
+	child.Finish()
+	child.Finish()
+	now = now.Add(time.Second)
+	root.Finish()
+
+	require.Len(t, exported, 2)
+	assert.Equal(t, root.TraceID, child.TraceID)
+	assert.Equal(t, root.SpanID, child.ParentID)
+	assert.True(t, root.ParentID.IsZero())
+	assert.NotEqual(t, root.SpanID, child.SpanID)
+	assert.Equal(t, []Attribute{{Key: "b", Value: "2"}}, child.Attributes)
+	assert.Equal(t, "boom", child.Error)
+	assert.Equal(t, time.Second, child.End.Sub(child.Start))
+	assert.Equal(t, 2*time.Second, root.End.Sub(root.Start))
+	assert.Empty(t, root.Error)
+}
+
+func TestNilSpans(t *testing.T) {
+	t.Parallel()
+	var tracer *Tracer
+	span := tracer.StartSpan("root")
+	child := span.StartChild("child")
+	assert.Nil(t, child)
+	child.SetAttributes(String("a", "1"))
+	child.SetError(errors.New("boom"))
+	child.Finish()
+	span.Finish()
+}
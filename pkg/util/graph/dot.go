@@ -0,0 +1,62 @@
+package graph
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Attributes are Graphviz attributes of a vertex, e.g. "color" or "label".
+type Attributes map[string]string
+
+// WriteDOT renders the graph in the Graphviz DOT format. Vertices are written in order of appearance, edges point
+// from a vertex to the vertices it depends on and are sorted by name. Names of vertices are formatted with fmt.
+// attributes returns attributes of a vertex and may be nil.
+func (g *Graph) WriteDOT(w io.Writer, name string, attributes func(name V, data D) Attributes) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "digraph %s {\n", dotID(name))
+	for _, v := range g.orderedVertices {
+		fmt.Fprintf(bw, "\t%s", dotID(fmt.Sprint(v)))
+		if attributes != nil {
+			writeDOTAttributes(bw, attributes(v, g.Vertices[v].Data))
+		}
+		bw.WriteString(";\n")
+	}
+	for _, v := range g.orderedVertices {
+		edges := make([]string, 0, len(g.Vertices[v].EdgesSet))
+		for _, edge := range g.Vertices[v].Edges() {
+			edges = append(edges, fmt.Sprint(edge))
+		}
+		sort.Strings(edges)
+		for _, edge := range edges {
+			fmt.Fprintf(bw, "\t%s -> %s;\n", dotID(fmt.Sprint(v)), dotID(edge))
+		}
+	}
+	bw.WriteString("}\n")
+	return errors.WithStack(bw.Flush())
+}
+
+func writeDOTAttributes(w io.Writer, attrs Attributes) {
+	if len(attrs) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, dotID(key)+"="+dotID(attrs[key]))
+	}
+	fmt.Fprintf(w, " [%s]", strings.Join(pairs, ", "))
+}
+
+// dotID returns the identifier as a DOT quoted string.
+func dotID(id string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(id) + `"`
+}
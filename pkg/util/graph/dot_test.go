@@ -0,0 +1,49 @@
+package graph
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteDOT(t *testing.T) {
+	t.Parallel()
+	g := initGraph()
+	require.NoError(t, g.AddEdge("a", "c"))
+	require.NoError(t, g.AddEdge("a", "b"))
+	require.NoError(t, g.AddEdge("b", "c"))
+
+	var buf bytes.Buffer
+	err := g.WriteDOT(&buf, "ns/bundle", func(name V, data D) Attributes {
+		if name == "c" {
+			return Attributes{
+				"label": "c\n\"ready\"",
+				"color": "green",
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `digraph "ns/bundle" {
+	"a";
+	"b";
+	"c" ["color"="green", "label"="c\n\"ready\""];
+	"d";
+	"a" -> "b";
+	"a" -> "c";
+	"b" -> "c";
+}
+`, buf.String())
+}
+
+func TestWriteDOTWithoutAttributes(t *testing.T) {
+	t.Parallel()
+	g := NewGraph(1)
+	g.AddVertex("a", nil)
+
+	var buf bytes.Buffer
+	require.NoError(t, g.WriteDOT(&buf, "g", nil))
+	assert.Equal(t, "digraph \"g\" {\n\t\"a\";\n}\n", buf.String())
+}
@@ -4,8 +4,17 @@ import (
 	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 func Resource(resourceName smith_v1.ResourceName) zapcore.Field {
 	return zap.String("resource", string(resourceName))
 }
+
+func ResourceGvk(gvk schema.GroupVersionKind) zapcore.Field {
+	return zap.String("gvk", gvk.String())
+}
+
+func Plugin(pluginName smith_v1.PluginName) zapcore.Field {
+	return zap.String("plugin", string(pluginName))
+}
@@ -0,0 +1,54 @@
+package validate
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+	api_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ResourcesLister lists resources of a group version, e.g. a discovery client.
+type ResourcesLister interface {
+	ServerResourcesForGroupVersion(groupVersion string) (*meta_v1.APIResourceList, error)
+}
+
+// DiscoveryKinds checks kinds against API discovery. Resources of each group version are only listed once.
+type DiscoveryKinds struct {
+	lister ResourcesLister
+
+	mx    sync.Mutex
+	kinds map[schema.GroupVersion]map[string]struct{}
+}
+
+// NewDiscoveryKinds returns a KindChecker that uses the lister, e.g. a discovery client.
+func NewDiscoveryKinds(lister ResourcesLister) *DiscoveryKinds {
+	return &DiscoveryKinds{
+		lister: lister,
+		kinds:  make(map[schema.GroupVersion]map[string]struct{}),
+	}
+}
+
+// IsKnownKind returns true if the API server serves the kind at the group version.
+func (d *DiscoveryKinds) IsKnownKind(gvk schema.GroupVersionKind) (bool, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	gv := gvk.GroupVersion()
+	kinds, ok := d.kinds[gv]
+	if !ok {
+		list, err := d.lister.ServerResourcesForGroupVersion(gv.String())
+		if err != nil && !api_errors.IsNotFound(err) {
+			return false, errors.Wrapf(err, "failed to discover resources of %s", gv)
+		}
+		kinds = make(map[string]struct{})
+		if list != nil {
+			for _, resource := range list.APIResources {
+				kinds[resource.Kind] = struct{}{}
+			}
+		}
+		d.kinds[gv] = kinds
+	}
+	_, ok = kinds[gvk.Kind]
+	return ok, nil
+}
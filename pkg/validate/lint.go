@@ -0,0 +1,138 @@
+// Package validate lints Bundle manifests with the checks the admission webhooks perform, optionally also checking
+// that kinds of objects are served by a cluster, so that problems are found before Bundles are applied.
+package validate
+
+import (
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/atlassian/smith/pkg/util"
+	"github.com/atlassian/smith/pkg/webhook"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8s_json "k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// KindChecker tells if objects of a kind can be created, e.g. because the kind is served by the API server.
+type KindChecker interface {
+	IsKnownKind(gvk schema.GroupVersionKind) (bool, error)
+}
+
+// Result is the outcome of linting a Bundle.
+type Result struct {
+	// Bundle is the linted Bundle with defaults applied.
+	Bundle *smith_v1.Bundle
+	// Errors are problems that would make processing of the Bundle fail.
+	Errors field.ErrorList
+	// Warnings are issues that do not prevent processing. Only checked if there are no errors.
+	Warnings []smith_v1.BundleWarning
+}
+
+// Valid returns true if there are no errors.
+func (r *Result) Valid() bool {
+	return len(r.Errors) == 0
+}
+
+// Linter lints Bundles. The zero value performs the same checks as the admission webhooks.
+type Linter struct {
+	// Kinds is used to check that kinds of objects and external objects are known. Not checked if nil.
+	Kinds KindChecker
+	// ReadinessRules is used to warn about objects of kinds without a readiness rule. Not checked if nil.
+	ReadinessRules webhook.ReadinessRules
+}
+
+// LintManifest lints a Bundle manifest in JSON. Defaults are applied first, the same way the defaulting webhook does.
+func (l *Linter) LintManifest(data []byte) (*Result, error) {
+	bundle, err := DefaultedBundle(data)
+	if err != nil {
+		return nil, err
+	}
+	return l.Lint(bundle)
+}
+
+// Lint lints a Bundle that has defaults applied already:
+// - the checks of webhook.ValidateBundle, e.g. duplicate resource names, invalid references and dependency cycles;
+// - kinds of objects and external objects are known, if the linter has a KindChecker;
+// - the checks of webhook.WarnBundle, if there are no errors.
+func (l *Linter) Lint(bundle *smith_v1.Bundle) (*Result, error) {
+	result := &Result{
+		Bundle: bundle,
+		Errors: webhook.ValidateBundle(bundle),
+	}
+	if l.Kinds != nil {
+		errs, err := l.unknownKinds(bundle)
+		if err != nil {
+			return nil, err
+		}
+		result.Errors = append(result.Errors, errs...)
+	}
+	if !result.Valid() {
+		return result, nil
+	}
+	warnings, err := webhook.WarnBundle(bundle, l.ReadinessRules)
+	if err != nil {
+		return nil, err
+	}
+	result.Warnings = warnings
+	return result, nil
+}
+
+// unknownKinds returns errors for resources with objects of kinds that the KindChecker does not know.
+// Kinds of objects produced by plugins are not known without running the plugins and are not checked.
+func (l *Linter) unknownKinds(bundle *smith_v1.Bundle) (field.ErrorList, error) {
+	var errs field.ErrorList
+	resourcesPath := field.NewPath("spec", "resources")
+	for i, res := range bundle.Spec.Resources {
+		var gvk schema.GroupVersionKind
+		var kindPath *field.Path
+		switch {
+		case res.Spec.Object != nil:
+			obj, err := util.RuntimeToUnstructured(res.Spec.Object)
+			if err != nil {
+				// Reported by ValidateBundle
+				continue
+			}
+			gvk = obj.GroupVersionKind()
+			kindPath = resourcesPath.Index(i).Child("spec", "object", "kind")
+		case res.Spec.External != nil:
+			gvk = res.Spec.External.GroupVersionKind()
+			kindPath = resourcesPath.Index(i).Child("spec", "external", "kind")
+		default:
+			continue
+		}
+		if gvk.Kind == "" || gvk.Version == "" {
+			continue
+		}
+		known, err := l.Kinds.IsKnownKind(gvk)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to check kind of resource %q", res.Name)
+		}
+		if !known {
+			errs = append(errs, field.Invalid(kindPath, gvk.Kind, "kind "+gvk.String()+" is not served by the cluster"))
+		}
+	}
+	return errs, nil
+}
+
+// DefaultedBundle unmarshals a Bundle manifest in JSON and applies defaults the same way the defaulting webhook does.
+func DefaultedBundle(data []byte) (*smith_v1.Bundle, error) {
+	var obj map[string]interface{}
+	if err := k8s_json.Unmarshal(data, &obj); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal Bundle")
+	}
+	spec, changed, err := webhook.DefaultBundle(obj)
+	if err != nil {
+		return nil, err
+	}
+	if changed {
+		obj["spec"] = spec
+	}
+	data, err = k8s_json.Marshal(obj)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var bundle smith_v1.Bundle
+	if err = k8s_json.Unmarshal(data, &bundle); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal Bundle")
+	}
+	return &bundle, nil
+}
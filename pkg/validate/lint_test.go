@@ -0,0 +1,178 @@
+package validate
+
+import (
+	"testing"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core_v1 "k8s.io/api/core/v1"
+	api_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type fakeLister struct {
+	resources map[string][]meta_v1.APIResource
+	calls     map[string]int
+}
+
+func (l *fakeLister) ServerResourcesForGroupVersion(groupVersion string) (*meta_v1.APIResourceList, error) {
+	l.calls[groupVersion]++
+	resources, ok := l.resources[groupVersion]
+	if !ok {
+		return nil, api_errors.NewNotFound(schema.GroupResource{}, "")
+	}
+	return &meta_v1.APIResourceList{
+		GroupVersion: groupVersion,
+		APIResources: resources,
+	}, nil
+}
+
+func configMapResource(name smith_v1.ResourceName, refs ...smith_v1.Reference) smith_v1.Resource {
+	return smith_v1.Resource{
+		Name:       name,
+		References: refs,
+		Spec: smith_v1.ResourceSpec{
+			Object: &core_v1.ConfigMap{
+				TypeMeta: meta_v1.TypeMeta{
+					Kind:       "ConfigMap",
+					APIVersion: core_v1.SchemeGroupVersion.String(),
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name: string(name),
+				},
+			},
+		},
+	}
+}
+
+func bundleOf(resources ...smith_v1.Resource) *smith_v1.Bundle {
+	return &smith_v1.Bundle{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name: "b1",
+		},
+		Spec: smith_v1.BundleSpec{
+			Resources: resources,
+		},
+	}
+}
+
+func TestLint(t *testing.T) {
+	t.Parallel()
+	database := smith_v1.Resource{
+		Name: "db",
+		Spec: smith_v1.ResourceSpec{
+			External: &smith_v1.ExternalObject{
+				APIVersion: "example.com/v1",
+				Kind:       "Database",
+				Name:       "db1",
+			},
+		},
+	}
+	testcases := map[string]struct {
+		bundle   *smith_v1.Bundle
+		fields   []string
+		warnings int
+	}{
+		"valid": {
+			bundle: bundleOf(configMapResource("a"), configMapResource("b", smith_v1.Reference{Resource: "a"})),
+		},
+		"duplicate names": {
+			bundle: bundleOf(configMapResource("a"), configMapResource("a")),
+			fields: []string{"spec.resources[1].name"},
+		},
+		"invalid reference": {
+			bundle: bundleOf(configMapResource("a", smith_v1.Reference{Resource: "missing"})),
+			fields: []string{"spec.resources[0].references[0].resource"},
+		},
+		"unknown kind of external object": {
+			bundle: bundleOf(database, configMapResource("a", smith_v1.Reference{Resource: "db"})),
+			fields: []string{"spec.resources[0].spec.external.kind"},
+		},
+		"warnings": {
+			bundle:   bundleOf(configMapResource("a"), configMapResource("b", smith_v1.Reference{Resource: "a"}, smith_v1.Reference{Resource: "a"})),
+			warnings: 1,
+		},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			linter := &Linter{
+				Kinds: NewDiscoveryKinds(&fakeLister{
+					resources: map[string][]meta_v1.APIResource{
+						"v1": {{Name: "configmaps", Kind: "ConfigMap"}},
+					},
+					calls: make(map[string]int),
+				}),
+			}
+			result, err := linter.Lint(tc.bundle)
+			require.NoError(t, err)
+			var fields []string
+			for _, e := range result.Errors {
+				fields = append(fields, e.Field)
+			}
+			assert.Equal(t, tc.fields, fields)
+			assert.Equal(t, len(tc.fields) == 0, result.Valid())
+			assert.Len(t, result.Warnings, tc.warnings)
+		})
+	}
+}
+
+func TestLintWithoutKinds(t *testing.T) {
+	t.Parallel()
+	bundle := bundleOf(smith_v1.Resource{
+		Name: "db",
+		Spec: smith_v1.ResourceSpec{
+			External: &smith_v1.ExternalObject{
+				APIVersion: "example.com/v1",
+				Kind:       "Database",
+				Name:       "db1",
+			},
+		},
+	})
+	result, err := (&Linter{}).Lint(bundle)
+	require.NoError(t, err)
+	assert.True(t, result.Valid())
+}
+
+func TestLintManifestAppliesDefaults(t *testing.T) {
+	t.Parallel()
+	manifest := []byte(`{
+		"apiVersion": "smith.atlassian.com/v1",
+		"kind": "Bundle",
+		"metadata": {"name": "b1"},
+		"spec": {"resources": [{"name": "a", "spec": {"object": {"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "a"}}}}]}
+	}`)
+	result, err := (&Linter{}).LintManifest(manifest)
+	require.NoError(t, err)
+	assert.True(t, result.Valid())
+	assert.Equal(t, "b1", result.Bundle.Name)
+	require.Len(t, result.Bundle.Spec.Resources, 1)
+}
+
+func TestDiscoveryKindsCachesGroupVersions(t *testing.T) {
+	t.Parallel()
+	lister := &fakeLister{
+		resources: map[string][]meta_v1.APIResource{
+			"v1": {{Name: "configmaps", Kind: "ConfigMap"}, {Name: "secrets", Kind: "Secret"}},
+		},
+		calls: make(map[string]int),
+	}
+	kinds := NewDiscoveryKinds(lister)
+	for _, kind := range []string{"ConfigMap", "Secret"} {
+		known, err := kinds.IsKnownKind(schema.GroupVersionKind{Version: "v1", Kind: kind})
+		require.NoError(t, err)
+		assert.True(t, known, kind)
+	}
+	known, err := kinds.IsKnownKind(schema.GroupVersionKind{Version: "v1", Kind: "Missing"})
+	require.NoError(t, err)
+	assert.False(t, known)
+	for i := 0; i < 2; i++ {
+		known, err = kinds.IsKnownKind(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Database"})
+		require.NoError(t, err)
+		assert.False(t, known)
+	}
+	assert.Equal(t, map[string]int{"v1": 1, "example.com/v1": 1}, lister.calls)
+}
@@ -0,0 +1,113 @@
+package webhook
+
+import (
+	"strings"
+
+	"github.com/atlassian/smith"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	admission_v1b1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8s_json "k8s.io/apimachinery/pkg/util/json"
+)
+
+// AuthoredVersionMetrics counts writes of Bundle specs by the API version they were written via, so that operators
+// can see when clients have migrated and an old version can be retired.
+type AuthoredVersionMetrics struct {
+	writes *prometheus.CounterVec
+}
+
+func NewAuthoredVersionMetrics() *AuthoredVersionMetrics {
+	return &AuthoredVersionMetrics{
+		writes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "smith",
+			Subsystem: "webhook",
+			Name:      "bundle_writes_total",
+			Help:      "Number of creates and spec updates of Bundles by the API version they were written via",
+		}, []string{"version"}),
+	}
+}
+
+// RegisterMetrics registers the metrics with the registerer.
+func (m *AuthoredVersionMetrics) RegisterMetrics(registerer prometheus.Registerer) error {
+	return errors.WithStack(registerer.Register(m.writes))
+}
+
+func (m *AuthoredVersionMetrics) observe(version string) {
+	if m == nil {
+		return
+	}
+	m.writes.WithLabelValues(version).Inc()
+}
+
+// authoredVersion returns the API version the spec of the Bundle in the request was written via, or an empty string
+// if the spec was not written, i.e. the update only changed metadata or status. Updates of the controller are such
+// updates, they preserve the version the spec was written via by a client.
+func authoredVersion(req *admission_v1b1.AdmissionRequest, bundle map[string]interface{}) (string, error) {
+	if req.Operation == admission_v1b1.Update {
+		var oldBundle map[string]interface{}
+		if err := k8s_json.Unmarshal(req.OldObject.Raw, &oldBundle); err != nil {
+			return "", errors.Wrap(err, "failed to unmarshal old Bundle")
+		}
+		if equality.Semantic.DeepEqual(oldBundle["spec"], bundle["spec"]) {
+			return "", nil
+		}
+	}
+	return meta_v1.GroupVersion{Group: req.Kind.Group, Version: req.Kind.Version}.String(), nil
+}
+
+// authoredVersionPatch returns a JSON patch operation that sets the AuthoredVersionAnnotation of the Bundle to the
+// version or nil if it is set to it already.
+func authoredVersionPatch(bundle map[string]interface{}, version string) map[string]interface{} {
+	metadata, ok := bundle["metadata"].(map[string]interface{})
+	if !ok {
+		return map[string]interface{}{
+			"op":   "add",
+			"path": "/metadata",
+			"value": map[string]interface{}{
+				"annotations": map[string]interface{}{
+					smith.AuthoredVersionAnnotation: version,
+				},
+			},
+		}
+	}
+	annotations, ok := metadata["annotations"].(map[string]interface{})
+	if !ok {
+		return map[string]interface{}{
+			"op":   "add",
+			"path": "/metadata/annotations",
+			"value": map[string]interface{}{
+				smith.AuthoredVersionAnnotation: version,
+			},
+		}
+	}
+	if annotations[smith.AuthoredVersionAnnotation] == version {
+		return nil
+	}
+	return map[string]interface{}{
+		"op":    "add",
+		"path":  "/metadata/annotations/" + escapeJSONPointer(smith.AuthoredVersionAnnotation),
+		"value": version,
+	}
+}
+
+// setAuthoredVersionIfMissing sets the AuthoredVersionAnnotation of a converted Bundle to the version it was
+// converted from unless it has been recorded already. Bundles written before the defaulting webhook recorded the
+// annotation are converted from the version they are stored in, that is the best guess there is.
+func setAuthoredVersionIfMissing(bundle meta_v1.Object, version string) {
+	annotations := bundle.GetAnnotations()
+	if _, ok := annotations[smith.AuthoredVersionAnnotation]; ok {
+		return
+	}
+	if annotations == nil {
+		annotations = make(map[string]string, 1)
+	}
+	annotations[smith.AuthoredVersionAnnotation] = version
+	bundle.SetAnnotations(annotations)
+}
+
+// escapeJSONPointer escapes a reference token of a JSON pointer, see RFC 6901.
+func escapeJSONPointer(token string) string {
+	return strings.Replace(strings.Replace(token, "~", "~0", -1), "/", "~1", -1)
+}
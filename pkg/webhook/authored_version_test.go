@@ -0,0 +1,202 @@
+package webhook
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	smith_v2alpha1 "github.com/atlassian/smith/pkg/apis/smith/v2alpha1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	admission_v1b1 "k8s.io/api/admission/v1beta1"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func authoredVersionRequest(t *testing.T, version string, operation admission_v1b1.Operation, bundle, oldBundle *smith_v1.Bundle) *admission_v1b1.AdmissionRequest {
+	raw, err := json.Marshal(bundle)
+	require.NoError(t, err)
+	req := &admission_v1b1.AdmissionRequest{
+		UID: "uid1",
+		Kind: meta_v1.GroupVersionKind{
+			Group:   smith_v1.SchemeGroupVersion.Group,
+			Version: version,
+			Kind:    smith_v1.BundleResourceKind,
+		},
+		Operation: operation,
+		Object: runtime.RawExtension{
+			Raw: raw,
+		},
+	}
+	if oldBundle != nil {
+		req.OldObject.Raw, err = json.Marshal(oldBundle)
+		require.NoError(t, err)
+	}
+	return req
+}
+
+func TestBundleDefaulterRecordsAuthoredVersion(t *testing.T) {
+	t.Parallel()
+	authored := func(version string) *smith_v1.Bundle {
+		bundle := bundleOf(configMapResource("a", map[string]string{"a": "b"}))
+		bundle.Annotations = map[string]string{
+			"other": "value",
+		}
+		if version != "" {
+			bundle.Annotations[smith.AuthoredVersionAnnotation] = version
+		}
+		bundle.Spec.Resources[0].Spec.Object.(*core_v1.ConfigMap).Labels = map[string]string{
+			smith.BundleNameLabel: "bundle1",
+		}
+		return bundle
+	}
+	changedSpec := authored(smith_v1.BundleResourceGroupVersion)
+	changedSpec.Spec.Resources[0].Spec.Object.(*core_v1.ConfigMap).Data["a"] = "c"
+
+	testcases := map[string]struct {
+		req           *admission_v1b1.AdmissionRequest
+		expectedPatch []patchOperation
+		expectedCount map[string]float64
+	}{
+		"create": {
+			req: authoredVersionRequest(t, smith_v2alpha1.SchemeGroupVersion.Version, admission_v1b1.Create, authored(""), nil),
+			expectedPatch: []patchOperation{
+				{
+					Op:    "add",
+					Path:  "/metadata/annotations/smith.atlassian.com~1AuthoredVersion",
+					Value: json.RawMessage(`"smith.atlassian.com/v2alpha1"`),
+				},
+			},
+			expectedCount: map[string]float64{
+				smith_v2alpha1.BundleResourceGroupVersion: 1,
+			},
+		},
+		"create with recorded version": {
+			req: authoredVersionRequest(t, smith_v1.SchemeGroupVersion.Version, admission_v1b1.Create, authored(smith_v1.BundleResourceGroupVersion), nil),
+			expectedCount: map[string]float64{
+				smith_v1.BundleResourceGroupVersion: 1,
+			},
+		},
+		"update of spec": {
+			req: authoredVersionRequest(t, smith_v2alpha1.SchemeGroupVersion.Version, admission_v1b1.Update, changedSpec, authored(smith_v1.BundleResourceGroupVersion)),
+			expectedPatch: []patchOperation{
+				{
+					Op:    "add",
+					Path:  "/metadata/annotations/smith.atlassian.com~1AuthoredVersion",
+					Value: json.RawMessage(`"smith.atlassian.com/v2alpha1"`),
+				},
+			},
+			expectedCount: map[string]float64{
+				smith_v2alpha1.BundleResourceGroupVersion: 1,
+			},
+		},
+		"update of metadata": {
+			req:           authoredVersionRequest(t, smith_v1.SchemeGroupVersion.Version, admission_v1b1.Update, authored(smith_v2alpha1.BundleResourceGroupVersion), authored(smith_v2alpha1.BundleResourceGroupVersion)),
+			expectedCount: map[string]float64{},
+		},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			metrics := NewAuthoredVersionMetrics()
+			registry := prometheus.NewPedanticRegistry()
+			require.NoError(t, metrics.RegisterMetrics(registry))
+
+			response := serveRequest(t, &BundleDefaulter{
+				Logger:           zaptest.NewLogger(t),
+				AuthoredVersions: metrics,
+			}, BundleDefaultingPath, tc.req)
+			assert.True(t, response.Allowed)
+			if tc.expectedPatch == nil {
+				assert.Empty(t, response.Patch)
+			} else {
+				assert.Equal(t, tc.expectedPatch, decodePatch(t, response.Patch))
+			}
+
+			families, err := registry.Gather()
+			require.NoError(t, err)
+			counts := make(map[string]float64)
+			for _, family := range families {
+				require.Equal(t, "smith_webhook_bundle_writes_total", family.GetName())
+				for _, metric := range family.GetMetric() {
+					require.Len(t, metric.GetLabel(), 1)
+					counts[metric.GetLabel()[0].GetValue()] = metric.GetCounter().GetValue()
+				}
+			}
+			assert.Equal(t, tc.expectedCount, counts)
+		})
+	}
+}
+
+func TestAuthoredVersionPatch(t *testing.T) {
+	t.Parallel()
+	testcases := map[string]struct {
+		bundle   map[string]interface{}
+		expected map[string]interface{}
+	}{
+		"no metadata": {
+			bundle: map[string]interface{}{},
+			expected: map[string]interface{}{
+				"op":   "add",
+				"path": "/metadata",
+				"value": map[string]interface{}{
+					"annotations": map[string]interface{}{
+						smith.AuthoredVersionAnnotation: "smith.atlassian.com/v1",
+					},
+				},
+			},
+		},
+		"no annotations": {
+			bundle: map[string]interface{}{
+				"metadata": map[string]interface{}{},
+			},
+			expected: map[string]interface{}{
+				"op":   "add",
+				"path": "/metadata/annotations",
+				"value": map[string]interface{}{
+					smith.AuthoredVersionAnnotation: "smith.atlassian.com/v1",
+				},
+			},
+		},
+		"other version": {
+			bundle: map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"annotations": map[string]interface{}{
+						smith.AuthoredVersionAnnotation: "smith.atlassian.com/v2alpha1",
+					},
+				},
+			},
+			expected: map[string]interface{}{
+				"op":    "add",
+				"path":  "/metadata/annotations/smith.atlassian.com~1AuthoredVersion",
+				"value": "smith.atlassian.com/v1",
+			},
+		},
+		"same version": {
+			bundle: map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"annotations": map[string]interface{}{
+						smith.AuthoredVersionAnnotation: "smith.atlassian.com/v1",
+					},
+				},
+			},
+		},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expected, authoredVersionPatch(tc.bundle, "smith.atlassian.com/v1"))
+		})
+	}
+}
+
+func TestEscapeJSONPointer(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "a~1b~0c", escapeJSONPointer("a/b~c"))
+}
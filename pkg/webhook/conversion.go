@@ -0,0 +1,122 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	smith_v2alpha1 "github.com/atlassian/smith/pkg/apis/smith/v2alpha1"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// conversionReview is a ConversionReview of the apiextensions.k8s.io/v1beta1 API. The API client Smith is built with
+// predates conversion webhooks.
+type conversionReview struct {
+	meta_v1.TypeMeta `json:",inline"`
+	Request          *conversionRequest  `json:"request,omitempty"`
+	Response         *conversionResponse `json:"response,omitempty"`
+}
+
+type conversionRequest struct {
+	UID               types.UID              `json:"uid"`
+	DesiredAPIVersion string                 `json:"desiredAPIVersion"`
+	Objects           []runtime.RawExtension `json:"objects"`
+}
+
+type conversionResponse struct {
+	UID              types.UID              `json:"uid"`
+	ConvertedObjects []runtime.RawExtension `json:"convertedObjects"`
+	Result           meta_v1.Status         `json:"result"`
+}
+
+// BundleConverter is a CRD conversion webhook handler that converts Bundles between the v1 and v2alpha1 versions.
+// See ConvertBundle.
+type BundleConverter struct {
+	Logger *zap.Logger
+}
+
+func (c *BundleConverter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var review conversionReview
+	if !decodeRequest(w, r, &review) {
+		return
+	}
+	if review.Request == nil {
+		http.Error(w, "request is missing", http.StatusBadRequest)
+		return
+	}
+	review.Response = c.convert(review.Request)
+	review.Request = nil
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(&review); err != nil {
+		c.Logger.Error("Failed to write conversion response", zap.Error(err))
+	}
+}
+
+func (c *BundleConverter) convert(req *conversionRequest) *conversionResponse {
+	resp := &conversionResponse{
+		UID:              req.UID,
+		ConvertedObjects: make([]runtime.RawExtension, 0, len(req.Objects)),
+	}
+	for i, obj := range req.Objects {
+		converted, err := ConvertBundle(obj.Raw, req.DesiredAPIVersion)
+		if err != nil {
+			// All or nothing, the API server rejects partial results
+			c.Logger.Info("Failed to convert Bundle", zap.String("desiredAPIVersion", req.DesiredAPIVersion), zap.Error(err))
+			resp.ConvertedObjects = nil
+			resp.Result = meta_v1.Status{
+				Status:  meta_v1.StatusFailure,
+				Reason:  meta_v1.StatusReasonBadRequest,
+				Message: errors.Wrapf(err, "objects[%d]", i).Error(),
+			}
+			return resp
+		}
+		resp.ConvertedObjects = append(resp.ConvertedObjects, runtime.RawExtension{Raw: converted})
+	}
+	resp.Result = meta_v1.Status{
+		Status: meta_v1.StatusSuccess,
+	}
+	return resp
+}
+
+// ConvertBundle converts a Bundle given as JSON to the desired API version. Bundles that are at that version
+// already are returned as is. Fields that neither version defines are dropped. The AuthoredVersionAnnotation is set
+// to the version the Bundle is converted from if it is not set.
+func ConvertBundle(raw []byte, desiredAPIVersion string) ([]byte, error) {
+	var typeMeta meta_v1.TypeMeta
+	if err := json.Unmarshal(raw, &typeMeta); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal Bundle")
+	}
+	if typeMeta.Kind != smith_v1.BundleResourceKind {
+		return nil, errors.Errorf("unexpected kind %q", typeMeta.Kind)
+	}
+	if typeMeta.APIVersion == desiredAPIVersion {
+		return raw, nil
+	}
+	var converted meta_v1.Object
+	switch {
+	case typeMeta.APIVersion == smith_v1.BundleResourceGroupVersion && desiredAPIVersion == smith_v2alpha1.BundleResourceGroupVersion:
+		var bundle smith_v1.Bundle
+		if err := json.Unmarshal(raw, &bundle); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal Bundle")
+		}
+		converted = smith_v2alpha1.ConvertFromV1(&bundle)
+	case typeMeta.APIVersion == smith_v2alpha1.BundleResourceGroupVersion && desiredAPIVersion == smith_v1.BundleResourceGroupVersion:
+		var bundle smith_v2alpha1.Bundle
+		if err := json.Unmarshal(raw, &bundle); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal Bundle")
+		}
+		converted = smith_v2alpha1.ConvertToV1(&bundle)
+	default:
+		return nil, errors.Errorf("conversion from %q to %q is not supported", typeMeta.APIVersion, desiredAPIVersion)
+	}
+	setAuthoredVersionIfMissing(converted, typeMeta.APIVersion)
+	result, err := json.Marshal(converted)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal Bundle")
+	}
+	return result, nil
+}
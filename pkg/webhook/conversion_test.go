@@ -0,0 +1,116 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	smith_v2alpha1 "github.com/atlassian/smith/pkg/apis/smith/v2alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func convert(t *testing.T, desiredAPIVersion string, objects ...interface{}) *conversionResponse {
+	review := conversionReview{
+		Request: &conversionRequest{
+			UID:               "uid1",
+			DesiredAPIVersion: desiredAPIVersion,
+		},
+	}
+	for _, obj := range objects {
+		raw, err := json.Marshal(obj)
+		require.NoError(t, err)
+		review.Request.Objects = append(review.Request.Objects, runtime.RawExtension{Raw: raw})
+	}
+	body, err := json.Marshal(&review)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	converter := &BundleConverter{
+		Logger: zaptest.NewLogger(t),
+	}
+	converter.ServeHTTP(w, httptest.NewRequest(http.MethodPost, BundleConversionPath, bytes.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response conversionReview
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.NotNil(t, response.Response)
+	assert.EqualValues(t, "uid1", response.Response.UID)
+	return response.Response
+}
+
+func TestBundleConverter(t *testing.T) {
+	t.Parallel()
+	v1Bundle := bundleOf(configMapResource("a", nil))
+	v1Bundle.TypeMeta = meta_v1.TypeMeta{
+		APIVersion: smith_v1.BundleResourceGroupVersion,
+		Kind:       smith_v1.BundleResourceKind,
+	}
+	v1Bundle.Spec.Resources[0].DeletionPolicy = smith_v1.DeletionPolicyOrphan
+
+	response := convert(t, smith_v2alpha1.BundleResourceGroupVersion, v1Bundle)
+	assert.Equal(t, meta_v1.StatusSuccess, response.Result.Status)
+	require.Len(t, response.ConvertedObjects, 1)
+	var v2Bundle smith_v2alpha1.Bundle
+	require.NoError(t, json.Unmarshal(response.ConvertedObjects[0].Raw, &v2Bundle))
+	assert.Equal(t, smith_v2alpha1.BundleResourceGroupVersion, v2Bundle.APIVersion)
+	assert.Equal(t, smith_v1.BundleResourceGroupVersion, v2Bundle.Annotations[smith.AuthoredVersionAnnotation])
+	require.Len(t, v2Bundle.Spec.Resources, 1)
+	require.NotNil(t, v2Bundle.Spec.Resources[0].Policies)
+	assert.Equal(t, smith_v1.DeletionPolicyOrphan, v2Bundle.Spec.Resources[0].Policies.Deletion)
+
+	response = convert(t, smith_v1.BundleResourceGroupVersion, &v2Bundle, v1Bundle)
+	assert.Equal(t, meta_v1.StatusSuccess, response.Result.Status)
+	require.Len(t, response.ConvertedObjects, 2)
+	for _, obj := range response.ConvertedObjects {
+		var bundle smith_v1.Bundle
+		require.NoError(t, json.Unmarshal(obj.Raw, &bundle))
+		assert.Equal(t, smith_v1.BundleResourceGroupVersion, bundle.APIVersion)
+		require.Len(t, bundle.Spec.Resources, 1)
+		assert.Equal(t, smith_v1.DeletionPolicyOrphan, bundle.Spec.Resources[0].DeletionPolicy)
+	}
+	// The recorded version is preserved
+	var bundle smith_v1.Bundle
+	require.NoError(t, json.Unmarshal(response.ConvertedObjects[0].Raw, &bundle))
+	assert.Equal(t, smith_v1.BundleResourceGroupVersion, bundle.Annotations[smith.AuthoredVersionAnnotation])
+}
+
+func TestBundleConverterPreservesAuthoredVersion(t *testing.T) {
+	t.Parallel()
+	v1Bundle := bundleOf(configMapResource("a", nil))
+	v1Bundle.TypeMeta = meta_v1.TypeMeta{
+		APIVersion: smith_v1.BundleResourceGroupVersion,
+		Kind:       smith_v1.BundleResourceKind,
+	}
+	v1Bundle.Annotations = map[string]string{
+		smith.AuthoredVersionAnnotation: smith_v2alpha1.BundleResourceGroupVersion,
+	}
+
+	response := convert(t, smith_v2alpha1.BundleResourceGroupVersion, v1Bundle)
+	assert.Equal(t, meta_v1.StatusSuccess, response.Result.Status)
+	require.Len(t, response.ConvertedObjects, 1)
+	var v2Bundle smith_v2alpha1.Bundle
+	require.NoError(t, json.Unmarshal(response.ConvertedObjects[0].Raw, &v2Bundle))
+	assert.Equal(t, smith_v2alpha1.BundleResourceGroupVersion, v2Bundle.Annotations[smith.AuthoredVersionAnnotation])
+}
+
+func TestBundleConverterUnsupportedVersion(t *testing.T) {
+	t.Parallel()
+	v1Bundle := bundleOf(configMapResource("a", nil))
+	v1Bundle.TypeMeta = meta_v1.TypeMeta{
+		APIVersion: smith_v1.BundleResourceGroupVersion,
+		Kind:       smith_v1.BundleResourceKind,
+	}
+
+	response := convert(t, "smith.atlassian.com/v3", v1Bundle)
+	assert.Equal(t, meta_v1.StatusFailure, response.Result.Status)
+	assert.Contains(t, response.Result.Message, `conversion from "smith.atlassian.com/v1" to "smith.atlassian.com/v3" is not supported`)
+	assert.Empty(t, response.ConvertedObjects)
+}
@@ -0,0 +1,210 @@
+package webhook
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/atlassian/smith"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	shorthandReferenceHashLength = 8
+	maxReferenceNameLength       = 253
+)
+
+var (
+	// shorthandReference matches references in the "{{<resource>#<path>}}" and "{{<resource>:<modifier>#<path>}}"
+	// syntax used before references had to be declared in the references block of a resource.
+	// ?s allows us to match multiline expressions.
+	shorthandReference = regexp.MustCompile(`(?s)^\{\{([^#:{}]+)(?::([^#{}]+))?#(.+)}}$`)
+)
+
+// DefaultBundle fills in defaults in a Bundle given as a JSON object and returns the defaulted spec:
+// - surrounding whitespace is removed from names of resources and from names in references, quorums and dependsOn;
+// - resources listed in dependsOn are turned into nameless references;
+// - shorthand references like "{{<resource>#<path>}}" in object and plugin specs are replaced with "!{<name>}"
+// and declared in the references block of the resource, names are derived from the resource name and a hash of the
+// shorthand;
+// - the BundleNameLabel is set on objects to the name of the Bundle.
+// The second return value is false if nothing has changed. The argument is not mutated.
+func DefaultBundle(bundle map[string]interface{}) (map[string]interface{}, bool, error) {
+	specMap, ok := bundle["spec"].(map[string]interface{})
+	if !ok {
+		return nil, false, nil
+	}
+	spec := runtime.DeepCopyJSON(specMap)
+	var bundleName string
+	if metadata, ok := bundle["metadata"].(map[string]interface{}); ok {
+		bundleName, _ = metadata["name"].(string)
+	}
+	resources, ok := spec["resources"].([]interface{})
+	if !ok {
+		return nil, false, nil
+	}
+	for i, r := range resources {
+		res, ok := r.(map[string]interface{})
+		if !ok {
+			return nil, false, errors.Errorf("spec.resources[%d] is not an object", i)
+		}
+		defaultResource(res, bundleName)
+	}
+	return spec, !equality.Semantic.DeepEqual(spec, specMap), nil
+}
+
+func defaultResource(res map[string]interface{}, bundleName string) {
+	trimString(res, "name")
+	var references []interface{}
+	if refs, ok := res["references"].([]interface{}); ok {
+		references = refs
+	}
+	for _, r := range references {
+		if ref, ok := r.(map[string]interface{}); ok {
+			trimString(ref, "resource")
+		}
+	}
+	if quorums, ok := res["quorums"].([]interface{}); ok {
+		for _, q := range quorums {
+			if quorum, ok := q.(map[string]interface{}); ok {
+				trimStrings(quorum, "resources")
+			}
+		}
+	}
+
+	// dependsOn is not a field of a resource, dependencies are declared as nameless references
+	if dependsOn, ok := res["dependsOn"].([]interface{}); ok {
+		for _, dep := range dependsOn {
+			if name, ok := dep.(string); ok {
+				references = append(references, map[string]interface{}{
+					"resource": strings.TrimSpace(name),
+				})
+			}
+		}
+		delete(res, "dependsOn")
+	}
+
+	declared := make(map[string]struct{}, len(references))
+	for _, r := range references {
+		if ref, ok := r.(map[string]interface{}); ok {
+			if name, ok := ref["name"].(string); ok {
+				declared[name] = struct{}{}
+			}
+		}
+	}
+	expand := func(value interface{}) interface{} {
+		return expandShorthandReferences(value, func(name, resource, modifier, path string, example interface{}) {
+			if _, ok := declared[name]; ok {
+				return
+			}
+			declared[name] = struct{}{}
+			ref := map[string]interface{}{
+				"name":     name,
+				"resource": resource,
+				"path":     path,
+			}
+			if modifier != "" {
+				ref["modifier"] = modifier
+			}
+			if example != nil {
+				ref["example"] = example
+			}
+			references = append(references, ref)
+		})
+	}
+	if spec, ok := res["spec"].(map[string]interface{}); ok {
+		if object, ok := spec["object"].(map[string]interface{}); ok {
+			spec["object"] = expand(object)
+			if bundleName != "" {
+				setBundleNameLabel(object, bundleName)
+			}
+		}
+		if plugin, ok := spec["plugin"].(map[string]interface{}); ok {
+			if pluginSpec, ok := plugin["spec"]; ok {
+				plugin["spec"] = expand(pluginSpec)
+			}
+		}
+	}
+	if len(references) > 0 {
+		res["references"] = references
+	}
+}
+
+// expandShorthandReferences replaces shorthand references in the value with references to reference names.
+// Names of references are derived from the shorthand, so the same shorthand used twice results in one reference.
+// Mutates the value.
+func expandShorthandReferences(value interface{}, declare func(name, resource, modifier, path string, example interface{})) interface{} {
+	switch v := value.(type) {
+	case string:
+		match := shorthandReference.FindStringSubmatch(v)
+		if match == nil {
+			return v
+		}
+		resource := strings.TrimSpace(match[1])
+		modifier := strings.TrimSpace(match[2])
+		path := match[3]
+		var example interface{}
+		// An optional example may follow the path, e.g. {{a#spec.x#"example"}}
+		if i := strings.Index(path, "#"); i >= 0 {
+			if err := json.Unmarshal([]byte(path[i+1:]), &example); err == nil {
+				path = path[:i]
+			}
+		}
+		name := shorthandReferenceName(resource, modifier, path)
+		declare(name, resource, modifier, path, example)
+		return "!{" + name + "}"
+	case map[string]interface{}:
+		for key, val := range v {
+			v[key] = expandShorthandReferences(val, declare)
+		}
+	case []interface{}:
+		for i, val := range v {
+			v[i] = expandShorthandReferences(val, declare)
+		}
+	}
+	return value
+}
+
+// shorthandReferenceName returns a name for the reference that is a valid DNS subdomain, as required by the schema.
+func shorthandReferenceName(resource, modifier, path string) string {
+	sum := sha256.Sum256([]byte(resource + ":" + modifier + "#" + path))
+	suffix := "-" + hex.EncodeToString(sum[:])[:shorthandReferenceHashLength]
+	if len(resource)+len(suffix) > maxReferenceNameLength {
+		resource = strings.TrimRight(resource[:maxReferenceNameLength-len(suffix)], "-.")
+	}
+	return resource + suffix
+}
+
+func setBundleNameLabel(object map[string]interface{}, bundleName string) {
+	metadata, ok := object["metadata"].(map[string]interface{})
+	if !ok {
+		metadata = make(map[string]interface{}, 1)
+		object["metadata"] = metadata
+	}
+	labels, ok := metadata["labels"].(map[string]interface{})
+	if !ok {
+		labels = make(map[string]interface{}, 1)
+		metadata["labels"] = labels
+	}
+	labels[smith.BundleNameLabel] = smith.LabelValue(bundleName)
+}
+
+func trimString(obj map[string]interface{}, field string) {
+	if s, ok := obj[field].(string); ok {
+		obj[field] = strings.TrimSpace(s)
+	}
+}
+
+func trimStrings(obj map[string]interface{}, field string) {
+	if list, ok := obj[field].([]interface{}); ok {
+		for i, item := range list {
+			if s, ok := item.(string); ok {
+				list[i] = strings.TrimSpace(s)
+			}
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package webhook
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/atlassian/smith"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func unstructuredBundle(resources ...interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name": "bundle1",
+		},
+		"spec": map[string]interface{}{
+			"resources": resources,
+		},
+	}
+}
+
+func TestDefaultBundleNoChanges(t *testing.T) {
+	t.Parallel()
+	bundle := unstructuredBundle(map[string]interface{}{
+		"name": "a",
+		"spec": map[string]interface{}{
+			"plugin": map[string]interface{}{
+				"name":       "p",
+				"objectName": "x",
+			},
+		},
+	})
+	_, changed, err := DefaultBundle(bundle)
+	require.NoError(t, err)
+	assert.False(t, changed)
+}
+
+func TestDefaultBundleNormalizesNames(t *testing.T) {
+	t.Parallel()
+	bundle := unstructuredBundle(map[string]interface{}{
+		"name": " a ",
+		"references": []interface{}{
+			map[string]interface{}{
+				"resource": "b ",
+			},
+		},
+		"quorums": []interface{}{
+			map[string]interface{}{
+				"resources": []interface{}{" c", "d"},
+			},
+		},
+		"dependsOn": []interface{}{" e "},
+	})
+	spec, changed, err := DefaultBundle(bundle)
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	res := spec["resources"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "a", res["name"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"resource": "b",
+		},
+		map[string]interface{}{
+			"resource": "e",
+		},
+	}, res["references"])
+	assert.Equal(t, []interface{}{"c", "d"}, res["quorums"].([]interface{})[0].(map[string]interface{})["resources"])
+	assert.NotContains(t, res, "dependsOn")
+
+	// The argument must not be mutated
+	orig := bundle["spec"].(map[string]interface{})["resources"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, " a ", orig["name"])
+	assert.Contains(t, orig, "dependsOn")
+}
+
+func TestDefaultBundleSetsBundleNameLabel(t *testing.T) {
+	t.Parallel()
+	bundle := unstructuredBundle(map[string]interface{}{
+		"name": "a",
+		"spec": map[string]interface{}{
+			"object": map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata": map[string]interface{}{
+					"name": "map1",
+				},
+			},
+		},
+	})
+	spec, changed, err := DefaultBundle(bundle)
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	object := spec["resources"].([]interface{})[0].(map[string]interface{})["spec"].(map[string]interface{})["object"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{
+		smith.BundleNameLabel: "bundle1",
+	}, object["metadata"].(map[string]interface{})["labels"])
+
+	// Defaulting is idempotent
+	bundle["spec"] = spec
+	_, changed, err = DefaultBundle(bundle)
+	require.NoError(t, err)
+	assert.False(t, changed)
+}
+
+func TestDefaultBundleSetsBundleNameLabelOfLongName(t *testing.T) {
+	t.Parallel()
+	name := strings.Repeat("b", 100)
+	bundle := unstructuredBundle(map[string]interface{}{
+		"name": "a",
+		"spec": map[string]interface{}{
+			"object": map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+			},
+		},
+	})
+	bundle["metadata"].(map[string]interface{})["name"] = name
+	spec, changed, err := DefaultBundle(bundle)
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	object := spec["resources"].([]interface{})[0].(map[string]interface{})["spec"].(map[string]interface{})["object"].(map[string]interface{})
+	value := object["metadata"].(map[string]interface{})["labels"].(map[string]interface{})[smith.BundleNameLabel]
+	assert.Equal(t, smith.LabelValue(name), value)
+	assert.Len(t, value, 63)
+}
+
+func TestDefaultBundleExpandsShorthandReferences(t *testing.T) {
+	t.Parallel()
+	bundle := unstructuredBundle(map[string]interface{}{
+		"name": "c",
+		"spec": map[string]interface{}{
+			"plugin": map[string]interface{}{
+				"name":       "p",
+				"objectName": "x",
+				"spec": map[string]interface{}{
+					"name":     "{{a#metadata.name}}",
+					"sameName": "{{a#metadata.name}}",
+					"host":     `{{b:bindsecret#data.host#"http://example.com"}}`,
+					"list":     []interface{}{"{{a#spec}}", "literal"},
+				},
+			},
+		},
+	})
+	spec, changed, err := DefaultBundle(bundle)
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	res := spec["resources"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{
+		"name":     "!{a-e3e73779}",
+		"sameName": "!{a-e3e73779}",
+		"host":     "!{b-decffec8}",
+		"list":     []interface{}{"!{a-03e7e651}", "literal"},
+	}, res["spec"].(map[string]interface{})["plugin"].(map[string]interface{})["spec"])
+
+	refs := res["references"].([]interface{})
+	require.Len(t, refs, 3)
+	assert.Contains(t, refs, map[string]interface{}{
+		"name":     "a-e3e73779",
+		"resource": "a",
+		"path":     "metadata.name",
+	})
+	assert.Contains(t, refs, map[string]interface{}{
+		"name":     "a-03e7e651",
+		"resource": "a",
+		"path":     "spec",
+	})
+	assert.Contains(t, refs, map[string]interface{}{
+		"name":     "b-decffec8",
+		"resource": "b",
+		"modifier": "bindsecret",
+		"path":     "data.host",
+		"example":  "http://example.com",
+	})
+}
+
+func TestDefaultBundleInvalidResource(t *testing.T) {
+	t.Parallel()
+	_, _, err := DefaultBundle(unstructuredBundle("a"))
+	require.Error(t, err)
+}
+
+func TestShorthandReferenceName(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "a-e3e73779", shorthandReferenceName("a", "", "metadata.name"))
+	assert.Equal(t, "b-decffec8", shorthandReferenceName("b", "bindsecret", "data.host"))
+	long := shorthandReferenceName(strings.Repeat("a", 250), "", "spec")
+	assert.Len(t, long, maxReferenceNameLength)
+}
@@ -0,0 +1,203 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	admission_v1b1 "k8s.io/api/admission/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8s_json "k8s.io/apimachinery/pkg/util/json"
+)
+
+const (
+	// BundleValidationPath is the path BundleValidator is served at.
+	BundleValidationPath = "/validate/bundles"
+	// BundleDefaultingPath is the path BundleDefaulter is served at.
+	BundleDefaultingPath = "/default/bundles"
+	// BundleConversionPath is the path BundleConverter is served at.
+	BundleConversionPath = "/convert/bundles"
+
+	// maxRequestSize is the maximum size of an AdmissionReview or a ConversionReview. Objects in etcd are limited to 1.5MiB.
+	maxRequestSize = 3 * 1024 * 1024
+)
+
+// BundleValidator is a ValidatingAdmissionWebhook handler that rejects Bundles with invalid specs.
+// See ValidateBundle for checks that are performed. Valid Bundles are admitted with warnings, see WarnBundle.
+type BundleValidator struct {
+	Logger *zap.Logger
+	// ReadinessRules are used to warn about objects of kinds without a readiness rule. Optional.
+	ReadinessRules ReadinessRules
+}
+
+func (v *BundleValidator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	serveReview(v.Logger, w, r, v.review)
+}
+
+// admissionReview is an AdmissionReview with warnings in the response.
+type admissionReview struct {
+	meta_v1.TypeMeta `json:",inline"`
+	Request          *admission_v1b1.AdmissionRequest `json:"request,omitempty"`
+	Response         *admissionResponse               `json:"response,omitempty"`
+}
+
+// admissionResponse is an AdmissionResponse with warnings. Warnings are shown to clients by API servers that
+// support them (Kubernetes 1.19 and later), older ones ignore the field.
+type admissionResponse struct {
+	admission_v1b1.AdmissionResponse `json:",inline"`
+	Warnings                         []string `json:"warnings,omitempty"`
+}
+
+// serveReview decodes an AdmissionReview from the request and responds with the result of the review function.
+func serveReview(logger *zap.Logger, w http.ResponseWriter, r *http.Request, review func(*admission_v1b1.AdmissionRequest) *admissionResponse) {
+	var ar admissionReview
+	if !decodeRequest(w, r, &ar) {
+		return
+	}
+	if ar.Request == nil {
+		http.Error(w, "request is missing", http.StatusBadRequest)
+		return
+	}
+	ar.Response = review(ar.Request)
+	ar.Response.UID = ar.Request.UID
+	ar.Request = nil
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(&ar); err != nil {
+		logger.Error("Failed to write admission response", zap.Error(err))
+	}
+}
+
+// decodeRequest decodes the JSON body of a POST request into v. Responds with an error and returns false if that
+// fails.
+func decodeRequest(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return false
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	if err = json.Unmarshal(body, v); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// isBundleWrite returns true if the request is a create or an update of a Bundle.
+func isBundleWrite(req *admission_v1b1.AdmissionRequest) bool {
+	return req.Kind.Group == smith_v1.SchemeGroupVersion.Group && req.Kind.Kind == smith_v1.BundleResourceKind &&
+		(req.Operation == admission_v1b1.Create || req.Operation == admission_v1b1.Update)
+}
+
+func (v *BundleValidator) review(req *admission_v1b1.AdmissionRequest) *admissionResponse {
+	if !isBundleWrite(req) {
+		// Only creates and updates of Bundles are validated
+		return allow()
+	}
+	var bundle smith_v1.Bundle
+	if err := json.Unmarshal(req.Object.Raw, &bundle); err != nil {
+		return deny(meta_v1.StatusReasonBadRequest, errors.Wrap(err, "failed to unmarshal Bundle").Error())
+	}
+	errs := ValidateBundle(&bundle)
+	if len(errs) > 0 {
+		v.Logger.Info("Rejected invalid Bundle", zap.String("namespace", req.Namespace), zap.String("name", bundle.Name), zap.Error(errs.ToAggregate()))
+		return deny(meta_v1.StatusReasonInvalid, errs.ToAggregate().Error())
+	}
+	resp := allow()
+	warnings, err := WarnBundle(&bundle, v.ReadinessRules)
+	if err != nil {
+		// Warnings must not block changes
+		v.Logger.Error("Failed to check Bundle for warnings", zap.String("namespace", req.Namespace), zap.String("name", bundle.Name), zap.Error(err))
+		return resp
+	}
+	for _, warning := range warnings {
+		resp.Warnings = append(resp.Warnings, warning.String())
+	}
+	return resp
+}
+
+func allow() *admissionResponse {
+	return &admissionResponse{
+		AdmissionResponse: admission_v1b1.AdmissionResponse{
+			Allowed: true,
+		},
+	}
+}
+
+func deny(reason meta_v1.StatusReason, message string) *admissionResponse {
+	return &admissionResponse{
+		AdmissionResponse: admission_v1b1.AdmissionResponse{
+			Allowed: false,
+			Result: &meta_v1.Status{
+				Status:  meta_v1.StatusFailure,
+				Reason:  reason,
+				Message: message,
+			},
+		},
+	}
+}
+
+// BundleDefaulter is a MutatingAdmissionWebhook handler that fills in defaults in Bundles.
+// See DefaultBundle for defaults that are filled in. The API version the spec was written via is recorded in the
+// AuthoredVersionAnnotation.
+type BundleDefaulter struct {
+	Logger *zap.Logger
+	// AuthoredVersions counts writes of Bundle specs by API version. Optional.
+	AuthoredVersions *AuthoredVersionMetrics
+}
+
+func (d *BundleDefaulter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	serveReview(d.Logger, w, r, d.review)
+}
+
+func (d *BundleDefaulter) review(req *admission_v1b1.AdmissionRequest) *admissionResponse {
+	if !isBundleWrite(req) {
+		// Only creates and updates of Bundles are defaulted
+		return allow()
+	}
+	var bundle map[string]interface{}
+	if err := k8s_json.Unmarshal(req.Object.Raw, &bundle); err != nil {
+		return deny(meta_v1.StatusReasonBadRequest, errors.Wrap(err, "failed to unmarshal Bundle").Error())
+	}
+	spec, changed, err := DefaultBundle(bundle)
+	if err != nil {
+		return deny(meta_v1.StatusReasonBadRequest, err.Error())
+	}
+	var ops []map[string]interface{}
+	if changed {
+		// The whole spec is replaced, it is simpler than a precise patch and the result is the same
+		ops = append(ops, map[string]interface{}{
+			"op":    "replace",
+			"path":  "/spec",
+			"value": spec,
+		})
+	}
+	version, err := authoredVersion(req, bundle)
+	if err != nil {
+		return deny(meta_v1.StatusReasonBadRequest, err.Error())
+	}
+	if version != "" {
+		d.AuthoredVersions.observe(version)
+		if op := authoredVersionPatch(bundle, version); op != nil {
+			ops = append(ops, op)
+		}
+	}
+	if len(ops) == 0 {
+		return allow()
+	}
+	patch, err := json.Marshal(ops)
+	if err != nil {
+		return deny(meta_v1.StatusReasonInternalError, errors.Wrap(err, "failed to marshal patch").Error())
+	}
+	patchType := admission_v1b1.PatchTypeJSONPatch
+	resp := allow()
+	resp.Patch = patch
+	resp.PatchType = &patchType
+	return resp
+}
@@ -0,0 +1,154 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/atlassian/smith"
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	admission_v1b1 "k8s.io/api/admission/v1beta1"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func reviewBundle(t *testing.T, bundle *smith_v1.Bundle) *admissionResponse {
+	raw, err := json.Marshal(bundle)
+	require.NoError(t, err)
+	return serve(t, &BundleValidator{
+		Logger: zaptest.NewLogger(t),
+	}, BundleValidationPath, raw)
+}
+
+func serve(t *testing.T, handler http.Handler, path string, raw []byte) *admissionResponse {
+	return serveRequest(t, handler, path, &admission_v1b1.AdmissionRequest{
+		UID: "uid1",
+		Kind: meta_v1.GroupVersionKind{
+			Group:   smith_v1.SchemeGroupVersion.Group,
+			Version: smith_v1.SchemeGroupVersion.Version,
+			Kind:    smith_v1.BundleResourceKind,
+		},
+		Operation: admission_v1b1.Create,
+		Object: runtime.RawExtension{
+			Raw: raw,
+		},
+	})
+}
+
+func serveRequest(t *testing.T, handler http.Handler, path string, req *admission_v1b1.AdmissionRequest) *admissionResponse {
+	review := admission_v1b1.AdmissionReview{
+		Request: req,
+	}
+	body, err := json.Marshal(&review)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response admissionReview
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.NotNil(t, response.Response)
+	assert.EqualValues(t, "uid1", response.Response.UID)
+	return response.Response
+}
+
+func TestBundleValidatorAllows(t *testing.T) {
+	t.Parallel()
+	response := reviewBundle(t, bundleOf(configMapResource("a", nil)))
+	assert.True(t, response.Allowed)
+	assert.Empty(t, response.Warnings)
+}
+
+func TestBundleValidatorDenies(t *testing.T) {
+	t.Parallel()
+	response := reviewBundle(t, bundleOf(configMapResource("a", nil), configMapResource("a", nil)))
+	assert.False(t, response.Allowed)
+	require.NotNil(t, response.Result)
+	assert.Equal(t, meta_v1.StatusReasonInvalid, response.Result.Reason)
+	assert.Contains(t, response.Result.Message, "spec.resources[1].name")
+}
+
+func TestBundleValidatorBadRequest(t *testing.T) {
+	t.Parallel()
+	v := &BundleValidator{
+		Logger: zaptest.NewLogger(t),
+	}
+	w := httptest.NewRecorder()
+	v.ServeHTTP(w, httptest.NewRequest(http.MethodPost, BundleValidationPath, bytes.NewReader([]byte("{"))))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestBundleDefaulterPatches(t *testing.T) {
+	t.Parallel()
+	bundle := bundleOf(configMapResource(" a ", nil))
+	bundle.Name = "bundle1"
+	raw, err := json.Marshal(bundle)
+	require.NoError(t, err)
+	response := serve(t, &BundleDefaulter{
+		Logger: zaptest.NewLogger(t),
+	}, BundleDefaultingPath, raw)
+	assert.True(t, response.Allowed)
+	require.NotNil(t, response.PatchType)
+	assert.Equal(t, admission_v1b1.PatchTypeJSONPatch, *response.PatchType)
+
+	patch := decodePatch(t, response.Patch)
+	require.Len(t, patch, 2)
+	assert.Equal(t, "replace", patch[0].Op)
+	assert.Equal(t, "/spec", patch[0].Path)
+	var spec smith_v1.BundleSpec
+	require.NoError(t, json.Unmarshal(patch[0].Value, &spec))
+	require.Len(t, spec.Resources, 1)
+	assert.EqualValues(t, "a", spec.Resources[0].Name)
+	assert.Equal(t, "add", patch[1].Op)
+	assert.Equal(t, "/metadata/annotations", patch[1].Path)
+	assert.JSONEq(t, `{"smith.atlassian.com/AuthoredVersion":"smith.atlassian.com/v1"}`, string(patch[1].Value))
+}
+
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+func decodePatch(t *testing.T, raw []byte) []patchOperation {
+	var patch []patchOperation
+	require.NoError(t, json.Unmarshal(raw, &patch))
+	return patch
+}
+
+func TestBundleDefaulterNoPatch(t *testing.T) {
+	t.Parallel()
+	res := configMapResource("a", nil)
+	res.Spec.Object.(*core_v1.ConfigMap).Labels = map[string]string{
+		smith.BundleNameLabel: "bundle1",
+	}
+	bundle := bundleOf(res)
+	bundle.Annotations = map[string]string{
+		smith.AuthoredVersionAnnotation: smith_v1.BundleResourceGroupVersion,
+	}
+	raw, err := json.Marshal(bundle)
+	require.NoError(t, err)
+	response := serve(t, &BundleDefaulter{
+		Logger: zaptest.NewLogger(t),
+	}, BundleDefaultingPath, raw)
+	assert.True(t, response.Allowed)
+	assert.Empty(t, response.Patch)
+	assert.Nil(t, response.PatchType)
+}
+
+func TestBundleValidatorWarns(t *testing.T) {
+	t.Parallel()
+	response := reviewBundle(t, bundleOf(
+		configMapResource("a", nil),
+		configMapResource("b", nil, smith_v1.Reference{Resource: "a"}, smith_v1.Reference{Resource: "a"}),
+	))
+	assert.True(t, response.Allowed)
+	assert.Equal(t, []string{`resource "b": dependency on "a" is unused, it is already declared by another dependsOn entry`}, response.Warnings)
+}
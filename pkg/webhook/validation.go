@@ -0,0 +1,518 @@
+package webhook
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/atlassian/smith/pkg/readychecker"
+	"github.com/atlassian/smith/pkg/refexpr"
+	"github.com/atlassian/smith/pkg/secretstore"
+	"github.com/atlassian/smith/pkg/speccheck"
+	"github.com/atlassian/smith/pkg/util"
+	"github.com/atlassian/smith/pkg/util/graph"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+var (
+	// parameterName matches valid names of parameters.
+	parameterName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+)
+
+// ValidateBundle checks the spec of a Bundle for problems that would make processing fail:
+// - resources without names or with duplicate names;
+// - resources with none or more than one of object, plugin and external specified;
+// - external resources without API version, kind or name, with invalid readiness expressions or with adoption;
+// - resources with invalid namespaces or namespaces that do not match namespaces of their objects;
+// - resources with invalid paths of ignored fields;
+// - references and quorums pointing at non-existent resources or at the resource itself;
+// - references with duplicate names, invalid paths or modifiers, uses of undeclared references in specs and invalid
+// reference expressions;
+// - inline references to resources that are not dependencies or with invalid paths or modifiers;
+// - parameters with invalid names and uses of undeclared parameters in specs;
+// - dependency cycles;
+// - resources that are the Bundle itself;
+// - outputs without names or with duplicate names, pointing at non-existent resources or with invalid paths;
+// - outputs exported with names that are not valid keys and exports to unsupported kinds, invalid names or objects
+// of resources of the Bundle;
+// - retry policies with a maximum delay shorter than the base delay or with unsupported retry classes.
+func ValidateBundle(bundle *smith_v1.Bundle) field.ErrorList {
+	var errs field.ErrorList
+	resourcesPath := field.NewPath("spec", "resources")
+	names := make(map[smith_v1.ResourceName]struct{}, len(bundle.Spec.Resources))
+	for i, res := range bundle.Spec.Resources {
+		namePath := resourcesPath.Index(i).Child("name")
+		if res.Name == "" {
+			errs = append(errs, field.Required(namePath, "resource name is required"))
+			continue
+		}
+		if _, ok := names[res.Name]; ok {
+			errs = append(errs, field.Duplicate(namePath, res.Name))
+			continue
+		}
+		names[res.Name] = struct{}{}
+	}
+	for i, res := range bundle.Spec.Resources {
+		errs = append(errs, validateResource(resourcesPath.Index(i), res, names, bundle.Spec.Parameters)...)
+		if isBundleItself(res, bundle) {
+			errs = append(errs, field.Invalid(resourcesPath.Index(i).Child("spec", "object"), bundle.Name, "Bundle cannot include itself"))
+		}
+	}
+	errs = append(errs, validateOutputs(field.NewPath("spec", "outputs"), bundle.Spec.Outputs, names)...)
+	if bundle.Spec.OutputsExport != nil {
+		errs = append(errs, validateOutputsExport(field.NewPath("spec"), bundle)...)
+	}
+	for name := range bundle.Spec.Parameters {
+		if !parameterName.MatchString(name) {
+			errs = append(errs, field.Invalid(field.NewPath("spec", "parameters").Key(name), name,
+				"parameter name must start with a letter or an underscore and consist of letters, digits, underscores and dashes"))
+		}
+	}
+	if bundle.Spec.RetryPolicy != nil {
+		errs = append(errs, validateRetryPolicy(field.NewPath("spec", "retryPolicy"), bundle.Spec.RetryPolicy)...)
+	}
+	if len(errs) > 0 {
+		// Cycles cannot be reliably detected in a malformed graph
+		return errs
+	}
+	return validateDependencies(resourcesPath, bundle.Spec.Resources)
+}
+
+func validateResource(path *field.Path, res smith_v1.Resource, names map[smith_v1.ResourceName]struct{}, parameters map[string]string) field.ErrorList {
+	var errs field.ErrorList
+	specPath := path.Child("spec")
+	var spec map[string]interface{}
+	switch {
+	case res.SpecFrom != nil && (res.Spec.Object != nil || res.Spec.Plugin != nil || res.Spec.External != nil):
+		errs = append(errs, field.Invalid(path.Child("specFrom"), "", `only one of "spec" and "specFrom" may be specified`))
+	case res.SpecFrom != nil:
+		// The manifest is validated by the controller once it is read
+		errs = append(errs, validateSpecSource(path.Child("specFrom"), res.SpecFrom)...)
+	case res.Spec.Object != nil && res.Spec.Plugin != nil,
+		res.Spec.Object != nil && res.Spec.External != nil,
+		res.Spec.Plugin != nil && res.Spec.External != nil:
+		errs = append(errs, field.Invalid(specPath, "", `only one of "object", "plugin" and "external" may be specified`))
+	case res.Spec.Object != nil:
+		specUnstr, err := util.RuntimeToUnstructured(res.Spec.Object)
+		if err != nil {
+			errs = append(errs, field.Invalid(specPath.Child("object"), "", err.Error()))
+		} else {
+			spec = specUnstr.Object
+			if specUnstr.GroupVersionKind().GroupKind() == smith_v1.BundleGVK.GroupKind() {
+				// Same as the controller does, resources of a nested Bundle are validated with the nested Bundle
+				removeNestedBundleFields(spec)
+			}
+			if specUnstr.GetName() == "" && specUnstr.GetGenerateName() == "" {
+				errs = append(errs, field.Required(specPath.Child("object", "metadata", "name"), "object name or generateName is required"))
+			}
+			if ns := specUnstr.GetNamespace(); ns != "" && res.Namespace != "" && ns != res.Namespace {
+				errs = append(errs, field.Invalid(specPath.Child("object", "metadata", "namespace"), ns, "does not match the namespace of the resource"))
+			}
+		}
+	case res.Spec.Plugin != nil:
+		spec = res.Spec.Plugin.Spec
+		if res.Spec.Plugin.Name == "" {
+			errs = append(errs, field.Required(specPath.Child("plugin", "name"), "plugin name is required"))
+		}
+		if res.Spec.Plugin.ObjectName == "" {
+			errs = append(errs, field.Required(specPath.Child("plugin", "objectName"), "object name is required"))
+		}
+	case res.Spec.External != nil:
+		errs = append(errs, validateExternalObject(specPath.Child("external"), res.Spec.External)...)
+		if res.Adopt {
+			errs = append(errs, field.Invalid(path.Child("adopt"), res.Adopt, "external objects are only observed and cannot be adopted"))
+		}
+	default:
+		errs = append(errs, field.Required(specPath, `one of "object", "plugin" and "external" must be specified unless "specFrom" is`))
+	}
+	if res.Namespace != "" {
+		for _, msg := range validation.IsDNS1123Label(res.Namespace) {
+			errs = append(errs, field.Invalid(path.Child("namespace"), res.Namespace, msg))
+		}
+	}
+	for i, ignored := range res.IgnoreFields {
+		if _, err := speccheck.ParseFieldPath(ignored); err != nil {
+			errs = append(errs, field.Invalid(path.Child("ignoreFields").Index(i), ignored, err.Error()))
+		}
+	}
+
+	referencesPath := path.Child("references")
+	referenceNames := make(map[smith_v1.ReferenceName]struct{}, len(res.References))
+	dependencies := make(map[smith_v1.ResourceName]struct{}, len(res.References))
+	for i, ref := range res.References {
+		refPath := referencesPath.Index(i)
+		if ref.IsExternal() {
+			errs = append(errs, validateExternalReference(refPath, ref)...)
+		} else {
+			errs = append(errs, validateDependency(refPath.Child("resource"), res.Name, ref.Resource, names)...)
+			dependencies[ref.Resource] = struct{}{}
+			if ref.Modifier != "" && ref.Modifier != smith_v1.ReferenceModifierBindSecret {
+				errs = append(errs, field.NotSupported(refPath.Child("modifier"), ref.Modifier, []string{smith_v1.ReferenceModifierBindSecret}))
+			}
+		}
+		if ref.Name == "" {
+			// Nameless references are only used to declare dependencies
+			continue
+		}
+		if _, ok := referenceNames[ref.Name]; ok {
+			errs = append(errs, field.Duplicate(refPath.Child("name"), ref.Name))
+		}
+		referenceNames[ref.Name] = struct{}{}
+		if ref.Path != "" && !ref.IsExternal() {
+			// Same as the controller does when resolving the reference
+			if err := jsonpath.New(string(ref.Name)).Parse(fmt.Sprintf("{$.%s}", ref.Path)); err != nil {
+				errs = append(errs, field.Invalid(refPath.Child("path"), ref.Path, err.Error()))
+			}
+		}
+	}
+	for i, quorum := range res.Quorums {
+		quorumPath := path.Child("quorums").Index(i)
+		for j, member := range quorum.Resources {
+			errs = append(errs, validateDependency(quorumPath.Child("resources").Index(j), res.Name, member, names)...)
+		}
+		if quorum.MinReady < 1 || quorum.MinReady > len(quorum.Resources) {
+			errs = append(errs, field.Invalid(quorumPath.Child("minReady"), quorum.MinReady,
+				fmt.Sprintf("must be between 1 and the number of resources in the quorum (%d)", len(quorum.Resources))))
+		}
+	}
+
+	if res.Spec.Object != nil && spec != nil {
+		errs = append(errs, validateReferenceUses(specPath.Child("object"), spec, referenceNames, dependencies)...)
+		errs = append(errs, validateParameterUses(specPath.Child("object"), spec, parameters)...)
+	} else if res.Spec.Plugin != nil {
+		errs = append(errs, validateReferenceUses(specPath.Child("plugin", "spec"), spec, referenceNames, dependencies)...)
+		errs = append(errs, validateParameterUses(specPath.Child("plugin", "spec"), spec, parameters)...)
+	}
+	return errs
+}
+
+func validateExternalObject(path *field.Path, external *smith_v1.ExternalObject) field.ErrorList {
+	var errs field.ErrorList
+	if external.APIVersion == "" {
+		errs = append(errs, field.Required(path.Child("apiVersion"), "API version is required"))
+	}
+	if external.Kind == "" {
+		errs = append(errs, field.Required(path.Child("kind"), "kind is required"))
+	}
+	if external.Name == "" {
+		errs = append(errs, field.Required(path.Child("name"), "object name is required"))
+	}
+	if external.ReadyWhen != "" {
+		if err := readychecker.ValidateReadyWhen(external.ReadyWhen); err != nil {
+			errs = append(errs, field.Invalid(path.Child("readyWhen"), external.ReadyWhen, err.Error()))
+		}
+	}
+	return errs
+}
+
+func validateDependency(path *field.Path, resName, dep smith_v1.ResourceName, names map[smith_v1.ResourceName]struct{}) field.ErrorList {
+	if dep == resName {
+		return field.ErrorList{field.Invalid(path, dep, "resource cannot depend on itself")}
+	}
+	if _, ok := names[dep]; !ok {
+		return field.ErrorList{field.NotFound(path, dep)}
+	}
+	return nil
+}
+
+func validateExternalReference(path *field.Path, ref smith_v1.Reference) field.ErrorList {
+	var errs field.ErrorList
+	if _, err := secretstore.ParseSource(ref.Source); err != nil {
+		errs = append(errs, field.Invalid(path.Child("source"), ref.Source, err.Error()))
+	}
+	if ref.Name == "" {
+		errs = append(errs, field.Required(path.Child("name"), "references to external secret stores must have a name"))
+	}
+	if ref.Resource != "" {
+		errs = append(errs, field.Forbidden(path.Child("resource"), `may not be specified together with "source"`))
+	}
+	if ref.Path != "" {
+		errs = append(errs, field.Forbidden(path.Child("path"), `may not be specified together with "source"`))
+	}
+	if ref.Modifier != "" {
+		errs = append(errs, field.Forbidden(path.Child("modifier"), `may not be specified together with "source"`))
+	}
+	return errs
+}
+
+func validateSpecSource(path *field.Path, source *smith_v1.SpecSource) field.ErrorList {
+	var errs field.ErrorList
+	switch {
+	case source.ConfigMapKeyRef != nil && source.SecretKeyRef != nil:
+		errs = append(errs, field.Invalid(path, "", `only one of "configMapKeyRef" and "secretKeyRef" may be specified`))
+	case source.ConfigMapKeyRef != nil:
+		errs = append(errs, validateKeyReference(path.Child("configMapKeyRef"), source.ConfigMapKeyRef)...)
+	case source.SecretKeyRef != nil:
+		errs = append(errs, validateKeyReference(path.Child("secretKeyRef"), source.SecretKeyRef)...)
+	default:
+		errs = append(errs, field.Required(path, `one of "configMapKeyRef" and "secretKeyRef" must be specified`))
+	}
+	return errs
+}
+
+func validateKeyReference(path *field.Path, ref *smith_v1.KeyReference) field.ErrorList {
+	var errs field.ErrorList
+	if ref.Name == "" {
+		errs = append(errs, field.Required(path.Child("name"), "name is required"))
+	} else {
+		for _, msg := range validation.IsDNS1123Subdomain(ref.Name) {
+			errs = append(errs, field.Invalid(path.Child("name"), ref.Name, msg))
+		}
+	}
+	if ref.Key == "" {
+		errs = append(errs, field.Required(path.Child("key"), "key is required"))
+	} else {
+		for _, msg := range validation.IsConfigMapKey(ref.Key) {
+			errs = append(errs, field.Invalid(path.Child("key"), ref.Key, msg))
+		}
+	}
+	return errs
+}
+
+// removeNestedBundleFields removes fields of the spec of a nested Bundle that are processed by the nested Bundle
+// itself. References and parameters in them are not resolved by the outer Bundle.
+func removeNestedBundleFields(obj map[string]interface{}) {
+	if spec, ok := obj["spec"].(map[string]interface{}); ok {
+		delete(spec, "resources")
+		delete(spec, "outputs")
+	}
+}
+
+// isBundleItself returns true if the object of the resource is the Bundle.
+func isBundleItself(res smith_v1.Resource, bundle *smith_v1.Bundle) bool {
+	if res.Spec.Object == nil {
+		return false
+	}
+	obj, err := util.RuntimeToUnstructured(res.Spec.Object)
+	if err != nil {
+		// Reported by validateResource
+		return false
+	}
+	if obj.GroupVersionKind().GroupKind() != smith_v1.BundleGVK.GroupKind() || obj.GetName() != bundle.Name {
+		return false
+	}
+	namespace := res.Namespace
+	if namespace == "" {
+		namespace = obj.GetNamespace()
+	}
+	return namespace == "" || namespace == bundle.Namespace
+}
+
+func validateOutputs(path *field.Path, outputs []smith_v1.Output, names map[smith_v1.ResourceName]struct{}) field.ErrorList {
+	var errs field.ErrorList
+	outputNames := make(map[string]struct{}, len(outputs))
+	for i, output := range outputs {
+		outputPath := path.Index(i)
+		if output.Name == "" {
+			errs = append(errs, field.Required(outputPath.Child("name"), "output name is required"))
+		} else if _, ok := outputNames[output.Name]; ok {
+			errs = append(errs, field.Duplicate(outputPath.Child("name"), output.Name))
+		} else {
+			outputNames[output.Name] = struct{}{}
+		}
+		if _, ok := names[output.Resource]; !ok {
+			errs = append(errs, field.NotFound(outputPath.Child("resource"), output.Resource))
+		}
+		if output.Path == "" {
+			errs = append(errs, field.Required(outputPath.Child("path"), "output path is required"))
+		} else if err := jsonpath.New(output.Name).Parse(fmt.Sprintf("{$.%s}", output.Path)); err != nil {
+			// Same as the controller does when evaluating the output
+			errs = append(errs, field.Invalid(outputPath.Child("path"), output.Path, err.Error()))
+		}
+	}
+	return errs
+}
+
+func validateOutputsExport(specPath *field.Path, bundle *smith_v1.Bundle) field.ErrorList {
+	var errs field.ErrorList
+	export := bundle.Spec.OutputsExport
+	path := specPath.Child("outputsExport")
+	var kind string
+	switch export.Kind {
+	case smith_v1.OutputsExportConfigMap, smith_v1.OutputsExportSecret:
+		kind = string(export.Kind)
+	default:
+		errs = append(errs, field.NotSupported(path.Child("kind"), export.Kind,
+			[]string{string(smith_v1.OutputsExportConfigMap), string(smith_v1.OutputsExportSecret)}))
+	}
+	if export.Name != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(export.Name) {
+			errs = append(errs, field.Invalid(path.Child("name"), export.Name, msg))
+		}
+	}
+	for i, output := range bundle.Spec.Outputs {
+		// Outputs become keys of the data of the object
+		for _, msg := range validation.IsConfigMapKey(output.Name) {
+			errs = append(errs, field.Invalid(specPath.Child("outputs").Index(i).Child("name"), output.Name, msg))
+		}
+	}
+	if kind == "" {
+		return errs
+	}
+	name := export.ObjectName(bundle.Name)
+	for _, res := range bundle.Spec.Resources {
+		if res.Spec.Object == nil || (res.Namespace != "" && res.Namespace != bundle.Namespace) {
+			continue
+		}
+		obj, err := util.RuntimeToUnstructured(res.Spec.Object)
+		if err != nil {
+			// Reported by validateResource
+			continue
+		}
+		if obj.GetAPIVersion() == "v1" && obj.GetKind() == kind && obj.GetName() == name {
+			errs = append(errs, field.Invalid(path, name, fmt.Sprintf("%s is the object of resource %q", kind, res.Name)))
+		}
+	}
+	return errs
+}
+
+func validateRetryPolicy(path *field.Path, policy *smith_v1.RetryPolicy) field.ErrorList {
+	var errs field.ErrorList
+	if policy.BaseDelaySeconds > 0 && policy.MaxDelaySeconds > 0 && policy.MaxDelaySeconds < policy.BaseDelaySeconds {
+		errs = append(errs, field.Invalid(path.Child("maxDelaySeconds"), policy.MaxDelaySeconds, "must not be less than baseDelaySeconds"))
+	}
+	supported := []string{
+		string(smith_v1.RetryClassResourceError),
+		string(smith_v1.RetryClassExternalReference),
+		string(smith_v1.RetryClassPruning),
+	}
+	for i, class := range policy.RetryOn {
+		switch class {
+		case smith_v1.RetryClassResourceError, smith_v1.RetryClassExternalReference, smith_v1.RetryClassPruning:
+		default:
+			errs = append(errs, field.NotSupported(path.Child("retryOn").Index(i), class, supported))
+		}
+	}
+	return errs
+}
+
+// validateReferenceUses checks that all references used in the spec are declared and that inline references point
+// at dependencies.
+func validateReferenceUses(path *field.Path, value interface{}, referenceNames map[smith_v1.ReferenceName]struct{}, dependencies map[smith_v1.ResourceName]struct{}) field.ErrorList {
+	switch v := value.(type) {
+	case string:
+		match := refexpr.Reference.FindStringSubmatch(v)
+		if match == nil {
+			return nil
+		}
+		if inline := refexpr.InlineReference.FindStringSubmatch(match[2]); inline != nil {
+			return validateInlineReference(path, v, inline[1], inline[2], inline[3], dependencies)
+		}
+		if !refexpr.IsExpression(match[2]) {
+			if _, ok := referenceNames[smith_v1.ReferenceName(match[2])]; !ok {
+				return field.ErrorList{field.Invalid(path, v, fmt.Sprintf("reference %q does not exist in resource references block", match[2]))}
+			}
+			return nil
+		}
+		// Same as the controller does when evaluating the expression
+		expr, err := refexpr.Parse(match[2])
+		if err != nil {
+			return field.ErrorList{field.Invalid(path, v, err.Error())}
+		}
+		var errs field.ErrorList
+		for _, name := range expr.References() {
+			if _, ok := referenceNames[smith_v1.ReferenceName(name)]; !ok {
+				errs = append(errs, field.Invalid(path, v, fmt.Sprintf("reference %q does not exist in resource references block", name)))
+			}
+		}
+		return errs
+	case map[string]interface{}:
+		var errs field.ErrorList
+		for key, val := range v {
+			errs = append(errs, validateReferenceUses(path.Child(key), val, referenceNames, dependencies)...)
+		}
+		return errs
+	default:
+		rv := reflect.ValueOf(value)
+		if rv.Kind() != reflect.Slice {
+			return nil
+		}
+		var errs field.ErrorList
+		for i := 0; i < rv.Len(); i++ {
+			errs = append(errs, validateReferenceUses(path.Index(i), rv.Index(i).Interface(), referenceNames, dependencies)...)
+		}
+		return errs
+	}
+	return nil
+}
+
+// validateInlineReference checks an inline reference to a field of a dependency.
+func validateInlineReference(path *field.Path, value, resource, modifier, refPath string, dependencies map[smith_v1.ResourceName]struct{}) field.ErrorList {
+	var errs field.ErrorList
+	if _, ok := dependencies[smith_v1.ResourceName(resource)]; !ok {
+		errs = append(errs, field.Invalid(path, value, fmt.Sprintf("resource %q of inline reference is not a dependency, declare it in resource references block", resource)))
+	}
+	if modifier != "" && modifier != smith_v1.ReferenceModifierBindSecret {
+		errs = append(errs, field.Invalid(path, value, fmt.Sprintf("modifier %q of inline reference is not supported", modifier)))
+	}
+	// Same as the controller does when resolving the reference
+	if err := jsonpath.New(value).Parse(fmt.Sprintf("{$.%s}", strings.TrimPrefix(refPath, "$."))); err != nil {
+		errs = append(errs, field.Invalid(path, value, err.Error()))
+	}
+	return errs
+}
+
+// validateParameterUses checks that all parameters used in the spec are declared.
+func validateParameterUses(path *field.Path, value interface{}, parameters map[string]string) field.ErrorList {
+	switch v := value.(type) {
+	case string:
+		var errs field.ErrorList
+		for _, match := range refexpr.ParameterReference.FindAllStringSubmatch(v, -1) {
+			if match[0][1] == '$' {
+				// Escaped
+				continue
+			}
+			if _, ok := parameters[match[1]]; !ok {
+				errs = append(errs, field.Invalid(path, v, fmt.Sprintf("parameter %q is not declared in spec.parameters", match[1])))
+			}
+		}
+		return errs
+	case map[string]interface{}:
+		var errs field.ErrorList
+		for key, val := range v {
+			errs = append(errs, validateParameterUses(path.Child(key), val, parameters)...)
+		}
+		return errs
+	case []interface{}:
+		var errs field.ErrorList
+		for i, val := range v {
+			errs = append(errs, validateParameterUses(path.Index(i), val, parameters)...)
+		}
+		return errs
+	}
+	return nil
+}
+
+// validateDependencies checks that dependencies of resources do not form a cycle.
+// References and quorums must point at existing resources.
+func validateDependencies(path *field.Path, resources []smith_v1.Resource) field.ErrorList {
+	g := graph.NewGraph(len(resources))
+	for _, res := range resources {
+		g.AddVertex(graph.V(res.Name), nil)
+	}
+	for _, res := range resources {
+		for _, ref := range res.References {
+			if ref.IsExternal() {
+				continue
+			}
+			if err := g.AddEdge(res.Name, ref.Resource); err != nil {
+				return field.ErrorList{field.InternalError(path, err)}
+			}
+		}
+		for _, quorum := range res.Quorums {
+			for _, member := range quorum.Resources {
+				if err := g.AddEdge(res.Name, member); err != nil {
+					return field.ErrorList{field.InternalError(path, err)}
+				}
+			}
+		}
+	}
+	if _, err := g.TopologicalSort(); err != nil {
+		return field.ErrorList{field.Invalid(path, "", err.Error())}
+	}
+	return nil
+}
@@ -0,0 +1,391 @@
+package webhook
+
+import (
+	"testing"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/stretchr/testify/assert"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func configMapResource(name smith_v1.ResourceName, data map[string]string, refs ...smith_v1.Reference) smith_v1.Resource {
+	return smith_v1.Resource{
+		Name:       name,
+		References: refs,
+		Spec: smith_v1.ResourceSpec{
+			Object: &core_v1.ConfigMap{
+				TypeMeta: meta_v1.TypeMeta{
+					Kind:       "ConfigMap",
+					APIVersion: core_v1.SchemeGroupVersion.String(),
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name: "map-" + string(name),
+				},
+				Data: data,
+			},
+		},
+	}
+}
+
+func bundleResource(name smith_v1.ResourceName, bundleName string) smith_v1.Resource {
+	return smith_v1.Resource{
+		Name: name,
+		Spec: smith_v1.ResourceSpec{
+			Object: &smith_v1.Bundle{
+				TypeMeta: meta_v1.TypeMeta{
+					Kind:       smith_v1.BundleResourceKind,
+					APIVersion: smith_v1.BundleResourceGroupVersion,
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name: bundleName,
+				},
+			},
+		},
+	}
+}
+
+// withNestedConfigMap adds a ConfigMap resource to the nested Bundle of the resource.
+func withNestedConfigMap(res smith_v1.Resource, data map[string]string) smith_v1.Resource {
+	nested := res.Spec.Object.(*smith_v1.Bundle)
+	nested.Spec.Resources = append(nested.Spec.Resources, configMapResource("nested", data))
+	return res
+}
+
+func inNamespace(namespace string, res smith_v1.Resource) smith_v1.Resource {
+	res.Namespace = namespace
+	return res
+}
+
+func withObjectNamespace(namespace string, res smith_v1.Resource) smith_v1.Resource {
+	res.Spec.Object.(*core_v1.ConfigMap).Namespace = namespace
+	return res
+}
+
+func withGenerateName(generateName string, res smith_v1.Resource) smith_v1.Resource {
+	cm := res.Spec.Object.(*core_v1.ConfigMap)
+	cm.Name = ""
+	cm.GenerateName = generateName
+	return res
+}
+
+func withAdopt(res smith_v1.Resource) smith_v1.Resource {
+	res.Adopt = true
+	return res
+}
+
+func bundleOf(resources ...smith_v1.Resource) *smith_v1.Bundle {
+	return &smith_v1.Bundle{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name: "bundle1",
+		},
+		Spec: smith_v1.BundleSpec{
+			Resources: resources,
+		},
+	}
+}
+
+func withRetryPolicy(policy *smith_v1.RetryPolicy, bundle *smith_v1.Bundle) *smith_v1.Bundle {
+	bundle.Spec.RetryPolicy = policy
+	return bundle
+}
+
+func withParameters(parameters map[string]string, bundle *smith_v1.Bundle) *smith_v1.Bundle {
+	bundle.Spec.Parameters = parameters
+	return bundle
+}
+
+func withSpecFrom(source *smith_v1.SpecSource, res smith_v1.Resource) smith_v1.Resource {
+	res.SpecFrom = source
+	return res
+}
+
+func withIgnoreFields(res smith_v1.Resource, fields ...string) smith_v1.Resource {
+	res.IgnoreFields = fields
+	return res
+}
+
+func externalResource(name smith_v1.ResourceName, external smith_v1.ExternalObject) smith_v1.Resource {
+	return smith_v1.Resource{
+		Name: name,
+		Spec: smith_v1.ResourceSpec{
+			External: &external,
+		},
+	}
+}
+
+func withOutputs(bundle *smith_v1.Bundle, outputs ...smith_v1.Output) *smith_v1.Bundle {
+	bundle.Spec.Outputs = outputs
+	return bundle
+}
+
+func withOutputsExport(kind smith_v1.OutputsExportKind, name string, bundle *smith_v1.Bundle) *smith_v1.Bundle {
+	bundle.Spec.OutputsExport = &smith_v1.OutputsExport{Kind: kind, Name: name}
+	return bundle
+}
+
+func TestValidateBundleValid(t *testing.T) {
+	t.Parallel()
+	bundle := bundleOf(
+		withIgnoreFields(configMapResource("a", nil), "data.x", `metadata.annotations["example.com/revision"]`),
+		configMapResource("b", map[string]string{
+			"x":   "!{aData}",
+			"y":   "!{password}",
+			"z":   "!{a#$.data.y}",
+			"dsn": `!{printf "postgres://app:%s@%s" (.password | trim) .aData}`,
+		}, smith_v1.Reference{
+			Name:     "aData",
+			Resource: "a",
+			Path:     "data.y",
+		}, smith_v1.Reference{
+			Name:   "password",
+			Source: "vault:secret/db#password",
+		}),
+		inNamespace("team-a", withObjectNamespace("team-a", configMapResource("c", nil))),
+	)
+	bundle.Spec.Resources = append(bundle.Spec.Resources,
+		configMapResource("d", map[string]string{"x": "${params.env}-$${params.undeclared}"}),
+		withNestedConfigMap(bundleResource("e", "bundle2"), map[string]string{"x": "!{nestedRef}", "y": "${params.nested}"}),
+		inNamespace("team-a", bundleResource("f", "bundle1")),
+		smith_v1.Resource{
+			Name: "g",
+			SpecFrom: &smith_v1.SpecSource{
+				ConfigMapKeyRef: &smith_v1.KeyReference{Name: "manifests", Key: "g.yaml"},
+			},
+		},
+		externalResource("h", smith_v1.ExternalObject{
+			APIVersion: "example.com/v1",
+			Kind:       "Database",
+			Name:       "db1",
+			ReadyWhen:  `$.status.phase == "Ready"`,
+		}),
+		withGenerateName("map-i-", configMapResource("i", nil)),
+		withAdopt(configMapResource("j", nil)))
+	bundle.Spec.Outputs = []smith_v1.Output{
+		{Name: "x", Resource: "a", Path: "data.x"},
+		{Name: "url", Resource: "e", Path: "status.outputs.url"},
+	}
+	bundle.Spec.OutputsExport = &smith_v1.OutputsExport{Kind: smith_v1.OutputsExportSecret}
+	bundle.Spec.Parameters = map[string]string{"env": "prod"}
+	bundle.Spec.RetryPolicy = &smith_v1.RetryPolicy{
+		BaseDelaySeconds: 5,
+		MaxDelaySeconds:  60,
+		RetryOn:          []smith_v1.RetryClass{smith_v1.RetryClassResourceError},
+	}
+	assert.Empty(t, ValidateBundle(bundle))
+}
+
+func TestValidateBundleInvalid(t *testing.T) {
+	t.Parallel()
+	testcases := map[string]struct {
+		bundle *smith_v1.Bundle
+		field  string
+	}{
+		"duplicate name": {
+			bundle: bundleOf(configMapResource("a", nil), configMapResource("a", nil)),
+			field:  "spec.resources[1].name",
+		},
+		"missing name": {
+			bundle: bundleOf(configMapResource("", nil)),
+			field:  "spec.resources[0].name",
+		},
+		"non-existent resource": {
+			bundle: bundleOf(configMapResource("a", nil, smith_v1.Reference{Resource: "b"})),
+			field:  "spec.resources[0].references[0].resource",
+		},
+		"self reference": {
+			bundle: bundleOf(configMapResource("a", nil, smith_v1.Reference{Resource: "a"})),
+			field:  "spec.resources[0].references[0].resource",
+		},
+		"undeclared reference": {
+			bundle: bundleOf(configMapResource("a", map[string]string{"x": "!{missing}"})),
+			field:  "spec.resources[0].spec.object.data.x",
+		},
+		"inline reference to non-dependency": {
+			bundle: bundleOf(
+				configMapResource("a", nil),
+				configMapResource("b", map[string]string{"x": "!{a#data.y}"}),
+			),
+			field: "spec.resources[1].spec.object.data.x",
+		},
+		"undeclared reference in expression": {
+			bundle: bundleOf(configMapResource("a", map[string]string{"x": "!{.missing | base64}"})),
+			field:  "spec.resources[0].spec.object.data.x",
+		},
+		"invalid reference expression": {
+			bundle: bundleOf(
+				configMapResource("a", nil),
+				configMapResource("b", map[string]string{"x": "!{upper .r}"}, smith_v1.Reference{Name: "r", Resource: "a", Path: "data.x"}),
+			),
+			field: "spec.resources[1].spec.object.data.x",
+		},
+		"invalid path": {
+			bundle: bundleOf(
+				configMapResource("a", nil),
+				configMapResource("b", nil, smith_v1.Reference{Name: "r", Resource: "a", Path: "data["}),
+			),
+			field: "spec.resources[1].references[0].path",
+		},
+		"invalid modifier": {
+			bundle: bundleOf(
+				configMapResource("a", nil),
+				configMapResource("b", nil, smith_v1.Reference{Name: "r", Resource: "a", Path: "data", Modifier: "x"}),
+			),
+			field: "spec.resources[1].references[0].modifier",
+		},
+		"cycle": {
+			bundle: bundleOf(
+				configMapResource("a", nil, smith_v1.Reference{Resource: "b"}),
+				configMapResource("b", nil, smith_v1.Reference{Resource: "a"}),
+			),
+			field: "spec.resources",
+		},
+		"invalid source": {
+			bundle: bundleOf(configMapResource("a", nil, smith_v1.Reference{Name: "r", Source: "vault"})),
+			field:  "spec.resources[0].references[0].source",
+		},
+		"source with resource": {
+			bundle: bundleOf(
+				configMapResource("a", nil),
+				configMapResource("b", nil, smith_v1.Reference{Name: "r", Resource: "a", Source: "vault:secret/db#password"}),
+			),
+			field: "spec.resources[1].references[0].resource",
+		},
+		"nameless source": {
+			bundle: bundleOf(configMapResource("a", nil, smith_v1.Reference{Source: "vault:secret/db#password"})),
+			field:  "spec.resources[0].references[0].name",
+		},
+		"no spec": {
+			bundle: bundleOf(smith_v1.Resource{Name: "a"}),
+			field:  "spec.resources[0].spec",
+		},
+		"spec and specFrom": {
+			bundle: bundleOf(withSpecFrom(&smith_v1.SpecSource{
+				SecretKeyRef: &smith_v1.KeyReference{Name: "manifests", Key: "a"},
+			}, configMapResource("a", nil))),
+			field: "spec.resources[0].specFrom",
+		},
+		"empty specFrom": {
+			bundle: bundleOf(withSpecFrom(&smith_v1.SpecSource{}, smith_v1.Resource{Name: "a"})),
+			field:  "spec.resources[0].specFrom",
+		},
+		"specFrom without key": {
+			bundle: bundleOf(withSpecFrom(&smith_v1.SpecSource{
+				ConfigMapKeyRef: &smith_v1.KeyReference{Name: "manifests"},
+			}, smith_v1.Resource{Name: "a"})),
+			field: "spec.resources[0].specFrom.configMapKeyRef.key",
+		},
+		"object and external": {
+			bundle: bundleOf(smith_v1.Resource{
+				Name: "a",
+				Spec: smith_v1.ResourceSpec{
+					Object:   configMapResource("a", nil).Spec.Object,
+					External: &smith_v1.ExternalObject{APIVersion: "v1", Kind: "ConfigMap", Name: "c1"},
+				},
+			}),
+			field: "spec.resources[0].spec",
+		},
+		"external without name": {
+			bundle: bundleOf(externalResource("a", smith_v1.ExternalObject{APIVersion: "v1", Kind: "ConfigMap"})),
+			field:  "spec.resources[0].spec.external.name",
+		},
+		"external with invalid readyWhen": {
+			bundle: bundleOf(externalResource("a", smith_v1.ExternalObject{
+				APIVersion: "v1",
+				Kind:       "ConfigMap",
+				Name:       "c1",
+				ReadyWhen:  "$.data.ready",
+			})),
+			field: "spec.resources[0].spec.external.readyWhen",
+		},
+		"adopted external": {
+			bundle: bundleOf(withAdopt(externalResource("a", smith_v1.ExternalObject{
+				APIVersion: "v1",
+				Kind:       "ConfigMap",
+				Name:       "c1",
+			}))),
+			field: "spec.resources[0].adopt",
+		},
+		"invalid ignored field": {
+			bundle: bundleOf(withIgnoreFields(configMapResource("a", nil), "data.x", `metadata.annotations["a`)),
+			field:  "spec.resources[0].ignoreFields[1]",
+		},
+		"ignored name": {
+			bundle: bundleOf(withIgnoreFields(configMapResource("a", nil), "metadata.name")),
+			field:  "spec.resources[0].ignoreFields[0]",
+		},
+		"no object name": {
+			bundle: bundleOf(withGenerateName("", configMapResource("a", nil))),
+			field:  "spec.resources[0].spec.object.metadata.name",
+		},
+		"invalid namespace": {
+			bundle: bundleOf(inNamespace("Team_A", configMapResource("a", nil))),
+			field:  "spec.resources[0].namespace",
+		},
+		"object in another namespace": {
+			bundle: bundleOf(inNamespace("team-a", withObjectNamespace("team-b", configMapResource("a", nil)))),
+			field:  "spec.resources[0].spec.object.metadata.namespace",
+		},
+		"retry max delay less than base delay": {
+			bundle: withRetryPolicy(&smith_v1.RetryPolicy{BaseDelaySeconds: 10, MaxDelaySeconds: 5}, bundleOf(configMapResource("a", nil))),
+			field:  "spec.retryPolicy.maxDelaySeconds",
+		},
+		"undeclared parameter": {
+			bundle: bundleOf(configMapResource("a", map[string]string{"x": "${params.env}"})),
+			field:  "spec.resources[0].spec.object.data.x",
+		},
+		"invalid parameter name": {
+			bundle: withParameters(map[string]string{"1env": "prod"}, bundleOf(configMapResource("a", nil))),
+			field:  "spec.parameters[1env]",
+		},
+		"includes itself": {
+			bundle: bundleOf(bundleResource("a", "bundle1")),
+			field:  "spec.resources[0].spec.object",
+		},
+		"duplicate output name": {
+			bundle: withOutputs(bundleOf(configMapResource("a", nil)),
+				smith_v1.Output{Name: "x", Resource: "a", Path: "data.x"},
+				smith_v1.Output{Name: "x", Resource: "a", Path: "data.y"}),
+			field: "spec.outputs[1].name",
+		},
+		"output of non-existent resource": {
+			bundle: withOutputs(bundleOf(configMapResource("a", nil)), smith_v1.Output{Name: "x", Resource: "b", Path: "data.x"}),
+			field:  "spec.outputs[0].resource",
+		},
+		"invalid output path": {
+			bundle: withOutputs(bundleOf(configMapResource("a", nil)), smith_v1.Output{Name: "x", Resource: "a", Path: "data["}),
+			field:  "spec.outputs[0].path",
+		},
+		"export to unsupported kind": {
+			bundle: withOutputsExport("Service", "", bundleOf(configMapResource("a", nil))),
+			field:  "spec.outputsExport.kind",
+		},
+		"invalid export name": {
+			bundle: withOutputsExport(smith_v1.OutputsExportConfigMap, "Outputs!", bundleOf(configMapResource("a", nil))),
+			field:  "spec.outputsExport.name",
+		},
+		"exported output name is not a valid key": {
+			bundle: withOutputsExport(smith_v1.OutputsExportSecret, "",
+				withOutputs(bundleOf(configMapResource("a", nil)), smith_v1.Output{Name: "x/y", Resource: "a", Path: "data.x"})),
+			field: "spec.outputs[0].name",
+		},
+		"export to object of resource": {
+			bundle: withOutputsExport(smith_v1.OutputsExportConfigMap, "map-a", bundleOf(configMapResource("a", nil))),
+			field:  "spec.outputsExport",
+		},
+		"unsupported retry class": {
+			bundle: withRetryPolicy(&smith_v1.RetryPolicy{RetryOn: []smith_v1.RetryClass{"Timeout"}}, bundleOf(configMapResource("a", nil))),
+			field:  "spec.retryPolicy.retryOn[0]",
+		},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			errs := ValidateBundle(tc.bundle)
+			if assert.Len(t, errs, 1) {
+				assert.Equal(t, tc.field, errs[0].Field)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package webhook
+
+import (
+	"fmt"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/atlassian/smith/pkg/util"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	// deprecatedAPIVersions maps kinds of deprecated API versions to the API versions that replace them.
+	deprecatedAPIVersions = map[schema.GroupVersionKind]string{
+		{Group: "extensions", Version: "v1beta1", Kind: "DaemonSet"}:     "apps/v1",
+		{Group: "extensions", Version: "v1beta1", Kind: "Deployment"}:    "apps/v1",
+		{Group: "extensions", Version: "v1beta1", Kind: "ReplicaSet"}:    "apps/v1",
+		{Group: "extensions", Version: "v1beta1", Kind: "NetworkPolicy"}: "networking.k8s.io/v1",
+		{Group: "apps", Version: "v1beta1", Kind: "Deployment"}:          "apps/v1",
+		{Group: "apps", Version: "v1beta1", Kind: "StatefulSet"}:         "apps/v1",
+		{Group: "apps", Version: "v1beta2", Kind: "DaemonSet"}:           "apps/v1",
+		{Group: "apps", Version: "v1beta2", Kind: "Deployment"}:          "apps/v1",
+		{Group: "apps", Version: "v1beta2", Kind: "ReplicaSet"}:          "apps/v1",
+		{Group: "apps", Version: "v1beta2", Kind: "StatefulSet"}:         "apps/v1",
+	}
+)
+
+// ReadinessRules tells if readiness of an object can be determined.
+type ReadinessRules interface {
+	HasReadinessRule(*unstructured.Unstructured) (bool, error)
+}
+
+// WarnBundle checks the spec of a valid Bundle for issues that do not prevent processing:
+// - objects of deprecated API versions;
+// - nameless references (dependsOn entries) to resources that are already dependencies of the resource;
+// - objects of kinds without a readiness rule. Only checked if rules is not nil.
+// Objects produced by plugins are not checked.
+func WarnBundle(bundle *smith_v1.Bundle, rules ReadinessRules) ([]smith_v1.BundleWarning, error) {
+	var warnings []smith_v1.BundleWarning
+	for _, res := range bundle.Spec.Resources {
+		warnings = append(warnings, unusedDependencies(res)...)
+		if res.Spec.Object == nil {
+			continue
+		}
+		obj, err := util.RuntimeToUnstructured(res.Spec.Object)
+		if err != nil {
+			return nil, errors.Wrapf(err, "resource %q", res.Name)
+		}
+		gvk := obj.GroupVersionKind()
+		if replacement, ok := deprecatedAPIVersions[gvk]; ok {
+			warnings = append(warnings, smith_v1.BundleWarning{
+				Resource: res.Name,
+				Reason:   smith_v1.WarningReasonDeprecatedAPIVersion,
+				Message:  fmt.Sprintf("%s of %s is deprecated, use %s instead", gvk.Kind, gvk.GroupVersion(), replacement),
+			})
+		}
+		if rules == nil {
+			continue
+		}
+		hasRule, err := rules.HasReadinessRule(obj)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to check readiness rule of resource %q", res.Name)
+		}
+		if !hasRule {
+			warnings = append(warnings, smith_v1.BundleWarning{
+				Resource: res.Name,
+				Reason:   smith_v1.WarningReasonNoReadinessRule,
+				Message:  fmt.Sprintf("there is no readiness rule for %s, the object will never be ready", gvk.GroupKind()),
+			})
+		}
+	}
+	return warnings, nil
+}
+
+// unusedDependencies returns warnings for nameless references to resources that are dependencies
+// of the resource because of a named reference, a quorum or an earlier nameless reference.
+func unusedDependencies(res smith_v1.Resource) []smith_v1.BundleWarning {
+	implied := make(map[smith_v1.ResourceName]string, len(res.References))
+	for _, ref := range res.References {
+		if ref.Name != "" && !ref.IsExternal() {
+			implied[ref.Resource] = fmt.Sprintf("reference %q", ref.Name)
+		}
+	}
+	for _, quorum := range res.Quorums {
+		for _, member := range quorum.Resources {
+			if _, ok := implied[member]; !ok {
+				implied[member] = "a quorum"
+			}
+		}
+	}
+	var warnings []smith_v1.BundleWarning
+	for _, ref := range res.References {
+		if ref.Name != "" || ref.IsExternal() {
+			continue
+		}
+		if by, ok := implied[ref.Resource]; ok {
+			warnings = append(warnings, smith_v1.BundleWarning{
+				Resource: res.Name,
+				Reason:   smith_v1.WarningReasonUnusedDependency,
+				Message:  fmt.Sprintf("dependency on %q is unused, it is already declared by %s", ref.Resource, by),
+			})
+			continue
+		}
+		implied[ref.Resource] = "another dependsOn entry"
+	}
+	return warnings
+}
@@ -0,0 +1,108 @@
+package webhook
+
+import (
+	"testing"
+
+	smith_v1 "github.com/atlassian/smith/pkg/apis/smith/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apps_v1b1 "k8s.io/api/apps/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+type kindRules map[string]bool
+
+func (r kindRules) HasReadinessRule(obj *unstructured.Unstructured) (bool, error) {
+	return r[obj.GetKind()], nil
+}
+
+func TestWarnBundleNoWarnings(t *testing.T) {
+	t.Parallel()
+	bundle := bundleOf(
+		configMapResource("a", nil),
+		configMapResource("b", map[string]string{"x": "!{aData}"}, smith_v1.Reference{
+			Name:     "aData",
+			Resource: "a",
+			Path:     "data.y",
+		}),
+		configMapResource("c", nil, smith_v1.Reference{Resource: "a"}),
+	)
+	warnings, err := WarnBundle(bundle, kindRules{"ConfigMap": true})
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+}
+
+func TestWarnBundleUnusedDependencies(t *testing.T) {
+	t.Parallel()
+	c := configMapResource("c", map[string]string{"x": "!{aData}"},
+		smith_v1.Reference{Name: "aData", Resource: "a", Path: "data.y"},
+		smith_v1.Reference{Resource: "a"},
+		smith_v1.Reference{Resource: "b"},
+		smith_v1.Reference{Resource: "b"},
+	)
+	d := configMapResource("d", nil, smith_v1.Reference{Resource: "a"})
+	d.Quorums = []smith_v1.Quorum{{Resources: []smith_v1.ResourceName{"a", "b"}, MinReady: 1}}
+	bundle := bundleOf(configMapResource("a", nil), configMapResource("b", nil), c, d)
+
+	warnings, err := WarnBundle(bundle, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []smith_v1.BundleWarning{
+		{
+			Resource: "c",
+			Reason:   smith_v1.WarningReasonUnusedDependency,
+			Message:  `dependency on "a" is unused, it is already declared by reference "aData"`,
+		},
+		{
+			Resource: "c",
+			Reason:   smith_v1.WarningReasonUnusedDependency,
+			Message:  `dependency on "b" is unused, it is already declared by another dependsOn entry`,
+		},
+		{
+			Resource: "d",
+			Reason:   smith_v1.WarningReasonUnusedDependency,
+			Message:  `dependency on "a" is unused, it is already declared by a quorum`,
+		},
+	}, warnings)
+}
+
+func TestWarnBundleDeprecatedAPIVersion(t *testing.T) {
+	t.Parallel()
+	bundle := bundleOf(smith_v1.Resource{
+		Name: "deployment",
+		Spec: smith_v1.ResourceSpec{
+			Object: &apps_v1b1.Deployment{
+				TypeMeta: meta_v1.TypeMeta{
+					Kind:       "Deployment",
+					APIVersion: apps_v1b1.SchemeGroupVersion.String(),
+				},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name: "deployment1",
+				},
+			},
+		},
+	})
+	warnings, err := WarnBundle(bundle, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []smith_v1.BundleWarning{
+		{
+			Resource: "deployment",
+			Reason:   smith_v1.WarningReasonDeprecatedAPIVersion,
+			Message:  "Deployment of apps/v1beta1 is deprecated, use apps/v1 instead",
+		},
+	}, warnings)
+}
+
+func TestWarnBundleNoReadinessRule(t *testing.T) {
+	t.Parallel()
+	bundle := bundleOf(configMapResource("a", nil))
+	warnings, err := WarnBundle(bundle, kindRules{})
+	require.NoError(t, err)
+	assert.Equal(t, []smith_v1.BundleWarning{
+		{
+			Resource: "a",
+			Reason:   smith_v1.WarningReasonNoReadinessRule,
+			Message:  "there is no readiness rule for ConfigMap, the object will never be ready",
+		},
+	}, warnings)
+}